@@ -0,0 +1,289 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/fluxcd/pkg/ssa"
+
+	"github.com/fluxcd/flux2/internal/lint"
+	"github.com/fluxcd/flux2/internal/utils"
+)
+
+var applyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Create or update Flux objects declared in a file",
+	Long: `The apply command creates or updates the Flux sources, Kustomizations and HelmReleases
+declared in a file, with defaults for the namespace, interval and labels of the objects.
+All objects are validated with a server-side dry-run before any of them is applied, and the
+changes are rolled back if one of the objects fails to apply.
+
+The file has the following format:
+
+  defaults:
+    namespace: flux-system
+    interval: 5m
+    labels:
+      team: platform
+  objects:
+    - apiVersion: source.toolkit.fluxcd.io/v1beta2
+      kind: GitRepository
+      metadata:
+        name: podinfo
+      spec:
+        url: https://github.com/stefanprodan/podinfo
+        ref:
+          branch: master`,
+	Example: `  # Create or update the objects declared in a file
+  flux apply -f fluxops.yaml
+
+  # Apply the objects and wait for them to become ready
+  flux apply -f fluxops.yaml --wait
+
+  # Apply the objects read from stdin
+  cat fluxops.yaml | flux apply -f -`,
+	RunE: applyCmdRun,
+}
+
+type applyFlags struct {
+	file string
+	wait bool
+}
+
+var applyArgs applyFlags
+
+func init() {
+	applyCmd.Flags().StringVarP(&applyArgs.file, "file", "f", "", "path to the file declaring the objects, '-' reads from stdin")
+	applyCmd.Flags().BoolVar(&applyArgs.wait, "wait", false, "wait for the applied objects to become ready")
+
+	rootCmd.AddCommand(applyCmd)
+}
+
+// fluxOpsFile declares a set of Flux objects and the defaults applied to them.
+type fluxOpsFile struct {
+	Defaults fluxOpsDefaults          `json:"defaults,omitempty"`
+	Objects  []map[string]interface{} `json:"objects"`
+}
+
+// fluxOpsDefaults are set on the objects which don't specify them.
+type fluxOpsDefaults struct {
+	Namespace string            `json:"namespace,omitempty"`
+	Interval  string            `json:"interval,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+func applyCmdRun(cmd *cobra.Command, args []string) error {
+	if applyArgs.file == "" {
+		return fmt.Errorf("--file is required")
+	}
+
+	var data []byte
+	var err error
+	if applyArgs.file == "-" {
+		data, err = io.ReadAll(cmd.InOrStdin())
+	} else {
+		data, err = os.ReadFile(applyArgs.file)
+	}
+	if err != nil {
+		return err
+	}
+
+	objects, err := fluxOpsObjects(data, *kubeconfigArgs.Namespace)
+	if err != nil {
+		return err
+	}
+	for _, obj := range objects {
+		findings, err := lint.Lint(obj, lint.Options{})
+		if err != nil {
+			return err
+		}
+		for _, f := range findings {
+			logger.Warningf("%s: %s", ssa.FmtUnstructured(obj), f.Message)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	defer cancel()
+
+	manager, err := utils.NewResourceManager(kubeconfigArgs, kubeclientOptions)
+	if err != nil {
+		return err
+	}
+
+	snapshot, err := snapshotObjects(ctx, manager.Client(), objects)
+	if err != nil {
+		return err
+	}
+
+	logger.Actionf("applying %d object(s)", len(objects))
+	changeSet, err := manager.ApplyAll(ctx, objects, ssa.DefaultApplyOptions())
+	if err != nil {
+		logger.Failuref("apply failed: %s", err.Error())
+		logger.Actionf("rolling back changes")
+		if rbErr := rollbackObjects(ctx, manager.Client(), objects, snapshot); rbErr != nil {
+			return fmt.Errorf("%w, rollback failed: %s", err, rbErr.Error())
+		}
+		logger.Successf("changes rolled back")
+		return err
+	}
+	for _, entry := range changeSet.Entries {
+		logger.Successf(entry.String())
+	}
+
+	if applyArgs.wait {
+		logger.Waitingf("waiting for %d object(s) to become ready", len(objects))
+		if err := manager.WaitForSet(changeSet.ToObjMetadataSet(), ssa.WaitOptions{
+			Interval: rootArgs.pollInterval,
+			Timeout:  rootArgs.timeout,
+		}); err != nil {
+			return err
+		}
+		logger.Successf("all objects are ready")
+	}
+	return nil
+}
+
+// fluxOpsObjects parses the file and returns its objects, with the defaults
+// set on them. The namespace falls back to the given one when neither the
+// object nor the defaults specify it.
+func fluxOpsObjects(data []byte, namespace string) ([]*unstructured.Unstructured, error) {
+	var file fluxOpsFile
+	if err := yaml.UnmarshalStrict(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid file: %w", err)
+	}
+	if len(file.Objects) == 0 {
+		return nil, fmt.Errorf("no objects found in file")
+	}
+
+	defaults := file.Defaults
+	if defaults.Namespace == "" {
+		defaults.Namespace = namespace
+	}
+	if defaults.Interval != "" {
+		if _, err := time.ParseDuration(defaults.Interval); err != nil {
+			return nil, fmt.Errorf("invalid default interval '%s': %w", defaults.Interval, err)
+		}
+	}
+
+	objects := make([]*unstructured.Unstructured, 0, len(file.Objects))
+	for i, content := range file.Objects {
+		obj := &unstructured.Unstructured{Object: content}
+		if obj.GetName() == "" || obj.GetKind() == "" {
+			return nil, fmt.Errorf("object %d: kind and metadata.name are required", i)
+		}
+		if !strings.HasSuffix(obj.GroupVersionKind().Group, ".toolkit.fluxcd.io") {
+			return nil, fmt.Errorf("%s: only Flux objects are supported", ssa.FmtUnstructured(obj))
+		}
+
+		if obj.GetNamespace() == "" {
+			obj.SetNamespace(defaults.Namespace)
+		}
+		if defaults.Interval != "" {
+			if _, ok, _ := unstructured.NestedFieldNoCopy(obj.Object, "spec", "interval"); !ok {
+				if err := unstructured.SetNestedField(obj.Object, defaults.Interval, "spec", "interval"); err != nil {
+					return nil, fmt.Errorf("%s: %w", ssa.FmtUnstructured(obj), err)
+				}
+			}
+		}
+		if len(defaults.Labels) > 0 {
+			labels := make(map[string]string, len(defaults.Labels))
+			for k, v := range defaults.Labels {
+				labels[k] = v
+			}
+			for k, v := range obj.GetLabels() {
+				labels[k] = v
+			}
+			obj.SetLabels(labels)
+		}
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
+
+// snapshotObjects returns the current state of the objects on the cluster,
+// the objects which don't exist yet are omitted.
+func snapshotObjects(ctx context.Context, kubeClient client.Client, objects []*unstructured.Unstructured) (map[string]*unstructured.Unstructured, error) {
+	snapshot := make(map[string]*unstructured.Unstructured, len(objects))
+	for _, obj := range objects {
+		existing := &unstructured.Unstructured{}
+		existing.SetGroupVersionKind(obj.GroupVersionKind())
+		if err := kubeClient.Get(ctx, client.ObjectKeyFromObject(obj), existing); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, fmt.Errorf("%s: %w", ssa.FmtUnstructured(obj), err)
+		}
+		snapshot[ssa.FmtUnstructured(obj)] = existing
+	}
+	return snapshot, nil
+}
+
+// rollbackObjects restores the objects to their snapshot, by deleting the
+// objects created by the apply and restoring the labels, annotations and
+// spec of the objects it changed.
+func rollbackObjects(ctx context.Context, kubeClient client.Client, objects []*unstructured.Unstructured,
+	snapshot map[string]*unstructured.Unstructured) error {
+	var failed []string
+	for _, obj := range objects {
+		current := &unstructured.Unstructured{}
+		current.SetGroupVersionKind(obj.GroupVersionKind())
+		if err := kubeClient.Get(ctx, client.ObjectKeyFromObject(obj), current); err != nil {
+			if !apierrors.IsNotFound(err) {
+				failed = append(failed, fmt.Sprintf("%s: %s", ssa.FmtUnstructured(obj), err.Error()))
+			}
+			continue
+		}
+
+		previous, existed := snapshot[ssa.FmtUnstructured(obj)]
+		if !existed {
+			if err := kubeClient.Delete(ctx, current); err != nil && !apierrors.IsNotFound(err) {
+				failed = append(failed, fmt.Sprintf("%s: %s", ssa.FmtUnstructured(obj), err.Error()))
+			}
+			continue
+		}
+		if current.GetResourceVersion() == previous.GetResourceVersion() {
+			continue
+		}
+
+		current.SetLabels(previous.GetLabels())
+		current.SetAnnotations(previous.GetAnnotations())
+		if spec, ok := previous.Object["spec"]; ok {
+			current.Object["spec"] = spec
+		} else {
+			delete(current.Object, "spec")
+		}
+		if err := kubeClient.Update(ctx, current); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %s", ssa.FmtUnstructured(obj), err.Error()))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%s", strings.Join(failed, "; "))
+	}
+	return nil
+}
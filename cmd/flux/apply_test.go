@@ -0,0 +1,159 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+
+	"github.com/fluxcd/flux2/internal/utils"
+)
+
+func TestFluxOpsObjects(t *testing.T) {
+	data := []byte(`
+defaults:
+  interval: 5m
+  labels:
+    team: platform
+    tier: backend
+objects:
+  - apiVersion: source.toolkit.fluxcd.io/v1beta2
+    kind: GitRepository
+    metadata:
+      name: podinfo
+      labels:
+        tier: frontend
+    spec:
+      url: https://github.com/stefanprodan/podinfo
+  - apiVersion: kustomize.toolkit.fluxcd.io/v1beta2
+    kind: Kustomization
+    metadata:
+      name: podinfo
+      namespace: apps
+    spec:
+      interval: 10m
+      sourceRef:
+        kind: GitRepository
+        name: podinfo
+`)
+	objects, err := fluxOpsObjects(data, "flux-system")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 2 {
+		t.Fatalf("expected 2 objects, got %d", len(objects))
+	}
+
+	repo, ks := objects[0], objects[1]
+	if repo.GetNamespace() != "flux-system" || ks.GetNamespace() != "apps" {
+		t.Errorf("unexpected namespaces %q and %q", repo.GetNamespace(), ks.GetNamespace())
+	}
+	if want := map[string]string{"team": "platform", "tier": "frontend"}; !reflect.DeepEqual(repo.GetLabels(), want) {
+		t.Errorf("unexpected labels %v, want %v", repo.GetLabels(), want)
+	}
+	for obj, want := range map[*unstructured.Unstructured]string{repo: "5m", ks: "10m"} {
+		if interval, _, _ := unstructured.NestedString(obj.Object, "spec", "interval"); interval != want {
+			t.Errorf("%s: unexpected interval %q, want %q", obj.GetKind(), interval, want)
+		}
+	}
+}
+
+func TestFluxOpsObjectsErrors(t *testing.T) {
+	tests := map[string]string{
+		"no objects":       "defaults:\n  namespace: apps\n",
+		"unknown field":    "objects:\n  - kind: GitRepository\nextra: true\n",
+		"invalid interval": "defaults:\n  interval: often\nobjects:\n  - apiVersion: source.toolkit.fluxcd.io/v1beta2\n    kind: GitRepository\n    metadata:\n      name: podinfo\n",
+		"missing name":     "objects:\n  - apiVersion: source.toolkit.fluxcd.io/v1beta2\n    kind: GitRepository\n",
+		"not flux":         "objects:\n  - apiVersion: v1\n    kind: ConfigMap\n    metadata:\n      name: podinfo\n",
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := fluxOpsObjects([]byte(data), "flux-system"); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestRollbackObjects(t *testing.T) {
+	ctx := context.TODO()
+	existing := &sourcev1.GitRepository{
+		ObjectMeta: metav1.ObjectMeta{Name: "existing", Namespace: "flux-system"},
+		Spec:       sourcev1.GitRepositorySpec{URL: "https://example.com/before"},
+	}
+	kubeClient := fake.NewClientBuilder().WithScheme(utils.NewScheme()).WithObjects(existing).Build()
+
+	newObject := func(name, url string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{
+			"spec": map[string]interface{}{"url": url, "interval": "1m"},
+		}}
+		obj.SetGroupVersionKind(sourcev1.GroupVersion.WithKind(sourcev1.GitRepositoryKind))
+		obj.SetName(name)
+		obj.SetNamespace("flux-system")
+		return obj
+	}
+	objects := []*unstructured.Unstructured{
+		newObject("existing", "https://example.com/after"),
+		newObject("created", "https://example.com/new"),
+	}
+
+	snapshot, err := snapshotObjects(ctx, kubeClient, objects)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshot) != 1 {
+		t.Fatalf("expected 1 object in snapshot, got %d", len(snapshot))
+	}
+
+	// Simulate a partial apply
+	var changed sourcev1.GitRepository
+	if err := kubeClient.Get(ctx, client.ObjectKeyFromObject(existing), &changed); err != nil {
+		t.Fatal(err)
+	}
+	changed.Spec.URL = "https://example.com/after"
+	if err := kubeClient.Update(ctx, &changed); err != nil {
+		t.Fatal(err)
+	}
+	if err := kubeClient.Create(ctx, objects[1].DeepCopy()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := rollbackObjects(ctx, kubeClient, objects, snapshot); err != nil {
+		t.Fatal(err)
+	}
+
+	var restored sourcev1.GitRepository
+	if err := kubeClient.Get(ctx, client.ObjectKeyFromObject(existing), &restored); err != nil {
+		t.Fatal(err)
+	}
+	if restored.Spec.URL != "https://example.com/before" {
+		t.Errorf("expected URL to be restored, got %s", restored.Spec.URL)
+	}
+	err = kubeClient.Get(ctx, client.ObjectKey{Namespace: "flux-system", Name: "created"}, &sourcev1.GitRepository{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("expected created object to be deleted, got %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/user"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const auditEventComponent = "flux-cli"
+
+// recordAuditEvent emits a Kubernetes Event on the given object to record
+// the action performed with the CLI and the user performing it, when
+// --audit-events is set. Failing to record the event is not fatal, as the
+// user may not be allowed to create events.
+func recordAuditEvent(ctx context.Context, kubeClient client.Client, gvk schema.GroupVersionKind,
+	obj client.Object, reason, action string) {
+	if !rootArgs.auditEvents {
+		return
+	}
+
+	// Events for cluster scoped objects are stored in the default namespace
+	namespace := obj.GetNamespace()
+	if namespace == "" {
+		namespace = metav1.NamespaceDefault
+	}
+
+	now := metav1.Now()
+	event := &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: obj.GetName() + ".",
+			Namespace:    namespace,
+		},
+		InvolvedObject: corev1.ObjectReference{
+			APIVersion:      gvk.GroupVersion().String(),
+			Kind:            gvk.Kind,
+			Name:            obj.GetName(),
+			Namespace:       obj.GetNamespace(),
+			UID:             obj.GetUID(),
+			ResourceVersion: obj.GetResourceVersion(),
+		},
+		Reason:         reason,
+		Message:        fmt.Sprintf("%s by %s using flux %s", action, auditUser(), rootArgs.defaults.Version),
+		Type:           corev1.EventTypeNormal,
+		Source:         corev1.EventSource{Component: auditEventComponent},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+	if err := kubeClient.Create(ctx, event); err != nil && rootArgs.verbose {
+		logger.Warningf("failed to record event for %s %s: %s", gvk.Kind, obj.GetName(), err.Error())
+	}
+}
+
+// recordNamespaceAuditEvent emits an audit Event on the Namespace with the
+// given name, see recordAuditEvent.
+func recordNamespaceAuditEvent(ctx context.Context, kubeClient client.Client, name, reason, action string) {
+	if !rootArgs.auditEvents {
+		return
+	}
+	namespace := &corev1.Namespace{}
+	if err := kubeClient.Get(ctx, client.ObjectKey{Name: name}, namespace); err != nil {
+		namespace.SetName(name)
+	}
+	recordAuditEvent(ctx, kubeClient, corev1.SchemeGroupVersion.WithKind("Namespace"), namespace, reason, action)
+}
+
+// auditUser returns the name of the user performing the CLI action, based on
+// the impersonation flag, the kubeconfig user of the current context or the
+// OS user.
+func auditUser() string {
+	if kubeconfigArgs.Impersonate != nil && *kubeconfigArgs.Impersonate != "" {
+		return *kubeconfigArgs.Impersonate
+	}
+	if rawConfig, err := kubeconfigArgs.ToRawKubeConfigLoader().RawConfig(); err == nil {
+		contextName := rawConfig.CurrentContext
+		if kubeconfigArgs.Context != nil && *kubeconfigArgs.Context != "" {
+			contextName = *kubeconfigArgs.Context
+		}
+		if c, ok := rawConfig.Contexts[contextName]; ok && c.AuthInfo != "" {
+			return c.AuthInfo
+		}
+	}
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return "unknown"
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+
+	"github.com/fluxcd/flux2/internal/utils"
+)
+
+func listAuditEvents(t *testing.T, kubeClient client.Client) []corev1.Event {
+	t.Helper()
+	var events corev1.EventList
+	if err := kubeClient.List(context.TODO(), &events); err != nil {
+		t.Fatal(err)
+	}
+	return events.Items
+}
+
+func TestRecordAuditEvent(t *testing.T) {
+	impersonate := "jane"
+	kubeconfigArgs.Impersonate = &impersonate
+	defer func() {
+		kubeconfigArgs.Impersonate = nil
+		rootArgs.auditEvents = false
+	}()
+
+	ks := &kustomizev1.Kustomization{
+		ObjectMeta: metav1.ObjectMeta{Name: "apps", Namespace: "flux-system", UID: "8a9f0c2e"},
+	}
+	gvk := kustomizev1.GroupVersion.WithKind(kustomizev1.KustomizationKind)
+
+	kubeClient := fake.NewClientBuilder().WithScheme(utils.NewScheme()).Build()
+	recordAuditEvent(context.TODO(), kubeClient, gvk, ks, "Suspended", "Kustomization suspended")
+	if events := listAuditEvents(t, kubeClient); len(events) != 0 {
+		t.Fatalf("expected no events without --audit-events, got %d", len(events))
+	}
+
+	rootArgs.auditEvents = true
+	recordAuditEvent(context.TODO(), kubeClient, gvk, ks, "Suspended", "Kustomization suspended")
+	events := listAuditEvents(t, kubeClient)
+	if len(events) != 1 {
+		t.Fatalf("expected one event, got %d", len(events))
+	}
+	event := events[0]
+	if event.Namespace != "flux-system" || !strings.HasPrefix(event.Name, "apps.") {
+		t.Errorf("unexpected event %s/%s", event.Namespace, event.Name)
+	}
+	want := corev1.ObjectReference{
+		APIVersion: kustomizev1.GroupVersion.String(),
+		Kind:       kustomizev1.KustomizationKind,
+		Name:       "apps",
+		Namespace:  "flux-system",
+		UID:        "8a9f0c2e",
+	}
+	if event.InvolvedObject != want {
+		t.Errorf("got involved object %+v, want %+v", event.InvolvedObject, want)
+	}
+	if event.Reason != "Suspended" || event.Type != corev1.EventTypeNormal || event.Source.Component != auditEventComponent {
+		t.Errorf("unexpected reason %q, type %q or source %q", event.Reason, event.Type, event.Source.Component)
+	}
+	if !strings.HasPrefix(event.Message, "Kustomization suspended by jane using flux ") {
+		t.Errorf("unexpected message %q", event.Message)
+	}
+}
+
+func TestRecordNamespaceAuditEvent(t *testing.T) {
+	rootArgs.auditEvents = true
+	defer func() { rootArgs.auditEvents = false }()
+
+	kubeClient := fake.NewClientBuilder().WithScheme(utils.NewScheme()).WithObjects(&corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "flux-system", UID: "d41d8cd9"},
+	}).Build()
+	recordNamespaceAuditEvent(context.TODO(), kubeClient, "flux-system", "Paused", "Flux paused")
+
+	events := listAuditEvents(t, kubeClient)
+	if len(events) != 1 {
+		t.Fatalf("expected one event, got %d", len(events))
+	}
+	// Events of cluster scoped objects are stored in the default namespace
+	if events[0].Namespace != metav1.NamespaceDefault {
+		t.Errorf("got event namespace %q, want %q", events[0].Namespace, metav1.NamespaceDefault)
+	}
+	if ref := events[0].InvolvedObject; ref.Kind != "Namespace" || ref.Name != "flux-system" || ref.UID != "d41d8cd9" {
+		t.Errorf("unexpected involved object %+v", ref)
+	}
+}
+
+func TestSuspendAuditEvent(t *testing.T) {
+	isolateEnv(t)
+	kubeClient := useFakeCluster(t, readObjectFile(t, "testdata/fake/objects.yaml")...)
+
+	cmd := cmdTestCase{
+		args:   "suspend kustomization --all -n flux-system --audit-events",
+		assert: assertSuccess(),
+	}
+	cmd.runTestCmd(t)
+
+	suspended := map[string]bool{}
+	for _, event := range listAuditEvents(t, kubeClient) {
+		if event.Reason == "Suspended" && event.InvolvedObject.Kind == kustomizev1.KustomizationKind {
+			suspended[event.InvolvedObject.Name] = true
+		}
+	}
+	if len(suspended) != 2 || !suspended["apps"] || !suspended["infra"] {
+		t.Errorf("expected Suspended events on apps and infra, got %v", suspended)
+	}
+}
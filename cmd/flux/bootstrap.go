@@ -17,22 +17,36 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"crypto/elliptic"
+	"crypto/x509"
 	"fmt"
+	"net/url"
+	"os"
 	"strings"
+	"time"
 
+	gitconfig "github.com/fluxcd/go-git/v5/config"
 	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/kustomize/api/konfig"
+
+	"github.com/fluxcd/pkg/git"
+	"github.com/fluxcd/pkg/git/gogit"
+	"github.com/fluxcd/pkg/git/repository"
 
 	"github.com/fluxcd/flux2/internal/flags"
 	"github.com/fluxcd/flux2/internal/utils"
+	"github.com/fluxcd/flux2/pkg/bootstrap"
 	"github.com/fluxcd/flux2/pkg/manifestgen"
 	"github.com/fluxcd/flux2/pkg/manifestgen/sourcesecret"
+	"github.com/fluxcd/flux2/pkg/manifestgen/sync"
 )
 
 var bootstrapCmd = &cobra.Command{
 	Use:   "bootstrap",
 	Short: "Deploy Flux on a cluster the GitOps way.",
-	Long: `The bootstrap sub-commands push the Flux manifests to a Git repository
+	Long: `The bootstrap sub-commands push the Flux manifests to a Git repository or an OCI artifact
 and deploy Flux on the cluster.`,
 }
 
@@ -43,6 +57,9 @@ type bootstrapFlags struct {
 	branch            string
 	recurseSubmodules bool
 	manifestsPath     string
+	manifestsArtifact string
+	componentsFile    string
+	syncFile          string
 
 	defaultComponents  []string
 	extraComponents    []string
@@ -51,19 +68,30 @@ type bootstrapFlags struct {
 	registry        string
 	imagePullSecret string
 
-	secretName     string
-	tokenAuth      bool
-	keyAlgorithm   flags.PublicKeyAlgorithm
-	keyRSABits     flags.RSAKeyBits
-	keyECDSACurve  flags.ECDSACurve
-	sshHostname    string
-	caFile         string
-	privateKeyFile string
+	secretName        string
+	secretRefExisting bool
+	existingSecret    string
+	tokenAuth         bool
+	keyAlgorithm      flags.PublicKeyAlgorithm
+	keyRSABits        flags.RSAKeyBits
+	keyECDSACurve     flags.ECDSACurve
+	sshHostname       string
+	proxy             string
+	caFile            string
+	privateKeyFile    string
 
 	watchAllNamespaces bool
 	networkPolicy      bool
+	networkPolicyCIDRs []string
 	clusterDomain      string
 	tolerationKeys     []string
+	nodeSelector       map[string]string
+	affinityFile       string
+	affinity           *corev1.Affinity
+	priorityClass      string
+	podSecurity        map[string]string
+	seccompProfileName string
+	seccompProfile     *corev1.SeccompProfile
 
 	authorName  string
 	authorEmail string
@@ -72,7 +100,24 @@ type bootstrapFlags struct {
 	gpgPassphrase  string
 	gpgKeyID       string
 
+	sshSigningKeyFile     string
+	sshSigningKeyPassword string
+
 	commitMessageAppendix string
+	signoff               bool
+	shallowClone          bool
+
+	withSOPSAge   bool
+	sopsAgeSecret string
+
+	contexts []string
+
+	postBootstrapCommands  []string
+	postBootstrapManifests []string
+	postBootstrapConfig    string
+	postBootstrapTimeout   time.Duration
+
+	summaryFile string
 }
 
 const (
@@ -100,34 +145,87 @@ func init() {
 		"when enabled, configures the GitRepository source to initialize and include Git submodules in the artifact it produces")
 
 	bootstrapCmd.PersistentFlags().StringVar(&bootstrapArgs.manifestsPath, "manifests", "", "path to the manifest directory")
+	bootstrapCmd.PersistentFlags().StringVar(&bootstrapArgs.manifestsArtifact, "manifests-artifact", "",
+		"OCI artifact (oci://<registry>/<repository>:<tag>) or local tarball containing the manifests of the Flux components, for installing from a mirror in air-gapped environments")
+	bootstrapCmd.PersistentFlags().StringVar(&bootstrapArgs.componentsFile, "components-file", bootstrapArgs.componentsFile,
+		"name of the file the component manifests are written to, in the directory named after the namespace under --path")
+	bootstrapCmd.PersistentFlags().StringVar(&bootstrapArgs.syncFile, "sync-file", bootstrapArgs.syncFile,
+		"name of the file the sync manifests are written to, in the directory named after the namespace under --path")
 
 	bootstrapCmd.PersistentFlags().BoolVar(&bootstrapArgs.watchAllNamespaces, "watch-all-namespaces", true,
 		"watch for custom resources in all namespaces, if set to false it will only watch the namespace where the Flux controllers are installed")
 	bootstrapCmd.PersistentFlags().BoolVar(&bootstrapArgs.networkPolicy, "network-policy", true,
 		"setup Kubernetes network policies to deny ingress access to the Flux controllers from other namespaces")
+	bootstrapCmd.PersistentFlags().StringSliceVar(&bootstrapArgs.networkPolicyCIDRs, "network-policy-cidrs", nil,
+		"list of IPv4 or IPv6 CIDRs allowed to reach the Flux controllers through the network policies, e.g. '10.0.0.0/8,fd00::/8' on dual-stack clusters")
 	bootstrapCmd.PersistentFlags().BoolVar(&bootstrapArgs.tokenAuth, "token-auth", false,
 		"when enabled, the personal access token will be used instead of the SSH deploy key")
 	bootstrapCmd.PersistentFlags().Var(&bootstrapArgs.logLevel, "log-level", bootstrapArgs.logLevel.Description())
 	bootstrapCmd.PersistentFlags().StringVar(&bootstrapArgs.clusterDomain, "cluster-domain", rootArgs.defaults.ClusterDomain, "internal cluster domain")
 	bootstrapCmd.PersistentFlags().StringSliceVar(&bootstrapArgs.tolerationKeys, "toleration-keys", nil,
 		"list of toleration keys used to schedule the controller pods onto nodes with matching taints")
+	bootstrapCmd.PersistentFlags().StringToStringVar(&bootstrapArgs.nodeSelector, "node-selector", nil,
+		"node labels used to schedule the controller pods, in the format 'key=value', accepts comma-separated values")
+	bootstrapCmd.PersistentFlags().StringVar(&bootstrapArgs.affinityFile, "affinity-file", "",
+		"path to a YAML file containing the affinity of the controller pods")
+	bootstrapCmd.PersistentFlags().StringVar(&bootstrapArgs.priorityClass, "priority-class", "",
+		"name of the priority class of the controller pods")
+	bootstrapCmd.PersistentFlags().StringToStringVar(&bootstrapArgs.podSecurity, "pod-security", nil,
+		"Pod Security admission levels labelled on the namespace, in the format 'mode=level' with the modes enforce, audit and warn, e.g. 'enforce=restricted', the warn level defaults to restricted")
+	bootstrapCmd.PersistentFlags().StringVar(&bootstrapArgs.seccompProfileName, "seccomp-profile", "",
+		"seccomp profile of the controller pods, can be 'RuntimeDefault', 'Unconfined' or 'Localhost/<profile>' with a profile path relative to the kubelet seccomp directory")
 
 	bootstrapCmd.PersistentFlags().StringVar(&bootstrapArgs.secretName, "secret-name", rootArgs.defaults.Namespace, "name of the secret the sync credentials can be found in or stored to")
+	bootstrapCmd.PersistentFlags().BoolVar(&bootstrapArgs.secretRefExisting, "secret-ref-existing", false,
+		"use the existing secret specified by --secret-name instead of generating the sync credentials, the secret is verified to be able to clone the repository")
+	bootstrapCmd.PersistentFlags().StringVar(&bootstrapArgs.existingSecret, "existing-secret", "",
+		"shorthand for --secret-ref-existing --secret-name=<name>, reuses a pre-provisioned secret in the Flux namespace for Git authentication")
 	bootstrapCmd.PersistentFlags().Var(&bootstrapArgs.keyAlgorithm, "ssh-key-algorithm", bootstrapArgs.keyAlgorithm.Description())
 	bootstrapCmd.PersistentFlags().Var(&bootstrapArgs.keyRSABits, "ssh-rsa-bits", bootstrapArgs.keyRSABits.Description())
 	bootstrapCmd.PersistentFlags().Var(&bootstrapArgs.keyECDSACurve, "ssh-ecdsa-curve", bootstrapArgs.keyECDSACurve.Description())
 	bootstrapCmd.PersistentFlags().StringVar(&bootstrapArgs.sshHostname, "ssh-hostname", "", "SSH hostname, to be used when the SSH host differs from the HTTPS one")
-	bootstrapCmd.PersistentFlags().StringVar(&bootstrapArgs.caFile, "ca-file", "", "path to TLS CA file used for validating self-signed certificates")
+	bootstrapCmd.PersistentFlags().StringVar(&bootstrapArgs.proxy, "proxy", "",
+		"URL of the HTTP/S or SOCKS5 proxy the Git server is reached through, stored in the source secret for source-controller, "+
+			"SSH repositories require a socks5:// proxy, when not set the CLI uses the proxy found in the HTTPS_PROXY environment variable")
+	bootstrapCmd.PersistentFlags().StringVar(&bootstrapArgs.caFile, "ca-file", "", "path to TLS CA file used for validating self-signed certificates of the Git server and the Git provider API, also stored in the source secret with HTTPS authentication")
 	bootstrapCmd.PersistentFlags().StringVar(&bootstrapArgs.privateKeyFile, "private-key-file", "", "path to a private key file used for authenticating to the Git SSH server")
 
-	bootstrapCmd.PersistentFlags().StringVar(&bootstrapArgs.authorName, "author-name", "Flux", "author name for Git commits")
-	bootstrapCmd.PersistentFlags().StringVar(&bootstrapArgs.authorEmail, "author-email", "", "author email for Git commits")
+	bootstrapCmd.PersistentFlags().StringVar(&bootstrapArgs.authorName, "author-name", "Flux",
+		"author name for Git commits, defaults to user.name from the global Git config if set")
+	bootstrapCmd.PersistentFlags().StringVar(&bootstrapArgs.authorEmail, "author-email", "",
+		"author email for Git commits, defaults to user.email from the global Git config if set")
 
 	bootstrapCmd.PersistentFlags().StringVar(&bootstrapArgs.gpgKeyRingPath, "gpg-key-ring", "", "path to GPG key ring for signing commits")
 	bootstrapCmd.PersistentFlags().StringVar(&bootstrapArgs.gpgPassphrase, "gpg-passphrase", "", "passphrase for decrypting GPG private key")
-	bootstrapCmd.PersistentFlags().StringVar(&bootstrapArgs.gpgKeyID, "gpg-key-id", "", "key id for selecting a particular key")
+	bootstrapCmd.PersistentFlags().StringVar(&bootstrapArgs.gpgKeyID, "gpg-key-id", "", "key id for selecting a particular key, defaults to user.signingkey from the global Git config if set")
+	bootstrapCmd.PersistentFlags().StringVar(&bootstrapArgs.sshSigningKeyFile, "ssh-signing-key-file", "", "path to an SSH private key for signing commits instead of GPG, e.g. the key given with --private-key-file, the deploy key generated by bootstrap is not used for signing")
+	bootstrapCmd.PersistentFlags().StringVar(&bootstrapArgs.sshSigningKeyPassword, "ssh-signing-key-password", "", "password for decrypting the SSH signing key")
 
 	bootstrapCmd.PersistentFlags().StringVar(&bootstrapArgs.commitMessageAppendix, "commit-message-appendix", "", "string to add to the commit messages, e.g. '[ci skip]'")
+	bootstrapCmd.PersistentFlags().BoolVar(&bootstrapArgs.signoff, "signoff", false,
+		"append a Signed-off-by trailer for the commit author to the commit messages, requires an author email")
+	bootstrapCmd.PersistentFlags().BoolVar(&bootstrapArgs.shallowClone, "shallow-clone", true,
+		"clone only the last commit of the branch, the full history is cloned if the Git server does not support shallow clones")
+
+	bootstrapCmd.PersistentFlags().BoolVar(&bootstrapArgs.withSOPSAge, "with-sops-age", false,
+		"generate an age key stored in the secret specified by --sops-age-secret, commit a .sops.yaml creation rule for it and enable the SOPS decryption of the sync Kustomization")
+	bootstrapCmd.PersistentFlags().StringVar(&bootstrapArgs.sopsAgeSecret, "sops-age-secret", "sops-age",
+		"name of the secret in the Flux namespace the age key used by --with-sops-age is stored in, an existing age key is reused")
+
+	bootstrapCmd.PersistentFlags().StringSliceVar(&bootstrapArgs.contexts, "contexts", nil,
+		"kubeconfig contexts to bootstrap against the same repository, the cluster of each context is synced from the path named after the context under --path, defaults to clusters/<context>")
+
+	bootstrapCmd.PersistentFlags().StringArrayVar(&bootstrapArgs.postBootstrapCommands, "post-bootstrap-commands", nil,
+		"shell commands to execute after a successful bootstrap, the commands are Go templates with access to the shell-quoted {{ .URL }}, {{ .Branch }}, {{ .Path }} and {{ .Namespace }}, also set as the FLUX_BOOTSTRAP_* env vars")
+	bootstrapCmd.PersistentFlags().StringSliceVar(&bootstrapArgs.postBootstrapManifests, "post-bootstrap-manifests", nil,
+		"paths to manifests or Kustomize overlays to apply on the cluster after a successful bootstrap")
+	bootstrapCmd.PersistentFlags().StringVar(&bootstrapArgs.postBootstrapConfig, "post-bootstrap-config", "",
+		"path to a YAML file with the 'commands' and 'manifests' to run after a successful bootstrap, in addition to the ones given with flags")
+	bootstrapCmd.PersistentFlags().DurationVar(&bootstrapArgs.postBootstrapTimeout, "post-bootstrap-timeout", 5*time.Minute,
+		"timeout for the post-bootstrap hooks, counted from the end of the bootstrap")
+
+	bootstrapCmd.PersistentFlags().StringVar(&bootstrapArgs.summaryFile, "summary-file", "",
+		"path to write a JSON summary of a successful bootstrap to, including the repository, commits, key fingerprint and component versions, use '-' for stdout")
 
 	bootstrapCmd.PersistentFlags().MarkHidden("manifests")
 
@@ -138,9 +236,15 @@ func NewBootstrapFlags() bootstrapFlags {
 	return bootstrapFlags{
 		logLevel:           flags.LogLevel(rootArgs.defaults.LogLevel),
 		requiredComponents: []string{"source-controller", "kustomize-controller"},
+		networkPolicy:      true,
 		keyAlgorithm:       flags.PublicKeyAlgorithm(sourcesecret.ECDSAPrivateKeyAlgorithm),
 		keyRSABits:         2048,
 		keyECDSACurve:      flags.ECDSACurve{Curve: elliptic.P384()},
+		componentsFile:     rootArgs.defaults.ManifestFile,
+		syncFile:           sync.MakeDefaultOptions().ManifestFile,
+		shallowClone:       true,
+
+		postBootstrapTimeout: 5 * time.Minute,
 	}
 }
 
@@ -149,13 +253,22 @@ func bootstrapComponents() []string {
 }
 
 func buildEmbeddedManifestBase() (string, error) {
-	if !isEmbeddedVersion(bootstrapArgs.version) {
+	if !isEmbeddedVersion(bootstrapArgs.version) && bootstrapArgs.manifestsArtifact == "" {
 		return "", nil
 	}
 	tmpBaseDir, err := manifestgen.MkdirTempAbs("", "flux-manifests-")
 	if err != nil {
 		return "", err
 	}
+	if bootstrapArgs.manifestsArtifact != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+		defer cancel()
+		if err := extractManifestsArtifact(ctx, bootstrapArgs.manifestsArtifact, tmpBaseDir, bootstrapComponents()); err != nil {
+			os.RemoveAll(tmpBaseDir)
+			return "", err
+		}
+		return tmpBaseDir, nil
+	}
 	if err := writeEmbeddedManifests(tmpBaseDir); err != nil {
 		return "", err
 	}
@@ -174,9 +287,165 @@ func bootstrapValidate() error {
 		return err
 	}
 
+	if err := validateNetworkPolicyCIDRs(bootstrapArgs.networkPolicy, bootstrapArgs.networkPolicyCIDRs); err != nil {
+		return err
+	}
+	if bootstrapArgs.existingSecret != "" {
+		if bootstrapCmd.PersistentFlags().Changed("secret-name") {
+			return fmt.Errorf("--existing-secret and --secret-name are mutually exclusive, use --secret-ref-existing with --secret-name instead")
+		}
+		bootstrapArgs.secretName = bootstrapArgs.existingSecret
+		bootstrapArgs.secretRefExisting = true
+	}
+	if bootstrapArgs.manifestsArtifact != "" && bootstrapArgs.manifestsPath != "" {
+		return fmt.Errorf("--manifests and --manifests-artifact are mutually exclusive")
+	}
+	if err := validateBootstrapFiles(bootstrapArgs.componentsFile, bootstrapArgs.syncFile); err != nil {
+		return err
+	}
+	if err := validateNodeSelector(bootstrapArgs.nodeSelector); err != nil {
+		return err
+	}
+	if err := validatePodSecurity(bootstrapArgs.podSecurity); err != nil {
+		return err
+	}
+	seccompProfile, err := parseSeccompProfile(bootstrapArgs.seccompProfileName)
+	if err != nil {
+		return err
+	}
+	bootstrapArgs.seccompProfile = seccompProfile
+	if bootstrapArgs.affinityFile != "" {
+		affinity, err := readAffinityFile(bootstrapArgs.affinityFile)
+		if err != nil {
+			return err
+		}
+		bootstrapArgs.affinity = affinity
+	}
+
+	if bootstrapArgs.withSOPSAge && bootstrapArgs.sopsAgeSecret == "" {
+		return fmt.Errorf("--sops-age-secret is required with --with-sops-age")
+	}
+
+	if bootstrapArgs.sshSigningKeyFile != "" && bootstrapArgs.gpgKeyRingPath != "" {
+		return fmt.Errorf("--ssh-signing-key-file and --gpg-key-ring are mutually exclusive")
+	}
+
+	if bootstrapArgs.signoff && bootstrapArgs.authorEmail == "" {
+		return fmt.Errorf("an author email is required to sign off commits, set --author-email or user.email in the Git config")
+	}
+
+	proxy, err := parseBootstrapProxy(bootstrapArgs.proxy)
+	if err != nil {
+		return err
+	}
+	if err := configureBootstrapProxy(proxy); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// validateBootstrapFiles checks that the component and sync manifests are
+// written to distinct files next to the generated kustomization.yaml.
+func validateBootstrapFiles(componentsFile, syncFile string) error {
+	for flag, name := range map[string]string{"--components-file": componentsFile, "--sync-file": syncFile} {
+		switch {
+		case name == "":
+			return fmt.Errorf("%s must not be empty", flag)
+		case strings.ContainsAny(name, `/\`) || name == "." || name == "..":
+			return fmt.Errorf("%s must be a file name, got %q", flag, name)
+		case !strings.HasSuffix(name, ".yaml") && !strings.HasSuffix(name, ".yml"):
+			return fmt.Errorf("%s must have a .yaml or .yml extension, got %q", flag, name)
+		}
+		for _, kfile := range konfig.RecognizedKustomizationFileNames() {
+			if name == kfile {
+				return fmt.Errorf("%s must not be named %s, the file is generated by bootstrap", flag, kfile)
+			}
+		}
+	}
+	if componentsFile == syncFile {
+		return fmt.Errorf("--components-file and --sync-file must differ, got %q", componentsFile)
+	}
+	return nil
+}
+
+// validateWebhookReceiver checks that the notification-controller, which
+// serves the Receiver, is part of the components and that the receiver URL
+// is set to an absolute HTTP(S) URL when the webhook receiver is enabled.
+func validateWebhookReceiver(enabled bool, receiverURL string, components []string) error {
+	if !enabled {
+		return nil
+	}
+	if !utils.ContainsItemString(components, "notification-controller") {
+		return fmt.Errorf("--with-webhook-receiver requires notification-controller in --components or --components-extra")
+	}
+	if receiverURL == "" {
+		return fmt.Errorf("--webhook-receiver-url is required with --with-webhook-receiver")
+	}
+	u, err := url.Parse(receiverURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid --webhook-receiver-url '%s', must be an http or https URL", receiverURL)
+	}
 	return nil
 }
 
+// readCAFile reads the PEM encoded CA bundle given with --ca-file, which is
+// used for the Git server, the Git provider API and the HTTPS source secret.
+// It returns nil when no file is given.
+func readCAFile(path string) ([]byte, error) {
+	if path == "" {
+		return nil, nil
+	}
+	caBundle, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read TLS CA file: %w", err)
+	}
+	if !x509.NewCertPool().AppendCertsFromPEM(caBundle) {
+		return nil, fmt.Errorf("no PEM encoded certificates found in TLS CA file '%s'", path)
+	}
+	return caBundle, nil
+}
+
+// setGitConfigDefaults sets the commit author and signing key from the
+// global Git config of the user when they are not given with flags.
+// Only the identity is read: the Git client used by bootstrap does not
+// execute hooks, so settings like core.hooksPath have no effect on it.
+func setGitConfigDefaults(cmd *cobra.Command) {
+	cfg, err := gitconfig.LoadConfig(gitconfig.GlobalScope)
+	if err != nil {
+		return
+	}
+
+	name, email := cfg.User.Name, cfg.User.Email
+	if cfg.Author.Name != "" {
+		name = cfg.Author.Name
+	}
+	if cfg.Author.Email != "" {
+		email = cfg.Author.Email
+	}
+	if !cmd.Flags().Changed("author-name") && name != "" {
+		bootstrapArgs.authorName = name
+	}
+	if !cmd.Flags().Changed("author-email") && email != "" {
+		bootstrapArgs.authorEmail = email
+	}
+
+	if bootstrapArgs.gpgKeyRingPath != "" && !cmd.Flags().Changed("gpg-key-id") {
+		if key := cfg.Raw.Section("user").Option("signingkey"); key != "" {
+			bootstrapArgs.gpgKeyID = key
+		}
+	}
+}
+
+// gitClientFactory returns the factory of the Git clients bootstrap clones
+// the repository with, the clone is made again with a new client when a push
+// conflicts with another commit.
+func gitClientFactory(authOpts *git.AuthOptions, clientOpts []gogit.ClientOption) bootstrap.GitClientFactory {
+	return func(path string) (repository.Client, error) {
+		return gogit.NewClient(path, authOpts, clientOpts...)
+	}
+}
+
 func mapTeamSlice(s []string, defaultPermission string) map[string]string {
 	m := make(map[string]string, len(s))
 	for _, v := range s {
@@ -0,0 +1,460 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/fluxcd/pkg/git"
+	"github.com/fluxcd/pkg/git/gogit"
+	"github.com/spf13/cobra"
+
+	"github.com/fluxcd/flux2/internal/flags"
+	"github.com/fluxcd/flux2/pkg/bootstrap"
+	"github.com/fluxcd/flux2/pkg/manifestgen"
+	"github.com/fluxcd/flux2/pkg/manifestgen/install"
+	"github.com/fluxcd/flux2/pkg/manifestgen/sourcesecret"
+	"github.com/fluxcd/flux2/pkg/manifestgen/sync"
+)
+
+var bootstrapAzureDevOpsCmd = &cobra.Command{
+	Use:   "azure-devops",
+	Short: "Deploy Flux on a cluster connected to an Azure DevOps repository",
+	Long: `The bootstrap azure-devops command creates the Azure DevOps repository if it doesn't exists and
+commits the Flux manifests to the specified branch.
+Then it configures the target cluster to synchronize with that repository.
+If the Flux components are present on the cluster,
+the bootstrap command will perform an upgrade if needed.
+
+Azure DevOps has no deploy keys, when using SSH authentication the generated RSA public key
+has to be added to the SSH public keys of a user with access to the repository.`,
+	Example: `  # Create an Azure DevOps personal access token and export it as an env var
+  export AZURE_DEVOPS_TOKEN=<my-token>
+
+  # Run bootstrap for a repository using HTTPS token authentication
+  flux bootstrap azure-devops --organization=<org> --project=<project> --repository=<repository name> --token-auth --path=clusters/my-cluster
+
+  # Run bootstrap for a repository using SSH authentication
+  flux bootstrap azure-devops --organization=<org> --project=<project> --repository=<repository name> --path=clusters/my-cluster
+
+  # Run bootstrap for a repository hosted on Azure DevOps Server
+  flux bootstrap azure-devops --organization=<collection> --project=<project> --repository=<repository name> --hostname=<domain> --ca-file=<path to CA file> --token-auth --path=clusters/my-cluster`,
+	RunE: bootstrapForContexts(&azureDevOpsArgs.path, bootstrapAzureDevOpsCmdRun),
+}
+
+const (
+	azdoDefaultDomain    = "dev.azure.com"
+	azdoDefaultSSHDomain = "ssh.dev.azure.com"
+	azdoTokenEnvVar      = "AZURE_DEVOPS_TOKEN"
+	azdoAPIVersion       = "7.0"
+)
+
+type azureDevOpsFlags struct {
+	organization string
+	project      string
+	repository   string
+	interval     time.Duration
+	hostname     string
+	path         flags.SafeRelativePath
+	silent       bool
+}
+
+var azureDevOpsArgs azureDevOpsFlags
+
+func init() {
+	bootstrapAzureDevOpsCmd.Flags().StringVar(&azureDevOpsArgs.organization, "organization", "", "Azure DevOps organization, or collection when using Azure DevOps Server")
+	bootstrapAzureDevOpsCmd.Flags().StringVar(&azureDevOpsArgs.project, "project", "", "Azure DevOps project name")
+	bootstrapAzureDevOpsCmd.Flags().StringVar(&azureDevOpsArgs.repository, "repository", "", "Azure DevOps repository name")
+	bootstrapAzureDevOpsCmd.Flags().DurationVar(&azureDevOpsArgs.interval, "interval", time.Minute, "sync interval")
+	bootstrapAzureDevOpsCmd.Flags().StringVar(&azureDevOpsArgs.hostname, "hostname", azdoDefaultDomain, "Azure DevOps hostname")
+	bootstrapAzureDevOpsCmd.Flags().Var(&azureDevOpsArgs.path, "path", "path relative to the repository root, when specified the cluster sync will be scoped to this path")
+	bootstrapAzureDevOpsCmd.Flags().BoolVarP(&azureDevOpsArgs.silent, "silent", "s", false, "assumes the SSH public key is already setup, skips confirmation")
+
+	bootstrapCmd.AddCommand(bootstrapAzureDevOpsCmd)
+}
+
+func bootstrapAzureDevOpsCmdRun(cmd *cobra.Command, args []string) error {
+	if azureDevOpsArgs.organization == "" || azureDevOpsArgs.project == "" || azureDevOpsArgs.repository == "" {
+		return fmt.Errorf("--organization, --project and --repository are required")
+	}
+
+	azdoToken := os.Getenv(azdoTokenEnvVar)
+	if azdoToken == "" {
+		var err error
+		azdoToken, err = readPasswordFromStdin("Please enter your Azure DevOps personal access token (PAT): ")
+		if err != nil {
+			return fmt.Errorf("could not read token: %w", err)
+		}
+	}
+
+	// Azure DevOps only accepts RSA keys for SSH authentication
+	if !bootstrapArgs.tokenAuth {
+		if cmd.Flags().Changed("ssh-key-algorithm") && bootstrapArgs.keyAlgorithm != flags.PublicKeyAlgorithm(sourcesecret.RSAPrivateKeyAlgorithm) {
+			return fmt.Errorf("only RSA SSH keys are supported by Azure DevOps, got --ssh-key-algorithm=%s", bootstrapArgs.keyAlgorithm)
+		}
+		bootstrapArgs.keyAlgorithm = flags.PublicKeyAlgorithm(sourcesecret.RSAPrivateKeyAlgorithm)
+	}
+
+	setGitConfigDefaults(cmd)
+	if err := bootstrapValidate(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
+	if err != nil {
+		return err
+	}
+
+	// Manifest base
+	if ver, err := getVersion(bootstrapArgs.version); err != nil {
+		return err
+	} else {
+		bootstrapArgs.version = ver
+	}
+	manifestsBase, err := buildEmbeddedManifestBase()
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(manifestsBase)
+
+	caBundle, err := readCAFile(bootstrapArgs.caFile)
+	if err != nil {
+		return err
+	}
+
+	// Create the repository when it doesn't exist
+	azdoClient, err := newAzureDevOpsClient(azureDevOpsArgs.hostname, azdoToken, caBundle)
+	if err != nil {
+		return err
+	}
+	created, err := azdoClient.ensureRepository(ctx, azureDevOpsArgs.organization, azureDevOpsArgs.project, azureDevOpsArgs.repository)
+	if err != nil {
+		return err
+	}
+	if created {
+		logger.Successf("repository %q created", azureDevOpsArgs.repository)
+	} else {
+		logger.Successf("repository %q exists", azureDevOpsArgs.repository)
+	}
+
+	repositoryURL := azureDevOpsRepositoryURL(azureDevOpsArgs.hostname, azureDevOpsArgs.organization,
+		azureDevOpsArgs.project, azureDevOpsArgs.repository)
+	authOpts := &git.AuthOptions{
+		Transport: git.HTTPS,
+		Username:  "git",
+		Password:  azdoToken,
+		CAFile:    caBundle,
+	}
+
+	// Detect the default branch of the repository, to use it when --branch
+	// is omitted and to create the branch from it when it does not exist.
+	defaultBranch, err := bootstrap.DefaultBranch(ctx, repositoryURL.String(), authOpts)
+	if err != nil {
+		logger.Warningf("unable to detect the default branch of %s: %s", repositoryURL.String(), err.Error())
+	}
+	if !cmd.Flags().Changed("branch") && defaultBranch != "" {
+		bootstrapArgs.branch = defaultBranch
+	}
+
+	// Lazy go-git repository
+	tmpDir, err := manifestgen.MkdirTempAbs("", "flux-bootstrap-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary working dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	clientOpts := []gogit.ClientOption{gogit.WithDiskStorage(), gogit.WithFallbackToDefaultKnownHosts()}
+	newGitClient := gitClientFactory(authOpts, clientOpts)
+	gitClient, err := newGitClient(tmpDir)
+	if err != nil {
+		return fmt.Errorf("failed to create a Git client: %w", err)
+	}
+
+	// Install manifest config
+	installOptions := install.Options{
+		BaseURL:                rootArgs.defaults.BaseURL,
+		Version:                bootstrapArgs.version,
+		Namespace:              *kubeconfigArgs.Namespace,
+		Components:             bootstrapComponents(),
+		Registry:               bootstrapArgs.registry,
+		ImagePullSecret:        bootstrapArgs.imagePullSecret,
+		WatchAllNamespaces:     bootstrapArgs.watchAllNamespaces,
+		NetworkPolicy:          bootstrapArgs.networkPolicy,
+		NetworkPolicyCIDRs:     bootstrapArgs.networkPolicyCIDRs,
+		LogLevel:               bootstrapArgs.logLevel.String(),
+		NotificationController: rootArgs.defaults.NotificationController,
+		ManifestFile:           bootstrapArgs.componentsFile,
+		Timeout:                rootArgs.timeout,
+		TargetPath:             azureDevOpsArgs.path.ToSlash(),
+		ClusterDomain:          bootstrapArgs.clusterDomain,
+		TolerationKeys:         bootstrapArgs.tolerationKeys,
+		NodeSelector:           bootstrapArgs.nodeSelector,
+		Affinity:               bootstrapArgs.affinity,
+		PriorityClassName:      bootstrapArgs.priorityClass,
+		PodSecurity:            bootstrapArgs.podSecurity,
+		SeccompProfile:         bootstrapArgs.seccompProfile,
+	}
+	if customBaseURL := bootstrapArgs.manifestsPath; customBaseURL != "" {
+		installOptions.BaseURL = customBaseURL
+	}
+
+	// Source generation and secret config
+	secretOpts := sourcesecret.Options{
+		Name:         bootstrapArgs.secretName,
+		Namespace:    *kubeconfigArgs.Namespace,
+		TargetPath:   azureDevOpsArgs.path.String(),
+		ManifestFile: sourcesecret.MakeDefaultOptions().ManifestFile,
+		Proxy:        bootstrapArgs.proxy,
+	}
+	syncURL := repositoryURL
+	if bootstrapArgs.tokenAuth {
+		secretOpts.Username = "git"
+		secretOpts.Password = azdoToken
+		secretOpts.CAFile = caBundle
+	} else {
+		keypair, err := sourcesecret.LoadKeyPairFromPath(bootstrapArgs.privateKeyFile, "")
+		if err != nil {
+			return err
+		}
+		secretOpts.Keypair = keypair
+		secretOpts.PrivateKeyAlgorithm = sourcesecret.PrivateKeyAlgorithm(bootstrapArgs.keyAlgorithm)
+		secretOpts.RSAKeyBits = int(bootstrapArgs.keyRSABits)
+
+		syncURL = azureDevOpsSSHURL(azureDevOpsArgs.hostname, bootstrapArgs.sshHostname, azureDevOpsArgs.organization,
+			azureDevOpsArgs.project, azureDevOpsArgs.repository)
+		secretOpts.SSHHostname = syncURL.Host
+	}
+
+	// Sync manifest config
+	syncOpts := sync.Options{
+		Interval:          azureDevOpsArgs.interval,
+		Name:              *kubeconfigArgs.Namespace,
+		Namespace:         *kubeconfigArgs.Namespace,
+		URL:               syncURL.String(),
+		Branch:            bootstrapArgs.branch,
+		Secret:            bootstrapArgs.secretName,
+		TargetPath:        azureDevOpsArgs.path.ToSlash(),
+		ManifestFile:      bootstrapArgs.syncFile,
+		RecurseSubmodules: bootstrapArgs.recurseSubmodules,
+	}
+	if bootstrapArgs.withSOPSAge {
+		syncOpts.DecryptionSecret = bootstrapArgs.sopsAgeSecret
+	}
+
+	entityList, err := bootstrap.LoadEntityListFromPath(bootstrapArgs.gpgKeyRingPath)
+	if err != nil {
+		return err
+	}
+	sshSigner, err := bootstrap.LoadSSHSignerFromPath(bootstrapArgs.sshSigningKeyFile, bootstrapArgs.sshSigningKeyPassword)
+	if err != nil {
+		return err
+	}
+
+	// Bootstrap config
+	bootstrapOpts := []bootstrap.GitOption{
+		bootstrap.WithGitClientFactory(newGitClient),
+		bootstrap.WithRepositoryURL(repositoryURL.String()),
+		bootstrap.WithBranch(bootstrapArgs.branch),
+		bootstrap.WithDefaultBranch(defaultBranch),
+		bootstrap.WithSignature(bootstrapArgs.authorName, bootstrapArgs.authorEmail),
+		bootstrap.WithCommitMessageAppendix(bootstrapArgs.commitMessageAppendix),
+		bootstrap.WithSignoff(bootstrapArgs.signoff),
+		bootstrap.WithShallowClone(bootstrapArgs.shallowClone),
+		bootstrap.WithKubeconfig(kubeconfigArgs, kubeclientOptions),
+		bootstrap.WithPostGenerateSecretFunc(promptPublicKey(azureDevOpsArgs.silent)),
+		bootstrap.WithLogger(logger),
+		bootstrap.WithGitCommitSigning(entityList, bootstrapArgs.gpgPassphrase, bootstrapArgs.gpgKeyID),
+		bootstrap.WithSSHCommitSigning(sshSigner),
+	}
+	if bootstrapArgs.secretRefExisting {
+		bootstrapOpts = append(bootstrapOpts, bootstrap.WithExistingSourceSecret())
+	}
+	if bootstrapArgs.withSOPSAge {
+		bootstrapOpts = append(bootstrapOpts, bootstrap.WithSOPSAge())
+	}
+
+	// Setup bootstrapper with constructed configs
+	b, err := bootstrap.NewPlainGitProvider(gitClient, kubeClient, bootstrapOpts...)
+	if err != nil {
+		return err
+	}
+
+	// Run
+	if err := bootstrap.Run(ctx, b, manifestsBase, installOptions, secretOpts, syncOpts, rootArgs.pollInterval, rootArgs.timeout); err != nil {
+		return err
+	}
+
+	if err := runPostBootstrapHooks(b.RepositoryURL(), syncOpts); err != nil {
+		return err
+	}
+
+	return writeBootstrapSummary(ctx, cmd.OutOrStdout(), kubeClient, b.RepositoryURL(), b.Commits(), installOptions, syncOpts)
+}
+
+// azureDevOpsRepositoryURL returns the HTTPS clone URL of the repository.
+func azureDevOpsRepositoryURL(hostname, organization, project, repository string) *url.URL {
+	return &url.URL{
+		Scheme: "https",
+		Host:   hostname,
+		Path:   fmt.Sprintf("/%s/%s/_git/%s", organization, project, repository),
+	}
+}
+
+// azureDevOpsSSHURL returns the SSH clone URL of the repository. Azure DevOps
+// Services serves SSH from a dedicated host with its own path layout, while
+// Azure DevOps Server uses the same path as for HTTPS.
+func azureDevOpsSSHURL(hostname, sshHostname, organization, project, repository string) *url.URL {
+	u := &url.URL{
+		Scheme: "ssh",
+		User:   url.User("git"),
+		Host:   sshHostname,
+	}
+	if hostname == azdoDefaultDomain {
+		if u.Host == "" {
+			u.Host = azdoDefaultSSHDomain
+		}
+		u.Path = fmt.Sprintf("/v3/%s/%s/%s", organization, project, repository)
+		return u
+	}
+	if u.Host == "" {
+		u.Host = hostname
+	}
+	u.Path = fmt.Sprintf("/%s/%s/_git/%s", organization, project, repository)
+	return u
+}
+
+// azureDevOpsClient is a minimal client of the Azure DevOps REST API, for
+// the repository operations of the bootstrap command.
+type azureDevOpsClient struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+func newAzureDevOpsClient(hostname, token string, caBundle []byte) (*azureDevOpsClient, error) {
+	httpClient := &http.Client{}
+	if len(caBundle) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(caBundle) {
+			return nil, fmt.Errorf("no valid certificates found in the CA file")
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+		httpClient.Transport = transport
+	}
+	return &azureDevOpsClient{
+		baseURL:    "https://" + hostname,
+		token:      token,
+		httpClient: httpClient,
+	}, nil
+}
+
+// ensureRepository creates the repository in the project if it doesn't
+// exist, and returns true when it was created.
+func (c *azureDevOpsClient) ensureRepository(ctx context.Context, organization, project, repository string) (bool, error) {
+	path := fmt.Sprintf("/%s/%s/_apis/git/repositories/%s", url.PathEscape(organization),
+		url.PathEscape(project), url.PathEscape(repository))
+	status, err := c.do(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return false, err
+	}
+	switch status {
+	case http.StatusOK:
+		return false, nil
+	case http.StatusNotFound:
+	default:
+		return false, fmt.Errorf("unable to get repository %q: Azure DevOps API returned status %d", repository, status)
+	}
+
+	var p struct {
+		ID string `json:"id"`
+	}
+	path = fmt.Sprintf("/%s/_apis/projects/%s", url.PathEscape(organization), url.PathEscape(project))
+	status, err = c.do(ctx, http.MethodGet, path, nil, &p)
+	if err != nil {
+		return false, err
+	}
+	if status != http.StatusOK {
+		return false, fmt.Errorf("unable to get project %q: Azure DevOps API returned status %d", project, status)
+	}
+
+	body := map[string]interface{}{
+		"name":    repository,
+		"project": map[string]string{"id": p.ID},
+	}
+	path = fmt.Sprintf("/%s/%s/_apis/git/repositories", url.PathEscape(organization), url.PathEscape(project))
+	status, err = c.do(ctx, http.MethodPost, path, body, nil)
+	if err != nil {
+		return false, err
+	}
+	if status != http.StatusCreated && status != http.StatusOK {
+		return false, fmt.Errorf("unable to create repository %q: Azure DevOps API returned status %d", repository, status)
+	}
+	return true, nil
+}
+
+// do sends a request to the Azure DevOps API authenticated with the personal
+// access token, and decodes the response into out when it is not nil.
+func (c *azureDevOpsClient) do(ctx context.Context, method, path string, in, out interface{}) (int, error) {
+	var body bytes.Buffer
+	if in != nil {
+		if err := json.NewEncoder(&body).Encode(in); err != nil {
+			return 0, err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path+"?api-version="+azdoAPIVersion, &body)
+	if err != nil {
+		return 0, err
+	}
+	req.SetBasicAuth("", c.token)
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("request to the Azure DevOps API failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// The API redirects unauthenticated requests to a sign-in page
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusNonAuthoritativeInfo {
+		return resp.StatusCode, fmt.Errorf("authentication to the Azure DevOps API failed, check the personal access token")
+	}
+	if out != nil && resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp.StatusCode, fmt.Errorf("unable to decode Azure DevOps API response: %w", err)
+		}
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAzureDevOpsURLs(t *testing.T) {
+	tests := []struct {
+		name        string
+		hostname    string
+		sshHostname string
+		wantHTTPS   string
+		wantSSH     string
+	}{
+		{
+			name:      "services",
+			hostname:  "dev.azure.com",
+			wantHTTPS: "https://dev.azure.com/org/my%20project/_git/fleet",
+			wantSSH:   "ssh://git@ssh.dev.azure.com/v3/org/my%20project/fleet",
+		},
+		{
+			name:      "server",
+			hostname:  "tfs.example.com",
+			wantHTTPS: "https://tfs.example.com/org/my%20project/_git/fleet",
+			wantSSH:   "ssh://git@tfs.example.com/org/my%20project/_git/fleet",
+		},
+		{
+			name:        "server with ssh hostname",
+			hostname:    "tfs.example.com",
+			sshHostname: "tfs.example.com:22",
+			wantHTTPS:   "https://tfs.example.com/org/my%20project/_git/fleet",
+			wantSSH:     "ssh://git@tfs.example.com:22/org/my%20project/_git/fleet",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := azureDevOpsRepositoryURL(tt.hostname, "org", "my project", "fleet").String(); got != tt.wantHTTPS {
+				t.Errorf("azureDevOpsRepositoryURL() = %s, want %s", got, tt.wantHTTPS)
+			}
+			if got := azureDevOpsSSHURL(tt.hostname, tt.sshHostname, "org", "my project", "fleet").String(); got != tt.wantSSH {
+				t.Errorf("azureDevOpsSSHURL() = %s, want %s", got, tt.wantSSH)
+			}
+		})
+	}
+}
+
+func TestAzureDevOpsEnsureRepository(t *testing.T) {
+	var created map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, token, _ := r.BasicAuth(); token != "pat" {
+			w.WriteHeader(http.StatusNonAuthoritativeInfo)
+			return
+		}
+		if r.URL.Query().Get("api-version") != azdoAPIVersion {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/org/_apis/projects/project":
+			w.Write([]byte(`{"id":"8c1a6b1e"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/org/project/_apis/git/repositories/existing":
+			w.Write([]byte(`{"name":"existing"}`))
+		case r.Method == http.MethodGet:
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodPost && r.URL.Path == "/org/project/_apis/git/repositories":
+			json.NewDecoder(r.Body).Decode(&created)
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	defer server.Close()
+
+	client := &azureDevOpsClient{baseURL: server.URL, token: "pat", httpClient: server.Client()}
+	ok, err := client.ensureRepository(context.TODO(), "org", "project", "existing")
+	if err != nil || ok {
+		t.Fatalf("expected existing repository not to be created, got %v, %v", ok, err)
+	}
+	if created != nil {
+		t.Fatalf("unexpected create request: %v", created)
+	}
+
+	ok, err = client.ensureRepository(context.TODO(), "org", "project", "fleet")
+	if err != nil || !ok {
+		t.Fatalf("expected repository to be created, got %v, %v", ok, err)
+	}
+	if created["name"] != "fleet" {
+		t.Errorf("expected repository name fleet, got %v", created["name"])
+	}
+	if project, _ := created["project"].(map[string]interface{}); project["id"] != "8c1a6b1e" {
+		t.Errorf("expected project id 8c1a6b1e, got %v", created["project"])
+	}
+
+	client.token = "invalid"
+	if _, err := client.ensureRepository(context.TODO(), "org", "project", "fleet"); err == nil {
+		t.Error("expected authentication error")
+	}
+}
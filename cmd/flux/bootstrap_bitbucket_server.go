@@ -27,7 +27,6 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/fluxcd/flux2/internal/flags"
-	"github.com/fluxcd/flux2/internal/utils"
 	"github.com/fluxcd/flux2/pkg/bootstrap"
 	"github.com/fluxcd/flux2/pkg/bootstrap/provider"
 	"github.com/fluxcd/flux2/pkg/manifestgen"
@@ -58,7 +57,7 @@ the bootstrap command will perform an upgrade if needed.`,
 
   # Run bootstrap for a an existing repository with a branch named main
   flux bootstrap bitbucket-server --owner=<project> --username=<user> --repository=<repository name> --branch=main --hostname=<domain> --token-auth --path=clusters/my-cluster`,
-	RunE: bootstrapBServerCmdRun,
+	RunE: bootstrapForContexts(&bServerArgs.path, bootstrapBServerCmdRun),
 }
 
 const (
@@ -112,6 +111,7 @@ func bootstrapBServerCmdRun(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("invalid hostname %q", bServerArgs.hostname)
 	}
 
+	setGitConfigDefaults(cmd)
 	if err := bootstrapValidate(); err != nil {
 		return err
 	}
@@ -119,7 +119,7 @@ func bootstrapBServerCmdRun(cmd *cobra.Command, args []string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(kubeconfigArgs, kubeclientOptions)
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
 	if err != nil {
 		return err
 	}
@@ -141,13 +141,9 @@ func bootstrapBServerCmdRun(cmd *cobra.Command, args []string) error {
 		user = bServerArgs.owner
 	}
 
-	var caBundle []byte
-	if bootstrapArgs.caFile != "" {
-		var err error
-		caBundle, err = os.ReadFile(bootstrapArgs.caFile)
-		if err != nil {
-			return fmt.Errorf("unable to read TLS CA file: %w", err)
-		}
+	caBundle, err := readCAFile(bootstrapArgs.caFile)
+	if err != nil {
+		return err
 	}
 
 	// Build Bitbucket Server provider
@@ -157,6 +153,7 @@ func bootstrapBServerCmdRun(cmd *cobra.Command, args []string) error {
 		Username: user,
 		Token:    bitbucketToken,
 		CaBundle: caBundle,
+		Logger:   logger,
 	}
 
 	providerClient, err := provider.BuildGitProvider(providerCfg)
@@ -172,12 +169,13 @@ func bootstrapBServerCmdRun(cmd *cobra.Command, args []string) error {
 	defer os.RemoveAll(tmpDir)
 
 	clientOpts := []gogit.ClientOption{gogit.WithDiskStorage(), gogit.WithFallbackToDefaultKnownHosts()}
-	gitClient, err := gogit.NewClient(tmpDir, &git.AuthOptions{
+	newGitClient := gitClientFactory(&git.AuthOptions{
 		Transport: git.HTTPS,
 		Username:  user,
 		Password:  bitbucketToken,
 		CAFile:    caBundle,
-	}, clientOpts...)
+	}, clientOpts)
+	gitClient, err := newGitClient(tmpDir)
 	if err != nil {
 		return fmt.Errorf("failed to create a Git client: %w", err)
 	}
@@ -192,13 +190,19 @@ func bootstrapBServerCmdRun(cmd *cobra.Command, args []string) error {
 		ImagePullSecret:        bootstrapArgs.imagePullSecret,
 		WatchAllNamespaces:     bootstrapArgs.watchAllNamespaces,
 		NetworkPolicy:          bootstrapArgs.networkPolicy,
+		NetworkPolicyCIDRs:     bootstrapArgs.networkPolicyCIDRs,
 		LogLevel:               bootstrapArgs.logLevel.String(),
 		NotificationController: rootArgs.defaults.NotificationController,
-		ManifestFile:           rootArgs.defaults.ManifestFile,
+		ManifestFile:           bootstrapArgs.componentsFile,
 		Timeout:                rootArgs.timeout,
 		TargetPath:             bServerArgs.path.ToSlash(),
 		ClusterDomain:          bootstrapArgs.clusterDomain,
 		TolerationKeys:         bootstrapArgs.tolerationKeys,
+		NodeSelector:           bootstrapArgs.nodeSelector,
+		Affinity:               bootstrapArgs.affinity,
+		PriorityClassName:      bootstrapArgs.priorityClass,
+		PodSecurity:            bootstrapArgs.podSecurity,
+		SeccompProfile:         bootstrapArgs.seccompProfile,
 	}
 	if customBaseURL := bootstrapArgs.manifestsPath; customBaseURL != "" {
 		installOptions.BaseURL = customBaseURL
@@ -210,6 +214,7 @@ func bootstrapBServerCmdRun(cmd *cobra.Command, args []string) error {
 		Namespace:    *kubeconfigArgs.Namespace,
 		TargetPath:   bServerArgs.path.String(),
 		ManifestFile: sourcesecret.MakeDefaultOptions().ManifestFile,
+		Proxy:        bootstrapArgs.proxy,
 	}
 	if bootstrapArgs.tokenAuth {
 		if bServerArgs.personal {
@@ -243,28 +248,42 @@ func bootstrapBServerCmdRun(cmd *cobra.Command, args []string) error {
 		Branch:            bootstrapArgs.branch,
 		Secret:            bootstrapArgs.secretName,
 		TargetPath:        bServerArgs.path.ToSlash(),
-		ManifestFile:      sync.MakeDefaultOptions().ManifestFile,
+		ManifestFile:      bootstrapArgs.syncFile,
 		RecurseSubmodules: bootstrapArgs.recurseSubmodules,
 	}
+	if bootstrapArgs.withSOPSAge {
+		syncOpts.DecryptionSecret = bootstrapArgs.sopsAgeSecret
+	}
 
 	entityList, err := bootstrap.LoadEntityListFromPath(bootstrapArgs.gpgKeyRingPath)
 	if err != nil {
 		return err
 	}
+	sshSigner, err := bootstrap.LoadSSHSignerFromPath(bootstrapArgs.sshSigningKeyFile, bootstrapArgs.sshSigningKeyPassword)
+	if err != nil {
+		return err
+	}
 
 	// Bootstrap config
 
 	bootstrapOpts := []bootstrap.GitProviderOption{
+		bootstrap.WithGitClientFactory(newGitClient),
 		bootstrap.WithProviderRepository(bServerArgs.owner, bServerArgs.repository, bServerArgs.personal),
 		bootstrap.WithBranch(bootstrapArgs.branch),
 		bootstrap.WithBootstrapTransportType("https"),
 		bootstrap.WithSignature(bootstrapArgs.authorName, bootstrapArgs.authorEmail),
 		bootstrap.WithCommitMessageAppendix(bootstrapArgs.commitMessageAppendix),
+		bootstrap.WithSignoff(bootstrapArgs.signoff),
+		bootstrap.WithShallowClone(bootstrapArgs.shallowClone),
 		bootstrap.WithProviderTeamPermissions(mapTeamSlice(bServerArgs.teams, bServerDefaultPermission)),
 		bootstrap.WithReadWriteKeyPermissions(bServerArgs.readWriteKey),
 		bootstrap.WithKubeconfig(kubeconfigArgs, kubeclientOptions),
 		bootstrap.WithLogger(logger),
 		bootstrap.WithGitCommitSigning(entityList, bootstrapArgs.gpgPassphrase, bootstrapArgs.gpgKeyID),
+		bootstrap.WithSSHCommitSigning(sshSigner),
+	}
+	if !cmd.Flags().Changed("branch") {
+		bootstrapOpts = append(bootstrapOpts, bootstrap.WithDefaultBranchDetection())
 	}
 	if bootstrapArgs.sshHostname != "" {
 		bootstrapOpts = append(bootstrapOpts, bootstrap.WithSSHHostname(bootstrapArgs.sshHostname))
@@ -279,6 +298,13 @@ func bootstrapBServerCmdRun(cmd *cobra.Command, args []string) error {
 		bootstrapOpts = append(bootstrapOpts, bootstrap.WithReconcile())
 	}
 
+	if bootstrapArgs.secretRefExisting {
+		bootstrapOpts = append(bootstrapOpts, bootstrap.WithExistingSourceSecret())
+	}
+	if bootstrapArgs.withSOPSAge {
+		bootstrapOpts = append(bootstrapOpts, bootstrap.WithSOPSAge())
+	}
+
 	// Setup bootstrapper with constructed configs
 	b, err := bootstrap.NewGitProviderBootstrapper(gitClient, providerClient, kubeClient, bootstrapOpts...)
 	if err != nil {
@@ -286,5 +312,13 @@ func bootstrapBServerCmdRun(cmd *cobra.Command, args []string) error {
 	}
 
 	// Run
-	return bootstrap.Run(ctx, b, manifestsBase, installOptions, secretOpts, syncOpts, rootArgs.pollInterval, rootArgs.timeout)
+	if err := bootstrap.Run(ctx, b, manifestsBase, installOptions, secretOpts, syncOpts, rootArgs.pollInterval, rootArgs.timeout); err != nil {
+		return err
+	}
+
+	if err := runPostBootstrapHooks(b.RepositoryURL(), syncOpts); err != nil {
+		return err
+	}
+
+	return writeBootstrapSummary(ctx, cmd.OutOrStdout(), kubeClient, b.RepositoryURL(), b.Commits(), installOptions, syncOpts)
 }
@@ -0,0 +1,482 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/codecommit"
+	cctypes "github.com/aws/aws-sdk-go-v2/service/codecommit/types"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/fluxcd/go-git/v5/plumbing/transport"
+	"github.com/fluxcd/pkg/git"
+	"github.com/fluxcd/pkg/git/gogit"
+	"github.com/fluxcd/pkg/ssh"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/fluxcd/flux2/internal/flags"
+	"github.com/fluxcd/flux2/pkg/bootstrap"
+	"github.com/fluxcd/flux2/pkg/manifestgen"
+	"github.com/fluxcd/flux2/pkg/manifestgen/install"
+	"github.com/fluxcd/flux2/pkg/manifestgen/sourcesecret"
+	"github.com/fluxcd/flux2/pkg/manifestgen/sync"
+)
+
+var bootstrapCodeCommitCmd = &cobra.Command{
+	Use:   "codecommit",
+	Short: "Deploy Flux on a cluster connected to an AWS CodeCommit repository",
+	Long: `The bootstrap codecommit command creates the AWS CodeCommit repository if it doesn't exists and
+commits the Flux manifests to the specified branch.
+Then it configures the target cluster to synchronize with that repository.
+If the Flux components are present on the cluster,
+the bootstrap command will perform an upgrade if needed.
+
+The AWS credentials are loaded from the environment, the shared configuration files
+or the instance metadata, the same way the AWS CLI does. The Git credentials of the
+generated source secret are issued for an IAM user: an SSH public key is uploaded for the
+user, or HTTPS Git credentials are created for the user when using --token-auth.`,
+	Example: `  # Run bootstrap for a repository using an SSH key uploaded for the current IAM user
+  flux bootstrap codecommit --repository=<repository name> --region=<region> --path=clusters/my-cluster
+
+  # Run bootstrap for a repository using HTTPS Git credentials created for an IAM user
+  flux bootstrap codecommit --repository=<repository name> --region=<region> --iam-user=<user> --token-auth --path=clusters/my-cluster
+
+  # Run bootstrap for a repository using existing HTTPS Git credentials
+  flux bootstrap codecommit --repository=<repository name> --region=<region> --token-auth --username=<user> --password=<password> --path=clusters/my-cluster
+
+  # Run bootstrap for a repository using an SSH key already uploaded to IAM
+  flux bootstrap codecommit --repository=<repository name> --region=<region> --private-key-file=<path/to/private.key> --ssh-key-id=<SSH key ID> --path=clusters/my-cluster`,
+	RunE: bootstrapForContexts(&codeCommitArgs.path, bootstrapCodeCommitCmdRun),
+}
+
+type codeCommitFlags struct {
+	repository  string
+	description string
+	region      string
+	iamUser     string
+	sshKeyID    string
+	username    string
+	password    string
+	interval    time.Duration
+	path        flags.SafeRelativePath
+}
+
+var codeCommitArgs codeCommitFlags
+
+func init() {
+	bootstrapCodeCommitCmd.Flags().StringVar(&codeCommitArgs.repository, "repository", "", "AWS CodeCommit repository name")
+	bootstrapCodeCommitCmd.Flags().StringVar(&codeCommitArgs.description, "description", "", "description of the repository when it is created")
+	bootstrapCodeCommitCmd.Flags().StringVar(&codeCommitArgs.region, "region", "", "AWS region of the repository, defaults to the region of the AWS configuration")
+	bootstrapCodeCommitCmd.Flags().StringVar(&codeCommitArgs.iamUser, "iam-user", "", "IAM user to issue the Git credentials for, defaults to the user of the AWS credentials")
+	bootstrapCodeCommitCmd.Flags().StringVar(&codeCommitArgs.sshKeyID, "ssh-key-id", "", "ID of an SSH public key already uploaded to IAM for the key of --private-key-file")
+	bootstrapCodeCommitCmd.Flags().StringVarP(&codeCommitArgs.username, "username", "u", "", "existing HTTPS Git credentials username, used with --token-auth")
+	bootstrapCodeCommitCmd.Flags().StringVarP(&codeCommitArgs.password, "password", "p", "", "existing HTTPS Git credentials password, used with --token-auth")
+	bootstrapCodeCommitCmd.Flags().DurationVar(&codeCommitArgs.interval, "interval", time.Minute, "sync interval")
+	bootstrapCodeCommitCmd.Flags().Var(&codeCommitArgs.path, "path", "path relative to the repository root, when specified the cluster sync will be scoped to this path")
+
+	bootstrapCmd.AddCommand(bootstrapCodeCommitCmd)
+}
+
+// codeCommitAPI is the subset of the CodeCommit client used by bootstrap.
+type codeCommitAPI interface {
+	GetRepository(ctx context.Context, params *codecommit.GetRepositoryInput, optFns ...func(*codecommit.Options)) (*codecommit.GetRepositoryOutput, error)
+	CreateRepository(ctx context.Context, params *codecommit.CreateRepositoryInput, optFns ...func(*codecommit.Options)) (*codecommit.CreateRepositoryOutput, error)
+}
+
+// codeCommitIAMAPI is the subset of the IAM client used by bootstrap to
+// issue Git credentials.
+type codeCommitIAMAPI interface {
+	GetUser(ctx context.Context, params *iam.GetUserInput, optFns ...func(*iam.Options)) (*iam.GetUserOutput, error)
+	UploadSSHPublicKey(ctx context.Context, params *iam.UploadSSHPublicKeyInput, optFns ...func(*iam.Options)) (*iam.UploadSSHPublicKeyOutput, error)
+	CreateServiceSpecificCredential(ctx context.Context, params *iam.CreateServiceSpecificCredentialInput, optFns ...func(*iam.Options)) (*iam.CreateServiceSpecificCredentialOutput, error)
+}
+
+const codeCommitServiceName = "codecommit.amazonaws.com"
+
+func bootstrapCodeCommitCmdRun(cmd *cobra.Command, args []string) error {
+	if codeCommitArgs.repository == "" {
+		return fmt.Errorf("--repository is required")
+	}
+	if codeCommitArgs.sshKeyID != "" && bootstrapArgs.privateKeyFile == "" {
+		return fmt.Errorf("--ssh-key-id requires --private-key-file")
+	}
+	if (codeCommitArgs.username != "" || codeCommitArgs.password != "") && !bootstrapArgs.tokenAuth {
+		return fmt.Errorf("--username and --password can only be used with --token-auth")
+	}
+
+	// CodeCommit only accepts RSA keys for SSH authentication
+	if !bootstrapArgs.tokenAuth {
+		if cmd.Flags().Changed("ssh-key-algorithm") && bootstrapArgs.keyAlgorithm != flags.PublicKeyAlgorithm(sourcesecret.RSAPrivateKeyAlgorithm) {
+			return fmt.Errorf("only RSA SSH keys are supported by AWS CodeCommit, got --ssh-key-algorithm=%s", bootstrapArgs.keyAlgorithm)
+		}
+		bootstrapArgs.keyAlgorithm = flags.PublicKeyAlgorithm(sourcesecret.RSAPrivateKeyAlgorithm)
+	}
+
+	setGitConfigDefaults(cmd)
+	if err := bootstrapValidate(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	defer cancel()
+
+	var cfgOpts []func(*config.LoadOptions) error
+	if codeCommitArgs.region != "" {
+		cfgOpts = append(cfgOpts, config.WithRegion(codeCommitArgs.region))
+	}
+	awsCfg, err := config.LoadDefaultConfig(ctx, cfgOpts...)
+	if err != nil {
+		return fmt.Errorf("unable to load the AWS configuration: %w", err)
+	}
+	if awsCfg.Region == "" {
+		return fmt.Errorf("the AWS region is not configured, set it with --region")
+	}
+
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
+	if err != nil {
+		return err
+	}
+
+	// Manifest base
+	if ver, err := getVersion(bootstrapArgs.version); err != nil {
+		return err
+	} else {
+		bootstrapArgs.version = ver
+	}
+	manifestsBase, err := buildEmbeddedManifestBase()
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(manifestsBase)
+
+	// Create the repository when it doesn't exist
+	metadata, created, err := ensureCodeCommitRepository(ctx, codecommit.NewFromConfig(awsCfg),
+		codeCommitArgs.repository, codeCommitArgs.description)
+	if err != nil {
+		return err
+	}
+	if created {
+		logger.Successf("repository %q created", codeCommitArgs.repository)
+	} else {
+		logger.Successf("repository %q exists", codeCommitArgs.repository)
+	}
+
+	// Issue the Git credentials used both to push and to sync
+	iamClient := iam.NewFromConfig(awsCfg)
+	secretOpts := sourcesecret.Options{
+		Name:         bootstrapArgs.secretName,
+		Namespace:    *kubeconfigArgs.Namespace,
+		TargetPath:   codeCommitArgs.path.String(),
+		ManifestFile: sourcesecret.MakeDefaultOptions().ManifestFile,
+		Proxy:        bootstrapArgs.proxy,
+	}
+	var repositoryURL *url.URL
+	var authOpts *git.AuthOptions
+	if bootstrapArgs.tokenAuth {
+		username, password := codeCommitArgs.username, codeCommitArgs.password
+		if username == "" || password == "" {
+			username, password, err = createCodeCommitCredentials(ctx, iamClient, codeCommitArgs.iamUser)
+			if err != nil {
+				return err
+			}
+			logger.Successf("HTTPS Git credentials created for user %s", username)
+		}
+		if repositoryURL, err = url.Parse(aws.ToString(metadata.CloneUrlHttp)); err != nil {
+			return fmt.Errorf("invalid repository clone URL: %w", err)
+		}
+		authOpts = &git.AuthOptions{
+			Transport: git.HTTPS,
+			Username:  username,
+			Password:  password,
+		}
+		secretOpts.Username = username
+		secretOpts.Password = password
+	} else {
+		keypair, err := sourcesecret.LoadKeyPairFromPath(bootstrapArgs.privateKeyFile, "")
+		if err != nil {
+			return err
+		}
+		if keypair != nil && !strings.HasPrefix(string(keypair.PublicKey), "ssh-rsa ") {
+			return fmt.Errorf("only RSA SSH keys are supported by AWS CodeCommit, the key of --private-key-file is not an RSA key")
+		}
+		if keypair == nil {
+			if keypair, err = ssh.NewRSAGenerator(int(bootstrapArgs.keyRSABits)).Generate(); err != nil {
+				return fmt.Errorf("key pair generation failed: %w", err)
+			}
+		}
+		keyID := codeCommitArgs.sshKeyID
+		if keyID == "" {
+			if keyID, err = uploadCodeCommitSSHKey(ctx, iamClient, codeCommitArgs.iamUser, keypair.PublicKey); err != nil {
+				return err
+			}
+			logger.Successf("SSH public key %s uploaded", keyID)
+		}
+		if repositoryURL, err = url.Parse(aws.ToString(metadata.CloneUrlSsh)); err != nil {
+			return fmt.Errorf("invalid repository clone URL: %w", err)
+		}
+		repositoryURL.User = url.User(keyID)
+		knownHosts, err := sourcesecret.ScanHostKey(repositoryURL.Host)
+		if err != nil {
+			return err
+		}
+		authOpts = &git.AuthOptions{
+			Transport:  git.SSH,
+			Username:   keyID,
+			Identity:   keypair.PrivateKey,
+			KnownHosts: knownHosts,
+		}
+		secretOpts.Keypair = keypair
+		secretOpts.SSHHostname = repositoryURL.Host
+	}
+
+	// Newly issued IAM credentials take a few seconds to be accepted,
+	// the default branch detection doubles as a check of their propagation.
+	logger.Waitingf("waiting for the Git credentials to be accepted")
+	defaultBranch, err := waitForCodeCommitCredentials(rootArgs.pollInterval, rootArgs.timeout, func() (string, error) {
+		return bootstrap.DefaultBranch(ctx, repositoryURL.String(), authOpts)
+	})
+	if err != nil {
+		return fmt.Errorf("the Git credentials were not accepted by %s: %w", repositoryURL.Host, err)
+	}
+	if !cmd.Flags().Changed("branch") && defaultBranch != "" {
+		bootstrapArgs.branch = defaultBranch
+	}
+
+	// Lazy go-git repository
+	tmpDir, err := manifestgen.MkdirTempAbs("", "flux-bootstrap-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary working dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	clientOpts := []gogit.ClientOption{gogit.WithDiskStorage(), gogit.WithFallbackToDefaultKnownHosts()}
+	newGitClient := gitClientFactory(authOpts, clientOpts)
+	gitClient, err := newGitClient(tmpDir)
+	if err != nil {
+		return fmt.Errorf("failed to create a Git client: %w", err)
+	}
+
+	// Install manifest config
+	installOptions := install.Options{
+		BaseURL:                rootArgs.defaults.BaseURL,
+		Version:                bootstrapArgs.version,
+		Namespace:              *kubeconfigArgs.Namespace,
+		Components:             bootstrapComponents(),
+		Registry:               bootstrapArgs.registry,
+		ImagePullSecret:        bootstrapArgs.imagePullSecret,
+		WatchAllNamespaces:     bootstrapArgs.watchAllNamespaces,
+		NetworkPolicy:          bootstrapArgs.networkPolicy,
+		NetworkPolicyCIDRs:     bootstrapArgs.networkPolicyCIDRs,
+		LogLevel:               bootstrapArgs.logLevel.String(),
+		NotificationController: rootArgs.defaults.NotificationController,
+		ManifestFile:           bootstrapArgs.componentsFile,
+		Timeout:                rootArgs.timeout,
+		TargetPath:             codeCommitArgs.path.ToSlash(),
+		ClusterDomain:          bootstrapArgs.clusterDomain,
+		TolerationKeys:         bootstrapArgs.tolerationKeys,
+		NodeSelector:           bootstrapArgs.nodeSelector,
+		Affinity:               bootstrapArgs.affinity,
+		PriorityClassName:      bootstrapArgs.priorityClass,
+		PodSecurity:            bootstrapArgs.podSecurity,
+		SeccompProfile:         bootstrapArgs.seccompProfile,
+	}
+	if customBaseURL := bootstrapArgs.manifestsPath; customBaseURL != "" {
+		installOptions.BaseURL = customBaseURL
+	}
+
+	// Sync manifest config
+	syncOpts := sync.Options{
+		Interval:          codeCommitArgs.interval,
+		Name:              *kubeconfigArgs.Namespace,
+		Namespace:         *kubeconfigArgs.Namespace,
+		URL:               repositoryURL.String(),
+		Branch:            bootstrapArgs.branch,
+		Secret:            bootstrapArgs.secretName,
+		TargetPath:        codeCommitArgs.path.ToSlash(),
+		ManifestFile:      bootstrapArgs.syncFile,
+		RecurseSubmodules: bootstrapArgs.recurseSubmodules,
+	}
+	if bootstrapArgs.withSOPSAge {
+		syncOpts.DecryptionSecret = bootstrapArgs.sopsAgeSecret
+	}
+
+	entityList, err := bootstrap.LoadEntityListFromPath(bootstrapArgs.gpgKeyRingPath)
+	if err != nil {
+		return err
+	}
+	sshSigner, err := bootstrap.LoadSSHSignerFromPath(bootstrapArgs.sshSigningKeyFile, bootstrapArgs.sshSigningKeyPassword)
+	if err != nil {
+		return err
+	}
+
+	// Bootstrap config
+	bootstrapOpts := []bootstrap.GitOption{
+		bootstrap.WithGitClientFactory(newGitClient),
+		bootstrap.WithRepositoryURL(repositoryURL.String()),
+		bootstrap.WithBranch(bootstrapArgs.branch),
+		bootstrap.WithDefaultBranch(defaultBranch),
+		bootstrap.WithSignature(bootstrapArgs.authorName, bootstrapArgs.authorEmail),
+		bootstrap.WithCommitMessageAppendix(bootstrapArgs.commitMessageAppendix),
+		bootstrap.WithSignoff(bootstrapArgs.signoff),
+		bootstrap.WithShallowClone(bootstrapArgs.shallowClone),
+		bootstrap.WithKubeconfig(kubeconfigArgs, kubeclientOptions),
+		bootstrap.WithLogger(logger),
+		bootstrap.WithGitCommitSigning(entityList, bootstrapArgs.gpgPassphrase, bootstrapArgs.gpgKeyID),
+		bootstrap.WithSSHCommitSigning(sshSigner),
+	}
+	if bootstrapArgs.secretRefExisting {
+		bootstrapOpts = append(bootstrapOpts, bootstrap.WithExistingSourceSecret())
+	}
+	if bootstrapArgs.withSOPSAge {
+		bootstrapOpts = append(bootstrapOpts, bootstrap.WithSOPSAge())
+	}
+
+	// Setup bootstrapper with constructed configs
+	b, err := bootstrap.NewPlainGitProvider(gitClient, kubeClient, bootstrapOpts...)
+	if err != nil {
+		return err
+	}
+
+	// Run
+	if err := bootstrap.Run(ctx, b, manifestsBase, installOptions, secretOpts, syncOpts, rootArgs.pollInterval, rootArgs.timeout); err != nil {
+		return err
+	}
+
+	if err := runPostBootstrapHooks(b.RepositoryURL(), syncOpts); err != nil {
+		return err
+	}
+
+	return writeBootstrapSummary(ctx, cmd.OutOrStdout(), kubeClient, b.RepositoryURL(), b.Commits(), installOptions, syncOpts)
+}
+
+// ensureCodeCommitRepository returns the metadata of the repository,
+// creating it when it doesn't exist, in which case created is true.
+func ensureCodeCommitRepository(ctx context.Context, client codeCommitAPI, name, description string) (*cctypes.RepositoryMetadata, bool, error) {
+	out, err := client.GetRepository(ctx, &codecommit.GetRepositoryInput{
+		RepositoryName: aws.String(name),
+	})
+	if err == nil {
+		return out.RepositoryMetadata, false, nil
+	}
+	var notFound *cctypes.RepositoryDoesNotExistException
+	if !errors.As(err, &notFound) {
+		return nil, false, fmt.Errorf("unable to get repository %q: %w", name, err)
+	}
+
+	input := &codecommit.CreateRepositoryInput{
+		RepositoryName: aws.String(name),
+	}
+	if description != "" {
+		input.RepositoryDescription = aws.String(description)
+	}
+	created, err := client.CreateRepository(ctx, input)
+	if err != nil {
+		return nil, false, fmt.Errorf("unable to create repository %q: %w", name, err)
+	}
+	return created.RepositoryMetadata, true, nil
+}
+
+// codeCommitUser returns the given IAM user name, or the name of the user
+// the AWS credentials belong to.
+func codeCommitUser(ctx context.Context, client codeCommitIAMAPI, user string) (string, error) {
+	if user != "" {
+		return user, nil
+	}
+	out, err := client.GetUser(ctx, &iam.GetUserInput{})
+	if err != nil {
+		return "", fmt.Errorf("unable to get the IAM user of the AWS credentials, set it with --iam-user: %w", err)
+	}
+	return aws.ToString(out.User.UserName), nil
+}
+
+// uploadCodeCommitSSHKey uploads the SSH public key for the IAM user and
+// returns its ID, which is the SSH username for CodeCommit.
+func uploadCodeCommitSSHKey(ctx context.Context, client codeCommitIAMAPI, user string, publicKey []byte) (string, error) {
+	user, err := codeCommitUser(ctx, client, user)
+	if err != nil {
+		return "", err
+	}
+	out, err := client.UploadSSHPublicKey(ctx, &iam.UploadSSHPublicKeyInput{
+		UserName:         aws.String(user),
+		SSHPublicKeyBody: aws.String(strings.TrimSpace(string(publicKey))),
+	})
+	if err != nil {
+		return "", fmt.Errorf("unable to upload the SSH public key for IAM user %s: %w", user, err)
+	}
+	return aws.ToString(out.SSHPublicKey.SSHPublicKeyId), nil
+}
+
+// createCodeCommitCredentials creates HTTPS Git credentials for CodeCommit
+// for the IAM user.
+func createCodeCommitCredentials(ctx context.Context, client codeCommitIAMAPI, user string) (string, string, error) {
+	user, err := codeCommitUser(ctx, client, user)
+	if err != nil {
+		return "", "", err
+	}
+	out, err := client.CreateServiceSpecificCredential(ctx, &iam.CreateServiceSpecificCredentialInput{
+		UserName:    aws.String(user),
+		ServiceName: aws.String(codeCommitServiceName),
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("unable to create HTTPS Git credentials for IAM user %s: %w", user, err)
+	}
+	return aws.ToString(out.ServiceSpecificCredential.ServiceUserName),
+		aws.ToString(out.ServiceSpecificCredential.ServicePassword), nil
+}
+
+// waitForCodeCommitCredentials polls defaultBranch until the Git credentials
+// are accepted, and returns the default branch of the repository. The
+// authentication failures are retried, as newly issued IAM credentials take a
+// few seconds to propagate, the other errors are returned immediately. On
+// timeout, the last authentication failure is returned.
+func waitForCodeCommitCredentials(interval, timeout time.Duration, defaultBranch func() (string, error)) (string, error) {
+	var branch string
+	var lastErr error
+	err := wait.PollImmediate(interval, timeout, func() (bool, error) {
+		b, err := defaultBranch()
+		if err != nil {
+			if !isCodeCommitAuthError(err) {
+				return false, err
+			}
+			lastErr = err
+			return false, nil
+		}
+		branch = b
+		return true, nil
+	})
+	if errors.Is(err, wait.ErrWaitTimeout) && lastErr != nil {
+		return "", fmt.Errorf("timed out: %w", lastErr)
+	}
+	return branch, err
+}
+
+// isCodeCommitAuthError returns true if err is a rejection of the Git
+// credentials by CodeCommit, over HTTPS or SSH.
+func isCodeCommitAuthError(err error) bool {
+	return errors.Is(err, transport.ErrAuthenticationRequired) ||
+		errors.Is(err, transport.ErrAuthorizationFailed) ||
+		strings.Contains(err.Error(), "ssh: unable to authenticate")
+}
@@ -0,0 +1,201 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/codecommit"
+	cctypes "github.com/aws/aws-sdk-go-v2/service/codecommit/types"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/fluxcd/go-git/v5/plumbing/transport"
+)
+
+type fakeCodeCommit struct {
+	repositories map[string]string
+	getErr       error
+}
+
+func (f *fakeCodeCommit) GetRepository(_ context.Context, in *codecommit.GetRepositoryInput, _ ...func(*codecommit.Options)) (*codecommit.GetRepositoryOutput, error) {
+	if f.getErr != nil {
+		return nil, f.getErr
+	}
+	name := aws.ToString(in.RepositoryName)
+	if _, ok := f.repositories[name]; !ok {
+		return nil, &cctypes.RepositoryDoesNotExistException{Message: aws.String(name + " does not exist")}
+	}
+	return &codecommit.GetRepositoryOutput{RepositoryMetadata: codeCommitMetadata(name)}, nil
+}
+
+func (f *fakeCodeCommit) CreateRepository(_ context.Context, in *codecommit.CreateRepositoryInput, _ ...func(*codecommit.Options)) (*codecommit.CreateRepositoryOutput, error) {
+	name := aws.ToString(in.RepositoryName)
+	f.repositories[name] = aws.ToString(in.RepositoryDescription)
+	return &codecommit.CreateRepositoryOutput{RepositoryMetadata: codeCommitMetadata(name)}, nil
+}
+
+func codeCommitMetadata(name string) *cctypes.RepositoryMetadata {
+	return &cctypes.RepositoryMetadata{
+		RepositoryName: aws.String(name),
+		CloneUrlHttp:   aws.String("https://git-codecommit.eu-west-1.amazonaws.com/v1/repos/" + name),
+		CloneUrlSsh:    aws.String("ssh://git-codecommit.eu-west-1.amazonaws.com/v1/repos/" + name),
+	}
+}
+
+func TestEnsureCodeCommitRepository(t *testing.T) {
+	client := &fakeCodeCommit{repositories: map[string]string{"existing": ""}}
+
+	metadata, created, err := ensureCodeCommitRepository(context.TODO(), client, "existing", "")
+	if err != nil || created {
+		t.Fatalf("expected existing repository not to be created, got %v, %v", created, err)
+	}
+	if aws.ToString(metadata.RepositoryName) != "existing" {
+		t.Errorf("unexpected repository %s", aws.ToString(metadata.RepositoryName))
+	}
+
+	metadata, created, err = ensureCodeCommitRepository(context.TODO(), client, "fleet", "Flux fleet")
+	if err != nil || !created {
+		t.Fatalf("expected repository to be created, got %v, %v", created, err)
+	}
+	if client.repositories["fleet"] != "Flux fleet" {
+		t.Errorf("expected description to be set, got %q", client.repositories["fleet"])
+	}
+	if aws.ToString(metadata.CloneUrlSsh) != "ssh://git-codecommit.eu-west-1.amazonaws.com/v1/repos/fleet" {
+		t.Errorf("unexpected clone URL %s", aws.ToString(metadata.CloneUrlSsh))
+	}
+
+	client.getErr = fmt.Errorf("access denied")
+	if _, _, err := ensureCodeCommitRepository(context.TODO(), client, "fleet", ""); err == nil {
+		t.Error("expected error")
+	}
+}
+
+type fakeCodeCommitIAM struct {
+	callerUser string
+	userName   string
+	publicKey  string
+}
+
+func (f *fakeCodeCommitIAM) GetUser(_ context.Context, in *iam.GetUserInput, _ ...func(*iam.Options)) (*iam.GetUserOutput, error) {
+	if f.callerUser == "" {
+		return nil, fmt.Errorf("must specify userName when calling with non-User credentials")
+	}
+	return &iam.GetUserOutput{User: &iamtypes.User{UserName: aws.String(f.callerUser)}}, nil
+}
+
+func (f *fakeCodeCommitIAM) UploadSSHPublicKey(_ context.Context, in *iam.UploadSSHPublicKeyInput, _ ...func(*iam.Options)) (*iam.UploadSSHPublicKeyOutput, error) {
+	f.userName = aws.ToString(in.UserName)
+	f.publicKey = aws.ToString(in.SSHPublicKeyBody)
+	return &iam.UploadSSHPublicKeyOutput{SSHPublicKey: &iamtypes.SSHPublicKey{SSHPublicKeyId: aws.String("APKAEIBAERJR2EXAMPLE")}}, nil
+}
+
+func (f *fakeCodeCommitIAM) CreateServiceSpecificCredential(_ context.Context, in *iam.CreateServiceSpecificCredentialInput, _ ...func(*iam.Options)) (*iam.CreateServiceSpecificCredentialOutput, error) {
+	f.userName = aws.ToString(in.UserName)
+	if aws.ToString(in.ServiceName) != codeCommitServiceName {
+		return nil, fmt.Errorf("unexpected service %s", aws.ToString(in.ServiceName))
+	}
+	return &iam.CreateServiceSpecificCredentialOutput{ServiceSpecificCredential: &iamtypes.ServiceSpecificCredential{
+		ServiceUserName: aws.String(f.userName + "-at-123456789012"),
+		ServicePassword: aws.String("secret"),
+	}}, nil
+}
+
+func TestCodeCommitCredentials(t *testing.T) {
+	client := &fakeCodeCommitIAM{callerUser: "flux"}
+	keyID, err := uploadCodeCommitSSHKey(context.TODO(), client, "", []byte("ssh-rsa AAAA\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keyID != "APKAEIBAERJR2EXAMPLE" || client.userName != "flux" || client.publicKey != "ssh-rsa AAAA" {
+		t.Errorf("unexpected upload of key %q for user %q with ID %q", client.publicKey, client.userName, keyID)
+	}
+
+	username, password, err := createCodeCommitCredentials(context.TODO(), client, "bootstrap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if username != "bootstrap-at-123456789012" || password != "secret" {
+		t.Errorf("unexpected credentials %s:%s", username, password)
+	}
+
+	client.callerUser = ""
+	if _, err := uploadCodeCommitSSHKey(context.TODO(), client, "", []byte("ssh-rsa AAAA")); err == nil {
+		t.Error("expected error when the IAM user can't be determined")
+	}
+}
+
+func TestWaitForCodeCommitCredentials(t *testing.T) {
+	tests := []struct {
+		name       string
+		errs       []error
+		wantBranch string
+		wantErr    error
+		wantCalls  int
+	}{
+		{
+			name:       "accepted after propagation",
+			errs:       []error{transport.ErrAuthorizationFailed, transport.ErrAuthenticationRequired, nil},
+			wantBranch: "main",
+			wantCalls:  3,
+		},
+		{
+			name:      "not retryable",
+			errs:      []error{transport.ErrRepositoryNotFound},
+			wantErr:   transport.ErrRepositoryNotFound,
+			wantCalls: 1,
+		},
+		{
+			name:    "timeout",
+			errs:    []error{fmt.Errorf("unable to list references: %w", transport.ErrAuthorizationFailed)},
+			wantErr: transport.ErrAuthorizationFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			branch, err := waitForCodeCommitCredentials(time.Millisecond, 50*time.Millisecond, func() (string, error) {
+				err := tt.errs[len(tt.errs)-1]
+				if calls < len(tt.errs) {
+					err = tt.errs[calls]
+				}
+				calls++
+				if err != nil {
+					return "", err
+				}
+				return "main", nil
+			})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if branch != tt.wantBranch {
+				t.Errorf("branch = %q, want %q", branch, tt.wantBranch)
+			}
+			if tt.wantCalls > 0 && calls != tt.wantCalls {
+				t.Errorf("expected %d calls, got %d", tt.wantCalls, calls)
+			}
+		})
+	}
+}
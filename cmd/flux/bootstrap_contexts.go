@@ -0,0 +1,88 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/spf13/cobra"
+
+	"github.com/fluxcd/flux2/internal/flags"
+)
+
+// bootstrapDefaultClustersPath is the repository path the cluster paths
+// are created in when bootstrapping several contexts without --path.
+const bootstrapDefaultClustersPath = "clusters"
+
+// bootstrapForContexts returns a run function which runs the bootstrap once
+// for each of the kubeconfig contexts given with --contexts, the cluster of
+// each context is synced from a path named after the context under the
+// --path of the command.
+func bootstrapForContexts(syncPath *flags.SafeRelativePath, run func(cmd *cobra.Command, args []string) error) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		if len(bootstrapArgs.contexts) == 0 {
+			return run(cmd, args)
+		}
+
+		if *kubeconfigArgs.Context != "" {
+			return fmt.Errorf("--context and --contexts are mutually exclusive")
+		}
+		if path, err := cmd.Flags().GetString("export-path"); err == nil && path != "" {
+			return fmt.Errorf("--contexts can't be used with --export-path")
+		}
+		seen := make(map[string]bool, len(bootstrapArgs.contexts))
+		for _, kubeContext := range bootstrapArgs.contexts {
+			if kubeContext == "" {
+				return fmt.Errorf("--contexts must not contain empty context names")
+			}
+			if seen[kubeContext] {
+				return fmt.Errorf("context %s is given more than once in --contexts", kubeContext)
+			}
+			seen[kubeContext] = true
+			if rootArgs.confirmContext != "" && kubeContext != rootArgs.confirmContext {
+				return fmt.Errorf("refusing to run '%s' against context %q, --confirm-context requires %q",
+					cmd.CommandPath(), kubeContext, rootArgs.confirmContext)
+			}
+		}
+
+		original := *syncPath
+		defer func() {
+			*syncPath = original
+			*kubeconfigArgs.Context = ""
+		}()
+
+		basePath := original.ToSlash()
+		if basePath == "" {
+			basePath = bootstrapDefaultClustersPath
+		}
+
+		for i, kubeContext := range bootstrapArgs.contexts {
+			if err := syncPath.Set(path.Join(basePath, kubeContext)); err != nil {
+				return fmt.Errorf("invalid path for context %s: %w", kubeContext, err)
+			}
+			*kubeconfigArgs.Context = kubeContext
+
+			logger.Actionf("bootstrapping context %s (%d/%d) with path %s",
+				kubeContext, i+1, len(bootstrapArgs.contexts), syncPath.ToSlash())
+			if err := run(cmd, args); err != nil {
+				return fmt.Errorf("bootstrap of context %s failed: %w", kubeContext, err)
+			}
+		}
+		return nil
+	}
+}
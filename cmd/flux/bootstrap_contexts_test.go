@@ -0,0 +1,106 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+
+	"github.com/fluxcd/flux2/internal/flags"
+)
+
+func TestBootstrapForContexts(t *testing.T) {
+	t.Cleanup(resetCmdArgs)
+	t.Cleanup(func() { bootstrapArgs = NewBootstrapFlags() })
+
+	type run struct {
+		context string
+		path    string
+	}
+	tests := []struct {
+		name     string
+		contexts []string
+		path     string
+		fail     string
+		want     []run
+		wantErr  string
+	}{
+		{
+			name: "single cluster",
+			path: "./clusters/dev",
+			want: []run{{path: "./clusters/dev"}},
+		},
+		{
+			name:     "default clusters path",
+			contexts: []string{"staging", "production"},
+			want: []run{
+				{context: "staging", path: "./clusters/staging"},
+				{context: "production", path: "./clusters/production"},
+			},
+		},
+		{
+			name:     "custom path",
+			contexts: []string{"staging", "production"},
+			path:     "./fleet",
+			want: []run{
+				{context: "staging", path: "./fleet/staging"},
+				{context: "production", path: "./fleet/production"},
+			},
+		},
+		{
+			name:     "stops at the first failure",
+			contexts: []string{"staging", "production"},
+			fail:     "staging",
+			want:     []run{{context: "staging", path: "./clusters/staging"}},
+			wantErr:  "bootstrap of context staging failed",
+		},
+		{
+			name:     "duplicate context",
+			contexts: []string{"staging", "staging"},
+			wantErr:  "more than once",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			bootstrapArgs.contexts = tt.contexts
+			syncPath := flags.SafeRelativePath(tt.path)
+
+			var got []run
+			runE := bootstrapForContexts(&syncPath, func(cmd *cobra.Command, args []string) error {
+				got = append(got, run{context: *kubeconfigArgs.Context, path: syncPath.String()})
+				if tt.fail != "" && *kubeconfigArgs.Context == tt.fail {
+					return errors.New("failed")
+				}
+				return nil
+			})
+
+			err := runE(&cobra.Command{}, nil)
+			if tt.wantErr != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(tt.wantErr)))
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+			g.Expect(got).To(Equal(tt.want))
+			g.Expect(syncPath.String()).To(Equal(tt.path))
+			g.Expect(*kubeconfigArgs.Context).To(BeEmpty())
+		})
+	}
+}
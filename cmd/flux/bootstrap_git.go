@@ -29,7 +29,6 @@ import (
 	corev1 "k8s.io/api/core/v1"
 
 	"github.com/fluxcd/flux2/internal/flags"
-	"github.com/fluxcd/flux2/internal/utils"
 	"github.com/fluxcd/flux2/pkg/bootstrap"
 	"github.com/fluxcd/flux2/pkg/manifestgen"
 	"github.com/fluxcd/flux2/pkg/manifestgen/install"
@@ -45,10 +44,16 @@ var bootstrapGitCmd = &cobra.Command{
 	Long: `The bootstrap git command commits the Flux manifests to the
 branch of a Git repository. And then it configures the target cluster to synchronize with
 that repository. If the Flux components are present on the cluster, the bootstrap
-command will perform an upgrade if needed.`,
+command will perform an upgrade if needed.
+
+When --branch is omitted, the default branch of the repository is used. When the given branch
+does not exist, it is created from the default branch of the repository.`,
 	Example: `  # Run bootstrap for a Git repository and authenticate with your SSH agent
   flux bootstrap git --url=ssh://git@example.com/repository.git --path=clusters/my-cluster
 
+  # Run bootstrap for a Git repository and authenticate with a specific key of your SSH agent
+  flux bootstrap git --url=ssh://git@example.com/repository.git --path=clusters/my-cluster --ssh-identity-fingerprint=SHA256:<fingerprint>
+
   # Run bootstrap for a Git repository and authenticate using a password
   flux bootstrap git --url=https://example.com/repository.git --password=<password> --path=clusters/my-cluster
 
@@ -66,34 +71,76 @@ command will perform an upgrade if needed.`,
 
   # Run bootstrap for a Git repository on Azure Devops
   flux bootstrap git --url=ssh://git@ssh.dev.azure.com/v3/<org>/<project>/<repository> --ssh-key-algorithm=rsa --ssh-rsa-bits=4096 --path=clusters/my-cluster
+
+  # Run bootstrap for a Git repository on Azure DevOps, with the cluster authenticating with workload identity
+  flux bootstrap git --url=https://dev.azure.com/<org>/<project>/_git/<repository> --password=<PAT> --token-auth --secretless --path=clusters/my-cluster
+
+  # Run bootstrap for a Git repository with bearer token authentication, e.g. an Azure DevOps OAuth token
+  GIT_BEARER_TOKEN=<token> && flux bootstrap git --url=https://dev.azure.com/<org>/<project>/_git/<repository> --path=clusters/my-cluster
+
+  # Run bootstrap for a Git repository using credentials from a secret managed outside of Flux
+  flux bootstrap git --url=ssh://git@example.com/repository.git --existing-secret=git-credentials --path=clusters/my-cluster
+
+  # Run bootstrap for a Git repository requiring GPG signed and signed-off commits
+  flux bootstrap git --url=ssh://git@example.com/repository.git --path=clusters/my-cluster \
+    --author-email=flux@example.com --gpg-key-ring=<path/to/keyring.asc> --gpg-key-id=<key-id> --signoff
+
+  # Run bootstrap for a local Git repository, with the cluster syncing from a mirror of it
+  flux bootstrap git --url=file:///path/to/repository --sync-url=ssh://git@git.example.com/repository.git --path=clusters/my-cluster
+
+  # Run bootstrap for a Git repository served over SSH on a custom port
+  flux bootstrap git --url=ssh://git@example.com:2222/repository.git --path=clusters/my-cluster
+
+  # Export the manifests bootstrap would commit to a local directory for review
+  flux bootstrap git --url=ssh://git@example.com/repository.git --path=clusters/my-cluster --export-path=./out
+
+  # Run bootstrap and register the cluster in an inventory system once Flux is ready
+  flux bootstrap git --url=ssh://git@example.com/repository.git --path=clusters/my-cluster \
+    --post-bootstrap-commands='inventory register --repo={{ .URL }} --path={{ .Path }}'
 `,
-	RunE: bootstrapGitCmdRun,
+	RunE: bootstrapForContexts(&gitArgs.path, bootstrapGitCmdRun),
 }
 
 type gitFlags struct {
 	url                 string
+	syncURL             string
 	interval            time.Duration
 	path                flags.SafeRelativePath
 	username            string
 	password            string
+	bearerToken         string
 	silent              bool
 	insecureHttpAllowed bool
+	secretless          bool
+	exportPath          string
+	sshIdentity         string
 }
 
 const (
-	gitPasswordEnvVar = "GIT_PASSWORD"
+	gitPasswordEnvVar    = "GIT_PASSWORD"
+	gitBearerTokenEnvVar = "GIT_BEARER_TOKEN"
 )
 
 var gitArgs gitFlags
 
 func init() {
-	bootstrapGitCmd.Flags().StringVar(&gitArgs.url, "url", "", "Git repository URL")
+	bootstrapGitCmd.Flags().StringVar(&gitArgs.url, "url", "", "Git repository URL, can be a ssh://, https:// or file:// URL")
+	bootstrapGitCmd.Flags().StringVar(&gitArgs.syncURL, "sync-url", "",
+		"Git repository URL used by the cluster to sync, defaults to --url and is required when --url is a file:// URL")
 	bootstrapGitCmd.Flags().DurationVar(&gitArgs.interval, "interval", time.Minute, "sync interval")
 	bootstrapGitCmd.Flags().Var(&gitArgs.path, "path", "path relative to the repository root, when specified the cluster sync will be scoped to this path")
 	bootstrapGitCmd.Flags().StringVarP(&gitArgs.username, "username", "u", "git", "basic authentication username")
 	bootstrapGitCmd.Flags().StringVarP(&gitArgs.password, "password", "p", "", "basic authentication password")
+	bootstrapGitCmd.Flags().StringVar(&gitArgs.bearerToken, "bearer-token", "",
+		"token sent in the 'Authorization: Bearer' header to the Git server and stored in the source secret instead of basic authentication, implies --token-auth, can be set with the "+gitBearerTokenEnvVar+" env var")
 	bootstrapGitCmd.Flags().BoolVarP(&gitArgs.silent, "silent", "s", false, "assumes the deploy key is already setup, skips confirmation")
 	bootstrapGitCmd.Flags().BoolVar(&gitArgs.insecureHttpAllowed, "allow-insecure-http", false, "allows insecure HTTP connections")
+	bootstrapGitCmd.Flags().BoolVar(&gitArgs.secretless, "secretless", false,
+		"skip the source secret and let the cluster authenticate to Azure DevOps with workload identity, requires a https:// sync URL and the GitRepository v1 API")
+	bootstrapGitCmd.Flags().StringVar(&gitArgs.sshIdentity, "ssh-identity-fingerprint", "",
+		"SHA256 fingerprint of the SSH agent key used to authenticate to the Git server when no private key file is given, e.g. 'SHA256:...'")
+	bootstrapGitCmd.Flags().StringVar(&gitArgs.exportPath, "export-path", "",
+		"write the manifests bootstrap would commit to the repository to this local directory, without pushing to the repository or changing the cluster")
 
 	bootstrapCmd.AddCommand(bootstrapGitCmd)
 }
@@ -103,7 +150,16 @@ func bootstrapGitCmdRun(cmd *cobra.Command, args []string) error {
 	if gitPassword != "" && gitArgs.password == "" {
 		gitArgs.password = gitPassword
 	}
-	if bootstrapArgs.tokenAuth && gitArgs.password == "" {
+	if token := os.Getenv(gitBearerTokenEnvVar); token != "" && gitArgs.bearerToken == "" {
+		gitArgs.bearerToken = token
+	}
+	if gitArgs.bearerToken != "" {
+		if gitArgs.password != "" {
+			return fmt.Errorf("--bearer-token and --password are mutually exclusive")
+		}
+		bootstrapArgs.tokenAuth = true
+	}
+	if bootstrapArgs.tokenAuth && gitArgs.password == "" && gitArgs.bearerToken == "" && gitArgs.exportPath == "" {
 		var err error
 		gitPassword, err = readPasswordFromStdin("Please enter your Git repository password: ")
 		if err != nil {
@@ -112,9 +168,13 @@ func bootstrapGitCmdRun(cmd *cobra.Command, args []string) error {
 		gitArgs.password = gitPassword
 	}
 
+	setGitConfigDefaults(cmd)
 	if err := bootstrapValidate(); err != nil {
 		return err
 	}
+	if gitArgs.sshIdentity != "" && bootstrapArgs.privateKeyFile != "" {
+		return fmt.Errorf("--ssh-identity-fingerprint and --private-key-file are mutually exclusive")
+	}
 
 	repositoryURL, err := url.Parse(gitArgs.url)
 	if err != nil {
@@ -141,11 +201,6 @@ func bootstrapGitCmdRun(cmd *cobra.Command, args []string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(kubeconfigArgs, kubeclientOptions)
-	if err != nil {
-		return err
-	}
-
 	// Manifest base
 	if ver, err := getVersion(bootstrapArgs.version); err != nil {
 		return err
@@ -165,24 +220,69 @@ func bootstrapGitCmdRun(cmd *cobra.Command, args []string) error {
 	}
 	defer os.RemoveAll(tmpDir)
 
-	var caBundle []byte
-	if bootstrapArgs.caFile != "" {
-		var err error
-		caBundle, err = os.ReadFile(bootstrapArgs.caFile)
-		if err != nil {
-			return fmt.Errorf("unable to read TLS CA file: %w", err)
-		}
+	caBundle, err := readCAFile(bootstrapArgs.caFile)
+	if err != nil {
+		return err
 	}
 	authOpts, err := getAuthOpts(repositoryURL, caBundle)
 	if err != nil {
 		return fmt.Errorf("failed to create authentication options for %s: %w", repositoryURL.String(), err)
 	}
 
+	// Authenticate with the SSH agent when no private key is given
+	if authOpts.Transport == git.SSH && len(authOpts.Identity) == 0 && gitArgs.exportPath == "" {
+		cleanup, err := useSSHAgent(gitArgs.sshIdentity)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+	}
+
+	// Detect the default branch of the repository, to use it when --branch
+	// is omitted and to create the branch from it when it does not exist.
+	// The repository is not accessed when exporting the manifests.
+	var defaultBranch string
+	if gitArgs.exportPath == "" {
+		defaultBranch, err = bootstrap.DefaultBranch(ctx, gitArgs.url, authOpts)
+		if err != nil {
+			logger.Warningf("unable to detect the default branch of %s: %s", gitArgs.url, err.Error())
+		}
+		if !cmd.Flags().Changed("branch") && defaultBranch != "" {
+			bootstrapArgs.branch = defaultBranch
+		}
+	}
+
+	// The cluster can't sync from a local repository, the URL it syncs
+	// from and authenticates against can be set separately.
+	if gitArgs.syncURL != "" {
+		syncURL, err := url.Parse(gitArgs.syncURL)
+		if err != nil {
+			return fmt.Errorf("invalid sync URL: %w", err)
+		}
+		if syncURL.Scheme == "file" {
+			return fmt.Errorf("sync URL %q must be reachable from the cluster, file:// URLs are not supported", gitArgs.syncURL)
+		}
+		repositoryURL = syncURL
+	} else if repositoryURL.Scheme == "file" {
+		return fmt.Errorf("--sync-url is required when bootstrapping a file:// repository")
+	}
+
+	var secretlessProvider string
+	if gitArgs.secretless {
+		if bootstrapArgs.secretRefExisting {
+			return fmt.Errorf("--secretless and --secret-ref-existing are mutually exclusive")
+		}
+		if secretlessProvider, err = gitWorkloadIdentityProvider(repositoryURL); err != nil {
+			return err
+		}
+	}
+
 	clientOpts := []gogit.ClientOption{gogit.WithDiskStorage(), gogit.WithFallbackToDefaultKnownHosts()}
 	if gitArgs.insecureHttpAllowed {
 		clientOpts = append(clientOpts, gogit.WithInsecureCredentialsOverHTTP())
 	}
-	gitClient, err := gogit.NewClient(tmpDir, authOpts, clientOpts...)
+	newGitClient := gitClientFactory(authOpts, clientOpts)
+	gitClient, err := newGitClient(tmpDir)
 	if err != nil {
 		return fmt.Errorf("failed to create a Git client: %w", err)
 	}
@@ -197,13 +297,19 @@ func bootstrapGitCmdRun(cmd *cobra.Command, args []string) error {
 		ImagePullSecret:        bootstrapArgs.imagePullSecret,
 		WatchAllNamespaces:     bootstrapArgs.watchAllNamespaces,
 		NetworkPolicy:          bootstrapArgs.networkPolicy,
+		NetworkPolicyCIDRs:     bootstrapArgs.networkPolicyCIDRs,
 		LogLevel:               bootstrapArgs.logLevel.String(),
 		NotificationController: rootArgs.defaults.NotificationController,
-		ManifestFile:           rootArgs.defaults.ManifestFile,
+		ManifestFile:           bootstrapArgs.componentsFile,
 		Timeout:                rootArgs.timeout,
 		TargetPath:             gitArgs.path.ToSlash(),
 		ClusterDomain:          bootstrapArgs.clusterDomain,
 		TolerationKeys:         bootstrapArgs.tolerationKeys,
+		NodeSelector:           bootstrapArgs.nodeSelector,
+		Affinity:               bootstrapArgs.affinity,
+		PriorityClassName:      bootstrapArgs.priorityClass,
+		PodSecurity:            bootstrapArgs.podSecurity,
+		SeccompProfile:         bootstrapArgs.seccompProfile,
 	}
 	if customBaseURL := bootstrapArgs.manifestsPath; customBaseURL != "" {
 		installOptions.BaseURL = customBaseURL
@@ -215,10 +321,18 @@ func bootstrapGitCmdRun(cmd *cobra.Command, args []string) error {
 		Namespace:    *kubeconfigArgs.Namespace,
 		TargetPath:   gitArgs.path.String(),
 		ManifestFile: sourcesecret.MakeDefaultOptions().ManifestFile,
+		Proxy:        bootstrapArgs.proxy,
 	}
-	if bootstrapArgs.tokenAuth {
-		secretOpts.Username = gitArgs.username
-		secretOpts.Password = gitArgs.password
+	if gitArgs.secretless {
+		// The credentials are only used by the CLI to push to the repository.
+		repositoryURL.User = nil
+	} else if bootstrapArgs.tokenAuth {
+		if gitArgs.bearerToken != "" {
+			secretOpts.BearerToken = gitArgs.bearerToken
+		} else {
+			secretOpts.Username = gitArgs.username
+			secretOpts.Password = gitArgs.password
+		}
 		secretOpts.CAFile = caBundle
 
 		// Remove port of the given host when not syncing over HTTP/S to not assume port for protocol
@@ -270,25 +384,60 @@ func bootstrapGitCmdRun(cmd *cobra.Command, args []string) error {
 		Branch:            bootstrapArgs.branch,
 		Secret:            bootstrapArgs.secretName,
 		TargetPath:        gitArgs.path.ToSlash(),
-		ManifestFile:      sync.MakeDefaultOptions().ManifestFile,
+		ManifestFile:      bootstrapArgs.syncFile,
 		RecurseSubmodules: bootstrapArgs.recurseSubmodules,
+		Provider:          secretlessProvider,
+	}
+	if bootstrapArgs.withSOPSAge {
+		syncOpts.DecryptionSecret = bootstrapArgs.sopsAgeSecret
+	}
+
+	if gitArgs.exportPath != "" && bootstrapArgs.withSOPSAge {
+		return fmt.Errorf("--with-sops-age can't be used with --export-path as the age key is stored in the cluster")
+	}
+	if gitArgs.exportPath != "" {
+		return exportBootstrapManifests(gitArgs.exportPath, manifestsBase, installOptions, syncOpts)
+	}
+
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
+	if err != nil {
+		return err
 	}
 
 	entityList, err := bootstrap.LoadEntityListFromPath(bootstrapArgs.gpgKeyRingPath)
 	if err != nil {
 		return err
 	}
+	sshSigner, err := bootstrap.LoadSSHSignerFromPath(bootstrapArgs.sshSigningKeyFile, bootstrapArgs.sshSigningKeyPassword)
+	if err != nil {
+		return err
+	}
 
 	// Bootstrap config
 	bootstrapOpts := []bootstrap.GitOption{
+		bootstrap.WithGitClientFactory(newGitClient),
 		bootstrap.WithRepositoryURL(gitArgs.url),
 		bootstrap.WithBranch(bootstrapArgs.branch),
+		bootstrap.WithDefaultBranch(defaultBranch),
 		bootstrap.WithSignature(bootstrapArgs.authorName, bootstrapArgs.authorEmail),
 		bootstrap.WithCommitMessageAppendix(bootstrapArgs.commitMessageAppendix),
+		bootstrap.WithSignoff(bootstrapArgs.signoff),
+		bootstrap.WithShallowClone(bootstrapArgs.shallowClone),
 		bootstrap.WithKubeconfig(kubeconfigArgs, kubeclientOptions),
-		bootstrap.WithPostGenerateSecretFunc(promptPublicKey),
+		bootstrap.WithPostGenerateSecretFunc(promptPublicKey(gitArgs.silent)),
 		bootstrap.WithLogger(logger),
 		bootstrap.WithGitCommitSigning(entityList, bootstrapArgs.gpgPassphrase, bootstrapArgs.gpgKeyID),
+		bootstrap.WithSSHCommitSigning(sshSigner),
+	}
+
+	if bootstrapArgs.secretRefExisting {
+		bootstrapOpts = append(bootstrapOpts, bootstrap.WithExistingSourceSecret())
+	}
+	if bootstrapArgs.withSOPSAge {
+		bootstrapOpts = append(bootstrapOpts, bootstrap.WithSOPSAge())
+	}
+	if gitArgs.secretless {
+		bootstrapOpts = append(bootstrapOpts, bootstrap.WithSecretless())
 	}
 
 	// Setup bootstrapper with constructed configs
@@ -298,30 +447,80 @@ func bootstrapGitCmdRun(cmd *cobra.Command, args []string) error {
 	}
 
 	// Run
-	return bootstrap.Run(ctx, b, manifestsBase, installOptions, secretOpts, syncOpts, rootArgs.pollInterval, rootArgs.timeout)
+	if err := bootstrap.Run(ctx, b, manifestsBase, installOptions, secretOpts, syncOpts, rootArgs.pollInterval, rootArgs.timeout); err != nil {
+		return err
+	}
+
+	if err := runPostBootstrapHooks(b.RepositoryURL(), syncOpts); err != nil {
+		return err
+	}
+
+	return writeBootstrapSummary(ctx, cmd.OutOrStdout(), kubeClient, b.RepositoryURL(), b.Commits(), installOptions, syncOpts)
+}
+
+// exportBootstrapManifests writes the manifests bootstrap commits to the
+// repository to a local directory, for them to be reviewed before running
+// the bootstrap.
+func exportBootstrapManifests(dir, manifestsBase string, installOpts install.Options, syncOpts sync.Options) error {
+	logger.Generatef("generating manifests in %s", dir)
+	files, err := bootstrap.Export(dir, manifestsBase, installOpts, syncOpts)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		logger.Successf("exported %s", file)
+	}
+	logger.Successf("manifests exported to %s, the repository and the cluster were not changed", dir)
+	return nil
+}
+
+// gitWorkloadIdentityProvider returns the provider source-controller can use
+// to authenticate to the Git server of the given URL with workload identity.
+func gitWorkloadIdentityProvider(u *url.URL) (string, error) {
+	if u.Scheme != "https" {
+		return "", fmt.Errorf("--secretless requires a https:// sync URL, got %s://", u.Scheme)
+	}
+	host := u.Hostname()
+	if host == "dev.azure.com" || strings.HasSuffix(host, ".visualstudio.com") {
+		return "azure", nil
+	}
+	return "", fmt.Errorf("--secretless is only supported for Azure DevOps, got %s", host)
 }
 
 // getAuthOpts retruns a AuthOptions based on the scheme
 // of the given URL and the configured flags. If the protocol equals
 // "ssh" but no private key is configured, authentication using the local
 // SSH-agent is attempted.
+// httpAuthOpts returns the authentication options for Git over HTTP/S,
+// with the bearer token when set or the basic authentication credentials.
+func httpAuthOpts(transport git.TransportType, caBundle []byte) *git.AuthOptions {
+	authOpts := &git.AuthOptions{
+		Transport: transport,
+		CAFile:    caBundle,
+	}
+	if gitArgs.bearerToken != "" {
+		authOpts.BearerToken = gitArgs.bearerToken
+	} else {
+		authOpts.Username = gitArgs.username
+		authOpts.Password = gitArgs.password
+	}
+	return authOpts
+}
+
 func getAuthOpts(u *url.URL, caBundle []byte) (*git.AuthOptions, error) {
 	switch u.Scheme {
 	case "http":
 		if !gitArgs.insecureHttpAllowed {
 			return nil, fmt.Errorf("scheme http is insecure, pass --allow-insecure-http=true to allow it")
 		}
-		return &git.AuthOptions{
-			Transport: git.HTTP,
-			Username:  gitArgs.username,
-			Password:  gitArgs.password,
-		}, nil
+		return httpAuthOpts(git.HTTP, nil), nil
 	case "https":
+		return httpAuthOpts(git.HTTPS, caBundle), nil
+	case "file":
+		// go-git selects the file transport from the URL scheme, the HTTP
+		// transport type without credentials results in no auth method.
 		return &git.AuthOptions{
-			Transport: git.HTTPS,
-			Username:  gitArgs.username,
-			Password:  gitArgs.password,
-			CAFile:    caBundle,
+			Transport: git.HTTP,
 		}, nil
 	case "ssh":
 		authOpts := &git.AuthOptions{
@@ -347,23 +546,28 @@ func getAuthOpts(u *url.URL, caBundle []byte) (*git.AuthOptions, error) {
 	}
 }
 
-func promptPublicKey(ctx context.Context, secret corev1.Secret, _ sourcesecret.Options) error {
-	ppk, ok := secret.StringData[sourcesecret.PublicKeySecretKey]
-	if !ok {
-		return nil
-	}
+// promptPublicKey returns a PostGenerateSecretFunc which prints the public
+// key of the generated secret and, unless silent, waits for the user to
+// confirm the key was given access to the repository.
+func promptPublicKey(silent bool) bootstrap.PostGenerateSecretFunc {
+	return func(ctx context.Context, secret corev1.Secret, _ sourcesecret.Options) error {
+		ppk, ok := secret.StringData[sourcesecret.PublicKeySecretKey]
+		if !ok {
+			return nil
+		}
 
-	logger.Successf("public key: %s", strings.TrimSpace(ppk))
+		logger.Successf("public key: %s", strings.TrimSpace(ppk))
 
-	if !gitArgs.silent {
-		prompt := promptui.Prompt{
-			Label:     "Please give the key access to your repository",
-			IsConfirm: true,
-		}
-		_, err := prompt.Run()
-		if err != nil {
-			return fmt.Errorf("aborting")
+		if !silent {
+			prompt := promptui.Prompt{
+				Label:     "Please give the key access to your repository",
+				IsConfirm: true,
+			}
+			_, err := prompt.Run()
+			if err != nil {
+				return fmt.Errorf("aborting")
+			}
 		}
+		return nil
 	}
-	return nil
 }
@@ -0,0 +1,128 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/fluxcd/pkg/git"
+)
+
+func TestGetAuthOpts(t *testing.T) {
+	tests := []struct {
+		name          string
+		url           string
+		wantTransport git.TransportType
+		bearerToken   string
+		wantUsername  string
+		wantToken     string
+		wantErr       bool
+	}{
+		{
+			name:          "https",
+			url:           "https://example.com/repository.git",
+			wantTransport: git.HTTPS,
+			wantUsername:  "git",
+		},
+		{
+			name:          "https with bearer token",
+			url:           "https://example.com/repository.git",
+			bearerToken:   "token",
+			wantTransport: git.HTTPS,
+			wantToken:     "token",
+		},
+		{
+			name:          "ssh with custom port",
+			url:           "ssh://flux@example.com:2222/repository.git",
+			wantTransport: git.SSH,
+			wantUsername:  "flux",
+		},
+		{
+			name:          "file",
+			url:           "file:///tmp/repository",
+			wantTransport: git.HTTP,
+		},
+		{
+			name:    "insecure http",
+			url:     "http://example.com/repository.git",
+			wantErr: true,
+		},
+		{
+			name:    "unsupported scheme",
+			url:     "ftp://example.com/repository.git",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gitArgs = gitFlags{username: "git", bearerToken: tt.bearerToken}
+			bootstrapArgs = NewBootstrapFlags()
+			defer func() { gitArgs = gitFlags{} }()
+
+			u, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			opts, err := getAuthOpts(u, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if opts.Transport != tt.wantTransport {
+				t.Errorf("got transport %q, want %q", opts.Transport, tt.wantTransport)
+			}
+			if opts.Username != tt.wantUsername {
+				t.Errorf("got username %q, want %q", opts.Username, tt.wantUsername)
+			}
+			if opts.BearerToken != tt.wantToken {
+				t.Errorf("got bearer token %q, want %q", opts.BearerToken, tt.wantToken)
+			}
+		})
+	}
+}
+
+func TestGitWorkloadIdentityProvider(t *testing.T) {
+	tests := []struct {
+		url     string
+		want    string
+		wantErr bool
+	}{
+		{url: "https://dev.azure.com/org/project/_git/fleet", want: "azure"},
+		{url: "https://org.visualstudio.com/project/_git/fleet", want: "azure"},
+		{url: "https://source.developers.google.com/p/project/r/fleet", wantErr: true},
+		{url: "ssh://git@ssh.dev.azure.com/v3/org/project/fleet", wantErr: true},
+		{url: "https://github.com/org/fleet", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := gitWorkloadIdentityProvider(u)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("gitWorkloadIdentityProvider() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("gitWorkloadIdentityProvider() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
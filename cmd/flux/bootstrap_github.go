@@ -27,7 +27,6 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/fluxcd/flux2/internal/flags"
-	"github.com/fluxcd/flux2/internal/utils"
 	"github.com/fluxcd/flux2/pkg/bootstrap"
 	"github.com/fluxcd/flux2/pkg/bootstrap/provider"
 	"github.com/fluxcd/flux2/pkg/manifestgen"
@@ -65,9 +64,16 @@ the bootstrap command will perform an upgrade if needed.`,
   # Run bootstrap for a private repository hosted on GitHub Enterprise using HTTPS auth
   flux bootstrap github --owner=<organization> --repository=<repository name> --hostname=<domain> --token-auth --path=clusters/my-cluster
 
+  # Run bootstrap for a private repository owned by a GitHub organization using a GitHub App
+  flux bootstrap github --owner=<organization> --repository=<repository name> --path=clusters/my-cluster \
+    --github-app-id=<app id> --github-app-installation-id=<installation id> --github-app-private-key-file=<path/to/app.pem>
+
   # Run bootstrap for an existing repository with a branch named main
-  flux bootstrap github --owner=<organization> --repository=<repository name> --branch=main --path=clusters/my-cluster`,
-	RunE: bootstrapGitHubCmdRun,
+  flux bootstrap github --owner=<organization> --repository=<repository name> --branch=main --path=clusters/my-cluster
+
+  # Run bootstrap for the clusters of the staging and production contexts, synced from clusters/staging and clusters/production
+  flux bootstrap github --owner=<organization> --repository=<repository name> --contexts=staging,production`,
+	RunE: bootstrapForContexts(&githubArgs.path, bootstrapGitHubCmdRun),
 }
 
 type githubFlags struct {
@@ -81,6 +87,13 @@ type githubFlags struct {
 	teams        []string
 	readWriteKey bool
 	reconcile    bool
+
+	withWebhookReceiver bool
+	webhookReceiverURL  string
+
+	appID             string
+	appInstallationID string
+	appPrivateKeyFile string
 }
 
 const (
@@ -102,13 +115,19 @@ func init() {
 	bootstrapGitHubCmd.Flags().Var(&githubArgs.path, "path", "path relative to the repository root, when specified the cluster sync will be scoped to this path")
 	bootstrapGitHubCmd.Flags().BoolVar(&githubArgs.readWriteKey, "read-write-key", false, "if true, the deploy key is configured with read/write permissions")
 	bootstrapGitHubCmd.Flags().BoolVar(&githubArgs.reconcile, "reconcile", false, "if true, the configured options are also reconciled if the repository already exists")
+	bootstrapGitHubCmd.Flags().BoolVar(&githubArgs.withWebhookReceiver, "with-webhook-receiver", false, "if true, a Receiver is generated and a webhook is registered on the GitHub repository to trigger the sync on push")
+	bootstrapGitHubCmd.Flags().StringVar(&githubArgs.webhookReceiverURL, "webhook-receiver-url", "", "externally reachable URL of the notification-controller webhook receiver, required with --with-webhook-receiver")
+	bootstrapGitHubCmd.Flags().StringVar(&githubArgs.appID, "github-app-id", "", "ID of the GitHub App used to authenticate instead of a personal access token")
+	bootstrapGitHubCmd.Flags().StringVar(&githubArgs.appInstallationID, "github-app-installation-id", "", "ID of the installation of the GitHub App on the owner of the repository")
+	bootstrapGitHubCmd.Flags().StringVar(&githubArgs.appPrivateKeyFile, "github-app-private-key-file", "", "path to the PEM encoded private key of the GitHub App")
 
 	bootstrapCmd.AddCommand(bootstrapGitHubCmd)
 }
 
 func bootstrapGitHubCmdRun(cmd *cobra.Command, args []string) error {
+	githubApp := githubArgs.appID != "" || githubArgs.appInstallationID != "" || githubArgs.appPrivateKeyFile != ""
 	ghToken := os.Getenv(ghTokenEnvVar)
-	if ghToken == "" {
+	if ghToken == "" && !githubApp {
 		var err error
 		ghToken, err = readPasswordFromStdin("Please enter your GitHub personal access token (PAT): ")
 		if err != nil {
@@ -116,14 +135,18 @@ func bootstrapGitHubCmdRun(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	setGitConfigDefaults(cmd)
 	if err := bootstrapValidate(); err != nil {
 		return err
 	}
+	if err := validateWebhookReceiver(githubArgs.withWebhookReceiver, githubArgs.webhookReceiverURL, bootstrapComponents()); err != nil {
+		return err
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(kubeconfigArgs, kubeclientOptions)
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
 	if err != nil {
 		return err
 	}
@@ -140,20 +163,25 @@ func bootstrapGitHubCmdRun(cmd *cobra.Command, args []string) error {
 	}
 	defer os.RemoveAll(manifestsBase)
 
-	var caBundle []byte
-	if bootstrapArgs.caFile != "" {
-		var err error
-		caBundle, err = os.ReadFile(bootstrapArgs.caFile)
+	caBundle, err := readCAFile(bootstrapArgs.caFile)
+	if err != nil {
+		return err
+	}
+	var appPrivateKey []byte
+	if githubApp {
+		ghToken, appPrivateKey, err = githubAppToken(ctx, caBundle)
 		if err != nil {
-			return fmt.Errorf("unable to read TLS CA file: %w", err)
+			return err
 		}
 	}
+
 	// Build GitHub provider
 	providerCfg := provider.Config{
 		Provider: provider.GitProviderGitHub,
 		Hostname: githubArgs.hostname,
 		Token:    ghToken,
 		CaBundle: caBundle,
+		Logger:   logger,
 	}
 	providerClient, err := provider.BuildGitProvider(providerCfg)
 	if err != nil {
@@ -167,12 +195,13 @@ func bootstrapGitHubCmdRun(cmd *cobra.Command, args []string) error {
 	defer os.RemoveAll(tmpDir)
 
 	clientOpts := []gogit.ClientOption{gogit.WithDiskStorage(), gogit.WithFallbackToDefaultKnownHosts()}
-	gitClient, err := gogit.NewClient(tmpDir, &git.AuthOptions{
+	newGitClient := gitClientFactory(&git.AuthOptions{
 		Transport: git.HTTPS,
-		Username:  githubArgs.owner,
+		Username:  githubGitUsername(githubApp),
 		Password:  ghToken,
 		CAFile:    caBundle,
-	}, clientOpts...)
+	}, clientOpts)
+	gitClient, err := newGitClient(tmpDir)
 	if err != nil {
 		return fmt.Errorf("failed to create a Git client: %w", err)
 	}
@@ -187,13 +216,19 @@ func bootstrapGitHubCmdRun(cmd *cobra.Command, args []string) error {
 		ImagePullSecret:        bootstrapArgs.imagePullSecret,
 		WatchAllNamespaces:     bootstrapArgs.watchAllNamespaces,
 		NetworkPolicy:          bootstrapArgs.networkPolicy,
+		NetworkPolicyCIDRs:     bootstrapArgs.networkPolicyCIDRs,
 		LogLevel:               bootstrapArgs.logLevel.String(),
 		NotificationController: rootArgs.defaults.NotificationController,
-		ManifestFile:           rootArgs.defaults.ManifestFile,
+		ManifestFile:           bootstrapArgs.componentsFile,
 		Timeout:                rootArgs.timeout,
 		TargetPath:             githubArgs.path.ToSlash(),
 		ClusterDomain:          bootstrapArgs.clusterDomain,
 		TolerationKeys:         bootstrapArgs.tolerationKeys,
+		NodeSelector:           bootstrapArgs.nodeSelector,
+		Affinity:               bootstrapArgs.affinity,
+		PriorityClassName:      bootstrapArgs.priorityClass,
+		PodSecurity:            bootstrapArgs.podSecurity,
+		SeccompProfile:         bootstrapArgs.seccompProfile,
 	}
 	if customBaseURL := bootstrapArgs.manifestsPath; customBaseURL != "" {
 		installOptions.BaseURL = customBaseURL
@@ -205,8 +240,25 @@ func bootstrapGitHubCmdRun(cmd *cobra.Command, args []string) error {
 		Namespace:    *kubeconfigArgs.Namespace,
 		TargetPath:   githubArgs.path.ToSlash(),
 		ManifestFile: sourcesecret.MakeDefaultOptions().ManifestFile,
+		Proxy:        bootstrapArgs.proxy,
 	}
-	if bootstrapArgs.tokenAuth {
+	var syncProvider string
+	if bootstrapArgs.tokenAuth && githubApp {
+		// Installation tokens expire, source-controller mints them
+		// with the App credentials instead. The token is only kept in
+		// the secret if the installed GitRepository API lacks the
+		// GitHub provider.
+		secretOpts.Username = "git"
+		secretOpts.Password = ghToken
+		secretOpts.GitHubAppID = githubArgs.appID
+		secretOpts.GitHubAppInstallationID = githubArgs.appInstallationID
+		secretOpts.GitHubAppPrivateKey = appPrivateKey
+		if githubArgs.hostname != ghDefaultDomain {
+			secretOpts.GitHubAppBaseURL = provider.GitHubAppBaseURL(githubArgs.hostname)
+		}
+		secretOpts.CAFile = caBundle
+		syncProvider = sync.GitHubProvider
+	} else if bootstrapArgs.tokenAuth {
 		secretOpts.Username = "git"
 		secretOpts.Password = ghToken
 		secretOpts.CAFile = caBundle
@@ -229,27 +281,42 @@ func bootstrapGitHubCmdRun(cmd *cobra.Command, args []string) error {
 		Branch:            bootstrapArgs.branch,
 		Secret:            bootstrapArgs.secretName,
 		TargetPath:        githubArgs.path.ToSlash(),
-		ManifestFile:      sync.MakeDefaultOptions().ManifestFile,
+		ManifestFile:      bootstrapArgs.syncFile,
 		RecurseSubmodules: bootstrapArgs.recurseSubmodules,
+		Provider:          syncProvider,
+	}
+	if bootstrapArgs.withSOPSAge {
+		syncOpts.DecryptionSecret = bootstrapArgs.sopsAgeSecret
 	}
 
 	entityList, err := bootstrap.LoadEntityListFromPath(bootstrapArgs.gpgKeyRingPath)
 	if err != nil {
 		return err
 	}
+	sshSigner, err := bootstrap.LoadSSHSignerFromPath(bootstrapArgs.sshSigningKeyFile, bootstrapArgs.sshSigningKeyPassword)
+	if err != nil {
+		return err
+	}
 
 	// Bootstrap config
 	bootstrapOpts := []bootstrap.GitProviderOption{
+		bootstrap.WithGitClientFactory(newGitClient),
 		bootstrap.WithProviderRepository(githubArgs.owner, githubArgs.repository, githubArgs.personal),
 		bootstrap.WithBranch(bootstrapArgs.branch),
 		bootstrap.WithBootstrapTransportType("https"),
 		bootstrap.WithSignature(bootstrapArgs.authorName, bootstrapArgs.authorEmail),
 		bootstrap.WithCommitMessageAppendix(bootstrapArgs.commitMessageAppendix),
+		bootstrap.WithSignoff(bootstrapArgs.signoff),
+		bootstrap.WithShallowClone(bootstrapArgs.shallowClone),
 		bootstrap.WithProviderTeamPermissions(mapTeamSlice(githubArgs.teams, ghDefaultPermission)),
 		bootstrap.WithReadWriteKeyPermissions(githubArgs.readWriteKey),
 		bootstrap.WithKubeconfig(kubeconfigArgs, kubeclientOptions),
 		bootstrap.WithLogger(logger),
 		bootstrap.WithGitCommitSigning(entityList, bootstrapArgs.gpgPassphrase, bootstrapArgs.gpgKeyID),
+		bootstrap.WithSSHCommitSigning(sshSigner),
+	}
+	if !cmd.Flags().Changed("branch") {
+		bootstrapOpts = append(bootstrapOpts, bootstrap.WithDefaultBranchDetection())
 	}
 	if bootstrapArgs.sshHostname != "" {
 		bootstrapOpts = append(bootstrapOpts, bootstrap.WithSSHHostname(bootstrapArgs.sshHostname))
@@ -263,6 +330,16 @@ func bootstrapGitHubCmdRun(cmd *cobra.Command, args []string) error {
 	if githubArgs.reconcile {
 		bootstrapOpts = append(bootstrapOpts, bootstrap.WithReconcile())
 	}
+	if githubArgs.withWebhookReceiver {
+		bootstrapOpts = append(bootstrapOpts, bootstrap.WithWebhookReceiver(githubArgs.webhookReceiverURL))
+	}
+
+	if bootstrapArgs.secretRefExisting {
+		bootstrapOpts = append(bootstrapOpts, bootstrap.WithExistingSourceSecret())
+	}
+	if bootstrapArgs.withSOPSAge {
+		bootstrapOpts = append(bootstrapOpts, bootstrap.WithSOPSAge())
+	}
 
 	// Setup bootstrapper with constructed configs
 	b, err := bootstrap.NewGitProviderBootstrapper(gitClient, providerClient, kubeClient, bootstrapOpts...)
@@ -271,5 +348,48 @@ func bootstrapGitHubCmdRun(cmd *cobra.Command, args []string) error {
 	}
 
 	// Run
-	return bootstrap.Run(ctx, b, manifestsBase, installOptions, secretOpts, syncOpts, rootArgs.pollInterval, rootArgs.timeout)
+	if err := bootstrap.Run(ctx, b, manifestsBase, installOptions, secretOpts, syncOpts, rootArgs.pollInterval, rootArgs.timeout); err != nil {
+		return err
+	}
+
+	if err := runPostBootstrapHooks(b.RepositoryURL(), syncOpts); err != nil {
+		return err
+	}
+
+	return writeBootstrapSummary(ctx, cmd.OutOrStdout(), kubeClient, b.RepositoryURL(), b.Commits(), installOptions, syncOpts)
+}
+
+// githubAppToken mints an installation token for the GitHub App given with
+// flags, and returns it along with the private key of the App.
+func githubAppToken(ctx context.Context, caBundle []byte) (string, []byte, error) {
+	if githubArgs.appID == "" || githubArgs.appInstallationID == "" || githubArgs.appPrivateKeyFile == "" {
+		return "", nil, fmt.Errorf("--github-app-id, --github-app-installation-id and --github-app-private-key-file are required to authenticate with a GitHub App")
+	}
+	privateKey, err := os.ReadFile(githubArgs.appPrivateKeyFile)
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to read GitHub App private key: %w", err)
+	}
+
+	logger.Actionf("requesting an installation token for GitHub App %s", githubArgs.appID)
+	token, err := provider.GitHubAppToken(ctx, provider.GitHubAppConfig{
+		AppID:          githubArgs.appID,
+		InstallationID: githubArgs.appInstallationID,
+		PrivateKey:     privateKey,
+		BaseURL:        provider.GitHubAppBaseURL(githubArgs.hostname),
+		CaBundle:       caBundle,
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	logger.Successf("GitHub App installation token obtained")
+	return token, privateKey, nil
+}
+
+// githubGitUsername returns the username to push with, GitHub requires
+// the x-access-token user for the installation tokens of GitHub Apps.
+func githubGitUsername(githubApp bool) string {
+	if githubApp {
+		return "x-access-token"
+	}
+	return githubArgs.owner
 }
@@ -0,0 +1,180 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/fluxcd/flux2/internal/utils"
+	"github.com/fluxcd/flux2/pkg/manifestgen/sync"
+)
+
+// postBootstrapConfig is the format of the file given with
+// --post-bootstrap-config.
+type postBootstrapConfig struct {
+	Commands  []string `json:"commands,omitempty"`
+	Manifests []string `json:"manifests,omitempty"`
+}
+
+// postBootstrapValues are the values available to the templated
+// post-bootstrap commands. The values are shell-quoted when rendered, as
+// the branch and path of the repository may contain shell metacharacters.
+type postBootstrapValues struct {
+	URL       string
+	Branch    string
+	Path      string
+	Namespace string
+}
+
+// env returns the values as environment variables for the commands.
+func (v postBootstrapValues) env() []string {
+	return []string{
+		"FLUX_BOOTSTRAP_URL=" + v.URL,
+		"FLUX_BOOTSTRAP_BRANCH=" + v.Branch,
+		"FLUX_BOOTSTRAP_PATH=" + v.Path,
+		"FLUX_BOOTSTRAP_NAMESPACE=" + v.Namespace,
+	}
+}
+
+// loadPostBootstrapConfig merges the hooks of the --post-bootstrap-config
+// file with the ones given with flags.
+func loadPostBootstrapConfig() (postBootstrapConfig, error) {
+	cfg := postBootstrapConfig{
+		Commands:  bootstrapArgs.postBootstrapCommands,
+		Manifests: bootstrapArgs.postBootstrapManifests,
+	}
+	if bootstrapArgs.postBootstrapConfig == "" {
+		return cfg, nil
+	}
+
+	data, err := os.ReadFile(bootstrapArgs.postBootstrapConfig)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read post-bootstrap config: %w", err)
+	}
+	var fileCfg postBootstrapConfig
+	if err := yaml.UnmarshalStrict(data, &fileCfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse post-bootstrap config: %w", err)
+	}
+	cfg.Commands = append(cfg.Commands, fileCfg.Commands...)
+	for _, m := range fileCfg.Manifests {
+		// Manifest paths are relative to the config file
+		if !filepath.IsAbs(m) {
+			m = filepath.Join(filepath.Dir(bootstrapArgs.postBootstrapConfig), m)
+		}
+		cfg.Manifests = append(cfg.Manifests, m)
+	}
+	return cfg, nil
+}
+
+// runPostBootstrapHooks applies the post-bootstrap manifests and executes
+// the post-bootstrap commands, in that order. The hooks have their own
+// timeout, as the bootstrap may have used up most of the --timeout.
+func runPostBootstrapHooks(url string, syncOpts sync.Options) error {
+	cfg, err := loadPostBootstrapConfig()
+	if err != nil {
+		return err
+	}
+	if len(cfg.Commands) == 0 && len(cfg.Manifests) == 0 {
+		return nil
+	}
+
+	values := postBootstrapValues{
+		URL:       url,
+		Branch:    syncOpts.Branch,
+		Path:      syncOpts.TargetPath,
+		Namespace: syncOpts.Namespace,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), bootstrapArgs.postBootstrapTimeout)
+	defer cancel()
+
+	for _, manifest := range cfg.Manifests {
+		path, err := filepath.Abs(manifest)
+		if err != nil {
+			return err
+		}
+		logger.Actionf("applying post-bootstrap manifests %s", manifest)
+		root := filepath.Dir(path)
+		if fi, err := os.Stat(path); err == nil && fi.IsDir() {
+			root = path
+		}
+		changeSet, err := utils.Apply(ctx, kubeconfigArgs, kubeclientOptions, root, path)
+		if err != nil {
+			return fmt.Errorf("failed to apply post-bootstrap manifests %s: %w", manifest, err)
+		}
+		fmt.Fprintln(os.Stderr, changeSet)
+	}
+
+	for _, command := range cfg.Commands {
+		rendered, err := renderPostBootstrapCommand(command, values)
+		if err != nil {
+			return err
+		}
+		logger.Actionf("running post-bootstrap command: %s", rendered)
+		cmd := exec.CommandContext(ctx, "sh", "-c", rendered)
+		cmd.Env = append(os.Environ(), values.env()...)
+		cmd.Stdout = os.Stderr
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("post-bootstrap command '%s' failed: %w", rendered, err)
+		}
+	}
+
+	logger.Successf("post-bootstrap hooks completed")
+	return nil
+}
+
+// renderPostBootstrapCommand executes the command template with the
+// shell-quoted values.
+func renderPostBootstrapCommand(command string, values postBootstrapValues) (string, error) {
+	tmpl, err := template.New("command").Option("missingkey=error").Parse(command)
+	if err != nil {
+		return "", fmt.Errorf("invalid post-bootstrap command '%s': %w", command, err)
+	}
+	quoted := postBootstrapValues{
+		URL:       shellQuote(values.URL),
+		Branch:    shellQuote(values.Branch),
+		Path:      shellQuote(values.Path),
+		Namespace: shellQuote(values.Namespace),
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, quoted); err != nil {
+		return "", fmt.Errorf("invalid post-bootstrap command '%s': %w", command, err)
+	}
+	return buf.String(), nil
+}
+
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// shellQuote returns the value quoted for a POSIX shell, values made only
+// of safe characters are returned as is.
+func shellQuote(s string) string {
+	if shellSafe.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
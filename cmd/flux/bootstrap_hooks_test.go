@@ -0,0 +1,161 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fluxcd/flux2/pkg/manifestgen/sync"
+)
+
+func TestRenderPostBootstrapCommand(t *testing.T) {
+	values := postBootstrapValues{
+		URL:       "ssh://git@github.com/org/fleet",
+		Branch:    "main",
+		Path:      "clusters/staging",
+		Namespace: "flux-system",
+	}
+
+	tests := []struct {
+		name    string
+		command string
+		values  postBootstrapValues
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "plain command",
+			command: "echo done",
+			want:    "echo done",
+		},
+		{
+			name:    "templated command",
+			command: "register --repo {{ .URL }}@{{ .Branch }} --path {{ .Path }} -n {{ .Namespace }}",
+			want:    "register --repo ssh://git@github.com/org/fleet@main --path clusters/staging -n flux-system",
+		},
+		{
+			name:    "quoted values",
+			command: "register {{ .Branch }} {{ .Path }}",
+			values: postBootstrapValues{
+				Branch: "main;touch pwned",
+				Path:   "clusters/it's $(id)",
+			},
+			want: `register 'main;touch pwned' 'clusters/it'"'"'s $(id)'`,
+		},
+		{
+			name:    "unknown field",
+			command: "echo {{ .Cluster }}",
+			wantErr: true,
+		},
+		{
+			name:    "invalid template",
+			command: "echo {{ .URL",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := values
+			if tt.values != (postBootstrapValues{}) {
+				v = tt.values
+			}
+			got, err := renderPostBootstrapCommand(tt.command, v)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadPostBootstrapConfig(t *testing.T) {
+	dir := t.TempDir()
+	cfgPath := filepath.Join(dir, "hooks.yaml")
+	if err := os.WriteFile(cfgPath, []byte(`commands:
+  - echo {{ .URL }}
+manifests:
+  - inventory.yaml
+  - /abs/path.yaml
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() { bootstrapArgs = NewBootstrapFlags() }()
+	bootstrapArgs.postBootstrapCommands = []string{"echo flag"}
+	bootstrapArgs.postBootstrapManifests = []string{"extra.yaml"}
+	bootstrapArgs.postBootstrapConfig = cfgPath
+
+	cfg, err := loadPostBootstrapConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Commands) != 2 || cfg.Commands[0] != "echo flag" || cfg.Commands[1] != "echo {{ .URL }}" {
+		t.Errorf("unexpected commands: %v", cfg.Commands)
+	}
+	wantManifests := []string{"extra.yaml", filepath.Join(dir, "inventory.yaml"), "/abs/path.yaml"}
+	if len(cfg.Manifests) != len(wantManifests) {
+		t.Fatalf("unexpected manifests: %v", cfg.Manifests)
+	}
+	for i := range wantManifests {
+		if cfg.Manifests[i] != wantManifests[i] {
+			t.Errorf("manifest %d: got %q, want %q", i, cfg.Manifests[i], wantManifests[i])
+		}
+	}
+
+	if err := os.WriteFile(cfgPath, []byte("hooks: []\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadPostBootstrapConfig(); err == nil {
+		t.Error("expected error for unknown config field")
+	}
+}
+
+func TestRunPostBootstrapHooks(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out")
+
+	defer func() { bootstrapArgs = NewBootstrapFlags() }()
+	bootstrapArgs.postBootstrapCommands = []string{
+		"printf '%s\\n' {{ .Branch }} \"$FLUX_BOOTSTRAP_BRANCH\" > " + out,
+	}
+
+	branch := "main;touch " + filepath.Join(dir, "pwned")
+	if err := runPostBootstrapHooks("ssh://git@github.com/org/fleet", sync.Options{Branch: branch}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := branch + "\n" + branch + "\n"; string(data) != want {
+		t.Errorf("got %q, want %q", data, want)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "pwned")); err == nil {
+		t.Error("expected the branch not to be interpreted by the shell")
+	}
+
+	bootstrapArgs.postBootstrapCommands = []string{"sleep 1"}
+	bootstrapArgs.postBootstrapTimeout = 100 * time.Millisecond
+	if err := runPostBootstrapHooks("ssh://git@github.com/org/fleet", sync.Options{}); err == nil {
+		t.Error("expected the command to be killed after the hooks timeout")
+	}
+}
@@ -0,0 +1,217 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	"github.com/fluxcd/pkg/git"
+	"github.com/fluxcd/pkg/git/gogit"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+
+	"github.com/fluxcd/flux2/internal/flags"
+	"github.com/fluxcd/flux2/pkg/bootstrap"
+	"github.com/fluxcd/flux2/pkg/manifestgen"
+	"github.com/fluxcd/flux2/pkg/manifestgen/sync"
+)
+
+var bootstrapMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Move the Flux manifests of a bootstrapped cluster to a new path or branch",
+	Long: `The bootstrap migrate command moves the manifests of a bootstrapped cluster to
+a new path or branch of its Git repository. The sync manifests are rewritten for the new
+location in the same commit, and the GitRepository and Kustomization on the cluster are
+updated to sync from it.
+
+The repository and its credentials are read from the GitRepository and its secret on the cluster.
+When the current or the new path is the root of the repository, only the directory of the
+Flux manifests is moved. Otherwise, the whole directory of the current path is moved.
+As the cluster sync prunes, moving from the root stops syncing the other manifests of the
+repository and deletes their objects, and moving to the root syncs all the manifests of the
+repository. The command lists these manifests and fails in both cases, unless --force is set.`,
+	Example: `  # Move the manifests of a cluster bootstrapped at the root of the repository to clusters/prod
+  flux bootstrap migrate --path=clusters/prod
+
+  # Sync the cluster from the production branch, created from the current branch if it does not exist
+  flux bootstrap migrate --branch=production
+
+  # Move the manifests from the root, pruning the objects of the other manifests of the repository
+  flux bootstrap migrate --path=clusters/prod --force
+
+  # Move the manifests to another path on another branch
+  flux bootstrap migrate --path=clusters/prod --branch=production`,
+	RunE: bootstrapMigrateCmdRun,
+}
+
+type bootstrapMigrateFlags struct {
+	path                flags.SafeRelativePath
+	insecureHttpAllowed bool
+	force               bool
+}
+
+var bootstrapMigrateArgs bootstrapMigrateFlags
+
+func init() {
+	bootstrapMigrateCmd.Flags().Var(&bootstrapMigrateArgs.path, "path",
+		"path relative to the repository root the manifests are moved to, defaults to the current path of the cluster")
+	bootstrapMigrateCmd.Flags().BoolVar(&bootstrapMigrateArgs.insecureHttpAllowed, "allow-insecure-http", false,
+		"allows insecure HTTP connections")
+	bootstrapMigrateCmd.Flags().BoolVar(&bootstrapMigrateArgs.force, "force", false,
+		"migrate from or to the root of the repository even though the other manifests of the repository stop or start being synced")
+
+	bootstrapCmd.AddCommand(bootstrapMigrateCmd)
+}
+
+func bootstrapMigrateCmdRun(cmd *cobra.Command, args []string) error {
+	setGitConfigDefaults(cmd)
+	if bootstrapArgs.sshSigningKeyFile != "" && bootstrapArgs.gpgKeyRingPath != "" {
+		return fmt.Errorf("--ssh-signing-key-file and --gpg-key-ring are mutually exclusive")
+	}
+	if bootstrapArgs.signoff && bootstrapArgs.authorEmail == "" {
+		return fmt.Errorf("an author email is required to sign off commits, set --author-email or user.email in the Git config")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
+	if err != nil {
+		return err
+	}
+
+	// The cluster is synced by the objects named after the namespace
+	objKey := client.ObjectKey{Name: *kubeconfigArgs.Namespace, Namespace: *kubeconfigArgs.Namespace}
+	var repo sourcev1.GitRepository
+	if err := kubeClient.Get(ctx, objKey, &repo); err != nil {
+		return fmt.Errorf("unable to get GitRepository %s, is the cluster bootstrapped: %w", objKey, err)
+	}
+	var ks kustomizev1.Kustomization
+	if err := kubeClient.Get(ctx, objKey, &ks); err != nil {
+		return fmt.Errorf("unable to get Kustomization %s, is the cluster bootstrapped: %w", objKey, err)
+	}
+
+	currentBranch := bootstrapDefaultBranch
+	if repo.Spec.Reference != nil && repo.Spec.Reference.Branch != "" {
+		currentBranch = repo.Spec.Reference.Branch
+	}
+	targetBranch := currentBranch
+	if cmd.Flags().Changed("branch") {
+		targetBranch = bootstrapArgs.branch
+	}
+	currentPath := path.Clean(strings.TrimPrefix(ks.Spec.Path, "./"))
+	targetPath := currentPath
+	if bootstrapMigrateArgs.path != "" {
+		targetPath = path.Clean(bootstrapMigrateArgs.path.ToSlash())
+	}
+	if targetPath == currentPath && targetBranch == currentBranch {
+		return fmt.Errorf("the cluster is already synced from path %q on branch %q, set --path or --branch to migrate it", currentPath, currentBranch)
+	}
+
+	// Authenticate with the credentials source-controller uses
+	repositoryURL, err := url.Parse(repo.Spec.URL)
+	if err != nil {
+		return fmt.Errorf("invalid URL of GitRepository %s: %w", objKey, err)
+	}
+	var secretData map[string][]byte
+	if repo.Spec.SecretRef != nil {
+		var secret corev1.Secret
+		secretKey := client.ObjectKey{Name: repo.Spec.SecretRef.Name, Namespace: repo.Namespace}
+		if err := kubeClient.Get(ctx, secretKey, &secret); err != nil {
+			return fmt.Errorf("unable to get the source secret %s: %w", secretKey, err)
+		}
+		secretData = secret.Data
+	}
+	authOpts, err := git.NewAuthOptions(*repositoryURL, secretData)
+	if err != nil {
+		return fmt.Errorf("failed to create authentication options for %s: %w", repositoryURL.Redacted(), err)
+	}
+
+	tmpDir, err := manifestgen.MkdirTempAbs("", "flux-bootstrap-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary working dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	clientOpts := []gogit.ClientOption{gogit.WithDiskStorage(), gogit.WithFallbackToDefaultKnownHosts()}
+	if bootstrapMigrateArgs.insecureHttpAllowed {
+		clientOpts = append(clientOpts, gogit.WithInsecureCredentialsOverHTTP())
+	}
+	newGitClient := gitClientFactory(authOpts, clientOpts)
+	gitClient, err := newGitClient(tmpDir)
+	if err != nil {
+		return fmt.Errorf("failed to create a Git client: %w", err)
+	}
+
+	entityList, err := bootstrap.LoadEntityListFromPath(bootstrapArgs.gpgKeyRingPath)
+	if err != nil {
+		return err
+	}
+	sshSigner, err := bootstrap.LoadSSHSignerFromPath(bootstrapArgs.sshSigningKeyFile, bootstrapArgs.sshSigningKeyPassword)
+	if err != nil {
+		return err
+	}
+
+	b, err := bootstrap.NewPlainGitProvider(gitClient, kubeClient,
+		bootstrap.WithGitClientFactory(newGitClient),
+		bootstrap.WithRepositoryURL(repositoryURL.String()),
+		bootstrap.WithBranch(currentBranch),
+		bootstrap.WithSignature(bootstrapArgs.authorName, bootstrapArgs.authorEmail),
+		bootstrap.WithCommitMessageAppendix(bootstrapArgs.commitMessageAppendix),
+		bootstrap.WithSignoff(bootstrapArgs.signoff),
+		bootstrap.WithShallowClone(bootstrapArgs.shallowClone),
+		bootstrap.WithKubeconfig(kubeconfigArgs, kubeclientOptions),
+		bootstrap.WithLogger(logger),
+		bootstrap.WithGitCommitSigning(entityList, bootstrapArgs.gpgPassphrase, bootstrapArgs.gpgKeyID),
+		bootstrap.WithSSHCommitSigning(sshSigner),
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Migrate(ctx, bootstrap.MigrateOptions{
+		Name:         objKey.Name,
+		Namespace:    objKey.Namespace,
+		Path:         currentPath,
+		TargetPath:   targetPath,
+		TargetBranch: targetBranch,
+		SyncFile:     bootstrapArgs.syncFile,
+		Force:        bootstrapMigrateArgs.force,
+	}); err != nil {
+		if errors.Is(err, bootstrap.ErrUnmigratedManifests) {
+			return fmt.Errorf("%w, move them or set --force to migrate anyway", err)
+		}
+		return err
+	}
+
+	syncOpts := sync.Options{
+		Name:      objKey.Name,
+		Namespace: objKey.Namespace,
+		Branch:    targetBranch,
+	}
+	return b.ReportKustomizationHealth(ctx, syncOpts, rootArgs.pollInterval, rootArgs.timeout)
+}
@@ -0,0 +1,247 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/spf13/cobra"
+
+	oci "github.com/fluxcd/pkg/oci/client"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+
+	"github.com/fluxcd/flux2/internal/flags"
+	"github.com/fluxcd/flux2/pkg/bootstrap"
+	"github.com/fluxcd/flux2/pkg/manifestgen"
+	"github.com/fluxcd/flux2/pkg/manifestgen/install"
+	"github.com/fluxcd/flux2/pkg/manifestgen/sourcesecret"
+	"github.com/fluxcd/flux2/pkg/manifestgen/sync"
+)
+
+var bootstrapOCICmd = &cobra.Command{
+	Use:   "oci",
+	Short: "Deploy Flux on a cluster synchronized with an OCI artifact",
+	Long: `The bootstrap oci command adds the Flux manifests to an OCI artifact and pushes it
+to a container registry. And then it configures the target cluster to synchronize with
+that artifact using an OCIRepository. The content of an existing artifact is preserved.
+If the Flux components are present on the cluster, the bootstrap command will perform
+an upgrade if needed.
+
+The command can read the credentials from '~/.docker/config.json' but they can also be passed with --creds,
+in which case they are also stored in a secret used by the cluster to pull the artifact.
+It can also login to a supported provider with the --provider flag.`,
+	Example: `  # Run bootstrap for a public artifact on GHCR, using the local Docker credentials to push
+  flux bootstrap oci --url=oci://ghcr.io/org/fleet --path=clusters/my-cluster
+
+  # Run bootstrap for a private artifact, the credentials are also used by the cluster to pull
+  flux bootstrap oci --url=oci://registry.example.com/fleet --tag=production --creds=flux:$REGISTRY_TOKEN --path=clusters/my-cluster
+
+  # Run bootstrap for an artifact on ECR, the cluster authenticates with workload identity
+  flux bootstrap oci --url=oci://<account>.dkr.ecr.<region>.amazonaws.com/fleet --provider=aws --path=clusters/my-cluster`,
+	RunE: bootstrapForContexts(&ociArgs.path, bootstrapOCICmdRun),
+}
+
+type ociFlags struct {
+	url      string
+	tag      string
+	interval time.Duration
+	path     flags.SafeRelativePath
+	creds    string
+	provider flags.SourceOCIProvider
+}
+
+var ociArgs = newOCIFlags()
+
+func newOCIFlags() ociFlags {
+	return ociFlags{
+		tag:      "latest",
+		interval: time.Minute,
+		provider: flags.SourceOCIProvider(sourcev1.GenericOCIProvider),
+	}
+}
+
+func init() {
+	bootstrapOCICmd.Flags().StringVar(&ociArgs.url, "url", "", "URL of the OCI repository in the format oci://<registry>/<repository>")
+	bootstrapOCICmd.Flags().StringVar(&ociArgs.tag, "tag", ociArgs.tag, "tag of the artifact the manifests are pushed to and the cluster syncs from")
+	bootstrapOCICmd.Flags().DurationVar(&ociArgs.interval, "interval", ociArgs.interval, "sync interval")
+	bootstrapOCICmd.Flags().Var(&ociArgs.path, "path", "path relative to the artifact root, when specified the cluster sync will be scoped to this path")
+	bootstrapOCICmd.Flags().StringVar(&ociArgs.creds, "creds", "", "credentials for the OCI registry in the format <username>[:<password>] if --provider is generic")
+	bootstrapOCICmd.Flags().Var(&ociArgs.provider, "provider", ociArgs.provider.Description())
+
+	bootstrapCmd.AddCommand(bootstrapOCICmd)
+}
+
+func bootstrapOCICmdRun(cmd *cobra.Command, args []string) error {
+	if err := bootstrapValidate(); err != nil {
+		return err
+	}
+
+	if ociArgs.url == "" {
+		return fmt.Errorf("--url is required")
+	}
+	if bootstrapArgs.withSOPSAge {
+		return fmt.Errorf("--with-sops-age is only supported for Git repositories")
+	}
+	repositoryURL, err := oci.ParseRepositoryURL(ociArgs.url)
+	if err != nil {
+		return err
+	}
+	if sourcev1.OCIRepositoryPrefix+repositoryURL != ociArgs.url {
+		return fmt.Errorf("--url must not contain a tag or digest, set the tag with --tag")
+	}
+	ref, err := name.ParseReference(repositoryURL)
+	if err != nil {
+		return err
+	}
+	if ociArgs.tag == "" {
+		return fmt.Errorf("--tag is required")
+	}
+	genericProvider := ociArgs.provider.String() == sourcev1.GenericOCIProvider
+	if ociArgs.creds != "" && !genericProvider {
+		return fmt.Errorf("--creds can't be used with the %s provider", ociArgs.provider.String())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
+	if err != nil {
+		return err
+	}
+
+	// Manifest base
+	if ver, err := getVersion(bootstrapArgs.version); err != nil {
+		return err
+	} else {
+		bootstrapArgs.version = ver
+	}
+	manifestsBase, err := buildEmbeddedManifestBase()
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(manifestsBase)
+
+	// Artifact content
+	tmpDir, err := manifestgen.MkdirTempAbs("", "flux-bootstrap-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary working dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	ociClient := oci.NewLocalClient()
+	if ociArgs.creds != "" {
+		logger.Actionf("logging in to registry with credentials")
+		if err := ociClient.LoginWithCredentials(ociArgs.creds); err != nil {
+			return fmt.Errorf("could not login with credentials: %w", err)
+		}
+	}
+	if !genericProvider {
+		logger.Actionf("logging in to registry with provider credentials")
+		ociProvider, err := ociArgs.provider.ToOCIProvider()
+		if err != nil {
+			return fmt.Errorf("provider not supported: %w", err)
+		}
+		if err := ociClient.LoginWithProvider(ctx, repositoryURL, ociProvider); err != nil {
+			return fmt.Errorf("error during login with provider: %w", err)
+		}
+	}
+
+	// Install manifest config
+	installOptions := install.Options{
+		BaseURL:                rootArgs.defaults.BaseURL,
+		Version:                bootstrapArgs.version,
+		Namespace:              *kubeconfigArgs.Namespace,
+		Components:             bootstrapComponents(),
+		Registry:               bootstrapArgs.registry,
+		ImagePullSecret:        bootstrapArgs.imagePullSecret,
+		WatchAllNamespaces:     bootstrapArgs.watchAllNamespaces,
+		NetworkPolicy:          bootstrapArgs.networkPolicy,
+		NetworkPolicyCIDRs:     bootstrapArgs.networkPolicyCIDRs,
+		LogLevel:               bootstrapArgs.logLevel.String(),
+		NotificationController: rootArgs.defaults.NotificationController,
+		ManifestFile:           bootstrapArgs.componentsFile,
+		Timeout:                rootArgs.timeout,
+		TargetPath:             ociArgs.path.ToSlash(),
+		ClusterDomain:          bootstrapArgs.clusterDomain,
+		TolerationKeys:         bootstrapArgs.tolerationKeys,
+		NodeSelector:           bootstrapArgs.nodeSelector,
+		Affinity:               bootstrapArgs.affinity,
+		PriorityClassName:      bootstrapArgs.priorityClass,
+		PodSecurity:            bootstrapArgs.podSecurity,
+		SeccompProfile:         bootstrapArgs.seccompProfile,
+	}
+	if customBaseURL := bootstrapArgs.manifestsPath; customBaseURL != "" {
+		installOptions.BaseURL = customBaseURL
+	}
+
+	// Image pull secret config, the cluster pulls with the same
+	// credentials the artifact is pushed with
+	secretOpts := sourcesecret.Options{
+		Name:         bootstrapArgs.secretName,
+		Namespace:    *kubeconfigArgs.Namespace,
+		TargetPath:   ociArgs.path.String(),
+		ManifestFile: sourcesecret.MakeDefaultOptions().ManifestFile,
+	}
+	var syncSecret string
+	if ociArgs.creds != "" {
+		username, password, _ := strings.Cut(ociArgs.creds, ":")
+		secretOpts.Registry = ref.Context().RegistryStr()
+		secretOpts.Username = username
+		secretOpts.Password = password
+		syncSecret = bootstrapArgs.secretName
+	}
+
+	// Sync manifest config
+	syncOpts := sync.Options{
+		Interval:     ociArgs.interval,
+		Name:         *kubeconfigArgs.Namespace,
+		Namespace:    *kubeconfigArgs.Namespace,
+		URL:          ociArgs.url,
+		Tag:          ociArgs.tag,
+		Secret:       syncSecret,
+		TargetPath:   ociArgs.path.ToSlash(),
+		ManifestFile: bootstrapArgs.syncFile,
+		Provider:     ociArgs.provider.String(),
+		SourceKind:   sourcev1.OCIRepositoryKind,
+	}
+
+	// Setup bootstrapper with constructed configs
+	b, err := bootstrap.NewOCIBootstrapper(ociClient, kubeClient, tmpDir,
+		bootstrap.WithArtifact(ociArgs.url, ociArgs.tag),
+		bootstrap.WithKubeconfig(kubeconfigArgs, kubeclientOptions),
+		bootstrap.WithLogger(logger),
+	)
+	if err != nil {
+		return err
+	}
+
+	// Run
+	if err := bootstrap.Run(ctx, b, manifestsBase, installOptions, secretOpts, syncOpts, rootArgs.pollInterval, rootArgs.timeout); err != nil {
+		return err
+	}
+
+	if err := runPostBootstrapHooks(b.URL(), syncOpts); err != nil {
+		return err
+	}
+
+	return writeBootstrapSummary(ctx, cmd.OutOrStdout(), kubeClient, b.URL(), nil, installOptions, syncOpts)
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// socks5Scheme is the URL scheme of SOCKS5 proxies, the only kind which
+// can tunnel the SSH transport.
+const socks5Scheme = "socks5"
+
+// parseBootstrapProxy validates the proxy URL given with --proxy, it
+// returns nil if no proxy is set.
+func parseBootstrapProxy(proxy string) (*url.URL, error) {
+	if proxy == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --proxy URL: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "https", socks5Scheme:
+	default:
+		return nil, fmt.Errorf("invalid --proxy URL %q, the scheme must be one of: http, https, %s", u.Redacted(), socks5Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid --proxy URL %q, the host is missing", u.Redacted())
+	}
+	return u, nil
+}
+
+// configureBootstrapProxy routes the HTTP requests made by bootstrap through
+// the proxy, which includes the Git operations over HTTP/S and the calls to
+// the Git provider API. The Git operations over SSH are routed through the
+// proxy when it is a SOCKS5 one.
+func configureBootstrapProxy(proxy *url.URL) error {
+	if proxy == nil {
+		return nil
+	}
+	transport, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return fmt.Errorf("failed to configure the proxy: unexpected HTTP transport %T", http.DefaultTransport)
+	}
+	transport.Proxy = http.ProxyURL(proxy)
+	if proxy.Scheme == socks5Scheme {
+		// The SSH transport dials through the proxy found in the environment.
+		if err := os.Setenv("ALL_PROXY", proxy.String()); err != nil {
+			return fmt.Errorf("failed to configure the proxy: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,276 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/fluxcd/pkg/ssa"
+
+	"github.com/fluxcd/flux2/internal/flags"
+	"github.com/fluxcd/flux2/pkg/manifestgen"
+	"github.com/fluxcd/flux2/pkg/printers"
+)
+
+var bootstrapStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Compare the bootstrap manifests in Git with the cluster",
+	Long: `The bootstrap status command compares the Flux manifests committed by bootstrap
+in a local checkout of the repository with the objects running in the cluster.
+It reports the components whose image differs, the sync objects whose spec differs,
+and whether the manifests were generated by another version of the CLI,
+which means bootstrap has to be re-run to upgrade the cluster.
+The command exits with an error when drift is detected.`,
+	Example: `  # Compare the bootstrap manifests of a cluster with the current context
+  flux bootstrap status --path=clusters/my-cluster
+
+  # Compare the manifests of a checkout in another directory
+  flux bootstrap status --repository-dir=./fleet-infra --path=clusters/my-cluster`,
+	RunE: bootstrapStatusCmdRun,
+}
+
+type bootstrapStatusFlags struct {
+	repositoryDir string
+	path          flags.SafeRelativePath
+}
+
+var bootstrapStatusArgs = bootstrapStatusFlags{
+	repositoryDir: ".",
+}
+
+func init() {
+	bootstrapStatusCmd.Flags().StringVar(&bootstrapStatusArgs.repositoryDir, "repository-dir", bootstrapStatusArgs.repositoryDir,
+		"path to a local checkout of the bootstrap repository")
+	bootstrapStatusCmd.Flags().Var(&bootstrapStatusArgs.path, "path", "path relative to the repository root the cluster was bootstrapped with")
+	bootstrapCmd.AddCommand(bootstrapStatusCmd)
+}
+
+const (
+	bootstrapStatusInSync    = "in sync"
+	bootstrapStatusDrifted   = "drifted"
+	bootstrapStatusMissing   = "missing in cluster"
+	bootstrapStatusNotInGit  = "not in Git"
+	bootstrapStatusNoVersion = "unknown"
+)
+
+var fluxVersionHeader = regexp.MustCompile(`(?m)^# Flux Version: (\S+)$`)
+
+func bootstrapStatusCmdRun(cmd *cobra.Command, args []string) error {
+	dir := filepath.Join(bootstrapStatusArgs.repositoryDir, bootstrapStatusArgs.path.String(), *kubeconfigArgs.Namespace)
+	components, err := os.ReadFile(filepath.Join(dir, bootstrapArgs.componentsFile))
+	if err != nil {
+		return fmt.Errorf("failed to read the bootstrap components: %w", err)
+	}
+	syncManifests, err := os.ReadFile(filepath.Join(dir, bootstrapArgs.syncFile))
+	if err != nil {
+		return fmt.Errorf("failed to read the bootstrap sync manifests: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
+	if err != nil {
+		return err
+	}
+
+	version := bootstrapStatusNoVersion
+	if m := fluxVersionHeader.FindSubmatch(components); m != nil {
+		version = string(m[1])
+	}
+	logger.Actionf("bootstrap manifests generated by Flux %s", version)
+	upgrade := version != rootArgs.defaults.Version
+	if upgrade {
+		logger.Warningf("the CLI version is %s, re-run bootstrap to upgrade the cluster", rootArgs.defaults.Version)
+	}
+
+	componentRows, err := bootstrapComponentsStatus(ctx, kubeClient, components)
+	if err != nil {
+		return err
+	}
+	syncRows, err := bootstrapSyncStatus(ctx, kubeClient, syncManifests)
+	if err != nil {
+		return err
+	}
+
+	rows := append(componentRows, syncRows...)
+	header := []string{"Object", "Field", "Git", "Cluster", "Status"}
+	if err := printers.TablePrinter(header).Print(cmd.OutOrStdout(), rows); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		if row[4] != bootstrapStatusInSync {
+			return fmt.Errorf("drift detected between the bootstrap manifests and the cluster")
+		}
+	}
+	if !upgrade {
+		logger.Successf("the cluster is in sync with the bootstrap manifests")
+	}
+	return nil
+}
+
+// bootstrapComponentsStatus compares the images of the controller
+// Deployments in the components manifests with the ones in the cluster.
+func bootstrapComponentsStatus(ctx context.Context, kubeClient client.Client, manifests []byte) ([][]string, error) {
+	objects, err := ssa.ReadObjects(bytes.NewReader(manifests))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the bootstrap components: %w", err)
+	}
+
+	var rows [][]string
+	inGit := map[string]bool{}
+	for _, obj := range objects {
+		if obj.GetKind() != "Deployment" {
+			continue
+		}
+		inGit[obj.GetName()] = true
+		ref := fmt.Sprintf("%s/%s", obj.GetKind(), obj.GetName())
+		gitImages := containerImages(obj)
+
+		existing, err := getUnstructured(ctx, kubeClient, obj)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				rows = append(rows, []string{ref, "image", gitImages, "-", bootstrapStatusMissing})
+				continue
+			}
+			return nil, err
+		}
+		clusterImages := containerImages(existing)
+		status := bootstrapStatusInSync
+		if gitImages != clusterImages {
+			status = bootstrapStatusDrifted
+		}
+		rows = append(rows, []string{ref, "image", gitImages, clusterImages, status})
+	}
+
+	// The components installed outside of bootstrap are reported as well.
+	var list unstructured.UnstructuredList
+	list.SetAPIVersion("apps/v1")
+	list.SetKind("DeploymentList")
+	if err := kubeClient.List(ctx, &list, client.InNamespace(*kubeconfigArgs.Namespace),
+		client.MatchingLabels{manifestgen.PartOfLabelKey: manifestgen.PartOfLabelValue}); err != nil {
+		return nil, err
+	}
+	for i := range list.Items {
+		obj := &list.Items[i]
+		if inGit[obj.GetName()] {
+			continue
+		}
+		rows = append(rows, []string{"Deployment/" + obj.GetName(), "image", "-", containerImages(obj), bootstrapStatusNotInGit})
+	}
+	return rows, nil
+}
+
+// bootstrapSyncStatus compares the spec fields set in the sync manifests
+// with the ones of the objects in the cluster. Only the fields set in Git
+// are compared, as the cluster objects hold the defaults of the API.
+func bootstrapSyncStatus(ctx context.Context, kubeClient client.Client, manifests []byte) ([][]string, error) {
+	objects, err := ssa.ReadObjects(bytes.NewReader(manifests))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the bootstrap sync manifests: %w", err)
+	}
+
+	var rows [][]string
+	for _, obj := range objects {
+		ref := fmt.Sprintf("%s/%s", obj.GetKind(), obj.GetName())
+		existing, err := getUnstructured(ctx, kubeClient, obj)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				rows = append(rows, []string{ref, "-", "-", "-", bootstrapStatusMissing})
+				continue
+			}
+			return nil, err
+		}
+
+		gitSpec, _, _ := unstructured.NestedMap(obj.Object, "spec")
+		clusterSpec, _, _ := unstructured.NestedMap(existing.Object, "spec")
+		for _, field := range sortedKeys(gitSpec) {
+			gitValue := gitSpec[field]
+			clusterValue, ok := clusterSpec[field]
+			status := bootstrapStatusInSync
+			if !ok || !equality.Semantic.DeepEqual(gitValue, clusterValue) {
+				status = bootstrapStatusDrifted
+			}
+			rows = append(rows, []string{ref, "spec." + field, compactJSON(gitValue), compactJSON(clusterValue), status})
+		}
+	}
+	return rows, nil
+}
+
+func getUnstructured(ctx context.Context, kubeClient client.Client, obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(obj.GroupVersionKind())
+	namespace := obj.GetNamespace()
+	if namespace == "" {
+		namespace = *kubeconfigArgs.Namespace
+	}
+	err := kubeClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: obj.GetName()}, existing)
+	return existing, err
+}
+
+// containerImages returns the images of the containers of a workload
+// object, separated by commas.
+func containerImages(obj *unstructured.Unstructured) string {
+	containers, _, _ := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "containers")
+	var images []string
+	for _, c := range containers {
+		if container, ok := c.(map[string]interface{}); ok {
+			if image, ok := container["image"].(string); ok {
+				images = append(images, image)
+			}
+		}
+	}
+	return strings.Join(images, ",")
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func compactJSON(v interface{}) string {
+	if v == nil {
+		return "-"
+	}
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
@@ -0,0 +1,52 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+)
+
+func TestBootstrapStatus(t *testing.T) {
+	runFakeCmdTests(t, []fakeCmdTestCase{
+		{
+			name:       "in sync",
+			args:       "bootstrap status --repository-dir=testdata/bootstrap_status/repo --path=clusters/my-cluster",
+			objectFile: "testdata/bootstrap_status/cluster.yaml",
+			goldenFile: "testdata/bootstrap_status/status.golden",
+		},
+		{
+			name:    "no bootstrap manifests",
+			args:    "bootstrap status --repository-dir=testdata/bootstrap_status/repo --path=clusters/other",
+			wantErr: "failed to read the bootstrap components: open testdata/bootstrap_status/repo/clusters/other/flux-system/gotk-components.yaml: no such file or directory",
+		},
+	})
+}
+
+func TestBootstrapStatusDrift(t *testing.T) {
+	isolateEnv(t)
+	objects := readObjectFile(t, "testdata/bootstrap_status/cluster.yaml")
+	objects = append(objects, readObjectFile(t, "testdata/bootstrap_status/cluster-extra.yaml")...)
+	useFakeCluster(t, objects...)
+
+	output, err := executeCommand("bootstrap status --repository-dir=testdata/bootstrap_status/outdated --path=clusters/my-cluster")
+	if err == nil || err.Error() != "drift detected between the bootstrap manifests and the cluster" {
+		t.Errorf("expected drift to be detected, got error: %v", err)
+	}
+	if assertErr := assertGoldenFile("testdata/bootstrap_status/drift.golden")(output, nil); assertErr != nil {
+		t.Error(assertErr)
+	}
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+
+	"github.com/fluxcd/flux2/pkg/manifestgen"
+	"github.com/fluxcd/flux2/pkg/manifestgen/install"
+	"github.com/fluxcd/flux2/pkg/manifestgen/sourcesecret"
+	"github.com/fluxcd/flux2/pkg/manifestgen/sync"
+)
+
+// bootstrapSummary is the machine-readable result of a successful
+// bootstrap, written with --summary-file.
+type bootstrapSummary struct {
+	URL            string            `json:"url"`
+	Branch         string            `json:"branch,omitempty"`
+	Path           string            `json:"path"`
+	Namespace      string            `json:"namespace"`
+	Revision       string            `json:"revision,omitempty"`
+	Commits        []string          `json:"commits,omitempty"`
+	SecretName     string            `json:"secretName,omitempty"`
+	KeyFingerprint string            `json:"keyFingerprint,omitempty"`
+	Version        string            `json:"version"`
+	Components     map[string]string `json:"components"`
+}
+
+// writeBootstrapSummary writes the bootstrap summary as JSON to the
+// --summary-file, or to w when the file is '-'. It is a no-op when no
+// summary file is given.
+func writeBootstrapSummary(ctx context.Context, w io.Writer, kubeClient client.Client, url string, commits []string,
+	installOpts install.Options, syncOpts sync.Options) error {
+	if bootstrapArgs.summaryFile == "" {
+		return nil
+	}
+
+	summary, err := newBootstrapSummary(ctx, kubeClient, url, commits, installOpts, syncOpts)
+	if err != nil {
+		return fmt.Errorf("failed to build bootstrap summary: %w", err)
+	}
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	if bootstrapArgs.summaryFile == "-" {
+		_, err = w.Write(data)
+		return err
+	}
+	if err := os.WriteFile(bootstrapArgs.summaryFile, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write bootstrap summary: %w", err)
+	}
+	logger.Successf("bootstrap summary written to %s", bootstrapArgs.summaryFile)
+	return nil
+}
+
+func newBootstrapSummary(ctx context.Context, kubeClient client.Client, url string, commits []string,
+	installOpts install.Options, syncOpts sync.Options) (*bootstrapSummary, error) {
+	summary := &bootstrapSummary{
+		URL:        url,
+		Branch:     syncOpts.Branch,
+		Path:       syncOpts.TargetPath,
+		Namespace:  syncOpts.Namespace,
+		Commits:    commits,
+		SecretName: syncOpts.Secret,
+		Version:    installOpts.Version,
+		Components: map[string]string{},
+	}
+
+	var kustomization kustomizev1.Kustomization
+	if err := kubeClient.Get(ctx, client.ObjectKey{Namespace: syncOpts.Namespace, Name: syncOpts.Name}, &kustomization); err != nil {
+		return nil, err
+	}
+	summary.Revision = kustomization.Status.LastAppliedRevision
+
+	if syncOpts.Secret != "" {
+		var secret corev1.Secret
+		err := kubeClient.Get(ctx, client.ObjectKey{Namespace: syncOpts.Namespace, Name: syncOpts.Secret}, &secret)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return nil, err
+		}
+		if pub, ok := secret.Data[sourcesecret.PublicKeySecretKey]; ok {
+			key, _, _, _, err := ssh.ParseAuthorizedKey(pub)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse public key of secret %q: %w", syncOpts.Secret, err)
+			}
+			summary.KeyFingerprint = ssh.FingerprintSHA256(key)
+		}
+	}
+
+	var deployments appsv1.DeploymentList
+	if err := kubeClient.List(ctx, &deployments, client.InNamespace(syncOpts.Namespace),
+		client.MatchingLabels{manifestgen.PartOfLabelKey: manifestgen.PartOfLabelValue}); err != nil {
+		return nil, err
+	}
+	for _, d := range deployments.Items {
+		if len(d.Spec.Template.Spec.Containers) == 0 {
+			continue
+		}
+		summary.Components[d.Name] = imageVersion(d.Spec.Template.Spec.Containers[0].Image)
+	}
+	return summary, nil
+}
+
+// imageVersion returns the tag or digest of an image reference.
+func imageVersion(image string) string {
+	if i := strings.LastIndex(image, "@"); i >= 0 {
+		return image[i+1:]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[i+1:]
+	}
+	return "latest"
+}
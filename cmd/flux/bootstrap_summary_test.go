@@ -0,0 +1,115 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"golang.org/x/crypto/ssh"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	crfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+
+	"github.com/fluxcd/flux2/internal/utils"
+	"github.com/fluxcd/flux2/pkg/manifestgen"
+	"github.com/fluxcd/flux2/pkg/manifestgen/install"
+	"github.com/fluxcd/flux2/pkg/manifestgen/sync"
+)
+
+func TestWriteBootstrapSummary(t *testing.T) {
+	g := NewWithT(t)
+	t.Cleanup(resetCmdArgs)
+
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	g.Expect(err).ToNot(HaveOccurred())
+	sshPub, err := ssh.NewPublicKey(pub)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	deployment := func(name, image string) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "flux-system",
+				Labels:    map[string]string{manifestgen.PartOfLabelKey: manifestgen.PartOfLabelValue},
+			},
+			Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "manager", Image: image}}},
+				},
+			},
+		}
+	}
+	objects := []client.Object{
+		&kustomizev1.Kustomization{
+			ObjectMeta: metav1.ObjectMeta{Name: "flux-system", Namespace: "flux-system"},
+			Status:     kustomizev1.KustomizationStatus{LastAppliedRevision: "main@sha1:abc"},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "flux-system", Namespace: "flux-system"},
+			Data:       map[string][]byte{"identity.pub": ssh.MarshalAuthorizedKey(sshPub)},
+		},
+		deployment("source-controller", "ghcr.io/fluxcd/source-controller:v1.1.0"),
+		deployment("kustomize-controller", "registry:5000/kustomize-controller@sha256:123"),
+	}
+	kubeClient := crfake.NewClientBuilder().WithScheme(utils.NewScheme()).WithObjects(objects...).Build()
+
+	syncOpts := sync.Options{
+		Name:       "flux-system",
+		Namespace:  "flux-system",
+		Branch:     "main",
+		TargetPath: "clusters/dev",
+		Secret:     "flux-system",
+	}
+	installOpts := install.Options{Version: "v2.1.0"}
+
+	var buf bytes.Buffer
+	g.Expect(writeBootstrapSummary(context.TODO(), &buf, kubeClient, "ssh://git@example.com/fleet", []string{"abc"},
+		installOpts, syncOpts)).To(Succeed())
+	g.Expect(buf.Len()).To(BeZero())
+
+	bootstrapArgs.summaryFile = "-"
+	g.Expect(writeBootstrapSummary(context.TODO(), &buf, kubeClient, "ssh://git@example.com/fleet", []string{"abc"},
+		installOpts, syncOpts)).To(Succeed())
+
+	var summary bootstrapSummary
+	g.Expect(json.Unmarshal(buf.Bytes(), &summary)).To(Succeed())
+	g.Expect(summary).To(Equal(bootstrapSummary{
+		URL:            "ssh://git@example.com/fleet",
+		Branch:         "main",
+		Path:           "clusters/dev",
+		Namespace:      "flux-system",
+		Revision:       "main@sha1:abc",
+		Commits:        []string{"abc"},
+		SecretName:     "flux-system",
+		KeyFingerprint: ssh.FingerprintSHA256(sshPub),
+		Version:        "v2.1.0",
+		Components: map[string]string{
+			"source-controller":    "v1.1.0",
+			"kustomize-controller": "sha256:123",
+		},
+	}))
+}
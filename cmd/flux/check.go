@@ -19,6 +19,7 @@ package main
 import (
 	"context"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/Masterminds/semver/v3"
@@ -45,7 +46,13 @@ the local environment is configured correctly and if the installed components ar
   flux check --pre
 
   # Run installation checks
-  flux check`,
+  flux check
+
+  # Run installation checks and call the readiness endpoint of each controller
+  flux check --readyz
+
+  # Run installation checks including the image automation components
+  flux check --components-extra=image-reflector-controller,image-automation-controller`,
 	RunE: runCheckCmd,
 }
 
@@ -54,6 +61,7 @@ type checkFlags struct {
 	components      []string
 	extraComponents []string
 	pollInterval    time.Duration
+	readyz          bool
 }
 
 var kubernetesConstraints = []string{
@@ -71,6 +79,8 @@ func init() {
 		"list of components in addition to those supplied or defaulted, accepts comma-separated values")
 	checkCmd.Flags().DurationVar(&checkArgs.pollInterval, "poll-interval", 5*time.Second,
 		"how often the health checker should poll the cluster for the latest state of the resources.")
+	checkCmd.Flags().BoolVar(&checkArgs.readyz, "readyz", false,
+		"call the readiness endpoint of each controller through a port-forward")
 	rootCmd.AddCommand(checkCmd)
 }
 
@@ -102,6 +112,18 @@ func runCheckCmd(cmd *cobra.Command, args []string) error {
 		checkFailed = true
 	}
 
+	if !networkPoliciesCheck() {
+		checkFailed = true
+	}
+
+	if cmd.Flags().Changed("components") || len(checkArgs.extraComponents) > 0 {
+		logger.Actionf("checking components")
+		components := append([]string{}, checkArgs.components...)
+		if !componentsPresenceCheck(append(components, checkArgs.extraComponents...)) {
+			checkFailed = true
+		}
+	}
+
 	if checkFailed {
 		logger.Failuref("check failed")
 		os.Exit(1)
@@ -192,7 +214,12 @@ func componentsCheck() bool {
 		return false
 	}
 
-	kubeClient, err := utils.KubeClient(kubeconfigArgs, kubeclientOptions)
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
+	if err != nil {
+		return false
+	}
+
+	clientSet, err := kubernetes.NewForConfig(kubeConfig)
 	if err != nil {
 		return false
 	}
@@ -212,6 +239,13 @@ func componentsCheck() bool {
 			if ref, err := buildComponentObjectRefs(d.Name); err == nil {
 				if err := statusChecker.Assess(ref...); err != nil {
 					ok = false
+				} else if checkArgs.readyz {
+					if err := readyzCheck(ctx, kubeConfig, clientSet, kubeClient, d); err != nil {
+						logger.Failuref("%s readiness check failed: %s", d.Name, err.Error())
+						ok = false
+					} else {
+						logger.Successf("%s: readiness endpoint is healthy", d.Name)
+					}
 				}
 			}
 			for _, c := range d.Spec.Template.Spec.Containers {
@@ -222,11 +256,63 @@ func componentsCheck() bool {
 	return ok
 }
 
+// networkPoliciesCheck verifies that the network policies allowing CIDRs
+// cover the IP families of the cluster.
+func networkPoliciesCheck() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
+	if err != nil {
+		return false
+	}
+
+	errs := networkPolicyIPFamiliesCheck(ctx, kubeClient, *kubeconfigArgs.Namespace)
+	if len(errs) > 0 {
+		logger.Actionf("checking network policies")
+	}
+	for _, err := range errs {
+		logger.Failuref("%s", err.Error())
+	}
+	return len(errs) == 0
+}
+
+// componentsPresenceCheck verifies explicitly the components given with
+// --components and --components-extra, instead of only the ones found.
+func componentsPresenceCheck(components []string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
+	if err != nil {
+		return false
+	}
+
+	errs := expectedComponentsCheck(ctx, kubeClient, *kubeconfigArgs.Namespace, components)
+	for _, err := range errs {
+		logger.Failuref("%s", err.Error())
+	}
+	if len(errs) == 0 {
+		logger.Successf("found %s", strings.Join(components, ", "))
+	}
+	return len(errs) == 0
+}
+
 func crdsCheck() bool {
 	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(kubeconfigArgs, kubeclientOptions)
+	kubeConfig, err := utils.KubeConfig(kubeconfigArgs, kubeclientOptions)
+	if err != nil {
+		return false
+	}
+
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
+	if err != nil {
+		return false
+	}
+
+	clientSet, err := kubernetes.NewForConfig(kubeConfig)
 	if err != nil {
 		return false
 	}
@@ -249,6 +335,10 @@ func crdsCheck() bool {
 				ok = false
 				logger.Failuref("no stored versions for %s", crd.Name)
 			}
+			for _, err := range crdServingCheck(ctx, clientSet, crd) {
+				ok = false
+				logger.Failuref("%s", err.Error())
+			}
 		}
 	}
 	return ok
@@ -0,0 +1,477 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// componentCRDs lists the CRDs installed along with each component.
+var componentCRDs = map[string][]string{
+	"source-controller": {
+		"buckets.source.toolkit.fluxcd.io",
+		"gitrepositories.source.toolkit.fluxcd.io",
+		"helmcharts.source.toolkit.fluxcd.io",
+		"helmrepositories.source.toolkit.fluxcd.io",
+		"ocirepositories.source.toolkit.fluxcd.io",
+	},
+	"kustomize-controller": {
+		"kustomizations.kustomize.toolkit.fluxcd.io",
+	},
+	"helm-controller": {
+		"helmreleases.helm.toolkit.fluxcd.io",
+	},
+	"notification-controller": {
+		"alerts.notification.toolkit.fluxcd.io",
+		"providers.notification.toolkit.fluxcd.io",
+		"receivers.notification.toolkit.fluxcd.io",
+	},
+	"image-reflector-controller": {
+		"imagepolicies.image.toolkit.fluxcd.io",
+		"imagerepositories.image.toolkit.fluxcd.io",
+	},
+	"image-automation-controller": {
+		"imageupdateautomations.image.toolkit.fluxcd.io",
+	},
+}
+
+// webhookReceiverService is the name of the Service exposing the webhook
+// receiver of notification-controller.
+const webhookReceiverService = "webhook-receiver"
+
+// expectedComponentsCheck verifies that the Deployment and the CRDs of each
+// component are present and, for notification-controller, that the webhook
+// receiver Service has endpoints and that the TLS Secrets of the Ingresses
+// routing to it exist.
+func expectedComponentsCheck(ctx context.Context, kubeClient client.Client, namespace string, components []string) []error {
+	var errs []error
+	for _, component := range components {
+		var deployment appsv1.Deployment
+		if err := kubeClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: component}, &deployment); err != nil {
+			errs = append(errs, fmt.Errorf("%s: deployment not found in '%s' namespace: %w", component, namespace, err))
+		}
+
+		for _, name := range componentCRDs[component] {
+			var crd apiextensionsv1.CustomResourceDefinition
+			if err := kubeClient.Get(ctx, types.NamespacedName{Name: name}, &crd); err != nil {
+				errs = append(errs, fmt.Errorf("%s: crd %s not found: %w", component, name, err))
+			}
+		}
+
+		if component == "notification-controller" {
+			errs = append(errs, webhookReceiverCheck(ctx, kubeClient, namespace)...)
+		}
+	}
+	return errs
+}
+
+// webhookReceiverCheck verifies that the webhook receiver Service has ready
+// endpoints, and that the TLS Secrets of the Ingresses routing to it exist.
+func webhookReceiverCheck(ctx context.Context, kubeClient client.Client, namespace string) []error {
+	var errs []error
+	name := types.NamespacedName{Namespace: namespace, Name: webhookReceiverService}
+
+	var endpoints corev1.Endpoints
+	if err := kubeClient.Get(ctx, name, &endpoints); err != nil {
+		errs = append(errs, fmt.Errorf("%s: endpoints not found: %w", webhookReceiverService, err))
+	} else {
+		ready := false
+		for _, subset := range endpoints.Subsets {
+			if len(subset.Addresses) > 0 {
+				ready = true
+			}
+		}
+		if !ready {
+			errs = append(errs, fmt.Errorf("%s: no ready endpoints", webhookReceiverService))
+		}
+	}
+
+	var ingresses networkingv1.IngressList
+	if err := kubeClient.List(ctx, &ingresses, client.InNamespace(namespace)); err != nil {
+		// Ingresses are optional, and may not be readable with the current permissions.
+		return errs
+	}
+	for _, ingress := range ingresses.Items {
+		if !routesToService(ingress, webhookReceiverService) {
+			continue
+		}
+		for _, tls := range ingress.Spec.TLS {
+			if tls.SecretName == "" {
+				continue
+			}
+			var secret corev1.Secret
+			err := kubeClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: tls.SecretName}, &secret)
+			if apierrors.IsNotFound(err) {
+				errs = append(errs, fmt.Errorf("%s: ingress %s references the TLS secret %s which does not exist",
+					webhookReceiverService, ingress.Name, tls.SecretName))
+			}
+		}
+	}
+	return errs
+}
+
+// routesToService returns true if the default backend or one of the rules of
+// the Ingress targets the named Service.
+func routesToService(ingress networkingv1.Ingress, service string) bool {
+	if b := ingress.Spec.DefaultBackend; b != nil && b.Service != nil && b.Service.Name == service {
+		return true
+	}
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			if path.Backend.Service != nil && path.Backend.Service.Name == service {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// networkPolicyIPFamiliesCheck verifies that the network policies in the
+// namespace which allow traffic from CIDRs have CIDRs for each IP family of
+// the cluster, as found in the spec of the kubernetes Service. Without them,
+// the traffic of the missing family is denied on IPv6-only and dual-stack
+// clusters.
+func networkPolicyIPFamiliesCheck(ctx context.Context, kubeClient client.Client, namespace string) []error {
+	var svc corev1.Service
+	if err := kubeClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: "kubernetes"}, &svc); err != nil {
+		return nil
+	}
+	families := svc.Spec.IPFamilies
+	if len(families) == 0 {
+		family := corev1.IPv4Protocol
+		if ip := net.ParseIP(svc.Spec.ClusterIP); ip != nil && ip.To4() == nil {
+			family = corev1.IPv6Protocol
+		}
+		families = []corev1.IPFamily{family}
+	}
+
+	var policies networkingv1.NetworkPolicyList
+	if err := kubeClient.List(ctx, &policies, client.InNamespace(namespace)); err != nil {
+		return nil
+	}
+
+	var errs []error
+	for _, policy := range policies.Items {
+		found := make(map[corev1.IPFamily]bool)
+		for _, rule := range policy.Spec.Ingress {
+			for _, peer := range rule.From {
+				if peer.IPBlock == nil {
+					continue
+				}
+				ip, _, err := net.ParseCIDR(peer.IPBlock.CIDR)
+				if err != nil {
+					continue
+				}
+				if ip.To4() != nil {
+					found[corev1.IPv4Protocol] = true
+				} else {
+					found[corev1.IPv6Protocol] = true
+				}
+			}
+		}
+		if len(found) == 0 {
+			continue
+		}
+		for _, family := range families {
+			if !found[family] {
+				errs = append(errs, fmt.Errorf("network policy %s has no %s CIDR, the %s traffic from outside the namespace is denied",
+					policy.Name, family, family))
+			}
+		}
+	}
+	return errs
+}
+
+// crdServingCheck verifies that the CRD is established, that all its
+// served versions are advertised by the API server and, if the CRD uses
+// a conversion webhook, that the webhook answers conversion requests.
+func crdServingCheck(ctx context.Context, clientSet kubernetes.Interface, crd apiextensionsv1.CustomResourceDefinition) []error {
+	var errs []error
+
+	established := false
+	for _, c := range crd.Status.Conditions {
+		if c.Type == apiextensionsv1.Established && c.Status == apiextensionsv1.ConditionTrue {
+			established = true
+		}
+	}
+	if !established {
+		errs = append(errs, fmt.Errorf("%s is not established", crd.Name))
+	}
+
+	for _, v := range crd.Spec.Versions {
+		if !v.Served {
+			continue
+		}
+		gv := crd.Spec.Group + "/" + v.Name
+		resources, err := clientSet.Discovery().ServerResourcesForGroupVersion(gv)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s/%s is not served: %w", crd.Name, v.Name, err))
+			continue
+		}
+		if !containsResource(resources, crd.Spec.Names.Plural) {
+			errs = append(errs, fmt.Errorf("%s/%s is not served: resource %s not found in %s",
+				crd.Name, v.Name, crd.Spec.Names.Plural, gv))
+		}
+	}
+
+	if crd.Spec.Conversion != nil && crd.Spec.Conversion.Strategy == apiextensionsv1.WebhookConverter {
+		if err := conversionWebhookCheck(ctx, clientSet, crd); err != nil {
+			errs = append(errs, fmt.Errorf("%s conversion webhook failed: %w", crd.Name, err))
+		}
+	}
+
+	return errs
+}
+
+func containsResource(list *metav1.APIResourceList, plural string) bool {
+	if list == nil {
+		return false
+	}
+	for _, r := range list.APIResources {
+		if r.Name == plural {
+			return true
+		}
+	}
+	return false
+}
+
+// conversionWebhookCheck sends an empty ConversionReview to the webhook
+// service of the CRD through the API server proxy, and verifies that a
+// successful response is returned for the request.
+func conversionWebhookCheck(ctx context.Context, clientSet kubernetes.Interface, crd apiextensionsv1.CustomResourceDefinition) error {
+	webhook := crd.Spec.Conversion.Webhook
+	if webhook == nil || webhook.ClientConfig == nil || webhook.ClientConfig.Service == nil {
+		return fmt.Errorf("no webhook service configured")
+	}
+	supportsV1 := false
+	for _, v := range webhook.ConversionReviewVersions {
+		if v == "v1" {
+			supportsV1 = true
+		}
+	}
+	if !supportsV1 {
+		return fmt.Errorf("the webhook does not accept v1 conversion reviews")
+	}
+
+	var storageVersion string
+	for _, v := range crd.Spec.Versions {
+		if v.Storage {
+			storageVersion = v.Name
+		}
+	}
+
+	uid := types.UID(fmt.Sprintf("flux-check-%d", time.Now().UnixNano()))
+	review := apiextensionsv1.ConversionReview{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: apiextensionsv1.SchemeGroupVersion.String(),
+			Kind:       "ConversionReview",
+		},
+		Request: &apiextensionsv1.ConversionRequest{
+			UID:               uid,
+			DesiredAPIVersion: crd.Spec.Group + "/" + storageVersion,
+			Objects:           []runtime.RawExtension{},
+		},
+	}
+	body, err := json.Marshal(review)
+	if err != nil {
+		return err
+	}
+
+	svc := webhook.ClientConfig.Service
+	port := int32(443)
+	if svc.Port != nil {
+		port = *svc.Port
+	}
+	path := ""
+	if svc.Path != nil {
+		path = *svc.Path
+	}
+
+	raw, err := clientSet.CoreV1().RESTClient().Post().
+		Namespace(svc.Namespace).
+		Resource("services").
+		Name(fmt.Sprintf("https:%s:%d", svc.Name, port)).
+		SubResource("proxy").
+		Suffix(path).
+		SetHeader("Content-Type", "application/json").
+		Body(body).
+		DoRaw(ctx)
+	if err != nil {
+		return err
+	}
+
+	var response apiextensionsv1.ConversionReview
+	if err := json.Unmarshal(raw, &response); err != nil {
+		return fmt.Errorf("invalid conversion response: %w", err)
+	}
+	if response.Response == nil || response.Response.UID != uid {
+		return fmt.Errorf("conversion response does not match the request")
+	}
+	if response.Response.Result.Status == metav1.StatusFailure {
+		return fmt.Errorf("conversion rejected: %s", response.Response.Result.Message)
+	}
+	return nil
+}
+
+// readinessEndpoint returns the path and container port of the HTTP
+// readiness probe of the deployment's first container, resolving named
+// ports against the container's ports.
+func readinessEndpoint(d appsv1.Deployment) (string, int, error) {
+	if len(d.Spec.Template.Spec.Containers) == 0 {
+		return "", 0, fmt.Errorf("no containers found")
+	}
+	container := d.Spec.Template.Spec.Containers[0]
+	probe := container.ReadinessProbe
+	if probe == nil || probe.HTTPGet == nil {
+		return "", 0, fmt.Errorf("no HTTP readiness probe defined for container %s", container.Name)
+	}
+
+	port := probe.HTTPGet.Port
+	if port.IntValue() > 0 {
+		return probe.HTTPGet.Path, port.IntValue(), nil
+	}
+	for _, p := range container.Ports {
+		if p.Name == port.String() {
+			return probe.HTTPGet.Path, int(p.ContainerPort), nil
+		}
+	}
+	return "", 0, fmt.Errorf("readiness probe port %s not found in container %s", port.String(), container.Name)
+}
+
+// readyzCheck port-forwards to a running pod of the deployment and
+// calls its readiness endpoint.
+func readyzCheck(ctx context.Context, cfg *rest.Config, clientSet kubernetes.Interface, kubeClient client.Client, d appsv1.Deployment) error {
+	path, port, err := readinessEndpoint(d)
+	if err != nil {
+		return err
+	}
+
+	pod, err := runningPod(ctx, kubeClient, d.Namespace, d.Spec.Selector.MatchLabels)
+	if err != nil {
+		return err
+	}
+
+	localPort, stop, err := forwardPodPort(ctx, cfg, clientSet, pod, port)
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	url := fmt.Sprintf("http://127.0.0.1:%d%s", localPort, path)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s returned %d: %s", path, resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// runningPod returns a running pod matching the labels in the namespace.
+func runningPod(ctx context.Context, kubeClient client.Client, namespace string, labels map[string]string) (*corev1.Pod, error) {
+	var pods corev1.PodList
+	if err := kubeClient.List(ctx, &pods, client.InNamespace(namespace),
+		client.MatchingLabels(labels)); err != nil {
+		return nil, err
+	}
+	for i := range pods.Items {
+		if pods.Items[i].Status.Phase == corev1.PodRunning && pods.Items[i].DeletionTimestamp == nil {
+			return &pods.Items[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no running pods found")
+}
+
+// forwardPodPort forwards a random local port to the port of the pod. It
+// returns the local port and a function closing the port-forward.
+func forwardPodPort(ctx context.Context, cfg *rest.Config, clientSet kubernetes.Interface, pod *corev1.Pod, port int) (uint16, func(), error) {
+	transport, upgrader, err := spdy.RoundTripperFor(cfg)
+	if err != nil {
+		return 0, nil, err
+	}
+	req := clientSet.CoreV1().RESTClient().Post().
+		Namespace(pod.Namespace).
+		Resource("pods").
+		Name(pod.Name).
+		SubResource("portforward")
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, req.URL())
+
+	stopCh := make(chan struct{})
+	readyCh := make(chan struct{})
+	stop := func() { close(stopCh) }
+	fw, err := portforward.NewOnAddresses(dialer, []string{"127.0.0.1"}, []string{"0:" + strconv.Itoa(port)},
+		stopCh, readyCh, io.Discard, io.Discard)
+	if err != nil {
+		return 0, nil, err
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- fw.ForwardPorts()
+	}()
+
+	select {
+	case <-readyCh:
+	case err := <-errCh:
+		return 0, nil, fmt.Errorf("port-forward to %s failed: %w", pod.Name, err)
+	case <-ctx.Done():
+		stop()
+		return 0, nil, ctx.Err()
+	}
+
+	ports, err := fw.GetPorts()
+	if err != nil {
+		stop()
+		return 0, nil, fmt.Errorf("port-forward to %s failed: %w", pod.Name, err)
+	}
+	if len(ports) == 0 {
+		stop()
+		return 0, nil, fmt.Errorf("port-forward to %s failed: no port forwarded", pod.Name)
+	}
+	return ports[0].Local, stop, nil
+}
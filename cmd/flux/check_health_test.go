@@ -0,0 +1,256 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	crfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/fluxcd/flux2/internal/utils"
+)
+
+func TestReadinessEndpoint(t *testing.T) {
+	deployment := func(port intstr.IntOrString) appsv1.Deployment {
+		return appsv1.Deployment{
+			Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{
+							Name:  "manager",
+							Ports: []corev1.ContainerPort{{Name: "healthz", ContainerPort: 9440}},
+							ReadinessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									HTTPGet: &corev1.HTTPGetAction{Path: "/readyz", Port: port},
+								},
+							},
+						}},
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		port     intstr.IntOrString
+		wantPort int
+		wantErr  bool
+	}{
+		{name: "named port", port: intstr.FromString("healthz"), wantPort: 9440},
+		{name: "numeric port", port: intstr.FromInt(8080), wantPort: 8080},
+		{name: "unknown named port", port: intstr.FromString("http"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, port, err := readinessEndpoint(deployment(tt.port))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr {
+				return
+			}
+			if path != "/readyz" || port != tt.wantPort {
+				t.Errorf("got %s:%d, want /readyz:%d", path, port, tt.wantPort)
+			}
+		})
+	}
+
+	if _, _, err := readinessEndpoint(appsv1.Deployment{}); err == nil {
+		t.Error("expected error for deployment without containers")
+	}
+}
+
+func TestCrdServingCheck(t *testing.T) {
+	crd := apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: "kustomizations.kustomize.toolkit.fluxcd.io"},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Group: "kustomize.toolkit.fluxcd.io",
+			Names: apiextensionsv1.CustomResourceDefinitionNames{Plural: "kustomizations"},
+			Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+				{Name: "v1beta1", Served: true},
+				{Name: "v1beta2", Served: true, Storage: true},
+				{Name: "v1alpha1", Served: false},
+			},
+		},
+		Status: apiextensionsv1.CustomResourceDefinitionStatus{
+			Conditions: []apiextensionsv1.CustomResourceDefinitionCondition{
+				{Type: apiextensionsv1.Established, Status: apiextensionsv1.ConditionTrue},
+			},
+		},
+	}
+
+	clientSet := fake.NewSimpleClientset()
+	clientSet.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "kustomize.toolkit.fluxcd.io/v1beta2",
+			APIResources: []metav1.APIResource{{Name: "kustomizations"}},
+		},
+	}
+
+	errs := crdServingCheck(context.TODO(), clientSet, crd)
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "/v1beta1 is not served") {
+		t.Errorf("expected v1beta1 to be reported as not served, got %v", errs)
+	}
+
+	crd.Status.Conditions = nil
+	crd.Spec.Versions = crd.Spec.Versions[1:2]
+	errs = crdServingCheck(context.TODO(), clientSet, crd)
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "is not established") {
+		t.Errorf("expected CRD to be reported as not established, got %v", errs)
+	}
+}
+
+func TestExpectedComponentsCheck(t *testing.T) {
+	objects := []client.Object{
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "notification-controller", Namespace: "flux-system"}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "image-reflector-controller", Namespace: "flux-system"}},
+		&apiextensionsv1.CustomResourceDefinition{ObjectMeta: metav1.ObjectMeta{Name: "alerts.notification.toolkit.fluxcd.io"}},
+		&apiextensionsv1.CustomResourceDefinition{ObjectMeta: metav1.ObjectMeta{Name: "providers.notification.toolkit.fluxcd.io"}},
+		&apiextensionsv1.CustomResourceDefinition{ObjectMeta: metav1.ObjectMeta{Name: "receivers.notification.toolkit.fluxcd.io"}},
+		&apiextensionsv1.CustomResourceDefinition{ObjectMeta: metav1.ObjectMeta{Name: "imagerepositories.image.toolkit.fluxcd.io"}},
+		&corev1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{Name: "webhook-receiver", Namespace: "flux-system"},
+			Subsets:    []corev1.EndpointSubset{{Addresses: []corev1.EndpointAddress{{IP: "10.0.0.1"}}}},
+		},
+		&networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: "webhook-receiver", Namespace: "flux-system"},
+			Spec: networkingv1.IngressSpec{
+				TLS: []networkingv1.IngressTLS{{Hosts: []string{"flux-webhook.example.com"}, SecretName: "webhook-tls"}},
+				DefaultBackend: &networkingv1.IngressBackend{
+					Service: &networkingv1.IngressServiceBackend{Name: "webhook-receiver"},
+				},
+			},
+		},
+		&networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: "podinfo", Namespace: "flux-system"},
+			Spec: networkingv1.IngressSpec{
+				TLS: []networkingv1.IngressTLS{{SecretName: "podinfo-tls"}},
+			},
+		},
+	}
+	kubeClient := crfake.NewClientBuilder().WithScheme(utils.NewScheme()).WithObjects(objects...).Build()
+
+	errs := expectedComponentsCheck(context.TODO(), kubeClient, "flux-system",
+		[]string{"notification-controller", "image-reflector-controller", "image-automation-controller"})
+
+	var got []string
+	for _, err := range errs {
+		got = append(got, err.Error())
+	}
+	for _, want := range []string{
+		"image-reflector-controller: crd imagepolicies.image.toolkit.fluxcd.io not found",
+		"image-automation-controller: deployment not found",
+		"image-automation-controller: crd imageupdateautomations.image.toolkit.fluxcd.io not found",
+		"webhook-receiver: ingress webhook-receiver references the TLS secret webhook-tls which does not exist",
+	} {
+		found := false
+		for _, msg := range got {
+			if strings.HasPrefix(msg, want) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("expected error %q, got %v", want, got)
+		}
+	}
+	if len(got) != 4 {
+		t.Errorf("expected 4 errors, got %d: %v", len(got), got)
+	}
+}
+
+func TestNetworkPolicyIPFamiliesCheck(t *testing.T) {
+	policy := func(name string, cidrs ...string) *networkingv1.NetworkPolicy {
+		var peers []networkingv1.NetworkPolicyPeer
+		for _, cidr := range cidrs {
+			peers = append(peers, networkingv1.NetworkPolicyPeer{IPBlock: &networkingv1.IPBlock{CIDR: cidr}})
+		}
+		return &networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "flux-system"},
+			Spec: networkingv1.NetworkPolicySpec{
+				Ingress: []networkingv1.NetworkPolicyIngressRule{{From: peers}},
+			},
+		}
+	}
+	kubernetesSvc := func(families ...corev1.IPFamily) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "kubernetes", Namespace: "default"},
+			Spec:       corev1.ServiceSpec{ClusterIP: "10.96.0.1", IPFamilies: families},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		objects []client.Object
+		want    []string
+	}{
+		{
+			name: "IPv4 cluster",
+			objects: []client.Object{
+				kubernetesSvc(),
+				policy("allow-cidrs", "10.0.0.0/8"),
+			},
+		},
+		{
+			name: "dual-stack cluster",
+			objects: []client.Object{
+				kubernetesSvc(corev1.IPv4Protocol, corev1.IPv6Protocol),
+				policy("allow-cidrs", "10.0.0.0/8", "fd00::/8"),
+				policy("allow-scraping"),
+			},
+		},
+		{
+			name: "dual-stack cluster without IPv6 CIDR",
+			objects: []client.Object{
+				kubernetesSvc(corev1.IPv4Protocol, corev1.IPv6Protocol),
+				policy("allow-cidrs", "10.0.0.0/8"),
+			},
+			want: []string{"network policy allow-cidrs has no IPv6 CIDR, the IPv6 traffic from outside the namespace is denied"},
+		},
+		{
+			name: "IPv6-only cluster",
+			objects: []client.Object{
+				kubernetesSvc(corev1.IPv6Protocol),
+				policy("allow-cidrs", "0.0.0.0/0"),
+			},
+			want: []string{"network policy allow-cidrs has no IPv6 CIDR, the IPv6 traffic from outside the namespace is denied"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kubeClient := crfake.NewClientBuilder().WithScheme(utils.NewScheme()).WithObjects(tt.objects...).Build()
+			var got []string
+			for _, err := range networkPolicyIPFamiliesCheck(context.TODO(), kubeClient, "flux-system") {
+				got = append(got, err.Error())
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("expected errors %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var completionInstallCmd = &cobra.Command{
+	Use:   "install [shell]",
+	Short: "Install the completion script for your shell",
+	Long: `The completion install command writes the completion script of the given shell,
+or of the current shell when omitted, to the location the shell loads completions from.
+When the shell needs to be configured to load the script, its config file is updated.`,
+	Example: `  # Install the completion script for the current shell
+  flux completion install
+
+  # Install the completion script for zsh
+  flux completion install zsh`,
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: []string{"bash", "fish", "powershell", "zsh"},
+	RunE:      completionInstallCmdRun,
+}
+
+func init() {
+	completionCmd.AddCommand(completionInstallCmd)
+}
+
+// completionTarget holds the paths where the completion of a shell is
+// installed. When rcFile is set, rcLine is appended to it to load the script.
+type completionTarget struct {
+	script string
+	rcFile string
+	rcLine string
+	gen    func(w io.Writer) error
+}
+
+func completionInstallCmdRun(cmd *cobra.Command, args []string) error {
+	var shell string
+	if len(args) > 0 {
+		shell = args[0]
+	} else {
+		shell = detectShell()
+		if shell == "" {
+			return fmt.Errorf("unable to detect the shell, specify one of: bash, fish, powershell, zsh")
+		}
+		logger.Actionf("detected %s shell", shell)
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+	target, err := completionInstallTarget(shell, home, runtime.GOOS)
+	if err != nil {
+		return err
+	}
+
+	var script bytes.Buffer
+	if err := target.gen(&script); err != nil {
+		return fmt.Errorf("unable to generate the %s completion script: %w", shell, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(target.script), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(target.script, script.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("unable to write the completion script: %w", err)
+	}
+	logger.Successf("completion script written to %s", target.script)
+
+	if target.rcFile == "" {
+		return nil
+	}
+	updated, err := appendLineOnce(target.rcFile, target.rcLine)
+	if err != nil {
+		return fmt.Errorf("unable to update %s: %w", target.rcFile, err)
+	}
+	if updated {
+		logger.Successf("added '%s' to %s", target.rcLine, target.rcFile)
+	}
+	logger.Successf("start a new %s session to load the completions", shell)
+	return nil
+}
+
+// detectShell returns the name of the shell of the user, from the SHELL
+// environment variable or the operating system.
+func detectShell() string {
+	switch name := filepath.Base(os.Getenv("SHELL")); name {
+	case "bash", "fish", "zsh":
+		return name
+	case "pwsh", "powershell":
+		return "powershell"
+	}
+	if runtime.GOOS == "windows" {
+		return "powershell"
+	}
+	return ""
+}
+
+// completionInstallTarget returns where the completion script of the shell
+// is written, following the XDG base directory conventions on Unix systems.
+func completionInstallTarget(shell, home, goos string) (*completionTarget, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		configHome = filepath.Join(home, ".config")
+	}
+
+	switch shell {
+	case "bash":
+		// Loaded on demand by bash-completion v2
+		return &completionTarget{
+			script: filepath.Join(dataHome, "bash-completion", "completions", "flux"),
+			gen:    rootCmd.GenBashCompletion,
+		}, nil
+	case "fish":
+		return &completionTarget{
+			script: filepath.Join(configHome, "fish", "completions", "flux.fish"),
+			gen: func(w io.Writer) error {
+				return rootCmd.GenFishCompletion(w, true)
+			},
+		}, nil
+	case "zsh":
+		zdotdir := os.Getenv("ZDOTDIR")
+		if zdotdir == "" {
+			zdotdir = home
+		}
+		dir := filepath.Join(dataHome, "zsh", "site-functions")
+		return &completionTarget{
+			script: filepath.Join(dir, "_flux"),
+			rcFile: filepath.Join(zdotdir, ".zshrc"),
+			rcLine: fmt.Sprintf("fpath=(%s $fpath); autoload -U compinit && compinit", dir),
+			gen:    genZshCompletion,
+		}, nil
+	case "powershell":
+		dir := filepath.Join(configHome, "powershell")
+		if goos == "windows" {
+			dir = filepath.Join(home, "Documents", "PowerShell")
+		}
+		script := filepath.Join(dir, "flux-completion.ps1")
+		return &completionTarget{
+			script: script,
+			rcFile: filepath.Join(dir, "Microsoft.PowerShell_profile.ps1"),
+			rcLine: fmt.Sprintf(". '%s'", script),
+			gen:    rootCmd.GenPowerShellCompletion,
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported shell %q, must be one of: bash, fish, powershell, zsh", shell)
+}
+
+// appendLineOnce appends the line to the file, creating it if needed, and
+// returns false if the file already contains the line.
+func appendLineOnce(path, line string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	for _, l := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(l) == line {
+			return false, nil
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return false, err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	prefix := ""
+	if len(data) > 0 && !bytes.HasSuffix(data, []byte("\n")) {
+		prefix = "\n"
+	}
+	if _, err := fmt.Fprintf(f, "%s# flux completion\n%s\n", prefix, line); err != nil {
+		return false, err
+	}
+	return true, nil
+}
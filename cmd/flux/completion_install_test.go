@@ -0,0 +1,104 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCompletionInstall(t *testing.T) {
+	tests := []struct {
+		shell  string
+		script string
+		rcFile string
+	}{
+		{shell: "bash", script: ".local/share/bash-completion/completions/flux"},
+		{shell: "fish", script: ".config/fish/completions/flux.fish"},
+		{shell: "zsh", script: ".local/share/zsh/site-functions/_flux", rcFile: ".zshrc"},
+		{shell: "powershell", script: ".config/powershell/flux-completion.ps1", rcFile: ".config/powershell/Microsoft.PowerShell_profile.ps1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			isolateEnv(t)
+			t.Setenv("XDG_DATA_HOME", "")
+			t.Setenv("XDG_CONFIG_HOME", "")
+			t.Setenv("ZDOTDIR", "")
+			t.Setenv("SHELL", "/bin/"+tt.shell)
+			home := os.Getenv("HOME")
+
+			// Installing twice must not duplicate the shell config
+			for i := 0; i < 2; i++ {
+				cmd := cmdTestCase{
+					args:   "completion install",
+					assert: assertSuccess(),
+				}
+				cmd.runTestCmd(t)
+			}
+
+			script, err := os.ReadFile(filepath.Join(home, tt.script))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(script), "flux") {
+				t.Errorf("unexpected completion script:\n%s", script)
+			}
+
+			if tt.rcFile == "" {
+				return
+			}
+			rc, err := os.ReadFile(filepath.Join(home, tt.rcFile))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n := strings.Count(string(rc), "# flux completion"); n != 1 {
+				t.Errorf("expected the completion to be configured once, got %d times:\n%s", n, rc)
+			}
+		})
+	}
+}
+
+func TestCompletionInstallUnsupportedShell(t *testing.T) {
+	isolateEnv(t)
+	cmd := cmdTestCase{
+		args:   "completion install tcsh",
+		assert: assertError(`unsupported shell "tcsh", must be one of: bash, fish, powershell, zsh`),
+	}
+	cmd.runTestCmd(t)
+}
+
+func TestAppendLineOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".zshrc")
+	if err := os.WriteFile(path, []byte("export EDITOR=vim"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if updated, err := appendLineOnce(path, "source ~/.flux"); err != nil || !updated {
+		t.Fatalf("expected the line to be appended, got %v, %v", updated, err)
+	}
+	if updated, err := appendLineOnce(path, "source ~/.flux"); err != nil || updated {
+		t.Fatalf("expected the line not to be appended again, got %v, %v", updated, err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "export EDITOR=vim\n# flux completion\nsource ~/.flux\n"; string(data) != want {
+		t.Errorf("got %q, want %q", data, want)
+	}
+}
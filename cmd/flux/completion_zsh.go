@@ -18,6 +18,7 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/spf13/cobra"
@@ -43,12 +44,19 @@ flux completion zsh > _flux
 mv _flux ~/.oh-my-zsh/completions  # oh-my-zsh
 mv _flux ~/.zprezto/modules/completion/external/src/  # zprezto`,
 	Run: func(cmd *cobra.Command, args []string) {
-		rootCmd.GenZshCompletion(os.Stdout)
-		// Cobra doesn't source zsh completion file, explicitly doing it here
-		fmt.Println("compdef _flux flux")
+		genZshCompletion(os.Stdout)
 	},
 }
 
 func init() {
 	completionCmd.AddCommand(completionZshCmd)
 }
+
+func genZshCompletion(w io.Writer) error {
+	if err := rootCmd.GenZshCompletion(w); err != nil {
+		return err
+	}
+	// Cobra doesn't source zsh completion file, explicitly doing it here
+	_, err := fmt.Fprintln(w, "compdef _flux flux")
+	return err
+}
@@ -0,0 +1,251 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/fluxcd/flux2/internal/flags"
+	"github.com/fluxcd/flux2/internal/utils"
+	"github.com/fluxcd/flux2/pkg/manifestgen"
+)
+
+var configControllersCmd = &cobra.Command{
+	Use:   "controllers",
+	Short: "Configure the log level and feature gates of the Flux controllers",
+	Long: `The config controllers command patches the arguments of the Flux controller Deployments in place.
+When Flux is bootstrapped, the changes are reverted on the next reconciliation of the flux-system Kustomization,
+use --export to generate the Kustomize patches to add to the kustomization.yaml of the bootstrap repository instead.`,
+	Example: `  # Enable debug logging for all controllers
+  flux config controllers --log-level=debug
+
+  # Enable a feature gate for the kustomize-controller
+  flux config controllers --component=kustomize-controller --feature-gates=DisableStatusPollerCache=true
+
+  # Generate the patches to add to clusters/my-cluster/flux-system/kustomization.yaml
+  flux config controllers --log-level=debug --export`,
+	RunE: configControllersCmdRun,
+}
+
+type configControllersFlags struct {
+	logLevel     flags.LogLevel
+	featureGates []string
+	components   []string
+	export       bool
+}
+
+var configControllersArgs configControllersFlags
+
+func init() {
+	configControllersCmd.Flags().Var(&configControllersArgs.logLevel, "log-level", configControllersArgs.logLevel.Description())
+	configControllersCmd.Flags().StringSliceVar(&configControllersArgs.featureGates, "feature-gates", nil,
+		"feature gates to set on the controllers, accepts comma-separated values in the format '<name>=<true|false>'")
+	configControllersCmd.Flags().StringSliceVar(&configControllersArgs.components, "component", nil,
+		"list of controllers to configure, defaults to all controllers found in the namespace")
+	configControllersCmd.Flags().BoolVar(&configControllersArgs.export, "export", false,
+		"print the Kustomize patches for the Flux bootstrap repository instead of patching the Deployments")
+
+	configCmd.AddCommand(configControllersCmd)
+}
+
+func configControllersCmdRun(cmd *cobra.Command, args []string) error {
+	if configControllersArgs.logLevel == "" && len(configControllersArgs.featureGates) == 0 {
+		return fmt.Errorf("at least one of --log-level or --feature-gates is required")
+	}
+	featureGates, err := parseFeatureGates(configControllersArgs.featureGates)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
+	if err != nil {
+		return err
+	}
+
+	var list appsv1.DeploymentList
+	selector := client.MatchingLabels{manifestgen.PartOfLabelKey: manifestgen.PartOfLabelValue}
+	if err := kubeClient.List(ctx, &list, client.InNamespace(*kubeconfigArgs.Namespace), selector); err != nil {
+		return err
+	}
+
+	var patches []kustomizePatch
+	var found int
+	for i := range list.Items {
+		deployment := &list.Items[i]
+		if len(configControllersArgs.components) > 0 &&
+			!utils.ContainsItemString(configControllersArgs.components, deployment.Name) {
+			continue
+		}
+		found++
+
+		index := controllerContainerIndex(deployment)
+		if index < 0 {
+			logger.Warningf("skipping %s, no %s container found", deployment.Name, controllerContainer)
+			continue
+		}
+
+		args := setControllerArgs(deployment.Spec.Template.Spec.Containers[index].Args,
+			configControllersArgs.logLevel.String(), featureGates)
+
+		if configControllersArgs.export {
+			patch, err := newArgsPatch(deployment.Name, index, args)
+			if err != nil {
+				return err
+			}
+			patches = append(patches, patch)
+			continue
+		}
+
+		logger.Actionf("patching %s", deployment.Name)
+		if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			var latest appsv1.Deployment
+			if err := kubeClient.Get(ctx, client.ObjectKeyFromObject(deployment), &latest); err != nil {
+				return err
+			}
+			latest.Spec.Template.Spec.Containers[index].Args = args
+			return kubeClient.Update(ctx, &latest)
+		}); err != nil {
+			return fmt.Errorf("failed to patch %s: %w", deployment.Name, err)
+		}
+		logger.Successf("%s configured", deployment.Name)
+	}
+
+	if found == 0 {
+		return fmt.Errorf("no controllers found in %s namespace", *kubeconfigArgs.Namespace)
+	}
+
+	if configControllersArgs.export {
+		data, err := yaml.Marshal(map[string][]kustomizePatch{"patches": patches})
+		if err != nil {
+			return err
+		}
+		rootCmd.Print(string(data))
+		return nil
+	}
+
+	logger.Warningf("the changes will be reverted if the controllers are managed by a Kustomization, use --export to generate the patches for Git")
+	return nil
+}
+
+// kustomizePatch is an entry of the patches field of a kustomization.yaml.
+type kustomizePatch struct {
+	Patch  string               `json:"patch"`
+	Target kustomizePatchTarget `json:"target"`
+}
+
+type kustomizePatchTarget struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+func newArgsPatch(name string, index int, args []string) (kustomizePatch, error) {
+	ops := []map[string]interface{}{
+		{
+			"op":    "replace",
+			"path":  fmt.Sprintf("/spec/template/spec/containers/%d/args", index),
+			"value": args,
+		},
+	}
+	data, err := yaml.Marshal(ops)
+	if err != nil {
+		return kustomizePatch{}, err
+	}
+	return kustomizePatch{
+		Patch: string(data),
+		Target: kustomizePatchTarget{
+			Kind: "Deployment",
+			Name: name,
+		},
+	}, nil
+}
+
+func controllerContainerIndex(deployment *appsv1.Deployment) int {
+	for i, c := range deployment.Spec.Template.Spec.Containers {
+		if c.Name == controllerContainer {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseFeatureGates parses the given '<name>=<true|false>' pairs into a map.
+func parseFeatureGates(gates []string) (map[string]string, error) {
+	result := make(map[string]string, len(gates))
+	for _, gate := range gates {
+		name, value, ok := strings.Cut(gate, "=")
+		if !ok || name == "" || (value != "true" && value != "false") {
+			return nil, fmt.Errorf("invalid feature gate '%s', must be in the format '<name>=<true|false>'", gate)
+		}
+		result[name] = value
+	}
+	return result, nil
+}
+
+// setControllerArgs returns a copy of the given controller arguments with
+// the log level replaced, and the given feature gates merged with any
+// feature gates already set.
+func setControllerArgs(args []string, logLevel string, featureGates map[string]string) []string {
+	const (
+		logLevelFlag     = "--log-level="
+		featureGatesFlag = "--feature-gates="
+	)
+
+	result := make([]string, 0, len(args)+2)
+	gates := map[string]string{}
+	for _, arg := range args {
+		switch {
+		case logLevel != "" && strings.HasPrefix(arg, logLevelFlag):
+			continue
+		case len(featureGates) > 0 && strings.HasPrefix(arg, featureGatesFlag):
+			for _, gate := range strings.Split(strings.TrimPrefix(arg, featureGatesFlag), ",") {
+				if name, value, ok := strings.Cut(gate, "="); ok {
+					gates[name] = value
+				}
+			}
+			continue
+		}
+		result = append(result, arg)
+	}
+
+	if logLevel != "" {
+		result = append(result, logLevelFlag+logLevel)
+	}
+	if len(featureGates) > 0 {
+		for k, v := range featureGates {
+			gates[k] = v
+		}
+		pairs := make([]string, 0, len(gates))
+		for k, v := range gates {
+			pairs = append(pairs, k+"="+v)
+		}
+		sort.Strings(pairs)
+		result = append(result, featureGatesFlag+strings.Join(pairs, ","))
+	}
+	return result
+}
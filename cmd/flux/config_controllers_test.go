@@ -0,0 +1,71 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSetControllerArgs(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []string
+		logLevel     string
+		featureGates map[string]string
+		want         []string
+	}{
+		{
+			name:     "replace log level",
+			args:     []string{"--events-addr=http://notification-controller.flux-system.svc.cluster.local./", "--log-level=info"},
+			logLevel: "debug",
+			want:     []string{"--events-addr=http://notification-controller.flux-system.svc.cluster.local./", "--log-level=debug"},
+		},
+		{
+			name:         "merge feature gates",
+			args:         []string{"--log-level=info", "--feature-gates=OOMWatch=true,CacheSecretsAndConfigMaps=false"},
+			featureGates: map[string]string{"CacheSecretsAndConfigMaps": "true", "DetectDrift": "true"},
+			want:         []string{"--log-level=info", "--feature-gates=CacheSecretsAndConfigMaps=true,DetectDrift=true,OOMWatch=true"},
+		},
+		{
+			name:         "add feature gates",
+			args:         []string{"--log-level=info"},
+			featureGates: map[string]string{"DetectDrift": "false"},
+			want:         []string{"--log-level=info", "--feature-gates=DetectDrift=false"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := setControllerArgs(tt.args, tt.logLevel, tt.featureGates); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("setControllerArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFeatureGates(t *testing.T) {
+	if _, err := parseFeatureGates([]string{"DetectDrift=yes"}); err == nil {
+		t.Error("expected error for invalid feature gate value")
+	}
+	got, err := parseFeatureGates([]string{"DetectDrift=true"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got["DetectDrift"] != "true" {
+		t.Errorf("parseFeatureGates() = %v", got)
+	}
+}
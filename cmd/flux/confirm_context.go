@@ -0,0 +1,94 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/fluxcd/flux2/internal/utils"
+)
+
+// mutatingCommands are the top level commands which change the state of
+// the cluster, these are guarded by --confirm-context.
+var mutatingCommands = []string{
+	"apply", "bootstrap", "config", "create", "delete", "edit", "install",
+	"pause", "reconcile", "restart", "resume", "suspend", "uninstall",
+}
+
+// confirmContext returns an error when --confirm-context is set and the
+// command would change the state of the cluster of another kubeconfig
+// context.
+func confirmContext(cmd *cobra.Command) error {
+	if rootArgs.confirmContext == "" || !isMutatingCommand(cmd) || isExportOnly(cmd) {
+		return nil
+	}
+	if contexts, err := cmd.Flags().GetStringSlice("contexts"); err == nil && len(contexts) > 0 {
+		// the bootstrap of several contexts checks each of them
+		return nil
+	}
+
+	current, err := currentContextName()
+	if err != nil {
+		return fmt.Errorf("unable to determine the current context: %w", err)
+	}
+	if current != rootArgs.confirmContext {
+		return fmt.Errorf("refusing to run '%s' against context %q, --confirm-context requires %q",
+			cmd.CommandPath(), current, rootArgs.confirmContext)
+	}
+	return nil
+}
+
+// currentContextName returns the name of the kubeconfig context the
+// commands run against, taking --context into account.
+func currentContextName() (string, error) {
+	if kubeconfigArgs.Context != nil && *kubeconfigArgs.Context != "" {
+		return *kubeconfigArgs.Context, nil
+	}
+	rawConfig, err := kubeconfigArgs.ToRawKubeConfigLoader().RawConfig()
+	if err != nil {
+		return "", err
+	}
+	return rawConfig.CurrentContext, nil
+}
+
+// isMutatingCommand returns true if the top level command of cmd is one
+// of the mutatingCommands.
+func isMutatingCommand(cmd *cobra.Command) bool {
+	for c := cmd; c.HasParent(); c = c.Parent() {
+		if !c.Parent().HasParent() {
+			return utils.ContainsItemString(mutatingCommands, c.Name())
+		}
+	}
+	return false
+}
+
+// isExportOnly returns true if the command only writes manifests instead
+// of applying them to the cluster.
+func isExportOnly(cmd *cobra.Command) bool {
+	if export, err := cmd.Flags().GetBool("export"); err == nil && export {
+		return true
+	}
+	if path, err := cmd.Flags().GetString("export-path"); err == nil && path != "" {
+		return true
+	}
+	if dir, err := cmd.Flags().GetString("export-dir"); err == nil && dir != "" {
+		return true
+	}
+	return false
+}
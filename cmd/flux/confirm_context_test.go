@@ -0,0 +1,132 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestConfirmContext(t *testing.T) {
+	isolateEnv(t)
+	t.Cleanup(resetCmdArgs)
+	kubeconfig := `apiVersion: v1
+kind: Config
+clusters:
+- name: dev
+  cluster:
+    server: https://dev.example.com
+contexts:
+- name: dev
+  context:
+    cluster: dev
+current-context: dev
+`
+	g := NewWithT(t)
+	g.Expect(os.WriteFile(os.Getenv("KUBECONFIG"), []byte(kubeconfig), 0o600)).To(Succeed())
+
+	tests := []struct {
+		name           string
+		args           []string
+		flags          map[string]string
+		confirmContext string
+		wantErr        string
+	}{
+		{
+			name: "not set",
+			args: []string{"suspend", "kustomization"},
+		},
+		{
+			name:           "matching context",
+			args:           []string{"suspend", "kustomization"},
+			confirmContext: "dev",
+		},
+		{
+			name:           "mismatching context",
+			args:           []string{"suspend", "kustomization"},
+			confirmContext: "prod",
+			wantErr:        `refusing to run 'flux suspend kustomization' against context "dev", --confirm-context requires "prod"`,
+		},
+		{
+			name:           "read-only command",
+			args:           []string{"get", "kustomizations"},
+			confirmContext: "prod",
+		},
+		{
+			name:           "bootstrap",
+			args:           []string{"bootstrap", "github"},
+			confirmContext: "prod",
+			wantErr:        `refusing to run 'flux bootstrap github' against context "dev"`,
+		},
+		{
+			name:           "config",
+			args:           []string{"config", "controllers"},
+			confirmContext: "prod",
+			wantErr:        `refusing to run 'flux config controllers' against context "dev"`,
+		},
+		{
+			name:           "config export",
+			args:           []string{"config", "controllers"},
+			flags:          map[string]string{"export": "true"},
+			confirmContext: "prod",
+		},
+		{
+			name:           "install export dir",
+			args:           []string{"install"},
+			flags:          map[string]string{"export-dir": "./flux-system"},
+			confirmContext: "prod",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			cmd, _, err := rootCmd.Find(tt.args)
+			g.Expect(err).ToNot(HaveOccurred())
+			for name, value := range tt.flags {
+				g.Expect(cmd.Flags().Set(name, value)).To(Succeed())
+			}
+			t.Cleanup(resetCmdArgs)
+			rootArgs.confirmContext = tt.confirmContext
+
+			err = confirmContext(cmd)
+			if tt.wantErr == "" {
+				g.Expect(err).ToNot(HaveOccurred())
+				return
+			}
+			g.Expect(err).To(MatchError(ContainSubstring(tt.wantErr)))
+		})
+	}
+}
+
+func TestConfirmContextCmd(t *testing.T) {
+	isolateEnv(t)
+	useFakeCluster(t)
+
+	cmd := cmdTestCase{
+		args:   "suspend kustomization podinfo --context=staging --confirm-context=prod",
+		assert: assertError(`refusing to run 'flux suspend kustomization' against context "staging", --confirm-context requires "prod"`),
+	}
+	cmd.runTestCmd(t)
+
+	cmd = cmdTestCase{
+		args:   "create source git podinfo --url=https://github.com/stefanprodan/podinfo --branch=master --interval=1m --context=staging --confirm-context=prod --export",
+		assert: assertSuccess(),
+	}
+	cmd.runTestCmd(t)
+}
@@ -17,20 +17,34 @@ limitations under the License.
 package main
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"os"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/yaml"
 
+	"github.com/fluxcd/flux2/internal/lint"
 	"github.com/fluxcd/flux2/internal/utils"
+	"github.com/fluxcd/flux2/pkg/manifestgen"
 )
 
 var createCmd = &cobra.Command{
@@ -40,23 +54,51 @@ var createCmd = &cobra.Command{
 }
 
 type createFlags struct {
-	interval time.Duration
-	export   bool
-	labels   []string
+	interval        time.Duration
+	export          bool
+	labels          []string
+	createNamespace bool
+	overwrite       bool
+	suspend         bool
 }
 
 var createArgs createFlags
 
 func init() {
-	createCmd.PersistentFlags().DurationVarP(&createArgs.interval, "interval", "", time.Minute, "source sync interval")
+	createCmd.PersistentFlags().DurationVarP(&createArgs.interval, "interval", "", 0,
+		"reconciliation interval, must be at least 1m, defaults to 1m for Git repositories, 5m for buckets, OCI and image repositories, and 10m for the other kinds")
 	createCmd.PersistentFlags().BoolVar(&createArgs.export, "export", false, "export in YAML format to stdout")
 	createCmd.PersistentFlags().StringSliceVar(&createArgs.labels, "label", nil,
 		"set labels on the resource (can specify multiple labels with commas: label1=value1,label2=value2)")
+	createCmd.PersistentFlags().BoolVar(&createArgs.createNamespace, "create-namespace", false,
+		"create the namespace of the resource if it does not exist, when used with --export the Namespace is included in the output")
+	createCmd.PersistentFlags().BoolVar(&createArgs.overwrite, "overwrite", false,
+		"update the resource even if it is managed by a Flux Kustomization, defaults to true when stdin is not a terminal")
+	createCmd.PersistentFlags().BoolVar(&createArgs.suspend, "suspend", false,
+		"create the resource with its reconciliation suspended, it can be started later with flux resume")
 	createCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if err := confirmContext(cmd); err != nil {
+			return err
+		}
+
+		if !cmd.Flags().Changed("overwrite") {
+			createArgs.overwrite = !term.IsTerminal(int(os.Stdin.Fd()))
+		}
+
 		if len(args) < 1 {
 			return fmt.Errorf("name is required")
 		}
 
+		interval, err := createInterval(cmd, createArgs.interval)
+		if err != nil {
+			return err
+		}
+		createArgs.interval = interval
+
+		if createArgs.suspend && !isSuspendableCreate(cmd) {
+			return fmt.Errorf("--suspend is not supported by %s", cmd.CommandPath())
+		}
+
 		name := args[0]
 		if !validateObjectName(name) {
 			return fmt.Errorf("name '%s' is invalid, it should adhere to standard defined in RFC 1123, the name can only contain alphanumeric characters or '-'", name)
@@ -67,6 +109,49 @@ func init() {
 	rootCmd.AddCommand(createCmd)
 }
 
+// createInterval returns the interval of the object created by cmd, which
+// defaults per kind to how often the source of the object is expected to
+// change. Shorter intervals than lint.MinInterval are rejected, as they
+// put load on the providers without bringing changes in faster in practice.
+func createInterval(cmd *cobra.Command, interval time.Duration) (time.Duration, error) {
+	if interval == 0 {
+		switch cmd {
+		case createSourceGitCmd:
+			return time.Minute, nil
+		case createSourceBucketCmd, createSourceOCIRepositoryCmd, createImageRepositoryCmd:
+			return 5 * time.Minute, nil
+		default:
+			return 10 * time.Minute, nil
+		}
+	}
+	if interval < lint.MinInterval {
+		return 0, fmt.Errorf("--interval must be at least %s, got %s", lint.MinInterval, interval)
+	}
+	return interval, nil
+}
+
+// isSuspendableCreate returns false for the create commands of objects
+// which have no reconciliation to suspend, such as secrets and tenants.
+func isSuspendableCreate(cmd *cobra.Command) bool {
+	for c := cmd; c != nil; c = c.Parent() {
+		if c == createSecretCmd || c == createTenantCmd {
+			return false
+		}
+	}
+	return true
+}
+
+// skipSuspendedWait returns true when the object is created with --suspend,
+// in which case the controller won't reconcile it and waiting for it to
+// become ready would time out.
+func skipSuspendedWait(kind string) bool {
+	if !createArgs.suspend {
+		return false
+	}
+	logger.Successf("%s is suspended, skipping the wait for reconciliation", kind)
+	return true
+}
+
 // upsertable is an interface for values that can be used in `upsert`.
 type upsertable interface {
 	adapter
@@ -91,7 +176,18 @@ func (names apiType) upsert(ctx context.Context, kubeClient client.Client, objec
 		Name:      object.GetName(),
 	}
 
-	op, err := controllerutil.CreateOrUpdate(ctx, kubeClient, object.asClientObject(), mutate)
+	if err := createNamespace(ctx, kubeClient, nsname.Namespace); err != nil {
+		return nsname, err
+	}
+
+	op, err := controllerutil.CreateOrUpdate(ctx, kubeClient, object.asClientObject(), func() error {
+		if object.asClientObject().GetResourceVersion() != "" {
+			if err := checkOverwrite(names.kind, object.asClientObject()); err != nil {
+				return err
+			}
+		}
+		return mutate()
+	})
 	if err != nil {
 		return nsname, err
 	}
@@ -105,6 +201,102 @@ func (names apiType) upsert(ctx context.Context, kubeClient client.Client, objec
 	return nsname, nil
 }
 
+// checkOverwrite guards the update of an existing object that is managed by
+// a Flux Kustomization, as the changes made by the create command would be
+// reverted on the next reconciliation. It returns an error unless
+// --overwrite is set, in which case only a warning is logged.
+func checkOverwrite(kind string, existing client.Object) error {
+	ksName, ksNamespace, ok := kustomizationManager(existing)
+	if !ok {
+		return nil
+	}
+
+	msg := fmt.Sprintf("%s %s/%s is managed by Kustomization %s/%s, changes will be reverted on the next reconciliation",
+		kind, existing.GetNamespace(), existing.GetName(), ksNamespace, ksName)
+	if !createArgs.overwrite {
+		return fmt.Errorf("%s, use --overwrite to update it anyway", msg)
+	}
+	logger.Warningf(msg)
+	return nil
+}
+
+// noCrossNamespaceRefsArg is the controller flag which makes the controllers
+// reject references to sources in other namespaces.
+const noCrossNamespaceRefsArg = "--no-cross-namespace-refs"
+
+// checkCrossNamespaceRef returns an error if the object references a source
+// in another namespace and the controller reconciling it runs with
+// cross-namespace references disabled. The check is skipped when the
+// controller Deployment can't be found or read.
+func checkCrossNamespaceRef(ctx context.Context, kubeClient client.Client, controller, namespace, sourceNamespace string) error {
+	if sourceNamespace == "" || sourceNamespace == namespace {
+		return nil
+	}
+
+	var list appsv1.DeploymentList
+	selector := client.MatchingLabels{manifestgen.PartOfLabelKey: manifestgen.PartOfLabelValue}
+	if err := kubeClient.List(ctx, &list, selector); err != nil {
+		return nil
+	}
+	for _, deployment := range list.Items {
+		if deployment.Name != controller {
+			continue
+		}
+		for _, container := range deployment.Spec.Template.Spec.Containers {
+			if crossNamespaceRefsDisabled(container.Args) {
+				return fmt.Errorf("%s in %s namespace runs with %s, the source in namespace '%s' can't be referenced from namespace '%s'",
+					controller, deployment.Namespace, noCrossNamespaceRefsArg, sourceNamespace, namespace)
+			}
+		}
+	}
+	return nil
+}
+
+// crossNamespaceRefsDisabled returns true if the container args contain the
+// flag disabling cross-namespace references.
+func crossNamespaceRefsDisabled(args []string) bool {
+	for _, arg := range args {
+		if arg == noCrossNamespaceRefsArg {
+			return true
+		}
+		if strings.HasPrefix(arg, noCrossNamespaceRefsArg+"=") {
+			disabled, err := strconv.ParseBool(strings.TrimPrefix(arg, noCrossNamespaceRefsArg+"="))
+			return err == nil && disabled
+		}
+	}
+	return false
+}
+
+// lintCreated warns about interval and timeout settings of a generated
+// object which are unlikely to behave as intended. When the object is applied,
+// the health check timeouts are also compared with the --timeout the command
+// waits for the reconciliation.
+func lintCreated(obj client.Object) {
+	gvk, err := apiutil.GVKForObject(obj, utils.NewScheme())
+	if err != nil {
+		return
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return
+	}
+	u := &unstructured.Unstructured{Object: content}
+	u.SetGroupVersionKind(gvk)
+
+	opts := lint.Options{}
+	if !createArgs.export {
+		opts.WaitTimeout = rootArgs.timeout
+	}
+	findings, err := lint.Lint(u, opts)
+	if err != nil {
+		logger.Warningf(err.Error())
+		return
+	}
+	for _, f := range findings {
+		logger.Warningf("%s", f.Message)
+	}
+}
+
 type upsertWaitable interface {
 	upsertable
 	statusable
@@ -117,7 +309,7 @@ func (names apiType) upsertAndWait(object upsertWaitable, mutate func() error) e
 	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(kubeconfigArgs, kubeclientOptions) // NB globals
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions) // NB globals
 	if err != nil {
 		return err
 	}
@@ -130,6 +322,10 @@ func (names apiType) upsertAndWait(object upsertWaitable, mutate func() error) e
 		return err
 	}
 
+	if skipSuspendedWait(names.kind) {
+		return nil
+	}
+
 	logger.Waitingf("waiting for %s reconciliation", names.kind)
 	if err := wait.PollImmediate(rootArgs.pollInterval, rootArgs.timeout,
 		isReady(ctx, kubeClient, namespacedName, object)); err != nil {
@@ -139,6 +335,62 @@ func (names apiType) upsertAndWait(object upsertWaitable, mutate func() error) e
 	return nil
 }
 
+// exportCreateNamespace prints the Namespace targeted by a create sub-command
+// when --create-namespace is set. It is called by the sub-commands once the
+// resource is validated, ahead of the exported resource. On the cluster, the
+// namespace is created by the upsert functions instead.
+func exportCreateNamespace() error {
+	if !createArgs.createNamespace {
+		return nil
+	}
+	return exportNamespace(*kubeconfigArgs.Namespace)
+}
+
+// exportNamespace prints the Namespace targeted by a create sub-command
+// ahead of the exported resource.
+func exportNamespace(name string) error {
+	namespace := corev1.Namespace{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Namespace",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+	}
+
+	data, err := yaml.Marshal(namespace)
+	if err != nil {
+		return err
+	}
+	data = bytes.Replace(data, []byte("spec: {}\n"), []byte(""), 1)
+	rootCmd.Println("---")
+	rootCmd.Println(resourceToString(data))
+	return nil
+}
+
+// createNamespace creates the namespace of the applied resources if it
+// does not exist and --create-namespace is set. It is called by the upsert
+// functions, so that nothing is created before the resources are validated.
+func createNamespace(ctx context.Context, kubeClient client.Client, name string) error {
+	if !createArgs.createNamespace {
+		return nil
+	}
+
+	var existing corev1.Namespace
+	err := kubeClient.Get(ctx, client.ObjectKey{Name: name}, &existing)
+	if err == nil || !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	logger.Actionf("applying namespace %s", name)
+	namespace := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	if err := kubeClient.Create(ctx, &namespace); err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create namespace %s: %w", name, err)
+	}
+	return nil
+}
+
 func parseLabels() (map[string]string, error) {
 	result := make(map[string]string)
 	for _, label := range createArgs.labels {
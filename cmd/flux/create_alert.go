@@ -108,18 +108,23 @@ func createAlertCmdRun(cmd *cobra.Command, args []string) error {
 			},
 			EventSeverity: alertArgs.eventSeverity,
 			EventSources:  eventSources,
-			Suspend:       false,
+			Suspend:       createArgs.suspend,
 		},
 	}
 
+	lintCreated(&alert)
+
 	if createArgs.export {
+		if err := exportCreateNamespace(); err != nil {
+			return err
+		}
 		return printExport(exportAlert(&alert))
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(kubeconfigArgs, kubeclientOptions)
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
 	if err != nil {
 		return err
 	}
@@ -130,6 +135,10 @@ func createAlertCmdRun(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if skipSuspendedWait("Alert") {
+		return nil
+	}
+
 	logger.Waitingf("waiting for Alert reconciliation")
 	if err := wait.PollImmediate(rootArgs.pollInterval, rootArgs.timeout,
 		isAlertReady(ctx, kubeClient, namespacedName, &alert)); err != nil {
@@ -146,6 +155,10 @@ func upsertAlert(ctx context.Context, kubeClient client.Client,
 		Name:      alert.GetName(),
 	}
 
+	if err := createNamespace(ctx, kubeClient, namespacedName.Namespace); err != nil {
+		return namespacedName, err
+	}
+
 	var existing notificationv1.Alert
 	err := kubeClient.Get(ctx, namespacedName, &existing)
 	if err != nil {
@@ -160,6 +173,10 @@ func upsertAlert(ctx context.Context, kubeClient client.Client,
 		return namespacedName, err
 	}
 
+	if err := checkOverwrite("Alert", &existing); err != nil {
+		return namespacedName, err
+	}
+
 	existing.Labels = alert.Labels
 	existing.Spec = alert.Spec
 	if err := kubeClient.Update(ctx, &existing); err != nil {
@@ -183,7 +200,7 @@ func isAlertReady(ctx context.Context, kubeClient client.Client,
 			case metav1.ConditionTrue:
 				return true, nil
 			case metav1.ConditionFalse:
-				return false, fmt.Errorf(c.Message)
+				return false, conditionError(alert, c)
 			}
 		}
 		return false, nil
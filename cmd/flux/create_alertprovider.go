@@ -30,8 +30,6 @@ import (
 
 	notificationv1 "github.com/fluxcd/notification-controller/api/v1beta2"
 	"github.com/fluxcd/pkg/apis/meta"
-
-	"github.com/fluxcd/flux2/internal/utils"
 )
 
 var createAlertProviderCmd = &cobra.Command{
@@ -99,6 +97,7 @@ func createAlertProviderCmdRun(cmd *cobra.Command, args []string) error {
 			Channel:  alertProviderArgs.channel,
 			Username: alertProviderArgs.username,
 			Address:  alertProviderArgs.address,
+			Suspend:  createArgs.suspend,
 		},
 	}
 
@@ -108,14 +107,19 @@ func createAlertProviderCmdRun(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	lintCreated(&provider)
+
 	if createArgs.export {
+		if err := exportCreateNamespace(); err != nil {
+			return err
+		}
 		return printExport(exportAlertProvider(&provider))
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(kubeconfigArgs, kubeclientOptions)
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
 	if err != nil {
 		return err
 	}
@@ -126,6 +130,10 @@ func createAlertProviderCmdRun(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if skipSuspendedWait("Provider") {
+		return nil
+	}
+
 	logger.Waitingf("waiting for Provider reconciliation")
 	if err := wait.PollImmediate(rootArgs.pollInterval, rootArgs.timeout,
 		isAlertProviderReady(ctx, kubeClient, namespacedName, &provider)); err != nil {
@@ -144,6 +152,10 @@ func upsertAlertProvider(ctx context.Context, kubeClient client.Client,
 		Name:      provider.GetName(),
 	}
 
+	if err := createNamespace(ctx, kubeClient, namespacedName.Namespace); err != nil {
+		return namespacedName, err
+	}
+
 	var existing notificationv1.Provider
 	err := kubeClient.Get(ctx, namespacedName, &existing)
 	if err != nil {
@@ -158,6 +170,10 @@ func upsertAlertProvider(ctx context.Context, kubeClient client.Client,
 		return namespacedName, err
 	}
 
+	if err := checkOverwrite("Provider", &existing); err != nil {
+		return namespacedName, err
+	}
+
 	existing.Labels = provider.Labels
 	existing.Spec = provider.Spec
 	if err := kubeClient.Update(ctx, &existing); err != nil {
@@ -181,7 +197,7 @@ func isAlertProviderReady(ctx context.Context, kubeClient client.Client,
 			case metav1.ConditionTrue:
 				return true, nil
 			case metav1.ConditionFalse:
-				return false, fmt.Errorf(c.Message)
+				return false, conditionError(provider, c)
 			}
 		}
 		return false, nil
@@ -18,22 +18,24 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"io"
 	"os"
-	"strings"
 	"time"
 
 	"github.com/fluxcd/flux2/internal/flags"
-	"github.com/fluxcd/flux2/internal/utils"
+	"github.com/fluxcd/flux2/pkg/apigen"
+	"github.com/fluxcd/flux2/pkg/manifestgen"
+	"github.com/fluxcd/flux2/pkg/printers"
 	"github.com/fluxcd/pkg/apis/meta"
-	"github.com/fluxcd/pkg/runtime/transform"
 
+	"github.com/gonvenience/ytbx"
+	"github.com/homeport/dyff/pkg/dyff"
+	"github.com/manifoldco/promptui"
 	"github.com/spf13/cobra"
-	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"golang.org/x/term"
 	"k8s.io/apimachinery/pkg/api/errors"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -73,6 +75,13 @@ var createHelmReleaseCmd = &cobra.Command{
     --values=./my-values1.yaml \
     --values=./my-values2.yaml
 
+  # Create a HelmRelease with values inlined from a URL and an OCI artifact containing a values.yaml
+  flux create hr podinfo \
+    --source=HelmRepository/podinfo \
+    --chart=podinfo \
+    --values=https://raw.githubusercontent.com/org/fleet/main/values/base.yaml \
+    --values=oci://ghcr.io/org/values/podinfo:production
+
   # Create a HelmRelease with values from a Kubernetes secret
   kubectl -n app create secret generic my-secret-values \
 	--from-file=values.yaml=/path/to/my-secret-values.yaml
@@ -105,7 +114,14 @@ var createHelmReleaseCmd = &cobra.Command{
     --source=HelmRepository/podinfo \
     --chart=podinfo \
     --values=./values.yaml \
-    --export > podinfo-release.yaml`,
+    --export > podinfo-release.yaml
+
+  # Update an existing HelmRelease without asking for the confirmation of the changes
+  flux create hr podinfo \
+    --source=HelmRepository/podinfo \
+    --chart=podinfo \
+    --values=./values.yaml \
+    --yes`,
 	RunE: createHelmReleaseCmdRun,
 }
 
@@ -124,12 +140,11 @@ type helmReleaseFlags struct {
 	reconcileStrategy   string
 	chartInterval       time.Duration
 	kubeConfigSecretRef string
+	yes                 bool
 }
 
 var helmReleaseArgs helmReleaseFlags
 
-var supportedHelmReleaseValuesFromKinds = []string{"Secret", "ConfigMap"}
-
 func init() {
 	createHelmReleaseCmd.Flags().StringVar(&helmReleaseArgs.name, "release-name", "", "name used for the Helm release, defaults to a composition of '[<target-namespace>-]<HelmRelease-name>'")
 	createHelmReleaseCmd.Flags().Var(&helmReleaseArgs.source, "source", helmReleaseArgs.source.Description())
@@ -141,20 +156,17 @@ func init() {
 	createHelmReleaseCmd.Flags().StringVar(&helmReleaseArgs.saName, "service-account", "", "the name of the service account to impersonate when reconciling this HelmRelease")
 	createHelmReleaseCmd.Flags().StringVar(&helmReleaseArgs.reconcileStrategy, "reconcile-strategy", "ChartVersion", "the reconcile strategy for helm chart created by the helm release(accepted values: Revision and ChartRevision)")
 	createHelmReleaseCmd.Flags().DurationVarP(&helmReleaseArgs.chartInterval, "chart-interval", "", 0, "the interval of which to check for new chart versions")
-	createHelmReleaseCmd.Flags().StringSliceVar(&helmReleaseArgs.valuesFiles, "values", nil, "local path to values.yaml files, also accepts comma-separated values")
+	createHelmReleaseCmd.Flags().StringSliceVar(&helmReleaseArgs.valuesFiles, "values", nil, "local path or http(s):// and oci:// URL of values.yaml files, the remote values are fetched and inlined at create time, also accepts comma-separated values")
 	createHelmReleaseCmd.Flags().StringSliceVar(&helmReleaseArgs.valuesFrom, "values-from", nil, "a Kubernetes object reference that contains the values.yaml data key in the format '<kind>/<name>', where kind must be one of: (Secret,ConfigMap)")
 	createHelmReleaseCmd.Flags().Var(&helmReleaseArgs.crds, "crds", helmReleaseArgs.crds.Description())
 	createHelmReleaseCmd.Flags().StringVar(&helmReleaseArgs.kubeConfigSecretRef, "kubeconfig-secret-ref", "", "the name of the Kubernetes Secret that contains a key with the kubeconfig file for connecting to a remote cluster")
+	createHelmReleaseCmd.Flags().BoolVar(&helmReleaseArgs.yes, "yes", false, "apply the changes to an existing HelmRelease without asking for confirmation, the confirmation is also skipped when stdin is not a terminal")
 	createCmd.AddCommand(createHelmReleaseCmd)
 }
 
 func createHelmReleaseCmdRun(cmd *cobra.Command, args []string) error {
 	name := args[0]
 
-	if helmReleaseArgs.chart == "" {
-		return fmt.Errorf("chart name or path is required")
-	}
-
 	sourceLabels, err := parseLabels()
 	if err != nil {
 		return err
@@ -164,147 +176,92 @@ func createHelmReleaseCmdRun(cmd *cobra.Command, args []string) error {
 		logger.Generatef("generating HelmRelease")
 	}
 
-	if !validateStrategy(helmReleaseArgs.reconcileStrategy) {
-		return fmt.Errorf("'%s' is an invalid reconcile strategy(valid: Revision, ChartVersion)",
-			helmReleaseArgs.reconcileStrategy)
-	}
-
-	helmRelease := helmv2.HelmRelease{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      name,
-			Namespace: *kubeconfigArgs.Namespace,
-			Labels:    sourceLabels,
-		},
-		Spec: helmv2.HelmReleaseSpec{
-			ReleaseName: helmReleaseArgs.name,
-			DependsOn:   utils.MakeDependsOn(helmReleaseArgs.dependsOn),
-			Interval: metav1.Duration{
-				Duration: createArgs.interval,
-			},
-			TargetNamespace: helmReleaseArgs.targetNamespace,
-
-			Chart: helmv2.HelmChartTemplate{
-				Spec: helmv2.HelmChartTemplateSpec{
-					Chart:   helmReleaseArgs.chart,
-					Version: helmReleaseArgs.chartVersion,
-					SourceRef: helmv2.CrossNamespaceObjectReference{
-						Kind:      helmReleaseArgs.source.Kind,
-						Name:      helmReleaseArgs.source.Name,
-						Namespace: helmReleaseArgs.source.Namespace,
-					},
-					ReconcileStrategy: helmReleaseArgs.reconcileStrategy,
-				},
-			},
-			Suspend: false,
-		},
+	tmpDir, err := manifestgen.MkdirTempAbs("", "flux-values-")
+	if err != nil {
+		return err
 	}
+	defer os.RemoveAll(tmpDir)
 
-	if helmReleaseArgs.kubeConfigSecretRef != "" {
-		helmRelease.Spec.KubeConfig = &helmv2.KubeConfig{
-			SecretRef: meta.SecretKeyReference{
-				Name: helmReleaseArgs.kubeConfigSecretRef,
-			},
-		}
+	fetchCtx, fetchCancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	defer fetchCancel()
+	valuesFiles, remotes, err := fetchValuesFiles(fetchCtx, helmReleaseArgs.valuesFiles, tmpDir)
+	if err != nil {
+		return err
 	}
-
-	if helmReleaseArgs.chartInterval != 0 {
-		helmRelease.Spec.Chart.Spec.Interval = &metav1.Duration{
-			Duration: helmReleaseArgs.chartInterval,
+	if !createArgs.export {
+		for _, r := range remotes {
+			logger.Actionf("inlining values from %s (%s)", r.url, r.checksum)
 		}
 	}
 
-	if helmReleaseArgs.createNamespace {
-		if helmRelease.Spec.Install == nil {
-			helmRelease.Spec.Install = &helmv2.Install{}
-		}
-
-		helmRelease.Spec.Install.CreateNamespace = helmReleaseArgs.createNamespace
-	}
-
-	if helmReleaseArgs.saName != "" {
-		helmRelease.Spec.ServiceAccountName = helmReleaseArgs.saName
-	}
-
-	if helmReleaseArgs.crds != "" {
-		if helmRelease.Spec.Install == nil {
-			helmRelease.Spec.Install = &helmv2.Install{}
-		}
-
-		helmRelease.Spec.Install.CRDs = helmv2.Create
-		helmRelease.Spec.Upgrade = &helmv2.Upgrade{CRDs: helmv2.CRDsPolicy(helmReleaseArgs.crds.String())}
+	helmRelease, err := apigen.HelmRelease(apigen.HelmReleaseOptions{
+		ObjectOptions: apigen.ObjectOptions{
+			Name:      name,
+			Namespace: *kubeconfigArgs.Namespace,
+			Labels:    sourceLabels,
+			Interval:  createArgs.interval,
+			Suspend:   createArgs.suspend,
+		},
+		ReleaseName:         helmReleaseArgs.name,
+		DependsOn:           helmReleaseArgs.dependsOn,
+		TargetNamespace:     helmReleaseArgs.targetNamespace,
+		CreateNamespace:     helmReleaseArgs.createNamespace,
+		SourceKind:          helmReleaseArgs.source.Kind,
+		SourceName:          helmReleaseArgs.source.Name,
+		SourceNamespace:     helmReleaseArgs.source.Namespace,
+		Chart:               helmReleaseArgs.chart,
+		ChartVersion:        helmReleaseArgs.chartVersion,
+		ChartInterval:       helmReleaseArgs.chartInterval,
+		ReconcileStrategy:   helmReleaseArgs.reconcileStrategy,
+		ValuesFiles:         valuesFiles,
+		ValuesFrom:          helmReleaseArgs.valuesFrom,
+		ServiceAccountName:  helmReleaseArgs.saName,
+		KubeConfigSecretRef: helmReleaseArgs.kubeConfigSecretRef,
+		CRDs:                helmReleaseArgs.crds.String(),
+	})
+	if err != nil {
+		return err
 	}
 
-	if len(helmReleaseArgs.valuesFiles) > 0 {
-		valuesMap := make(map[string]interface{})
-		for _, v := range helmReleaseArgs.valuesFiles {
-			data, err := os.ReadFile(v)
-			if err != nil {
-				return fmt.Errorf("reading values from %s failed: %w", v, err)
-			}
-
-			jsonBytes, err := yaml.YAMLToJSON(data)
-			if err != nil {
-				return fmt.Errorf("converting values to JSON from %s failed: %w", v, err)
-			}
-
-			jsonMap := make(map[string]interface{})
-			if err := json.Unmarshal(jsonBytes, &jsonMap); err != nil {
-				return fmt.Errorf("unmarshaling values from %s failed: %w", v, err)
-			}
-
-			valuesMap = transform.MergeMaps(valuesMap, jsonMap)
-		}
+	lintCreated(helmRelease)
 
-		jsonRaw, err := json.Marshal(valuesMap)
-		if err != nil {
-			return fmt.Errorf("marshaling values failed: %w", err)
+	if createArgs.export {
+		if err := exportCreateNamespace(); err != nil {
+			return err
 		}
-
-		helmRelease.Spec.Values = &apiextensionsv1.JSON{Raw: jsonRaw}
+		return writeExportWithValues(rootCmd.OutOrStdout(), exportHelmRelease(helmRelease), remotes)
 	}
 
-	if len(helmReleaseArgs.valuesFrom) != 0 {
-		values := []helmv2.ValuesReference{}
-		for _, value := range helmReleaseArgs.valuesFrom {
-			sourceKind, sourceName := utils.ParseObjectKindName(value)
-			if sourceKind == "" {
-				return fmt.Errorf("invalid Kubernetes object reference '%s', must be in format <kind>/<name>", value)
-			}
-			cleanSourceKind, ok := utils.ContainsEqualFoldItemString(supportedHelmReleaseValuesFromKinds, sourceKind)
-			if !ok {
-				return fmt.Errorf("reference kind '%s' is not supported, must be one of: %s",
-					sourceKind, strings.Join(supportedHelmReleaseValuesFromKinds, ", "))
-			}
+	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	defer cancel()
 
-			values = append(values, helmv2.ValuesReference{
-				Name: sourceName,
-				Kind: cleanSourceKind,
-			})
-		}
-		helmRelease.Spec.ValuesFrom = values
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
+	if err != nil {
+		return err
 	}
 
-	if createArgs.export {
-		return printExport(exportHelmRelease(&helmRelease))
+	if err := checkCrossNamespaceRef(ctx, kubeClient, "helm-controller",
+		helmRelease.Namespace, helmRelease.Spec.Chart.Spec.SourceRef.Namespace); err != nil {
+		return err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
-	defer cancel()
-
-	kubeClient, err := utils.KubeClient(kubeconfigArgs, kubeclientOptions)
-	if err != nil {
+	if err := confirmHelmReleaseChanges(ctx, kubeClient, helmRelease); err != nil {
 		return err
 	}
 
 	logger.Actionf("applying HelmRelease")
-	namespacedName, err := upsertHelmRelease(ctx, kubeClient, &helmRelease)
+	namespacedName, err := upsertHelmRelease(ctx, kubeClient, helmRelease)
 	if err != nil {
 		return err
 	}
 
+	if skipSuspendedWait("HelmRelease") {
+		return nil
+	}
+
 	logger.Waitingf("waiting for HelmRelease reconciliation")
 	if err := wait.PollImmediate(rootArgs.pollInterval, rootArgs.timeout,
-		isHelmReleaseReady(ctx, kubeClient, namespacedName, &helmRelease)); err != nil {
+		isHelmReleaseReady(ctx, kubeClient, namespacedName, helmRelease)); err != nil {
 		return err
 	}
 	logger.Successf("HelmRelease %s is ready", name)
@@ -320,6 +277,10 @@ func upsertHelmRelease(ctx context.Context, kubeClient client.Client,
 		Name:      helmRelease.GetName(),
 	}
 
+	if err := createNamespace(ctx, kubeClient, namespacedName.Namespace); err != nil {
+		return namespacedName, err
+	}
+
 	var existing helmv2.HelmRelease
 	err := kubeClient.Get(ctx, namespacedName, &existing)
 	if err != nil {
@@ -334,6 +295,10 @@ func upsertHelmRelease(ctx context.Context, kubeClient client.Client,
 		return namespacedName, err
 	}
 
+	if err := checkOverwrite("HelmRelease", &existing); err != nil {
+		return namespacedName, err
+	}
+
 	existing.Labels = helmRelease.Labels
 	existing.Spec = helmRelease.Spec
 	if err := kubeClient.Update(ctx, &existing); err != nil {
@@ -344,6 +309,91 @@ func upsertHelmRelease(ctx context.Context, kubeClient client.Client,
 	return namespacedName, nil
 }
 
+// confirmHelmReleaseChanges prints the differences between the HelmRelease
+// found in the cluster and the generated one, and asks for confirmation before
+// they are applied, unless --yes is set or stdin is not a terminal. It is a
+// no-op when the HelmRelease does not exist yet.
+func confirmHelmReleaseChanges(ctx context.Context, kubeClient client.Client, helmRelease *helmv2.HelmRelease) error {
+	var existing helmv2.HelmRelease
+	err := kubeClient.Get(ctx, client.ObjectKeyFromObject(helmRelease), &existing)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	logger.Actionf("comparing HelmRelease with the in-cluster object")
+	changed, err := diffHelmRelease(&existing, helmRelease, rootCmd.OutOrStdout())
+	if err != nil {
+		return err
+	}
+	if !changed {
+		logger.Successf("no changes detected")
+		return nil
+	}
+
+	if !helmReleaseArgs.yes && term.IsTerminal(int(os.Stdin.Fd())) {
+		prompt := promptui.Prompt{
+			Label:     "Are you sure you want to apply these changes to the HelmRelease",
+			IsConfirm: true,
+		}
+		if _, err := prompt.Run(); err != nil {
+			return fmt.Errorf("aborting")
+		}
+	}
+	return nil
+}
+
+// diffHelmRelease writes a report of the differences in labels and spec
+// between the live and the updated HelmRelease to w, and returns true if
+// any were found.
+func diffHelmRelease(live, updated *helmv2.HelmRelease, w io.Writer) (bool, error) {
+	from, err := helmReleaseDiffInput("live", live)
+	if err != nil {
+		return false, err
+	}
+	to, err := helmReleaseDiffInput("updated", updated)
+	if err != nil {
+		return false, err
+	}
+
+	report, err := dyff.CompareInputFiles(from, to,
+		dyff.IgnoreOrderChanges(false),
+		dyff.KubernetesEntityDetection(false),
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to compare HelmRelease objects: %w", err)
+	}
+	if len(report.Diffs) == 0 {
+		return false, nil
+	}
+
+	if err := printers.NewDyffPrinter().Print(w, report); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func helmReleaseDiffInput(location string, hr *helmv2.HelmRelease) (ytbx.InputFile, error) {
+	data, err := yaml.Marshal(struct {
+		Labels map[string]string      `json:"labels,omitempty"`
+		Spec   helmv2.HelmReleaseSpec `json:"spec"`
+	}{
+		Labels: hr.GetLabels(),
+		Spec:   hr.Spec,
+	})
+	if err != nil {
+		return ytbx.InputFile{}, fmt.Errorf("failed to marshal %s HelmRelease: %w", location, err)
+	}
+
+	docs, err := ytbx.LoadDocuments(data)
+	if err != nil {
+		return ytbx.InputFile{}, fmt.Errorf("failed to load %s HelmRelease: %w", location, err)
+	}
+	return ytbx.InputFile{Location: location, Documents: docs}, nil
+}
+
 func isHelmReleaseReady(ctx context.Context, kubeClient client.Client,
 	namespacedName types.NamespacedName, helmRelease *helmv2.HelmRelease) wait.ConditionFunc {
 	return func() (bool, error) {
@@ -360,15 +410,3 @@ func isHelmReleaseReady(ctx context.Context, kubeClient client.Client,
 		return apimeta.IsStatusConditionTrue(helmRelease.Status.Conditions, meta.ReadyCondition), nil
 	}
 }
-
-func validateStrategy(input string) bool {
-	allowedStrategy := []string{"Revision", "ChartVersion"}
-
-	for _, strategy := range allowedStrategy {
-		if strategy == input {
-			return true
-		}
-	}
-
-	return false
-}
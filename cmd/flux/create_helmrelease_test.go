@@ -0,0 +1,88 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+
+	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
+)
+
+func TestDiffHelmRelease(t *testing.T) {
+	newHelmRelease := func(values string) *helmv2.HelmRelease {
+		hr := &helmv2.HelmRelease{}
+		hr.Name = "podinfo"
+		hr.Namespace = "default"
+		hr.Spec.Chart.Spec.Chart = "podinfo"
+		if values != "" {
+			hr.Spec.Values = &apiextensionsv1.JSON{Raw: []byte(values)}
+		}
+		return hr
+	}
+
+	tests := []struct {
+		name     string
+		live     *helmv2.HelmRelease
+		updated  *helmv2.HelmRelease
+		changed  bool
+		contains string
+	}{
+		{
+			name:    "no changes",
+			live:    newHelmRelease(`{"replicaCount":2}`),
+			updated: newHelmRelease(`{"replicaCount":2}`),
+			changed: false,
+		},
+		{
+			name:     "changed values",
+			live:     newHelmRelease(`{"replicaCount":3}`),
+			updated:  newHelmRelease(`{"replicaCount":2}`),
+			changed:  true,
+			contains: "replicaCount",
+		},
+		{
+			name:     "removed values",
+			live:     newHelmRelease(`{"replicaCount":3}`),
+			updated:  newHelmRelease(""),
+			changed:  true,
+			contains: "values",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			changed, err := diffHelmRelease(tt.live, tt.updated, &buf)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if changed != tt.changed {
+				t.Errorf("expected changed to be %v, got %v", tt.changed, changed)
+			}
+			if tt.contains != "" && !strings.Contains(buf.String(), tt.contains) {
+				t.Errorf("expected diff to contain %q, got:\n%s", tt.contains, buf.String())
+			}
+			if !tt.changed && buf.Len() > 0 {
+				t.Errorf("expected no output, got:\n%s", buf.String())
+			}
+		})
+	}
+}
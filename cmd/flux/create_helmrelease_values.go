@@ -0,0 +1,154 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	oci "github.com/fluxcd/pkg/oci/client"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+)
+
+// helmReleaseValuesFileName is the file read from the OCI artifacts
+// given to --values.
+const helmReleaseValuesFileName = "values.yaml"
+
+// maxRemoteValuesSize is the maximum size of a values file fetched over HTTP.
+const maxRemoteValuesSize = 10 << 20
+
+// remoteValues records the checksum of a values file fetched from a URL,
+// the values are inlined in the HelmRelease at create time.
+type remoteValues struct {
+	url      string
+	checksum string
+}
+
+// isRemoteValues returns true if the --values entry is an http(s):// or
+// oci:// URL.
+func isRemoteValues(ref string) bool {
+	return strings.HasPrefix(ref, "http://") || strings.HasPrefix(ref, "https://") ||
+		strings.HasPrefix(ref, sourcev1.OCIRepositoryPrefix)
+}
+
+// fetchValuesFiles downloads the values given as URLs to dir and returns the
+// local paths of all the values files in the order they were given, along
+// with the checksums of the remote ones. The values of an OCI artifact are
+// read from the values.yaml file at the root of the artifact.
+func fetchValuesFiles(ctx context.Context, refs []string, dir string) ([]string, []remoteValues, error) {
+	var files []string
+	var remotes []remoteValues
+	for i, ref := range refs {
+		if !isRemoteValues(ref) {
+			files = append(files, ref)
+			continue
+		}
+
+		var file string
+		var err error
+		if strings.HasPrefix(ref, sourcev1.OCIRepositoryPrefix) {
+			file, err = pullValuesArtifact(ctx, ref, filepath.Join(dir, fmt.Sprintf("artifact-%d", i)))
+		} else {
+			file, err = downloadValues(ctx, ref, filepath.Join(dir, fmt.Sprintf("values-%d.yaml", i)))
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading values from %s failed: %w", ref, err)
+		}
+		files = append(files, file)
+		remotes = append(remotes, remoteValues{
+			url:      ref,
+			checksum: fmt.Sprintf("sha256:%x", sha256.Sum256(data)),
+		})
+	}
+	return files, remotes, nil
+}
+
+// downloadValues writes the values served at the http(s) URL to file.
+func downloadValues(ctx context.Context, url, file string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("downloading values from %s failed: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("downloading values from %s failed: %s", url, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteValuesSize+1))
+	if err != nil {
+		return "", fmt.Errorf("downloading values from %s failed: %w", url, err)
+	}
+	if len(data) > maxRemoteValuesSize {
+		return "", fmt.Errorf("values from %s exceed the maximum size of %d bytes", url, maxRemoteValuesSize)
+	}
+	if err := os.WriteFile(file, data, 0o600); err != nil {
+		return "", err
+	}
+	return file, nil
+}
+
+// pullValuesArtifact pulls the OCI artifact to dir and returns the path of
+// its values file.
+func pullValuesArtifact(ctx context.Context, ref, dir string) (string, error) {
+	url, err := oci.ParseArtifactURL(ref)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	if _, err := oci.NewLocalClient().Pull(ctx, url, dir); err != nil {
+		return "", fmt.Errorf("failed to pull values from %s: %w", ref, err)
+	}
+	file := filepath.Join(dir, helmReleaseValuesFileName)
+	if _, err := os.Stat(file); err != nil {
+		return "", fmt.Errorf("values artifact %s does not contain %s", ref, helmReleaseValuesFileName)
+	}
+	return file, nil
+}
+
+// writeExportWithValues writes the exported object preceded by a comment
+// pinning the checksum of each values file inlined from a URL.
+func writeExportWithValues(w io.Writer, export interface{}, remotes []remoteValues) error {
+	var buf bytes.Buffer
+	if err := writeExport(&buf, export); err != nil {
+		return err
+	}
+	var comments strings.Builder
+	for _, r := range remotes {
+		fmt.Fprintf(&comments, "# values: %s %s\n", r.url, r.checksum)
+	}
+	data := strings.Replace(buf.String(), "---\n", "---\n"+comments.String(), 1)
+	_, err := io.WriteString(w, data)
+	return err
+}
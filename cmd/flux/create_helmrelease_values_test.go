@@ -0,0 +1,90 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
+
+	"github.com/fluxcd/flux2/pkg/apigen"
+)
+
+func TestFetchValuesFiles(t *testing.T) {
+	values := "replicaCount: 1\n---\nreplicaCount: 2\n"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/values.yaml" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, values)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	local := dir + "/local.yaml"
+	if err := os.WriteFile(local, []byte("image:\n  tag: v1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	files, remotes, err := fetchValuesFiles(context.Background(), []string{local, srv.URL + "/values.yaml"}, dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(files) != 2 || files[0] != local {
+		t.Fatalf("unexpected values files %v", files)
+	}
+	wantChecksum := fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(values)))
+	if len(remotes) != 1 || remotes[0].url != srv.URL+"/values.yaml" || remotes[0].checksum != wantChecksum {
+		t.Errorf("unexpected remote values %v", remotes)
+	}
+
+	merged, err := apigen.MergeValuesFiles(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(merged.Raw); got != `{"image":{"tag":"v1"},"replicaCount":2}` {
+		t.Errorf("unexpected merged values %s", got)
+	}
+
+	if _, _, err := fetchValuesFiles(context.Background(), []string{srv.URL + "/missing.yaml"}, dir); err == nil {
+		t.Error("expected error for a missing values URL")
+	}
+}
+
+func TestWriteExportWithValues(t *testing.T) {
+	hr := &helmv2.HelmRelease{}
+	hr.Name = "podinfo"
+	hr.Namespace = "default"
+
+	var buf bytes.Buffer
+	remotes := []remoteValues{{url: "https://example.com/values.yaml", checksum: "sha256:abc"}}
+	if err := writeExportWithValues(&buf, exportHelmRelease(hr), remotes); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), "---\n# values: https://example.com/values.yaml sha256:abc\napiVersion:") {
+		t.Errorf("unexpected export:\n%s", buf.String())
+	}
+}
@@ -18,17 +18,12 @@ package main
 
 import (
 	"fmt"
-	"regexp/syntax"
-	"strings"
-	"unicode"
-	"unicode/utf8"
 
 	"github.com/spf13/cobra"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-
-	"github.com/fluxcd/pkg/apis/meta"
 
 	imagev1 "github.com/fluxcd/image-reflector-controller/api/v1beta2"
+
+	"github.com/fluxcd/flux2/pkg/apigen"
 )
 
 var createImagePolicyCmd = &cobra.Command{
@@ -95,71 +90,36 @@ func createImagePolicyRun(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	var policy = imagev1.ImagePolicy{
-		ObjectMeta: metav1.ObjectMeta{
+	policy, err := apigen.ImagePolicy(apigen.ImagePolicyOptions{
+		ObjectOptions: apigen.ObjectOptions{
 			Name:      objectName,
 			Namespace: *kubeconfigArgs.Namespace,
 			Labels:    labels,
+			Interval:  createArgs.interval,
+			Suspend:   createArgs.suspend,
 		},
-		Spec: imagev1.ImagePolicySpec{
-			ImageRepositoryRef: meta.NamespacedObjectReference{
-				Name: imagePolicyArgs.imageRef,
-			},
-		},
-	}
-
-	switch {
-	case imagePolicyArgs.semver != "" && imagePolicyArgs.alpha != "":
-	case imagePolicyArgs.semver != "" && imagePolicyArgs.numeric != "":
-	case imagePolicyArgs.alpha != "" && imagePolicyArgs.numeric != "":
-		return fmt.Errorf("only one of --select-semver, --select-alpha or --select-numeric can be specified")
-	case imagePolicyArgs.semver != "":
-		policy.Spec.Policy.SemVer = &imagev1.SemVerPolicy{
-			Range: imagePolicyArgs.semver,
-		}
-	case imagePolicyArgs.alpha != "":
-		if imagePolicyArgs.alpha != "desc" && imagePolicyArgs.alpha != "asc" {
-			return fmt.Errorf("--select-alpha must be one of [\"asc\", \"desc\"]")
-		}
-		policy.Spec.Policy.Alphabetical = &imagev1.AlphabeticalPolicy{
-			Order: imagePolicyArgs.alpha,
-		}
-	case imagePolicyArgs.numeric != "":
-		if imagePolicyArgs.numeric != "desc" && imagePolicyArgs.numeric != "asc" {
-			return fmt.Errorf("--select-numeric must be one of [\"asc\", \"desc\"]")
-		}
-		policy.Spec.Policy.Numerical = &imagev1.NumericalPolicy{
-			Order: imagePolicyArgs.numeric,
-		}
-	default:
-		return fmt.Errorf("a policy must be provided with either --select-semver or --select-alpha")
+		ImageRepositoryRef: imagePolicyArgs.imageRef,
+		SemVer:             imagePolicyArgs.semver,
+		Alphabetical:       imagePolicyArgs.alpha,
+		Numerical:          imagePolicyArgs.numeric,
+		FilterRegex:        imagePolicyArgs.filterRegex,
+		FilterExtract:      imagePolicyArgs.filterExtract,
+	})
+	if err != nil {
+		return err
 	}
 
-	if imagePolicyArgs.filterRegex != "" {
-		exp, err := syntax.Parse(imagePolicyArgs.filterRegex, syntax.Perl)
-		if err != nil {
-			return fmt.Errorf("--filter-regex is an invalid regex pattern")
-		}
-		policy.Spec.FilterTags = &imagev1.TagFilter{
-			Pattern: imagePolicyArgs.filterRegex,
-		}
-
-		if imagePolicyArgs.filterExtract != "" {
-			if err := validateExtractStr(imagePolicyArgs.filterExtract, exp.CapNames()); err != nil {
-				return err
-			}
-			policy.Spec.FilterTags.Extract = imagePolicyArgs.filterExtract
-		}
-	} else if imagePolicyArgs.filterExtract != "" {
-		return fmt.Errorf("cannot specify --filter-extract without specifying --filter-regex")
-	}
+	lintCreated(policy)
 
 	if createArgs.export {
-		return printExport(exportImagePolicy(&policy))
+		if err := exportCreateNamespace(); err != nil {
+			return err
+		}
+		return printExport(exportImagePolicy(policy))
 	}
 
 	var existing imagev1.ImagePolicy
-	copyName(&existing, &policy)
+	copyName(&existing, policy)
 	err = imagePolicyType.upsertAndWait(imagePolicyAdapter{&existing}, func() error {
 		existing.Spec = policy.Spec
 		existing.SetLabels(policy.Labels)
@@ -167,94 +127,3 @@ func createImagePolicyRun(cmd *cobra.Command, args []string) error {
 	})
 	return err
 }
-
-// Performs a dry-run of the extract function in Regexp to validate the template
-func validateExtractStr(template string, capNames []string) error {
-	for len(template) > 0 {
-		i := strings.Index(template, "$")
-		if i < 0 {
-			return nil
-		}
-		template = template[i:]
-		if len(template) > 1 && template[1] == '$' {
-			template = template[2:]
-			continue
-		}
-		name, num, rest, ok := extract(template)
-		if !ok {
-			// Malformed extract string, assume user didn't want this
-			template = template[1:]
-			return fmt.Errorf("--filter-extract is malformed")
-		}
-		template = rest
-		if num >= 0 {
-			// we won't worry about numbers as we can't validate these
-			continue
-		} else {
-			found := false
-			for _, capName := range capNames {
-				if name == capName {
-					found = true
-				}
-			}
-			if !found {
-				return fmt.Errorf("capture group $%s used in --filter-extract not found in --filter-regex", name)
-			}
-		}
-
-	}
-	return nil
-}
-
-// extract method from the regexp package
-// returns the name or number of the value prepended by $
-func extract(str string) (name string, num int, rest string, ok bool) {
-	if len(str) < 2 || str[0] != '$' {
-		return
-	}
-	brace := false
-	if str[1] == '{' {
-		brace = true
-		str = str[2:]
-	} else {
-		str = str[1:]
-	}
-	i := 0
-	for i < len(str) {
-		rune, size := utf8.DecodeRuneInString(str[i:])
-		if !unicode.IsLetter(rune) && !unicode.IsDigit(rune) && rune != '_' {
-			break
-		}
-		i += size
-	}
-	if i == 0 {
-		// empty name is not okay
-		return
-	}
-	name = str[:i]
-	if brace {
-		if i >= len(str) || str[i] != '}' {
-			// missing closing brace
-			return
-		}
-		i++
-	}
-
-	// Parse number.
-	num = 0
-	for i := 0; i < len(name); i++ {
-		if name[i] < '0' || '9' < name[i] || num >= 1e8 {
-			num = -1
-			break
-		}
-		num = num*10 + int(name[i]) - '0'
-	}
-	// Disallow leading zeros.
-	if name[0] == '0' && len(name) > 1 {
-		num = -1
-	}
-
-	rest = str[i:]
-	ok = true
-	return
-}
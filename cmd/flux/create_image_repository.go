@@ -17,23 +17,38 @@ limitations under the License.
 package main
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"strings"
 	"time"
 
+	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/spf13/cobra"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-
-	"github.com/fluxcd/pkg/apis/meta"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	imagev1 "github.com/fluxcd/image-reflector-controller/api/v1beta2"
+
+	"github.com/fluxcd/flux2/pkg/apigen"
+	"github.com/fluxcd/flux2/pkg/manifestgen/sourcesecret"
 )
 
 var createImageRepositoryCmd = &cobra.Command{
 	Use:   "repository [name]",
 	Short: "Create or update an ImageRepository object",
 	Long: `The create image repository command generates an ImageRepository resource.
-An ImageRepository object specifies an image repository to scan.`,
+An ImageRepository object specifies an image repository to scan.
+
+Before applying the object, the command checks that the tags of the image repository
+can be listed using the credentials and certificates referenced by the object.
+The check is skipped when exporting the object or when --skip-check is set.`,
 	Example: `  # Create an ImageRepository object to scan the alpine image repository:
   flux create image repository alpine-repo --image alpine --interval 20m
 
@@ -66,6 +81,7 @@ type imageRepoFlags struct {
 	secretRef     string
 	certSecretRef string
 	timeout       time.Duration
+	skipCheck     bool
 }
 
 var imageRepoArgs = imageRepoFlags{}
@@ -74,7 +90,12 @@ func init() {
 	flags := createImageRepositoryCmd.Flags()
 	flags.StringVar(&imageRepoArgs.image, "image", "", "the image repository to scan; e.g., library/alpine")
 	flags.StringVar(&imageRepoArgs.secretRef, "secret-ref", "", "the name of a docker-registry secret to use for credentials")
+	flags.StringVar(&imageRepoArgs.certSecretRef, "cert-secret-ref", "",
+		"the name of a secret containing the caFile, certFile and keyFile to use for connecting to the registry")
 	flags.StringVar(&imageRepoArgs.certSecretRef, "cert-ref", "", "the name of a secret to use for TLS certificates")
+	flags.MarkDeprecated("cert-ref", "use --cert-secret-ref instead")
+	flags.BoolVar(&imageRepoArgs.skipCheck, "skip-check", false,
+		"skip listing the image tags before applying the object")
 	// NB there is already a --timeout in the global flags, for
 	// controlling timeout on operations while e.g., creating objects.
 	flags.DurationVar(&imageRepoArgs.timeout, "scan-timeout", 0, "a timeout for scanning; this defaults to the interval if not set")
@@ -89,47 +110,46 @@ func createImageRepositoryRun(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("an image repository (--image) is required")
 	}
 
-	if _, err := name.NewRepository(imageRepoArgs.image); err != nil {
-		return fmt.Errorf("unable to parse image value: %w", err)
-	}
-
 	labels, err := parseLabels()
 	if err != nil {
 		return err
 	}
 
-	var repo = imagev1.ImageRepository{
-		ObjectMeta: metav1.ObjectMeta{
+	repo, err := apigen.ImageRepository(apigen.ImageRepositoryOptions{
+		ObjectOptions: apigen.ObjectOptions{
 			Name:      objectName,
 			Namespace: *kubeconfigArgs.Namespace,
 			Labels:    labels,
+			Interval:  createArgs.interval,
+			Suspend:   createArgs.suspend,
 		},
-		Spec: imagev1.ImageRepositorySpec{
-			Image:    imageRepoArgs.image,
-			Interval: metav1.Duration{Duration: createArgs.interval},
-		},
-	}
-	if imageRepoArgs.timeout != 0 {
-		repo.Spec.Timeout = &metav1.Duration{Duration: imageRepoArgs.timeout}
-	}
-	if imageRepoArgs.secretRef != "" {
-		repo.Spec.SecretRef = &meta.LocalObjectReference{
-			Name: imageRepoArgs.secretRef,
-		}
+		Image:         imageRepoArgs.image,
+		SecretRef:     imageRepoArgs.secretRef,
+		CertSecretRef: imageRepoArgs.certSecretRef,
+		Timeout:       imageRepoArgs.timeout,
+	})
+	if err != nil {
+		return err
 	}
-	if imageRepoArgs.certSecretRef != "" {
-		repo.Spec.CertSecretRef = &meta.LocalObjectReference{
-			Name: imageRepoArgs.certSecretRef,
+
+	lintCreated(repo)
+
+	if createArgs.export {
+		if err := exportCreateNamespace(); err != nil {
+			return err
 		}
+		return printExport(exportImageRepository(repo))
 	}
 
-	if createArgs.export {
-		return printExport(exportImageRepository(&repo))
+	if !imageRepoArgs.skipCheck {
+		if err := checkImageRepositoryAccess(repo); err != nil {
+			return err
+		}
 	}
 
 	// a temp value for use with the rest
 	var existing imagev1.ImageRepository
-	copyName(&existing, &repo)
+	copyName(&existing, repo)
 	err = imageRepositoryType.upsertAndWait(imageRepositoryAdapter{&existing}, func() error {
 		existing.Spec = repo.Spec
 		existing.Labels = repo.Labels
@@ -137,3 +157,140 @@ func createImageRepositoryRun(cmd *cobra.Command, args []string) error {
 	})
 	return err
 }
+
+// checkImageRepositoryAccess lists the tags of the image repository using
+// the pull secret and TLS certificates referenced by the object, the same
+// way the image-reflector-controller will when scanning it.
+func checkImageRepositoryAccess(repo *imagev1.ImageRepository) error {
+	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	defer cancel()
+
+	ref, err := name.NewRepository(repo.Spec.Image)
+	if err != nil {
+		return fmt.Errorf("unable to parse image value: %w", err)
+	}
+
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
+	if err != nil {
+		return err
+	}
+
+	logger.Actionf("checking access to image repository %s", ref.String())
+	opts := []remote.Option{remote.WithContext(ctx)}
+
+	if repo.Spec.SecretRef != nil {
+		secret, err := getImageRepositorySecret(ctx, kubeClient, repo.Namespace, repo.Spec.SecretRef.Name)
+		if err != nil {
+			return err
+		}
+		auth, err := dockerConfigAuth(secret, ref.RegistryStr())
+		if err != nil {
+			return fmt.Errorf("invalid secret '%s': %w", secret.Name, err)
+		}
+		opts = append(opts, remote.WithAuth(auth))
+	}
+
+	if repo.Spec.CertSecretRef != nil {
+		secret, err := getImageRepositorySecret(ctx, kubeClient, repo.Namespace, repo.Spec.CertSecretRef.Name)
+		if err != nil {
+			return err
+		}
+		tlsConfig, err := imageRepositoryTLSConfig(secret)
+		if err != nil {
+			return fmt.Errorf("invalid secret '%s': %w", secret.Name, err)
+		}
+		transport := remote.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = tlsConfig
+		opts = append(opts, remote.WithTransport(transport))
+	}
+
+	tags, err := remote.List(ref, opts...)
+	if err != nil {
+		return fmt.Errorf("unable to list tags of %s: %w", ref.String(), err)
+	}
+	if len(tags) == 0 {
+		logger.Warningf("no tags found in image repository %s", ref.String())
+		return nil
+	}
+	logger.Successf("image repository %s is accessible", ref.String())
+	return nil
+}
+
+func getImageRepositorySecret(ctx context.Context, kubeClient client.Client, namespace, name string) (*corev1.Secret, error) {
+	var secret corev1.Secret
+	if err := kubeClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, &secret); err != nil {
+		return nil, fmt.Errorf("unable to read secret '%s': %w", name, err)
+	}
+	return &secret, nil
+}
+
+// dockerConfigAuth returns the credentials of the given registry from a
+// docker-registry secret. The registry hosts of the secret are matched
+// after normalisation, e.g. https://index.docker.io/v1/ matches docker.io.
+func dockerConfigAuth(secret *corev1.Secret, registry string) (authn.Authenticator, error) {
+	data, ok := secret.Data[corev1.DockerConfigJsonKey]
+	if !ok {
+		return nil, fmt.Errorf("'%s' key not found", corev1.DockerConfigJsonKey)
+	}
+	var cfg sourcesecret.DockerConfigJSON
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, err
+	}
+	registry = normalizeRegistryHost(registry)
+	for host, entry := range cfg.Auths {
+		if normalizeRegistryHost(host) != registry {
+			continue
+		}
+		return authn.FromConfig(authn.AuthConfig{
+			Username: entry.Username,
+			Password: entry.Password,
+			Auth:     entry.Auth,
+		}), nil
+	}
+	return nil, fmt.Errorf("no credentials found for registry '%s'", registry)
+}
+
+// normalizeRegistryHost returns the host of a registry found in a Docker
+// config, without scheme nor path, and with the Docker Hub aliases
+// replaced by the default registry.
+func normalizeRegistryHost(host string) string {
+	host = strings.TrimPrefix(strings.TrimPrefix(host, "https://"), "http://")
+	host, _, _ = strings.Cut(host, "/")
+	switch host {
+	case "docker.io", "registry-1.docker.io", "registry.hub.docker.com":
+		return name.DefaultRegistry
+	}
+	return host
+}
+
+// imageRepositoryTLSConfig builds a TLS configuration from the caFile,
+// certFile and keyFile entries of the given secret.
+func imageRepositoryTLSConfig(secret *corev1.Secret) (*tls.Config, error) {
+	tlsConfig := &tls.Config{}
+
+	if caFile, ok := secret.Data[sourcesecret.CAFileSecretKey]; ok {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(caFile) {
+			return nil, fmt.Errorf("no valid certificates found in '%s'", sourcesecret.CAFileSecretKey)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	certFile, hasCert := secret.Data[sourcesecret.CertFileSecretKey]
+	keyFile, hasKey := secret.Data[sourcesecret.KeyFileSecretKey]
+	if hasCert != hasKey {
+		return nil, fmt.Errorf("both '%s' and '%s' must be set", sourcesecret.CertFileSecretKey, sourcesecret.KeyFileSecretKey)
+	}
+	if hasCert {
+		cert, err := tls.X509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
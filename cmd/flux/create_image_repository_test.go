@@ -0,0 +1,81 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestDockerConfigAuth(t *testing.T) {
+	secret := &corev1.Secret{
+		Data: map[string][]byte{
+			corev1.DockerConfigJsonKey: []byte(`{"auths":{"https://registry.example.com":{"username":"flux","password":"secret"}}}`),
+		},
+	}
+
+	auth, err := dockerConfigAuth(secret, "registry.example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	cfg, err := auth.Authorization()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if cfg.Username != "flux" || cfg.Password != "secret" {
+		t.Errorf("unexpected credentials: %s/%s", cfg.Username, cfg.Password)
+	}
+
+	for _, host := range []string{"https://index.docker.io/v1/", "docker.io", "registry-1.docker.io"} {
+		hub := &corev1.Secret{
+			Data: map[string][]byte{
+				corev1.DockerConfigJsonKey: []byte(`{"auths":{"` + host + `":{"username":"flux","password":"hub"}}}`),
+			},
+		}
+		if _, err := dockerConfigAuth(hub, "index.docker.io"); err != nil {
+			t.Errorf("expected credentials of %s to match Docker Hub: %s", host, err)
+		}
+	}
+
+	if _, err := dockerConfigAuth(secret, "ghcr.io"); err == nil {
+		t.Error("expected error for a registry without credentials")
+	}
+	if _, err := dockerConfigAuth(&corev1.Secret{}, "ghcr.io"); err == nil {
+		t.Error("expected error for a secret without docker config")
+	}
+}
+
+func TestImageRepositoryTLSConfig(t *testing.T) {
+	if _, err := imageRepositoryTLSConfig(&corev1.Secret{
+		Data: map[string][]byte{"caFile": []byte("invalid")},
+	}); err == nil {
+		t.Error("expected error for an invalid CA certificate")
+	}
+	if _, err := imageRepositoryTLSConfig(&corev1.Secret{
+		Data: map[string][]byte{"certFile": []byte("cert")},
+	}); err == nil {
+		t.Error("expected error for a certificate without key")
+	}
+	cfg, err := imageRepositoryTLSConfig(&corev1.Secret{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if cfg.RootCAs != nil || len(cfg.Certificates) != 0 {
+		t.Error("expected an empty TLS config")
+	}
+}
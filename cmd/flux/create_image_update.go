@@ -20,10 +20,10 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	autov1 "github.com/fluxcd/image-automation-controller/api/v1beta1"
-	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+
+	"github.com/fluxcd/flux2/pkg/apigen"
 )
 
 var createImageUpdateCmd = &cobra.Command{
@@ -62,6 +62,15 @@ mentioned in YAMLs in a git repository.`,
     --author-name=flux \
     --author-email=flux@example.com \
     --commit-template="{{range .Updated.Images}}{{println .}}{{end}}"
+
+  # Configure image updates for a repository requiring signed and signed-off commits
+  flux create image update flux-system \
+    --git-repo-ref=flux-system \
+    --checkout-branch=main \
+    --author-name=flux \
+    --author-email=flux@example.com \
+    --signing-key-secret-ref=flux-gpg-signing-key \
+    --signoff
 `,
 	RunE: createImageUpdateRun,
 }
@@ -75,6 +84,8 @@ type imageUpdateFlags struct {
 	commitTemplate   string
 	authorName       string
 	authorEmail      string
+	signoff          bool
+	signingKeyRef    string
 }
 
 var imageUpdateArgs = imageUpdateFlags{}
@@ -89,6 +100,8 @@ func init() {
 	flags.StringVar(&imageUpdateArgs.commitTemplate, "commit-template", "", "a template for commit messages")
 	flags.StringVar(&imageUpdateArgs.authorName, "author-name", "", "the name to use for commit author")
 	flags.StringVar(&imageUpdateArgs.authorEmail, "author-email", "", "the email to use for commit author")
+	flags.BoolVar(&imageUpdateArgs.signoff, "signoff", false, "append a Signed-off-by trailer for the commit author to the commit messages")
+	flags.StringVar(&imageUpdateArgs.signingKeyRef, "signing-key-secret-ref", "", "the name of a secret containing the GPG key (git.asc) used to sign commits")
 
 	createImageCmd.AddCommand(createImageUpdateCmd)
 }
@@ -117,58 +130,40 @@ func createImageUpdateRun(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	var update = autov1.ImageUpdateAutomation{
-		ObjectMeta: metav1.ObjectMeta{
+	update, err := apigen.ImageUpdateAutomation(apigen.ImageUpdateAutomationOptions{
+		ObjectOptions: apigen.ObjectOptions{
 			Name:      objectName,
 			Namespace: *kubeconfigArgs.Namespace,
 			Labels:    labels,
+			Interval:  createArgs.interval,
+			Suspend:   createArgs.suspend,
 		},
-		Spec: autov1.ImageUpdateAutomationSpec{
-			SourceRef: autov1.CrossNamespaceSourceReference{
-				Kind:      sourcev1.GitRepositoryKind,
-				Name:      imageUpdateArgs.gitRepoName,
-				Namespace: imageUpdateArgs.gitRepoNamespace,
-			},
-
-			GitSpec: &autov1.GitSpec{
-				Checkout: &autov1.GitCheckoutSpec{
-					Reference: sourcev1.GitRepositoryRef{
-						Branch: imageUpdateArgs.checkoutBranch,
-					},
-				},
-				Commit: autov1.CommitSpec{
-					Author: autov1.CommitUser{
-						Name:  imageUpdateArgs.authorName,
-						Email: imageUpdateArgs.authorEmail,
-					},
-					MessageTemplate: imageUpdateArgs.commitTemplate,
-				},
-			},
-			Interval: metav1.Duration{
-				Duration: createArgs.interval,
-			},
-		},
-	}
-
-	if imageUpdateArgs.pushBranch != "" {
-		update.Spec.GitSpec.Push = &autov1.PushSpec{
-			Branch: imageUpdateArgs.pushBranch,
-		}
+		GitRepositoryName:      imageUpdateArgs.gitRepoName,
+		GitRepositoryNamespace: imageUpdateArgs.gitRepoNamespace,
+		CheckoutBranch:         imageUpdateArgs.checkoutBranch,
+		PushBranch:             imageUpdateArgs.pushBranch,
+		AuthorName:             imageUpdateArgs.authorName,
+		AuthorEmail:            imageUpdateArgs.authorEmail,
+		CommitTemplate:         imageUpdateArgs.commitTemplate,
+		Signoff:                imageUpdateArgs.signoff,
+		SigningKeySecretRef:    imageUpdateArgs.signingKeyRef,
+		Path:                   imageUpdateArgs.gitRepoPath,
+	})
+	if err != nil {
+		return err
 	}
 
-	if imageUpdateArgs.gitRepoPath != "" {
-		update.Spec.Update = &autov1.UpdateStrategy{
-			Path:     imageUpdateArgs.gitRepoPath,
-			Strategy: autov1.UpdateStrategySetters,
-		}
-	}
+	lintCreated(update)
 
 	if createArgs.export {
-		return printExport(exportImageUpdate(&update))
+		if err := exportCreateNamespace(); err != nil {
+			return err
+		}
+		return printExport(exportImageUpdate(update))
 	}
 
 	var existing autov1.ImageUpdateAutomation
-	copyName(&existing, &update)
+	copyName(&existing, update)
 	err = imageUpdateAutomationType.upsertAndWait(imageUpdateAutomationAdapter{&existing}, func() error {
 		existing.Spec = update.Spec
 		existing.Labels = update.Labels
@@ -26,16 +26,20 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/kube-openapi/pkg/util/proto"
+	"k8s.io/kubectl/pkg/util/openapi"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/status"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
-	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
 	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
 	"github.com/fluxcd/pkg/apis/meta"
 
 	"github.com/fluxcd/flux2/internal/flags"
-	"github.com/fluxcd/flux2/internal/utils"
+	"github.com/fluxcd/flux2/pkg/apigen"
 )
 
 var createKsCmd = &cobra.Command{
@@ -68,6 +72,15 @@ var createKsCmd = &cobra.Command{
     --prune=true \
     --interval=5m
 
+  # Create a Kustomization in a namespace that does not exist yet
+  flux create kustomization podinfo \
+    --namespace=apps \
+    --create-namespace \
+    --source=GitRepository/podinfo.flux-system \
+    --path="./kustomize" \
+    --prune=true \
+    --interval=5m
+
   # Create a Kustomization resource that references an OCIRepository
   flux create kustomization podinfo \
     --source=OCIRepository/podinfo \
@@ -79,7 +92,15 @@ var createKsCmd = &cobra.Command{
   flux create kustomization secrets \
     --source=Bucket/secrets \
     --prune=true \
-    --interval=5m`,
+    --interval=5m
+
+  # Create a Kustomization resource that includes kustomize components
+  flux create kustomization podinfo \
+    --source=GitRepository/podinfo \
+    --path="./kustomize/overlays/production" \
+    --components="../../components/ingress,../../components/hpa" \
+    --prune=true \
+    --interval=10m`,
 	RunE: createKsCmdRun,
 }
 
@@ -88,6 +109,7 @@ type kustomizationFlags struct {
 	path                flags.SafeRelativePath
 	prune               bool
 	dependsOn           []string
+	components          []string
 	validation          string
 	healthCheck         []string
 	healthTimeout       time.Duration
@@ -106,10 +128,11 @@ func init() {
 	createKsCmd.Flags().Var(&kustomizationArgs.path, "path", "path to the directory containing a kustomization.yaml file")
 	createKsCmd.Flags().BoolVar(&kustomizationArgs.prune, "prune", false, "enable garbage collection")
 	createKsCmd.Flags().BoolVar(&kustomizationArgs.wait, "wait", false, "enable health checking of all the applied resources")
-	createKsCmd.Flags().StringSliceVar(&kustomizationArgs.healthCheck, "health-check", nil, "workload to be included in the health assessment, in the format '<kind>/<name>.<namespace>'")
+	createKsCmd.Flags().StringSliceVar(&kustomizationArgs.healthCheck, "health-check", nil, "workload to be included in the health assessment, in the format '<kind>/<name>.<namespace>', the kind must be served by the cluster")
 	createKsCmd.Flags().DurationVar(&kustomizationArgs.healthTimeout, "health-check-timeout", 2*time.Minute, "timeout of health checking operations")
 	createKsCmd.Flags().StringVar(&kustomizationArgs.validation, "validation", "", "validate the manifests before applying them on the cluster, can be 'client' or 'server'")
 	createKsCmd.Flags().StringSliceVar(&kustomizationArgs.dependsOn, "depends-on", nil, "Kustomization that must be ready before this Kustomization can be applied, supported formats '<name>' and '<namespace>/<name>', also accepts comma-separated values")
+	createKsCmd.Flags().StringSliceVar(&kustomizationArgs.components, "components", nil, "path to a kustomize component relative to the path of the Kustomization, can be repeated or comma-separated")
 	createKsCmd.Flags().StringVar(&kustomizationArgs.saName, "service-account", "", "the name of the service account to impersonate when reconciling this Kustomization")
 	createKsCmd.Flags().Var(&kustomizationArgs.decryptionProvider, "decryption-provider", kustomizationArgs.decryptionProvider.Description())
 	createKsCmd.Flags().StringVar(&kustomizationArgs.decryptionSecret, "decryption-secret", "", "set the Kubernetes secret name that contains the OpenPGP private keys used for sops decryption")
@@ -129,13 +152,6 @@ func NewKustomizationFlags() kustomizationFlags {
 func createKsCmdRun(cmd *cobra.Command, args []string) error {
 	name := args[0]
 
-	if kustomizationArgs.path == "" {
-		return fmt.Errorf("path is required")
-	}
-	if !strings.HasPrefix(kustomizationArgs.path.String(), "./") {
-		return fmt.Errorf("path must begin with ./")
-	}
-
 	if !createArgs.export {
 		logger.Generatef("generating Kustomization")
 	}
@@ -145,120 +161,78 @@ func createKsCmdRun(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	kustomization := kustomizev1.Kustomization{
-		ObjectMeta: metav1.ObjectMeta{
+	var healthCheckAPIVersions map[string]string
+	if !createArgs.export && len(kustomizationArgs.healthCheck) > 0 && !kustomizationArgs.wait {
+		healthCheckAPIVersions, err = discoverHealthCheckKinds(kustomizationArgs.healthCheck)
+		if err != nil {
+			return err
+		}
+	}
+
+	kustomization, err := apigen.Kustomization(apigen.KustomizationOptions{
+		ObjectOptions: apigen.ObjectOptions{
 			Name:      name,
 			Namespace: *kubeconfigArgs.Namespace,
 			Labels:    kslabels,
+			Interval:  createArgs.interval,
+			Suspend:   createArgs.suspend,
 		},
-		Spec: kustomizev1.KustomizationSpec{
-			DependsOn: utils.MakeDependsOn(kustomizationArgs.dependsOn),
-			Interval: metav1.Duration{
-				Duration: createArgs.interval,
-			},
-			Path:  kustomizationArgs.path.ToSlash(),
-			Prune: kustomizationArgs.prune,
-			SourceRef: kustomizev1.CrossNamespaceSourceReference{
-				Kind:      kustomizationArgs.source.Kind,
-				Name:      kustomizationArgs.source.Name,
-				Namespace: kustomizationArgs.source.Namespace,
-			},
-			Suspend:         false,
-			TargetNamespace: kustomizationArgs.targetNamespace,
-		},
-	}
-
-	if kustomizationArgs.kubeConfigSecretRef != "" {
-		kustomization.Spec.KubeConfig = &meta.KubeConfigReference{
-			SecretRef: meta.SecretKeyReference{
-				Name: kustomizationArgs.kubeConfigSecretRef,
-			},
-		}
-	}
-
-	if len(kustomizationArgs.healthCheck) > 0 && !kustomizationArgs.wait {
-		healthChecks := make([]meta.NamespacedObjectKindReference, 0)
-		for _, w := range kustomizationArgs.healthCheck {
-			kindObj := strings.Split(w, "/")
-			if len(kindObj) != 2 {
-				return fmt.Errorf("invalid health check '%s' must be in the format 'kind/name.namespace' %v", w, kindObj)
-			}
-			kind := kindObj[0]
-
-			//TODO: (stefan) extend this list with all the kstatus builtin kinds
-			kinds := map[string]bool{
-				"Deployment":           true,
-				"DaemonSet":            true,
-				"StatefulSet":          true,
-				helmv2.HelmReleaseKind: true,
-			}
-			if !kinds[kind] {
-				return fmt.Errorf("invalid health check kind '%s' can be HelmRelease, Deployment, DaemonSet or StatefulSet", kind)
-			}
-			nameNs := strings.Split(kindObj[1], ".")
-			if len(nameNs) != 2 {
-				return fmt.Errorf("invalid health check '%s' must be in the format 'kind/name.namespace'", w)
-			}
-
-			check := meta.NamespacedObjectKindReference{
-				Kind:      kind,
-				Name:      nameNs[0],
-				Namespace: nameNs[1],
-			}
-
-			if kind == helmv2.HelmReleaseKind {
-				check.APIVersion = helmv2.GroupVersion.String()
-			}
-			healthChecks = append(healthChecks, check)
-		}
-		kustomization.Spec.HealthChecks = healthChecks
-		kustomization.Spec.Timeout = &metav1.Duration{
-			Duration: kustomizationArgs.healthTimeout,
-		}
-	}
-
-	if kustomizationArgs.wait {
-		kustomization.Spec.Wait = true
-		kustomization.Spec.Timeout = &metav1.Duration{
-			Duration: kustomizationArgs.healthTimeout,
-		}
-	}
-
-	if kustomizationArgs.saName != "" {
-		kustomization.Spec.ServiceAccountName = kustomizationArgs.saName
+		SourceKind:             kustomizationArgs.source.Kind,
+		SourceName:             kustomizationArgs.source.Name,
+		SourceNamespace:        kustomizationArgs.source.Namespace,
+		Path:                   kustomizationArgs.path.ToSlash(),
+		Prune:                  kustomizationArgs.prune,
+		DependsOn:              kustomizationArgs.dependsOn,
+		Components:             kustomizationArgs.components,
+		TargetNamespace:        kustomizationArgs.targetNamespace,
+		HealthChecks:           kustomizationArgs.healthCheck,
+		HealthCheckAPIVersions: healthCheckAPIVersions,
+		Wait:                   kustomizationArgs.wait,
+		HealthTimeout:          kustomizationArgs.healthTimeout,
+		ServiceAccountName:     kustomizationArgs.saName,
+		KubeConfigSecretRef:    kustomizationArgs.kubeConfigSecretRef,
+		DecryptionProvider:     kustomizationArgs.decryptionProvider.String(),
+		DecryptionSecret:       kustomizationArgs.decryptionSecret,
+	})
+	if err != nil {
+		return err
 	}
 
-	if kustomizationArgs.decryptionProvider != "" {
-		kustomization.Spec.Decryption = &kustomizev1.Decryption{
-			Provider: kustomizationArgs.decryptionProvider.String(),
-		}
-
-		if kustomizationArgs.decryptionSecret != "" {
-			kustomization.Spec.Decryption.SecretRef = &meta.LocalObjectReference{Name: kustomizationArgs.decryptionSecret}
-		}
-	}
+	lintCreated(kustomization)
 
 	if createArgs.export {
-		return printExport(exportKs(&kustomization))
+		if err := exportCreateNamespace(); err != nil {
+			return err
+		}
+		return printExport(exportKs(kustomization))
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(kubeconfigArgs, kubeclientOptions)
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
 	if err != nil {
 		return err
 	}
 
+	if err := checkCrossNamespaceRef(ctx, kubeClient, "kustomize-controller",
+		kustomization.Namespace, kustomization.Spec.SourceRef.Namespace); err != nil {
+		return err
+	}
+
 	logger.Actionf("applying Kustomization")
-	namespacedName, err := upsertKustomization(ctx, kubeClient, &kustomization)
+	namespacedName, err := upsertKustomization(ctx, kubeClient, kustomization)
 	if err != nil {
 		return err
 	}
 
+	if skipSuspendedWait("Kustomization") {
+		return nil
+	}
+
 	logger.Waitingf("waiting for Kustomization reconciliation")
 	if err := wait.PollImmediate(rootArgs.pollInterval, rootArgs.timeout,
-		isKustomizationReady(ctx, kubeClient, namespacedName, &kustomization)); err != nil {
+		isKustomizationReady(ctx, kubeClient, namespacedName, kustomization)); err != nil {
 		return err
 	}
 	logger.Successf("Kustomization %s is ready", name)
@@ -267,6 +241,94 @@ func createKsCmdRun(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// discoverHealthCheckKinds looks up the kinds of the given health checks
+// with the cluster discovery API and returns their preferred API version.
+// It warns about the kinds that don't follow the kstatus conventions, as
+// their health can't be assessed and they are considered ready as soon as
+// they exist.
+func discoverHealthCheckKinds(checks []string) (map[string]string, error) {
+	discoveryClient, err := kubeconfigArgs.ToDiscoveryClient()
+	if err != nil {
+		return nil, err
+	}
+
+	resourceLists, err := discoveryClient.ServerPreferredResources()
+	if err != nil && len(resourceLists) == 0 {
+		return nil, fmt.Errorf("failed to discover the cluster API resources: %w", err)
+	}
+
+	var resources openapi.Resources
+	if doc, err := discoveryClient.OpenAPISchema(); err == nil {
+		resources, _ = openapi.NewOpenAPIData(doc)
+	}
+
+	apiVersions := make(map[string]string)
+	for _, check := range checks {
+		kind, _, _ := strings.Cut(check, "/")
+		if _, ok := apiVersions[kind]; ok {
+			continue
+		}
+		gvk, ok := findServedKind(resourceLists, kind)
+		if !ok {
+			return nil, fmt.Errorf("invalid health check kind '%s', the kind is not served by the cluster", kind)
+		}
+		apiVersions[kind] = gvk.GroupVersion().String()
+
+		var kindSchema proto.Schema
+		if resources != nil {
+			kindSchema = resources.LookupResource(gvk)
+		}
+		if !followsKstatusConventions(gvk, kindSchema) {
+			logger.Warningf("health check kind '%s' has no status.conditions, its health can't be assessed and it will be considered ready once it exists", kind)
+		}
+	}
+	return apiVersions, nil
+}
+
+// findServedKind returns the preferred group version of the given kind.
+func findServedKind(resourceLists []*metav1.APIResourceList, kind string) (schema.GroupVersionKind, bool) {
+	for _, list := range resourceLists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			continue
+		}
+		for _, resource := range list.APIResources {
+			if resource.Kind == kind && !strings.Contains(resource.Name, "/") {
+				return gv.WithKind(kind), true
+			}
+		}
+	}
+	return schema.GroupVersionKind{}, false
+}
+
+// followsKstatusConventions returns false if the OpenAPI schema of the kind
+// shows that its health can't be computed by kstatus. Kinds with builtin
+// kstatus support and kinds without a published schema are assumed to be
+// supported.
+func followsKstatusConventions(gvk schema.GroupVersionKind, kindSchema proto.Schema) bool {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(gvk)
+	if status.GetLegacyConditionsFn(u) != nil || kindSchema == nil {
+		return true
+	}
+
+	statusSchema := schemaField(kindSchema, "status")
+	if _, ok := statusSchema.(*proto.Arbitrary); ok {
+		return true
+	}
+	return schemaField(statusSchema, "conditions") != nil
+}
+
+func schemaField(s proto.Schema, name string) proto.Schema {
+	switch t := s.(type) {
+	case *proto.Ref:
+		return schemaField(t.SubSchema(), name)
+	case *proto.Kind:
+		return t.Fields[name]
+	}
+	return nil
+}
+
 func upsertKustomization(ctx context.Context, kubeClient client.Client,
 	kustomization *kustomizev1.Kustomization) (types.NamespacedName, error) {
 	namespacedName := types.NamespacedName{
@@ -274,6 +336,10 @@ func upsertKustomization(ctx context.Context, kubeClient client.Client,
 		Name:      kustomization.GetName(),
 	}
 
+	if err := createNamespace(ctx, kubeClient, namespacedName.Namespace); err != nil {
+		return namespacedName, err
+	}
+
 	var existing kustomizev1.Kustomization
 	err := kubeClient.Get(ctx, namespacedName, &existing)
 	if err != nil {
@@ -288,6 +354,10 @@ func upsertKustomization(ctx context.Context, kubeClient client.Client,
 		return namespacedName, err
 	}
 
+	if err := checkOverwrite("Kustomization", &existing); err != nil {
+		return namespacedName, err
+	}
+
 	existing.Labels = kustomization.Labels
 	existing.Spec = kustomization.Spec
 	if err := kubeClient.Update(ctx, &existing); err != nil {
@@ -316,7 +386,7 @@ func isKustomizationReady(ctx context.Context, kubeClient client.Client,
 			case metav1.ConditionTrue:
 				return true, nil
 			case metav1.ConditionFalse:
-				return false, fmt.Errorf(c.Message)
+				return false, conditionError(kustomization, c)
 			}
 		}
 		return false, nil
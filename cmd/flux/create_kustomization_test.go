@@ -0,0 +1,194 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/kube-openapi/pkg/util/proto"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+)
+
+func TestCreateKustomization(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   string
+		assert assertFunc
+	}{
+		{
+			name:   "with namespace",
+			args:   "create kustomization podinfo --source=GitRepository/podinfo.flux-system --path=./deploy --interval=5m --namespace=apps --create-namespace --export",
+			assert: assertGoldenFile("./testdata/create_kustomization/kustomization-namespace.yaml"),
+		},
+		{
+			name: "namespace with invalid resource",
+			args: "create kustomization podinfo --source=GitRepository/podinfo.flux-system --path=./deploy --health-check=Deployment --namespace=apps --create-namespace --export",
+			assert: func(output string, err error) error {
+				if err == nil {
+					return fmt.Errorf("expected the health check to be rejected")
+				}
+				if output != "" {
+					return fmt.Errorf("expected no output, got:\n%s", output)
+				}
+				return nil
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := cmdTestCase{
+				args:   tt.args,
+				assert: tt.assert,
+			}
+			cmd.runTestCmd(t)
+		})
+	}
+}
+
+func TestFindServedKind(t *testing.T) {
+	resourceLists := []*metav1.APIResourceList{
+		{
+			GroupVersion: "apps/v1",
+			APIResources: []metav1.APIResource{
+				{Name: "deployments", Kind: "Deployment"},
+				{Name: "deployments/scale", Kind: "Scale"},
+			},
+		},
+		{
+			GroupVersion: "example.com/v1alpha1",
+			APIResources: []metav1.APIResource{
+				{Name: "widgets", Kind: "Widget"},
+			},
+		},
+	}
+
+	gvk, ok := findServedKind(resourceLists, "Widget")
+	if !ok || gvk.GroupVersion().String() != "example.com/v1alpha1" {
+		t.Errorf("expected Widget to be served by example.com/v1alpha1, got %v", gvk)
+	}
+	if _, ok := findServedKind(resourceLists, "Scale"); ok {
+		t.Errorf("expected subresource kinds to be ignored")
+	}
+	if _, ok := findServedKind(resourceLists, "Pod"); ok {
+		t.Errorf("expected Pod not to be served")
+	}
+}
+
+func TestFollowsKstatusConventions(t *testing.T) {
+	withConditions := &proto.Kind{
+		Fields: map[string]proto.Schema{
+			"status": &proto.Kind{
+				Fields: map[string]proto.Schema{
+					"conditions": &proto.Array{},
+				},
+			},
+		},
+	}
+	withoutConditions := &proto.Kind{
+		Fields: map[string]proto.Schema{
+			"status": &proto.Kind{
+				Fields: map[string]proto.Schema{
+					"phase": &proto.Primitive{Type: "string"},
+				},
+			},
+		},
+	}
+	withoutStatus := &proto.Kind{Fields: map[string]proto.Schema{}}
+
+	tests := []struct {
+		name   string
+		gvk    schema.GroupVersionKind
+		schema proto.Schema
+		want   bool
+	}{
+		{
+			name:   "builtin kstatus kind",
+			gvk:    schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+			schema: withoutConditions,
+			want:   true,
+		},
+		{
+			name:   "custom kind with conditions",
+			gvk:    schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"},
+			schema: withConditions,
+			want:   true,
+		},
+		{
+			name:   "custom kind without conditions",
+			gvk:    schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"},
+			schema: withoutConditions,
+			want:   false,
+		},
+		{
+			name:   "custom kind without status",
+			gvk:    schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"},
+			schema: withoutStatus,
+			want:   false,
+		},
+		{
+			name: "custom kind without schema",
+			gvk:  schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"},
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := followsKstatusConventions(tt.gvk, tt.schema); got != tt.want {
+				t.Errorf("followsKstatusConventions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateKustomizationCreateNamespace(t *testing.T) {
+	isolateEnv(t)
+	kubeClient := useFakeCluster(t)
+
+	invalid := cmdTestCase{
+		args:   "create kustomization podinfo --source=GitRepository/podinfo.flux-system --path=./deploy --components=/base --namespace=apps --create-namespace",
+		assert: assertError("invalid component path '/base': must be relative to the path of the Kustomization"),
+	}
+	invalid.runTestCmd(t)
+
+	err := kubeClient.Get(context.TODO(), client.ObjectKey{Name: "apps"}, &corev1.Namespace{})
+	if !apierrors.IsNotFound(err) {
+		t.Fatalf("expected the namespace not to be created for an invalid Kustomization, got %v", err)
+	}
+
+	valid := cmdTestCase{
+		args:   "create kustomization podinfo --source=GitRepository/podinfo.flux-system --path=./deploy --namespace=apps --create-namespace --suspend",
+		assert: assertSuccess(),
+	}
+	valid.runTestCmd(t)
+
+	if err := kubeClient.Get(context.TODO(), client.ObjectKey{Name: "apps"}, &corev1.Namespace{}); err != nil {
+		t.Errorf("expected the namespace to be created, got %v", err)
+	}
+	if err := kubeClient.Get(context.TODO(), client.ObjectKey{Namespace: "apps", Name: "podinfo"}, &kustomizev1.Kustomization{}); err != nil {
+		t.Errorf("expected the Kustomization to be created, got %v", err)
+	}
+}
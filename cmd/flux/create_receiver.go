@@ -18,9 +18,12 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 
 	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -45,7 +48,15 @@ var createReceiverCmd = &cobra.Command{
 	--event push \
 	--secret-ref webhook-token \
 	--resource GitRepository/webapp \
-	--resource HelmRepository/webapp`,
+	--resource HelmRepository/webapp
+
+  # Create a Receiver with a generated token stored in the secret 'github-receiver-token'
+  flux create receiver github-receiver \
+	--type github \
+	--event ping \
+	--event push \
+	--generate-secret \
+	--resource GitRepository/webapp`,
 	RunE: createReceiverCmdRun,
 }
 
@@ -54,8 +65,13 @@ type receiverFlags struct {
 	secretRef    string
 	events       []string
 	resources    []string
+	generate     bool
 }
 
+// receiverTokenSuffix is appended to the Receiver name to derive the name
+// of the generated token secret.
+const receiverTokenSuffix = "-token"
+
 var receiverArgs receiverFlags
 
 func init() {
@@ -63,6 +79,8 @@ func init() {
 	createReceiverCmd.Flags().StringVar(&receiverArgs.secretRef, "secret-ref", "", "")
 	createReceiverCmd.Flags().StringSliceVar(&receiverArgs.events, "event", []string{}, "also accepts comma-separated values")
 	createReceiverCmd.Flags().StringSliceVar(&receiverArgs.resources, "resource", []string{}, "also accepts comma-separated values")
+	createReceiverCmd.Flags().BoolVar(&receiverArgs.generate, "generate-secret", false,
+		"generate a random token in a secret named after the Receiver, or the --secret-ref name if set, and print the token once")
 	createCmd.AddCommand(createReceiverCmd)
 }
 
@@ -73,8 +91,12 @@ func createReceiverCmdRun(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("Receiver type is required")
 	}
 
-	if receiverArgs.secretRef == "" {
-		return fmt.Errorf("secret ref is required")
+	secretName := receiverArgs.secretRef
+	if receiverArgs.generate && secretName == "" {
+		secretName = name + receiverTokenSuffix
+	}
+	if secretName == "" {
+		return fmt.Errorf("secret ref is required, or use --generate-secret")
 	}
 
 	resources := []notificationv1.CrossNamespaceObjectReference{}
@@ -114,30 +136,87 @@ func createReceiverCmdRun(cmd *cobra.Command, args []string) error {
 			Events:    receiverArgs.events,
 			Resources: resources,
 			SecretRef: meta.LocalObjectReference{
-				Name: receiverArgs.secretRef,
+				Name: secretName,
 			},
-			Suspend: false,
+			Suspend: createArgs.suspend,
 		},
 	}
 
+	lintCreated(&receiver)
+
+	var secret *corev1.Secret
+	if receiverArgs.generate {
+		token, err := generateReceiverToken()
+		if err != nil {
+			return err
+		}
+		secret = &corev1.Secret{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "Secret",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      secretName,
+				Namespace: *kubeconfigArgs.Namespace,
+				Labels:    sourceLabels,
+			},
+			StringData: map[string]string{
+				"token": token,
+			},
+		}
+	}
+
 	if createArgs.export {
+		if err := exportCreateNamespace(); err != nil {
+			return err
+		}
+		if secret != nil {
+			if err := printExport(secret); err != nil {
+				return err
+			}
+		}
 		return printExport(exportReceiver(&receiver))
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(kubeconfigArgs, kubeclientOptions)
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
 	if err != nil {
 		return err
 	}
 
+	if secret != nil {
+		// The token of an existing secret may be configured in the
+		// webhook of the sender already, it is never overwritten.
+		var existing corev1.Secret
+		err := kubeClient.Get(ctx, client.ObjectKeyFromObject(secret), &existing)
+		if err == nil {
+			return fmt.Errorf("secret '%s' already exists, use --secret-ref instead of --generate-secret to reference it", secretName)
+		}
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		if err := createNamespace(ctx, kubeClient, secret.Namespace); err != nil {
+			return err
+		}
+		logger.Actionf("applying token secret")
+		if err := kubeClient.Create(ctx, secret); err != nil {
+			return err
+		}
+		logger.Successf("generated token %s in secret '%s', it won't be printed again", secret.StringData["token"], secretName)
+	}
+
 	logger.Actionf("applying Receiver")
 	namespacedName, err := upsertReceiver(ctx, kubeClient, &receiver)
 	if err != nil {
 		return err
 	}
 
+	if skipSuspendedWait("Receiver") {
+		return nil
+	}
+
 	logger.Waitingf("waiting for Receiver reconciliation")
 	if err := wait.PollImmediate(rootArgs.pollInterval, rootArgs.timeout,
 		isReceiverReady(ctx, kubeClient, namespacedName, &receiver)); err != nil {
@@ -149,6 +228,15 @@ func createReceiverCmdRun(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// generateReceiverToken returns a random token for the Receiver webhooks.
+func generateReceiverToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
 func upsertReceiver(ctx context.Context, kubeClient client.Client,
 	receiver *notificationv1.Receiver) (types.NamespacedName, error) {
 	namespacedName := types.NamespacedName{
@@ -156,6 +244,10 @@ func upsertReceiver(ctx context.Context, kubeClient client.Client,
 		Name:      receiver.GetName(),
 	}
 
+	if err := createNamespace(ctx, kubeClient, namespacedName.Namespace); err != nil {
+		return namespacedName, err
+	}
+
 	var existing notificationv1.Receiver
 	err := kubeClient.Get(ctx, namespacedName, &existing)
 	if err != nil {
@@ -170,6 +262,10 @@ func upsertReceiver(ctx context.Context, kubeClient client.Client,
 		return namespacedName, err
 	}
 
+	if err := checkOverwrite("Receiver", &existing); err != nil {
+		return namespacedName, err
+	}
+
 	existing.Labels = receiver.Labels
 	existing.Spec = receiver.Spec
 	if err := kubeClient.Update(ctx, &existing); err != nil {
@@ -193,7 +289,7 @@ func isReceiverReady(ctx context.Context, kubeClient client.Client,
 			case metav1.ConditionTrue:
 				return true, nil
 			case metav1.ConditionFalse:
-				return false, fmt.Errorf(c.Message)
+				return false, conditionError(receiver, c)
 			}
 		}
 		return false, nil
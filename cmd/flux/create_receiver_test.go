@@ -0,0 +1,64 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCreateReceiverGenerateSecret(t *testing.T) {
+	isolateEnv(t)
+	useFakeCluster(t)
+
+	output, err := executeCommand("create receiver github-receiver --type=github --event=push --resource=GitRepository/webapp --generate-secret --export")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !regexp.MustCompile(`kind: Secret\nmetadata:\n  name: github-receiver-token\n  namespace: flux-system\nstringData:\n  token: [0-9a-f]{64}\n`).MatchString(output) {
+		t.Errorf("expected a token secret in the output, got:\n%s", output)
+	}
+	if !strings.Contains(output, "secretRef:\n    name: github-receiver-token\n") {
+		t.Errorf("expected the Receiver to reference the token secret, got:\n%s", output)
+	}
+
+	output, err = executeCommand("create receiver github-receiver --type=github --resource=GitRepository/webapp --generate-secret --secret-ref=webhook-token --export")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(output, "name: webhook-token\n") || strings.Contains(output, "github-receiver-token") {
+		t.Errorf("expected the secret to be named after --secret-ref, got:\n%s", output)
+	}
+}
+
+func TestCreateReceiverGenerateSecretExisting(t *testing.T) {
+	isolateEnv(t)
+	useFakeCluster(t, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "github-receiver-token", Namespace: "flux-system"},
+		StringData: map[string]string{"token": "configured"},
+	})
+
+	cmd := cmdTestCase{
+		args:   "create receiver github-receiver --type=github --resource=GitRepository/webapp --generate-secret",
+		assert: assertError("secret 'github-receiver-token' already exists, use --secret-ref instead of --generate-secret to reference it"),
+	}
+	cmd.runTestCmd(t)
+}
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"net/url"
 	"os"
+	"path/filepath"
 
 	"github.com/spf13/cobra"
 	corev1 "k8s.io/api/core/v1"
@@ -29,6 +30,8 @@ import (
 
 	"github.com/fluxcd/flux2/internal/flags"
 	"github.com/fluxcd/flux2/internal/utils"
+	"github.com/fluxcd/flux2/pkg/bootstrap/provider"
+	"github.com/fluxcd/flux2/pkg/manifestgen"
 	"github.com/fluxcd/flux2/pkg/manifestgen/sourcesecret"
 )
 
@@ -37,7 +40,7 @@ var createSecretGitCmd = &cobra.Command{
 	Short: "Create or update a Kubernetes secret for Git authentication",
 	Long: `The create secret git command generates a Kubernetes secret with Git credentials.
 For Git over SSH, the host and SSH keys are automatically generated and stored in the secret.
-For Git over HTTP/S, the provided basic authentication credentials are stored in the secret.`,
+For Git over HTTP/S, the provided basic authentication credentials or bearer token are stored in the secret.`,
 	Example: `  # Create a Git SSH authentication secret using an ECDSA P-521 curve public key
 
   flux create secret git podinfo-auth \
@@ -58,12 +61,30 @@ For Git over HTTP/S, the provided basic authentication credentials are stored in
     --private-key-file=./private.key \
     --password=<password>
 
+  # Create a Git SSH authentication secret for a repository with submodules
+  # hosted on another server, the host keys of both servers are added to known_hosts
+  flux create secret git podinfo-auth \
+    --url=ssh://git@github.com/stefanprodan/podinfo \
+    --submodule-url=ssh://git@gitlab.com/stefanprodan/charts
+
   # Create a secret for a Git repository using basic authentication
   flux create secret git podinfo-auth \
     --url=https://github.com/stefanprodan/podinfo \
     --username=username \
     --password=password
 
+  # Create a secret for a Git repository using bearer token authentication
+  flux create secret git podinfo-auth \
+    --url=https://dev.azure.com/<org>/<project>/_git/podinfo \
+    --bearer-token=<token>
+
+  # Create a secret for a GitHub repository using the credentials of a GitHub App
+  flux create secret git podinfo-auth \
+    --url=https://github.com/stefanprodan/podinfo \
+    --github-app-id=<app id> \
+    --github-app-installation-id=<installation id> \
+    --github-app-private-key-file=./app.pem
+
   # Create a Git SSH secret on disk
   flux create secret git podinfo-auth \
     --url=ssh://git@github.com/stefanprodan/podinfo \
@@ -72,6 +93,15 @@ For Git over HTTP/S, the provided basic authentication credentials are stored in
   # Print the deploy key
   yq eval '.stringData."identity.pub"' podinfo-auth.yaml
 
+  # Create an ExternalSecret referencing the credentials stored
+  # at the path 'git/podinfo' of the 'vault-backend' SecretStore
+  flux create secret git podinfo-auth \
+    --url=ssh://git@github.com/stefanprodan/podinfo \
+    --backend=external-secrets \
+    --store=vault-backend \
+    --store-path=git/podinfo \
+    --export > podinfo-auth.yaml
+
   # Encrypt the secret on disk with Mozilla SOPS
   sops --encrypt --encrypted-regex '^(data|stringData)$' \
     --in-place podinfo-auth.yaml`,
@@ -82,11 +112,20 @@ type secretGitFlags struct {
 	url            string
 	username       string
 	password       string
+	bearerToken    string
 	keyAlgorithm   flags.PublicKeyAlgorithm
 	rsaBits        flags.RSAKeyBits
 	ecdsaCurve     flags.ECDSACurve
 	caFile         string
 	privateKeyFile string
+	backend        flags.SecretBackend
+	store          string
+	storePath      string
+	submoduleURLs  []string
+
+	githubAppID             string
+	githubAppInstallationID string
+	githubAppPrivateKeyFile string
 }
 
 var secretGitArgs = NewSecretGitFlags()
@@ -95,11 +134,24 @@ func init() {
 	createSecretGitCmd.Flags().StringVar(&secretGitArgs.url, "url", "", "git address, e.g. ssh://git@host/org/repository")
 	createSecretGitCmd.Flags().StringVarP(&secretGitArgs.username, "username", "u", "", "basic authentication username")
 	createSecretGitCmd.Flags().StringVarP(&secretGitArgs.password, "password", "p", "", "basic authentication password")
+	createSecretGitCmd.Flags().StringVar(&secretGitArgs.bearerToken, "bearer-token", "",
+		"token sent in the 'Authorization: Bearer' header for Git over HTTP/S, instead of basic authentication")
 	createSecretGitCmd.Flags().Var(&secretGitArgs.keyAlgorithm, "ssh-key-algorithm", secretGitArgs.keyAlgorithm.Description())
 	createSecretGitCmd.Flags().Var(&secretGitArgs.rsaBits, "ssh-rsa-bits", secretGitArgs.rsaBits.Description())
 	createSecretGitCmd.Flags().Var(&secretGitArgs.ecdsaCurve, "ssh-ecdsa-curve", secretGitArgs.ecdsaCurve.Description())
 	createSecretGitCmd.Flags().StringVar(&secretGitArgs.caFile, "ca-file", "", "path to TLS CA file used for validating self-signed certificates")
 	createSecretGitCmd.Flags().StringVar(&secretGitArgs.privateKeyFile, "private-key-file", "", "path to a passwordless private key file used for authenticating to the Git SSH server")
+	createSecretGitCmd.Flags().StringSliceVar(&secretGitArgs.submoduleURLs, "submodule-url", nil,
+		"URLs of the Git submodules of the repository, the host keys of the SSH submodule hosts are added to known_hosts, accepts comma-separated values")
+	createSecretGitCmd.Flags().StringVar(&secretGitArgs.githubAppID, "github-app-id", "", "ID of the GitHub App source-controller authenticates with to GitHub over HTTPS")
+	createSecretGitCmd.Flags().StringVar(&secretGitArgs.githubAppInstallationID, "github-app-installation-id", "", "ID of the installation of the GitHub App on the owner of the repository")
+	createSecretGitCmd.Flags().StringVar(&secretGitArgs.githubAppPrivateKeyFile, "github-app-private-key-file", "", "path to the PEM encoded private key of the GitHub App")
+
+	createSecretGitCmd.Flags().Var(&secretGitArgs.backend, "backend", secretGitArgs.backend.Description())
+	createSecretGitCmd.Flags().StringVar(&secretGitArgs.store, "store", "",
+		"name of the SecretStore for the external-secrets backend, or of the VaultAuth for the vault backend")
+	createSecretGitCmd.Flags().StringVar(&secretGitArgs.storePath, "store-path", "",
+		"path of the credentials in the store, defaults to <namespace>/<name>, for the vault backend the first element is the KV mount")
 
 	createSecretCmd.AddCommand(createSecretGitCmd)
 }
@@ -109,6 +161,7 @@ func NewSecretGitFlags() secretGitFlags {
 		keyAlgorithm: flags.PublicKeyAlgorithm(sourcesecret.ECDSAPrivateKeyAlgorithm),
 		rsaBits:      2048,
 		ecdsaCurve:   flags.ECDSACurve{Curve: elliptic.P384()},
+		backend:      flags.SecretBackend(sourcesecret.KubernetesBackend),
 	}
 }
 
@@ -133,7 +186,33 @@ func createSecretGitCmdRun(cmd *cobra.Command, args []string) error {
 		Namespace:    *kubeconfigArgs.Namespace,
 		Labels:       labels,
 		ManifestFile: sourcesecret.MakeDefaultOptions().ManifestFile,
+		Backend:      sourcesecret.Backend(secretGitArgs.backend),
+		Store:        secretGitArgs.store,
+		StorePath:    secretGitArgs.storePath,
+	}
+	if opts.Backend != sourcesecret.KubernetesBackend {
+		// the credentials are read from the secret store by the backend
+		for flag, value := range map[string]string{
+			"username":         secretGitArgs.username,
+			"password":         secretGitArgs.password,
+			"private-key-file": secretGitArgs.privateKeyFile,
+		} {
+			if value != "" {
+				return fmt.Errorf("--%s is not supported by the %s backend, the credentials are read from the secret store", flag, opts.Backend)
+			}
+		}
+		return createSecretGitFromBackend(opts)
+	}
+
+	extraHosts, err := submoduleSSHHosts(u, secretGitArgs.submoduleURLs)
+	if err != nil {
+		return err
+	}
+
+	if secretGitArgs.bearerToken != "" && u.Scheme != "https" && u.Scheme != "http" {
+		return fmt.Errorf("--bearer-token is only supported for Git over HTTP/S")
 	}
+
 	switch u.Scheme {
 	case "ssh":
 		keypair, err := sourcesecret.LoadKeyPairFromPath(secretGitArgs.privateKeyFile, secretGitArgs.password)
@@ -142,22 +221,37 @@ func createSecretGitCmdRun(cmd *cobra.Command, args []string) error {
 		}
 		opts.Keypair = keypair
 		opts.SSHHostname = u.Host
+		opts.ExtraSSHHostnames = extraHosts
 		opts.PrivateKeyAlgorithm = sourcesecret.PrivateKeyAlgorithm(secretGitArgs.keyAlgorithm)
 		opts.RSAKeyBits = int(secretGitArgs.rsaBits)
 		opts.ECDSACurve = secretGitArgs.ecdsaCurve.Curve
 		opts.Password = secretGitArgs.password
-	case "http", "https":
-		if secretGitArgs.username == "" || secretGitArgs.password == "" {
-			return fmt.Errorf("for Git over HTTP/S the username and password are required")
-		}
-		opts.Username = secretGitArgs.username
-		opts.Password = secretGitArgs.password
-		if secretGitArgs.caFile != "" {
-			caBundle, err := os.ReadFile(secretGitArgs.caFile)
-			if err != nil {
-				return fmt.Errorf("unable to read TLS CA file: %w", err)
+	case "https":
+		if secretGitArgs.githubAppID == "" && secretGitArgs.githubAppInstallationID == "" && secretGitArgs.githubAppPrivateKeyFile == "" {
+			if err := setHTTPAuthOptions(&opts); err != nil {
+				return err
 			}
-			opts.CAFile = caBundle
+			break
+		}
+		if secretGitArgs.bearerToken != "" {
+			return fmt.Errorf("--bearer-token and GitHub App authentication are mutually exclusive")
+		}
+		if secretGitArgs.githubAppID == "" || secretGitArgs.githubAppInstallationID == "" || secretGitArgs.githubAppPrivateKeyFile == "" {
+			return fmt.Errorf("--github-app-id, --github-app-installation-id and --github-app-private-key-file are required for GitHub App authentication")
+		}
+		privateKey, err := os.ReadFile(secretGitArgs.githubAppPrivateKeyFile)
+		if err != nil {
+			return fmt.Errorf("unable to read GitHub App private key: %w", err)
+		}
+		opts.GitHubAppID = secretGitArgs.githubAppID
+		opts.GitHubAppInstallationID = secretGitArgs.githubAppInstallationID
+		opts.GitHubAppPrivateKey = privateKey
+		if u.Hostname() != ghDefaultDomain {
+			opts.GitHubAppBaseURL = provider.GitHubAppBaseURL(u.Host)
+		}
+	case "http":
+		if err := setHTTPAuthOptions(&opts); err != nil {
+			return err
 		}
 	default:
 		return fmt.Errorf("git URL scheme '%s' not supported, can be: ssh, http and https", u.Scheme)
@@ -169,6 +263,9 @@ func createSecretGitCmdRun(cmd *cobra.Command, args []string) error {
 	}
 
 	if createArgs.export {
+		if err := exportCreateNamespace(); err != nil {
+			return err
+		}
 		rootCmd.Println(secret.Content)
 		return nil
 	}
@@ -184,7 +281,7 @@ func createSecretGitCmdRun(cmd *cobra.Command, args []string) error {
 
 	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
 	defer cancel()
-	kubeClient, err := utils.KubeClient(kubeconfigArgs, kubeclientOptions)
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
 	if err != nil {
 		return err
 	}
@@ -195,3 +292,99 @@ func createSecretGitCmdRun(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// setHTTPAuthOptions sets the basic authentication credentials or the
+// bearer token, and the CA bundle for Git over HTTP/S.
+func setHTTPAuthOptions(opts *sourcesecret.Options) error {
+	if secretGitArgs.bearerToken != "" {
+		if secretGitArgs.username != "" || secretGitArgs.password != "" {
+			return fmt.Errorf("--bearer-token and basic authentication with --username and --password are mutually exclusive")
+		}
+		opts.BearerToken = secretGitArgs.bearerToken
+	} else {
+		if secretGitArgs.username == "" || secretGitArgs.password == "" {
+			return fmt.Errorf("for Git over HTTP/S the username and password are required")
+		}
+		opts.Username = secretGitArgs.username
+		opts.Password = secretGitArgs.password
+	}
+	if secretGitArgs.caFile != "" {
+		caBundle, err := os.ReadFile(secretGitArgs.caFile)
+		if err != nil {
+			return fmt.Errorf("unable to read TLS CA file: %w", err)
+		}
+		opts.CAFile = caBundle
+	}
+	return nil
+}
+
+// submoduleSSHHosts returns the hosts of the SSH submodule URLs which differ
+// from the repository host, and warns about the submodules for which the
+// credentials of the repository may not be valid.
+func submoduleSSHHosts(repository *url.URL, submoduleURLs []string) ([]string, error) {
+	var hosts []string
+	for _, submoduleURL := range submoduleURLs {
+		u, err := url.Parse(submoduleURL)
+		if err != nil {
+			return nil, fmt.Errorf("submodule URL parse failed: %w", err)
+		}
+		if u.Scheme != repository.Scheme {
+			logger.Warningf("submodule %s uses %s while the repository uses %s, the secret only contains %s credentials",
+				submoduleURL, u.Scheme, repository.Scheme, repository.Scheme)
+			continue
+		}
+		if u.Host == repository.Host {
+			continue
+		}
+		logger.Warningf("submodule host %s differs from %s, the credentials must be valid for both hosts",
+			u.Host, repository.Host)
+		if u.Scheme == "ssh" && !utils.ContainsItemString(hosts, u.Host) {
+			hosts = append(hosts, u.Host)
+		}
+	}
+	return hosts, nil
+}
+
+// createSecretGitFromBackend generates a manifest which references the Git
+// credentials in an external secret store, and applies it to the cluster
+// using server-side apply as the kind is not known to the Flux scheme.
+func createSecretGitFromBackend(opts sourcesecret.Options) error {
+	manifest, err := sourcesecret.Generate(opts)
+	if err != nil {
+		return err
+	}
+
+	if createArgs.export {
+		if err := exportCreateNamespace(); err != nil {
+			return err
+		}
+		rootCmd.Println(manifest.Content)
+		return nil
+	}
+
+	tmpDir, err := manifestgen.MkdirTempAbs("", opts.Namespace)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if _, err := manifest.WriteFile(tmpDir); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	defer cancel()
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
+	if err != nil {
+		return err
+	}
+	if err := createNamespace(ctx, kubeClient, opts.Namespace); err != nil {
+		return err
+	}
+	if _, err := utils.Apply(ctx, kubeconfigArgs, kubeclientOptions, tmpDir, filepath.Join(tmpDir, manifest.Path)); err != nil {
+		return err
+	}
+	logger.Successf("git secret '%s' referencing the %s backend created in '%s' namespace", opts.Name, opts.Backend, opts.Namespace)
+
+	return nil
+}
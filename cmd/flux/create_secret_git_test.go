@@ -1,6 +1,8 @@
 package main
 
 import (
+	"net/url"
+	"reflect"
 	"testing"
 )
 
@@ -20,6 +22,21 @@ func TestCreateGitSecret(t *testing.T) {
 			args:   "create secret git podinfo-auth --url=https://github.com/stefanprodan/podinfo --username=my-username --password=my-password --namespace=my-namespace --export",
 			assert: assertGoldenFile("./testdata/create_secret/git/secret-git-basic.yaml"),
 		},
+		{
+			name:   "bearer token",
+			args:   "create secret git podinfo-auth --url=https://github.com/stefanprodan/podinfo --bearer-token=my-token --namespace=my-namespace --export",
+			assert: assertGoldenFile("testdata/create_secret/git/secret-git-bearer-token.yaml"),
+		},
+		{
+			name:   "bearer token with basic auth",
+			args:   "create secret git podinfo-auth --url=https://github.com/stefanprodan/podinfo --bearer-token=my-token --username=my-username --password=my-password --export",
+			assert: assertError("--bearer-token and basic authentication with --username and --password are mutually exclusive"),
+		},
+		{
+			name:   "bearer token over ssh",
+			args:   "create secret git podinfo-auth --url=ssh://git@github.com/stefanprodan/podinfo --bearer-token=my-token --export",
+			assert: assertError("--bearer-token is only supported for Git over HTTP/S"),
+		},
 		{
 			name:   "ssh key",
 			args:   "create secret git podinfo-auth --url=ssh://git@github.com/stefanprodan/podinfo --private-key-file=./testdata/create_secret/git/ecdsa.private --namespace=my-namespace --export",
@@ -30,6 +47,46 @@ func TestCreateGitSecret(t *testing.T) {
 			args:   "create secret git podinfo-auth --url=ssh://git@github.com/stefanprodan/podinfo --private-key-file=./testdata/create_secret/git/ecdsa-password.private --password=password --namespace=my-namespace --export",
 			assert: assertGoldenFile("testdata/create_secret/git/git-ssh-secret-password.yaml"),
 		},
+		{
+			name:   "github app",
+			args:   "create secret git podinfo-auth --url=https://github.com/stefanprodan/podinfo --github-app-id=123 --github-app-installation-id=456 --github-app-private-key-file=./testdata/create_secret/git/github-app.pem --namespace=my-namespace --export",
+			assert: assertGoldenFile("testdata/create_secret/git/secret-git-github-app.yaml"),
+		},
+		{
+			name:   "github app on enterprise server",
+			args:   "create secret git podinfo-auth --url=https://github.example.com/stefanprodan/podinfo --github-app-id=123 --github-app-installation-id=456 --github-app-private-key-file=./testdata/create_secret/git/github-app.pem --namespace=my-namespace --export",
+			assert: assertGoldenFile("testdata/create_secret/git/secret-git-github-app-enterprise.yaml"),
+		},
+		{
+			name:   "github app without private key",
+			args:   "create secret git podinfo-auth --url=https://github.com/stefanprodan/podinfo --github-app-id=123 --github-app-installation-id=456 --export",
+			assert: assertError("--github-app-id, --github-app-installation-id and --github-app-private-key-file are required for GitHub App authentication"),
+		},
+		{
+			name:   "external secrets backend",
+			args:   "create secret git podinfo-auth --url=ssh://git@github.com/stefanprodan/podinfo --backend=external-secrets --store=vault-backend --store-path=git/podinfo --namespace=my-namespace --export",
+			assert: assertGoldenFile("testdata/create_secret/git/secret-git-external-secrets.yaml"),
+		},
+		{
+			name:   "vault backend",
+			args:   "create secret git podinfo-auth --url=ssh://git@github.com/stefanprodan/podinfo --backend=vault --store=flux --store-path=kv/git/podinfo --namespace=my-namespace --export",
+			assert: assertGoldenFile("testdata/create_secret/git/secret-git-vault.yaml"),
+		},
+		{
+			name:   "backend without store",
+			args:   "create secret git podinfo-auth --url=ssh://git@github.com/stefanprodan/podinfo --backend=external-secrets --export",
+			assert: assertError("a secret store name is required for the external-secrets backend"),
+		},
+		{
+			name:   "backend with basic auth",
+			args:   "create secret git podinfo-auth --url=https://github.com/stefanprodan/podinfo --backend=vault --store=flux --password=s3cr3t --export",
+			assert: assertError("--password is not supported by the vault backend, the credentials are read from the secret store"),
+		},
+		{
+			name:   "backend with private key",
+			args:   "create secret git podinfo-auth --url=ssh://git@github.com/stefanprodan/podinfo --backend=external-secrets --store=vault-backend --private-key-file=./testdata/create_secret/git/ecdsa.private --export",
+			assert: assertError("--private-key-file is not supported by the external-secrets backend, the credentials are read from the secret store"),
+		},
 	}
 
 	for _, tt := range tests {
@@ -42,3 +99,23 @@ func TestCreateGitSecret(t *testing.T) {
 		})
 	}
 }
+
+func TestSubmoduleSSHHosts(t *testing.T) {
+	repository, _ := url.Parse("ssh://git@github.com/stefanprodan/podinfo")
+	hosts, err := submoduleSSHHosts(repository, []string{
+		"ssh://git@github.com/stefanprodan/charts",
+		"ssh://git@gitlab.com/stefanprodan/charts",
+		"ssh://git@gitlab.com/stefanprodan/docs",
+		"https://bitbucket.org/stefanprodan/docs",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if want := []string{"gitlab.com"}; !reflect.DeepEqual(hosts, want) {
+		t.Errorf("submoduleSSHHosts() = %v, want %v", hosts, want)
+	}
+
+	if _, err := submoduleSSHHosts(repository, []string{"ssh://git@gitlab.com:port/invalid"}); err == nil {
+		t.Error("expected error for invalid URL")
+	}
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/fluxcd/flux2/pkg/manifestgen/sourcesecret"
+)
+
+var createSecretGPGKeysCmd = &cobra.Command{
+	Use:   "gpg-keys [name]",
+	Short: "Create or update a Kubernetes secret with GPG public keys",
+	Long: `The create secret gpg-keys command generates a Kubernetes secret with the GPG public keys
+of the trusted Git authors, for verifying the commit signatures of a GitRepository.
+Each key ring file is stored under its file name, the files must contain armored public keys only.`,
+	Example: `  # Create a secret with the public keys of the maintainers
+  flux create secret gpg-keys pgp-public-keys \
+    --keyring=./keys/alice.asc \
+    --keyring=./keys/bob.asc
+
+  # Use the secret to verify the commits of a GitRepository
+  flux create source git podinfo \
+    --url=https://github.com/stefanprodan/podinfo \
+    --branch=master \
+    --verify-provenance \
+    --verify-secret-ref=pgp-public-keys`,
+	RunE: createSecretGPGKeysCmdRun,
+}
+
+type secretGPGKeysFlags struct {
+	keyRings []string
+}
+
+var secretGPGKeysArgs secretGPGKeysFlags
+
+func init() {
+	createSecretGPGKeysCmd.Flags().StringSliceVar(&secretGPGKeysArgs.keyRings, "keyring", nil,
+		"path to an armored GPG public key ring file, can be repeated or accepts comma-separated values")
+	createSecretCmd.AddCommand(createSecretGPGKeysCmd)
+}
+
+func createSecretGPGKeysCmdRun(cmd *cobra.Command, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("secret name is required")
+	}
+	name := args[0]
+
+	if len(secretGPGKeysArgs.keyRings) == 0 {
+		return fmt.Errorf("at least one --keyring is required")
+	}
+
+	labels, err := parseLabels()
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[string][]byte, len(secretGPGKeysArgs.keyRings))
+	for _, path := range secretGPGKeysArgs.keyRings {
+		key := filepath.Base(path)
+		if _, ok := keys[key]; ok {
+			return fmt.Errorf("duplicate key ring file name '%s'", key)
+		}
+		keyRing, err := readGPGPublicKeyRing(path)
+		if err != nil {
+			return err
+		}
+		keys[key] = keyRing
+	}
+
+	opts := sourcesecret.Options{
+		Name:          name,
+		Namespace:     *kubeconfigArgs.Namespace,
+		Labels:        labels,
+		GPGPublicKeys: keys,
+	}
+	secret, err := sourcesecret.Generate(opts)
+	if err != nil {
+		return err
+	}
+
+	if createArgs.export {
+		if err := exportCreateNamespace(); err != nil {
+			return err
+		}
+		rootCmd.Print(secret.Content)
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	defer cancel()
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
+	if err != nil {
+		return err
+	}
+	var s corev1.Secret
+	if err := yaml.Unmarshal([]byte(secret.Content), &s); err != nil {
+		return err
+	}
+	if err := upsertSecret(ctx, kubeClient, s); err != nil {
+		return err
+	}
+
+	logger.Actionf("gpg-keys secret '%s' created in '%s' namespace", name, *kubeconfigArgs.Namespace)
+	return nil
+}
+
+// readGPGPublicKeyRing reads an armored key ring file, making sure it
+// contains public keys only so that no private key ends up in the cluster.
+func readGPGPublicKeyRing(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read key ring file: %w", err)
+	}
+	entities, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read key ring file '%s': %w", path, err)
+	}
+	for _, entity := range entities {
+		if entity.PrivateKey != nil {
+			return nil, fmt.Errorf("key ring file '%s' contains a private key, export the public key with 'gpg --export --armor'", path)
+		}
+	}
+	return data, nil
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+)
+
+func TestCreateSecretGPGKeys(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   string
+		assert assertFunc
+	}{
+		{
+			name:   "no keyring",
+			args:   "create secret gpg-keys pgp-public-keys --export",
+			assert: assertError("at least one --keyring is required"),
+		},
+		{
+			name:   "private key",
+			args:   "create secret gpg-keys pgp-public-keys --keyring=./testdata/create_secret/gpg-keys/private.asc --export",
+			assert: assertError("key ring file './testdata/create_secret/gpg-keys/private.asc' contains a private key, export the public key with 'gpg --export --armor'"),
+		},
+		{
+			name:   "public key",
+			args:   "create secret gpg-keys pgp-public-keys --namespace=my-namespace --keyring=./testdata/create_secret/gpg-keys/author.asc --export",
+			assert: assertGoldenFile("testdata/create_secret/gpg-keys/secret-gpg-keys.yaml"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := cmdTestCase{
+				args:   tt.args,
+				assert: tt.assert,
+			}
+			cmd.runTestCmd(t)
+		})
+	}
+}
@@ -20,7 +20,6 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"strings"
 
 	"github.com/spf13/cobra"
 	corev1 "k8s.io/api/core/v1"
@@ -36,7 +35,7 @@ import (
 	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
 
 	"github.com/fluxcd/flux2/internal/flags"
-	"github.com/fluxcd/flux2/internal/utils"
+	"github.com/fluxcd/flux2/pkg/apigen"
 )
 
 var createSourceBucketCmd = &cobra.Command{
@@ -119,49 +118,40 @@ func createSourceBucketCmdRun(cmd *cobra.Command, args []string) error {
 	}
 	defer os.RemoveAll(tmpDir)
 
-	var ignorePaths *string
-	if len(sourceBucketArgs.ignorePaths) > 0 {
-		ignorePathsStr := strings.Join(sourceBucketArgs.ignorePaths, "\n")
-		ignorePaths = &ignorePathsStr
-	}
-
-	bucket := &sourcev1.Bucket{
-		ObjectMeta: metav1.ObjectMeta{
+	bucket, err := apigen.Bucket(apigen.BucketOptions{
+		ObjectOptions: apigen.ObjectOptions{
 			Name:      name,
 			Namespace: *kubeconfigArgs.Namespace,
 			Labels:    sourceLabels,
+			Interval:  createArgs.interval,
+			Suspend:   createArgs.suspend,
 		},
-		Spec: sourcev1.BucketSpec{
-			BucketName: sourceBucketArgs.name,
-			Provider:   sourceBucketArgs.provider.String(),
-			Insecure:   sourceBucketArgs.insecure,
-			Endpoint:   sourceBucketArgs.endpoint,
-			Region:     sourceBucketArgs.region,
-			Interval: metav1.Duration{
-				Duration: createArgs.interval,
-			},
-			Ignore: ignorePaths,
-		},
-	}
-
-	if createSourceArgs.fetchTimeout > 0 {
-		bucket.Spec.Timeout = &metav1.Duration{Duration: createSourceArgs.fetchTimeout}
+		BucketName:  sourceBucketArgs.name,
+		Provider:    sourceBucketArgs.provider.String(),
+		Endpoint:    sourceBucketArgs.endpoint,
+		Region:      sourceBucketArgs.region,
+		Insecure:    sourceBucketArgs.insecure,
+		SecretRef:   sourceBucketArgs.secretRef,
+		IgnorePaths: sourceBucketArgs.ignorePaths,
+		Timeout:     createSourceArgs.fetchTimeout,
+	})
+	if err != nil {
+		return err
 	}
 
-	if sourceBucketArgs.secretRef != "" {
-		bucket.Spec.SecretRef = &meta.LocalObjectReference{
-			Name: sourceBucketArgs.secretRef,
-		}
-	}
+	lintCreated(bucket)
 
 	if createArgs.export {
+		if err := exportCreateNamespace(); err != nil {
+			return err
+		}
 		return printExport(exportBucket(bucket))
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(kubeconfigArgs, kubeclientOptions)
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
 	if err != nil {
 		return err
 	}
@@ -203,6 +193,10 @@ func createSourceBucketCmdRun(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if skipSuspendedWait("Bucket source") {
+		return nil
+	}
+
 	logger.Waitingf("waiting for Bucket source reconciliation")
 	if err := wait.PollImmediate(rootArgs.pollInterval, rootArgs.timeout,
 		isBucketReady(ctx, kubeClient, namespacedName, bucket)); err != nil {
@@ -224,6 +218,10 @@ func upsertBucket(ctx context.Context, kubeClient client.Client,
 		Name:      bucket.GetName(),
 	}
 
+	if err := createNamespace(ctx, kubeClient, namespacedName.Namespace); err != nil {
+		return namespacedName, err
+	}
+
 	var existing sourcev1.Bucket
 	err := kubeClient.Get(ctx, namespacedName, &existing)
 	if err != nil {
@@ -238,6 +236,10 @@ func upsertBucket(ctx context.Context, kubeClient client.Client,
 		return namespacedName, err
 	}
 
+	if err := checkOverwrite("Bucket", &existing); err != nil {
+		return namespacedName, err
+	}
+
 	existing.Labels = bucket.Labels
 	existing.Spec = bucket.Spec
 	if err := kubeClient.Update(ctx, &existing); err != nil {
@@ -268,7 +270,7 @@ func isBucketReady(ctx context.Context, kubeClient client.Client,
 			case metav1.ConditionTrue:
 				return true, nil
 			case metav1.ConditionFalse:
-				return false, fmt.Errorf(c.Message)
+				return false, conditionError(bucket, c)
 			}
 		}
 		return false, nil
@@ -22,7 +22,6 @@ import (
 	"fmt"
 	"net/url"
 	"os"
-	"strings"
 
 	"github.com/manifoldco/promptui"
 	"github.com/spf13/cobra"
@@ -40,7 +39,7 @@ import (
 	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
 
 	"github.com/fluxcd/flux2/internal/flags"
-	"github.com/fluxcd/flux2/internal/utils"
+	"github.com/fluxcd/flux2/pkg/apigen"
 	"github.com/fluxcd/flux2/pkg/manifestgen/sourcesecret"
 )
 
@@ -58,8 +57,12 @@ type sourceGitFlags struct {
 	caFile            string
 	privateKeyFile    string
 	recurseSubmodules bool
+	submoduleURLs     []string
 	silent            bool
 	ignorePaths       []string
+	verifyProvenance  bool
+	verifySecretRef   string
+	verifyMode        string
 }
 
 var createSourceGitCmd = &cobra.Command{
@@ -118,7 +121,23 @@ For private Git repositories, the basic authentication credentials are stored in
     --url=https://github.com/stefanprodan/podinfo \
     --branch=master \
     --username=username \
-    --password=password`,
+    --password=password
+
+  # Create a source for a Git repository with submodules using SSH authentication,
+  # the host keys of the submodule servers are added to known_hosts
+  flux create source git podinfo \
+    --url=ssh://git@github.com/stefanprodan/podinfo \
+    --branch=master \
+    --recurse-submodules \
+    --submodule-url=ssh://git@gitlab.com/stefanprodan/charts
+
+  # Create a source for a Git repository which verifies the signature of the HEAD commit
+  # with the GPG public keys of a secret created with 'flux create secret gpg-keys'
+  flux create source git podinfo \
+    --url=https://github.com/stefanprodan/podinfo \
+    --branch=master \
+    --verify-provenance \
+    --verify-secret-ref=pgp-public-keys`,
 	RunE: createSourceGitCmdRun,
 }
 
@@ -139,8 +158,16 @@ func init() {
 	createSourceGitCmd.Flags().StringVar(&sourceGitArgs.privateKeyFile, "private-key-file", "", "path to a passwordless private key file used for authenticating to the Git SSH server")
 	createSourceGitCmd.Flags().BoolVar(&sourceGitArgs.recurseSubmodules, "recurse-submodules", false,
 		"when enabled, configures the GitRepository source to initialize and include Git submodules in the artifact it produces")
+	createSourceGitCmd.Flags().StringSliceVar(&sourceGitArgs.submoduleURLs, "submodule-url", nil,
+		"URLs of the Git submodules, the host keys of the SSH submodule hosts are added to the generated known_hosts, accepts comma-separated values")
 	createSourceGitCmd.Flags().BoolVarP(&sourceGitArgs.silent, "silent", "s", false, "assumes the deploy key is already setup, skips confirmation")
 	createSourceGitCmd.Flags().StringSliceVar(&sourceGitArgs.ignorePaths, "ignore-paths", nil, "set paths to ignore in git resource (can specify multiple paths with commas: path1,path2)")
+	createSourceGitCmd.Flags().BoolVar(&sourceGitArgs.verifyProvenance, "verify-provenance", false,
+		"verify the signature of the fetched commits with the GPG public keys of the secret given with --verify-secret-ref")
+	createSourceGitCmd.Flags().StringVar(&sourceGitArgs.verifySecretRef, "verify-secret-ref", "",
+		"the name of an existing secret containing the GPG public keys of the trusted Git authors")
+	createSourceGitCmd.Flags().StringVar(&sourceGitArgs.verifyMode, "verify-mode", "head",
+		"what Git object to verify the signature of, can be: head")
 
 	createSourceCmd.AddCommand(createSourceGitCmd)
 }
@@ -150,6 +177,7 @@ func newSourceGitFlags() sourceGitFlags {
 		keyAlgorithm:  flags.PublicKeyAlgorithm(sourcesecret.ECDSAPrivateKeyAlgorithm),
 		keyRSABits:    2048,
 		keyECDSACurve: flags.ECDSACurve{Curve: elliptic.P384()},
+		verifyMode:    "head",
 	}
 }
 
@@ -176,6 +204,17 @@ func createSourceGitCmdRun(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("specifying a CA file is not supported for Git over SSH")
 	}
 
+	if len(sourceGitArgs.submoduleURLs) > 0 && !sourceGitArgs.recurseSubmodules {
+		return fmt.Errorf("--submodule-url requires --recurse-submodules")
+	}
+
+	if sourceGitArgs.verifyProvenance && sourceGitArgs.verifySecretRef == "" {
+		return fmt.Errorf("--verify-provenance requires --verify-secret-ref")
+	}
+	if !sourceGitArgs.verifyProvenance && sourceGitArgs.verifySecretRef != "" {
+		return fmt.Errorf("--verify-secret-ref requires --verify-provenance")
+	}
+
 	tmpDir, err := os.MkdirTemp("", name)
 	if err != nil {
 		return err
@@ -187,61 +226,53 @@ func createSourceGitCmdRun(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	var ignorePaths *string
-	if len(sourceGitArgs.ignorePaths) > 0 {
-		ignorePathsStr := strings.Join(sourceGitArgs.ignorePaths, "\n")
-		ignorePaths = &ignorePathsStr
-	}
-
-	gitRepository := sourcev1.GitRepository{
-		ObjectMeta: metav1.ObjectMeta{
+	gitRepository, err := apigen.GitRepository(apigen.GitRepositoryOptions{
+		ObjectOptions: apigen.ObjectOptions{
 			Name:      name,
 			Namespace: *kubeconfigArgs.Namespace,
 			Labels:    sourceLabels,
+			Interval:  createArgs.interval,
+			Suspend:   createArgs.suspend,
 		},
-		Spec: sourcev1.GitRepositorySpec{
-			URL: sourceGitArgs.url,
-			Interval: metav1.Duration{
-				Duration: createArgs.interval,
-			},
-			RecurseSubmodules: sourceGitArgs.recurseSubmodules,
-			Reference:         &sourcev1.GitRepositoryRef{},
-			Ignore:            ignorePaths,
-		},
-	}
-
-	if createSourceArgs.fetchTimeout > 0 {
-		gitRepository.Spec.Timeout = &metav1.Duration{Duration: createSourceArgs.fetchTimeout}
-	}
-
-	if sourceGitArgs.semver != "" {
-		gitRepository.Spec.Reference.SemVer = sourceGitArgs.semver
-	} else if sourceGitArgs.tag != "" {
-		gitRepository.Spec.Reference.Tag = sourceGitArgs.tag
-	} else {
-		gitRepository.Spec.Reference.Branch = sourceGitArgs.branch
+		URL:               sourceGitArgs.url,
+		Branch:            sourceGitArgs.branch,
+		Tag:               sourceGitArgs.tag,
+		SemVer:            sourceGitArgs.semver,
+		SecretRef:         sourceGitArgs.secretRef,
+		RecurseSubmodules: sourceGitArgs.recurseSubmodules,
+		IgnorePaths:       sourceGitArgs.ignorePaths,
+		Timeout:           createSourceArgs.fetchTimeout,
+		VerifySecretRef:   sourceGitArgs.verifySecretRef,
+		VerifyMode:        sourceGitArgs.verifyMode,
+	})
+	if err != nil {
+		return err
 	}
 
-	if sourceGitArgs.secretRef != "" {
-		gitRepository.Spec.SecretRef = &meta.LocalObjectReference{
-			Name: sourceGitArgs.secretRef,
-		}
-	}
+	lintCreated(gitRepository)
 
 	if createArgs.export {
-		return printExport(exportGit(&gitRepository))
+		if err := exportCreateNamespace(); err != nil {
+			return err
+		}
+		return printExport(exportGit(gitRepository))
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(kubeconfigArgs, kubeclientOptions)
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
 	if err != nil {
 		return err
 	}
 
 	logger.Generatef("generating GitRepository source")
 	if sourceGitArgs.secretRef == "" {
+		extraHosts, err := submoduleSSHHosts(u, sourceGitArgs.submoduleURLs)
+		if err != nil {
+			return err
+		}
+
 		secretOpts := sourcesecret.Options{
 			Name:         name,
 			Namespace:    *kubeconfigArgs.Namespace,
@@ -255,6 +286,7 @@ func createSourceGitCmdRun(cmd *cobra.Command, args []string) error {
 			}
 			secretOpts.Keypair = keypair
 			secretOpts.SSHHostname = u.Host
+			secretOpts.ExtraSSHHostnames = extraHosts
 			secretOpts.PrivateKeyAlgorithm = sourcesecret.PrivateKeyAlgorithm(sourceGitArgs.keyAlgorithm)
 			secretOpts.RSAKeyBits = int(sourceGitArgs.keyRSABits)
 			secretOpts.ECDSACurve = sourceGitArgs.keyECDSACurve.Curve
@@ -310,14 +342,18 @@ func createSourceGitCmdRun(cmd *cobra.Command, args []string) error {
 	}
 
 	logger.Actionf("applying GitRepository source")
-	namespacedName, err := upsertGitRepository(ctx, kubeClient, &gitRepository)
+	namespacedName, err := upsertGitRepository(ctx, kubeClient, gitRepository)
 	if err != nil {
 		return err
 	}
 
+	if skipSuspendedWait("GitRepository source") {
+		return nil
+	}
+
 	logger.Waitingf("waiting for GitRepository source reconciliation")
 	if err := wait.PollImmediate(rootArgs.pollInterval, rootArgs.timeout,
-		isGitRepositoryReady(ctx, kubeClient, namespacedName, &gitRepository)); err != nil {
+		isGitRepositoryReady(ctx, kubeClient, namespacedName, gitRepository)); err != nil {
 		return err
 	}
 	logger.Successf("GitRepository source reconciliation completed")
@@ -336,6 +372,10 @@ func upsertGitRepository(ctx context.Context, kubeClient client.Client,
 		Name:      gitRepository.GetName(),
 	}
 
+	if err := createNamespace(ctx, kubeClient, namespacedName.Namespace); err != nil {
+		return namespacedName, err
+	}
+
 	var existing sourcev1.GitRepository
 	err := kubeClient.Get(ctx, namespacedName, &existing)
 	if err != nil {
@@ -350,6 +390,10 @@ func upsertGitRepository(ctx context.Context, kubeClient client.Client,
 		return namespacedName, err
 	}
 
+	if err := checkOverwrite("GitRepository", &existing); err != nil {
+		return namespacedName, err
+	}
+
 	existing.Labels = gitRepository.Labels
 	existing.Spec = gitRepository.Spec
 	if err := kubeClient.Update(ctx, &existing); err != nil {
@@ -380,7 +424,7 @@ func isGitRepositoryReady(ctx context.Context, kubeClient client.Client,
 			case metav1.ConditionTrue:
 				return true, nil
 			case metav1.ConditionFalse:
-				return false, fmt.Errorf(c.Message)
+				return false, conditionError(gitRepository, c)
 			}
 		}
 		return false, nil
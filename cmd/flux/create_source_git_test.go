@@ -98,6 +98,16 @@ func TestCreateSourceGitExport(t *testing.T) {
 			command,
 			assertGoldenFile("testdata/create_source_git/export.golden"),
 		},
+		{
+			"ExportVerification",
+			"create source git podinfo --url=https://github.com/stefanprodan/podinfo --branch=master -n default --interval 1m --verify-provenance --verify-secret-ref=pgp-public-keys --export",
+			assertGoldenFile("testdata/create_source_git/export-verification.golden"),
+		},
+		{
+			"VerificationWithoutSecret",
+			"create source git podinfo --url=https://github.com/stefanprodan/podinfo --branch=master --verify-provenance --export",
+			assertError("--verify-provenance requires --verify-secret-ref"),
+		},
 	}
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -19,7 +19,6 @@ package main
 import (
 	"context"
 	"fmt"
-	"net/url"
 	"os"
 
 	"github.com/fluxcd/pkg/apis/meta"
@@ -35,7 +34,8 @@ import (
 
 	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
 
-	"github.com/fluxcd/flux2/internal/utils"
+	"github.com/fluxcd/flux2/internal/flags"
+	"github.com/fluxcd/flux2/pkg/apigen"
 	"github.com/fluxcd/flux2/pkg/manifestgen/sourcesecret"
 )
 
@@ -71,7 +71,13 @@ For private Helm repositories, the basic authentication credentials are stored i
   # Create a source for an OCI Helm repository using an existing secret with basic auth or dockerconfig credentials
   flux create source helm podinfo \
     --url=oci://ghcr.io/stefanprodan/charts/podinfo
-    --secret-ref=docker-config`,
+    --secret-ref=docker-config
+
+  # Create a source for an OCI Helm repository hosted on ECR using the node IAM role
+  flux create source helm podinfo \
+    --url=oci://012345678901.dkr.ecr.us-east-1.amazonaws.com/charts \
+    --type=oci \
+    --provider=aws`,
 	RunE: createSourceHelmCmdRun,
 }
 
@@ -84,6 +90,8 @@ type sourceHelmFlags struct {
 	caFile          string
 	secretRef       string
 	passCredentials bool
+	repoType        string
+	provider        flags.SourceOCIProvider
 }
 
 var sourceHelmArgs sourceHelmFlags
@@ -97,6 +105,11 @@ func init() {
 	createSourceHelmCmd.Flags().StringVar(&sourceHelmArgs.caFile, "ca-file", "", "TLS authentication CA file path")
 	createSourceHelmCmd.Flags().StringVarP(&sourceHelmArgs.secretRef, "secret-ref", "", "", "the name of an existing secret containing TLS, basic auth or docker-config credentials")
 	createSourceHelmCmd.Flags().BoolVarP(&sourceHelmArgs.passCredentials, "pass-credentials", "", false, "pass credentials to all domains")
+	createSourceHelmCmd.Flags().StringVar(&sourceHelmArgs.repoType, "type", "",
+		fmt.Sprintf("the Helm repository type, can be '%s' or '%s', inferred from the URL scheme when not specified",
+			sourcev1.HelmRepositoryTypeDefault, sourcev1.HelmRepositoryTypeOCI))
+	createSourceHelmCmd.Flags().Var(&sourceHelmArgs.provider, "provider",
+		sourceHelmArgs.provider.Description()+", only supported for OCI repositories")
 
 	createSourceCmd.AddCommand(createSourceHelmCmd)
 }
@@ -108,6 +121,12 @@ func createSourceHelmCmdRun(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("url is required")
 	}
 
+	provider := sourceHelmArgs.provider.String()
+	if provider != "" && provider != sourcev1.GenericOCIProvider &&
+		(sourceHelmArgs.username != "" || sourceHelmArgs.password != "" || sourceHelmArgs.certFile != "" || sourceHelmArgs.keyFile != "") {
+		return fmt.Errorf("credentials can't be combined with the %s provider", provider)
+	}
+
 	sourceLabels, err := parseLabels()
 	if err != nil {
 		return err
@@ -119,51 +138,38 @@ func createSourceHelmCmdRun(cmd *cobra.Command, args []string) error {
 	}
 	defer os.RemoveAll(tmpDir)
 
-	if _, err := url.Parse(sourceHelmArgs.url); err != nil {
-		return fmt.Errorf("url parse failed: %w", err)
-	}
-
-	helmRepository := &sourcev1.HelmRepository{
-		ObjectMeta: metav1.ObjectMeta{
+	helmRepository, err := apigen.HelmRepository(apigen.HelmRepositoryOptions{
+		ObjectOptions: apigen.ObjectOptions{
 			Name:      name,
 			Namespace: *kubeconfigArgs.Namespace,
 			Labels:    sourceLabels,
+			Interval:  createArgs.interval,
+			Suspend:   createArgs.suspend,
 		},
-		Spec: sourcev1.HelmRepositorySpec{
-			URL: sourceHelmArgs.url,
-			Interval: metav1.Duration{
-				Duration: createArgs.interval,
-			},
-		},
-	}
-
-	url, err := url.Parse(sourceHelmArgs.url)
+		URL:             sourceHelmArgs.url,
+		Type:            sourceHelmArgs.repoType,
+		Provider:        provider,
+		SecretRef:       sourceHelmArgs.secretRef,
+		PassCredentials: sourceHelmArgs.passCredentials,
+		Timeout:         createSourceArgs.fetchTimeout,
+	})
 	if err != nil {
-		return fmt.Errorf("failed to parse URL: %w", err)
-	}
-	if url.Scheme == sourcev1.HelmRepositoryTypeOCI {
-		helmRepository.Spec.Type = sourcev1.HelmRepositoryTypeOCI
-	}
-
-	if createSourceArgs.fetchTimeout > 0 {
-		helmRepository.Spec.Timeout = &metav1.Duration{Duration: createSourceArgs.fetchTimeout}
+		return err
 	}
 
-	if sourceHelmArgs.secretRef != "" {
-		helmRepository.Spec.SecretRef = &meta.LocalObjectReference{
-			Name: sourceHelmArgs.secretRef,
-		}
-		helmRepository.Spec.PassCredentials = sourceHelmArgs.passCredentials
-	}
+	lintCreated(helmRepository)
 
 	if createArgs.export {
+		if err := exportCreateNamespace(); err != nil {
+			return err
+		}
 		return printExport(exportHelmRepository(helmRepository))
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(kubeconfigArgs, kubeclientOptions)
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
 	if err != nil {
 		return err
 	}
@@ -227,6 +233,10 @@ func createSourceHelmCmdRun(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if skipSuspendedWait("HelmRepository source") {
+		return nil
+	}
+
 	logger.Waitingf("waiting for HelmRepository source reconciliation")
 	if err := wait.PollImmediate(rootArgs.pollInterval, rootArgs.timeout,
 		isHelmRepositoryReady(ctx, kubeClient, namespacedName, helmRepository)); err != nil {
@@ -253,6 +263,10 @@ func upsertHelmRepository(ctx context.Context, kubeClient client.Client,
 		Name:      helmRepository.GetName(),
 	}
 
+	if err := createNamespace(ctx, kubeClient, namespacedName.Namespace); err != nil {
+		return namespacedName, err
+	}
+
 	var existing sourcev1.HelmRepository
 	err := kubeClient.Get(ctx, namespacedName, &existing)
 	if err != nil {
@@ -267,6 +281,10 @@ func upsertHelmRepository(ctx context.Context, kubeClient client.Client,
 		return namespacedName, err
 	}
 
+	if err := checkOverwrite("HelmRepository", &existing); err != nil {
+		return namespacedName, err
+	}
+
 	existing.Labels = helmRepository.Labels
 	existing.Spec = helmRepository.Spec
 	if err := kubeClient.Update(ctx, &existing); err != nil {
@@ -297,7 +315,7 @@ func isHelmRepositoryReady(ctx context.Context, kubeClient client.Client,
 			case metav1.ConditionTrue:
 				return true, nil
 			case metav1.ConditionFalse:
-				return false, fmt.Errorf(c.Message)
+				return false, conditionError(helmRepository, c)
 			}
 		}
 		return false, nil
@@ -48,6 +48,24 @@ func TestCreateSourceHelm(t *testing.T) {
 			resultFile: "./testdata/create_source_helm/oci-with-secret.golden",
 			assertFunc: "assertGoldenTemplateFile",
 		},
+		{
+			name:       "OCI repo with provider",
+			args:       "create source helm podinfo --url=oci://012345678901.dkr.ecr.us-east-1.amazonaws.com/charts --type=oci --provider=aws --interval 5m --export",
+			resultFile: "./testdata/create_source_helm/oci-with-provider.golden",
+			assertFunc: "assertGoldenTemplateFile",
+		},
+		{
+			name:       "provider with secret ref",
+			args:       "create source helm podinfo --url=oci://012345678901.dkr.ecr.us-east-1.amazonaws.com/charts --provider=aws --secret-ref=creds --export",
+			resultFile: "secret ref can't be combined with the aws provider",
+			assertFunc: "assertError",
+		},
+		{
+			name:       "provider with HTTPS repo",
+			args:       "create source helm podinfo --url=https://stefanprodan.github.io/charts/podinfo --provider=azure --export",
+			resultFile: "provider is only supported for repositories of type oci",
+			assertFunc: "assertError",
+		},
 		{
 			name:       "HTTPS repo",
 			args:       "create source helm podinfo --url=https://stefanprodan.github.io/charts/podinfo --interval 5m --export",
@@ -19,7 +19,6 @@ package main
 import (
 	"context"
 	"fmt"
-	"strings"
 
 	"github.com/spf13/cobra"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -34,7 +33,7 @@ import (
 	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
 
 	"github.com/fluxcd/flux2/internal/flags"
-	"github.com/fluxcd/flux2/internal/utils"
+	"github.com/fluxcd/flux2/pkg/apigen"
 )
 
 var createSourceOCIRepositoryCmd = &cobra.Command{
@@ -102,68 +101,43 @@ func createSourceOCIRepositoryCmdRun(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	var ignorePaths *string
-	if len(sourceOCIRepositoryArgs.ignorePaths) > 0 {
-		ignorePathsStr := strings.Join(sourceOCIRepositoryArgs.ignorePaths, "\n")
-		ignorePaths = &ignorePathsStr
-	}
-
-	repository := &sourcev1.OCIRepository{
-		ObjectMeta: metav1.ObjectMeta{
+	repository, err := apigen.OCIRepository(apigen.OCIRepositoryOptions{
+		ObjectOptions: apigen.ObjectOptions{
 			Name:      name,
 			Namespace: *kubeconfigArgs.Namespace,
 			Labels:    sourceLabels,
+			Interval:  createArgs.interval,
+			Suspend:   createArgs.suspend,
 		},
-		Spec: sourcev1.OCIRepositorySpec{
-			Provider: sourceOCIRepositoryArgs.provider.String(),
-			URL:      sourceOCIRepositoryArgs.url,
-			Insecure: sourceOCIRepositoryArgs.insecure,
-			Interval: metav1.Duration{
-				Duration: createArgs.interval,
-			},
-			Reference: &sourcev1.OCIRepositoryRef{},
-			Ignore:    ignorePaths,
-		},
-	}
-
-	if digest := sourceOCIRepositoryArgs.digest; digest != "" {
-		repository.Spec.Reference.Digest = digest
-	}
-	if semver := sourceOCIRepositoryArgs.semver; semver != "" {
-		repository.Spec.Reference.SemVer = semver
-	}
-	if tag := sourceOCIRepositoryArgs.tag; tag != "" {
-		repository.Spec.Reference.Tag = tag
-	}
-
-	if createSourceArgs.fetchTimeout > 0 {
-		repository.Spec.Timeout = &metav1.Duration{Duration: createSourceArgs.fetchTimeout}
-	}
-
-	if saName := sourceOCIRepositoryArgs.serviceAccount; saName != "" {
-		repository.Spec.ServiceAccountName = saName
-	}
-
-	if secretName := sourceOCIRepositoryArgs.secretRef; secretName != "" {
-		repository.Spec.SecretRef = &meta.LocalObjectReference{
-			Name: secretName,
-		}
+		URL:                sourceOCIRepositoryArgs.url,
+		Tag:                sourceOCIRepositoryArgs.tag,
+		SemVer:             sourceOCIRepositoryArgs.semver,
+		Digest:             sourceOCIRepositoryArgs.digest,
+		Provider:           sourceOCIRepositoryArgs.provider.String(),
+		Insecure:           sourceOCIRepositoryArgs.insecure,
+		SecretRef:          sourceOCIRepositoryArgs.secretRef,
+		CertSecretRef:      sourceOCIRepositoryArgs.certSecretRef,
+		ServiceAccountName: sourceOCIRepositoryArgs.serviceAccount,
+		IgnorePaths:        sourceOCIRepositoryArgs.ignorePaths,
+		Timeout:            createSourceArgs.fetchTimeout,
+	})
+	if err != nil {
+		return err
 	}
 
-	if secretName := sourceOCIRepositoryArgs.certSecretRef; secretName != "" {
-		repository.Spec.CertSecretRef = &meta.LocalObjectReference{
-			Name: secretName,
-		}
-	}
+	lintCreated(repository)
 
 	if createArgs.export {
+		if err := exportCreateNamespace(); err != nil {
+			return err
+		}
 		return printExport(exportOCIRepository(repository))
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(kubeconfigArgs, kubeclientOptions)
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
 	if err != nil {
 		return err
 	}
@@ -174,6 +148,10 @@ func createSourceOCIRepositoryCmdRun(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if skipSuspendedWait("OCIRepository") {
+		return nil
+	}
+
 	logger.Waitingf("waiting for OCIRepository reconciliation")
 	if err := wait.PollImmediate(rootArgs.pollInterval, rootArgs.timeout,
 		isOCIRepositoryReady(ctx, kubeClient, namespacedName, repository)); err != nil {
@@ -195,6 +173,10 @@ func upsertOCIRepository(ctx context.Context, kubeClient client.Client,
 		Name:      ociRepository.GetName(),
 	}
 
+	if err := createNamespace(ctx, kubeClient, namespacedName.Namespace); err != nil {
+		return namespacedName, err
+	}
+
 	var existing sourcev1.OCIRepository
 	err := kubeClient.Get(ctx, namespacedName, &existing)
 	if err != nil {
@@ -209,6 +191,10 @@ func upsertOCIRepository(ctx context.Context, kubeClient client.Client,
 		return namespacedName, err
 	}
 
+	if err := checkOverwrite("OCIRepository", &existing); err != nil {
+		return namespacedName, err
+	}
+
 	existing.Labels = ociRepository.Labels
 	existing.Spec = ociRepository.Spec
 	if err := kubeClient.Update(ctx, &existing); err != nil {
@@ -239,7 +225,7 @@ func isOCIRepositoryReady(ctx context.Context, kubeClient client.Client,
 			case metav1.ConditionTrue:
 				return true, nil
 			case metav1.ConditionFalse:
-				return false, fmt.Errorf(c.Message)
+				return false, conditionError(ociRepository, c)
 			}
 		}
 		return false, nil
@@ -1,9 +1,19 @@
 package main
 
 import (
+	"context"
+	"strings"
 	"testing"
 
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/rand"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/fluxcd/flux2/internal/utils"
+	"github.com/fluxcd/flux2/pkg/manifestgen"
 )
 
 func Test_validateObjectName(t *testing.T) {
@@ -53,3 +63,172 @@ func Test_validateObjectName(t *testing.T) {
 		}
 	}
 }
+
+func Test_checkOverwrite(t *testing.T) {
+	tests := []struct {
+		name      string
+		labels    map[string]string
+		overwrite bool
+		wantErr   string
+	}{
+		{
+			name:      "not managed",
+			labels:    map[string]string{"app": "podinfo"},
+			overwrite: false,
+		},
+		{
+			name: "managed without overwrite",
+			labels: map[string]string{
+				"kustomize.toolkit.fluxcd.io/name":      "apps",
+				"kustomize.toolkit.fluxcd.io/namespace": "flux-system",
+			},
+			overwrite: false,
+			wantErr:   "GitRepository default/podinfo is managed by Kustomization flux-system/apps",
+		},
+		{
+			name: "managed with overwrite",
+			labels: map[string]string{
+				"kustomize.toolkit.fluxcd.io/name":      "apps",
+				"kustomize.toolkit.fluxcd.io/namespace": "flux-system",
+			},
+			overwrite: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func(v bool) { createArgs.overwrite = v }(createArgs.overwrite)
+			createArgs.overwrite = tt.overwrite
+
+			repo := &sourcev1.GitRepository{}
+			repo.Name = "podinfo"
+			repo.Namespace = "default"
+			repo.Labels = tt.labels
+
+			err := checkOverwrite("GitRepository", repo)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func Test_checkCrossNamespaceRef(t *testing.T) {
+	newController := func(args ...string) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "kustomize-controller",
+				Namespace: "flux-system",
+				Labels:    map[string]string{manifestgen.PartOfLabelKey: manifestgen.PartOfLabelValue},
+			},
+			Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "manager", Args: args}},
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name            string
+		controller      *appsv1.Deployment
+		sourceNamespace string
+		wantErr         bool
+	}{
+		{
+			name:            "same namespace",
+			controller:      newController("--no-cross-namespace-refs=true"),
+			sourceNamespace: "apps",
+		},
+		{
+			name:            "cross-namespace allowed",
+			controller:      newController("--watch-all-namespaces=true"),
+			sourceNamespace: "flux-system",
+		},
+		{
+			name:            "cross-namespace explicitly allowed",
+			controller:      newController("--no-cross-namespace-refs=false"),
+			sourceNamespace: "flux-system",
+		},
+		{
+			name:            "cross-namespace disabled",
+			controller:      newController("--no-cross-namespace-refs=true"),
+			sourceNamespace: "flux-system",
+			wantErr:         true,
+		},
+		{
+			name:            "cross-namespace disabled without value",
+			controller:      newController("--no-cross-namespace-refs"),
+			sourceNamespace: "flux-system",
+			wantErr:         true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kubeClient := fake.NewClientBuilder().WithScheme(utils.NewScheme()).WithObjects(tt.controller).Build()
+			err := checkCrossNamespaceRef(context.TODO(), kubeClient, "kustomize-controller", "apps", tt.sourceNamespace)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkCrossNamespaceRef() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCreateCmdExport(t *testing.T) {
+	runFakeCmdTests(t, []fakeCmdTestCase{
+		{
+			name:       "kustomization",
+			args:       "create kustomization apps --source=GitRepository/podinfo --path=./apps --prune=true --interval=10m --export -n flux-system",
+			goldenFile: "testdata/fake/create_kustomization.golden",
+		},
+		{
+			name:       "kustomization with components",
+			args:       "create kustomization apps --source=GitRepository/podinfo --path=./apps/production --components=../components/ingress,../components/hpa --interval=10m --export -n flux-system",
+			goldenFile: "testdata/fake/create_kustomization_components.golden",
+		},
+		{
+			name:       "helmrelease",
+			args:       "create helmrelease podinfo --source=HelmRepository/podinfo --chart=podinfo --chart-version=6.x --interval=10m --export -n flux-system",
+			goldenFile: "testdata/fake/create_helmrelease.golden",
+		},
+		{
+			name:       "suspended alert",
+			args:       "create alert podinfo --provider-ref=slack --event-source=Kustomization/apps --suspend --export -n flux-system",
+			goldenFile: "testdata/fake/create_alert_suspended.golden",
+		},
+		{
+			name:       "suspended git source",
+			args:       "create source git podinfo --url=https://github.com/stefanprodan/podinfo --branch=master --interval=1m --suspend --export -n flux-system",
+			goldenFile: "testdata/fake/create_source_git_suspended.golden",
+		},
+		{
+			name:       "helm source default interval",
+			args:       "create source helm podinfo --url=https://stefanprodan.github.io/podinfo --export -n flux-system",
+			goldenFile: "testdata/fake/create_source_helm_default_interval.golden",
+		},
+		{
+			name:    "sub-minute interval",
+			args:    "create source git podinfo --url=https://github.com/stefanprodan/podinfo --branch=master --interval=30s --export -n flux-system",
+			wantErr: "--interval must be at least 1m0s, got 30s",
+		},
+		{
+			name:    "suspended secret",
+			args:    "create secret tls creds --suspend --export -n flux-system",
+			wantErr: "--suspend is not supported by flux create secret tls",
+		},
+		{
+			name:    "kustomization with invalid source",
+			args:    "create kustomization apps --source=HelmRepository/podinfo --export -n flux-system",
+			wantErr: `invalid argument "HelmRepository/podinfo" for "--source" flag: source kind 'HelmRepository' is not supported, must be one of: OCIRepository, GitRepository, Bucket`,
+		},
+	})
+}
@@ -19,22 +19,35 @@ package main
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/manifoldco/promptui"
 	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
-
-	"github.com/fluxcd/flux2/internal/utils"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 var deleteCmd = &cobra.Command{
 	Use:   "delete",
 	Short: "Delete sources and resources",
-	Long:  "The delete sub-commands delete sources and resources.",
+	Long: `The delete sub-commands delete sources and resources.
+With --file, the delete command deletes the Flux objects declared in manifests, e.g. as previously exported.
+When the manifests are read from stdin, the confirmation can't be prompted and --silent is required.`,
+	Example: `  # Delete the Flux objects previously exported to a file
+  flux delete -f ./flux-system.yaml --silent
+
+  # Delete the Flux objects read from stdin, defaulting their namespace
+  flux export kustomization --all -n apps | flux delete -f - -n apps --silent`,
+	Args: cobra.NoArgs,
+	RunE: deleteCmdRun,
 }
 
 type deleteFlags struct {
 	silent bool
+	file   string
 }
 
 var deleteArgs deleteFlags
@@ -42,10 +55,107 @@ var deleteArgs deleteFlags
 func init() {
 	deleteCmd.PersistentFlags().BoolVarP(&deleteArgs.silent, "silent", "s", false,
 		"delete resource without asking for confirmation")
+	deleteCmd.Flags().StringVarP(&deleteArgs.file, "file", "f", "",
+		"delete the Flux objects declared in a manifest file, in the YAML files of a directory, or read from stdin with '-'")
 
 	rootCmd.AddCommand(deleteCmd)
 }
 
+func deleteCmdRun(cmd *cobra.Command, args []string) error {
+	if deleteArgs.file == "" {
+		return cmd.Help()
+	}
+	if deleteArgs.file == "-" && !deleteArgs.silent {
+		return fmt.Errorf("--silent is required when reading the manifests from stdin, as the deletion can't be confirmed interactively")
+	}
+
+	objects, err := readManifestObjects(deleteArgs.file, cmd.InOrStdin())
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
+	if err != nil {
+		return err
+	}
+
+	targets, err := deleteTargets(ctx, kubeClient, objects, *kubeconfigArgs.Namespace)
+	if err != nil {
+		return err
+	}
+	if len(targets) == 0 {
+		logger.Failuref("no Flux objects to delete found in the cluster")
+		return nil
+	}
+
+	if !deleteArgs.silent {
+		prompt := promptui.Prompt{
+			Label:     fmt.Sprintf("Are you sure you want to delete these %d objects", len(targets)),
+			IsConfirm: true,
+		}
+		if _, err := prompt.Run(); err != nil {
+			return fmt.Errorf("aborting")
+		}
+	}
+
+	var failed int
+	for _, obj := range targets {
+		logger.Actionf("deleting %s %s in %s namespace", obj.GetKind(), obj.GetName(), obj.GetNamespace())
+		if err := kubeClient.Delete(ctx, obj); err != nil && !apierrors.IsNotFound(err) {
+			logger.Failuref("%s/%s/%s: %s", obj.GetKind(), obj.GetNamespace(), obj.GetName(), err)
+			failed++
+			continue
+		}
+		logger.Successf("%s deleted", obj.GetKind())
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to delete %d of %d objects", failed, len(targets))
+	}
+	return nil
+}
+
+// deleteTargets returns the Flux objects of the manifests as found in the
+// cluster. The namespace of the objects defaults to the given one, the
+// objects that are not Flux objects or not in the cluster are skipped.
+func deleteTargets(ctx context.Context, kubeClient client.Client, objects []*unstructured.Unstructured,
+	namespace string) ([]*unstructured.Unstructured, error) {
+	var targets []*unstructured.Unstructured
+	for _, obj := range objects {
+		if !strings.HasSuffix(obj.GroupVersionKind().Group, ".toolkit.fluxcd.io") {
+			logger.Warningf("skipping %s %s, not a Flux object", obj.GetKind(), obj.GetName())
+			continue
+		}
+		if obj.GetNamespace() == "" {
+			obj.SetNamespace(namespace)
+		}
+
+		live := &unstructured.Unstructured{}
+		live.SetGroupVersionKind(obj.GroupVersionKind())
+		if err := kubeClient.Get(ctx, client.ObjectKeyFromObject(obj), live); err != nil {
+			if apierrors.IsNotFound(err) {
+				logger.Warningf("skipping %s %s in %s namespace, not found", obj.GetKind(), obj.GetName(), obj.GetNamespace())
+				continue
+			}
+			return nil, err
+		}
+		warnManagedDeletion(obj.GetKind(), live)
+		targets = append(targets, live)
+	}
+	return targets, nil
+}
+
+// warnManagedDeletion warns that the object is managed by a Kustomization
+// or owned by a controller, which will recreate it after its deletion.
+func warnManagedDeletion(kind string, obj metav1.Object) {
+	if manager := managedBy(obj); manager != "<none>" {
+		logger.Warningf("%s %s is managed by %s and may be recreated, remove it from its source to delete it permanently",
+			kind, obj.GetName(), manager)
+	}
+}
+
 type deleteCommand struct {
 	apiType
 	object adapter // for getting the value, and later deleting it
@@ -60,7 +170,7 @@ func (del deleteCommand) run(cmd *cobra.Command, args []string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(kubeconfigArgs, kubeclientOptions)
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
 	if err != nil {
 		return err
 	}
@@ -74,6 +184,7 @@ func (del deleteCommand) run(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	warnManagedDeletion(del.humanKind, del.object.asClientObject())
 
 	if !deleteArgs.silent {
 		prompt := promptui.Prompt{
@@ -17,7 +17,11 @@ limitations under the License.
 package main
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
 
 	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
 )
@@ -28,14 +32,50 @@ var deleteKsCmd = &cobra.Command{
 	Short:   "Delete a Kustomization resource",
 	Long:    "The delete kustomization command deletes the given Kustomization from the cluster.",
 	Example: `  # Delete a kustomization and the Kubernetes resources created by it when prune is enabled
-  flux delete kustomization podinfo`,
+  flux delete kustomization podinfo
+
+  # Print the objects garbage collected with the Kustomization before confirming its deletion
+  flux delete kustomization podinfo --prune-preview`,
 	ValidArgsFunction: resourceNamesCompletionFunc(kustomizev1.GroupVersion.WithKind(kustomizev1.KustomizationKind)),
-	RunE: deleteCommand{
-		apiType: kustomizationType,
-		object:  universalAdapter{&kustomizev1.Kustomization{}},
-	}.run,
+	RunE:              deleteKsCmdRun,
+}
+
+type deleteKsFlags struct {
+	prunePreview bool
+}
+
+var deleteKsArgs deleteKsFlags
+
+var deleteKsCommand = deleteCommand{
+	apiType: kustomizationType,
+	object:  universalAdapter{&kustomizev1.Kustomization{}},
 }
 
 func init() {
+	deleteKsCmd.Flags().BoolVar(&deleteKsArgs.prunePreview, "prune-preview", false,
+		"print the inventory objects garbage collected with the Kustomization when prune is enabled")
 	deleteCmd.AddCommand(deleteKsCmd)
 }
+
+func deleteKsCmdRun(cmd *cobra.Command, args []string) error {
+	if !deleteKsArgs.prunePreview || len(args) < 1 {
+		return deleteKsCommand.run(cmd, args)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
+	if err != nil {
+		return err
+	}
+
+	var ks kustomizev1.Kustomization
+	if err := kubeClient.Get(ctx, types.NamespacedName{Namespace: *kubeconfigArgs.Namespace, Name: args[0]}, &ks); err != nil {
+		return fmt.Errorf("failed to get %s: %w", kustomizationType.humanKind, err)
+	}
+	if err := printPruneTree(ctx, cmd.OutOrStdout(), kubeClient, []kustomizev1.Kustomization{ks}); err != nil {
+		return err
+	}
+	return deleteKsCommand.run(cmd, args)
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+)
+
+func TestDeleteFromFile(t *testing.T) {
+	isolateEnv(t)
+	kubeClient := useFakeCluster(t, readObjectFile(t, "testdata/fake/objects.yaml")...)
+
+	cmd := cmdTestCase{
+		args:   "delete -f testdata/fake/delete.yaml -n flux-system --silent",
+		assert: assertGoldenFile("testdata/fake/delete_file.golden"),
+	}
+	cmd.runTestCmd(t)
+
+	for name, obj := range map[string]client.Object{
+		"apps":    &kustomizev1.Kustomization{},
+		"podinfo": &helmv2.HelmRelease{},
+	} {
+		err := kubeClient.Get(context.TODO(), client.ObjectKey{Namespace: "flux-system", Name: name}, obj)
+		if !apierrors.IsNotFound(err) {
+			t.Errorf("expected %s to be deleted, got %v", name, err)
+		}
+	}
+	if err := kubeClient.Get(context.TODO(), client.ObjectKey{Namespace: "flux-system", Name: "infra"}, &kustomizev1.Kustomization{}); err != nil {
+		t.Errorf("expected infra to be kept, got %v", err)
+	}
+}
+
+func TestDeleteFromStdinRequiresSilent(t *testing.T) {
+	cmd := cmdTestCase{
+		args:   "delete -f - -n flux-system",
+		assert: assertError("--silent is required when reading the manifests from stdin, as the deletion can't be confirmed interactively"),
+	}
+	cmd.runTestCmd(t)
+}
+
+func TestDeleteKustomizationPrunePreview(t *testing.T) {
+	isolateEnv(t)
+	kubeClient := useFakeCluster(t, readObjectFile(t, "testdata/fake/prune_preview.yaml")...)
+
+	cmd := cmdTestCase{
+		args:   "delete kustomization apps -n flux-system --prune-preview --silent",
+		assert: assertGoldenFile("testdata/fake/delete_prune_preview.golden"),
+	}
+	cmd.runTestCmd(t)
+
+	err := kubeClient.Get(context.TODO(), client.ObjectKey{Namespace: "flux-system", Name: "apps"}, &kustomizev1.Kustomization{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("expected apps to be deleted, got %v", err)
+	}
+}
@@ -0,0 +1,308 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/gonvenience/ytbx"
+	"github.com/homeport/dyff/pkg/dyff"
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+
+	"github.com/fluxcd/flux2/pkg/apigen"
+	"github.com/fluxcd/flux2/pkg/printers"
+)
+
+var diffHelmReleaseCmd = &cobra.Command{
+	Use:     "helmrelease [name]",
+	Aliases: []string{"hr"},
+	Short:   "Diff HelmRelease",
+	Long: `The diff helmrelease command compares a local HelmRelease, or the changes given with flags,
+with the HelmRelease in the cluster and prints the differences in labels, chart and values.
+With --render, the chart is also templated locally with the helm binary for the values of the cluster
+and the new values, and the differences in the rendered manifests are printed.
+Exit status: 0 No differences were found. 1 Differences were found. >1 diff failed with an error.`,
+	Example: `  # Preview the changes of a local HelmRelease file
+  flux diff helmrelease podinfo --file ./apps/podinfo/release.yaml
+
+  # Preview the changes of a HelmRelease read from stdin
+  cat ./apps/podinfo/release.yaml | flux diff hr podinfo --file -
+
+  # Preview a chart upgrade with new values
+  flux diff helmrelease podinfo --chart-version=6.3.x --values=./values.yaml
+
+  # Preview the changes of new values in the manifests rendered by the chart
+  flux diff helmrelease podinfo --values=./values.yaml --render`,
+	ValidArgsFunction: resourceNamesCompletionFunc(helmv2.GroupVersion.WithKind(helmv2.HelmReleaseKind)),
+	RunE:              diffHelmReleaseCmdRun,
+}
+
+type diffHelmReleaseFlags struct {
+	file         string
+	chartVersion string
+	valuesFiles  []string
+	render       bool
+}
+
+var diffHelmReleaseArgs diffHelmReleaseFlags
+
+func init() {
+	diffHelmReleaseCmd.Flags().StringVarP(&diffHelmReleaseArgs.file, "file", "f", "", "path to the local HelmRelease YAML file, or '-' to read it from stdin")
+	diffHelmReleaseCmd.Flags().StringVar(&diffHelmReleaseArgs.chartVersion, "chart-version", "", "the chart version to compare with the one of the cluster, accepts a semver range")
+	diffHelmReleaseCmd.Flags().StringSliceVar(&diffHelmReleaseArgs.valuesFiles, "values", nil, "local path to values.yaml files to compare with the values of the cluster, also accepts comma-separated values")
+	diffHelmReleaseCmd.Flags().BoolVar(&diffHelmReleaseArgs.render, "render", false,
+		"render the chart locally with 'helm template' and print the differences in the manifests, requires the helm binary and a chart from a HelmRepository")
+	diffCmd.AddCommand(diffHelmReleaseCmd)
+}
+
+func diffHelmReleaseCmdRun(cmd *cobra.Command, args []string) error {
+	if diffHelmReleaseArgs.file == "" && diffHelmReleaseArgs.chartVersion == "" && len(diffHelmReleaseArgs.valuesFiles) == 0 {
+		return &RequestError{StatusCode: 2, Err: fmt.Errorf("one of --file, --chart-version or --values is required")}
+	}
+
+	var local *helmv2.HelmRelease
+	if diffHelmReleaseArgs.file != "" {
+		hr, err := readHelmReleaseFile(diffHelmReleaseArgs.file, cmd.InOrStdin())
+		if err != nil {
+			return &RequestError{StatusCode: 2, Err: err}
+		}
+		local = hr
+	}
+
+	name := ""
+	if len(args) > 0 {
+		name = args[0]
+	}
+	namespace := *kubeconfigArgs.Namespace
+	if local != nil {
+		if name != "" && local.Name != name {
+			return &RequestError{StatusCode: 2, Err: fmt.Errorf("the HelmRelease in %s is named '%s' instead of '%s'",
+				diffHelmReleaseArgs.file, local.Name, name)}
+		}
+		name = local.Name
+		if local.Namespace != "" {
+			namespace = local.Namespace
+		}
+	}
+	if name == "" {
+		return &RequestError{StatusCode: 2, Err: fmt.Errorf("%s name is required", helmReleaseType.humanKind)}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
+	if err != nil {
+		return &RequestError{StatusCode: 2, Err: err}
+	}
+
+	live := &helmv2.HelmRelease{}
+	liveFound := true
+	err = kubeClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, live)
+	if err != nil {
+		if !apierrors.IsNotFound(err) || local == nil {
+			return &RequestError{StatusCode: 2, Err: err}
+		}
+		logger.Warningf("HelmRelease %s not found in %s namespace, all of its spec is new", name, namespace)
+		liveFound = false
+	}
+
+	updated := local
+	if updated == nil {
+		updated = live.DeepCopy()
+	}
+	if diffHelmReleaseArgs.chartVersion != "" {
+		updated.Spec.Chart.Spec.Version = diffHelmReleaseArgs.chartVersion
+	}
+	if len(diffHelmReleaseArgs.valuesFiles) > 0 {
+		values, err := apigen.MergeValuesFiles(diffHelmReleaseArgs.valuesFiles)
+		if err != nil {
+			return &RequestError{StatusCode: 2, Err: err}
+		}
+		updated.Spec.Values = values
+	}
+
+	changed, err := diffHelmRelease(live, updated, cmd.OutOrStdout())
+	if err != nil {
+		return &RequestError{StatusCode: 2, Err: err}
+	}
+
+	if diffHelmReleaseArgs.render {
+		var from ytbx.InputFile
+		if liveFound {
+			from, err = renderHelmRelease(ctx, kubeClient, "live", live)
+		} else {
+			from = ytbx.InputFile{Location: "live"}
+		}
+		if err != nil {
+			return &RequestError{StatusCode: 2, Err: err}
+		}
+		to, err := renderHelmRelease(ctx, kubeClient, "updated", updated)
+		if err != nil {
+			return &RequestError{StatusCode: 2, Err: err}
+		}
+		logger.Actionf("comparing the manifests rendered by the chart")
+		rendered, err := diffRenderedManifests(from, to, cmd.OutOrStdout())
+		if err != nil {
+			return &RequestError{StatusCode: 2, Err: err}
+		}
+		changed = changed || rendered
+	}
+
+	if changed {
+		return &RequestError{StatusCode: 1, Err: fmt.Errorf("identified at least one change, exiting with non-zero exit code")}
+	}
+	return nil
+}
+
+// readHelmReleaseFile reads a single HelmRelease from the file at path,
+// or from stdin when path is '-'.
+func readHelmReleaseFile(path string, stdin io.Reader) (*helmv2.HelmRelease, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if path == "-" {
+		data, err = io.ReadAll(stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	hr := &helmv2.HelmRelease{}
+	if err := yaml.Unmarshal(data, hr); err != nil {
+		return nil, fmt.Errorf("failed to decode HelmRelease from %s: %w", path, err)
+	}
+	if hr.Kind != helmv2.HelmReleaseKind {
+		return nil, fmt.Errorf("%s does not contain a HelmRelease", path)
+	}
+	return hr, nil
+}
+
+// renderHelmRelease templates the chart of the HelmRelease with its values
+// using the helm binary, and returns the rendered manifests. Only charts from
+// a HelmRepository without credentials are supported, the values referenced
+// in spec.valuesFrom are not included.
+func renderHelmRelease(ctx context.Context, kubeClient client.Client, location string, hr *helmv2.HelmRelease) (ytbx.InputFile, error) {
+	helmBin, err := exec.LookPath("helm")
+	if err != nil {
+		return ytbx.InputFile{}, fmt.Errorf("--render requires the helm binary: %w", err)
+	}
+
+	chart := hr.Spec.Chart.Spec
+	if chart.SourceRef.Kind != sourcev1.HelmRepositoryKind {
+		return ytbx.InputFile{}, fmt.Errorf("--render only supports charts from a %s, the %s HelmRelease uses a %s",
+			sourcev1.HelmRepositoryKind, location, chart.SourceRef.Kind)
+	}
+	if len(hr.Spec.ValuesFrom) > 0 {
+		logger.Warningf("the values of spec.valuesFrom are not included in the %s rendered manifests", location)
+	}
+
+	repoNamespace := chart.SourceRef.Namespace
+	if repoNamespace == "" {
+		repoNamespace = hr.Namespace
+	}
+	var repo sourcev1.HelmRepository
+	if err := kubeClient.Get(ctx, types.NamespacedName{Namespace: repoNamespace, Name: chart.SourceRef.Name}, &repo); err != nil {
+		return ytbx.InputFile{}, fmt.Errorf("failed to get HelmRepository %s/%s: %w", repoNamespace, chart.SourceRef.Name, err)
+	}
+	if repo.Spec.SecretRef != nil {
+		return ytbx.InputFile{}, fmt.Errorf("--render does not support the HelmRepository %s/%s with credentials", repoNamespace, repo.Name)
+	}
+
+	values, err := yaml.Marshal(hr.GetValues())
+	if err != nil {
+		return ytbx.InputFile{}, fmt.Errorf("failed to marshal the %s values: %w", location, err)
+	}
+	valuesFile, err := os.CreateTemp("", "flux-diff-values-*.yaml")
+	if err != nil {
+		return ytbx.InputFile{}, err
+	}
+	defer os.Remove(valuesFile.Name())
+	if _, err := valuesFile.Write(values); err != nil {
+		valuesFile.Close()
+		return ytbx.InputFile{}, err
+	}
+	if err := valuesFile.Close(); err != nil {
+		return ytbx.InputFile{}, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	c := exec.CommandContext(ctx, helmBin, helmTemplateArgs(hr, &repo, valuesFile.Name())...)
+	c.Stdout = &stdout
+	c.Stderr = &stderr
+	if err := c.Run(); err != nil {
+		return ytbx.InputFile{}, fmt.Errorf("failed to render the %s chart: %w: %s", location, err, strings.TrimSpace(stderr.String()))
+	}
+
+	docs, err := ytbx.LoadDocuments(stdout.Bytes())
+	if err != nil {
+		return ytbx.InputFile{}, fmt.Errorf("failed to load the %s rendered manifests: %w", location, err)
+	}
+	return ytbx.InputFile{Location: location, Documents: docs}, nil
+}
+
+// helmTemplateArgs returns the arguments of 'helm template' for the chart
+// of the HelmRelease from the given HelmRepository.
+func helmTemplateArgs(hr *helmv2.HelmRelease, repo *sourcev1.HelmRepository, valuesFile string) []string {
+	chart := hr.Spec.Chart.Spec
+	args := []string{"template", hr.GetReleaseName()}
+	if repo.Spec.Type == sourcev1.HelmRepositoryTypeOCI {
+		args = append(args, strings.TrimSuffix(repo.Spec.URL, "/")+"/"+chart.Chart)
+	} else {
+		args = append(args, chart.Chart, "--repo", repo.Spec.URL)
+	}
+	if chart.Version != "" {
+		args = append(args, "--version", chart.Version)
+	}
+	return append(args, "--namespace", hr.GetReleaseNamespace(), "--values", valuesFile)
+}
+
+// diffRenderedManifests writes a report of the differences between the
+// rendered manifests to w, and returns true if any were found.
+func diffRenderedManifests(from, to ytbx.InputFile, w io.Writer) (bool, error) {
+	report, err := dyff.CompareInputFiles(from, to,
+		dyff.IgnoreOrderChanges(false),
+		dyff.KubernetesEntityDetection(true),
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to compare the rendered manifests: %w", err)
+	}
+	if len(report.Diffs) == 0 {
+		return false, nil
+	}
+
+	if err := printers.NewDyffPrinter().Print(w, report); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+)
+
+func TestDiffHelmReleaseCmd(t *testing.T) {
+	runFakeCmdTests(t, []fakeCmdTestCase{
+		{
+			name:       "local file",
+			args:       "diff helmrelease podinfo --file testdata/fake/podinfo-helmrelease.yaml",
+			objectFile: "testdata/fake/objects.yaml",
+			goldenFile: "testdata/fake/diff_helmrelease_file.golden",
+		},
+		{
+			name:       "values and chart version",
+			args:       "diff helmrelease podinfo -n flux-system --chart-version=6.3.x --values=testdata/fake/podinfo-values.yaml",
+			objectFile: "testdata/fake/objects.yaml",
+			goldenFile: "testdata/fake/diff_helmrelease_flags.golden",
+		},
+		{
+			name:       "no changes",
+			args:       "diff helmrelease podinfo -n flux-system --chart-version=6.2.x",
+			objectFile: "testdata/fake/objects.yaml",
+			goldenFile: "testdata/fake/diff_helmrelease_none.golden",
+		},
+		{
+			name:       "name mismatch",
+			args:       "diff helmrelease redis --file testdata/fake/podinfo-helmrelease.yaml",
+			objectFile: "testdata/fake/objects.yaml",
+			wantErr:    "the HelmRelease in testdata/fake/podinfo-helmrelease.yaml is named 'podinfo' instead of 'redis'",
+		},
+		{
+			name:    "missing changes",
+			args:    "diff helmrelease podinfo",
+			wantErr: "one of --file, --chart-version or --values is required",
+		},
+	})
+}
+
+// fakeHelm renders a ConfigMap holding the values given to 'helm template'.
+const fakeHelm = `#!/bin/sh
+while [ $# -gt 0 ]; do
+  case "$1" in
+    --values) values="$2"; shift ;;
+    --namespace) namespace="$2"; shift ;;
+  esac
+  shift
+done
+printf -- '---\n# Source: podinfo/templates/configmap.yaml\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: podinfo\n  namespace: %s\ndata:\n' "$namespace"
+sed 's/^/  /' "$values"
+`
+
+func TestDiffHelmReleaseRenderCmd(t *testing.T) {
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "helm"), []byte(fakeHelm), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	runFakeCmdTests(t, []fakeCmdTestCase{
+		{
+			name:       "rendered values",
+			args:       "diff helmrelease podinfo -n flux-system --values=testdata/fake/podinfo-values.yaml --render",
+			objectFile: "testdata/fake/helmrelease-render-objects.yaml",
+			goldenFile: "testdata/fake/diff_helmrelease_render.golden",
+		},
+		{
+			name:       "chart from a GitRepository",
+			args:       "diff helmrelease git-chart -n flux-system --values=testdata/fake/podinfo-values.yaml --render",
+			objectFile: "testdata/fake/helmrelease-render-objects.yaml",
+			wantErr:    "--render only supports charts from a HelmRepository, the live HelmRelease uses a GitRepository",
+		},
+	})
+}
+
+func TestHelmTemplateArgs(t *testing.T) {
+	hr := &helmv2.HelmRelease{
+		ObjectMeta: metav1.ObjectMeta{Name: "podinfo", Namespace: "flux-system"},
+		Spec: helmv2.HelmReleaseSpec{
+			TargetNamespace: "apps",
+			Chart: helmv2.HelmChartTemplate{
+				Spec: helmv2.HelmChartTemplateSpec{Chart: "podinfo", Version: "6.3.x"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name string
+		repo sourcev1.HelmRepositorySpec
+		want []string
+	}{
+		{
+			name: "helm repository",
+			repo: sourcev1.HelmRepositorySpec{URL: "https://stefanprodan.github.io/podinfo"},
+			want: []string{"template", "apps-podinfo", "podinfo", "--repo", "https://stefanprodan.github.io/podinfo",
+				"--version", "6.3.x", "--namespace", "apps", "--values", "values.yaml"},
+		},
+		{
+			name: "oci repository",
+			repo: sourcev1.HelmRepositorySpec{URL: "oci://ghcr.io/stefanprodan/charts/", Type: sourcev1.HelmRepositoryTypeOCI},
+			want: []string{"template", "apps-podinfo", "oci://ghcr.io/stefanprodan/charts/podinfo",
+				"--version", "6.3.x", "--namespace", "apps", "--values", "values.yaml"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			repo := &sourcev1.HelmRepository{Spec: tt.repo}
+			g.Expect(helmTemplateArgs(hr, repo, "values.yaml")).To(Equal(tt.want))
+		})
+	}
+}
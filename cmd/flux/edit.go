@@ -0,0 +1,260 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apiextensions-apiserver/pkg/apiserver/validation"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/kubectl/pkg/cmd/util/editor"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/fluxcd/pkg/ssa"
+)
+
+var editCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Edit resources",
+	Long: `The edit sub-commands change the spec of Flux resources in place.
+When no changes are given with flags, the object is opened in the editor defined by the
+KUBE_EDITOR or EDITOR environment variables, validated against the CRD schema on save,
+and applied on the cluster with server-side apply.`,
+}
+
+type editFlags struct {
+	force bool
+}
+
+var editArgs editFlags
+
+func init() {
+	editCmd.PersistentFlags().BoolVar(&editArgs.force, "force", false,
+		"take the ownership of the fields managed by other field managers when applying the changes")
+	rootCmd.AddCommand(editCmd)
+}
+
+// editHeader is prepended to the object opened in the editor, lines
+// starting with '#' at the top of the file are ignored.
+const editHeader = `# Please edit the object below. Lines beginning with a '#' will be ignored,
+# and an empty file will abort the edit. If an error occurs while saving this file will be
+# reopened with the relevant failures.
+#
+`
+
+// editInteractively opens the object in the user's editor until the
+// changes are valid or the edit is aborted, then applies the edited object.
+// The timeout applies to the API calls only, not to the time spent in the
+// editor.
+func editInteractively(kubeClient client.Client, t apiType, name types.NamespacedName) error {
+	gvk := t.groupVersion.WithKind(t.kind)
+	original := &unstructured.Unstructured{}
+	original.SetGroupVersionKind(gvk)
+	getCtx, getCancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	defer getCancel()
+	if err := kubeClient.Get(getCtx, name, original); err != nil {
+		return err
+	}
+
+	if ksName, ksNamespace, ok := kustomizationManager(original); ok {
+		logger.Warningf("%s %s is managed by Kustomization %s/%s, the changes will be reverted on the next reconciliation unless they are committed to Git",
+			t.kind, name.Name, ksNamespace, ksName)
+	}
+
+	// Fields which can't be set with server-side apply
+	original.SetManagedFields(nil)
+	unstructured.RemoveNestedField(original.Object, "status")
+
+	data, err := yaml.Marshal(original.Object)
+	if err != nil {
+		return err
+	}
+
+	crdValidation, err := embeddedCRDValidation(embeddedManifests, gvk)
+	if err != nil {
+		return err
+	}
+	if crdValidation == nil {
+		logger.Warningf("no embedded CRD found for %s, the changes are validated by the cluster only", gvk.String())
+	}
+
+	e := editor.NewDefaultEditor([]string{"KUBE_EDITOR", "EDITOR"})
+	buf := []byte(editHeader + string(data))
+	var edited *unstructured.Unstructured
+	for {
+		result, file, err := e.LaunchTempFile("flux-edit-", ".yaml", bytes.NewReader(buf))
+		os.Remove(file)
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(result, buf) {
+			logger.Successf("edit cancelled, no changes made")
+			return nil
+		}
+		content := stripEditHeader(result)
+		if len(bytes.TrimSpace(content)) == 0 {
+			logger.Successf("edit cancelled, saved file was empty")
+			return nil
+		}
+
+		edited, err = parseEditedObject(original, content, crdValidation)
+		if err == nil {
+			break
+		}
+		buf = []byte(editHeader + commentLines(err.Error()) + "#\n" + string(content))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	defer cancel()
+
+	opts := []client.PatchOption{client.FieldOwner("flux")}
+	if editArgs.force {
+		opts = append(opts, client.ForceOwnership)
+	}
+	logger.Actionf("applying %s %s in %s namespace", t.kind, name.Name, name.Namespace)
+	if err := kubeClient.Patch(ctx, edited, client.Apply, opts...); err != nil {
+		if apierrors.IsConflict(err) {
+			return fmt.Errorf("failed to apply the changes, the object was modified on the cluster or the fields are managed by other field managers, "+
+				"retry the edit or use --force: %w", err)
+		}
+		return err
+	}
+	recordAuditEvent(ctx, kubeClient, gvk, edited, "Edited", fmt.Sprintf("%s edited", t.kind))
+	logger.Successf("%s edited", t.kind)
+	return nil
+}
+
+// parseEditedObject decodes the edited content and validates it against the
+// original object and the CRD schema, when given.
+func parseEditedObject(original *unstructured.Unstructured, content []byte,
+	crdValidation *apiextensionsv1.CustomResourceValidation) (*unstructured.Unstructured, error) {
+	edited := &unstructured.Unstructured{}
+	if err := yaml.Unmarshal(content, &edited.Object); err != nil {
+		return nil, fmt.Errorf("invalid YAML: %w", err)
+	}
+
+	if edited.GroupVersionKind() != original.GroupVersionKind() {
+		return nil, fmt.Errorf("the apiVersion and kind can't be changed")
+	}
+	if edited.GetName() != original.GetName() || edited.GetNamespace() != original.GetNamespace() {
+		return nil, fmt.Errorf("the name and namespace can't be changed")
+	}
+
+	if crdValidation != nil {
+		var internal apiextensions.CustomResourceValidation
+		if err := apiextensionsv1.Convert_v1_CustomResourceValidation_To_apiextensions_CustomResourceValidation(crdValidation, &internal, nil); err != nil {
+			return nil, err
+		}
+		validator, _, err := validation.NewSchemaValidator(&internal)
+		if err != nil {
+			return nil, err
+		}
+		if errs := validation.ValidateCustomResource(nil, edited.UnstructuredContent(), validator); len(errs) > 0 {
+			return nil, errs.ToAggregate()
+		}
+	}
+	return edited, nil
+}
+
+// embeddedCRDValidation returns the schema of the given kind and version from
+// the CRDs found in the manifests, or nil if the CRD is not found.
+func embeddedCRDValidation(manifests fs.FS, gvk schema.GroupVersionKind) (*apiextensionsv1.CustomResourceValidation, error) {
+	files, err := fs.ReadDir(manifests, "manifests")
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		data, err := fs.ReadFile(manifests, path.Join("manifests", file.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("reading file failed: %w", err)
+		}
+		objects, err := ssa.ReadObjects(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file.Name(), err)
+		}
+		for _, obj := range objects {
+			if obj.GetKind() != "CustomResourceDefinition" {
+				continue
+			}
+			var crd apiextensionsv1.CustomResourceDefinition
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &crd); err != nil {
+				return nil, err
+			}
+			if crd.Spec.Group != gvk.Group || crd.Spec.Names.Kind != gvk.Kind {
+				continue
+			}
+			for _, version := range crd.Spec.Versions {
+				if version.Name == gvk.Version {
+					return version.Schema, nil
+				}
+			}
+		}
+	}
+	return nil, nil
+}
+
+// stripEditHeader removes the comment lines at the top of the edited file.
+func stripEditHeader(data []byte) []byte {
+	lines := strings.SplitAfter(string(data), "\n")
+	for i, line := range lines {
+		if !strings.HasPrefix(line, "#") {
+			return []byte(strings.Join(lines[i:], ""))
+		}
+	}
+	return nil
+}
+
+func commentLines(s string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(strings.TrimSpace(s), "\n") {
+		b.WriteString("# " + line + "\n")
+	}
+	return b.String()
+}
+
+// editObjectCmdRun returns the run function of an edit sub-command which
+// opens the named object of the given type in the editor.
+func editObjectCmdRun(t apiType) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return fmt.Errorf("%s name is required", t.humanKind)
+		}
+
+		kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
+		if err != nil {
+			return err
+		}
+		return editInteractively(kubeClient, t, types.NamespacedName{
+			Namespace: *kubeconfigArgs.Namespace,
+			Name:      args[0],
+		})
+	}
+}
@@ -0,0 +1,37 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
+	"github.com/spf13/cobra"
+)
+
+var editHrCmd = &cobra.Command{
+	Use:     "helmrelease [name]",
+	Aliases: []string{"hr"},
+	Short:   "Edit a HelmRelease in the editor",
+	Long:    `The edit helmrelease command opens a HelmRelease in the editor and applies the changes on save.`,
+	Example: `  # Edit a HelmRelease with vim
+  KUBE_EDITOR=vim flux edit hr podinfo -n default`,
+	ValidArgsFunction: resourceNamesCompletionFunc(helmv2.GroupVersion.WithKind(helmv2.HelmReleaseKind)),
+	RunE:              editObjectCmdRun(helmReleaseType),
+}
+
+func init() {
+	editCmd.AddCommand(editHrCmd)
+}
@@ -0,0 +1,270 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/fluxcd/flux2/internal/flags"
+)
+
+var editKsCmd = &cobra.Command{
+	Use:     "kustomization [name]",
+	Aliases: []string{"ks"},
+	Short:   "Edit the spec of a Kustomization",
+	Long: `The edit kustomization command changes the spec fields of a Kustomization with a JSON patch.
+The fields that can be edited are: ` + strings.Join(editFieldNames(editKustomizationFields), ", ") + `.
+Without --set and --unset, the Kustomization is opened in the editor.`,
+	Example: `  # Open a Kustomization in the editor
+  flux edit kustomization podinfo
+
+  # Change the interval and the path of a Kustomization
+  flux edit kustomization podinfo --set interval=5m --set path=./deploy/production
+
+  # Point a Kustomization to another source
+  flux edit kustomization podinfo --set sourceRef=GitRepository/podinfo.flux-system
+
+  # Disable garbage collection and remove the target namespace
+  flux edit kustomization podinfo --set prune=false --unset targetNamespace`,
+	ValidArgsFunction: resourceNamesCompletionFunc(kustomizev1.GroupVersion.WithKind(kustomizev1.KustomizationKind)),
+	RunE:              editKsCmdRun,
+}
+
+type editKsFlags struct {
+	set   []string
+	unset []string
+}
+
+var editKsArgs editKsFlags
+
+func init() {
+	editKsCmd.Flags().StringArrayVar(&editKsArgs.set, "set", nil,
+		"set a spec field in the format '<field>=<value>', can be specified multiple times")
+	editKsCmd.Flags().StringArrayVar(&editKsArgs.unset, "unset", nil,
+		"remove an optional spec field, can be specified multiple times")
+
+	editCmd.AddCommand(editKsCmd)
+}
+
+// editField describes a spec field that can be edited with --set and --unset.
+type editField struct {
+	// path is the JSON pointer of the field.
+	path string
+	// parse converts the flag value to the JSON value of the field.
+	parse func(value string) (interface{}, error)
+	// optional fields can be removed with --unset.
+	optional bool
+}
+
+var editKustomizationFields = map[string]editField{
+	"interval":           {path: "/spec/interval", parse: parseEditDuration},
+	"timeout":            {path: "/spec/timeout", parse: parseEditDuration, optional: true},
+	"path":               {path: "/spec/path", parse: parseEditPath, optional: true},
+	"prune":              {path: "/spec/prune", parse: parseEditBool},
+	"suspend":            {path: "/spec/suspend", parse: parseEditBool, optional: true},
+	"sourceRef":          {path: "/spec/sourceRef", parse: parseEditKustomizationSource},
+	"targetNamespace":    {path: "/spec/targetNamespace", parse: parseEditString, optional: true},
+	"serviceAccountName": {path: "/spec/serviceAccountName", parse: parseEditString, optional: true},
+}
+
+func editKsCmdRun(cmd *cobra.Command, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("Kustomization name is required")
+	}
+	name := args[0]
+
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
+	if err != nil {
+		return err
+	}
+
+	namespacedName := types.NamespacedName{
+		Namespace: *kubeconfigArgs.Namespace,
+		Name:      name,
+	}
+	if len(editKsArgs.set) == 0 && len(editKsArgs.unset) == 0 {
+		return editInteractively(kubeClient, kustomizationType, namespacedName)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	defer cancel()
+
+	var kustomization kustomizev1.Kustomization
+	if err := kubeClient.Get(ctx, namespacedName, &kustomization); err != nil {
+		return err
+	}
+
+	patch, err := buildEditPatch(&kustomization, editKustomizationFields, editKsArgs.set, editKsArgs.unset)
+	if err != nil {
+		return err
+	}
+
+	logger.Actionf("editing Kustomization %s in %s namespace", name, *kubeconfigArgs.Namespace)
+	if err := kubeClient.Patch(ctx, &kustomization, client.RawPatch(types.JSONPatchType, patch)); err != nil {
+		return fmt.Errorf("failed to patch Kustomization: %w", err)
+	}
+	recordAuditEvent(ctx, kubeClient, kustomizationType.groupVersion.WithKind(kustomizationType.kind), &kustomization,
+		"Edited", fmt.Sprintf("%s edited", kustomizationType.kind))
+	logger.Successf("Kustomization edited")
+	return nil
+}
+
+type jsonPatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// buildEditPatch returns the JSON patch for the given '<field>=<value>' pairs
+// to set and the fields to remove. The patch tests the resource version of
+// the object, so that concurrent changes are not overwritten.
+func buildEditPatch(obj client.Object, fields map[string]editField, set, unset []string) ([]byte, error) {
+	ops := []jsonPatchOperation{
+		{Op: "test", Path: "/metadata/resourceVersion", Value: obj.GetResourceVersion()},
+	}
+
+	for _, pair := range set {
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid --set '%s', must be in the format '<field>=<value>'", pair)
+		}
+		field, ok := fields[name]
+		if !ok {
+			return nil, fmt.Errorf("unsupported field '%s', can be one of: %s", name, strings.Join(editFieldNames(fields), ", "))
+		}
+		v, err := field.parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %w", name, err)
+		}
+		// 'add' replaces the value of existing members of an object
+		ops = append(ops, jsonPatchOperation{Op: "add", Path: field.path, Value: v})
+	}
+
+	if len(unset) > 0 {
+		current, err := objectToMap(obj)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range unset {
+			field, ok := fields[name]
+			if !ok {
+				return nil, fmt.Errorf("unsupported field '%s', can be one of: %s", name, strings.Join(editFieldNames(fields), ", "))
+			}
+			if !field.optional {
+				return nil, fmt.Errorf("field '%s' is required and can't be removed", name)
+			}
+			// removing a missing member fails the whole patch
+			if !jsonPointerExists(current, field.path) {
+				continue
+			}
+			ops = append(ops, jsonPatchOperation{Op: "remove", Path: field.path})
+		}
+	}
+
+	return json.Marshal(ops)
+}
+
+func objectToMap(obj client.Object) (map[string]interface{}, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// jsonPointerExists returns true if the object has a member at the given
+// JSON pointer, which must not contain escaped characters.
+func jsonPointerExists(obj map[string]interface{}, pointer string) bool {
+	current := obj
+	parts := strings.Split(strings.TrimPrefix(pointer, "/"), "/")
+	for i, part := range parts {
+		value, ok := current[part]
+		if !ok {
+			return false
+		}
+		if i == len(parts)-1 {
+			return true
+		}
+		if current, ok = value.(map[string]interface{}); !ok {
+			return false
+		}
+	}
+	return false
+}
+
+func editFieldNames(fields map[string]editField) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func parseEditString(value string) (interface{}, error) {
+	if value == "" {
+		return nil, fmt.Errorf("value can't be empty, use --unset to remove the field")
+	}
+	return value, nil
+}
+
+func parseEditDuration(value string) (interface{}, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return nil, err
+	}
+	return d.String(), nil
+}
+
+func parseEditBool(value string) (interface{}, error) {
+	return strconv.ParseBool(value)
+}
+
+func parseEditPath(value string) (interface{}, error) {
+	var path flags.SafeRelativePath
+	if err := path.Set(value); err != nil {
+		return nil, err
+	}
+	return path.ToSlash(), nil
+}
+
+func parseEditKustomizationSource(value string) (interface{}, error) {
+	var source flags.KustomizationSource
+	if err := source.Set(value); err != nil {
+		return nil, err
+	}
+	return kustomizev1.CrossNamespaceSourceReference{
+		Kind:      source.Kind,
+		Name:      source.Name,
+		Namespace: source.Namespace,
+	}, nil
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestBuildEditPatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		set     []string
+		unset   []string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "set fields",
+			set:  []string{"interval=5m", "prune=false", "path=deploy/production"},
+			want: `[{"op":"test","path":"/metadata/resourceVersion","value":"42"},` +
+				`{"op":"add","path":"/spec/interval","value":"5m0s"},` +
+				`{"op":"add","path":"/spec/prune","value":false},` +
+				`{"op":"add","path":"/spec/path","value":"./deploy/production"}]`,
+		},
+		{
+			name: "set source",
+			set:  []string{"sourceRef=OCIRepository/podinfo.flux-system"},
+			want: `[{"op":"test","path":"/metadata/resourceVersion","value":"42"},` +
+				`{"op":"add","path":"/spec/sourceRef","value":{"kind":"OCIRepository","name":"podinfo","namespace":"flux-system"}}]`,
+		},
+		{
+			name:  "unset present and missing fields",
+			unset: []string{"targetNamespace", "timeout"},
+			want: `[{"op":"test","path":"/metadata/resourceVersion","value":"42"},` +
+				`{"op":"remove","path":"/spec/targetNamespace"}]`,
+		},
+		{
+			name:    "unset required field",
+			unset:   []string{"interval"},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported field",
+			set:     []string{"force=true"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid value",
+			set:     []string{"interval=often"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ks := &kustomizev1.Kustomization{
+				ObjectMeta: metav1.ObjectMeta{Name: "podinfo", ResourceVersion: "42"},
+				Spec: kustomizev1.KustomizationSpec{
+					Interval:        metav1.Duration{Duration: time.Minute},
+					TargetNamespace: "default",
+				},
+			}
+			got, err := buildEditPatch(ks, editKustomizationFields, tt.set, tt.unset)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("buildEditPatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && string(got) != tt.want {
+				t.Errorf("buildEditPatch() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+	"testing/fstest"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const editTestCRD = `---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: kustomizations.kustomize.toolkit.fluxcd.io
+spec:
+  group: kustomize.toolkit.fluxcd.io
+  names:
+    kind: Kustomization
+    plural: kustomizations
+  scope: Namespaced
+  versions:
+  - name: v1beta2
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            required: [interval]
+            properties:
+              interval:
+                type: string
+              prune:
+                type: boolean
+`
+
+func TestEmbeddedCRDValidation(t *testing.T) {
+	manifests := fstest.MapFS{
+		"manifests/kustomize-controller.yaml": {Data: []byte(editTestCRD)},
+	}
+
+	v, err := embeddedCRDValidation(manifests, kustomizev1.GroupVersion.WithKind(kustomizev1.KustomizationKind))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v == nil || v.OpenAPIV3Schema == nil {
+		t.Fatal("expected the Kustomization schema to be found")
+	}
+
+	v, err = embeddedCRDValidation(manifests, kustomizev1.GroupVersion.WithKind("HelmRelease"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != nil {
+		t.Error("expected no schema for HelmRelease")
+	}
+}
+
+func TestParseEditedObject(t *testing.T) {
+	manifests := fstest.MapFS{
+		"manifests/kustomize-controller.yaml": {Data: []byte(editTestCRD)},
+	}
+	crdValidation, err := embeddedCRDValidation(manifests, kustomizev1.GroupVersion.WithKind(kustomizev1.KustomizationKind))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	original := &unstructured.Unstructured{}
+	original.SetAPIVersion(kustomizev1.GroupVersion.String())
+	original.SetKind(kustomizev1.KustomizationKind)
+	original.SetName("podinfo")
+	original.SetNamespace("flux-system")
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			content: `apiVersion: kustomize.toolkit.fluxcd.io/v1beta2
+kind: Kustomization
+metadata:
+  name: podinfo
+  namespace: flux-system
+spec:
+  interval: 5m
+  prune: true
+`,
+		},
+		{
+			name: "invalid type",
+			content: `apiVersion: kustomize.toolkit.fluxcd.io/v1beta2
+kind: Kustomization
+metadata:
+  name: podinfo
+  namespace: flux-system
+spec:
+  interval: 5m
+  prune: "yes"
+`,
+			wantErr: true,
+		},
+		{
+			name: "missing required field",
+			content: `apiVersion: kustomize.toolkit.fluxcd.io/v1beta2
+kind: Kustomization
+metadata:
+  name: podinfo
+  namespace: flux-system
+spec:
+  prune: true
+`,
+			wantErr: true,
+		},
+		{
+			name: "renamed",
+			content: `apiVersion: kustomize.toolkit.fluxcd.io/v1beta2
+kind: Kustomization
+metadata:
+  name: podinfo2
+  namespace: flux-system
+spec:
+  interval: 5m
+`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseEditedObject(original, []byte(tt.content), crdValidation)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseEditedObject() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStripEditHeader(t *testing.T) {
+	got := string(stripEditHeader([]byte(editHeader + "# error\nkind: Kustomization\n# comment\n")))
+	if want := "kind: Kustomization\n# comment\n"; got != want {
+		t.Errorf("stripEditHeader() = %q, want %q", got, want)
+	}
+}
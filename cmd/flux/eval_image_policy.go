@@ -0,0 +1,335 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/yaml"
+
+	imagev1 "github.com/fluxcd/image-reflector-controller/api/v1beta2"
+
+	"github.com/fluxcd/flux2/pkg/printers"
+)
+
+var evalImagePolicyCmd = &cobra.Command{
+	Use:   "image-policy [name]",
+	Short: "Evaluate which tag an ImagePolicy selects",
+	Long: `The eval image-policy command evaluates an ImagePolicy against a list of tags
+and explains how each tag was filtered and ordered, and which one the policy selects.
+The policy is read from a file with --file, or from the cluster when a name is given.
+The tags are read with --tags or --tags-file, or are listed from the container registry.`,
+	Example: `  # Evaluate a policy from the cluster against the tags in the registry
+  flux eval image-policy podinfo
+
+  # Evaluate a policy file against a list of tags
+  flux eval image-policy --file=./podinfo-policy.yaml --tags=5.0.0,5.1.0,6.0.0-rc.1
+
+  # Evaluate a policy file against the tags of an image in the registry
+  flux eval image-policy --file=./podinfo-policy.yaml --image=ghcr.io/stefanprodan/podinfo
+
+  # Evaluate a policy file against tags read from stdin
+  crane ls ghcr.io/stefanprodan/podinfo | flux eval image-policy --file=./podinfo-policy.yaml --tags-file=-`,
+	ValidArgsFunction: resourceNamesCompletionFunc(imagev1.GroupVersion.WithKind(imagev1.ImagePolicyKind)),
+	RunE:              evalImagePolicyCmdRun,
+}
+
+type evalImagePolicyFlags struct {
+	file     string
+	tags     []string
+	tagsFile string
+	image    string
+}
+
+var evalImagePolicyArgs evalImagePolicyFlags
+
+func init() {
+	evalImagePolicyCmd.Flags().StringVarP(&evalImagePolicyArgs.file, "file", "f", "",
+		"path to an ImagePolicy YAML file, when set the policy is not read from the cluster")
+	evalImagePolicyCmd.Flags().StringSliceVar(&evalImagePolicyArgs.tags, "tags", nil,
+		"list of tags to evaluate, accepts comma-separated values")
+	evalImagePolicyCmd.Flags().StringVar(&evalImagePolicyArgs.tagsFile, "tags-file", "",
+		"path to a file with one tag per line, use '-' to read from stdin")
+	evalImagePolicyCmd.Flags().StringVar(&evalImagePolicyArgs.image, "image", "",
+		"image repository to list the tags from, defaults to the image of the referenced ImageRepository")
+	evalCmd.AddCommand(evalImagePolicyCmd)
+}
+
+func evalImagePolicyCmdRun(cmd *cobra.Command, args []string) error {
+	if len(args) < 1 && evalImagePolicyArgs.file == "" {
+		return fmt.Errorf("ImagePolicy name or --file is required")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	defer cancel()
+
+	var policy imagev1.ImagePolicy
+	if evalImagePolicyArgs.file != "" {
+		data, err := os.ReadFile(evalImagePolicyArgs.file)
+		if err != nil {
+			return fmt.Errorf("failed to read policy file: %w", err)
+		}
+		if err := yaml.Unmarshal(data, &policy); err != nil {
+			return fmt.Errorf("failed to parse policy file: %w", err)
+		}
+		if policy.Kind != imagev1.ImagePolicyKind {
+			return fmt.Errorf("expected kind %s in %s, got '%s'", imagev1.ImagePolicyKind, evalImagePolicyArgs.file, policy.Kind)
+		}
+	} else {
+		kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
+		if err != nil {
+			return err
+		}
+		key := types.NamespacedName{Namespace: *kubeconfigArgs.Namespace, Name: args[0]}
+		if err := kubeClient.Get(ctx, key, &policy); err != nil {
+			return err
+		}
+		if len(evalImagePolicyArgs.tags) == 0 && evalImagePolicyArgs.tagsFile == "" && evalImagePolicyArgs.image == "" {
+			repoKey := types.NamespacedName{
+				Namespace: policy.Spec.ImageRepositoryRef.Namespace,
+				Name:      policy.Spec.ImageRepositoryRef.Name,
+			}
+			if repoKey.Namespace == "" {
+				repoKey.Namespace = policy.Namespace
+			}
+			var repo imagev1.ImageRepository
+			if err := kubeClient.Get(ctx, repoKey, &repo); err != nil {
+				return fmt.Errorf("failed to get ImageRepository %s: %w", repoKey, err)
+			}
+			evalImagePolicyArgs.image = repo.Spec.Image
+		}
+	}
+
+	tags, err := evalImagePolicyTags(ctx, cmd.InOrStdin())
+	if err != nil {
+		return err
+	}
+
+	result, err := evaluateImagePolicy(policy.Spec, tags)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(result.Tags))
+	for _, t := range result.Tags {
+		rows = append(rows, []string{t.Tag, t.Value, t.Status})
+	}
+	if err := printers.TablePrinter([]string{"tag", "value", "status"}).Print(cmd.OutOrStdout(), rows); err != nil {
+		return err
+	}
+
+	if result.Selected == "" {
+		return fmt.Errorf("no tag matches the policy, %d tags evaluated", len(tags))
+	}
+	logger.Successf("selected tag %s out of %d candidates from %d tags", result.Selected, result.Candidates, len(tags))
+	return nil
+}
+
+func evalImagePolicyTags(ctx context.Context, stdin io.Reader) ([]string, error) {
+	tags := append([]string{}, evalImagePolicyArgs.tags...)
+
+	if evalImagePolicyArgs.tagsFile != "" {
+		var r io.Reader = stdin
+		if evalImagePolicyArgs.tagsFile != "-" {
+			f, err := os.Open(evalImagePolicyArgs.tagsFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read tags file: %w", err)
+			}
+			defer f.Close()
+			r = f
+		}
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			if tag := strings.TrimSpace(scanner.Text()); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read tags: %w", err)
+		}
+	}
+
+	if len(tags) == 0 && evalImagePolicyArgs.image != "" {
+		repo, err := name.NewRepository(evalImagePolicyArgs.image)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse image value: %w", err)
+		}
+		logger.Actionf("listing tags of %s", repo.String())
+		tags, err = remote.List(repo, remote.WithContext(ctx), remote.WithAuthFromKeychain(authn.DefaultKeychain))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tags: %w", err)
+		}
+	}
+
+	if len(tags) == 0 {
+		return nil, fmt.Errorf("no tags to evaluate, use --tags, --tags-file or --image")
+	}
+	return tags, nil
+}
+
+const (
+	tagStatusFiltered   = "filtered out"
+	tagStatusInvalid    = "invalid"
+	tagStatusOutOfRange = "out of range"
+	tagStatusCandidate  = "candidate"
+	tagStatusSelected   = "selected"
+)
+
+type evaluatedTag struct {
+	Tag    string
+	Value  string
+	Status string
+}
+
+type imagePolicyEvaluation struct {
+	Tags       []evaluatedTag
+	Candidates int
+	Selected   string
+}
+
+// evaluateImagePolicy applies the tag filter and the policy of the spec
+// to the given tags, in the same way as the image-reflector-controller.
+func evaluateImagePolicy(spec imagev1.ImagePolicySpec, tags []string) (*imagePolicyEvaluation, error) {
+	var filter *regexp.Regexp
+	var extract string
+	if spec.FilterTags != nil && spec.FilterTags.Pattern != "" {
+		var err error
+		filter, err = regexp.Compile(spec.FilterTags.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter pattern: %w", err)
+		}
+		extract = spec.FilterTags.Extract
+	}
+
+	var less func(a, b string) bool
+	var accept func(value string) string
+	switch {
+	case spec.Policy.SemVer != nil:
+		constraint, err := semver.NewConstraint(spec.Policy.SemVer.Range)
+		if err != nil {
+			return nil, fmt.Errorf("invalid semver range '%s': %w", spec.Policy.SemVer.Range, err)
+		}
+		accept = func(value string) string {
+			v, err := semver.NewVersion(value)
+			if err != nil {
+				return tagStatusInvalid
+			}
+			if !constraint.Check(v) {
+				return tagStatusOutOfRange
+			}
+			return tagStatusCandidate
+		}
+		less = func(a, b string) bool {
+			return semver.MustParse(a).LessThan(semver.MustParse(b))
+		}
+	case spec.Policy.Alphabetical != nil:
+		accept = func(string) string { return tagStatusCandidate }
+		less = func(a, b string) bool { return a < b }
+		if spec.Policy.Alphabetical.Order == "desc" {
+			less = func(a, b string) bool { return a > b }
+		}
+	case spec.Policy.Numerical != nil:
+		accept = func(value string) string {
+			if _, err := strconv.ParseFloat(value, 64); err != nil {
+				return tagStatusInvalid
+			}
+			return tagStatusCandidate
+		}
+		num := func(s string) float64 {
+			f, _ := strconv.ParseFloat(s, 64)
+			return f
+		}
+		less = func(a, b string) bool { return num(a) < num(b) }
+		if spec.Policy.Numerical.Order == "desc" {
+			less = func(a, b string) bool { return num(a) > num(b) }
+		}
+	default:
+		return nil, fmt.Errorf("the policy must define one of semver, alphabetical or numerical")
+	}
+
+	result := &imagePolicyEvaluation{}
+	best := -1
+	for _, tag := range tags {
+		t := evaluatedTag{Tag: tag, Value: tag}
+		if filter != nil {
+			match := filter.FindStringSubmatchIndex(tag)
+			if match == nil {
+				t.Status = tagStatusFiltered
+				result.Tags = append(result.Tags, t)
+				continue
+			}
+			if extract != "" {
+				t.Value = string(filter.ExpandString(nil, extract, tag, match))
+			}
+		}
+		t.Status = accept(t.Value)
+		if t.Status == tagStatusCandidate {
+			result.Candidates++
+			if best < 0 || less(result.Tags[best].Value, t.Value) {
+				best = len(result.Tags)
+			}
+		}
+		result.Tags = append(result.Tags, t)
+	}
+
+	if best >= 0 {
+		result.Tags[best].Status = tagStatusSelected
+		result.Selected = result.Tags[best].Tag
+	}
+
+	// List the candidates first, ordered by preference.
+	sort.SliceStable(result.Tags, func(i, j int) bool {
+		ri, rj := tagStatusRank(result.Tags[i].Status), tagStatusRank(result.Tags[j].Status)
+		if ri != rj {
+			return ri < rj
+		}
+		if ri <= 1 {
+			return less(result.Tags[j].Value, result.Tags[i].Value)
+		}
+		return false
+	})
+	return result, nil
+}
+
+func tagStatusRank(status string) int {
+	switch status {
+	case tagStatusSelected:
+		return 0
+	case tagStatusCandidate:
+		return 1
+	case tagStatusOutOfRange:
+		return 2
+	case tagStatusInvalid:
+		return 3
+	default:
+		return 4
+	}
+}
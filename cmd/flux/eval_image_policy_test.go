@@ -0,0 +1,60 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+)
+
+func TestEvalImagePolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   string
+		assert assertFunc
+	}{
+		{
+			name:   "no policy",
+			args:   "eval image-policy",
+			assert: assertError("ImagePolicy name or --file is required"),
+		},
+		{
+			name:   "semver range",
+			args:   "eval image-policy --file=testdata/eval_image_policy/semver.yaml --tags=4.0.6,5.0.0,5.2.1,6.0.0,latest,5.1.0-rc.1",
+			assert: assertGoldenFile("testdata/eval_image_policy/semver.golden"),
+		},
+		{
+			name:   "numerical with extract",
+			args:   "eval image-policy --file=testdata/eval_image_policy/numerical.yaml --tags-file=testdata/eval_image_policy/tags.txt",
+			assert: assertGoldenFile("testdata/eval_image_policy/numerical.golden"),
+		},
+		{
+			name:   "no match",
+			args:   "eval image-policy --file=testdata/eval_image_policy/semver.yaml --tags=6.0.0,latest",
+			assert: assertError("no tag matches the policy, 2 tags evaluated"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := cmdTestCase{
+				args:   tt.args,
+				assert: tt.assert,
+			}
+			cmd.runTestCmd(t)
+		})
+	}
+}
@@ -0,0 +1,240 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var eventsExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the recent events of Flux resources",
+	Long: `The events export command collects the recent events of the Flux resources and
+writes them as newline delimited JSON to stdout or a file, or posts them to a webhook.`,
+	Example: `  # Export the events of the last hour in the flux-system namespace
+  flux events export
+
+  # Export the events of the last day in all namespaces to a file
+  flux events export -A --since=24h --output=events.ndjson
+
+  # Post the events of the last 10 minutes to a webhook
+  flux events export -A --since=10m --webhook=https://incidents.example.com/hooks/flux`,
+	Args: cobra.NoArgs,
+	RunE: eventsExportCmdRun,
+}
+
+type eventsExportFlags struct {
+	allNamespaces bool
+	since         time.Duration
+	output        string
+	webhook       string
+}
+
+var eventsExportArgs = eventsExportFlags{
+	since: time.Hour,
+}
+
+func init() {
+	eventsExportCmd.Flags().BoolVarP(&eventsExportArgs.allNamespaces, "all-namespaces", "A", false,
+		"export the events from all namespaces")
+	eventsExportCmd.Flags().DurationVar(&eventsExportArgs.since, "since", eventsExportArgs.since,
+		"export only the events that occurred within this duration, 0 exports all events")
+	eventsExportCmd.Flags().StringVarP(&eventsExportArgs.output, "output", "o", "",
+		"the file to write the events to, defaults to stdout")
+	eventsExportCmd.Flags().StringVar(&eventsExportArgs.webhook, "webhook", "",
+		"the URL to post the events to instead of writing them")
+	eventsCmd.AddCommand(eventsExportCmd)
+}
+
+// exportedEvent is the JSON record written for each event.
+type exportedEvent struct {
+	Timestamp      time.Time           `json:"timestamp"`
+	Type           string              `json:"type"`
+	Reason         string              `json:"reason"`
+	Message        string              `json:"message"`
+	Count          int32               `json:"count,omitempty"`
+	Controller     string              `json:"controller,omitempty"`
+	InvolvedObject exportedEventObject `json:"involvedObject"`
+	Metadata       map[string]string   `json:"metadata,omitempty"`
+}
+
+type exportedEventObject struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace,omitempty"`
+}
+
+func eventsExportCmdRun(cmd *cobra.Command, args []string) error {
+	if eventsExportArgs.output != "" && eventsExportArgs.webhook != "" {
+		return fmt.Errorf("--output and --webhook are mutually exclusive")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
+	if err != nil {
+		return err
+	}
+
+	var opts []client.ListOption
+	if !eventsExportArgs.allNamespaces {
+		opts = append(opts, client.InNamespace(*kubeconfigArgs.Namespace))
+	}
+	var list corev1.EventList
+	if err := kubeClient.List(ctx, &list, opts...); err != nil {
+		return fmt.Errorf("unable to list events: %w", err)
+	}
+
+	var since time.Time
+	if eventsExportArgs.since > 0 {
+		since = time.Now().Add(-eventsExportArgs.since)
+	}
+	events := fluxEvents(list.Items, since)
+
+	var buf bytes.Buffer
+	if err := writeEvents(&buf, events); err != nil {
+		return err
+	}
+
+	switch {
+	case eventsExportArgs.webhook != "":
+		if err := postEvents(ctx, eventsExportArgs.webhook, buf.Bytes()); err != nil {
+			return err
+		}
+		logger.Successf("posted %d events to %s", len(events), eventsExportArgs.webhook)
+	case eventsExportArgs.output != "":
+		if err := os.WriteFile(eventsExportArgs.output, buf.Bytes(), 0o644); err != nil {
+			return fmt.Errorf("unable to write events: %w", err)
+		}
+		logger.Successf("exported %d events to %s", len(events), eventsExportArgs.output)
+	default:
+		_, err := cmd.OutOrStdout().Write(buf.Bytes())
+		return err
+	}
+	return nil
+}
+
+// fluxEvents returns the events of Flux resources which occurred after since,
+// sorted by their timestamp. A zero since returns all the events.
+func fluxEvents(items []corev1.Event, since time.Time) []exportedEvent {
+	var events []exportedEvent
+	for _, e := range items {
+		if !strings.Contains(e.InvolvedObject.APIVersion, ".toolkit.fluxcd.io/") {
+			continue
+		}
+		ts := eventTime(e)
+		if ts.Before(since) {
+			continue
+		}
+		events = append(events, exportedEvent{
+			Timestamp:  ts.UTC(),
+			Type:       e.Type,
+			Reason:     e.Reason,
+			Message:    e.Message,
+			Count:      e.Count,
+			Controller: e.Source.Component,
+			InvolvedObject: exportedEventObject{
+				APIVersion: e.InvolvedObject.APIVersion,
+				Kind:       e.InvolvedObject.Kind,
+				Name:       e.InvolvedObject.Name,
+				Namespace:  e.InvolvedObject.Namespace,
+			},
+			Metadata: eventMetadata(e),
+		})
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp.Before(events[j].Timestamp)
+	})
+	return events
+}
+
+// eventTime returns the time the event last occurred, falling back to the
+// fields set by the older event recorders.
+func eventTime(e corev1.Event) time.Time {
+	switch {
+	case !e.LastTimestamp.IsZero():
+		return e.LastTimestamp.Time
+	case !e.EventTime.IsZero():
+		return e.EventTime.Time
+	case !e.FirstTimestamp.IsZero():
+		return e.FirstTimestamp.Time
+	default:
+		return e.CreationTimestamp.Time
+	}
+}
+
+// eventMetadata returns the Flux annotations of the event, such as the
+// revision of the reconciled source, without the group prefix.
+func eventMetadata(e corev1.Event) map[string]string {
+	var metadata map[string]string
+	for k, v := range e.Annotations {
+		i := strings.Index(k, ".toolkit.fluxcd.io/")
+		if i < 0 {
+			continue
+		}
+		if metadata == nil {
+			metadata = make(map[string]string)
+		}
+		metadata[k[i+len(".toolkit.fluxcd.io/"):]] = v
+	}
+	return metadata
+}
+
+// writeEvents writes the events as newline delimited JSON.
+func writeEvents(w io.Writer, events []exportedEvent) error {
+	enc := json.NewEncoder(w)
+	for _, e := range events {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// postEvents sends the newline delimited JSON events to the webhook.
+func postEvents(ctx context.Context, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid webhook: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to post events: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unable to post events: webhook returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEventsExportCmd(t *testing.T) {
+	runFakeCmdTests(t, []fakeCmdTestCase{
+		{
+			name:       "namespace",
+			args:       "events export --since=0 -n flux-system",
+			objectFile: "testdata/fake/events.yaml",
+			goldenFile: "testdata/fake/events_export.golden",
+		},
+		{
+			name:       "all namespaces",
+			args:       "events export --since=0 -A",
+			objectFile: "testdata/fake/events.yaml",
+			goldenFile: "testdata/fake/events_export_all.golden",
+		},
+		{
+			name:       "since",
+			args:       "events export --since=1h -A",
+			objectFile: "testdata/fake/events.yaml",
+			goldenFile: "testdata/fake/events_export_since.golden",
+		},
+		{
+			name:    "output and webhook",
+			args:    "events export --output=events.ndjson --webhook=http://localhost",
+			wantErr: "--output and --webhook are mutually exclusive",
+		},
+	})
+}
+
+func TestEventsExportWebhook(t *testing.T) {
+	var contentType string
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	isolateEnv(t)
+	useFakeCluster(t, readObjectFile(t, "testdata/fake/events.yaml")...)
+	cmd := cmdTestCase{
+		args:   "events export --since=0 -n flux-system --webhook=" + server.URL,
+		assert: assertGoldenValue(fmt.Sprintf("✔ posted 2 events to %s\n", server.URL)),
+	}
+	cmd.runTestCmd(t)
+
+	if contentType != "application/x-ndjson" {
+		t.Errorf("expected application/x-ndjson content type, got %q", contentType)
+	}
+	want, err := os.ReadFile("testdata/fake/events_export.golden")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != string(want) {
+		t.Errorf("unexpected webhook body:\n%s", body)
+	}
+}
+
+func TestEventsExportOutput(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "events.ndjson")
+	isolateEnv(t)
+	useFakeCluster(t, readObjectFile(t, "testdata/fake/events.yaml")...)
+	cmd := cmdTestCase{
+		args:   "events export --since=0 -A --output=" + output,
+		assert: assertGoldenValue(fmt.Sprintf("✔ exported 3 events to %s\n", output)),
+	}
+	cmd.runTestCmd(t)
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), "\n"); n != 3 {
+		t.Errorf("expected 3 events, got %d", n)
+	}
+}
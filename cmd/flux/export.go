@@ -20,33 +20,211 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 
 	"github.com/spf13/cobra"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/yaml"
 
-	"github.com/fluxcd/flux2/internal/utils"
+	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
+	autov1 "github.com/fluxcd/image-automation-controller/api/v1beta1"
+	imagev1 "github.com/fluxcd/image-reflector-controller/api/v1beta2"
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	notificationv1 "github.com/fluxcd/notification-controller/api/v1beta2"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
 )
 
 var exportCmd = &cobra.Command{
 	Use:   "export",
 	Short: "Export resources in YAML format",
-	Long:  "The export sub-commands export resources in YAML format.",
+	Long: `The export sub-commands export resources in YAML format.
+With --all, the export command exports the Flux objects of all kinds, sources first.
+The objects are listed in pages of --chunk-size and written as they are fetched,
+the kinds are fetched concurrently and written in order.`,
+	Example: `  # Export the Flux objects of all kinds in all namespaces
+  flux export --all --all-namespaces > flux-objects.yaml
+
+  # Export the Kustomizations in all namespaces, 100 objects per request
+  flux export kustomization --all -A --chunk-size=100`,
+	Args: cobra.NoArgs,
+	RunE: exportCmdRun,
 }
 
 type exportFlags struct {
-	all bool
+	all           bool
+	allNamespaces bool
+	chunkSize     int64
+	concurrency   int
 }
 
-var exportArgs exportFlags
+var exportArgs = newExportFlags()
+
+func newExportFlags() exportFlags {
+	return exportFlags{
+		chunkSize:   500,
+		concurrency: 4,
+	}
+}
 
 func init() {
 	exportCmd.PersistentFlags().BoolVar(&exportArgs.all, "all", false, "select all resources")
+	exportCmd.PersistentFlags().BoolVarP(&exportArgs.allNamespaces, "all-namespaces", "A", false,
+		"select the resources in all namespaces, requires --all")
+	exportCmd.PersistentFlags().Int64Var(&exportArgs.chunkSize, "chunk-size", exportArgs.chunkSize,
+		"number of objects fetched per list request with --all, 0 fetches all the objects at once")
+	exportCmd.Flags().IntVar(&exportArgs.concurrency, "concurrency", exportArgs.concurrency,
+		"number of kinds fetched concurrently when exporting all kinds")
 
 	rootCmd.AddCommand(exportCmd)
 }
 
+// exportKinds returns the lists of the kinds exported by 'flux export --all',
+// the sources come first so that the output can be applied as is.
+func exportKinds() []exportableList {
+	return []exportableList{
+		gitRepositoryListAdapter{&sourcev1.GitRepositoryList{}},
+		ociRepositoryListAdapter{&sourcev1.OCIRepositoryList{}},
+		helmRepositoryListAdapter{&sourcev1.HelmRepositoryList{}},
+		bucketListAdapter{&sourcev1.BucketList{}},
+		kustomizationListAdapter{&kustomizev1.KustomizationList{}},
+		helmReleaseListAdapter{&helmv2.HelmReleaseList{}},
+		alertProviderListAdapter{&notificationv1.ProviderList{}},
+		alertListAdapter{&notificationv1.AlertList{}},
+		receiverListAdapter{&notificationv1.ReceiverList{}},
+		imageRepositoryListAdapter{&imagev1.ImageRepositoryList{}},
+		imagePolicyListAdapter{&imagev1.ImagePolicyList{}},
+		imageUpdateAutomationListAdapter{&autov1.ImageUpdateAutomationList{}},
+	}
+}
+
+func exportCmdRun(cmd *cobra.Command, args []string) error {
+	if !exportArgs.all {
+		return cmd.Help()
+	}
+	if exportArgs.concurrency < 1 {
+		return fmt.Errorf("--concurrency must be at least 1")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
+	if err != nil {
+		return err
+	}
+
+	kinds := exportKinds()
+	pages := make([]chan string, len(kinds))
+	errs := make([]chan error, len(kinds))
+	for i := range kinds {
+		pages[i] = make(chan string, 1)
+		errs[i] = make(chan error, 1)
+	}
+
+	// The workers are started in the order of the kinds, so the kind being
+	// written always holds a slot and the later ones can't starve it.
+	go func() {
+		sem := make(chan struct{}, exportArgs.concurrency)
+		for i := range kinds {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				for ; i < len(kinds); i++ {
+					close(pages[i])
+					errs[i] <- ctx.Err()
+				}
+				return
+			}
+			go func(i int) {
+				defer func() { <-sem }()
+				defer close(pages[i])
+				errs[i] <- exportKind(ctx, kubeClient, kinds[i], pages[i])
+			}(i)
+		}
+	}()
+
+	written := false
+	for i := range kinds {
+		for page := range pages[i] {
+			written = true
+			rootCmd.Print(page)
+		}
+		if err := <-errs[i]; err != nil {
+			return err
+		}
+	}
+	if !written {
+		return fmt.Errorf("no objects found in %s", exportScope())
+	}
+	return nil
+}
+
+// exportKind sends the objects of the list kind to out page by page,
+// the kinds which CRDs are not installed on the cluster are skipped.
+func exportKind(ctx context.Context, kubeClient client.Client, list exportableList, out chan<- string) error {
+	err := listPages(ctx, kubeClient, list, func() error {
+		var buf bytes.Buffer
+		for i := 0; i < list.len(); i++ {
+			if err := writeExport(&buf, list.exportItem(i)); err != nil {
+				return err
+			}
+		}
+		if list.len() > 0 {
+			select {
+			case out <- buf.String():
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		return nil
+	})
+	if apimeta.IsNoMatchError(err) {
+		return nil
+	}
+	return err
+}
+
+// listPages lists the objects selected with --all and --all-namespaces in
+// pages of --chunk-size, calling fn after each page so that the objects can
+// be written before the next page is fetched.
+func listPages(ctx context.Context, kubeClient client.Client, list listAdapter, fn func() error) error {
+	var opts []client.ListOption
+	if !exportArgs.allNamespaces {
+		opts = append(opts, client.InNamespace(*kubeconfigArgs.Namespace))
+	}
+	if exportArgs.chunkSize > 0 {
+		opts = append(opts, client.Limit(exportArgs.chunkSize))
+	}
+
+	continueToken := ""
+	for {
+		pageOpts := opts
+		if continueToken != "" {
+			pageOpts = append(opts[:len(opts):len(opts)], client.Continue(continueToken))
+		}
+		if err := kubeClient.List(ctx, list.asClientList(), pageOpts...); err != nil {
+			return err
+		}
+		if err := fn(); err != nil {
+			return err
+		}
+		continueToken = list.asClientList().GetContinue()
+		if continueToken == "" {
+			return nil
+		}
+	}
+}
+
+// exportScope describes the namespaces the objects are exported from.
+func exportScope() string {
+	if exportArgs.allNamespaces {
+		return "any namespace"
+	}
+	return fmt.Sprintf("%s namespace", *kubeconfigArgs.Namespace)
+}
+
 // exportable represents a type that you can fetch from the Kubernetes
 // API, then tidy up for serialising.
 type exportable interface {
@@ -70,29 +248,35 @@ func (export exportCommand) run(cmd *cobra.Command, args []string) error {
 	if !exportArgs.all && len(args) < 1 {
 		return fmt.Errorf("name is required")
 	}
+	if exportArgs.allNamespaces && !exportArgs.all {
+		return fmt.Errorf("--all-namespaces requires --all")
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(kubeconfigArgs, kubeclientOptions)
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
 	if err != nil {
 		return err
 	}
 
 	if exportArgs.all {
-		err = kubeClient.List(ctx, export.list.asClientList(), client.InNamespace(*kubeconfigArgs.Namespace))
+		var total int
+		err = listPages(ctx, kubeClient, export.list, func() error {
+			total += export.list.len()
+			for i := 0; i < export.list.len(); i++ {
+				if err := printExport(export.list.exportItem(i)); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
 		if err != nil {
 			return err
 		}
 
-		if export.list.len() == 0 {
-			return fmt.Errorf("no objects found in %s namespace", *kubeconfigArgs.Namespace)
-		}
-
-		for i := 0; i < export.list.len(); i++ {
-			if err = printExport(export.list.exportItem(i)); err != nil {
-				return err
-			}
+		if total == 0 {
+			return fmt.Errorf("no objects found in %s", exportScope())
 		}
 	} else {
 		name := args[0]
@@ -110,13 +294,16 @@ func (export exportCommand) run(cmd *cobra.Command, args []string) error {
 }
 
 func printExport(export interface{}) error {
+	return writeExport(rootCmd.OutOrStdout(), export)
+}
+
+func writeExport(w io.Writer, export interface{}) error {
 	data, err := yaml.Marshal(export)
 	if err != nil {
 		return err
 	}
-	rootCmd.Println("---")
-	rootCmd.Println(resourceToString(data))
-	return nil
+	_, err = fmt.Fprintf(w, "---\n%s\n", resourceToString(data))
+	return err
 }
 
 func resourceToString(data []byte) string {
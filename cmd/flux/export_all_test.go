@@ -0,0 +1,123 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	runclient "github.com/fluxcd/pkg/runtime/client"
+)
+
+// pagingClient paginates the lists of the fake client, which ignores the
+// limit and continue options.
+type pagingClient struct {
+	client.WithWatch
+	lists atomic.Int32
+}
+
+func (c *pagingClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	c.lists.Add(1)
+	listOpts := (&client.ListOptions{}).ApplyOptions(opts)
+	if err := c.WithWatch.List(ctx, list, opts...); err != nil {
+		return err
+	}
+	if listOpts.Limit == 0 {
+		return nil
+	}
+
+	items, err := apimeta.ExtractList(list)
+	if err != nil {
+		return err
+	}
+	start := 0
+	if listOpts.Continue != "" {
+		if start, err = strconv.Atoi(listOpts.Continue); err != nil {
+			return err
+		}
+	}
+	end := start + int(listOpts.Limit)
+	if end < len(items) {
+		list.SetContinue(strconv.Itoa(end))
+	} else {
+		end = len(items)
+		list.SetContinue("")
+	}
+	return apimeta.SetList(list, items[start:end])
+}
+
+func usePagingFakeCluster(t *testing.T) *pagingClient {
+	t.Helper()
+	kubeClient := &pagingClient{
+		WithWatch: useFakeCluster(t, readObjectFile(t, "testdata/export_all/objects.yaml")...),
+	}
+	previous := newKubeClient
+	newKubeClient = func(genericclioptions.RESTClientGetter, *runclient.Options) (client.WithWatch, error) {
+		return kubeClient, nil
+	}
+	t.Cleanup(func() { newKubeClient = previous })
+	return kubeClient
+}
+
+func TestExportAllNamespaces(t *testing.T) {
+	isolateEnv(t)
+	kubeClient := usePagingFakeCluster(t)
+
+	cmd := cmdTestCase{
+		args:   "export kustomization --all -A --chunk-size=1",
+		assert: assertGoldenFile("testdata/export_all/kustomizations.golden"),
+	}
+	cmd.runTestCmd(t)
+	if n := kubeClient.lists.Load(); n != 3 {
+		t.Errorf("expected the 3 Kustomizations to be listed in 3 pages, got %d list requests", n)
+	}
+
+	cmd = cmdTestCase{
+		args:   "export kustomization podinfo -A",
+		assert: assertError("--all-namespaces requires --all"),
+	}
+	cmd.runTestCmd(t)
+
+	cmd = cmdTestCase{
+		args:   "export kustomization --all -n default",
+		assert: assertError("no objects found in default namespace"),
+	}
+	cmd.runTestCmd(t)
+}
+
+func TestExportAllKinds(t *testing.T) {
+	isolateEnv(t)
+	usePagingFakeCluster(t)
+
+	cmd := cmdTestCase{
+		args:   "export --all -A --chunk-size=1 --concurrency=2",
+		assert: assertGoldenFile("testdata/export_all/all.golden"),
+	}
+	cmd.runTestCmd(t)
+
+	cmd = cmdTestCase{
+		args:   "export --all -n flux-system --chunk-size=0",
+		assert: assertGoldenFile("testdata/export_all/all-namespace.golden"),
+	}
+	cmd.runTestCmd(t)
+}
@@ -17,8 +17,19 @@ limitations under the License.
 package main
 
 import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	securejoin "github.com/cyphar/filepath-securejoin"
 	"github.com/spf13/cobra"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	autov1 "github.com/fluxcd/image-automation-controller/api/v1beta1"
 )
@@ -31,18 +42,181 @@ var exportImageUpdateCmd = &cobra.Command{
   flux export image update --all > updates.yaml
 
   # Export a specific automation
-  flux export image update latest-images > latest.yaml`,
+  flux export image update latest-images > latest.yaml
+
+  # Export an automation along with the image policy markers found
+  # in its update path, given a local checkout of the Git repository
+  flux export image update latest-images --with-markers --local-path ./fleet-infra`,
 	ValidArgsFunction: resourceNamesCompletionFunc(autov1.GroupVersion.WithKind(autov1.ImageUpdateAutomationKind)),
-	RunE: exportCommand{
-		object: imageUpdateAutomationAdapter{&autov1.ImageUpdateAutomation{}},
-		list:   imageUpdateAutomationListAdapter{&autov1.ImageUpdateAutomationList{}},
-	}.run,
+	RunE:              exportImageUpdateCmdRun,
+}
+
+type exportImageUpdateFlags struct {
+	withMarkers bool
+	localPath   string
+}
+
+var exportImageUpdateArgs exportImageUpdateFlags
+
+var exportImageUpdateCommand = exportCommand{
+	object: imageUpdateAutomationAdapter{&autov1.ImageUpdateAutomation{}},
+	list:   imageUpdateAutomationListAdapter{&autov1.ImageUpdateAutomationList{}},
 }
 
 func init() {
+	exportImageUpdateCmd.Flags().BoolVar(&exportImageUpdateArgs.withMarkers, "with-markers", false,
+		"append a comment block listing the image policy markers found in the update path")
+	exportImageUpdateCmd.Flags().StringVar(&exportImageUpdateArgs.localPath, "local-path", "",
+		"path to a local checkout of the Git repository the automation updates, required by --with-markers")
 	exportImageCmd.AddCommand(exportImageUpdateCmd)
 }
 
+func exportImageUpdateCmdRun(cmd *cobra.Command, args []string) error {
+	if !exportImageUpdateArgs.withMarkers {
+		return exportImageUpdateCommand.run(cmd, args)
+	}
+	if exportImageUpdateArgs.localPath == "" {
+		return fmt.Errorf("--local-path is required when using --with-markers")
+	}
+	if fs, err := os.Stat(exportImageUpdateArgs.localPath); err != nil || !fs.IsDir() {
+		return fmt.Errorf("invalid local path '%s', must point to a directory", exportImageUpdateArgs.localPath)
+	}
+	if !exportArgs.all && len(args) < 1 {
+		return fmt.Errorf("name is required")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
+	if err != nil {
+		return err
+	}
+
+	var items []autov1.ImageUpdateAutomation
+	if exportArgs.all {
+		var list autov1.ImageUpdateAutomationList
+		if err := kubeClient.List(ctx, &list, client.InNamespace(*kubeconfigArgs.Namespace)); err != nil {
+			return err
+		}
+		if len(list.Items) == 0 {
+			return fmt.Errorf("no objects found in %s namespace", *kubeconfigArgs.Namespace)
+		}
+		items = list.Items
+	} else {
+		var auto autov1.ImageUpdateAutomation
+		namespacedName := types.NamespacedName{
+			Namespace: *kubeconfigArgs.Namespace,
+			Name:      args[0],
+		}
+		if err := kubeClient.Get(ctx, namespacedName, &auto); err != nil {
+			return err
+		}
+		items = append(items, auto)
+	}
+
+	for i := range items {
+		if err := printExport(exportImageUpdate(&items[i])); err != nil {
+			return err
+		}
+		summary, err := imageUpdateMarkersSummary(&items[i], exportImageUpdateArgs.localPath)
+		if err != nil {
+			return err
+		}
+		rootCmd.Print(summary)
+	}
+	return nil
+}
+
+// imagePolicyMarkerRe matches the setter markers used by the
+// image-automation-controller, e.g. # {"$imagepolicy": "flux-system:podinfo:tag"}.
+var imagePolicyMarkerRe = regexp.MustCompile(`#\s*\{\s*"\$imagepolicy"\s*:\s*"([^"]+)"\s*\}`)
+
+// imageUpdateMarkersSummary scans the update path of the automation in the
+// given local checkout and returns a YAML comment block listing the
+// image policy markers the automation will act upon.
+func imageUpdateMarkersSummary(item *autov1.ImageUpdateAutomation, localPath string) (string, error) {
+	updatePath := "."
+	if item.Spec.Update != nil && item.Spec.Update.Path != "" {
+		updatePath = item.Spec.Update.Path
+	}
+	root, err := securejoin.SecureJoin(localPath, updatePath)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(root); err != nil {
+		return "", fmt.Errorf("update path '%s' not found in %s", updatePath, localPath)
+	}
+
+	var markers []string
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if ext := filepath.Ext(path); ext != ".yaml" && ext != ".yml" {
+			return nil
+		}
+		found, err := scanImagePolicyMarkers(path, item.Namespace)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		for _, m := range found {
+			markers = append(markers, fmt.Sprintf("#   %s:%s", filepath.ToSlash(rel), m))
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to scan update path '%s': %w", updatePath, err)
+	}
+
+	var sb strings.Builder
+	if len(markers) == 0 {
+		sb.WriteString(fmt.Sprintf("# No image policy markers found in %s\n", updatePath))
+		return sb.String(), nil
+	}
+	sb.WriteString(fmt.Sprintf("# Image policy markers found in %s:\n", updatePath))
+	for _, m := range markers {
+		sb.WriteString(m + "\n")
+	}
+	return sb.String(), nil
+}
+
+// scanImagePolicyMarkers returns the line number and policy reference of
+// each marker in the given file. Markers referring to policies outside of
+// the automation namespace are flagged, as the controller ignores them.
+func scanImagePolicyMarkers(path, namespace string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var markers []string
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		match := imagePolicyMarkerRe.FindStringSubmatch(scanner.Text())
+		if match == nil {
+			continue
+		}
+		marker := fmt.Sprintf("%d %s", line, match[1])
+		if ns := strings.SplitN(match[1], ":", 2)[0]; ns != namespace {
+			marker += " (ignored, policy not in the automation namespace)"
+		}
+		markers = append(markers, marker)
+	}
+	return markers, scanner.Err()
+}
+
 // exportImageUpdate returns a value which has extraneous information
 // stripped out.
 func exportImageUpdate(item *autov1.ImageUpdateAutomation) interface{} {
@@ -0,0 +1,72 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	autov1 "github.com/fluxcd/image-automation-controller/api/v1beta1"
+)
+
+func TestImageUpdateMarkersSummary(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		expected string
+	}{
+		{
+			name: "markers in update path",
+			path: "./clusters/my-cluster",
+			expected: `# Image policy markers found in ./clusters/my-cluster:
+#   apps/kustomization.yaml:7 flux-system:podinfo:tag
+#   apps/podinfo.yaml:11 flux-system:podinfo
+#   apps/podinfo.yaml:13 other:sidecar (ignored, policy not in the automation namespace)
+`,
+		},
+		{
+			name:     "no markers in update path",
+			path:     "./clusters/staging",
+			expected: "# No image policy markers found in ./clusters/staging\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auto := &autov1.ImageUpdateAutomation{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "flux-system",
+					Namespace: "flux-system",
+				},
+				Spec: autov1.ImageUpdateAutomationSpec{
+					Update: &autov1.UpdateStrategy{
+						Path:     tt.path,
+						Strategy: autov1.UpdateStrategySetters,
+					},
+				},
+			}
+			summary, err := imageUpdateMarkersSummary(auto, "testdata/export/image-update-repo")
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if summary != tt.expected {
+				t.Errorf("unexpected summary:\n%s\nexpected:\n%s", summary, tt.expected)
+			}
+		})
+	}
+}
@@ -25,8 +25,6 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-
-	"github.com/fluxcd/flux2/internal/utils"
 )
 
 // exportableWithSecret represents a type that you can fetch from the Kubernetes
@@ -55,36 +53,41 @@ func (export exportWithSecretCommand) run(cmd *cobra.Command, args []string) err
 	if !exportArgs.all && len(args) < 1 {
 		return fmt.Errorf("name is required")
 	}
+	if exportArgs.allNamespaces && !exportArgs.all {
+		return fmt.Errorf("--all-namespaces requires --all")
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(kubeconfigArgs, kubeclientOptions)
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
 	if err != nil {
 		return err
 	}
 
 	if exportArgs.all {
-		err = kubeClient.List(ctx, export.list.asClientList(), client.InNamespace(*kubeconfigArgs.Namespace))
+		var total int
+		err = listPages(ctx, kubeClient, export.list, func() error {
+			total += export.list.len()
+			for i := 0; i < export.list.len(); i++ {
+				if err := printExport(export.list.exportItem(i)); err != nil {
+					return err
+				}
+
+				if exportSourceWithCred && export.list.secretItem(i) != nil {
+					if err := printSecretCredentials(ctx, kubeClient, *export.list.secretItem(i)); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		})
 		if err != nil {
 			return err
 		}
 
-		if export.list.len() == 0 {
-			return fmt.Errorf("no objects found in %s namespace", *kubeconfigArgs.Namespace)
-		}
-
-		for i := 0; i < export.list.len(); i++ {
-			if err = printExport(export.list.exportItem(i)); err != nil {
-				return err
-			}
-
-			if exportSourceWithCred {
-				if export.list.secretItem(i) != nil {
-					namespacedName := *export.list.secretItem(i)
-					return printSecretCredentials(ctx, kubeClient, namespacedName)
-				}
-			}
+		if total == 0 {
+			return fmt.Errorf("no objects found in %s", exportScope())
 		}
 	} else {
 		name := args[0]
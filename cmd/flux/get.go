@@ -19,20 +19,27 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/duration"
 	"k8s.io/apimachinery/pkg/watch"
+	cliprinters "k8s.io/cli-runtime/pkg/printers"
 	watchtools "k8s.io/client-go/tools/watch"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
 	"github.com/fluxcd/pkg/apis/meta"
 
-	"github.com/fluxcd/flux2/internal/utils"
 	"github.com/fluxcd/flux2/pkg/printers"
 )
 
@@ -67,17 +74,44 @@ type GetFlags struct {
 	noHeader       bool
 	statusSelector string
 	watch          bool
+	showLabels     bool
+	output         string
+	omitSuspended  bool
+	onlySuspended  bool
+	age            bool
+	countOnly      bool
 }
 
+const (
+	getOutputWide = "wide"
+	getOutputJSON = "json"
+	getOutputYAML = "yaml"
+	getOutputName = "name"
+)
+
+var supportedGetOutputs = []string{getOutputWide, getOutputJSON, getOutputYAML, getOutputName}
+
 var getArgs GetFlags
 
 func init() {
 	getCmd.PersistentFlags().BoolVarP(&getArgs.allNamespaces, "all-namespaces", "A", false,
 		"list the requested object(s) across all namespaces")
 	getCmd.PersistentFlags().BoolVarP(&getArgs.noHeader, "no-header", "", false, "skip the header when printing the results")
+	getCmd.PersistentFlags().BoolVar(&getArgs.noHeader, "no-headers", false, "skip the header when printing the results, alias of --no-header")
+	getCmd.PersistentFlags().BoolVar(&getArgs.showLabels, "show-labels", false, "show the labels of the objects as the last column")
+	getCmd.PersistentFlags().StringVarP(&getArgs.output, "output", "o", "",
+		fmt.Sprintf("output format, one of: (%s), defaults to a table", strings.Join(supportedGetOutputs, ", ")))
 	getCmd.PersistentFlags().BoolVarP(&getArgs.watch, "watch", "w", false, "After listing/getting the requested object, watch for changes.")
 	getCmd.PersistentFlags().StringVar(&getArgs.statusSelector, "status-selector", "",
 		"specify the status condition name and the desired state to filter the get result, e.g. ready=false")
+	getCmd.PersistentFlags().BoolVar(&getArgs.omitSuspended, "omit-suspended", false,
+		"hide the suspended objects from the get result")
+	getCmd.PersistentFlags().BoolVar(&getArgs.onlySuspended, "only-suspended", false,
+		"show only the suspended objects in the get result")
+	getCmd.PersistentFlags().BoolVar(&getArgs.age, "age", false,
+		"show the age of the objects and the time since their last reconciliation")
+	getCmd.PersistentFlags().BoolVar(&getArgs.countOnly, "count-only", false,
+		"print only the number of objects per kind, the objects are listed with their metadata only which doesn't include their status")
 	rootCmd.AddCommand(getCmd)
 }
 
@@ -130,13 +164,16 @@ type getCommand struct {
 	apiType
 	list    summarisable
 	funcMap typeMap
+	// filter removes the objects not matching the command specific flags
+	// from the list, it is optional.
+	filter func(ctx context.Context, kubeClient client.Client, list summarisable) error
 }
 
 func (get getCommand) run(cmd *cobra.Command, args []string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(kubeconfigArgs, kubeclientOptions)
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
 	if err != nil {
 		return err
 	}
@@ -152,7 +189,21 @@ func (get getCommand) run(cmd *cobra.Command, args []string) error {
 
 	getAll := cmd.Use == "all"
 
+	if getArgs.omitSuspended && getArgs.onlySuspended {
+		return fmt.Errorf("--omit-suspended and --only-suspended are mutually exclusive")
+	}
+
+	if getArgs.countOnly {
+		if err := validateCountOnly(); err != nil {
+			return err
+		}
+		return get.count(ctx, cmd.OutOrStdout(), kubeClient, getAll, listOpts)
+	}
+
 	if getArgs.watch {
+		if getArgs.output != "" && getArgs.output != getOutputWide {
+			return fmt.Errorf("--output=%s is not supported with --watch", getArgs.output)
+		}
 		return get.watch(ctx, kubeClient, cmd, args, listOpts)
 	}
 
@@ -161,6 +212,16 @@ func (get getCommand) run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if err := filterSuspended(get.list); err != nil {
+		return err
+	}
+
+	if get.filter != nil {
+		if err := get.filter(ctx, kubeClient, get.list); err != nil {
+			return err
+		}
+	}
+
 	if get.list.len() == 0 {
 		if len(args) > 0 {
 			logger.Failuref("%s object '%s' not found in %s namespace",
@@ -177,11 +238,11 @@ func (get getCommand) run(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	var header []string
-	if !getArgs.noHeader {
-		header = get.list.headers(getArgs.allNamespaces)
+	if getArgs.output != "" && getArgs.output != getOutputWide {
+		return printObjects(cmd.OutOrStdout(), get.apiType, get.list.asClientList())
 	}
 
+	header := getHeaders(get.list)
 	rows, err := getRowsToPrint(getAll, get.list)
 	if err != nil {
 		return err
@@ -199,6 +260,67 @@ func (get getCommand) run(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// validateCountOnly returns an error when --count-only is combined with
+// flags which need the spec or the status of the objects.
+func validateCountOnly() error {
+	switch {
+	case getArgs.watch:
+		return fmt.Errorf("--count-only can't be used with --watch")
+	case getArgs.output != "":
+		return fmt.Errorf("--count-only can't be used with --output")
+	case getArgs.statusSelector != "":
+		return fmt.Errorf("--count-only can't be used with --status-selector")
+	case getArgs.omitSuspended || getArgs.onlySuspended:
+		return fmt.Errorf("--count-only can't be used with --omit-suspended and --only-suspended")
+	}
+	return nil
+}
+
+// count prints the number of objects of the kind, per namespace with
+// --all-namespaces. The objects are listed as metadata only, which is
+// cheaper than transferring the whole objects.
+func (get getCommand) count(ctx context.Context, w io.Writer, kubeClient client.Client, getAll bool, listOpts []client.ListOption) error {
+	list := &metav1.PartialObjectMetadataList{}
+	list.SetGroupVersionKind(get.groupVersion.WithKind(get.kind + "List"))
+	if err := kubeClient.List(ctx, list, listOpts...); err != nil {
+		return err
+	}
+
+	var header []string
+	if !getArgs.noHeader {
+		header = []string{"Kind", "Count"}
+		if getArgs.allNamespaces {
+			header = []string{"Kind", "Namespace", "Count"}
+		}
+	}
+
+	var rows [][]string
+	if getArgs.allNamespaces {
+		counts := map[string]int{}
+		for _, item := range list.Items {
+			counts[item.GetNamespace()]++
+		}
+		namespaces := make([]string, 0, len(counts))
+		for namespace := range counts {
+			namespaces = append(namespaces, namespace)
+		}
+		sort.Strings(namespaces)
+		for _, namespace := range namespaces {
+			rows = append(rows, []string{get.kind, namespace, fmt.Sprint(counts[namespace])})
+		}
+	} else {
+		rows = append(rows, []string{get.kind, fmt.Sprint(len(list.Items))})
+	}
+
+	if err := printers.TablePrinter(header).Print(w, rows); err != nil {
+		return err
+	}
+	if getAll {
+		fmt.Fprintln(w)
+	}
+	return nil
+}
+
 func namespaceNameOrAny(allNamespaces bool, namespaceName string) string {
 	if allNamespaces {
 		return "any"
@@ -206,6 +328,195 @@ func namespaceNameOrAny(allNamespaces bool, namespaceName string) string {
 	return fmt.Sprintf("%q", namespaceName)
 }
 
+// getHeaders returns the table headers for the given list, including the
+// columns enabled with --output=wide and --show-labels.
+func getHeaders(list summarisable) []string {
+	if getArgs.noHeader {
+		return nil
+	}
+	header := list.headers(getArgs.allNamespaces)
+	if getArgs.output == getOutputWide {
+		header = append(header, "Age", "Managed-By")
+	}
+	if getArgs.age {
+		if getArgs.output != getOutputWide {
+			header = append(header, "Age")
+		}
+		header = append(header, "Last-Reconciled")
+	}
+	if getArgs.showLabels {
+		header = append(header, "Labels")
+	}
+	return header
+}
+
+// extraColumns returns the values for the columns enabled with
+// --output=wide, --age and --show-labels.
+func extraColumns(obj runtime.Object) []string {
+	var columns []string
+	if getArgs.output != getOutputWide && !getArgs.showLabels && !getArgs.age {
+		return columns
+	}
+	accessor, err := apimeta.Accessor(obj)
+	if err != nil {
+		return columns
+	}
+	age := duration.HumanDuration(time.Since(accessor.GetCreationTimestamp().Time))
+	if getArgs.output == getOutputWide {
+		columns = append(columns, age, managedBy(accessor))
+	}
+	if getArgs.age {
+		if getArgs.output != getOutputWide {
+			columns = append(columns, age)
+		}
+		lastReconciled := "<unknown>"
+		if t, ok := lastReconcileTime(obj); ok {
+			lastReconciled = duration.HumanDuration(time.Since(t))
+		}
+		columns = append(columns, lastReconciled)
+	}
+	if getArgs.showLabels {
+		columns = append(columns, formatLabels(accessor.GetLabels()))
+	}
+	return columns
+}
+
+// lastReconcileTime returns the most recent of the artifact update time, the
+// handled reconcile request and the Ready condition transition of the object.
+func lastReconcileTime(obj runtime.Object) (time.Time, bool) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	var candidates []string
+	if v, ok, _ := unstructured.NestedString(content, "status", "artifact", "lastUpdateTime"); ok {
+		candidates = append(candidates, v)
+	}
+	if v, ok, _ := unstructured.NestedString(content, "status", "lastHandledReconcileAt"); ok {
+		candidates = append(candidates, v)
+	}
+	conditions, _, _ := unstructured.NestedSlice(content, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok || condition["type"] != meta.ReadyCondition {
+			continue
+		}
+		if v, ok := condition["lastTransitionTime"].(string); ok {
+			candidates = append(candidates, v)
+		}
+	}
+
+	var latest time.Time
+	for _, v := range candidates {
+		// lastHandledReconcileAt is an opaque token, it is only taken
+		// into account when it holds a timestamp
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil && t.After(latest) {
+			latest = t
+		}
+	}
+	return latest, !latest.IsZero()
+}
+
+// kustomizationManager returns the name and namespace of the Kustomization
+// that applied the object, based on the labels set by kustomize-controller.
+func kustomizationManager(obj metav1.Object) (string, string, bool) {
+	labels := obj.GetLabels()
+	name := labels[kustomizev1.GroupVersion.Group+"/name"]
+	if name == "" {
+		return "", "", false
+	}
+	return name, labels[kustomizev1.GroupVersion.Group+"/namespace"], true
+}
+
+// managedBy returns the Flux Kustomization or the controller owner that
+// manages the object, or '<none>' for objects created outside of GitOps.
+func managedBy(obj metav1.Object) string {
+	if name, namespace, ok := kustomizationManager(obj); ok {
+		return fmt.Sprintf("%s/%s/%s", kustomizev1.KustomizationKind, namespace, name)
+	}
+	if owner := metav1.GetControllerOf(obj); owner != nil {
+		return fmt.Sprintf("%s/%s", owner.Kind, owner.Name)
+	}
+	return "<none>"
+}
+
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return "<none>"
+	}
+	pairs := make([]string, 0, len(labels))
+	for k, v := range labels {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// printObjects prints the list with the cli-runtime printer of the given
+// --output format.
+func printObjects(w io.Writer, t apiType, list client.ObjectList) error {
+	var printer cliprinters.ResourcePrinter
+	switch getArgs.output {
+	case getOutputJSON:
+		printer = &cliprinters.JSONPrinter{}
+	case getOutputYAML:
+		printer = &cliprinters.YAMLPrinter{}
+	case getOutputName:
+		printer = &cliprinters.NamePrinter{}
+	default:
+		return fmt.Errorf("unsupported output format '%s', must be one of: %s",
+			getArgs.output, strings.Join(supportedGetOutputs, ", "))
+	}
+
+	// The typed client does not set the type information of the items,
+	// which the printers rely on.
+	items, err := apimeta.ExtractList(list)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		item.GetObjectKind().SetGroupVersionKind(t.groupVersion.WithKind(t.kind))
+	}
+	if err := apimeta.SetList(list, items); err != nil {
+		return err
+	}
+	list.GetObjectKind().SetGroupVersionKind(schema.GroupVersionKind{Version: "v1", Kind: "List"})
+	if getArgs.output == getOutputName {
+		for _, item := range items {
+			if err := printer.PrintObj(item, w); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return printer.PrintObj(list, w)
+}
+
+// filterSuspended removes the items from the list according to the
+// --omit-suspended and --only-suspended flags. Objects of kinds which can't
+// be suspended are considered not suspended.
+func filterSuspended(list summarisable) error {
+	if !getArgs.omitSuspended && !getArgs.onlySuspended {
+		return nil
+	}
+	items, err := apimeta.ExtractList(list.asClientList())
+	if err != nil {
+		return err
+	}
+	var filtered []runtime.Object
+	for i, item := range items {
+		suspended := false
+		if s, ok := list.(listSuspendable); ok {
+			suspended = s.item(i).isSuspended()
+		}
+		if suspended == getArgs.onlySuspended {
+			filtered = append(filtered, item)
+		}
+	}
+	return apimeta.SetList(list.asClientList(), filtered)
+}
+
 func getRowsToPrint(getAll bool, list summarisable) ([][]string, error) {
 	noFilter := true
 	var conditionType, conditionStatus string
@@ -218,10 +529,17 @@ func getRowsToPrint(getAll bool, list summarisable) ([][]string, error) {
 		conditionStatus = parts[1]
 		noFilter = false
 	}
+	items, err := apimeta.ExtractList(list.asClientList())
+	if err != nil {
+		return nil, err
+	}
 	var rows [][]string
 	for i := 0; i < list.len(); i++ {
 		if noFilter || list.statusSelectorMatches(i, conditionType, conditionStatus) {
 			row := list.summariseItem(i, getArgs.allNamespaces, getAll)
+			if i < len(items) {
+				row = append(row, extraColumns(items[i])...)
+			}
 			rows = append(rows, row)
 		}
 	}
@@ -251,11 +569,14 @@ func watchUntil(ctx context.Context, w watch.Interface, get *getCommand) (bool,
 		if err != nil {
 			return false, err
 		}
-
-		var header []string
-		if !getArgs.noHeader {
-			header = sink.headers(getArgs.allNamespaces)
+		if err := filterSuspended(sink); err != nil {
+			return false, err
+		}
+		if sink.len() == 0 {
+			return false, nil
 		}
+
+		header := getHeaders(sink)
 		rows, err := getRowsToPrint(false, sink)
 		if err != nil {
 			return false, err
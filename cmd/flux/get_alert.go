@@ -17,14 +17,21 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"strings"
 
 	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	notificationv1 "github.com/fluxcd/notification-controller/api/v1beta2"
+
+	"github.com/fluxcd/flux2/internal/utils"
 )
 
 var getAlertCmd = &cobra.Command{
@@ -33,7 +40,13 @@ var getAlertCmd = &cobra.Command{
 	Short:   "Get Alert statuses",
 	Long:    "The get alert command prints the statuses of the resources.",
 	Example: `  # List all Alerts and their status
-  flux get alerts`,
+  flux get alerts
+
+  # List all Alerts with their provider, severity and event sources
+  flux get alerts --show-event-sources
+
+  # List the Alerts notifying about the events of a Kustomization
+  flux get alerts --for=Kustomization/apps`,
 	ValidArgsFunction: resourceNamesCompletionFunc(notificationv1.GroupVersion.WithKind(notificationv1.AlertKind)),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		get := getCommand{
@@ -42,6 +55,19 @@ var getAlertCmd = &cobra.Command{
 			funcMap: make(typeMap),
 		}
 
+		if getAlertArgs.forObject != "" {
+			kind, name := utils.ParseObjectKindName(getAlertArgs.forObject)
+			if kind == "" || name == "" {
+				return fmt.Errorf("invalid --for %q, expected the <kind>/<name> format", getAlertArgs.forObject)
+			}
+			if getArgs.watch || getArgs.countOnly {
+				return fmt.Errorf("--for can't be used with --watch and --count-only")
+			}
+			get.filter = func(ctx context.Context, kubeClient client.Client, list summarisable) error {
+				return filterAlertsFor(ctx, kubeClient, list.(*alertListAdapter).AlertList, kind, name, *kubeconfigArgs.Namespace)
+			}
+		}
+
 		err := get.funcMap.registerCommand(get.apiType.kind, func(obj runtime.Object) (summarisable, error) {
 			o, ok := obj.(*notificationv1.Alert)
 			if !ok {
@@ -70,18 +96,152 @@ var getAlertCmd = &cobra.Command{
 	},
 }
 
+type getAlertFlags struct {
+	showEventSources bool
+	forObject        string
+}
+
+var getAlertArgs getAlertFlags
+
 func init() {
+	getAlertCmd.Flags().BoolVar(&getAlertArgs.showEventSources, "show-event-sources", false,
+		"show the provider, the event severity and the event sources of the alerts, enabled by --output=wide")
+	getAlertCmd.Flags().StringVar(&getAlertArgs.forObject, "for", "",
+		"list only the alerts notifying about the events of the object in the <kind>/<name> format, the object is looked up in the --namespace")
 	getCmd.AddCommand(getAlertCmd)
 }
 
+// showAlertEventSources returns true if the Provider, Severity and
+// Event-Sources columns are included in the table.
+func showAlertEventSources() bool {
+	return getAlertArgs.showEventSources || getArgs.output == getOutputWide
+}
+
+// alertEventSources returns the event sources of the Alert, the namespace
+// is only included when it differs from the Alert.
+func alertEventSources(item notificationv1.Alert) string {
+	sources := make([]string, 0, len(item.Spec.EventSources))
+	for _, src := range item.Spec.EventSources {
+		source := fmt.Sprintf("%s/%s", src.Kind, src.Name)
+		if src.Namespace != "" && src.Namespace != item.Namespace {
+			source = fmt.Sprintf("%s/%s/%s", src.Kind, src.Namespace, src.Name)
+		}
+		if len(src.MatchLabels) > 0 {
+			source = fmt.Sprintf("%s(%s)", source, formatLabels(src.MatchLabels))
+		}
+		sources = append(sources, source)
+	}
+	return strings.Join(sources, ", ")
+}
+
+// alertSeverity returns the event severity of the Alert, which defaults
+// to info.
+func alertSeverity(item notificationv1.Alert) string {
+	if item.Spec.EventSeverity == "" {
+		return "info"
+	}
+	return item.Spec.EventSeverity
+}
+
+// filterAlertsFor removes the suspended Alerts and the ones without an event
+// source matching the object from the list. The labels of the object are
+// only looked up if an event source selects the objects by labels.
+func filterAlertsFor(ctx context.Context, kubeClient client.Client, list *notificationv1.AlertList, kind, name, namespace string) error {
+	var objLabels map[string]string
+	getLabels := func() (map[string]string, error) {
+		if objLabels != nil {
+			return objLabels, nil
+		}
+		gvk, ok := fluxKindGVK(kind)
+		if !ok {
+			return nil, fmt.Errorf("unsupported kind %q", kind)
+		}
+		obj := &metav1.PartialObjectMetadata{}
+		obj.SetGroupVersionKind(gvk)
+		if err := kubeClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, obj); err != nil {
+			return nil, fmt.Errorf("failed to get %s/%s in %s namespace: %w", kind, name, namespace, err)
+		}
+		objLabels = obj.GetLabels()
+		if objLabels == nil {
+			objLabels = map[string]string{}
+		}
+		return objLabels, nil
+	}
+
+	var items []notificationv1.Alert
+	for _, item := range list.Items {
+		if item.Spec.Suspend {
+			continue
+		}
+		ok, err := alertMatches(item, kind, name, namespace, getLabels)
+		if err != nil {
+			return err
+		}
+		if ok {
+			items = append(items, item)
+		}
+	}
+	list.Items = items
+	return nil
+}
+
+// alertMatches returns true if one of the event sources of the Alert matches
+// the object, the same way notification-controller matches the events.
+func alertMatches(alert notificationv1.Alert, kind, name, namespace string, getLabels func() (map[string]string, error)) (bool, error) {
+	for _, src := range alert.Spec.EventSources {
+		srcNamespace := src.Namespace
+		if srcNamespace == "" {
+			srcNamespace = alert.Namespace
+		}
+		if src.Kind != kind || srcNamespace != namespace {
+			continue
+		}
+		if src.Name == name {
+			return true, nil
+		}
+		if src.Name != "*" {
+			continue
+		}
+		if len(src.MatchLabels) == 0 {
+			return true, nil
+		}
+		objLabels, err := getLabels()
+		if err != nil {
+			return false, err
+		}
+		if labels.SelectorFromSet(src.MatchLabels).Matches(labels.Set(objLabels)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// fluxKindGVK returns the group, version and kind of the Flux kind from
+// the scheme of the CLI.
+func fluxKindGVK(kind string) (schema.GroupVersionKind, bool) {
+	for gvk := range utils.NewScheme().AllKnownTypes() {
+		if gvk.Kind == kind && strings.HasSuffix(gvk.Group, ".fluxcd.io") {
+			return gvk, true
+		}
+	}
+	return schema.GroupVersionKind{}, false
+}
+
 func (s alertListAdapter) summariseItem(i int, includeNamespace bool, includeKind bool) []string {
 	item := s.Items[i]
 	status, msg := statusAndMessage(item.Status.Conditions)
-	return append(nameColumns(&item, includeNamespace, includeKind), strings.Title(strconv.FormatBool(item.Spec.Suspend)), status, msg)
+	row := append(nameColumns(&item, includeNamespace, includeKind), strings.Title(strconv.FormatBool(item.Spec.Suspend)), status, msg)
+	if showAlertEventSources() {
+		row = append(row, item.Spec.ProviderRef.Name, alertSeverity(item), alertEventSources(item))
+	}
+	return row
 }
 
 func (s alertListAdapter) headers(includeNamespace bool) []string {
 	headers := []string{"Name", "Suspended", "Ready", "Message"}
+	if showAlertEventSources() {
+		headers = append(headers, "Provider", "Severity", "Event-Sources")
+	}
 	if includeNamespace {
 		return append(namespaceHeader, headers...)
 	}
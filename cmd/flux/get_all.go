@@ -34,7 +34,10 @@ var getAllCmd = &cobra.Command{
   flux get all --namespace=flux-system
 
   # List all resources in all namespaces
-  flux get all --all-namespaces`,
+  flux get all --all-namespaces
+
+  # Count the resources of each kind per namespace
+  flux get all --all-namespaces --count-only`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		err := validateWatchOption(cmd, "all")
 		if err != nil {
@@ -35,7 +35,10 @@ var getKsCmd = &cobra.Command{
 	Short:   "Get Kustomization statuses",
 	Long:    "The get kustomizations command prints the statuses of the resources.",
 	Example: `  # List all kustomizations and their status
-  flux get kustomizations`,
+  flux get kustomizations
+
+  # List all kustomizations with the source and path they deploy from
+  flux get kustomizations --show-path-and-source`,
 	ValidArgsFunction: resourceNamesCompletionFunc(kustomizev1.GroupVersion.WithKind(kustomizev1.KustomizationKind)),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		get := getCommand{
@@ -72,22 +75,57 @@ var getKsCmd = &cobra.Command{
 	},
 }
 
+type getKsFlags struct {
+	showPathAndSource bool
+}
+
+var getKsArgs getKsFlags
+
 func init() {
+	getKsCmd.Flags().BoolVar(&getKsArgs.showPathAndSource, "show-path-and-source", false,
+		"show the source reference and path of the kustomizations, enabled by --output=wide")
 	getCmd.AddCommand(getKsCmd)
 }
 
+// showKsPathAndSource returns true if the Source and Path columns are
+// included in the table.
+func showKsPathAndSource() bool {
+	return getKsArgs.showPathAndSource || getArgs.output == getOutputWide
+}
+
+// kustomizationSource returns the source reference of the Kustomization,
+// the namespace is only included when it differs from the Kustomization.
+func kustomizationSource(item kustomizev1.Kustomization) string {
+	ref := item.Spec.SourceRef
+	if ref.Namespace != "" && ref.Namespace != item.Namespace {
+		return fmt.Sprintf("%s/%s/%s", ref.Kind, ref.Namespace, ref.Name)
+	}
+	return fmt.Sprintf("%s/%s", ref.Kind, ref.Name)
+}
+
 func (a kustomizationListAdapter) summariseItem(i int, includeNamespace bool, includeKind bool) []string {
 	item := a.Items[i]
 	revision := item.Status.LastAppliedRevision
 	status, msg := statusAndMessage(item.Status.Conditions)
 	revision = utils.TruncateHex(revision)
 	msg = utils.TruncateHex(msg)
-	return append(nameColumns(&item, includeNamespace, includeKind),
+	row := append(nameColumns(&item, includeNamespace, includeKind),
 		revision, strings.Title(strconv.FormatBool(item.Spec.Suspend)), status, msg)
+	if showKsPathAndSource() {
+		path := item.Spec.Path
+		if path == "" {
+			path = "./"
+		}
+		row = append(row, kustomizationSource(item), path)
+	}
+	return row
 }
 
 func (a kustomizationListAdapter) headers(includeNamespace bool) []string {
 	headers := []string{"Name", "Revision", "Suspended", "Ready", "Message"}
+	if showKsPathAndSource() {
+		headers = append(headers, "Source", "Path")
+	}
 	if includeNamespace {
 		headers = append([]string{"Namespace"}, headers...)
 	}
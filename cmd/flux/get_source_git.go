@@ -37,9 +37,16 @@ var getSourceGitCmd = &cobra.Command{
   flux get sources git
 
  # List Git repositories from all namespaces
-  flux get sources git --all-namespaces`,
+  flux get sources git --all-namespaces
+
+  # List Git repositories of which the artifact is behind the remote branch
+  flux get sources git --revision-drift`,
 	ValidArgsFunction: resourceNamesCompletionFunc(sourcev1.GroupVersion.WithKind(sourcev1.GitRepositoryKind)),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if getSourceGitArgs.revisionDrift {
+			return getSourceGitDriftRun(cmd, args)
+		}
+
 		get := getCommand{
 			apiType: gitRepositoryType,
 			list:    &gitRepositoryListAdapter{&sourcev1.GitRepositoryList{}},
@@ -71,7 +78,15 @@ var getSourceGitCmd = &cobra.Command{
 	},
 }
 
+type getSourceGitFlags struct {
+	revisionDrift bool
+}
+
+var getSourceGitArgs getSourceGitFlags
+
 func init() {
+	getSourceGitCmd.Flags().BoolVar(&getSourceGitArgs.revisionDrift, "revision-drift", false,
+		"query the remote repositories and list the sources of which the artifact revision differs from the HEAD of the tracked branch")
 	getSourceCmd.AddCommand(getSourceGitCmd)
 }
 
@@ -0,0 +1,243 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	extgogit "github.com/fluxcd/go-git/v5"
+	"github.com/fluxcd/go-git/v5/config"
+	"github.com/fluxcd/go-git/v5/plumbing"
+	"github.com/fluxcd/go-git/v5/plumbing/transport"
+	"github.com/fluxcd/go-git/v5/plumbing/transport/http"
+	"github.com/fluxcd/go-git/v5/plumbing/transport/ssh"
+	"github.com/fluxcd/go-git/v5/storage/memory"
+	"github.com/spf13/cobra"
+	gossh "golang.org/x/crypto/ssh"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/fluxcd/pkg/git"
+	"github.com/fluxcd/pkg/ssh/knownhosts"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+
+	"github.com/fluxcd/flux2/internal/utils"
+	"github.com/fluxcd/flux2/pkg/printers"
+)
+
+// defaultGitRepositoryBranch is the branch checked out by source-controller
+// when no reference is specified.
+const defaultGitRepositoryBranch = "master"
+
+// getSourceGitDriftRun lists the GitRepository sources of which the artifact
+// revision differs from the HEAD of the tracked branch in the remote
+// repository.
+func getSourceGitDriftRun(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
+	if err != nil {
+		return err
+	}
+
+	var listOpts []client.ListOption
+	if !getArgs.allNamespaces {
+		listOpts = append(listOpts, client.InNamespace(*kubeconfigArgs.Namespace))
+	}
+	if len(args) > 0 {
+		listOpts = append(listOpts, client.MatchingFields{"metadata.name": args[0]})
+	}
+
+	var list sourcev1.GitRepositoryList
+	if err := kubeClient.List(ctx, &list, listOpts...); err != nil {
+		return err
+	}
+	if len(list.Items) == 0 {
+		logger.Failuref("no %s objects found in %s namespace", sourcev1.GitRepositoryKind,
+			namespaceNameOrAny(getArgs.allNamespaces, *kubeconfigArgs.Namespace))
+		return nil
+	}
+
+	var rows [][]string
+	for _, repository := range list.Items {
+		branch := trackedBranch(repository)
+		if branch == "" {
+			logger.Warningf("skipping %s/%s, revision drift can only be detected for branches",
+				repository.Namespace, repository.Name)
+			continue
+		}
+
+		remote, err := remoteBranchHead(ctx, kubeClient, repository, branch)
+		if err != nil {
+			logger.Failuref("failed to query remote of %s/%s: %s", repository.Namespace, repository.Name, err.Error())
+			continue
+		}
+
+		var revision string
+		if artifact := repository.GetArtifact(); artifact != nil {
+			revision = sourcev1.TransformLegacyRevision(artifact.Revision)
+		}
+		if revision == fmt.Sprintf("%s@sha1:%s", branch, remote) {
+			continue
+		}
+
+		row := []string{repository.Name, branch, utils.TruncateHex(revision), utils.TruncateHex("sha1:" + remote)}
+		if getArgs.allNamespaces {
+			row = append([]string{repository.Namespace}, row...)
+		}
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 {
+		logger.Successf("no revision drift found")
+		return nil
+	}
+
+	var header []string
+	if !getArgs.noHeader {
+		header = []string{"Name", "Branch", "Revision", "Remote revision"}
+		if getArgs.allNamespaces {
+			header = append(namespaceHeader, header...)
+		}
+	}
+	return printers.TablePrinter(header).Print(cmd.OutOrStdout(), rows)
+}
+
+// trackedBranch returns the branch the GitRepository tracks, or an empty
+// string if the reference is pinned to a tag, semver range or commit.
+func trackedBranch(repository sourcev1.GitRepository) string {
+	ref := repository.Spec.Reference
+	if ref == nil {
+		return defaultGitRepositoryBranch
+	}
+	if ref.Tag != "" || ref.SemVer != "" || ref.Commit != "" {
+		return ""
+	}
+	if ref.Branch == "" {
+		return defaultGitRepositoryBranch
+	}
+	return ref.Branch
+}
+
+// remoteBranchHead lists the references of the remote repository, using the
+// credentials of the secret referenced by the GitRepository, and returns the
+// commit the given branch points to.
+func remoteBranchHead(ctx context.Context, kubeClient client.Client, repository sourcev1.GitRepository, branch string) (string, error) {
+	auth, caBundle, err := gitRepositoryAuth(ctx, kubeClient, repository)
+	if err != nil {
+		return "", err
+	}
+
+	remote := extgogit.NewRemote(memory.NewStorage(), &config.RemoteConfig{
+		Name: git.DefaultRemote,
+		URLs: []string{repository.Spec.URL},
+	})
+	refs, err := remote.ListContext(ctx, &extgogit.ListOptions{
+		Auth:     auth,
+		CABundle: caBundle,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	name := plumbing.NewBranchReferenceName(branch)
+	for _, ref := range refs {
+		if ref.Name() == name {
+			return ref.Hash().String(), nil
+		}
+	}
+	return "", fmt.Errorf("branch '%s' not found", strings.TrimPrefix(name.String(), "refs/heads/"))
+}
+
+// gitRepositoryAuth returns the transport auth method and CA bundle for the
+// remote of the GitRepository, using the credentials of its secret.
+func gitRepositoryAuth(ctx context.Context, kubeClient client.Client, repository sourcev1.GitRepository) (transport.AuthMethod, []byte, error) {
+	u, err := url.Parse(repository.Spec.URL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid URL: %w", err)
+	}
+
+	var data map[string][]byte
+	if ref := repository.Spec.SecretRef; ref != nil {
+		var secret corev1.Secret
+		if err := kubeClient.Get(ctx, client.ObjectKey{Namespace: repository.Namespace, Name: ref.Name}, &secret); err != nil {
+			return nil, nil, fmt.Errorf("failed to get secret '%s': %w", ref.Name, err)
+		}
+		data = secret.Data
+	}
+
+	authOpts, err := git.NewAuthOptions(*u, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	auth, err := remoteAuth(authOpts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return auth, authOpts.CAFile, nil
+}
+
+func remoteAuth(opts *git.AuthOptions) (transport.AuthMethod, error) {
+	switch opts.Transport {
+	case git.HTTPS, git.HTTP:
+		if opts.Username != "" || opts.Password != "" {
+			return &http.BasicAuth{Username: opts.Username, Password: opts.Password}, nil
+		}
+		if opts.BearerToken != "" {
+			return &http.TokenAuth{Token: opts.BearerToken}, nil
+		}
+		return nil, nil
+	case git.SSH:
+		if len(opts.Identity) == 0 {
+			return ssh.DefaultAuthBuilder(opts.Username)
+		}
+		pk, err := ssh.NewPublicKeys(opts.Username, opts.Identity, opts.Password)
+		if err != nil {
+			return nil, err
+		}
+		if len(opts.KnownHosts) == 0 {
+			return pk, nil
+		}
+		callback, err := knownhosts.New(opts.KnownHosts)
+		if err != nil {
+			return nil, err
+		}
+		return &knownHostsPublicKeys{PublicKeys: pk, callback: callback}, nil
+	default:
+		return nil, fmt.Errorf("unsupported transport '%s'", opts.Transport)
+	}
+}
+
+// knownHostsPublicKeys verifies the host key of the remote against the
+// known_hosts of the source secret.
+type knownHostsPublicKeys struct {
+	*ssh.PublicKeys
+	callback gossh.HostKeyCallback
+}
+
+func (a *knownHostsPublicKeys) ClientConfig() (*gossh.ClientConfig, error) {
+	config, err := a.PublicKeys.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	config.HostKeyCallback = a.callback
+	return config, nil
+}
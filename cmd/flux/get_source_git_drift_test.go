@@ -0,0 +1,193 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cgi"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	extgogit "github.com/fluxcd/go-git/v5"
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/fluxcd/pkg/git"
+	"github.com/fluxcd/pkg/git/gogit"
+	"github.com/fluxcd/pkg/git/repository"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/fluxcd/flux2/internal/utils"
+)
+
+func TestRemoteBranchHead(t *testing.T) {
+	repoURL, caFile, head := newBasicAuthGitServer(t, "git", "s3cr3t")
+	kubeClient := fake.NewClientBuilder().WithScheme(utils.NewScheme()).WithObjects(
+		newGitCredentials("valid", "s3cr3t", caFile),
+		newGitCredentials("invalid", "wrong", caFile),
+	).Build()
+
+	tests := []struct {
+		name    string
+		secret  string
+		branch  string
+		wantErr string
+	}{
+		{name: "valid credentials", secret: "valid", branch: "master"},
+		{name: "wrong password", secret: "invalid", branch: "master", wantErr: "authentication required"},
+		{name: "secret not found", secret: "missing", branch: "master", wantErr: "failed to get secret 'missing'"},
+		{name: "branch not found", secret: "valid", branch: "dev", wantErr: "branch 'dev' not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := remoteBranchHead(context.TODO(), kubeClient, *newDriftGitRepository("podinfo", repoURL, tt.secret, ""), tt.branch)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != head {
+				t.Errorf("remoteBranchHead() = %q, want %q", got, head)
+			}
+		})
+	}
+}
+
+func TestGetSourceGitRevisionDrift(t *testing.T) {
+	isolateEnv(t)
+	repoURL, caFile, head := newBasicAuthGitServer(t, "git", "s3cr3t")
+	useFakeCluster(t,
+		newGitCredentials("valid", "s3cr3t", caFile),
+		newGitCredentials("invalid", "wrong", caFile),
+		newDriftGitRepository("synced", repoURL, "valid", "master@sha1:"+head),
+		newDriftGitRepository("behind", repoURL, "valid", "master@sha1:5a2d8a5e1f0a9c8b7d6e5f4a3b2c1d0e9f8a7b6c"),
+		newDriftGitRepository("unauthorized", repoURL, "invalid", "master@sha1:"+head),
+	)
+
+	cmd := cmdTestCase{
+		args: "get sources git --revision-drift -n flux-system",
+		assert: func(output string, err error) error {
+			if err != nil {
+				return fmt.Errorf("unexpected error: %w", err)
+			}
+			if !strings.Contains(output, "failed to query remote of flux-system/unauthorized: authentication required") {
+				return fmt.Errorf("expected the authentication error of unauthorized, got:\n%s", output)
+			}
+			if !strings.Contains(output, "behind") || !strings.Contains(output, utils.TruncateHex("sha1:"+head)) {
+				return fmt.Errorf("expected behind to drift from %s, got:\n%s", head, output)
+			}
+			if strings.Contains(output, "synced") {
+				return fmt.Errorf("expected synced not to drift, got:\n%s", output)
+			}
+			return nil
+		},
+	}
+	cmd.runTestCmd(t)
+}
+
+func newGitCredentials(name, password, caFile string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "flux-system"},
+		Data: map[string][]byte{
+			"username": []byte("git"),
+			"password": []byte(password),
+			"caFile":   []byte(caFile),
+		},
+	}
+}
+
+func newDriftGitRepository(name, url, secret, revision string) *sourcev1.GitRepository {
+	repository := &sourcev1.GitRepository{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "flux-system"},
+		Spec: sourcev1.GitRepositorySpec{
+			URL:       url,
+			SecretRef: &meta.LocalObjectReference{Name: secret},
+		},
+	}
+	if revision != "" {
+		repository.Status.Artifact = &sourcev1.Artifact{Revision: revision}
+	}
+	return repository
+}
+
+// newBasicAuthGitServer serves a repository with a single commit on the
+// master branch over HTTPS, with git http-backend behind basic auth. It
+// returns the URL of the repository, the PEM encoded certificate of the
+// server and the commit.
+func newBasicAuthGitServer(t *testing.T, username, password string) (string, string, string) {
+	t.Helper()
+	execPath, err := exec.Command("git", "--exec-path").Output()
+	if err != nil {
+		t.Skip("git is required to serve the test repository")
+	}
+	backend := filepath.Join(strings.TrimSpace(string(execPath)), "git-http-backend")
+	if _, err := os.Stat(backend); err != nil {
+		t.Skip("git http-backend is required to serve the test repository")
+	}
+
+	root := t.TempDir()
+	remote := filepath.Join(root, "repo.git")
+	if _, err := extgogit.PlainInit(remote, true); err != nil {
+		t.Fatal(err)
+	}
+	seed, err := gogit.NewClient(t.TempDir(), &git.AuthOptions{Transport: git.HTTP}, gogit.WithDiskStorage())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := seed.Init(context.TODO(), remote, "master"); err != nil {
+		t.Fatal(err)
+	}
+	head, err := seed.Commit(git.Commit{
+		Author:  git.Signature{Name: "Flux", Email: "flux@example.com"},
+		Message: "Initial commit",
+	}, repository.WithFiles(map[string]io.Reader{"README.md": strings.NewReader("flux")}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := seed.Push(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+
+	handler := &cgi.Handler{
+		Path: backend,
+		Env:  []string{"GIT_PROJECT_ROOT=" + root, "GIT_HTTP_EXPORT_ALL=1"},
+	}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if u, p, ok := r.BasicAuth(); !ok || u != username || p != password {
+			w.Header().Set("WWW-Authenticate", `Basic realm="git"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	caFile := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	return server.URL + "/repo.git", string(caFile), head
+}
@@ -0,0 +1,356 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	"github.com/fluxcd/pkg/apis/meta"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+)
+
+func TestPrintObjects(t *testing.T) {
+	tests := []struct {
+		output string
+		want   string
+	}{
+		{
+			output: "name",
+			want:   "gitrepository.source.toolkit.fluxcd.io/podinfo\n",
+		},
+		{
+			output: "yaml",
+			want: `apiVersion: v1
+items:
+- apiVersion: source.toolkit.fluxcd.io/v1beta2
+  kind: GitRepository
+  metadata:
+    creationTimestamp: null
+    labels:
+      app: podinfo
+    name: podinfo
+    namespace: flux-system
+  spec:
+    interval: 0s
+    url: ""
+  status: {}
+kind: List
+metadata: {}
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.output, func(t *testing.T) {
+			getArgs = GetFlags{output: tt.output}
+			defer func() { getArgs = GetFlags{} }()
+
+			list := &sourcev1.GitRepositoryList{
+				Items: []sourcev1.GitRepository{
+					{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      "podinfo",
+							Namespace: "flux-system",
+							Labels:    map[string]string{"app": "podinfo"},
+						},
+					},
+				},
+			}
+			var buf bytes.Buffer
+			if err := printObjects(&buf, gitRepositoryType, list); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("printObjects() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatLabels(t *testing.T) {
+	if got := formatLabels(nil); got != "<none>" {
+		t.Errorf("formatLabels() = %s, want <none>", got)
+	}
+	if got := formatLabels(map[string]string{"b": "2", "a": "1"}); got != "a=1,b=2" {
+		t.Errorf("formatLabels() = %s, want a=1,b=2", got)
+	}
+}
+
+func TestManagedBy(t *testing.T) {
+	isController := true
+	tests := []struct {
+		name string
+		meta metav1.ObjectMeta
+		want string
+	}{
+		{
+			name: "created manually",
+			meta: metav1.ObjectMeta{Name: "podinfo"},
+			want: "<none>",
+		},
+		{
+			name: "managed by kustomization",
+			meta: metav1.ObjectMeta{
+				Name: "podinfo",
+				Labels: map[string]string{
+					"kustomize.toolkit.fluxcd.io/name":      "apps",
+					"kustomize.toolkit.fluxcd.io/namespace": "flux-system",
+				},
+			},
+			want: "Kustomization/flux-system/apps",
+		},
+		{
+			name: "owned by controller",
+			meta: metav1.ObjectMeta{
+				Name: "default-podinfo",
+				OwnerReferences: []metav1.OwnerReference{
+					{Kind: "HelmRelease", Name: "podinfo", Controller: &isController},
+				},
+			},
+			want: "HelmRelease/podinfo",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &sourcev1.GitRepository{ObjectMeta: tt.meta}
+			if got := managedBy(repo); got != tt.want {
+				t.Errorf("managedBy() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLastReconcileTime(t *testing.T) {
+	older := metav1.NewTime(time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC))
+	newer := metav1.NewTime(time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC))
+	tests := []struct {
+		name   string
+		status sourcev1.GitRepositoryStatus
+		want   time.Time
+		found  bool
+	}{
+		{
+			name: "never reconciled",
+		},
+		{
+			name: "artifact newer than ready condition",
+			status: sourcev1.GitRepositoryStatus{
+				Artifact: &sourcev1.Artifact{LastUpdateTime: newer},
+				Conditions: []metav1.Condition{
+					{Type: meta.ReadyCondition, Status: metav1.ConditionTrue, LastTransitionTime: older},
+				},
+			},
+			want:  newer.Time,
+			found: true,
+		},
+		{
+			name: "handled reconcile request",
+			status: sourcev1.GitRepositoryStatus{
+				ReconcileRequestStatus: meta.ReconcileRequestStatus{
+					LastHandledReconcileAt: newer.Format(time.RFC3339Nano),
+				},
+				Conditions: []metav1.Condition{
+					{Type: meta.ReadyCondition, Status: metav1.ConditionTrue, LastTransitionTime: older},
+				},
+			},
+			want:  newer.Time,
+			found: true,
+		},
+		{
+			name: "opaque reconcile token",
+			status: sourcev1.GitRepositoryStatus{
+				ReconcileRequestStatus: meta.ReconcileRequestStatus{
+					LastHandledReconcileAt: "now",
+				},
+				Conditions: []metav1.Condition{
+					{Type: meta.ReadyCondition, Status: metav1.ConditionTrue, LastTransitionTime: older},
+				},
+			},
+			want:  older.Time,
+			found: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &sourcev1.GitRepository{Status: tt.status}
+			got, found := lastReconcileTime(repo)
+			if found != tt.found || !got.Equal(tt.want) {
+				t.Errorf("lastReconcileTime() = %v, %v, want %v, %v", got, found, tt.want, tt.found)
+			}
+		})
+	}
+}
+
+func TestFilterSuspended(t *testing.T) {
+	tests := []struct {
+		name string
+		args GetFlags
+		want []string
+	}{
+		{
+			name: "no filter",
+			want: []string{"podinfo", "redis"},
+		},
+		{
+			name: "omit suspended",
+			args: GetFlags{omitSuspended: true},
+			want: []string{"podinfo"},
+		},
+		{
+			name: "only suspended",
+			args: GetFlags{onlySuspended: true},
+			want: []string{"redis"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getArgs = tt.args
+			defer func() { getArgs = GetFlags{} }()
+
+			list := &gitRepositoryListAdapter{&sourcev1.GitRepositoryList{
+				Items: []sourcev1.GitRepository{
+					{ObjectMeta: metav1.ObjectMeta{Name: "podinfo"}},
+					{ObjectMeta: metav1.ObjectMeta{Name: "redis"}, Spec: sourcev1.GitRepositorySpec{Suspend: true}},
+				},
+			}}
+			if err := filterSuspended(list); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			var got []string
+			for _, item := range list.Items {
+				got = append(got, item.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filterSuspended() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKustomizationPathAndSourceColumns(t *testing.T) {
+	getKsArgs = getKsFlags{showPathAndSource: true}
+	defer func() { getKsArgs = getKsFlags{} }()
+
+	list := kustomizationListAdapter{&kustomizev1.KustomizationList{
+		Items: []kustomizev1.Kustomization{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "apps", Namespace: "flux-system"},
+				Spec: kustomizev1.KustomizationSpec{
+					Path:      "./apps/production",
+					SourceRef: kustomizev1.CrossNamespaceSourceReference{Kind: "GitRepository", Name: "flux-system"},
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "podinfo", Namespace: "apps"},
+				Spec: kustomizev1.KustomizationSpec{
+					SourceRef: kustomizev1.CrossNamespaceSourceReference{Kind: "OCIRepository", Name: "podinfo", Namespace: "flux-system"},
+				},
+			},
+		},
+	}}
+
+	headers := list.headers(false)
+	if got := strings.Join(headers[len(headers)-2:], ","); got != "Source,Path" {
+		t.Errorf("unexpected headers %v", headers)
+	}
+	tests := []string{
+		"GitRepository/flux-system,./apps/production",
+		"OCIRepository/flux-system/podinfo,./",
+	}
+	for i, want := range tests {
+		row := list.summariseItem(i, false, false)
+		if got := strings.Join(row[len(row)-2:], ","); got != want {
+			t.Errorf("summariseItem(%d) = %v, want columns %s", i, row, want)
+		}
+	}
+}
+
+func TestGetCmd(t *testing.T) {
+	runFakeCmdTests(t, []fakeCmdTestCase{
+		{
+			name:       "kustomizations",
+			args:       "get kustomizations -n flux-system",
+			objectFile: "testdata/fake/objects.yaml",
+			goldenFile: "testdata/fake/get_kustomizations.golden",
+		},
+		{
+			name:       "kustomizations with path and source",
+			args:       "get kustomizations -n flux-system --show-path-and-source",
+			objectFile: "testdata/fake/objects.yaml",
+			goldenFile: "testdata/fake/get_kustomizations_path_source.golden",
+		},
+		{
+			name:       "suspended kustomizations",
+			args:       "get kustomizations -n flux-system --only-suspended",
+			objectFile: "testdata/fake/objects.yaml",
+			goldenFile: "testdata/fake/get_kustomizations_suspended.golden",
+		},
+		{
+			name:       "git repositories",
+			args:       "get sources git -n flux-system",
+			objectFile: "testdata/fake/objects.yaml",
+			goldenFile: "testdata/fake/get_sources_git.golden",
+		},
+		{
+			name:       "count kustomizations",
+			args:       "get kustomizations -n flux-system --count-only",
+			objectFile: "testdata/fake/objects.yaml",
+			goldenFile: "testdata/fake/get_kustomizations_count.golden",
+		},
+		{
+			name:       "count kustomizations in all namespaces",
+			args:       "get kustomizations -A --count-only",
+			objectFile: "testdata/fake/objects.yaml",
+			goldenFile: "testdata/fake/get_kustomizations_count_all_namespaces.golden",
+		},
+		{
+			name:       "count no objects",
+			args:       "get kustomizations -n default --count-only",
+			objectFile: "testdata/fake/objects.yaml",
+			goldenFile: "testdata/fake/get_kustomizations_count_empty.golden",
+		},
+		{
+			name:       "alerts with event sources",
+			args:       "get alerts -n flux-system --show-event-sources",
+			objectFile: "testdata/fake/alerts.yaml",
+			goldenFile: "testdata/fake/get_alerts_event_sources.golden",
+		},
+		{
+			name:       "alerts for kustomization",
+			args:       "get alerts -n flux-system --for=Kustomization/apps",
+			objectFile: "testdata/fake/alerts.yaml",
+			goldenFile: "testdata/fake/get_alerts_for.golden",
+		},
+		{
+			name:    "alerts for invalid object",
+			args:    "get alerts -n flux-system --for=apps",
+			wantErr: `invalid --for "apps", expected the <kind>/<name> format`,
+		},
+		{
+			name:       "no objects",
+			args:       "get kustomizations -n default",
+			objectFile: "testdata/fake/objects.yaml",
+			goldenFile: "testdata/fake/get_kustomizations_empty.golden",
+		},
+	})
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	"github.com/fluxcd/pkg/apis/meta"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+
+	"github.com/fluxcd/flux2/internal/utils"
+)
+
+// remediationHint describes how to follow up on a failed reconciliation.
+// The command is a Go template with access to the Kind, Resource, Name
+// and Namespace of the object.
+type remediationHint struct {
+	hint    string
+	command string
+}
+
+const logsHintCommand = "flux logs --kind={{ .Kind }} --name={{ .Name }} -n {{ .Namespace }}"
+
+// remediationHints maps the reasons of the Ready condition set by the
+// controllers to remediation hints.
+var remediationHints = map[string]remediationHint{
+	meta.DependencyNotReadyReason: {
+		hint:    "one or more dependencies are not ready",
+		command: "flux get {{ .Resource }} -n {{ .Namespace }}",
+	},
+	kustomizev1.ArtifactFailedReason: {
+		hint:    "the artifact of the source could not be fetched",
+		command: "flux get sources all -n {{ .Namespace }}",
+	},
+	kustomizev1.BuildFailedReason: {
+		hint:    "the manifests could not be built, build them locally to see the error",
+		command: "flux build kustomization {{ .Name }} -n {{ .Namespace }} --path <local path>",
+	},
+	kustomizev1.HealthCheckFailedReason: {
+		hint:    "the applied workloads did not become ready",
+		command: "flux tree kustomization {{ .Name }} -n {{ .Namespace }}",
+	},
+	kustomizev1.PruneFailedReason: {
+		hint:    "the garbage collection of removed objects failed",
+		command: logsHintCommand,
+	},
+	kustomizev1.ReconciliationFailedReason: {
+		hint:    "the reconciliation failed",
+		command: logsHintCommand,
+	},
+	sourcev1.AuthenticationFailedReason: {
+		hint:    "the credentials were rejected, check the secret referenced by the source",
+		command: "flux get {{ .Resource }} {{ .Name }} -n {{ .Namespace }}",
+	},
+	sourcev1.URLInvalidReason: {
+		hint:    "the URL of the source is invalid",
+		command: "flux get {{ .Resource }} {{ .Name }} -n {{ .Namespace }}",
+	},
+	sourcev1.GitOperationFailedReason: {
+		hint:    "the Git operation failed, check the URL, reference and credentials of the source",
+		command: logsHintCommand,
+	},
+	sourcev1.IndexationFailedReason: {
+		hint:    "the Helm repository index could not be fetched",
+		command: logsHintCommand,
+	},
+	sourcev1.OCIPullFailedReason: {
+		hint:    "the OCI artifact could not be pulled, check the URL, reference and credentials of the source",
+		command: logsHintCommand,
+	},
+	helmv2.InstallFailedReason: {
+		hint:    "the Helm install failed",
+		command: logsHintCommand,
+	},
+	helmv2.UpgradeFailedReason: {
+		hint:    "the Helm upgrade failed",
+		command: logsHintCommand,
+	},
+	helmv2.TestFailedReason: {
+		hint:    "the Helm tests failed",
+		command: logsHintCommand,
+	},
+	helmv2.RollbackFailedReason: {
+		hint:    "the Helm rollback failed",
+		command: logsHintCommand,
+	},
+}
+
+// hintResources maps the kinds to the resource names used by flux get.
+var hintResources = map[string]string{
+	kustomizev1.KustomizationKind: "kustomizations",
+	helmv2.HelmReleaseKind:        "helmreleases",
+	sourcev1.GitRepositoryKind:    "sources git",
+	sourcev1.HelmRepositoryKind:   "sources helm",
+	sourcev1.HelmChartKind:        "sources chart",
+	sourcev1.BucketKind:           "sources bucket",
+	sourcev1.OCIRepositoryKind:    "sources oci",
+	"ImageRepository":             "images repository",
+	"ImagePolicy":                 "images policy",
+	"ImageUpdateAutomation":       "images update",
+	"Alert":                       "alerts",
+	"Provider":                    "alert-providers",
+	"Receiver":                    "receivers",
+}
+
+// conditionError returns an error with the message of the given failed
+// condition, followed by a remediation hint for its reason when known.
+func conditionError(obj client.Object, c *metav1.Condition) error {
+	if hint := remediationHintFor(obj, c.Reason); hint != "" {
+		return fmt.Errorf("%s\n%s", c.Message, hint)
+	}
+	return fmt.Errorf("%s", c.Message)
+}
+
+// remediationHintFor returns the one line hint for the given reason, or
+// an empty string if there is none.
+func remediationHintFor(obj client.Object, reason string) string {
+	rh, ok := remediationHints[reason]
+	if !ok {
+		return ""
+	}
+
+	kind := obj.GetObjectKind().GroupVersionKind().Kind
+	if kind == "" {
+		if gvk, err := apiutil.GVKForObject(obj, utils.NewScheme()); err == nil {
+			kind = gvk.Kind
+		}
+	}
+	resource, ok := hintResources[kind]
+	if !ok {
+		resource = "all"
+	}
+
+	tmpl, err := template.New("hint").Parse(rh.command)
+	if err != nil {
+		return ""
+	}
+	var command bytes.Buffer
+	if err := tmpl.Execute(&command, struct {
+		Kind, Resource, Name, Namespace string
+	}{kind, resource, obj.GetName(), obj.GetNamespace()}); err != nil {
+		return ""
+	}
+	return fmt.Sprintf("hint: %s, run '%s'", rh.hint, command.String())
+}
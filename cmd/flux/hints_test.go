@@ -0,0 +1,85 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	"github.com/fluxcd/pkg/apis/meta"
+)
+
+func TestConditionError(t *testing.T) {
+	ks := &kustomizev1.Kustomization{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "apps",
+			Namespace: "flux-system",
+		},
+	}
+
+	tests := []struct {
+		name      string
+		condition metav1.Condition
+		want      string
+	}{
+		{
+			name: "dependency not ready",
+			condition: metav1.Condition{
+				Reason:  meta.DependencyNotReadyReason,
+				Message: "dependency 'flux-system/infra' is not ready",
+			},
+			want: "dependency 'flux-system/infra' is not ready\n" +
+				"hint: one or more dependencies are not ready, run 'flux get kustomizations -n flux-system'",
+		},
+		{
+			name: "health check failed",
+			condition: metav1.Condition{
+				Reason:  kustomizev1.HealthCheckFailedReason,
+				Message: "Health check failed after 5m0s",
+			},
+			want: "Health check failed after 5m0s\n" +
+				"hint: the applied workloads did not become ready, run 'flux tree kustomization apps -n flux-system'",
+		},
+		{
+			name: "artifact failed",
+			condition: metav1.Condition{
+				Reason:  kustomizev1.ArtifactFailedReason,
+				Message: "failed to download artifact",
+			},
+			want: "failed to download artifact\n" +
+				"hint: the artifact of the source could not be fetched, run 'flux get sources all -n flux-system'",
+		},
+		{
+			name: "unknown reason",
+			condition: metav1.Condition{
+				Reason:  "Unknown",
+				Message: "something went wrong",
+			},
+			want: "something went wrong",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := conditionError(ks, &tt.condition)
+			if err.Error() != tt.want {
+				t.Errorf("got %q, want %q", err.Error(), tt.want)
+			}
+		})
+	}
+}
@@ -19,17 +19,22 @@ package main
 import (
 	"context"
 	"fmt"
+	"net"
 	"os"
-	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/yaml"
 
 	"github.com/fluxcd/flux2/internal/flags"
 	"github.com/fluxcd/flux2/internal/utils"
+	"github.com/fluxcd/flux2/pkg/bootstrap"
+	"github.com/fluxcd/flux2/pkg/installer"
 	"github.com/fluxcd/flux2/pkg/manifestgen"
 	"github.com/fluxcd/flux2/pkg/manifestgen/install"
-	"github.com/fluxcd/flux2/pkg/status"
 )
 
 var installCmd = &cobra.Command{
@@ -49,16 +54,26 @@ If a previous version is installed, then an in-place upgrade will be performed.`
   # Install Flux onto tainted Kubernetes nodes
   flux install --toleration-keys=node.kubernetes.io/dedicated-to-flux
 
+  # Install Flux onto the infrastructure nodes with a high priority
+  flux install --node-selector=node-role.kubernetes.io/infra=true --priority-class=system-cluster-critical
+
   # Dry-run install
   flux install --export | kubectl apply --dry-run=client -f- 
 
   # Write install manifests to file
-  flux install --export > flux-system.yaml`,
+  flux install --export > flux-system.yaml
+
+  # Write install manifests to a directory, one file per component
+  flux install --export-dir=./clusters/my-cluster/flux-system
+
+  # Install Flux from the manifests mirrored to a private registry
+  flux install --manifests-artifact=oci://registry.local/flux-manifests:v2.0.0 --registry=registry.local/fluxcd`,
 	RunE: installCmdRun,
 }
 
 type installFlags struct {
 	export             bool
+	exportDir          string
 	version            string
 	defaultComponents  []string
 	extraComponents    []string
@@ -66,12 +81,20 @@ type installFlags struct {
 	imagePullSecret    string
 	branch             string
 	watchAllNamespaces bool
+	watchNamespaces    []string
 	networkPolicy      bool
+	networkPolicyCIDRs []string
 	manifestsPath      string
+	manifestsArtifact  string
 	logLevel           flags.LogLevel
 	tokenAuth          bool
 	clusterDomain      string
 	tolerationKeys     []string
+	nodeSelector       map[string]string
+	affinityFile       string
+	priorityClass      string
+	podSecurity        map[string]string
+	seccompProfile     string
 }
 
 var installArgs = NewInstallFlags()
@@ -79,6 +102,8 @@ var installArgs = NewInstallFlags()
 func init() {
 	installCmd.Flags().BoolVar(&installArgs.export, "export", false,
 		"write the install manifests to stdout and exit")
+	installCmd.Flags().StringVar(&installArgs.exportDir, "export-dir", "",
+		"write the install manifests to the given directory, one file per component with a kustomization.yaml, and exit")
 	installCmd.Flags().StringVarP(&installArgs.version, "version", "v", "",
 		"toolkit version, when specified the manifests are downloaded from https://github.com/fluxcd/flux2/releases")
 	installCmd.Flags().StringSliceVar(&installArgs.defaultComponents, "components", rootArgs.defaults.Components,
@@ -86,18 +111,35 @@ func init() {
 	installCmd.Flags().StringSliceVar(&installArgs.extraComponents, "components-extra", nil,
 		"list of components in addition to those supplied or defaulted, accepts values such as 'image-reflector-controller,image-automation-controller'")
 	installCmd.Flags().StringVar(&installArgs.manifestsPath, "manifests", "", "path to the manifest directory")
+	installCmd.Flags().StringVar(&installArgs.manifestsArtifact, "manifests-artifact", "",
+		"OCI artifact (oci://<registry>/<repository>:<tag>) or local tarball containing the manifests of the toolkit components, for installing from a mirror in air-gapped environments")
 	installCmd.Flags().StringVar(&installArgs.registry, "registry", rootArgs.defaults.Registry,
 		"container registry where the toolkit images are published")
 	installCmd.Flags().StringVar(&installArgs.imagePullSecret, "image-pull-secret", "",
 		"Kubernetes secret name used for pulling the toolkit images from a private registry")
 	installCmd.Flags().BoolVar(&installArgs.watchAllNamespaces, "watch-all-namespaces", rootArgs.defaults.WatchAllNamespaces,
 		"watch for custom resources in all namespaces, if set to false it will only watch the namespace where the toolkit is installed")
+	installCmd.Flags().StringSliceVar(&installArgs.watchNamespaces, "watch-namespace", nil,
+		"namespaces the toolkit reconciles into in addition to its own, for running multiple isolated instances on a cluster, "+
+			"the controllers watch the custom resources of their namespace only and are bound to the listed namespaces instead of the whole cluster, implies --watch-all-namespaces=false")
 	installCmd.Flags().Var(&installArgs.logLevel, "log-level", installArgs.logLevel.Description())
 	installCmd.Flags().BoolVar(&installArgs.networkPolicy, "network-policy", rootArgs.defaults.NetworkPolicy,
 		"deny ingress access to the toolkit controllers from other namespaces using network policies")
+	installCmd.Flags().StringSliceVar(&installArgs.networkPolicyCIDRs, "network-policy-cidrs", nil,
+		"list of IPv4 or IPv6 CIDRs allowed to reach the toolkit controllers through the network policies, e.g. '10.0.0.0/8,fd00::/8' on dual-stack clusters")
 	installCmd.Flags().StringVar(&installArgs.clusterDomain, "cluster-domain", rootArgs.defaults.ClusterDomain, "internal cluster domain")
 	installCmd.Flags().StringSliceVar(&installArgs.tolerationKeys, "toleration-keys", nil,
 		"list of toleration keys used to schedule the components pods onto nodes with matching taints")
+	installCmd.Flags().StringToStringVar(&installArgs.nodeSelector, "node-selector", nil,
+		"node labels used to schedule the components pods, in the format 'key=value', accepts comma-separated values")
+	installCmd.Flags().StringVar(&installArgs.affinityFile, "affinity-file", "",
+		"path to a YAML file containing the affinity of the components pods")
+	installCmd.Flags().StringVar(&installArgs.priorityClass, "priority-class", "",
+		"name of the priority class of the components pods")
+	installCmd.Flags().StringToStringVar(&installArgs.podSecurity, "pod-security", nil,
+		"Pod Security admission levels labelled on the namespace, in the format 'mode=level' with the modes enforce, audit and warn, e.g. 'enforce=restricted', the warn level defaults to restricted")
+	installCmd.Flags().StringVar(&installArgs.seccompProfile, "seccomp-profile", "",
+		"seccomp profile of the components pods, can be 'RuntimeDefault', 'Unconfined' or 'Localhost/<profile>' with a profile path relative to the kubelet seccomp directory")
 	installCmd.Flags().MarkHidden("manifests")
 
 	rootCmd.AddCommand(installCmd)
@@ -105,8 +147,103 @@ func init() {
 
 func NewInstallFlags() installFlags {
 	return installFlags{
-		logLevel: flags.LogLevel(rootArgs.defaults.LogLevel),
+		logLevel:      flags.LogLevel(rootArgs.defaults.LogLevel),
+		networkPolicy: rootArgs.defaults.NetworkPolicy,
+	}
+}
+
+// validateNetworkPolicyCIDRs returns an error if the CIDRs are set while the
+// network policy is disabled, or if one of the values is not an IPv4 or IPv6
+// CIDR, IPv6 address literals must not be enclosed in brackets.
+func validateNetworkPolicyCIDRs(networkPolicy bool, cidrs []string) error {
+	if !networkPolicy && len(cidrs) > 0 {
+		return fmt.Errorf("--network-policy-cidrs requires --network-policy")
+	}
+	for _, cidr := range cidrs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return fmt.Errorf("invalid network policy CIDR '%s': %w", cidr, err)
+		}
+	}
+	return nil
+}
+
+// validateNodeSelector returns an error if a key or a value of the node
+// selector is not a valid label key or value.
+func validateNodeSelector(nodeSelector map[string]string) error {
+	for key, value := range nodeSelector {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("invalid node selector key '%s': %s", key, strings.Join(errs, "; "))
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return fmt.Errorf("invalid node selector value '%s': %s", value, strings.Join(errs, "; "))
+		}
+	}
+	return nil
+}
+
+// validateWatchNamespaces returns an error if a watched namespace is not a
+// valid namespace name, or if all namespaces are explicitly watched.
+func validateWatchNamespaces(namespaces []string, watchAllNamespaces bool) error {
+	if len(namespaces) > 0 && watchAllNamespaces {
+		return fmt.Errorf("--watch-namespace and --watch-all-namespaces=true are mutually exclusive")
+	}
+	for _, ns := range namespaces {
+		if errs := validation.IsDNS1123Label(ns); len(errs) > 0 {
+			return fmt.Errorf("invalid watch namespace '%s': %s", ns, strings.Join(errs, "; "))
+		}
 	}
+	return nil
+}
+
+// validatePodSecurity returns an error if a mode or a level of the Pod
+// Security admission labels is unknown.
+func validatePodSecurity(podSecurity map[string]string) error {
+	for mode, level := range podSecurity {
+		switch mode {
+		case "enforce", "audit", "warn":
+		default:
+			return fmt.Errorf("invalid pod security mode '%s', must be one of enforce, audit or warn", mode)
+		}
+		switch level {
+		case "privileged", "baseline", "restricted":
+		default:
+			return fmt.Errorf("invalid pod security level '%s', must be one of privileged, baseline or restricted", level)
+		}
+	}
+	return nil
+}
+
+// parseSeccompProfile parses the seccomp profile given with
+// --seccomp-profile, it returns nil when no profile is given.
+func parseSeccompProfile(profile string) (*corev1.SeccompProfile, error) {
+	switch {
+	case profile == "":
+		return nil, nil
+	case profile == string(corev1.SeccompProfileTypeRuntimeDefault):
+		return &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault}, nil
+	case profile == string(corev1.SeccompProfileTypeUnconfined):
+		return &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeUnconfined}, nil
+	case strings.HasPrefix(profile, string(corev1.SeccompProfileTypeLocalhost)+"/"):
+		localhostProfile := strings.TrimPrefix(profile, string(corev1.SeccompProfileTypeLocalhost)+"/")
+		if localhostProfile == "" || strings.HasPrefix(localhostProfile, "/") {
+			return nil, fmt.Errorf("invalid seccomp profile '%s', the localhost profile must be a relative path", profile)
+		}
+		return &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeLocalhost, LocalhostProfile: &localhostProfile}, nil
+	}
+	return nil, fmt.Errorf("invalid seccomp profile '%s', must be RuntimeDefault, Unconfined or Localhost/<profile>", profile)
+}
+
+// readAffinityFile reads the pod affinity from a YAML file.
+func readAffinityFile(path string) (*corev1.Affinity, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read affinity file: %w", err)
+	}
+	affinity := &corev1.Affinity{}
+	if err := yaml.UnmarshalStrict(data, affinity); err != nil {
+		return nil, fmt.Errorf("invalid affinity file %s: %w", path, err)
+	}
+	return affinity, nil
 }
 
 func installCmdRun(cmd *cobra.Command, args []string) error {
@@ -125,10 +262,54 @@ func installCmdRun(cmd *cobra.Command, args []string) error {
 		installArgs.version = ver
 	}
 
+	if err := validateNetworkPolicyCIDRs(installArgs.networkPolicy, installArgs.networkPolicyCIDRs); err != nil {
+		return err
+	}
+	if err := validateNodeSelector(installArgs.nodeSelector); err != nil {
+		return err
+	}
+	if len(installArgs.watchNamespaces) > 0 && !cmd.Flags().Changed("watch-all-namespaces") {
+		installArgs.watchAllNamespaces = false
+	}
+	if err := validateWatchNamespaces(installArgs.watchNamespaces, installArgs.watchAllNamespaces); err != nil {
+		return err
+	}
+	if err := validatePodSecurity(installArgs.podSecurity); err != nil {
+		return err
+	}
+	seccompProfile, err := parseSeccompProfile(installArgs.seccompProfile)
+	if err != nil {
+		return err
+	}
+	var affinity *corev1.Affinity
+	if installArgs.affinityFile != "" {
+		affinity, err = readAffinityFile(installArgs.affinityFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	if installArgs.export && installArgs.exportDir != "" {
+		return fmt.Errorf("--export and --export-dir are mutually exclusive")
+	}
+
+	if installArgs.manifestsArtifact != "" && installArgs.manifestsPath != "" {
+		return fmt.Errorf("--manifests and --manifests-artifact are mutually exclusive")
+	}
+
 	if !installArgs.export {
+		logger.Progress("install", 0)
 		logger.Generatef("generating manifests")
 	}
 
+	var phases bootstrap.Phases
+	phaseStart := time.Now()
+	endPhase := func(name string) {
+		now := time.Now()
+		phases = append(phases, bootstrap.Phase{Name: name, Duration: now.Sub(phaseStart)})
+		phaseStart = now
+	}
+
 	tmpDir, err := manifestgen.MkdirTempAbs("", *kubeconfigArgs.Namespace)
 	if err != nil {
 		return err
@@ -136,7 +317,15 @@ func installCmdRun(cmd *cobra.Command, args []string) error {
 	defer os.RemoveAll(tmpDir)
 
 	manifestsBase := ""
-	if isEmbeddedVersion(installArgs.version) {
+	switch {
+	case installArgs.manifestsArtifact != "":
+		ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+		defer cancel()
+		if err := extractManifestsArtifact(ctx, installArgs.manifestsArtifact, tmpDir, components); err != nil {
+			return err
+		}
+		manifestsBase = tmpDir
+	case isEmbeddedVersion(installArgs.version):
 		if err := writeEmbeddedManifests(tmpDir); err != nil {
 			return err
 		}
@@ -151,25 +340,49 @@ func installCmdRun(cmd *cobra.Command, args []string) error {
 		Registry:               installArgs.registry,
 		ImagePullSecret:        installArgs.imagePullSecret,
 		WatchAllNamespaces:     installArgs.watchAllNamespaces,
+		WatchNamespaces:        installArgs.watchNamespaces,
 		NetworkPolicy:          installArgs.networkPolicy,
+		NetworkPolicyCIDRs:     installArgs.networkPolicyCIDRs,
 		LogLevel:               installArgs.logLevel.String(),
 		NotificationController: rootArgs.defaults.NotificationController,
 		ManifestFile:           fmt.Sprintf("%s.yaml", *kubeconfigArgs.Namespace),
 		Timeout:                rootArgs.timeout,
 		ClusterDomain:          installArgs.clusterDomain,
 		TolerationKeys:         installArgs.tolerationKeys,
+		NodeSelector:           installArgs.nodeSelector,
+		Affinity:               affinity,
+		PriorityClassName:      installArgs.priorityClass,
+		PodSecurity:            installArgs.podSecurity,
+		SeccompProfile:         seccompProfile,
 	}
 
 	if installArgs.manifestsPath == "" {
 		opts.BaseURL = install.MakeDefaultOptions().BaseURL
 	}
 
-	manifest, err := install.Generate(opts, manifestsBase)
-	if err != nil {
-		return fmt.Errorf("install failed: %w", err)
+	if installArgs.exportDir != "" {
+		manifests, err := install.GenerateFiles(opts, manifestsBase)
+		if err != nil {
+			return fmt.Errorf("install failed: %w", err)
+		}
+		for _, m := range manifests {
+			if _, err := m.WriteFile(installArgs.exportDir); err != nil {
+				return fmt.Errorf("install failed: %w", err)
+			}
+		}
+		logger.Successf("manifests written to %s", installArgs.exportDir)
+		return nil
 	}
 
-	if _, err := manifest.WriteFile(tmpDir); err != nil {
+	installerOpts := []installer.Option{
+		installer.WithInstallOptions(opts),
+		installer.WithManifestsBase(manifestsBase),
+		installer.WithClientOptions(kubeclientOptions),
+		installer.WithLogger(logger),
+		installer.WithPollInterval(5 * time.Second),
+	}
+	manifest, err := installer.Generate(installerOpts...)
+	if err != nil {
 		return fmt.Errorf("install failed: %w", err)
 	}
 
@@ -180,33 +393,35 @@ func installCmdRun(cmd *cobra.Command, args []string) error {
 		fmt.Print(manifest.Content)
 	}
 
+	endPhase("manifests")
+	logger.Progress("install", 25)
 	logger.Successf("manifests build completed")
 	logger.Actionf("installing components in %s namespace", *kubeconfigArgs.Namespace)
 
-	applyOutput, err := utils.Apply(ctx, kubeconfigArgs, kubeclientOptions, tmpDir, filepath.Join(tmpDir, manifest.Path))
+	changeSet, err := installer.Apply(ctx, kubeconfigArgs, manifest, installerOpts...)
 	if err != nil {
 		return fmt.Errorf("install failed: %w", err)
 	}
 
-	fmt.Fprintln(os.Stderr, applyOutput)
+	fmt.Fprintln(os.Stderr, changeSet.String())
+	var applied utils.ApplyStats
+	applied.Add(changeSet)
+	endPhase("apply")
 
-	kubeConfig, err := utils.KubeConfig(kubeconfigArgs, kubeclientOptions)
-	if err != nil {
-		return fmt.Errorf("install failed: %w", err)
-	}
-	statusChecker, err := status.NewStatusChecker(kubeConfig, 5*time.Second, rootArgs.timeout, logger)
-	if err != nil {
-		return fmt.Errorf("install failed: %w", err)
-	}
-	componentRefs, err := buildComponentObjectRefs(components...)
-	if err != nil {
-		return fmt.Errorf("install failed: %w", err)
-	}
+	logger.Progress("wait", 75)
 	logger.Waitingf("verifying installation")
-	if err := statusChecker.Assess(componentRefs...); err != nil {
+	if err := installer.WaitForComponents(kubeconfigArgs, installerOpts...); err != nil {
 		return fmt.Errorf("install failed")
 	}
+	endPhase("health checks")
+
+	if kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions); err == nil {
+		recordNamespaceAuditEvent(ctx, kubeClient, *kubeconfigArgs.Namespace, "Installed",
+			fmt.Sprintf("Flux %s installed", opts.Version))
+	}
 
-	logger.Successf("install finished")
+	logger.Progress("wait", 100)
+	logger.Successf("applied objects: %s", applied)
+	logger.Successf("install finished in %s (%s)", phases.Total().Round(time.Millisecond), phases)
 	return nil
 }
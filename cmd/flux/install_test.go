@@ -16,7 +16,13 @@ limitations under the License.
 
 package main
 
-import "testing"
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
 
 func TestInstall(t *testing.T) {
 	// The pointer to kubeconfigArgs.Namespace is shared across
@@ -37,6 +43,61 @@ func TestInstall(t *testing.T) {
 			args:   "install --namespace='@#[]'",
 			assert: assertError("namespace must be a valid DNS label: \"@#[]\""),
 		},
+		{
+			name: "invalid node selector",
+			args: "install --node-selector=node-role.kubernetes.io/infra=in/valid",
+			assert: func(output string, err error) error {
+				if err == nil || !strings.HasPrefix(err.Error(), "invalid node selector value 'in/valid'") {
+					return fmt.Errorf("expected node selector error, got %v", err)
+				}
+				return nil
+			},
+		},
+		{
+			name:   "invalid network policy CIDR",
+			args:   "install --network-policy-cidrs=10.0.0.0/8,[fd00::]/8",
+			assert: assertError("invalid network policy CIDR '[fd00::]/8': invalid CIDR address: [fd00::]/8"),
+		},
+		{
+			name:   "network policy CIDRs without network policy",
+			args:   "install --network-policy=false --network-policy-cidrs=10.0.0.0/8",
+			assert: assertError("--network-policy-cidrs requires --network-policy"),
+		},
+		{
+			name:   "missing affinity file",
+			args:   "install --affinity-file=testdata/install/missing-affinity.yaml",
+			assert: assertError("failed to read affinity file: open testdata/install/missing-affinity.yaml: no such file or directory"),
+		},
+		{
+			name:   "invalid pod security level",
+			args:   "install --pod-security=enforce=strict",
+			assert: assertError("invalid pod security level 'strict', must be one of privileged, baseline or restricted"),
+		},
+		{
+			name: "invalid watch namespace",
+			args: "install --watch-namespace=apps,Web",
+			assert: func(output string, err error) error {
+				if err == nil || !strings.HasPrefix(err.Error(), "invalid watch namespace 'Web'") {
+					return fmt.Errorf("expected watch namespace error, got %v", err)
+				}
+				return nil
+			},
+		},
+		{
+			name:   "watch namespace with all namespaces",
+			args:   "install --watch-namespace=apps --watch-all-namespaces=true",
+			assert: assertError("--watch-namespace and --watch-all-namespaces=true are mutually exclusive"),
+		},
+		{
+			name:   "invalid seccomp profile",
+			args:   "install --seccomp-profile=runtime/default",
+			assert: assertError("invalid seccomp profile 'runtime/default', must be RuntimeDefault, Unconfined or Localhost/<profile>"),
+		},
+		{
+			name:   "absolute localhost seccomp profile",
+			args:   "install --seccomp-profile=Localhost//var/lib/kubelet/seccomp/flux.json",
+			assert: assertError("invalid seccomp profile 'Localhost//var/lib/kubelet/seccomp/flux.json', the localhost profile must be a relative path"),
+		},
 	}
 
 	for _, tt := range tests {
@@ -49,3 +110,49 @@ func TestInstall(t *testing.T) {
 		})
 	}
 }
+
+func TestValidatePodSecurity(t *testing.T) {
+	tests := []struct {
+		name        string
+		podSecurity map[string]string
+		wantErr     string
+	}{
+		{name: "none"},
+		{name: "all modes", podSecurity: map[string]string{"enforce": "restricted", "audit": "baseline", "warn": "privileged"}},
+		{name: "invalid mode", podSecurity: map[string]string{"enforced": "restricted"}, wantErr: "invalid pod security mode 'enforced', must be one of enforce, audit or warn"},
+		{name: "invalid level", podSecurity: map[string]string{"audit": "Restricted"}, wantErr: "invalid pod security level 'Restricted', must be one of privileged, baseline or restricted"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePodSecurity(tt.podSecurity)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("expected error %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestParseSeccompProfile(t *testing.T) {
+	profile, err := parseSeccompProfile("Localhost/profiles/flux.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.Type != corev1.SeccompProfileTypeLocalhost || profile.LocalhostProfile == nil || *profile.LocalhostProfile != "profiles/flux.json" {
+		t.Errorf("unexpected localhost profile %v", profile)
+	}
+
+	profile, err = parseSeccompProfile("RuntimeDefault")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.Type != corev1.SeccompProfileTypeRuntimeDefault || profile.LocalhostProfile != nil {
+		t.Errorf("unexpected runtime default profile %v", profile)
+	}
+
+	if profile, err := parseSeccompProfile(""); profile != nil || err != nil {
+		t.Errorf("expected no profile, got %v, %v", profile, err)
+	}
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/fluxcd/pkg/ssa"
+
+	"github.com/fluxcd/flux2/internal/lint"
+)
+
+var lintCmd = &cobra.Command{
+	Use:   "lint [path]...",
+	Short: "Check the interval and timeout settings of Flux manifests",
+	Long: `The lint command checks the Flux objects found in YAML manifests for interval and timeout
+settings which are valid but unlikely to behave as intended, such as an interval shorter than
+a typical reconciliation, a retry interval longer than the interval, or a health check timeout
+exceeding the interval.
+Directories are searched recursively for .yaml and .yml files, '-' reads the manifests from stdin.`,
+	Example: `  # Lint the manifests of a cluster
+  flux lint ./clusters/production
+
+  # Lint the output of a create command
+  flux create kustomization podinfo --source=podinfo --interval=10s --export | flux lint -`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: lintCmdRun,
+}
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+}
+
+func lintCmdRun(cmd *cobra.Command, args []string) error {
+	var objects []*unstructured.Unstructured
+	for _, path := range args {
+		objs, err := readManifestObjects(path, cmd.InOrStdin())
+		if err != nil {
+			return err
+		}
+		objects = append(objects, objs...)
+	}
+
+	count, err := lintObjects(cmd.OutOrStdout(), objects)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("%d issue(s) found", count)
+	}
+	logger.Successf("no issues found in %d object(s)", len(objects))
+	return nil
+}
+
+// lintObjects runs the lint rules against the Flux objects and prints the
+// findings, it returns the number of findings.
+func lintObjects(w io.Writer, objects []*unstructured.Unstructured) (int, error) {
+	count := 0
+	for _, obj := range objects {
+		if !strings.HasSuffix(obj.GroupVersionKind().Group, ".toolkit.fluxcd.io") {
+			continue
+		}
+		findings, err := lint.Lint(obj, lint.Options{})
+		if err != nil {
+			return count, err
+		}
+		for _, f := range findings {
+			fmt.Fprintf(w, "%s/%s/%s: %s (%s)\n", obj.GetKind(), obj.GetNamespace(), obj.GetName(), f.Message, f.Rule)
+			count++
+		}
+	}
+	return count, nil
+}
+
+// readManifestObjects reads the objects from a manifest file, from the YAML files
+// in a directory tree, or from stdin when the path is '-'.
+func readManifestObjects(path string, stdin io.Reader) ([]*unstructured.Unstructured, error) {
+	if path == "-" {
+		return ssa.ReadObjects(bufio.NewReader(stdin))
+	}
+
+	var objects []*unstructured.Unstructured
+	err := filepath.WalkDir(path, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if ext := filepath.Ext(p); p != path && ext != ".yaml" && ext != ".yml" {
+			return nil
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		objs, err := ssa.ReadObjects(bufio.NewReader(f))
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", p, err)
+		}
+		objects = append(objects, objs...)
+		return nil
+	})
+	return objects, err
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const lintManifests = `---
+apiVersion: source.toolkit.fluxcd.io/v1beta2
+kind: GitRepository
+metadata:
+  name: podinfo
+  namespace: flux-system
+spec:
+  interval: 5s
+  url: https://github.com/stefanprodan/podinfo
+---
+apiVersion: kustomize.toolkit.fluxcd.io/v1beta2
+kind: Kustomization
+metadata:
+  name: podinfo
+  namespace: flux-system
+spec:
+  interval: 10m
+  retryInterval: 1m
+  sourceRef:
+    kind: GitRepository
+    name: podinfo
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: not-flux
+  namespace: flux-system
+spec:
+  interval: 1s
+`
+
+func TestLintObjects(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "apps"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "apps", "podinfo.yaml"), []byte(lintManifests), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("# not a manifest"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	objects, err := readManifestObjects(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 3 {
+		t.Fatalf("expected 3 objects, got %d", len(objects))
+	}
+
+	fromStdin, err := readManifestObjects("-", strings.NewReader(lintManifests))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fromStdin) != 3 {
+		t.Fatalf("expected 3 objects from stdin, got %d", len(fromStdin))
+	}
+
+	var out bytes.Buffer
+	count, err := lintObjects(&out, objects)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "GitRepository/flux-system/podinfo: interval 5s is shorter than the typical reconciliation duration of 1m0s (min-interval)\n"
+	if count != 1 || out.String() != want {
+		t.Errorf("unexpected findings (%d):\n%s", count, out.String())
+	}
+}
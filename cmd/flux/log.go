@@ -17,34 +17,112 @@ limitations under the License.
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
+	"sync"
+	"time"
+)
+
+// ANSI color codes of the log symbols.
+const (
+	colorReset  = "\033[0m"
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+	colorCyan   = "\033[36m"
 )
 
 type stderrLogger struct {
-	stderr io.Writer
+	stderr   io.Writer
+	colorize bool
+	progress *progressEmitter
 }
 
 func (l stderrLogger) Actionf(format string, a ...interface{}) {
-	fmt.Fprintln(l.stderr, `►`, fmt.Sprintf(format, a...))
+	l.log("action", `►`, colorCyan, format, a...)
 }
 
 func (l stderrLogger) Generatef(format string, a ...interface{}) {
-	fmt.Fprintln(l.stderr, `✚`, fmt.Sprintf(format, a...))
+	l.log("generate", `✚`, colorCyan, format, a...)
 }
 
 func (l stderrLogger) Waitingf(format string, a ...interface{}) {
-	fmt.Fprintln(l.stderr, `◎`, fmt.Sprintf(format, a...))
+	l.log("waiting", `◎`, colorCyan, format, a...)
 }
 
 func (l stderrLogger) Successf(format string, a ...interface{}) {
-	fmt.Fprintln(l.stderr, `✔`, fmt.Sprintf(format, a...))
+	l.log("success", `✔`, colorGreen, format, a...)
 }
 
 func (l stderrLogger) Warningf(format string, a ...interface{}) {
-	fmt.Fprintln(l.stderr, `⚠️`, fmt.Sprintf(format, a...))
+	l.log("warning", `⚠️`, colorYellow, format, a...)
 }
 
 func (l stderrLogger) Failuref(format string, a ...interface{}) {
-	fmt.Fprintln(l.stderr, `✗`, fmt.Sprintf(format, a...))
+	l.log("failure", `✗`, colorRed, format, a...)
+}
+
+func (l stderrLogger) log(level, symbol, color, format string, a ...interface{}) {
+	msg := fmt.Sprintf(format, a...)
+	if l.colorize {
+		symbol = color + symbol + colorReset
+	}
+	fmt.Fprintln(l.stderr, symbol, msg)
+	if l.progress != nil {
+		l.progress.emit(level, msg)
+	}
+}
+
+// Progress sets the phase and the completion percentage of the current
+// operation, reported with the next progress events.
+func (l stderrLogger) Progress(phase string, percent int) {
+	if l.progress != nil {
+		l.progress.set(phase, percent)
+	}
+}
+
+// progressEvent is written as a JSON line for each log message when
+// --progress-fd is set, for tools wrapping the CLI to track the operations.
+type progressEvent struct {
+	Time    string `json:"time"`
+	Phase   string `json:"phase,omitempty"`
+	Level   string `json:"level"`
+	Message string `json:"message"`
+	Percent *int   `json:"percent,omitempty"`
+}
+
+type progressEmitter struct {
+	mu      sync.Mutex
+	w       io.Writer
+	phase   string
+	percent *int
+}
+
+func newProgressEmitter(w io.Writer) *progressEmitter {
+	return &progressEmitter{w: w}
+}
+
+func (p *progressEmitter) set(phase string, percent int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.phase = phase
+	p.percent = &percent
+}
+
+func (p *progressEmitter) emit(level, msg string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	data, err := json.Marshal(progressEvent{
+		Time:    time.Now().UTC().Format(time.RFC3339),
+		Phase:   p.phase,
+		Level:   level,
+		Message: msg,
+		Percent: p.percent,
+	})
+	if err != nil {
+		return
+	}
+	// Failing to report progress must not fail the operation.
+	_, _ = p.w.Write(append(data, '\n'))
 }
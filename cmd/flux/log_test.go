@@ -0,0 +1,65 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestStderrLoggerProgress(t *testing.T) {
+	var stderr, progress bytes.Buffer
+	l := stderrLogger{stderr: &stderr, progress: newProgressEmitter(&progress)}
+
+	l.Actionf("installing %s", "components")
+	l.Progress("wait", 100)
+	l.Successf("install finished")
+
+	if got, want := stderr.String(), "► installing components\n✔ install finished\n"; got != want {
+		t.Errorf("stderr = %q, want %q", got, want)
+	}
+
+	lines := strings.Split(strings.TrimSpace(progress.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 progress events, got %d", len(lines))
+	}
+	var events []progressEvent
+	for _, line := range lines {
+		var e progressEvent
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("invalid progress event %q: %s", line, err)
+		}
+		events = append(events, e)
+	}
+	if events[0].Phase != "" || events[0].Level != "action" || events[0].Message != "installing components" || events[0].Percent != nil {
+		t.Errorf("unexpected first event: %+v", events[0])
+	}
+	if events[1].Phase != "wait" || events[1].Level != "success" || events[1].Percent == nil || *events[1].Percent != 100 {
+		t.Errorf("unexpected second event: %+v", events[1])
+	}
+}
+
+func TestStderrLoggerColorize(t *testing.T) {
+	var stderr bytes.Buffer
+	l := stderrLogger{stderr: &stderr, colorize: true}
+	l.Failuref("failed")
+	if got, want := stderr.String(), colorRed+"✗"+colorReset+" failed\n"; got != want {
+		t.Errorf("stderr = %q, want %q", got, want)
+	}
+}
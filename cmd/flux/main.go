@@ -18,9 +18,12 @@ package main
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"os/exec"
+	"strconv"
 	"strings"
 	"time"
 
@@ -33,6 +36,7 @@ import (
 
 	runclient "github.com/fluxcd/pkg/runtime/client"
 
+	"github.com/fluxcd/flux2/internal/utils"
 	"github.com/fluxcd/flux2/pkg/manifestgen/install"
 )
 
@@ -107,17 +111,21 @@ Command line utility for assembling Kubernetes CD pipelines the GitOps way.`,
 			return fmt.Errorf("namespace must be a valid DNS label: %q", ns)
 		}
 
-		return nil
+		return confirmContext(cmd)
 	},
 }
 
 var logger = stderrLogger{stderr: os.Stderr}
 
 type rootFlags struct {
-	timeout      time.Duration
-	verbose      bool
-	pollInterval time.Duration
-	defaults     install.Options
+	timeout        time.Duration
+	verbose        bool
+	pollInterval   time.Duration
+	defaults       install.Options
+	forceColor     bool
+	progressFD     int
+	confirmContext string
+	auditEvents    bool
 }
 
 // RequestError is a custom error type that wraps an error returned by the flux api.
@@ -134,9 +142,24 @@ var rootArgs = NewRootFlags()
 var kubeconfigArgs = genericclioptions.NewConfigFlags(false)
 var kubeclientOptions = new(runclient.Options)
 
+// newKubeClient returns the Kubernetes client used by the commands,
+// tests replace it to run the commands against a fake client.
+var newKubeClient = utils.KubeClient
+
 func init() {
 	rootCmd.PersistentFlags().DurationVar(&rootArgs.timeout, "timeout", 5*time.Minute, "timeout for this operation")
 	rootCmd.PersistentFlags().BoolVar(&rootArgs.verbose, "verbose", false, "print generated objects")
+	rootCmd.PersistentFlags().BoolVar(&rootArgs.forceColor, "force-color", false,
+		"colorize the symbols of the log messages")
+	rootCmd.PersistentFlags().IntVar(&rootArgs.progressFD, "progress-fd", 0,
+		"write the progress of the operation as JSON lines with the phase, log level, message and percent to the given file descriptor")
+
+	rootCmd.PersistentFlags().StringVar(&rootArgs.confirmContext, "confirm-context", "",
+		"refuse to run commands changing the cluster state unless the current kubeconfig context has this name")
+
+	auditEvents, _ := strconv.ParseBool(os.Getenv("FLUX_AUDIT_EVENTS"))
+	rootCmd.PersistentFlags().BoolVar(&rootArgs.auditEvents, "audit-events", auditEvents,
+		"record a Kubernetes event on the objects changed by the command with the user performing the change, can be enabled with the FLUX_AUDIT_EVENTS env var")
 
 	configureDefaultNamespace()
 	kubeconfigArgs.APIServer = nil // prevent AddFlags from configuring --server flag
@@ -157,6 +180,8 @@ func init() {
 
 	rootCmd.DisableAutoGenTag = true
 	rootCmd.SetOut(os.Stdout)
+
+	cobra.OnInitialize(configureLogger)
 }
 
 func NewRootFlags() rootFlags {
@@ -170,6 +195,18 @@ func NewRootFlags() rootFlags {
 
 func main() {
 	log.SetFlags(0)
+	if ok, err := runPlugin(os.Args[1:]); ok {
+		if err != nil {
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				os.Exit(exitErr.ExitCode())
+			}
+			logger.Failuref("%v", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
 	if err := rootCmd.Execute(); err != nil {
 
 		if err, ok := err.(*RequestError); ok {
@@ -187,6 +224,21 @@ func main() {
 	}
 }
 
+// configureLogger enables the colors and the progress events of the logger
+// from the flags.
+func configureLogger() {
+	logger.colorize = rootArgs.forceColor
+
+	if rootArgs.progressFD > 0 {
+		f := os.NewFile(uintptr(rootArgs.progressFD), "progress")
+		if _, err := f.Stat(); err != nil {
+			logger.Warningf("ignoring --progress-fd=%d: %s", rootArgs.progressFD, err.Error())
+			return
+		}
+		logger.progress = newProgressEmitter(f)
+	}
+}
+
 func configureDefaultNamespace() {
 	*kubeconfigArgs.Namespace = rootArgs.defaults.Namespace
 	fromEnv := os.Getenv("FLUX_SYSTEM_NAMESPACE")
@@ -36,9 +36,13 @@ import (
 	"github.com/mattn/go-shellwords"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/client-go/tools/clientcmd"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
+
+	runclient "github.com/fluxcd/pkg/runtime/client"
 )
 
 var nextNamespaceId int64
@@ -322,6 +326,83 @@ func assertGoldenTemplateFile(goldenFile string, templateValues map[string]strin
 		})
 }
 
+// useFakeCluster makes the commands run against a fake client seeded with
+// the objects, status included, for the duration of the test.
+func useFakeCluster(t *testing.T, objects ...client.Object) client.WithWatch {
+	t.Helper()
+	kubeClient := fake.NewClientBuilder().WithScheme(utils.NewScheme()).WithObjects(objects...).Build()
+	previous := newKubeClient
+	newKubeClient = func(genericclioptions.RESTClientGetter, *runclient.Options) (client.WithWatch, error) {
+		return kubeClient, nil
+	}
+	t.Cleanup(func() { newKubeClient = previous })
+	return kubeClient
+}
+
+// isolateEnv keeps the commands from reading the kubeconfig and the home
+// directory of the machine running the tests.
+func isolateEnv(t *testing.T) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("KUBECONFIG", filepath.Join(home, "kubeconfig"))
+}
+
+// readObjectFile returns the objects of a yaml file to seed a fake cluster with.
+func readObjectFile(t *testing.T, objectFile string) []client.Object {
+	t.Helper()
+	f, err := os.Open(objectFile)
+	if err != nil {
+		t.Fatalf("Error reading file '%s': %v", objectFile, err)
+	}
+	defer f.Close()
+	objects, err := readYamlObjects(f)
+	if err != nil {
+		t.Fatalf("Error decoding yaml file '%s': %v", objectFile, err)
+	}
+	var result []client.Object
+	for _, obj := range objects {
+		result = append(result, obj)
+	}
+	return result
+}
+
+// Structure used for table-driven tests running a command against a fake
+// cluster and comparing its output with a golden file.
+type fakeCmdTestCase struct {
+	name string
+	// The command line arguments to test.
+	args string
+	// Filename that contains yaml objects to seed the fake cluster with.
+	objectFile string
+	// Filename that contains the expected output, used when wantErr is empty.
+	goldenFile string
+	// The expected error of the command.
+	wantErr string
+}
+
+func runFakeCmdTests(t *testing.T, tests []fakeCmdTestCase) {
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolateEnv(t)
+			var objects []client.Object
+			if tt.objectFile != "" {
+				objects = readObjectFile(t, tt.objectFile)
+			}
+			useFakeCluster(t, objects...)
+
+			cmd := cmdTestCase{
+				args:   tt.args,
+				assert: assertGoldenFile(tt.goldenFile),
+			}
+			if tt.wantErr != "" {
+				cmd.assert = assertError(tt.wantErr)
+			}
+			cmd.runTestCmd(t)
+		})
+	}
+}
+
 type TestClusterMode int
 
 const (
@@ -388,18 +469,32 @@ func executeCommand(cmd string) (string, error) {
 // Note: this will also clear default value of the flags set in init()
 func resetCmdArgs() {
 	*kubeconfigArgs.Namespace = rootArgs.defaults.Namespace
+	rootArgs.confirmContext = ""
+	rootArgs.auditEvents = false
 	alertArgs = alertFlags{}
 	alertProviderArgs = alertProviderFlags{}
+	applyArgs = applyFlags{}
+	azureDevOpsArgs = azureDevOpsFlags{}
 	bootstrapArgs = NewBootstrapFlags()
+	bootstrapMigrateArgs = bootstrapMigrateFlags{}
+	bootstrapStatusArgs = bootstrapStatusFlags{repositoryDir: "."}
 	bServerArgs = bServerFlags{}
 	buildKsArgs = buildKsFlags{}
 	checkArgs = checkFlags{}
+	codeCommitArgs = codeCommitFlags{}
 	createArgs = createFlags{}
 	deleteArgs = deleteFlags{}
+	deleteKsArgs = deleteKsFlags{}
+	diffHelmReleaseArgs = diffHelmReleaseFlags{}
 	diffKsArgs = diffKsFlags{}
-	exportArgs = exportFlags{}
+	eventsExportArgs = eventsExportFlags{}
+	exportArgs = newExportFlags()
 	getArgs = GetFlags{}
+	getKsArgs = getKsFlags{}
+	getAlertArgs = getAlertFlags{}
+	getSourceGitArgs = getSourceGitFlags{}
 	gitArgs = gitFlags{}
+	ociArgs = newOCIFlags()
 	githubArgs = githubFlags{}
 	gitlabArgs = gitlabFlags{}
 	helmReleaseArgs = helmReleaseFlags{
@@ -409,18 +504,31 @@ func resetCmdArgs() {
 	imageRepoArgs = imageRepoFlags{}
 	imageUpdateArgs = imageUpdateFlags{}
 	kustomizationArgs = NewKustomizationFlags()
+	manifestsArgs = manifestsFlags{}
+	installArgs = NewInstallFlags()
 	receiverArgs = receiverFlags{}
 	resumeArgs = ResumeFlags{}
+	resumeKsArgs = resumeKsFlags{}
+	reconcileArgs = reconcileFlags{}
+	reconcileReceiverArgs = reconcileReceiverFlags{
+		fluxNamespace: rootArgs.defaults.Namespace,
+	}
+	reconcileImageRepositoryArgs = reconcileImageRepositoryFlags{}
 	rhrArgs = reconcileHelmReleaseFlags{}
 	rksArgs = reconcileKsFlags{}
 	secretGitArgs = NewSecretGitFlags()
+	configControllersArgs = configControllersFlags{}
+	secretGPGKeysArgs = secretGPGKeysFlags{}
 	secretHelmArgs = secretHelmFlags{}
 	secretTLSArgs = secretTLSFlags{}
 	sourceBucketArgs = sourceBucketFlags{}
 	sourceGitArgs = newSourceGitFlags()
 	sourceHelmArgs = sourceHelmFlags{}
 	sourceOCIRepositoryArgs = sourceOCIRepositoryFlags{}
+	evalImagePolicyArgs = evalImagePolicyFlags{}
+	exportImageUpdateArgs = exportImageUpdateFlags{}
 	suspendArgs = SuspendFlags{}
+	suspendKsArgs = suspendKsFlags{}
 	tenantArgs = tenantFlags{}
 	traceArgs = traceFlags{}
 	treeKsArgs = TreeKsFlags{}
@@ -0,0 +1,137 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/fluxcd/flux2/internal/utils"
+	"github.com/fluxcd/flux2/pkg/manifestgen"
+	"github.com/fluxcd/flux2/pkg/manifestgen/install"
+)
+
+var manifestsCmd = &cobra.Command{
+	Use:   "manifests",
+	Short: "Print the install manifests",
+	Long: `The manifests command prints the manifests of the toolkit components without applying them,
+either as the raw manifests the install is built from, or as built by flux install with --build.`,
+	Example: `  # Print the raw manifests of the default components
+  flux manifests
+
+  # Print the manifests of a given version, as built by flux install
+  flux manifests --version=v2.0.0 --build
+
+  # Scan the manifests of the image automation components
+  flux manifests --components=image-reflector-controller,image-automation-controller | kubeconform -`,
+	Args: cobra.NoArgs,
+	RunE: manifestsCmdRun,
+}
+
+type manifestsFlags struct {
+	version           string
+	defaultComponents []string
+	extraComponents   []string
+	registry          string
+	networkPolicy     bool
+	manifestsPath     string
+	build             bool
+}
+
+var manifestsArgs manifestsFlags
+
+func init() {
+	manifestsCmd.Flags().StringVarP(&manifestsArgs.version, "version", "v", "",
+		"toolkit version, when specified the manifests are downloaded from https://github.com/fluxcd/flux2/releases")
+	manifestsCmd.Flags().StringSliceVar(&manifestsArgs.defaultComponents, "components", rootArgs.defaults.Components,
+		"list of components, accepts comma-separated values")
+	manifestsCmd.Flags().StringSliceVar(&manifestsArgs.extraComponents, "components-extra", nil,
+		"list of components in addition to those supplied or defaulted, accepts values such as 'image-reflector-controller,image-automation-controller'")
+	manifestsCmd.Flags().StringVar(&manifestsArgs.registry, "registry", rootArgs.defaults.Registry,
+		"container registry where the toolkit images are published, used with --build")
+	manifestsCmd.Flags().BoolVar(&manifestsArgs.networkPolicy, "network-policy", rootArgs.defaults.NetworkPolicy,
+		"include the network policies denying ingress access to the toolkit controllers from other namespaces")
+	manifestsCmd.Flags().StringVar(&manifestsArgs.manifestsPath, "manifests", "", "path to the manifest directory")
+	manifestsCmd.Flags().BoolVar(&manifestsArgs.build, "build", false,
+		"print the manifests built with kustomize, as applied by flux install")
+	manifestsCmd.Flags().MarkHidden("manifests")
+
+	rootCmd.AddCommand(manifestsCmd)
+}
+
+func manifestsCmdRun(cmd *cobra.Command, args []string) error {
+	components := append(append([]string{}, manifestsArgs.defaultComponents...), manifestsArgs.extraComponents...)
+	if err := utils.ValidateComponents(components); err != nil {
+		return err
+	}
+
+	version, err := getVersion(manifestsArgs.version)
+	if err != nil {
+		return err
+	}
+
+	tmpDir, err := manifestgen.MkdirTempAbs("", *kubeconfigArgs.Namespace)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	manifestsBase := ""
+	if isEmbeddedVersion(version) {
+		if err := writeEmbeddedManifests(tmpDir); err != nil {
+			return err
+		}
+		manifestsBase = tmpDir
+	}
+
+	opts := install.MakeDefaultOptions()
+	opts.Version = version
+	opts.Namespace = *kubeconfigArgs.Namespace
+	opts.Components = components
+	opts.Registry = manifestsArgs.registry
+	opts.NetworkPolicy = manifestsArgs.networkPolicy
+	opts.NotificationController = rootArgs.defaults.NotificationController
+	opts.ManifestFile = fmt.Sprintf("%s.yaml", *kubeconfigArgs.Namespace)
+	opts.Timeout = rootArgs.timeout
+	if manifestsArgs.manifestsPath != "" {
+		opts.BaseURL = manifestsArgs.manifestsPath
+	}
+
+	if manifestsArgs.build {
+		manifest, err := install.Generate(opts, manifestsBase)
+		if err != nil {
+			return fmt.Errorf("manifests build failed: %w", err)
+		}
+		cmd.Print(manifest.Content)
+		return nil
+	}
+
+	manifests, err := install.Sources(opts, manifestsBase)
+	if err != nil {
+		return err
+	}
+	for _, m := range manifests {
+		cmd.Printf("---\n# Source: %s\n%s", m.Path, strings.TrimPrefix(m.Content, "---\n"))
+		if !strings.HasSuffix(m.Content, "\n") {
+			cmd.Println()
+		}
+	}
+	return nil
+}
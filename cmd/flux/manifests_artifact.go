@@ -0,0 +1,62 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	oci "github.com/fluxcd/pkg/oci/client"
+	"github.com/fluxcd/pkg/untar"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+)
+
+// extractManifestsArtifact writes the manifests of the Flux components to dir,
+// reading them from an OCI artifact when ref has the 'oci://' prefix or from
+// a local tarball otherwise. The content must have the same layout as the
+// manifests.tar.gz published with the Flux releases, which allows installing
+// Flux without access to GitHub.
+func extractManifestsArtifact(ctx context.Context, ref, dir string, components []string) error {
+	if strings.HasPrefix(ref, sourcev1.OCIRepositoryPrefix) {
+		url, err := oci.ParseArtifactURL(ref)
+		if err != nil {
+			return err
+		}
+		if _, err := oci.NewLocalClient().Pull(ctx, url, dir); err != nil {
+			return fmt.Errorf("failed to pull manifests from %s: %w", ref, err)
+		}
+	} else {
+		f, err := os.Open(ref)
+		if err != nil {
+			return fmt.Errorf("failed to open manifests tarball: %w", err)
+		}
+		defer f.Close()
+		if _, err := untar.Untar(f, dir); err != nil {
+			return fmt.Errorf("failed to extract manifests from %s: %w", ref, err)
+		}
+	}
+
+	for _, name := range append([]string{"rbac"}, components...) {
+		if _, err := os.Stat(filepath.Join(dir, name+".yaml")); err != nil {
+			return fmt.Errorf("manifests artifact %s does not contain %s.yaml", ref, name)
+		}
+	}
+	return nil
+}
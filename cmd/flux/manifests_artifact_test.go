@@ -0,0 +1,81 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestExtractManifestsArtifact(t *testing.T) {
+	g := NewWithT(t)
+
+	tarball := filepath.Join(t.TempDir(), "manifests.tar.gz")
+	g.Expect(writeTarball(tarball, map[string]string{
+		"rbac.yaml":              "kind: ClusterRole\n",
+		"source-controller.yaml": "kind: Deployment\n",
+	})).To(Succeed())
+
+	dir := t.TempDir()
+	err := extractManifestsArtifact(context.TODO(), tarball, dir, []string{"source-controller"})
+	g.Expect(err).ToNot(HaveOccurred())
+	content, err := os.ReadFile(filepath.Join(dir, "source-controller.yaml"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(content)).To(Equal("kind: Deployment\n"))
+
+	err = extractManifestsArtifact(context.TODO(), tarball, t.TempDir(), []string{"source-controller", "helm-controller"})
+	g.Expect(err).To(MatchError(ContainSubstring("does not contain helm-controller.yaml")))
+
+	err = extractManifestsArtifact(context.TODO(), filepath.Join(t.TempDir(), "missing.tar.gz"), t.TempDir(), nil)
+	g.Expect(err).To(MatchError(ContainSubstring("failed to open manifests tarball")))
+}
+
+func TestInstallManifestsArtifactExclusive(t *testing.T) {
+	cmd := cmdTestCase{
+		args:   "install --manifests=./manifests --manifests-artifact=oci://localhost/flux-manifests:v2.0.0",
+		assert: assertError("--manifests and --manifests-artifact are mutually exclusive"),
+	}
+	cmd.runTestCmd(t)
+}
+
+func writeTarball(path string, files map[string]string) error {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content))}); err != nil {
+			return err
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gw.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
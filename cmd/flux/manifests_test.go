@@ -0,0 +1,39 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import "testing"
+
+func TestManifestsCmd(t *testing.T) {
+	runFakeCmdTests(t, []fakeCmdTestCase{
+		{
+			name:       "raw manifests",
+			args:       "manifests --manifests=testdata/manifests --components=source-controller --network-policy=false",
+			goldenFile: "testdata/manifests/raw.golden",
+		},
+		{
+			name:       "built manifests",
+			args:       "manifests --manifests=testdata/manifests --components=source-controller --build",
+			goldenFile: "testdata/manifests/build.golden",
+		},
+		{
+			name:    "unknown component",
+			args:    "manifests --components=source-controller --components-extra=podinfo",
+			wantErr: "component podinfo is not available",
+		},
+	})
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	"github.com/spf13/cobra"
+	appsv1 "k8s.io/api/apps/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/fluxcd/flux2/pkg/manifestgen"
+)
+
+var pauseClusterCmd = &cobra.Command{
+	Use:   "cluster",
+	Short: "Pause Flux on the cluster for a maintenance window",
+	Long: `The pause cluster command scales the Flux controllers to zero replicas,
+or with --suspend-kustomizations suspends the top-level Kustomizations instead.
+The previous state is recorded on the objects, and is restored with 'flux resume cluster'.`,
+	Example: `  # Scale the Flux controllers to zero
+  flux pause cluster
+
+  # Suspend all the Kustomizations that are not managed by another Kustomization
+  flux pause cluster --suspend-kustomizations
+
+  # Restore the controllers and the Kustomizations after the maintenance
+  flux resume cluster`,
+	RunE: pauseClusterCmdRun,
+}
+
+type pauseClusterFlags struct {
+	suspendKustomizations bool
+}
+
+var pauseClusterArgs pauseClusterFlags
+
+const (
+	// pausedReplicasAnnotation records the replicas of a controller
+	// Deployment before it was scaled to zero by 'flux pause cluster'.
+	pausedReplicasAnnotation = "toolkit.fluxcd.io/paused-replicas"
+	// pausedAnnotation marks the Kustomizations suspended by
+	// 'flux pause cluster', so that only those are resumed.
+	pausedAnnotation = "toolkit.fluxcd.io/paused"
+)
+
+func init() {
+	pauseClusterCmd.Flags().BoolVar(&pauseClusterArgs.suspendKustomizations, "suspend-kustomizations", false,
+		"suspend the top-level Kustomizations in all namespaces instead of scaling the controllers to zero")
+
+	pauseCmd.AddCommand(pauseClusterCmd)
+}
+
+func pauseClusterCmdRun(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
+	if err != nil {
+		return err
+	}
+
+	if pauseClusterArgs.suspendKustomizations {
+		if err := suspendTopLevelKustomizations(ctx, kubeClient); err != nil {
+			return err
+		}
+	} else if err := scaleDownControllers(ctx, kubeClient, *kubeconfigArgs.Namespace); err != nil {
+		return err
+	}
+
+	recordNamespaceAuditEvent(ctx, kubeClient, *kubeconfigArgs.Namespace, "Paused", "Flux paused")
+	logger.Successf("Flux paused, run 'flux resume cluster' to resume")
+	return nil
+}
+
+// scaleDownControllers scales the Flux controller Deployments to zero and
+// records their replicas in an annotation.
+func scaleDownControllers(ctx context.Context, kubeClient client.Client, namespace string) error {
+	var list appsv1.DeploymentList
+	selector := client.MatchingLabels{manifestgen.PartOfLabelKey: manifestgen.PartOfLabelValue}
+	if err := kubeClient.List(ctx, &list, client.InNamespace(namespace), selector); err != nil {
+		return err
+	}
+	if len(list.Items) == 0 {
+		return fmt.Errorf("no controllers found in %s namespace", namespace)
+	}
+
+	for i := range list.Items {
+		deployment := &list.Items[i]
+		if _, ok := deployment.Annotations[pausedReplicasAnnotation]; ok {
+			logger.Successf("%s already paused", deployment.Name)
+			continue
+		}
+
+		replicas := int32(1)
+		if deployment.Spec.Replicas != nil {
+			replicas = *deployment.Spec.Replicas
+		}
+
+		logger.Actionf("scaling %s to zero", deployment.Name)
+		patch := client.MergeFrom(deployment.DeepCopy())
+		if deployment.Annotations == nil {
+			deployment.Annotations = make(map[string]string)
+		}
+		deployment.Annotations[pausedReplicasAnnotation] = strconv.Itoa(int(replicas))
+		zero := int32(0)
+		deployment.Spec.Replicas = &zero
+		if err := kubeClient.Patch(ctx, deployment, patch); err != nil {
+			return fmt.Errorf("failed to scale %s: %w", deployment.Name, err)
+		}
+	}
+	return nil
+}
+
+// suspendTopLevelKustomizations suspends the Kustomizations which are not
+// managed by another Kustomization and marks them with an annotation.
+// Kustomizations that are already suspended are left as is.
+func suspendTopLevelKustomizations(ctx context.Context, kubeClient client.Client) error {
+	var list kustomizev1.KustomizationList
+	if err := kubeClient.List(ctx, &list); err != nil {
+		return err
+	}
+
+	var found int
+	for i := range list.Items {
+		ks := &list.Items[i]
+		if !isTopLevelKustomization(ks) {
+			continue
+		}
+		found++
+		if ks.Spec.Suspend {
+			continue
+		}
+
+		logger.Actionf("suspending Kustomization %s in %s namespace", ks.Name, ks.Namespace)
+		patch := client.MergeFrom(ks.DeepCopy())
+		if ks.Annotations == nil {
+			ks.Annotations = make(map[string]string)
+		}
+		ks.Annotations[pausedAnnotation] = "true"
+		ks.Spec.Suspend = true
+		if err := kubeClient.Patch(ctx, ks, patch); err != nil {
+			return fmt.Errorf("failed to suspend %s/%s: %w", ks.Namespace, ks.Name, err)
+		}
+	}
+	if found == 0 {
+		return fmt.Errorf("no Kustomizations found")
+	}
+	return nil
+}
+
+// isTopLevelKustomization returns true if the Kustomization is not managed by
+// another Kustomization, e.g. it was created at bootstrap or manages itself.
+func isTopLevelKustomization(ks *kustomizev1.Kustomization) bool {
+	name, namespace, ok := kustomizationManager(ks)
+	return !ok || (name == ks.Name && namespace == ks.Namespace)
+}
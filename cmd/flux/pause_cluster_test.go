@@ -0,0 +1,134 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"testing"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/fluxcd/flux2/internal/utils"
+	"github.com/fluxcd/flux2/pkg/manifestgen"
+)
+
+func TestPauseResumeControllers(t *testing.T) {
+	replicas := int32(2)
+	kubeClient := fake.NewClientBuilder().WithScheme(utils.NewScheme()).WithObjects(
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "source-controller",
+				Namespace: "flux-system",
+				Labels:    map[string]string{manifestgen.PartOfLabelKey: manifestgen.PartOfLabelValue},
+			},
+			Spec: appsv1.DeploymentSpec{Replicas: &replicas},
+		},
+	).Build()
+	ctx := context.TODO()
+	key := client.ObjectKey{Namespace: "flux-system", Name: "source-controller"}
+
+	if err := scaleDownControllers(ctx, kubeClient, "flux-system"); err != nil {
+		t.Fatal(err)
+	}
+	// pausing twice must not overwrite the recorded replicas
+	if err := scaleDownControllers(ctx, kubeClient, "flux-system"); err != nil {
+		t.Fatal(err)
+	}
+
+	var deployment appsv1.Deployment
+	if err := kubeClient.Get(ctx, key, &deployment); err != nil {
+		t.Fatal(err)
+	}
+	if *deployment.Spec.Replicas != 0 {
+		t.Errorf("expected zero replicas, got %d", *deployment.Spec.Replicas)
+	}
+	if got := deployment.Annotations[pausedReplicasAnnotation]; got != "2" {
+		t.Errorf("expected recorded replicas '2', got '%s'", got)
+	}
+
+	scaled, err := scaleUpControllers(ctx, kubeClient, "flux-system")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scaled != 1 {
+		t.Errorf("expected one controller scaled, got %d", scaled)
+	}
+	if err := kubeClient.Get(ctx, key, &deployment); err != nil {
+		t.Fatal(err)
+	}
+	if *deployment.Spec.Replicas != 2 {
+		t.Errorf("expected 2 replicas, got %d", *deployment.Spec.Replicas)
+	}
+	if _, ok := deployment.Annotations[pausedReplicasAnnotation]; ok {
+		t.Errorf("expected %s annotation to be removed", pausedReplicasAnnotation)
+	}
+}
+
+func TestPauseResumeKustomizations(t *testing.T) {
+	managedBy := func(name string) map[string]string {
+		return map[string]string{
+			kustomizev1.GroupVersion.Group + "/name":      name,
+			kustomizev1.GroupVersion.Group + "/namespace": "flux-system",
+		}
+	}
+	kubeClient := fake.NewClientBuilder().WithScheme(utils.NewScheme()).WithObjects(
+		&kustomizev1.Kustomization{
+			ObjectMeta: metav1.ObjectMeta{Name: "flux-system", Namespace: "flux-system", Labels: managedBy("flux-system")},
+		},
+		&kustomizev1.Kustomization{
+			ObjectMeta: metav1.ObjectMeta{Name: "apps", Namespace: "flux-system", Labels: managedBy("flux-system")},
+		},
+		&kustomizev1.Kustomization{
+			ObjectMeta: metav1.ObjectMeta{Name: "infra", Namespace: "default"},
+			Spec:       kustomizev1.KustomizationSpec{Suspend: true},
+		},
+	).Build()
+	ctx := context.TODO()
+
+	if err := suspendTopLevelKustomizations(ctx, kubeClient); err != nil {
+		t.Fatal(err)
+	}
+
+	expectSuspended := func(namespace, name string, suspended bool) {
+		t.Helper()
+		var ks kustomizev1.Kustomization
+		if err := kubeClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &ks); err != nil {
+			t.Fatal(err)
+		}
+		if ks.Spec.Suspend != suspended {
+			t.Errorf("expected %s/%s suspend to be %v", namespace, name, suspended)
+		}
+	}
+	expectSuspended("flux-system", "flux-system", true)
+	expectSuspended("flux-system", "apps", false)
+	expectSuspended("default", "infra", true)
+
+	resumed, err := resumePausedKustomizations(ctx, kubeClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resumed != 1 {
+		t.Errorf("expected one Kustomization resumed, got %d", resumed)
+	}
+	expectSuspended("flux-system", "flux-system", false)
+	// suspended before the pause, must be left as is
+	expectSuspended("default", "infra", true)
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// pluginPrefix is the prefix of the executables on PATH which extend the
+// CLI with new commands, e.g. 'flux tenant' runs 'flux-tenant'.
+const pluginPrefix = "flux-"
+
+var pluginCmd = &cobra.Command{
+	Use:   "plugin",
+	Short: "Manage the flux plugins",
+	Long: `The plugin sub-commands manage the executables extending the CLI.
+
+Any executable on PATH named flux-<name> is a plugin, 'flux <name> [args]' runs it
+with the args when <name> is not a flux command. The kubeconfig, context and namespace
+given to flux are passed to the plugin in the FLUX_KUBECONFIG, FLUX_CONTEXT and
+FLUX_NAMESPACE environment variables.`,
+}
+
+func init() {
+	rootCmd.AddCommand(pluginCmd)
+}
+
+// runPlugin runs the flux-<name> plugin when the first argument which is not
+// a global flag names a plugin instead of a flux command. It returns false
+// when no plugin handles the command line, which is then left to cobra.
+func runPlugin(args []string) (bool, error) {
+	flagArgs, name, pluginArgs, ok := splitPluginArgs(rootCmd.PersistentFlags(), args)
+	if !ok || isFluxCommand(name) {
+		return false, nil
+	}
+	path, err := exec.LookPath(pluginPrefix + name)
+	if err != nil {
+		return false, nil
+	}
+	if err := rootCmd.PersistentFlags().Parse(flagArgs); err != nil {
+		return false, nil
+	}
+
+	cmd := exec.Command(path, pluginArgs...)
+	cmd.Env = append(os.Environ(), pluginEnv()...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return true, cmd.Run()
+}
+
+// splitPluginArgs splits the args in the global flags, the plugin name and
+// the plugin args. It returns false if there is no plugin name, or if the
+// args before it are not all global flags.
+func splitPluginArgs(flags *pflag.FlagSet, args []string) ([]string, string, []string, bool) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return nil, "", nil, false
+		}
+		if !strings.HasPrefix(arg, "-") {
+			if strings.HasPrefix(arg, "__") || strings.ContainsAny(arg, `/\`) {
+				return nil, "", nil, false
+			}
+			return args[:i], arg, args[i+1:], true
+		}
+
+		var flag *pflag.Flag
+		switch {
+		case strings.HasPrefix(arg, "--"):
+			name, _, _ := strings.Cut(arg[2:], "=")
+			flag = flags.Lookup(name)
+		case len(arg) >= 2:
+			flag = flags.ShorthandLookup(arg[1:2])
+		}
+		if flag == nil {
+			return nil, "", nil, false
+		}
+		// Skip the value of flags given as '--flag value' or '-f value'
+		if flag.NoOptDefVal == "" && !strings.Contains(arg, "=") && (strings.HasPrefix(arg, "--") || len(arg) == 2) {
+			i++
+		}
+	}
+	return nil, "", nil, false
+}
+
+// isFluxCommand returns true if name is a flux command or one of its
+// aliases, plugins can not override them.
+func isFluxCommand(name string) bool {
+	if name == "help" {
+		return true
+	}
+	for _, c := range rootCmd.Commands() {
+		if c.Name() == name || c.HasAlias(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// pluginEnv returns the environment variables passing the global flags to
+// the plugins.
+func pluginEnv() []string {
+	var kubeconfig, context string
+	if kubeconfigArgs.KubeConfig != nil {
+		kubeconfig = *kubeconfigArgs.KubeConfig
+	}
+	if kubeconfig == "" {
+		kubeconfig = os.Getenv("KUBECONFIG")
+	}
+	if kubeconfigArgs.Context != nil {
+		context = *kubeconfigArgs.Context
+	}
+	return []string{
+		"FLUX_KUBECONFIG=" + kubeconfig,
+		"FLUX_CONTEXT=" + context,
+		"FLUX_NAMESPACE=" + *kubeconfigArgs.Namespace,
+	}
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var pluginListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the plugins found on PATH",
+	Long: `The plugin list command prints the path of the flux-<name> executables found on PATH.
+Plugins shadowed by a flux command or by a plugin earlier on PATH are reported with a warning.`,
+	Example: `  # List the plugins
+  flux plugin list`,
+	Args: cobra.NoArgs,
+	RunE: pluginListCmdRun,
+}
+
+func init() {
+	pluginCmd.AddCommand(pluginListCmd)
+}
+
+func pluginListCmdRun(cmd *cobra.Command, args []string) error {
+	seen := map[string]string{}
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		for _, path := range findPlugins(dir) {
+			name := pluginName(path)
+			switch {
+			case isFluxCommand(name):
+				logger.Warningf("%s is shadowed by the flux %s command", path, name)
+			case seen[name] != "":
+				logger.Warningf("%s is shadowed by %s", path, seen[name])
+			default:
+				seen[name] = path
+				rootCmd.Println(path)
+			}
+		}
+	}
+	if len(seen) == 0 {
+		logger.Failuref("no plugins found on PATH")
+	}
+	return nil
+}
+
+// findPlugins returns the paths of the plugin executables in dir.
+func findPlugins(dir string) []string {
+	if dir == "" {
+		dir = "."
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var plugins []string
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), pluginPrefix) || pluginName(entry.Name()) == "" {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		if runtime.GOOS == "windows" {
+			if !strings.EqualFold(filepath.Ext(entry.Name()), ".exe") {
+				continue
+			}
+		} else if info.Mode().Perm()&0o111 == 0 {
+			continue
+		}
+		plugins = append(plugins, filepath.Join(dir, entry.Name()))
+	}
+	return plugins
+}
+
+// pluginName returns the command name of the plugin at path.
+func pluginName(path string) string {
+	name := strings.TrimPrefix(filepath.Base(path), pluginPrefix)
+	if runtime.GOOS == "windows" {
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+	return name
+}
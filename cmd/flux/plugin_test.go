@@ -0,0 +1,115 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestSplitPluginArgs(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantFlags  []string
+		wantName   string
+		wantArgs   []string
+		wantPlugin bool
+	}{
+		{
+			name:       "plugin only",
+			args:       []string{"tenant", "create", "dev"},
+			wantFlags:  []string{},
+			wantName:   "tenant",
+			wantArgs:   []string{"create", "dev"},
+			wantPlugin: true,
+		},
+		{
+			name:       "global flags before the plugin",
+			args:       []string{"--context", "kind", "-n=apps", "--verbose", "tenant", "--force"},
+			wantFlags:  []string{"--context", "kind", "-n=apps", "--verbose"},
+			wantName:   "tenant",
+			wantArgs:   []string{"--force"},
+			wantPlugin: true,
+		},
+		{
+			name: "unknown flag",
+			args: []string{"--unknown", "tenant"},
+		},
+		{
+			name: "no plugin name",
+			args: []string{"--verbose"},
+		},
+		{
+			name: "completion request",
+			args: []string{"__complete", "tenant"},
+		},
+		{
+			name: "path",
+			args: []string{"../tenant"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			flags, name, args, ok := splitPluginArgs(rootCmd.PersistentFlags(), tt.args)
+			g.Expect(ok).To(Equal(tt.wantPlugin))
+			if !tt.wantPlugin {
+				return
+			}
+			g.Expect(flags).To(Equal(tt.wantFlags))
+			g.Expect(name).To(Equal(tt.wantName))
+			g.Expect(args).To(Equal(tt.wantArgs))
+		})
+	}
+}
+
+func TestRunPlugin(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test plugin is a shell script")
+	}
+	g := NewWithT(t)
+	defer resetCmdArgs()
+	defer func(ctx string) { *kubeconfigArgs.Context = ctx }(*kubeconfigArgs.Context)
+
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out")
+	script := "#!/bin/sh\necho \"$@ $FLUX_CONTEXT $FLUX_NAMESPACE\" > " + out + "\n"
+	g.Expect(os.WriteFile(filepath.Join(dir, "flux-tenant"), []byte(script), 0o755)).To(Succeed())
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	ok, err := runPlugin([]string{"--context=kind", "-n", "apps", "tenant", "create", "dev"})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(ok).To(BeTrue())
+	data, err := os.ReadFile(out)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(data)).To(Equal("create dev kind apps\n"))
+
+	g.Expect(findPlugins(dir)).To(Equal([]string{filepath.Join(dir, "flux-tenant")}))
+
+	ok, err = runPlugin([]string{"version", "--client"})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(ok).To(BeFalse())
+
+	ok, err = runPlugin([]string{"missing"})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(ok).To(BeFalse())
+}
@@ -19,21 +19,23 @@ package main
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/spf13/cobra"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	notificationv1 "github.com/fluxcd/notification-controller/api/v1beta2"
 	"github.com/fluxcd/pkg/apis/meta"
-
-	"github.com/fluxcd/flux2/internal/utils"
 )
 
 var reconcileCmd = &cobra.Command{
@@ -42,13 +44,49 @@ var reconcileCmd = &cobra.Command{
 	Long:  "The reconcile sub-commands trigger a reconciliation of sources and resources.",
 }
 
+type reconcileFlags struct {
+	annotations []string
+	all         bool
+	selector    string
+}
+
+var reconcileArgs reconcileFlags
+
 func init() {
+	reconcileCmd.PersistentFlags().StringSliceVar(&reconcileArgs.annotations, "annotate", nil,
+		"set annotations on the object together with the reconcile request, in the format 'key=value' (can specify multiple annotations with commas: key1=value1,key2=value2)")
+	reconcileCmd.PersistentFlags().BoolVar(&reconcileArgs.all, "all", false,
+		"reconcile all resources in that namespace concurrently")
+	reconcileCmd.PersistentFlags().StringVarP(&reconcileArgs.selector, "selector", "l", "",
+		"reconcile the resources in that namespace matching the label selector concurrently, e.g. 'app=podinfo'")
 	rootCmd.AddCommand(reconcileCmd)
 }
 
+// parseReconcileAnnotations returns the annotations given with --annotate.
+func parseReconcileAnnotations() (map[string]string, error) {
+	result := make(map[string]string)
+	for _, annotation := range reconcileArgs.annotations {
+		key, value, ok := strings.Cut(annotation, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid annotation format '%s', must be key=value", annotation)
+		}
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid annotation key '%s': %s", key, strings.Join(errs, "; "))
+		}
+		result[key] = value
+	}
+	return result, nil
+}
+
 type reconcileCommand struct {
 	apiType
 	object reconcilable
+	list   listReconcilable
+}
+
+type listReconcilable interface {
+	listAdapter
+	reconcileItem(i int) reconcilable
 }
 
 type reconcilable interface {
@@ -77,25 +115,56 @@ func reconcilableConditions(object reconcilable) []metav1.Condition {
 }
 
 func (reconcile reconcileCommand) run(cmd *cobra.Command, args []string) error {
-	if len(args) < 1 {
+	if len(args) < 1 && !reconcileArgs.all && reconcileArgs.selector == "" {
 		return fmt.Errorf("%s name is required", reconcile.kind)
 	}
-	name := args[0]
+
+	annotations, err := parseReconcileAnnotations()
+	if err != nil {
+		return err
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(kubeconfigArgs, kubeclientOptions)
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
 	if err != nil {
 		return err
 	}
 
-	namespacedName := types.NamespacedName{
-		Namespace: *kubeconfigArgs.Namespace,
-		Name:      name,
+	if len(args) == 1 && !reconcileArgs.all && reconcileArgs.selector == "" {
+		return reconcile.reconcileObject(ctx, kubeClient, types.NamespacedName{
+			Namespace: *kubeconfigArgs.Namespace,
+			Name:      args[0],
+		}, annotations)
+	}
+
+	targets, err := reconcile.listTargets(ctx, kubeClient, args)
+	if err != nil {
+		return err
+	}
+	if len(targets) == 0 {
+		logger.Failuref("no %s objects found in %s namespace", reconcile.kind, *kubeconfigArgs.Namespace)
+		return nil
+	}
+
+	sources, err := reconcileSources(ctx, kubeClient, targets)
+	if err != nil {
+		return err
+	}
+	if len(sources) > 0 {
+		if err := reconcileConcurrently(ctx, kubeClient, sources, nil); err != nil {
+			return err
+		}
 	}
+	return reconcileConcurrently(ctx, kubeClient, targets, annotations)
+}
 
-	err = kubeClient.Get(ctx, namespacedName, reconcile.object.asClientObject())
+// reconcileObject requests the reconciliation of the named object, and of
+// its source if asked to, and waits for it to finish.
+func (reconcile reconcileCommand) reconcileObject(ctx context.Context, kubeClient client.Client,
+	namespacedName types.NamespacedName, annotations map[string]string) error {
+	err := kubeClient.Get(ctx, namespacedName, reconcile.object.asClientObject())
 	if err != nil {
 		return err
 	}
@@ -104,25 +173,68 @@ func (reconcile reconcileCommand) run(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("resource is suspended")
 	}
 
-	logger.Actionf("annotating %s %s in %s namespace", reconcile.kind, name, *kubeconfigArgs.Namespace)
-	if err := requestReconciliation(ctx, kubeClient, namespacedName,
-		reconcile.groupVersion.WithKind(reconcile.kind)); err != nil {
+	if source, ok := reconcile.source(); ok {
+		sourceCmd, sourceName := source.getSource()
+		if sourceName.Namespace == "" {
+			sourceName.Namespace = namespacedName.Namespace
+		}
+		if err := sourceCmd.reconcileObject(ctx, kubeClient, sourceName, nil); err != nil {
+			return err
+		}
+	}
+
+	logger.Actionf("annotating %s %s in %s namespace", reconcile.kind, namespacedName.Name, namespacedName.Namespace)
+	lastHandledReconcileAt, err := reconcile.trigger(ctx, kubeClient, annotations)
+	if err != nil {
 		return err
 	}
 	logger.Successf("%s annotated", reconcile.kind)
 
-	if reconcile.kind == notificationv1.AlertKind || reconcile.kind == notificationv1.ReceiverKind {
-		if err = wait.PollImmediate(rootArgs.pollInterval, rootArgs.timeout,
-			isReconcileReady(ctx, kubeClient, namespacedName, reconcile.object)); err != nil {
-			return err
-		}
+	if !reconcile.waitsForReadiness() {
+		logger.Waitingf("waiting for %s reconciliation", reconcile.kind)
+	}
+	if err := reconcile.waitFor(ctx, kubeClient, lastHandledReconcileAt); err != nil {
+		return err
+	}
+	logger.Successf(reconcile.object.successMessage())
+	return nil
+}
 
-		logger.Successf(reconcile.object.successMessage())
-		return nil
+// source returns the object as reconcileWithSource if its source should be
+// reconciled along with it.
+func (reconcile reconcileCommand) source() (reconcileWithSource, bool) {
+	source, ok := reconcile.object.(reconcileWithSource)
+	if !ok || !source.reconcileSource() {
+		return nil, false
 	}
+	return source, true
+}
+
+// waitsForReadiness returns true for the kinds that don't record the last
+// handled reconcile request, for which only the Ready condition is checked.
+func (reconcile reconcileCommand) waitsForReadiness() bool {
+	return reconcile.kind == notificationv1.AlertKind || reconcile.kind == notificationv1.ReceiverKind
+}
 
+// trigger sets the reconcile request annotation on the object and returns
+// the last handled reconcile request from before the annotation.
+func (reconcile reconcileCommand) trigger(ctx context.Context, kubeClient client.Client,
+	annotations map[string]string) (string, error) {
 	lastHandledReconcileAt := reconcile.object.lastHandledReconcileRequest()
-	logger.Waitingf("waiting for %s reconciliation", reconcile.kind)
+	err := requestReconciliation(ctx, kubeClient, client.ObjectKeyFromObject(reconcile.object.asClientObject()),
+		reconcile.groupVersion.WithKind(reconcile.kind), annotations)
+	return lastHandledReconcileAt, err
+}
+
+// waitFor waits for the reconcile request to be handled and returns an
+// error if the object is not ready afterwards.
+func (reconcile reconcileCommand) waitFor(ctx context.Context, kubeClient client.Client, lastHandledReconcileAt string) error {
+	namespacedName := client.ObjectKeyFromObject(reconcile.object.asClientObject())
+	if reconcile.waitsForReadiness() {
+		return wait.PollImmediate(rootArgs.pollInterval, rootArgs.timeout,
+			isReconcileReady(ctx, kubeClient, namespacedName, reconcile.object))
+	}
+
 	if err := wait.PollImmediate(rootArgs.pollInterval, rootArgs.timeout,
 		reconciliationHandled(ctx, kubeClient, namespacedName, reconcile.object, lastHandledReconcileAt)); err != nil {
 		return err
@@ -133,9 +245,123 @@ func (reconcile reconcileCommand) run(cmd *cobra.Command, args []string) error {
 	}
 
 	if readyCond.Status != metav1.ConditionTrue {
-		return fmt.Errorf("%s reconciliation failed: '%s'", reconcile.kind, readyCond.Message)
+		return fmt.Errorf("%s reconciliation failed: %w", reconcile.kind, conditionError(reconcile.object.asClientObject(), readyCond))
+	}
+	return nil
+}
+
+// listTargets returns the objects in the namespace matching the given names
+// and --selector, skipping the suspended ones.
+func (reconcile reconcileCommand) listTargets(ctx context.Context, kubeClient client.Client, names []string) ([]reconcileCommand, error) {
+	if reconcile.list == nil {
+		return nil, fmt.Errorf("reconciling multiple %s objects is not supported", reconcile.kind)
+	}
+
+	listOpts := []client.ListOption{client.InNamespace(*kubeconfigArgs.Namespace)}
+	if reconcileArgs.selector != "" {
+		selector, err := labels.Parse(reconcileArgs.selector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector '%s': %w", reconcileArgs.selector, err)
+		}
+		listOpts = append(listOpts, client.MatchingLabelsSelector{Selector: selector})
+	}
+	if err := kubeClient.List(ctx, reconcile.list.asClientList(), listOpts...); err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]reconcilable, reconcile.list.len())
+	var items []reconcilable
+	for i := 0; i < reconcile.list.len(); i++ {
+		item := reconcile.list.reconcileItem(i)
+		found[item.asClientObject().GetName()] = item
+		items = append(items, item)
+	}
+	if len(names) > 0 {
+		items = items[:0]
+		for _, name := range names {
+			item, ok := found[name]
+			if !ok {
+				return nil, fmt.Errorf("%s object '%s' not found in %q namespace", reconcile.kind, name, *kubeconfigArgs.Namespace)
+			}
+			items = append(items, item)
+		}
+	}
+
+	var targets []reconcileCommand
+	for _, item := range items {
+		if item.isSuspended() {
+			logger.Warningf("%s %s is suspended, skipping", reconcile.kind, item.asClientObject().GetName())
+			continue
+		}
+		targets = append(targets, reconcileCommand{apiType: reconcile.apiType, object: item})
+	}
+	return targets, nil
+}
+
+// reconcileSources returns the distinct sources of the targets that should
+// be reconciled along with them.
+func reconcileSources(ctx context.Context, kubeClient client.Client, targets []reconcileCommand) ([]reconcileCommand, error) {
+	var sources []reconcileCommand
+	seen := make(map[string]bool)
+	for _, target := range targets {
+		source, ok := target.source()
+		if !ok {
+			continue
+		}
+		sourceCmd, sourceName := source.getSource()
+		if sourceName.Namespace == "" {
+			sourceName.Namespace = target.object.asClientObject().GetNamespace()
+		}
+		key := sourceCmd.kind + "/" + sourceName.String()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		if err := kubeClient.Get(ctx, sourceName, sourceCmd.object.asClientObject()); err != nil {
+			return nil, err
+		}
+		if sourceCmd.object.isSuspended() {
+			return nil, fmt.Errorf("%s %s is suspended", sourceCmd.kind, sourceName)
+		}
+		sources = append(sources, sourceCmd)
+	}
+	return sources, nil
+}
+
+// reconcileConcurrently triggers the reconciliation of the targets, waits
+// for all of them to finish and reports the results in order.
+func reconcileConcurrently(ctx context.Context, kubeClient client.Client, targets []reconcileCommand, annotations map[string]string) error {
+	errs := make([]error, len(targets))
+	var wg sync.WaitGroup
+	for i := range targets {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			lastHandledReconcileAt, err := targets[i].trigger(ctx, kubeClient, annotations)
+			if err == nil {
+				err = targets[i].waitFor(ctx, kubeClient, lastHandledReconcileAt)
+			}
+			errs[i] = err
+		}(i)
+	}
+
+	logger.Waitingf("waiting for %d reconciliations", len(targets))
+	wg.Wait()
+
+	var failed int
+	for i, target := range targets {
+		obj := target.object.asClientObject()
+		if errs[i] != nil {
+			failed++
+			logger.Failuref("%s %s/%s: %s", target.kind, obj.GetNamespace(), obj.GetName(), errs[i])
+			continue
+		}
+		logger.Successf("%s %s/%s: %s", target.kind, obj.GetNamespace(), obj.GetName(), target.object.successMessage())
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d reconciliations failed", failed, len(targets))
 	}
-	logger.Successf(reconcile.object.successMessage())
 	return nil
 }
 
@@ -152,8 +378,10 @@ func reconciliationHandled(ctx context.Context, kubeClient client.Client,
 	}
 }
 
+// requestReconciliation sets the reconcile request annotation on the object,
+// along with the given annotations, in a single patch.
 func requestReconciliation(ctx context.Context, kubeClient client.Client,
-	namespacedName types.NamespacedName, gvk schema.GroupVersionKind) error {
+	namespacedName types.NamespacedName, gvk schema.GroupVersionKind, annotations map[string]string) error {
 	return retry.RetryOnConflict(retry.DefaultBackoff, func() (err error) {
 		object := &metav1.PartialObjectMetadata{}
 		object.SetGroupVersionKind(gvk)
@@ -163,15 +391,21 @@ func requestReconciliation(ctx context.Context, kubeClient client.Client,
 			return err
 		}
 		patch := client.MergeFrom(object.DeepCopy())
-		if ann := object.GetAnnotations(); ann == nil {
-			object.SetAnnotations(map[string]string{
-				meta.ReconcileRequestAnnotation: time.Now().Format(time.RFC3339Nano),
-			})
-		} else {
-			ann[meta.ReconcileRequestAnnotation] = time.Now().Format(time.RFC3339Nano)
-			object.SetAnnotations(ann)
-		}
-		return kubeClient.Patch(ctx, object, patch)
+		ann := object.GetAnnotations()
+		if ann == nil {
+			ann = make(map[string]string, len(annotations)+1)
+		}
+		for k, v := range annotations {
+			ann[k] = v
+		}
+		ann[meta.ReconcileRequestAnnotation] = time.Now().Format(time.RFC3339Nano)
+		object.SetAnnotations(ann)
+		if err := kubeClient.Patch(ctx, object, patch); err != nil {
+			return err
+		}
+		recordAuditEvent(ctx, kubeClient, gvk, object, "ReconcileRequested",
+			fmt.Sprintf("%s reconciliation requested", gvk.Kind))
+		return nil
 	})
 }
 
@@ -188,7 +422,7 @@ func isReconcileReady(ctx context.Context, kubeClient client.Client,
 			case metav1.ConditionTrue:
 				return true, nil
 			case metav1.ConditionFalse:
-				return false, fmt.Errorf(c.Message)
+				return false, conditionError(obj.asClientObject(), c)
 			}
 		}
 		return false, nil
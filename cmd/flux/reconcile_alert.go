@@ -23,7 +23,7 @@ import (
 )
 
 var reconcileAlertCmd = &cobra.Command{
-	Use:   "alert [name]",
+	Use:   "alert [name...]",
 	Short: "Reconcile an Alert",
 	Long:  `The reconcile alert command triggers a reconciliation of an Alert resource and waits for it to finish.`,
 	Example: `  # Trigger a reconciliation for an existing alert
@@ -32,6 +32,7 @@ var reconcileAlertCmd = &cobra.Command{
 	RunE: reconcileCommand{
 		apiType: alertType,
 		object:  alertAdapter{&notificationv1.Alert{}},
+		list:    &alertListAdapter{&notificationv1.AlertList{}},
 	}.run,
 }
 
@@ -42,3 +43,7 @@ func init() {
 func (obj alertAdapter) lastHandledReconcileRequest() string {
 	return ""
 }
+
+func (a alertListAdapter) reconcileItem(i int) reconcilable {
+	return &alertAdapter{&a.AlertList.Items[i]}
+}
@@ -27,8 +27,6 @@ import (
 
 	notificationv1 "github.com/fluxcd/notification-controller/api/v1beta2"
 	"github.com/fluxcd/pkg/apis/meta"
-
-	"github.com/fluxcd/flux2/internal/utils"
 )
 
 var reconcileAlertProviderCmd = &cobra.Command{
@@ -49,12 +47,20 @@ func reconcileAlertProviderCmdRun(cmd *cobra.Command, args []string) error {
 	if len(args) < 1 {
 		return fmt.Errorf("Provider name is required")
 	}
+	if len(args) > 1 || reconcileArgs.all || reconcileArgs.selector != "" {
+		return fmt.Errorf("reconciling multiple Provider objects is not supported")
+	}
 	name := args[0]
 
+	annotations, err := parseReconcileAnnotations()
+	if err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(kubeconfigArgs, kubeclientOptions)
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
 	if err != nil {
 		return err
 	}
@@ -78,6 +84,9 @@ func reconcileAlertProviderCmdRun(cmd *cobra.Command, args []string) error {
 	} else {
 		alertProvider.Annotations[meta.ReconcileRequestAnnotation] = time.Now().Format(time.RFC3339Nano)
 	}
+	for k, v := range annotations {
+		alertProvider.Annotations[k] = v
+	}
 	if err := kubeClient.Update(ctx, &alertProvider); err != nil {
 		return err
 	}
@@ -25,7 +25,7 @@ import (
 )
 
 var reconcileHrCmd = &cobra.Command{
-	Use:     "helmrelease [name]",
+	Use:     "helmrelease [name...]",
 	Aliases: []string{"hr"},
 	Short:   "Reconcile a HelmRelease resource",
 	Long: `
@@ -34,11 +34,18 @@ The reconcile kustomization command triggers a reconciliation of a HelmRelease r
   flux reconcile hr podinfo
 
   # Trigger a reconciliation of the HelmRelease's source and apply changes
-  flux reconcile hr podinfo --with-source`,
+  flux reconcile hr podinfo --with-source
+
+  # Trigger the reconciliation of all HelmReleases in a namespace and wait for them concurrently
+  flux reconcile hr --all -n apps
+
+  # Trigger a reconciliation and set a custom annotation in the same request
+  flux reconcile hr podinfo --annotate=example.com/reason=hotfix`,
 	ValidArgsFunction: resourceNamesCompletionFunc(helmv2.GroupVersion.WithKind(helmv2.HelmReleaseKind)),
 	RunE: reconcileWithSourceCommand{
 		apiType: helmReleaseType,
 		object:  helmReleaseAdapter{&helmv2.HelmRelease{}},
+		list:    helmReleaseListAdapter{&helmv2.HelmReleaseList{}},
 	}.run,
 }
 
@@ -58,6 +65,10 @@ func (obj helmReleaseAdapter) lastHandledReconcileRequest() string {
 	return obj.Status.GetLastHandledReconcileRequest()
 }
 
+func (a helmReleaseListAdapter) reconcileItem(i int) reconcilable {
+	return &helmReleaseAdapter{&a.HelmReleaseList.Items[i]}
+}
+
 func (obj helmReleaseAdapter) reconcileSource() bool {
 	return rhrArgs.syncHrWithSource
 }
@@ -17,34 +17,95 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/spf13/cobra"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	imagev1 "github.com/fluxcd/image-reflector-controller/api/v1beta2"
 )
 
 var reconcileImageRepositoryCmd = &cobra.Command{
-	Use:   "repository [name]",
+	Use:   "repository [name...]",
 	Short: "Reconcile an ImageRepository",
 	Long:  `The reconcile image repository command triggers a reconciliation of an ImageRepository resource and waits for it to finish.`,
 	Example: `  # Trigger an scan for an existing image repository
-  flux reconcile image repository alpine`,
+  flux reconcile image repository alpine
+
+  # Discard the last scan result and rescan all the tags of the image repository
+  flux reconcile image repository alpine --scan-now`,
 	ValidArgsFunction: resourceNamesCompletionFunc(imagev1.GroupVersion.WithKind(imagev1.ImagePolicyKind)),
-	RunE: reconcileCommand{
-		apiType: imageRepositoryType,
-		object:  imageRepositoryAdapter{&imagev1.ImageRepository{}},
-	}.run,
+	RunE:              reconcileImageRepositoryCmdRun,
+}
+
+type reconcileImageRepositoryFlags struct {
+	scanNow bool
 }
 
+var reconcileImageRepositoryArgs reconcileImageRepositoryFlags
+
 func init() {
+	reconcileImageRepositoryCmd.Flags().BoolVar(&reconcileImageRepositoryArgs.scanNow, "scan-now", false,
+		"discard the last scan result so that all the tags are fetched again from the registry, e.g. after tags were mutated in the registry")
 	reconcileImageCmd.AddCommand(reconcileImageRepositoryCmd)
 }
 
+func reconcileImageRepositoryCmdRun(cmd *cobra.Command, args []string) error {
+	reconcile := reconcileCommand{
+		apiType: imageRepositoryType,
+		object:  imageRepositoryAdapter{&imagev1.ImageRepository{}},
+		list:    imageRepositoryListAdapter{&imagev1.ImageRepositoryList{}},
+	}
+	if !reconcileImageRepositoryArgs.scanNow || (len(args) < 1 && !reconcileArgs.all && reconcileArgs.selector == "") {
+		return reconcile.run(cmd, args)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
+	if err != nil {
+		return err
+	}
+
+	if err := resetImageRepositoryScans(ctx, kubeClient, reconcile, args); err != nil {
+		return err
+	}
+	return reconcile.run(cmd, args)
+}
+
+// resetImageRepositoryScans removes the last scan result from the status of
+// the targeted image repositories, which makes image-reflector-controller
+// perform a full scan instead of relying on the tags it has already seen.
+func resetImageRepositoryScans(ctx context.Context, kubeClient client.Client, reconcile reconcileCommand, names []string) error {
+	targets, err := reconcile.listTargets(ctx, kubeClient, names)
+	if err != nil {
+		return err
+	}
+	for _, target := range targets {
+		repository, ok := target.object.asClientObject().(*imagev1.ImageRepository)
+		if !ok || repository.Status.LastScanResult == nil {
+			continue
+		}
+		logger.Actionf("discarding the last scan result of %s %s", imagev1.ImageRepositoryKind, repository.Name)
+		patch := client.MergeFrom(repository.DeepCopy())
+		repository.Status.LastScanResult = nil
+		if err := kubeClient.Status().Patch(ctx, repository, patch); err != nil {
+			return fmt.Errorf("failed to reset the scan result of %s: %w", repository.Name, err)
+		}
+	}
+	return nil
+}
+
 func (obj imageRepositoryAdapter) lastHandledReconcileRequest() string {
 	return obj.Status.GetLastHandledReconcileRequest()
 }
 
+func (a imageRepositoryListAdapter) reconcileItem(i int) reconcilable {
+	return &imageRepositoryAdapter{&a.ImageRepositoryList.Items[i]}
+}
+
 func (obj imageRepositoryAdapter) successMessage() string {
 	return fmt.Sprintf("scan fetched %d tags", obj.Status.LastScanResult.TagCount)
 }
@@ -27,7 +27,7 @@ import (
 )
 
 var reconcileImageUpdateCmd = &cobra.Command{
-	Use:   "update [name]",
+	Use:   "update [name...]",
 	Short: "Reconcile an ImageUpdateAutomation",
 	Long:  `The reconcile image update command triggers a reconciliation of an ImageUpdateAutomation resource and waits for it to finish.`,
 	Example: `  # Trigger an automation run for an existing image update automation
@@ -36,6 +36,7 @@ var reconcileImageUpdateCmd = &cobra.Command{
 	RunE: reconcileCommand{
 		apiType: imageUpdateAutomationType,
 		object:  imageUpdateAutomationAdapter{&autov1.ImageUpdateAutomation{}},
+		list:    imageUpdateAutomationListAdapter{&autov1.ImageUpdateAutomationList{}},
 	}.run,
 }
 
@@ -51,6 +52,10 @@ func (obj imageUpdateAutomationAdapter) lastHandledReconcileRequest() string {
 	return obj.Status.GetLastHandledReconcileRequest()
 }
 
+func (a imageUpdateAutomationListAdapter) reconcileItem(i int) reconcilable {
+	return &imageUpdateAutomationAdapter{&a.ImageUpdateAutomationList.Items[i]}
+}
+
 func (obj imageUpdateAutomationAdapter) successMessage() string {
 	if rc := apimeta.FindStatusCondition(obj.Status.Conditions, meta.ReadyCondition); rc != nil {
 		return rc.Message
@@ -25,7 +25,7 @@ import (
 )
 
 var reconcileKsCmd = &cobra.Command{
-	Use:     "kustomization [name]",
+	Use:     "kustomization [name...]",
 	Aliases: []string{"ks"},
 	Short:   "Reconcile a Kustomization resource",
 	Long: `
@@ -34,11 +34,21 @@ The reconcile kustomization command triggers a reconciliation of a Kustomization
   flux reconcile kustomization podinfo
 
   # Trigger a sync of the Kustomization's source and apply changes
-  flux reconcile kustomization podinfo --with-source`,
+  flux reconcile kustomization podinfo --with-source
+
+  # Trigger the reconciliation of multiple Kustomizations and wait for them concurrently
+  flux reconcile kustomization frontend backend
+
+  # Trigger the reconciliation of all Kustomizations matching a label selector
+  flux reconcile kustomization -l app=podinfo
+
+  # Trigger a reconciliation and set a custom annotation in the same request
+  flux reconcile kustomization podinfo --annotate=example.com/reason=hotfix`,
 	ValidArgsFunction: resourceNamesCompletionFunc(kustomizev1.GroupVersion.WithKind(kustomizev1.KustomizationKind)),
 	RunE: reconcileWithSourceCommand{
 		apiType: kustomizationType,
 		object:  kustomizationAdapter{&kustomizev1.Kustomization{}},
+		list:    kustomizationListAdapter{&kustomizev1.KustomizationList{}},
 	}.run,
 }
 
@@ -58,6 +68,10 @@ func (obj kustomizationAdapter) lastHandledReconcileRequest() string {
 	return obj.Status.GetLastHandledReconcileRequest()
 }
 
+func (a kustomizationListAdapter) reconcileItem(i int) reconcilable {
+	return &kustomizationAdapter{&a.KustomizationList.Items[i]}
+}
+
 func (obj kustomizationAdapter) reconcileSource() bool {
 	return rksArgs.syncKsWithSource
 }
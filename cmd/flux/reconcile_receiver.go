@@ -27,21 +27,42 @@ import (
 
 	notificationv1 "github.com/fluxcd/notification-controller/api/v1beta2"
 	"github.com/fluxcd/pkg/apis/meta"
-
-	"github.com/fluxcd/flux2/internal/utils"
 )
 
 var reconcileReceiverCmd = &cobra.Command{
 	Use:   "receiver [name]",
 	Short: "Reconcile a Receiver",
-	Long:  `The reconcile receiver command triggers a reconciliation of a Receiver resource and waits for it to finish.`,
+	Long: `The reconcile receiver command triggers a reconciliation of a Receiver resource and waits for it to finish.
+
+With --test-payload, a payload of the Receiver type is sent to its webhook through a port-forward to
+notification-controller instead, and the resources annotated by the Receiver are reported.`,
 	Example: `  # Trigger a reconciliation for an existing receiver
-  flux reconcile receiver main`,
+  flux reconcile receiver main
+
+  # Send a test payload to the webhook of a receiver
+  flux reconcile receiver main --test-payload
+
+  # Send a test payload for the GitLab tag push event
+  flux reconcile receiver main --test-payload --test-event="Tag Push Hook"`,
 	ValidArgsFunction: resourceNamesCompletionFunc(notificationv1.GroupVersion.WithKind(notificationv1.ReceiverKind)),
 	RunE:              reconcileReceiverCmdRun,
 }
 
+type reconcileReceiverFlags struct {
+	testPayload   bool
+	testEvent     string
+	fluxNamespace string
+}
+
+var reconcileReceiverArgs reconcileReceiverFlags
+
 func init() {
+	reconcileReceiverCmd.Flags().BoolVar(&reconcileReceiverArgs.testPayload, "test-payload", false,
+		"send a test payload to the webhook of the Receiver and report the annotated resources")
+	reconcileReceiverCmd.Flags().StringVar(&reconcileReceiverArgs.testEvent, "test-event", "",
+		"event of the test payload, defaults to the first event of the Receiver")
+	reconcileReceiverCmd.Flags().StringVar(&reconcileReceiverArgs.fluxNamespace, "flux-namespace", rootArgs.defaults.Namespace,
+		"the namespace where notification-controller is running")
 	reconcileCmd.AddCommand(reconcileReceiverCmd)
 }
 
@@ -49,12 +70,20 @@ func reconcileReceiverCmdRun(cmd *cobra.Command, args []string) error {
 	if len(args) < 1 {
 		return fmt.Errorf("receiver name is required")
 	}
+	if len(args) > 1 || reconcileArgs.all || reconcileArgs.selector != "" {
+		return fmt.Errorf("reconciling multiple Receiver objects is not supported")
+	}
 	name := args[0]
 
+	annotations, err := parseReconcileAnnotations()
+	if err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(kubeconfigArgs, kubeclientOptions)
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
 	if err != nil {
 		return err
 	}
@@ -74,6 +103,10 @@ func reconcileReceiverCmdRun(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("resource is suspended")
 	}
 
+	if reconcileReceiverArgs.testPayload {
+		return testReceiverPayload(ctx, kubeClient, receiver)
+	}
+
 	logger.Actionf("annotating Receiver %s in %s namespace", name, *kubeconfigArgs.Namespace)
 	if receiver.Annotations == nil {
 		receiver.Annotations = map[string]string{
@@ -82,6 +115,9 @@ func reconcileReceiverCmdRun(cmd *cobra.Command, args []string) error {
 	} else {
 		receiver.Annotations[meta.ReconcileRequestAnnotation] = time.Now().Format(time.RFC3339Nano)
 	}
+	for k, v := range annotations {
+		receiver.Annotations[k] = v
+	}
 	if err := kubeClient.Update(ctx, &receiver); err != nil {
 		return err
 	}
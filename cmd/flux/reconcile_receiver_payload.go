@@ -0,0 +1,284 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	notificationv1 "github.com/fluxcd/notification-controller/api/v1beta2"
+	"github.com/fluxcd/pkg/apis/meta"
+
+	"github.com/fluxcd/flux2/internal/utils"
+)
+
+const (
+	// receiverWebhookPortName is the name of the notification-controller
+	// container port serving the Receiver webhooks.
+	receiverWebhookPortName = "http-webhook"
+	receiverWebhookPort     = 9292
+)
+
+// receiverTestPayloads are the bodies of the test payloads, they carry the
+// fields notification-controller reads for the receiver types decoding the
+// payload.
+var receiverTestPayloads = map[string]string{
+	notificationv1.GenericReceiver:     `{"source":"flux","test":true}`,
+	notificationv1.GenericHMACReceiver: `{"source":"flux","test":true}`,
+	notificationv1.GitHubReceiver:      `{"ref":"refs/heads/flux-test","repository":{"full_name":"flux/test"}}`,
+	notificationv1.GitLabReceiver:      `{"object_kind":"push","ref":"refs/heads/flux-test","project":{"path_with_namespace":"flux/test"}}`,
+	notificationv1.BitbucketReceiver:   `{"eventKey":"repo:refs_changed","repository":{"slug":"test"}}`,
+	notificationv1.HarborReceiver:      `{"type":"PUSH_ARTIFACT","event_data":{"repository":{"repo_full_name":"flux/test"}}}`,
+	notificationv1.DockerHubReceiver:   `{"push_data":{"tag":"latest"},"repository":{"repo_url":"https://hub.docker.com/r/flux/test"}}`,
+	notificationv1.QuayReceiver:        `{"docker_url":"quay.io/flux/test","updated_tags":["latest"]}`,
+	notificationv1.NexusReceiver:       `{"action":"CREATED","repositoryName":"flux-test"}`,
+	notificationv1.ACRReceiver:         `{"action":"push","target":{"repository":"flux/test","tag":"latest"},"request":{"host":"flux.azurecr.io"}}`,
+}
+
+// receiverDefaultEvents are the events sent when the Receiver doesn't
+// restrict the events.
+var receiverDefaultEvents = map[string]string{
+	notificationv1.GitHubReceiver:    "push",
+	notificationv1.GitLabReceiver:    "Push Hook",
+	notificationv1.BitbucketReceiver: "repo:refs_changed",
+}
+
+// testReceiverPayload sends a test payload to the webhook of the Receiver
+// through a port-forward to notification-controller, and reports the
+// resources annotated by the Receiver.
+func testReceiverPayload(ctx context.Context, kubeClient client.Client, receiver notificationv1.Receiver) error {
+	if receiver.Status.WebhookPath == "" {
+		return fmt.Errorf("Receiver has no webhook path, it is not ready")
+	}
+
+	var secret corev1.Secret
+	secretKey := types.NamespacedName{Namespace: receiver.Namespace, Name: receiver.Spec.SecretRef.Name}
+	if err := kubeClient.Get(ctx, secretKey, &secret); err != nil {
+		return fmt.Errorf("unable to read the Receiver token: %w", err)
+	}
+	token := string(secret.Data["token"])
+	if token == "" {
+		return fmt.Errorf("no 'token' found in secret %q", secretKey)
+	}
+
+	event := receiverTestEvent(receiver)
+	header, body, err := receiverTestPayload(receiver.Spec.Type, token, event)
+	if err != nil {
+		return err
+	}
+
+	targets, err := receiverTargets(ctx, kubeClient, receiver)
+	if err != nil {
+		return err
+	}
+	if len(targets) == 0 {
+		return fmt.Errorf("no resources match the Receiver")
+	}
+	before := make(map[string]string, len(targets))
+	for _, obj := range targets {
+		before[targetKey(obj)] = obj.GetAnnotations()[meta.ReconcileRequestAnnotation]
+	}
+
+	cfg, err := utils.KubeConfig(kubeconfigArgs, kubeclientOptions)
+	if err != nil {
+		return err
+	}
+	clientSet, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return err
+	}
+	pod, err := runningPod(ctx, kubeClient, reconcileReceiverArgs.fluxNamespace,
+		map[string]string{"app": "notification-controller"})
+	if err != nil {
+		return fmt.Errorf("notification-controller: %w", err)
+	}
+	localPort, stop, err := forwardPodPort(ctx, cfg, clientSet, pod, receiverPort(*pod))
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	if event != "" {
+		logger.Actionf("sending %s '%s' test payload to Receiver %s", receiver.Spec.Type, event, receiver.Name)
+	} else {
+		logger.Actionf("sending %s test payload to Receiver %s", receiver.Spec.Type, receiver.Name)
+	}
+	url := fmt.Sprintf("http://127.0.0.1:%d%s", localPort, receiver.Status.WebhookPath)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header = header
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("Receiver rejected the payload with %d %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	logger.Successf("Receiver accepted the payload")
+
+	var annotated int
+	for _, obj := range targets {
+		current := &unstructured.Unstructured{}
+		current.SetGroupVersionKind(obj.GroupVersionKind())
+		if err := kubeClient.Get(ctx, client.ObjectKeyFromObject(&obj), current); err != nil {
+			return err
+		}
+		if current.GetAnnotations()[meta.ReconcileRequestAnnotation] != before[targetKey(obj)] {
+			logger.Successf("%s annotated", targetKey(obj))
+			annotated++
+		} else {
+			logger.Failuref("%s not annotated", targetKey(obj))
+		}
+	}
+	if annotated == 0 {
+		return fmt.Errorf("no resources were annotated by the Receiver")
+	}
+	return nil
+}
+
+// receiverTestEvent returns the event of the test payload, the first event
+// accepted by the Receiver which triggers a reconciliation.
+func receiverTestEvent(receiver notificationv1.Receiver) string {
+	if reconcileReceiverArgs.testEvent != "" {
+		return reconcileReceiverArgs.testEvent
+	}
+	for _, event := range receiver.Spec.Events {
+		if event != "ping" {
+			return event
+		}
+	}
+	return receiverDefaultEvents[receiver.Spec.Type]
+}
+
+// receiverTestPayload returns the headers and the body of a test payload
+// for the receiver type, authenticated with the token the way the provider
+// does it.
+func receiverTestPayload(receiverType, token, event string) (http.Header, []byte, error) {
+	payload, ok := receiverTestPayloads[receiverType]
+	if !ok {
+		return nil, nil, fmt.Errorf("test payloads are not supported for the '%s' receiver type", receiverType)
+	}
+	body := []byte(payload)
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	switch receiverType {
+	case notificationv1.GenericHMACReceiver:
+		header.Set("X-Signature", "sha256="+hmacHex(sha256.New, token, body))
+	case notificationv1.GitHubReceiver:
+		header.Set("X-GitHub-Event", event)
+		header.Set("X-Hub-Signature-256", "sha256="+hmacHex(sha256.New, token, body))
+	case notificationv1.GitLabReceiver:
+		header.Set("X-Gitlab-Event", event)
+		header.Set("X-Gitlab-Token", token)
+	case notificationv1.BitbucketReceiver:
+		header.Set("X-Event-Key", event)
+		header.Set("X-Hub-Signature", "sha256="+hmacHex(sha256.New, token, body))
+	case notificationv1.HarborReceiver:
+		header.Set("Authorization", token)
+	case notificationv1.NexusReceiver:
+		header.Set("X-Nexus-Webhook-Signature", hmacHex(sha1.New, token, body))
+	}
+	return header, body, nil
+}
+
+func hmacHex(h func() hash.Hash, key string, body []byte) string {
+	mac := hmac.New(h, []byte(key))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// receiverTargets returns the objects selected by the resources of the
+// Receiver.
+func receiverTargets(ctx context.Context, kubeClient client.Client, receiver notificationv1.Receiver) ([]unstructured.Unstructured, error) {
+	var targets []unstructured.Unstructured
+	for _, ref := range receiver.Spec.Resources {
+		gvk, ok := fluxKindGVK(ref.Kind)
+		if ref.APIVersion != "" {
+			gv, err := schema.ParseGroupVersion(ref.APIVersion)
+			if err != nil {
+				return nil, err
+			}
+			gvk, ok = gv.WithKind(ref.Kind), true
+		}
+		if !ok {
+			return nil, fmt.Errorf("unknown kind '%s' in the Receiver resources", ref.Kind)
+		}
+		namespace := ref.Namespace
+		if namespace == "" {
+			namespace = receiver.Namespace
+		}
+
+		if ref.Name != "*" {
+			obj := unstructured.Unstructured{}
+			obj.SetGroupVersionKind(gvk)
+			if err := kubeClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: ref.Name}, &obj); err != nil {
+				return nil, fmt.Errorf("unable to get %s/%s/%s: %w", ref.Kind, namespace, ref.Name, err)
+			}
+			targets = append(targets, obj)
+			continue
+		}
+
+		list := unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+		if err := kubeClient.List(ctx, &list, client.InNamespace(namespace), client.MatchingLabels(ref.MatchLabels)); err != nil {
+			return nil, fmt.Errorf("unable to list %s in %s: %w", ref.Kind, namespace, err)
+		}
+		for _, obj := range list.Items {
+			obj.SetGroupVersionKind(gvk)
+			targets = append(targets, obj)
+		}
+	}
+	return targets, nil
+}
+
+// receiverPort returns the port of the notification-controller pod serving
+// the Receiver webhooks.
+func receiverPort(pod corev1.Pod) int {
+	for _, c := range pod.Spec.Containers {
+		for _, p := range c.Ports {
+			if p.Name == receiverWebhookPortName {
+				return int(p.ContainerPort)
+			}
+		}
+	}
+	return receiverWebhookPort
+}
+
+func targetKey(obj unstructured.Unstructured) string {
+	return strings.Join([]string{obj.GetKind(), obj.GetNamespace(), obj.GetName()}, "/")
+}
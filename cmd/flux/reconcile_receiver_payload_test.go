@@ -0,0 +1,67 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v55/github"
+	. "github.com/onsi/gomega"
+
+	notificationv1 "github.com/fluxcd/notification-controller/api/v1beta2"
+)
+
+func TestReceiverTestPayload(t *testing.T) {
+	g := NewWithT(t)
+
+	header, body, err := receiverTestPayload(notificationv1.GitHubReceiver, "secret", "push")
+	g.Expect(err).ToNot(HaveOccurred())
+	req, err := http.NewRequest(http.MethodPost, "http://localhost/hook", bytes.NewReader(body))
+	g.Expect(err).ToNot(HaveOccurred())
+	req.Header = header
+	payload, err := github.ValidatePayload(req, []byte("secret"))
+	g.Expect(err).ToNot(HaveOccurred())
+	event, err := github.ParseWebHook(github.WebHookType(req), payload)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(event).To(BeAssignableToTypeOf(&github.PushEvent{}))
+
+	header, _, err = receiverTestPayload(notificationv1.GitLabReceiver, "secret", "Tag Push Hook")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(header.Get("X-Gitlab-Token")).To(Equal("secret"))
+	g.Expect(header.Get("X-Gitlab-Event")).To(Equal("Tag Push Hook"))
+
+	_, _, err = receiverTestPayload(notificationv1.GCRReceiver, "secret", "")
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestReceiverTestEvent(t *testing.T) {
+	g := NewWithT(t)
+	defer resetCmdArgs()
+
+	receiver := notificationv1.Receiver{
+		Spec: notificationv1.ReceiverSpec{Type: notificationv1.GitHubReceiver},
+	}
+	g.Expect(receiverTestEvent(receiver)).To(Equal("push"))
+
+	receiver.Spec.Events = []string{"ping", "release"}
+	g.Expect(receiverTestEvent(receiver)).To(Equal("release"))
+
+	reconcileReceiverArgs.testEvent = "create"
+	g.Expect(receiverTestEvent(receiver)).To(Equal("create"))
+}
@@ -25,7 +25,7 @@ import (
 )
 
 var reconcileSourceBucketCmd = &cobra.Command{
-	Use:   "bucket [name]",
+	Use:   "bucket [name...]",
 	Short: "Reconcile a Bucket source",
 	Long:  `The reconcile source command triggers a reconciliation of a Bucket resource and waits for it to finish.`,
 	Example: `  # Trigger a reconciliation for an existing source
@@ -34,6 +34,7 @@ var reconcileSourceBucketCmd = &cobra.Command{
 	RunE: reconcileCommand{
 		apiType: bucketType,
 		object:  bucketAdapter{&sourcev1.Bucket{}},
+		list:    bucketListAdapter{&sourcev1.BucketList{}},
 	}.run,
 }
 
@@ -45,6 +46,10 @@ func (obj bucketAdapter) lastHandledReconcileRequest() string {
 	return obj.Status.GetLastHandledReconcileRequest()
 }
 
+func (a bucketListAdapter) reconcileItem(i int) reconcilable {
+	return &bucketAdapter{&a.BucketList.Items[i]}
+}
+
 func (obj bucketAdapter) successMessage() string {
 	return fmt.Sprintf("fetched revision %s", obj.Status.Artifact.Revision)
 }
@@ -25,7 +25,7 @@ import (
 )
 
 var reconcileSourceGitCmd = &cobra.Command{
-	Use:   "git [name]",
+	Use:   "git [name...]",
 	Short: "Reconcile a GitRepository source",
 	Long:  `The reconcile source command triggers a reconciliation of a GitRepository resource and waits for it to finish.`,
 	Example: `  # Trigger a git pull for an existing source
@@ -34,6 +34,7 @@ var reconcileSourceGitCmd = &cobra.Command{
 	RunE: reconcileCommand{
 		apiType: gitRepositoryType,
 		object:  gitRepositoryAdapter{&sourcev1.GitRepository{}},
+		list:    gitRepositoryListAdapter{&sourcev1.GitRepositoryList{}},
 	}.run,
 }
 
@@ -45,6 +46,10 @@ func (obj gitRepositoryAdapter) lastHandledReconcileRequest() string {
 	return obj.Status.GetLastHandledReconcileRequest()
 }
 
+func (a gitRepositoryListAdapter) reconcileItem(i int) reconcilable {
+	return &gitRepositoryAdapter{&a.GitRepositoryList.Items[i]}
+}
+
 func (obj gitRepositoryAdapter) successMessage() string {
 	return fmt.Sprintf("fetched revision %s", obj.Status.Artifact.Revision)
 }
@@ -27,7 +27,7 @@ import (
 )
 
 var reconcileSourceHelmCmd = &cobra.Command{
-	Use:   "helm [name]",
+	Use:   "helm [name...]",
 	Short: "Reconcile a HelmRepository source",
 	Long:  `The reconcile source command triggers a reconciliation of a HelmRepository resource and waits for it to finish.`,
 	Example: `  # Trigger a reconciliation for an existing source
@@ -36,6 +36,7 @@ var reconcileSourceHelmCmd = &cobra.Command{
 	RunE: reconcileCommand{
 		apiType: helmRepositoryType,
 		object:  helmRepositoryAdapter{&sourcev1.HelmRepository{}},
+		list:    helmRepositoryListAdapter{&sourcev1.HelmRepositoryList{}},
 	}.run,
 }
 
@@ -47,6 +48,10 @@ func (obj helmRepositoryAdapter) lastHandledReconcileRequest() string {
 	return obj.Status.GetLastHandledReconcileRequest()
 }
 
+func (a helmRepositoryListAdapter) reconcileItem(i int) reconcilable {
+	return &helmRepositoryAdapter{&a.HelmRepositoryList.Items[i]}
+}
+
 func (obj helmRepositoryAdapter) successMessage() string {
 	// HelmRepository of type OCI don't set an Artifact
 	if obj.Spec.Type == sourcev1.HelmRepositoryTypeOCI {
@@ -25,7 +25,7 @@ import (
 )
 
 var reconcileSourceOCIRepositoryCmd = &cobra.Command{
-	Use:   "oci [name]",
+	Use:   "oci [name...]",
 	Short: "Reconcile an OCIRepository",
 	Long:  `The reconcile source command triggers a reconciliation of an OCIRepository resource and waits for it to finish.`,
 	Example: `  # Trigger a reconciliation for an existing source
@@ -34,6 +34,7 @@ var reconcileSourceOCIRepositoryCmd = &cobra.Command{
 	RunE: reconcileCommand{
 		apiType: ociRepositoryType,
 		object:  ociRepositoryAdapter{&sourcev1.OCIRepository{}},
+		list:    ociRepositoryListAdapter{&sourcev1.OCIRepositoryList{}},
 	}.run,
 }
 
@@ -45,6 +46,10 @@ func (obj ociRepositoryAdapter) lastHandledReconcileRequest() string {
 	return obj.Status.GetLastHandledReconcileRequest()
 }
 
+func (a ociRepositoryListAdapter) reconcileItem(i int) reconcilable {
+	return &ociRepositoryAdapter{&a.OCIRepositoryList.Items[i]}
+}
+
 func (obj ociRepositoryAdapter) successMessage() string {
 	return fmt.Sprintf("fetched revision %s", obj.Status.Artifact.Revision)
 }
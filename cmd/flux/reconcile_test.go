@@ -0,0 +1,266 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	imagev1 "github.com/fluxcd/image-reflector-controller/api/v1beta2"
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+
+	"github.com/fluxcd/flux2/internal/utils"
+)
+
+func TestParseReconcileAnnotations(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations []string
+		want        map[string]string
+		wantErr     bool
+	}{
+		{
+			name: "no annotations",
+			want: map[string]string{},
+		},
+		{
+			name:        "valid annotations",
+			annotations: []string{"example.com/reason=hotfix", "owner=team-a", "empty="},
+			want: map[string]string{
+				"example.com/reason": "hotfix",
+				"owner":              "team-a",
+				"empty":              "",
+			},
+		},
+		{
+			name:        "value with equal sign",
+			annotations: []string{"query=a=b"},
+			want:        map[string]string{"query": "a=b"},
+		},
+		{
+			name:        "missing value",
+			annotations: []string{"example.com/reason"},
+			wantErr:     true,
+		},
+		{
+			name:        "invalid key",
+			annotations: []string{"-invalid=true"},
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reconcileArgs = reconcileFlags{annotations: tt.annotations}
+			defer func() { reconcileArgs = reconcileFlags{} }()
+
+			got, err := parseReconcileAnnotations()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseReconcileAnnotations() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseReconcileAnnotations() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReconcileListTargets(t *testing.T) {
+	newKustomization := func(name, source string, labels map[string]string, suspend bool) *kustomizev1.Kustomization {
+		return &kustomizev1.Kustomization{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "apps", Labels: labels},
+			Spec: kustomizev1.KustomizationSpec{
+				Suspend: suspend,
+				SourceRef: kustomizev1.CrossNamespaceSourceReference{
+					Kind:      "GitRepository",
+					Name:      source,
+					Namespace: "flux-system",
+				},
+			},
+		}
+	}
+	kubeClient := fake.NewClientBuilder().WithScheme(utils.NewScheme()).WithObjects(
+		newKustomization("frontend", "podinfo", map[string]string{"app": "podinfo"}, false),
+		newKustomization("backend", "podinfo", map[string]string{"app": "podinfo"}, false),
+		newKustomization("redis", "redis", nil, false),
+		newKustomization("legacy", "podinfo", map[string]string{"app": "podinfo"}, true),
+		&sourcev1.GitRepository{ObjectMeta: metav1.ObjectMeta{Name: "podinfo", Namespace: "flux-system"}},
+		&sourcev1.GitRepository{ObjectMeta: metav1.ObjectMeta{Name: "redis", Namespace: "flux-system"}},
+	).Build()
+
+	namespace := *kubeconfigArgs.Namespace
+	*kubeconfigArgs.Namespace = "apps"
+	t.Cleanup(func() { *kubeconfigArgs.Namespace = namespace })
+
+	reconcile := reconcileCommand{
+		apiType: kustomizationType,
+		object:  kustomizationAdapter{&kustomizev1.Kustomization{}},
+		list:    kustomizationListAdapter{&kustomizev1.KustomizationList{}},
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		selector   string
+		withSource bool
+		want       []string
+		sources    int
+		wantErr    string
+	}{
+		{
+			name: "all",
+			want: []string{"backend", "frontend", "redis"},
+		},
+		{
+			name: "names in argument order",
+			args: []string{"redis", "frontend"},
+			want: []string{"redis", "frontend"},
+		},
+		{
+			name:     "selector",
+			selector: "app=podinfo",
+			want:     []string{"backend", "frontend"},
+		},
+		{
+			name:       "distinct sources",
+			withSource: true,
+			want:       []string{"backend", "frontend", "redis"},
+			sources:    2,
+		},
+		{
+			name:    "missing name",
+			args:    []string{"frontend", "missing"},
+			wantErr: "Kustomization object 'missing' not found",
+		},
+		{
+			name:     "invalid selector",
+			selector: "app in (",
+			wantErr:  "invalid selector",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reconcileArgs = reconcileFlags{selector: tt.selector}
+			rksArgs = reconcileKsFlags{syncKsWithSource: tt.withSource}
+			defer func() {
+				reconcileArgs = reconcileFlags{}
+				rksArgs = reconcileKsFlags{}
+			}()
+
+			targets, err := reconcile.listTargets(context.TODO(), kubeClient, tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var names []string
+			for _, target := range targets {
+				names = append(names, target.object.asClientObject().GetName())
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("listTargets() = %v, want %v", names, tt.want)
+			}
+
+			sources, err := reconcileSources(context.TODO(), kubeClient, targets)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(sources) != tt.sources {
+				t.Errorf("expected %d distinct sources, got %d", tt.sources, len(sources))
+			}
+		})
+	}
+}
+
+func TestResetImageRepositoryScans(t *testing.T) {
+	newRepository := func(name string) *imagev1.ImageRepository {
+		return &imagev1.ImageRepository{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "apps"},
+			Status: imagev1.ImageRepositoryStatus{
+				LastScanResult: &imagev1.ScanResult{TagCount: 10},
+			},
+		}
+	}
+	kubeClient := fake.NewClientBuilder().WithScheme(utils.NewScheme()).WithObjects(
+		newRepository("podinfo"),
+		newRepository("redis"),
+	).Build()
+
+	namespace := *kubeconfigArgs.Namespace
+	*kubeconfigArgs.Namespace = "apps"
+	t.Cleanup(func() { *kubeconfigArgs.Namespace = namespace })
+
+	reconcile := reconcileCommand{
+		apiType: imageRepositoryType,
+		object:  imageRepositoryAdapter{&imagev1.ImageRepository{}},
+		list:    imageRepositoryListAdapter{&imagev1.ImageRepositoryList{}},
+	}
+	if err := resetImageRepositoryScans(context.TODO(), kubeClient, reconcile, []string{"podinfo"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for name, wantReset := range map[string]bool{"podinfo": true, "redis": false} {
+		var repository imagev1.ImageRepository
+		if err := kubeClient.Get(context.TODO(), client.ObjectKey{Namespace: "apps", Name: name}, &repository); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if reset := repository.Status.LastScanResult == nil; reset != wantReset {
+			t.Errorf("%s: scan result reset = %v, want %v", name, reset, wantReset)
+		}
+	}
+}
+
+func TestReconcileCmd(t *testing.T) {
+	runFakeCmdTests(t, []fakeCmdTestCase{
+		{
+			name:       "suspended kustomization",
+			args:       "reconcile kustomization infra -n flux-system",
+			objectFile: "testdata/fake/objects.yaml",
+			wantErr:    "resource is suspended",
+		},
+		{
+			name:       "missing kustomization",
+			args:       "reconcile kustomization backend -n flux-system",
+			objectFile: "testdata/fake/objects.yaml",
+			wantErr:    `kustomizations.kustomize.toolkit.fluxcd.io "backend" not found`,
+		},
+		{
+			name:       "no kustomizations",
+			args:       "reconcile kustomization --all -n default",
+			objectFile: "testdata/fake/objects.yaml",
+			goldenFile: "testdata/fake/reconcile_kustomizations_empty.golden",
+		},
+		{
+			name:    "name required",
+			args:    "reconcile kustomization -n flux-system",
+			wantErr: "Kustomization name is required",
+		},
+	})
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/cli-utils/pkg/object"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/fluxcd/flux2/internal/utils"
+	"github.com/fluxcd/flux2/pkg/manifestgen"
+	"github.com/fluxcd/flux2/pkg/status"
+)
+
+var restartControllersCmd = &cobra.Command{
+	Use:   "controllers",
+	Short: "Rollout restart the Flux controllers",
+	Long: `The restart controllers command performs a rollout restart of the Flux controller Deployments
+and waits for the new pods to become ready.`,
+	Example: `  # Restart all controllers in the flux-system namespace
+  flux restart controllers
+
+  # Restart the source-controller only
+  flux restart controllers --component=source-controller`,
+	RunE: restartControllersCmdRun,
+}
+
+type restartControllersFlags struct {
+	components []string
+}
+
+var restartControllersArgs restartControllersFlags
+
+// restartedAtAnnotation is the pod template annotation set by
+// 'kubectl rollout restart' to trigger a new rollout.
+const restartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
+
+func init() {
+	restartControllersCmd.Flags().StringSliceVar(&restartControllersArgs.components, "component", nil,
+		"list of controllers to restart, defaults to all controllers found in the namespace")
+
+	restartCmd.AddCommand(restartControllersCmd)
+}
+
+func restartControllersCmdRun(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
+	if err != nil {
+		return err
+	}
+
+	restarted, err := restartControllers(ctx, kubeClient, *kubeconfigArgs.Namespace,
+		restartControllersArgs.components, time.Now())
+	if err != nil {
+		return err
+	}
+
+	kubeConfig, err := utils.KubeConfig(kubeconfigArgs, kubeclientOptions)
+	if err != nil {
+		return err
+	}
+	statusChecker, err := status.NewStatusChecker(kubeConfig, 2*time.Second, rootArgs.timeout, logger)
+	if err != nil {
+		return err
+	}
+
+	var objRefs []object.ObjMetadata
+	for _, name := range restarted {
+		objRefs = append(objRefs, object.ObjMetadata{
+			Namespace: *kubeconfigArgs.Namespace,
+			Name:      name,
+			GroupKind: schema.GroupKind{Group: appsv1.GroupName, Kind: "Deployment"},
+		})
+	}
+
+	logger.Waitingf("waiting for rollout to complete")
+	if err := statusChecker.Assess(objRefs...); err != nil {
+		return fmt.Errorf("rollout failed")
+	}
+	logger.Successf("restart finished")
+	return nil
+}
+
+// restartControllers sets the restartedAt annotation on the pod template of
+// the Flux controller Deployments, filtered by components when specified,
+// and returns the names of the restarted Deployments.
+func restartControllers(ctx context.Context, kubeClient client.Client, namespace string,
+	components []string, now time.Time) ([]string, error) {
+	var list appsv1.DeploymentList
+	selector := client.MatchingLabels{manifestgen.PartOfLabelKey: manifestgen.PartOfLabelValue}
+	if err := kubeClient.List(ctx, &list, client.InNamespace(namespace), selector); err != nil {
+		return nil, err
+	}
+
+	for _, component := range components {
+		found := false
+		for _, deployment := range list.Items {
+			if deployment.Name == component {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("controller %s not found in %s namespace", component, namespace)
+		}
+	}
+
+	var restarted []string
+	for i := range list.Items {
+		deployment := &list.Items[i]
+		if len(components) > 0 && !utils.ContainsItemString(components, deployment.Name) {
+			continue
+		}
+
+		logger.Actionf("restarting %s", deployment.Name)
+		patch := client.MergeFrom(deployment.DeepCopy())
+		if deployment.Spec.Template.Annotations == nil {
+			deployment.Spec.Template.Annotations = make(map[string]string)
+		}
+		deployment.Spec.Template.Annotations[restartedAtAnnotation] = now.Format(time.RFC3339)
+		if err := kubeClient.Patch(ctx, deployment, patch); err != nil {
+			return nil, fmt.Errorf("failed to restart %s: %w", deployment.Name, err)
+		}
+		restarted = append(restarted, deployment.Name)
+	}
+
+	if len(restarted) == 0 {
+		return nil, fmt.Errorf("no controllers found in %s namespace", namespace)
+	}
+	return restarted, nil
+}
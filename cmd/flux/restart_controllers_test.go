@@ -0,0 +1,92 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/fluxcd/flux2/internal/utils"
+	"github.com/fluxcd/flux2/pkg/manifestgen"
+)
+
+func TestRestartControllers(t *testing.T) {
+	newDeployment := func(name string, labels map[string]string) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "flux-system", Labels: labels},
+		}
+	}
+	fluxLabels := map[string]string{manifestgen.PartOfLabelKey: manifestgen.PartOfLabelValue}
+	now := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		components []string
+		want       []string
+		wantErr    bool
+	}{
+		{
+			name: "all controllers",
+			want: []string{"kustomize-controller", "source-controller"},
+		},
+		{
+			name:       "selected controller",
+			components: []string{"source-controller"},
+			want:       []string{"source-controller"},
+		},
+		{
+			name:       "unknown controller",
+			components: []string{"helm-controller"},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kubeClient := fake.NewClientBuilder().WithScheme(utils.NewScheme()).WithObjects(
+				newDeployment("source-controller", fluxLabels),
+				newDeployment("kustomize-controller", fluxLabels),
+				newDeployment("podinfo", nil),
+			).Build()
+
+			got, err := restartControllers(context.TODO(), kubeClient, "flux-system", tt.components, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("restartControllers() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("restartControllers() = %v, want %v", got, tt.want)
+			}
+
+			for _, name := range []string{"source-controller", "kustomize-controller", "podinfo"} {
+				var deployment appsv1.Deployment
+				if err := kubeClient.Get(context.TODO(), client.ObjectKey{Namespace: "flux-system", Name: name}, &deployment); err != nil {
+					t.Fatal(err)
+				}
+				_, restarted := deployment.Spec.Template.Annotations[restartedAtAnnotation]
+				if restarted != utils.ContainsItemString(tt.want, name) {
+					t.Errorf("%s restarted = %v", name, restarted)
+				}
+			}
+		})
+	}
+}
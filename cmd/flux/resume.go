@@ -24,8 +24,6 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-
-	"github.com/fluxcd/flux2/internal/utils"
 )
 
 var resumeCmd = &cobra.Command{
@@ -76,7 +74,7 @@ func (resume resumeCommand) run(cmd *cobra.Command, args []string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(kubeconfigArgs, kubeclientOptions)
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
 	if err != nil {
 		return err
 	}
@@ -100,32 +98,50 @@ func (resume resumeCommand) run(cmd *cobra.Command, args []string) error {
 	}
 
 	for i := 0; i < resume.list.len(); i++ {
-		logger.Actionf("resuming %s %s in %s namespace", resume.humanKind, resume.list.resumeItem(i).asClientObject().GetName(), *kubeconfigArgs.Namespace)
 		obj := resume.list.resumeItem(i)
-		patch := client.MergeFrom(obj.deepCopyClientObject())
-		obj.setUnsuspended()
-		if err := kubeClient.Patch(ctx, obj.asClientObject(), patch); err != nil {
+		if err := resume.resume(ctx, kubeClient, obj); err != nil {
 			return err
 		}
 
-		logger.Successf("%s resumed", resume.humanKind)
-
 		if resumeArgs.wait || !resumeArgs.all {
-			namespacedName := types.NamespacedName{
-				Name:      resume.list.resumeItem(i).asClientObject().GetName(),
-				Namespace: *kubeconfigArgs.Namespace,
-			}
-
-			logger.Waitingf("waiting for %s reconciliation", resume.kind)
-			if err := wait.PollImmediate(rootArgs.pollInterval, rootArgs.timeout,
-				isReady(ctx, kubeClient, namespacedName, resume.list.resumeItem(i))); err != nil {
+			if err := resume.waitForReady(ctx, kubeClient, obj); err != nil {
 				logger.Failuref(err.Error())
 				continue
 			}
-			logger.Successf("%s reconciliation completed", resume.kind)
-			logger.Successf(resume.list.resumeItem(i).successMessage())
 		}
 	}
 
 	return nil
 }
+
+// resume patches the given object to resume its reconciliation.
+func (resume resumeCommand) resume(ctx context.Context, kubeClient client.Client, obj resumable) error {
+	logger.Actionf("resuming %s %s in %s namespace", resume.humanKind, obj.asClientObject().GetName(), obj.asClientObject().GetNamespace())
+	patch := client.MergeFrom(obj.deepCopyClientObject())
+	obj.setUnsuspended()
+	if err := kubeClient.Patch(ctx, obj.asClientObject(), patch); err != nil {
+		return err
+	}
+
+	recordAuditEvent(ctx, kubeClient, resume.groupVersion.WithKind(resume.kind), obj.asClientObject(),
+		"Resumed", fmt.Sprintf("%s resumed", resume.kind))
+	logger.Successf("%s resumed", resume.humanKind)
+	return nil
+}
+
+// waitForReady waits for the resumed object to be reconciled.
+func (resume resumeCommand) waitForReady(ctx context.Context, kubeClient client.Client, obj resumable) error {
+	namespacedName := types.NamespacedName{
+		Name:      obj.asClientObject().GetName(),
+		Namespace: obj.asClientObject().GetNamespace(),
+	}
+
+	logger.Waitingf("waiting for %s reconciliation", resume.kind)
+	if err := wait.PollImmediate(rootArgs.pollInterval, rootArgs.timeout,
+		isReady(ctx, kubeClient, namespacedName, obj)); err != nil {
+		return err
+	}
+	logger.Successf("%s reconciliation completed", resume.kind)
+	logger.Successf(obj.successMessage())
+	return nil
+}
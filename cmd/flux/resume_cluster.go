@@ -0,0 +1,133 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	"github.com/spf13/cobra"
+	appsv1 "k8s.io/api/apps/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/fluxcd/flux2/pkg/manifestgen"
+)
+
+var resumeClusterCmd = &cobra.Command{
+	Use:   "cluster",
+	Short: "Resume Flux on the cluster after a maintenance window",
+	Long: `The resume cluster command restores the replicas of the Flux controllers
+and resumes the Kustomizations paused with 'flux pause cluster'.`,
+	Example: `  # Resume Flux after the maintenance
+  flux resume cluster`,
+	RunE: resumeClusterCmdRun,
+}
+
+func init() {
+	resumeCmd.AddCommand(resumeClusterCmd)
+}
+
+func resumeClusterCmdRun(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
+	if err != nil {
+		return err
+	}
+
+	// The controllers must be running for the Kustomizations to be reconciled.
+	scaled, err := scaleUpControllers(ctx, kubeClient, *kubeconfigArgs.Namespace)
+	if err != nil {
+		return err
+	}
+	resumed, err := resumePausedKustomizations(ctx, kubeClient)
+	if err != nil {
+		return err
+	}
+	if scaled+resumed == 0 {
+		logger.Successf("Flux is not paused")
+		return nil
+	}
+
+	recordNamespaceAuditEvent(ctx, kubeClient, *kubeconfigArgs.Namespace, "Resumed", "Flux resumed")
+	logger.Successf("Flux resumed")
+	return nil
+}
+
+// scaleUpControllers restores the replicas recorded by scaleDownControllers
+// and returns the number of Deployments scaled.
+func scaleUpControllers(ctx context.Context, kubeClient client.Client, namespace string) (int, error) {
+	var list appsv1.DeploymentList
+	selector := client.MatchingLabels{manifestgen.PartOfLabelKey: manifestgen.PartOfLabelValue}
+	if err := kubeClient.List(ctx, &list, client.InNamespace(namespace), selector); err != nil {
+		return 0, err
+	}
+
+	var scaled int
+	for i := range list.Items {
+		deployment := &list.Items[i]
+		value, ok := deployment.Annotations[pausedReplicasAnnotation]
+		if !ok {
+			continue
+		}
+		replicas, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return scaled, fmt.Errorf("invalid %s annotation on %s: %w", pausedReplicasAnnotation, deployment.Name, err)
+		}
+
+		logger.Actionf("scaling %s to %d", deployment.Name, replicas)
+		patch := client.MergeFrom(deployment.DeepCopy())
+		delete(deployment.Annotations, pausedReplicasAnnotation)
+		r := int32(replicas)
+		deployment.Spec.Replicas = &r
+		if err := kubeClient.Patch(ctx, deployment, patch); err != nil {
+			return scaled, fmt.Errorf("failed to scale %s: %w", deployment.Name, err)
+		}
+		scaled++
+	}
+	return scaled, nil
+}
+
+// resumePausedKustomizations resumes the Kustomizations suspended by
+// suspendTopLevelKustomizations and returns the number of resumed objects.
+func resumePausedKustomizations(ctx context.Context, kubeClient client.Client) (int, error) {
+	var list kustomizev1.KustomizationList
+	if err := kubeClient.List(ctx, &list); err != nil {
+		return 0, err
+	}
+
+	var resumed int
+	for i := range list.Items {
+		ks := &list.Items[i]
+		if _, ok := ks.Annotations[pausedAnnotation]; !ok {
+			continue
+		}
+
+		logger.Actionf("resuming Kustomization %s in %s namespace", ks.Name, ks.Namespace)
+		patch := client.MergeFrom(ks.DeepCopy())
+		delete(ks.Annotations, pausedAnnotation)
+		ks.Spec.Suspend = false
+		if err := kubeClient.Patch(ctx, ks, patch); err != nil {
+			return resumed, fmt.Errorf("failed to resume %s/%s: %w", ks.Namespace, ks.Name, err)
+		}
+		resumed++
+	}
+	return resumed, nil
+}
@@ -17,9 +17,14 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
 )
@@ -31,19 +36,168 @@ var resumeKsCmd = &cobra.Command{
 	Long: `The resume command marks a previously suspended Kustomization resource for reconciliation and waits for it to
 finish the apply.`,
 	Example: `  # Resume reconciliation for an existing Kustomization
-  flux resume ks podinfo`,
+  flux resume ks podinfo
+
+  # Resume all Kustomizations in a namespace in dependency order,
+  # waiting for each level of dependencies to be ready before the next
+  flux resume ks --all --cascade-order
+
+  # Resume a set of Kustomizations in dependency order
+  flux resume ks infra config apps --cascade-order`,
 	ValidArgsFunction: resourceNamesCompletionFunc(kustomizev1.GroupVersion.WithKind(kustomizev1.KustomizationKind)),
-	RunE: resumeCommand{
-		apiType: kustomizationType,
-		object:  kustomizationAdapter{&kustomizev1.Kustomization{}},
-		list:    kustomizationListAdapter{&kustomizev1.KustomizationList{}},
-	}.run,
+	RunE:              resumeKsCmdRun,
+}
+
+type resumeKsFlags struct {
+	cascadeOrder bool
+}
+
+var resumeKsArgs resumeKsFlags
+
+var resumeKsCommand = resumeCommand{
+	apiType: kustomizationType,
+	object:  kustomizationAdapter{&kustomizev1.Kustomization{}},
+	list:    kustomizationListAdapter{&kustomizev1.KustomizationList{}},
 }
 
 func init() {
+	resumeKsCmd.Flags().BoolVar(&resumeKsArgs.cascadeOrder, "cascade-order", false,
+		"resume the Kustomizations in dependency order, waiting for each level to be ready before resuming the next one")
 	resumeCmd.AddCommand(resumeKsCmd)
 }
 
+func resumeKsCmdRun(cmd *cobra.Command, args []string) error {
+	if !resumeKsArgs.cascadeOrder {
+		return resumeKsCommand.run(cmd, args)
+	}
+	if len(args) < 1 && !resumeArgs.all {
+		return fmt.Errorf("%s name is required", kustomizationType.humanKind)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
+	if err != nil {
+		return err
+	}
+
+	var list kustomizev1.KustomizationList
+	if err := kubeClient.List(ctx, &list, client.InNamespace(*kubeconfigArgs.Namespace)); err != nil {
+		return err
+	}
+
+	var selected []kustomizev1.Kustomization
+	if len(args) > 0 {
+		for _, name := range args {
+			found := false
+			for _, ks := range list.Items {
+				if ks.Name == name {
+					selected = append(selected, ks)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return fmt.Errorf("%s %s not found in %s namespace", kustomizationType.humanKind, name, *kubeconfigArgs.Namespace)
+			}
+		}
+	} else {
+		selected = list.Items
+	}
+	if len(selected) == 0 {
+		logger.Failuref("no %s objects found in %s namespace", kustomizationType.kind, *kubeconfigArgs.Namespace)
+		return nil
+	}
+
+	levels, err := kustomizationLevels(selected)
+	if err != nil {
+		return err
+	}
+
+	for i, level := range levels {
+		var names []string
+		for _, ks := range level {
+			names = append(names, ks.Name)
+		}
+		logger.Actionf("resuming dependency level %d/%d: %s", i+1, len(levels), strings.Join(names, ", "))
+
+		for j := range level {
+			if err := resumeKsCommand.resume(ctx, kubeClient, kustomizationAdapter{&level[j]}); err != nil {
+				return err
+			}
+		}
+
+		var failed []string
+		for j := range level {
+			if err := resumeKsCommand.waitForReady(ctx, kubeClient, kustomizationAdapter{&level[j]}); err != nil {
+				logger.Failuref("%s: %s", level[j].Name, err.Error())
+				failed = append(failed, level[j].Name)
+			}
+		}
+		if len(failed) > 0 {
+			return fmt.Errorf("dependency level %d failed to reconcile (%s), the following levels were not resumed",
+				i+1, strings.Join(failed, ", "))
+		}
+	}
+
+	return nil
+}
+
+// kustomizationLevels groups the given Kustomizations by their depth in
+// the dependency graph. Dependencies on Kustomizations outside the given
+// set are ignored. Each level only depends on the levels before it.
+func kustomizationLevels(items []kustomizev1.Kustomization) ([][]kustomizev1.Kustomization, error) {
+	pending := make(map[types.NamespacedName]kustomizev1.Kustomization, len(items))
+	for _, ks := range items {
+		pending[types.NamespacedName{Namespace: ks.Namespace, Name: ks.Name}] = ks
+	}
+	inSet := make(map[types.NamespacedName]bool, len(pending))
+	for key := range pending {
+		inSet[key] = true
+	}
+
+	var levels [][]kustomizev1.Kustomization
+	done := make(map[types.NamespacedName]bool, len(pending))
+	for len(pending) > 0 {
+		var level []kustomizev1.Kustomization
+		for key, ks := range pending {
+			ready := true
+			for _, dep := range ks.Spec.DependsOn {
+				depKey := types.NamespacedName{Namespace: dep.Namespace, Name: dep.Name}
+				if depKey.Namespace == "" {
+					depKey.Namespace = key.Namespace
+				}
+				if inSet[depKey] && !done[depKey] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				level = append(level, ks)
+			}
+		}
+		if len(level) == 0 {
+			var names []string
+			for key := range pending {
+				names = append(names, key.String())
+			}
+			sort.Strings(names)
+			return nil, fmt.Errorf("dependency cycle detected between: %s", strings.Join(names, ", "))
+		}
+		sort.Slice(level, func(i, j int) bool {
+			return level[i].Namespace+"/"+level[i].Name < level[j].Namespace+"/"+level[j].Name
+		})
+		for _, ks := range level {
+			key := types.NamespacedName{Namespace: ks.Namespace, Name: ks.Name}
+			done[key] = true
+			delete(pending, key)
+		}
+		levels = append(levels, level)
+	}
+	return levels, nil
+}
+
 func (obj kustomizationAdapter) getObservedGeneration() int64 {
 	return obj.Kustomization.Status.ObservedGeneration
 }
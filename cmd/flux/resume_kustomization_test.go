@@ -0,0 +1,76 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	"github.com/fluxcd/pkg/apis/meta"
+)
+
+func TestKustomizationLevels(t *testing.T) {
+	ks := func(name string, deps ...string) kustomizev1.Kustomization {
+		k := kustomizev1.Kustomization{}
+		k.Namespace = "flux-system"
+		k.Name = name
+		for _, d := range deps {
+			k.Spec.DependsOn = append(k.Spec.DependsOn, meta.NamespacedObjectReference{Name: d})
+		}
+		return k
+	}
+
+	levels, err := kustomizationLevels([]kustomizev1.Kustomization{
+		ks("apps", "config", "infra"),
+		ks("config", "infra"),
+		ks("infra"),
+		ks("monitoring"),
+		// dependencies outside of the set are ignored
+		ks("tenants", "not-selected"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got [][]string
+	for _, level := range levels {
+		var names []string
+		for _, k := range level {
+			names = append(names, k.Name)
+		}
+		got = append(got, names)
+	}
+	want := [][]string{
+		{"infra", "monitoring", "tenants"},
+		{"config"},
+		{"apps"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got levels %v, want %v", got, want)
+	}
+
+	_, err = kustomizationLevels([]kustomizev1.Kustomization{
+		ks("a", "b"),
+		ks("b", "a"),
+		ks("c"),
+	})
+	if err == nil || !strings.Contains(err.Error(), "flux-system/a, flux-system/b") {
+		t.Errorf("expected cycle error, got %v", err)
+	}
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+const sshAuthSockEnvVar = "SSH_AUTH_SOCK"
+
+// useSSHAgent verifies that the SSH agent, local or forwarded, holds keys
+// to authenticate with. When a fingerprint is given, the agent is served
+// restricted to the matching key on a temporary socket, which replaces
+// SSH_AUTH_SOCK until the returned cleanup function is called.
+func useSSHAgent(fingerprint string) (func(), error) {
+	sock := os.Getenv(sshAuthSockEnvVar)
+	if sock == "" {
+		return nil, fmt.Errorf("no SSH agent found, %s is not set: start an agent and add a key with ssh-add, or use --private-key-file", sshAuthSockEnvVar)
+	}
+	conn, err := net.Dial("unix", sock)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the SSH agent at %s: %w", sock, err)
+	}
+	upstream := agent.NewClient(conn)
+	keys, err := upstream.List()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to list the keys of the SSH agent: %w", err)
+	}
+	if len(keys) == 0 {
+		conn.Close()
+		return nil, fmt.Errorf("the SSH agent has no keys: add one with ssh-add, or use --private-key-file")
+	}
+
+	if fingerprint == "" {
+		conn.Close()
+		logger.Actionf("authenticating with the %d key(s) of the SSH agent", len(keys))
+		return func() {}, nil
+	}
+
+	if !strings.HasPrefix(fingerprint, "SHA256:") {
+		fingerprint = "SHA256:" + fingerprint
+	}
+	var key *agent.Key
+	var fingerprints []string
+	for _, k := range keys {
+		fingerprints = append(fingerprints, ssh.FingerprintSHA256(k))
+		if ssh.FingerprintSHA256(k) == fingerprint {
+			key = k
+		}
+	}
+	if key == nil {
+		conn.Close()
+		return nil, fmt.Errorf("no key with fingerprint %s in the SSH agent, available keys: %s",
+			fingerprint, strings.Join(fingerprints, ", "))
+	}
+
+	dir, err := os.MkdirTemp("", "flux-ssh-agent-")
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	listener, err := net.Listen("unix", filepath.Join(dir, "agent.sock"))
+	if err != nil {
+		conn.Close()
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to serve the SSH agent: %w", err)
+	}
+	go func() {
+		filtered := &identityAgent{upstream: upstream, key: key}
+		for {
+			c, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer c.Close()
+				agent.ServeAgent(filtered, c)
+			}()
+		}
+	}()
+
+	os.Setenv(sshAuthSockEnvVar, listener.Addr().String())
+	logger.Actionf("authenticating with the SSH agent key %s (%s)", fingerprint, key.Comment)
+	return func() {
+		os.Setenv(sshAuthSockEnvVar, sock)
+		listener.Close()
+		conn.Close()
+		os.RemoveAll(dir)
+	}, nil
+}
+
+var errIdentityAgentReadOnly = errors.New("the SSH agent is restricted to a single identity and is read-only")
+
+// identityAgent is an agent.ExtendedAgent exposing a single key of the
+// upstream agent.
+type identityAgent struct {
+	upstream agent.ExtendedAgent
+	key      *agent.Key
+}
+
+func (a *identityAgent) List() ([]*agent.Key, error) {
+	return []*agent.Key{a.key}, nil
+}
+
+func (a *identityAgent) Sign(key ssh.PublicKey, data []byte) (*ssh.Signature, error) {
+	return a.SignWithFlags(key, data, 0)
+}
+
+func (a *identityAgent) SignWithFlags(key ssh.PublicKey, data []byte, flags agent.SignatureFlags) (*ssh.Signature, error) {
+	if !bytes.Equal(key.Marshal(), a.key.Marshal()) {
+		return nil, fmt.Errorf("key %s is not the selected identity", ssh.FingerprintSHA256(key))
+	}
+	return a.upstream.SignWithFlags(key, data, flags)
+}
+
+func (a *identityAgent) Signers() ([]ssh.Signer, error) {
+	return nil, errIdentityAgentReadOnly
+}
+
+func (a *identityAgent) Add(agent.AddedKey) error {
+	return errIdentityAgentReadOnly
+}
+
+func (a *identityAgent) Remove(ssh.PublicKey) error {
+	return errIdentityAgentReadOnly
+}
+
+func (a *identityAgent) RemoveAll() error {
+	return errIdentityAgentReadOnly
+}
+
+func (a *identityAgent) Lock([]byte) error {
+	return errIdentityAgentReadOnly
+}
+
+func (a *identityAgent) Unlock([]byte) error {
+	return errIdentityAgentReadOnly
+}
+
+func (a *identityAgent) Extension(string, []byte) ([]byte, error) {
+	return nil, agent.ErrExtensionUnsupported
+}
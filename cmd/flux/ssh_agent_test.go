@@ -0,0 +1,94 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func TestUseSSHAgent(t *testing.T) {
+	g := NewWithT(t)
+
+	// Unix socket paths are limited in length, t.TempDir can be too long
+	dir, err := os.MkdirTemp("", "agent-")
+	g.Expect(err).ToNot(HaveOccurred())
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	listener, err := net.Listen("unix", filepath.Join(dir, "agent.sock"))
+	g.Expect(err).ToNot(HaveOccurred())
+	t.Cleanup(func() { listener.Close() })
+
+	keyring := agent.NewKeyring()
+	go func() {
+		for {
+			c, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go agent.ServeAgent(keyring, c)
+		}
+	}()
+	t.Setenv(sshAuthSockEnvVar, listener.Addr().String())
+
+	_, err = useSSHAgent("")
+	g.Expect(err).To(MatchError(ContainSubstring("the SSH agent has no keys")))
+
+	var fingerprints []string
+	for _, comment := range []string{"first", "second"} {
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(keyring.Add(agent.AddedKey{PrivateKey: priv, Comment: comment})).To(Succeed())
+		signer, err := ssh.NewSignerFromKey(priv)
+		g.Expect(err).ToNot(HaveOccurred())
+		fingerprints = append(fingerprints, ssh.FingerprintSHA256(signer.PublicKey()))
+	}
+
+	cleanup, err := useSSHAgent("")
+	g.Expect(err).ToNot(HaveOccurred())
+	cleanup()
+	g.Expect(os.Getenv(sshAuthSockEnvVar)).To(Equal(listener.Addr().String()))
+
+	_, err = useSSHAgent("SHA256:unknown")
+	g.Expect(err).To(MatchError(ContainSubstring("no key with fingerprint SHA256:unknown")))
+
+	cleanup, err = useSSHAgent(fingerprints[1])
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(os.Getenv(sshAuthSockEnvVar)).ToNot(Equal(listener.Addr().String()))
+
+	conn, err := net.Dial("unix", os.Getenv(sshAuthSockEnvVar))
+	g.Expect(err).ToNot(HaveOccurred())
+	defer conn.Close()
+	client := agent.NewClient(conn)
+	keys, err := client.List()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(keys).To(HaveLen(1))
+	g.Expect(keys[0].Comment).To(Equal("second"))
+	_, err = client.Sign(keys[0], []byte("data"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(client.RemoveAll()).ToNot(Succeed())
+
+	cleanup()
+	g.Expect(os.Getenv(sshAuthSockEnvVar)).To(Equal(listener.Addr().String()))
+}
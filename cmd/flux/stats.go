@@ -19,7 +19,6 @@ package main
 import (
 	"context"
 	"fmt"
-	"github.com/fluxcd/flux2/internal/utils"
 	"github.com/fluxcd/flux2/pkg/printers"
 	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
 	autov1 "github.com/fluxcd/image-automation-controller/api/v1beta1"
@@ -60,87 +59,89 @@ func init() {
 	rootCmd.AddCommand(statsCmd)
 }
 
+// fluxReconcilerKinds lists the kinds of the Flux custom resources reconciled
+// by the controllers.
+var fluxReconcilerKinds = []metav1.GroupVersionKind{
+	{
+		Kind:    sourcev1.GitRepositoryKind,
+		Version: sourcev1.GroupVersion.Version,
+		Group:   sourcev1.GroupVersion.Group,
+	},
+	{
+		Kind:    sourcev1.OCIRepositoryKind,
+		Version: sourcev1.GroupVersion.Version,
+		Group:   sourcev1.GroupVersion.Group,
+	},
+	{
+		Kind:    sourcev1.HelmRepositoryKind,
+		Version: sourcev1.GroupVersion.Version,
+		Group:   sourcev1.GroupVersion.Group,
+	},
+	{
+		Kind:    sourcev1.HelmChartKind,
+		Version: sourcev1.GroupVersion.Version,
+		Group:   sourcev1.GroupVersion.Group,
+	},
+	{
+		Kind:    sourcev1.BucketKind,
+		Version: sourcev1.GroupVersion.Version,
+		Group:   sourcev1.GroupVersion.Group,
+	},
+	{
+		Kind:    kustomizev1.KustomizationKind,
+		Version: kustomizev1.GroupVersion.Version,
+		Group:   kustomizev1.GroupVersion.Group,
+	},
+	{
+		Kind:    helmv2.HelmReleaseKind,
+		Version: helmv2.GroupVersion.Version,
+		Group:   helmv2.GroupVersion.Group,
+	},
+	{
+		Kind:    notificationv1.AlertKind,
+		Version: notificationv1.GroupVersion.Version,
+		Group:   notificationv1.GroupVersion.Group,
+	},
+	{
+		Kind:    notificationv1.ProviderKind,
+		Version: notificationv1.GroupVersion.Version,
+		Group:   notificationv1.GroupVersion.Group,
+	},
+	{
+		Kind:    notificationv1.ReceiverKind,
+		Version: notificationv1.GroupVersion.Version,
+		Group:   notificationv1.GroupVersion.Group,
+	},
+	{
+		Kind:    autov1.ImageUpdateAutomationKind,
+		Version: autov1.GroupVersion.Version,
+		Group:   autov1.GroupVersion.Group,
+	},
+	{
+		Kind:    imagev1.ImagePolicyKind,
+		Version: imagev1.GroupVersion.Version,
+		Group:   imagev1.GroupVersion.Group,
+	},
+	{
+		Kind:    imagev1.ImageRepositoryKind,
+		Version: imagev1.GroupVersion.Version,
+		Group:   imagev1.GroupVersion.Group,
+	},
+}
+
 func runStatsCmd(cmd *cobra.Command, args []string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(kubeconfigArgs, kubeclientOptions)
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
 	if err != nil {
 		return err
 	}
 
-	types := []metav1.GroupVersionKind{
-		{
-			Kind:    sourcev1.GitRepositoryKind,
-			Version: sourcev1.GroupVersion.Version,
-			Group:   sourcev1.GroupVersion.Group,
-		},
-		{
-			Kind:    sourcev1.OCIRepositoryKind,
-			Version: sourcev1.GroupVersion.Version,
-			Group:   sourcev1.GroupVersion.Group,
-		},
-		{
-			Kind:    sourcev1.HelmRepositoryKind,
-			Version: sourcev1.GroupVersion.Version,
-			Group:   sourcev1.GroupVersion.Group,
-		},
-		{
-			Kind:    sourcev1.HelmChartKind,
-			Version: sourcev1.GroupVersion.Version,
-			Group:   sourcev1.GroupVersion.Group,
-		},
-		{
-			Kind:    sourcev1.BucketKind,
-			Version: sourcev1.GroupVersion.Version,
-			Group:   sourcev1.GroupVersion.Group,
-		},
-		{
-			Kind:    kustomizev1.KustomizationKind,
-			Version: kustomizev1.GroupVersion.Version,
-			Group:   kustomizev1.GroupVersion.Group,
-		},
-		{
-			Kind:    helmv2.HelmReleaseKind,
-			Version: helmv2.GroupVersion.Version,
-			Group:   helmv2.GroupVersion.Group,
-		},
-		{
-			Kind:    notificationv1.AlertKind,
-			Version: notificationv1.GroupVersion.Version,
-			Group:   notificationv1.GroupVersion.Group,
-		},
-		{
-			Kind:    notificationv1.ProviderKind,
-			Version: notificationv1.GroupVersion.Version,
-			Group:   notificationv1.GroupVersion.Group,
-		},
-		{
-			Kind:    notificationv1.ReceiverKind,
-			Version: notificationv1.GroupVersion.Version,
-			Group:   notificationv1.GroupVersion.Group,
-		},
-		{
-			Kind:    autov1.ImageUpdateAutomationKind,
-			Version: autov1.GroupVersion.Version,
-			Group:   autov1.GroupVersion.Group,
-		},
-		{
-			Kind:    imagev1.ImagePolicyKind,
-			Version: imagev1.GroupVersion.Version,
-			Group:   imagev1.GroupVersion.Group,
-		},
-		{
-			Kind:    imagev1.ImageRepositoryKind,
-			Version: imagev1.GroupVersion.Version,
-			Group:   imagev1.GroupVersion.Group,
-		},
-	}
-
 	header := []string{"Reconcilers", "Running", "Failing", "Suspended", "Storage"}
 	var rows [][]string
 
-	for _, t := range types {
+	for _, t := range fluxReconcilerKinds {
 		var total int
 		var suspended int
 		var failing int
@@ -22,8 +22,6 @@ import (
 
 	"github.com/spf13/cobra"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-
-	"github.com/fluxcd/flux2/internal/utils"
 )
 
 var suspendCmd = &cobra.Command{
@@ -70,7 +68,7 @@ func (suspend suspendCommand) run(cmd *cobra.Command, args []string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(kubeconfigArgs, kubeclientOptions)
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
 	if err != nil {
 		return err
 	}
@@ -94,17 +92,25 @@ func (suspend suspendCommand) run(cmd *cobra.Command, args []string) error {
 	}
 
 	for i := 0; i < suspend.list.len(); i++ {
-		logger.Actionf("suspending %s %s in %s namespace", suspend.humanKind, suspend.list.item(i).asClientObject().GetName(), *kubeconfigArgs.Namespace)
-
-		obj := suspend.list.item(i)
-		patch := client.MergeFrom(obj.deepCopyClientObject())
-		obj.setSuspended()
-		if err := kubeClient.Patch(ctx, obj.asClientObject(), patch); err != nil {
+		if err := suspend.suspend(ctx, kubeClient, suspend.list.item(i)); err != nil {
 			return err
 		}
-		logger.Successf("%s suspended", suspend.humanKind)
-
 	}
 
 	return nil
 }
+
+// suspend patches the given object to suspend its reconciliation.
+func (suspend suspendCommand) suspend(ctx context.Context, kubeClient client.Client, obj suspendable) error {
+	logger.Actionf("suspending %s %s in %s namespace", suspend.humanKind, obj.asClientObject().GetName(), obj.asClientObject().GetNamespace())
+
+	patch := client.MergeFrom(obj.deepCopyClientObject())
+	obj.setSuspended()
+	if err := kubeClient.Patch(ctx, obj.asClientObject(), patch); err != nil {
+		return err
+	}
+	recordAuditEvent(ctx, kubeClient, suspend.groupVersion.WithKind(suspend.kind), obj.asClientObject(),
+		"Suspended", fmt.Sprintf("%s suspended", suspend.kind))
+	logger.Successf("%s suspended", suspend.humanKind)
+	return nil
+}
@@ -17,9 +17,18 @@ limitations under the License.
 package main
 
 import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/manifoldco/promptui"
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+
+	"github.com/fluxcd/flux2/pkg/printers"
 )
 
 var suspendKsCmd = &cobra.Command{
@@ -28,19 +37,209 @@ var suspendKsCmd = &cobra.Command{
 	Short:   "Suspend reconciliation of Kustomization",
 	Long:    "The suspend command disables the reconciliation of a Kustomization resource.",
 	Example: `  # Suspend reconciliation for an existing Kustomization
-  flux suspend ks podinfo`,
+  flux suspend ks podinfo
+
+  # Suspend reconciliation for a Kustomization and all the Kustomizations depending on it
+  flux suspend ks infrastructure --with-dependents
+
+  # Print the objects which would be garbage collected if the Kustomization was deleted
+  flux suspend ks podinfo --prune-preview`,
 	ValidArgsFunction: resourceNamesCompletionFunc(kustomizev1.GroupVersion.WithKind(kustomizev1.KustomizationKind)),
-	RunE: suspendCommand{
-		apiType: kustomizationType,
-		object:  kustomizationAdapter{&kustomizev1.Kustomization{}},
-		list:    &kustomizationListAdapter{&kustomizev1.KustomizationList{}},
-	}.run,
+	RunE:              suspendKsCmdRun,
+}
+
+type suspendKsFlags struct {
+	withDependents bool
+	silent         bool
+	prunePreview   bool
+}
+
+var suspendKsArgs suspendKsFlags
+
+var suspendKsCommand = suspendCommand{
+	apiType: kustomizationType,
+	object:  kustomizationAdapter{&kustomizev1.Kustomization{}},
+	list:    &kustomizationListAdapter{&kustomizev1.KustomizationList{}},
 }
 
 func init() {
+	suspendKsCmd.Flags().BoolVar(&suspendKsArgs.withDependents, "with-dependents", false,
+		"also suspend the Kustomizations which depend on the given ones, directly or transitively via dependsOn")
+	suspendKsCmd.Flags().BoolVarP(&suspendKsArgs.silent, "silent", "s", false,
+		"suspend the dependents without asking for confirmation")
+	suspendKsCmd.Flags().BoolVar(&suspendKsArgs.prunePreview, "prune-preview", false,
+		"print the inventory objects of the Kustomizations with prune enabled, which are garbage collected if the Kustomizations are deleted")
 	suspendCmd.AddCommand(suspendKsCmd)
 }
 
+func suspendKsCmdRun(cmd *cobra.Command, args []string) error {
+	if !suspendKsArgs.withDependents {
+		if suspendKsArgs.prunePreview {
+			if err := suspendKsPrunePreview(cmd, args); err != nil {
+				return err
+			}
+		}
+		return suspendKsCommand.run(cmd, args)
+	}
+	if len(args) < 1 && !suspendArgs.all {
+		return fmt.Errorf("%s name is required", kustomizationType.humanKind)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
+	if err != nil {
+		return err
+	}
+
+	// Dependencies can cross namespaces, so the graph is built from
+	// the Kustomizations in all namespaces.
+	var list kustomizev1.KustomizationList
+	if err := kubeClient.List(ctx, &list); err != nil {
+		return err
+	}
+
+	var roots []types.NamespacedName
+	for _, ks := range list.Items {
+		if ks.Namespace != *kubeconfigArgs.Namespace {
+			continue
+		}
+		if len(args) > 0 && ks.Name != args[0] {
+			continue
+		}
+		roots = append(roots, types.NamespacedName{Namespace: ks.Namespace, Name: ks.Name})
+	}
+	if len(roots) == 0 {
+		logger.Failuref("no %s objects found in %s namespace", kustomizationType.kind, *kubeconfigArgs.Namespace)
+		return nil
+	}
+
+	set := kustomizationDependents(list.Items, roots)
+
+	rows := make([][]string, 0, len(set))
+	for _, ks := range set {
+		rows = append(rows, []string{ks.Namespace, ks.Name, fmt.Sprintf("%t", ks.Spec.Suspend)})
+	}
+	if err := printers.TablePrinter([]string{"namespace", "name", "suspended"}).Print(cmd.OutOrStdout(), rows); err != nil {
+		return err
+	}
+	if suspendKsArgs.prunePreview {
+		if err := printPruneTree(ctx, cmd.OutOrStdout(), kubeClient, set); err != nil {
+			return err
+		}
+	}
+
+	if !suspendKsArgs.silent {
+		prompt := promptui.Prompt{
+			Label:     fmt.Sprintf("Are you sure you want to suspend these %d Kustomizations", len(set)),
+			IsConfirm: true,
+		}
+		if _, err := prompt.Run(); err != nil {
+			return fmt.Errorf("aborting")
+		}
+	}
+
+	for i := range set {
+		if set[i].Spec.Suspend {
+			continue
+		}
+		if err := suspendKsCommand.suspend(ctx, kubeClient, kustomizationAdapter{&set[i]}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// suspendKsPrunePreview prints the inventory of the Kustomizations about to be
+// suspended, as selected by the name argument or by --all.
+func suspendKsPrunePreview(cmd *cobra.Command, args []string) error {
+	if len(args) < 1 && !suspendArgs.all {
+		return fmt.Errorf("%s name is required", kustomizationType.humanKind)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
+	if err != nil {
+		return err
+	}
+
+	var list kustomizev1.KustomizationList
+	if err := kubeClient.List(ctx, &list, client.InNamespace(*kubeconfigArgs.Namespace)); err != nil {
+		return err
+	}
+	items := list.Items[:0]
+	for _, ks := range list.Items {
+		if len(args) > 0 && ks.Name != args[0] {
+			continue
+		}
+		items = append(items, ks)
+	}
+	return printPruneTree(ctx, cmd.OutOrStdout(), kubeClient, items)
+}
+
+// kustomizationDependents returns the given root Kustomizations and all
+// the Kustomizations which depend on them, transitively via dependsOn.
+// The result is ordered with the most downstream Kustomizations first,
+// so that they are suspended before the ones they depend on.
+func kustomizationDependents(items []kustomizev1.Kustomization, roots []types.NamespacedName) []kustomizev1.Kustomization {
+	byName := make(map[types.NamespacedName]kustomizev1.Kustomization, len(items))
+	dependents := make(map[types.NamespacedName][]types.NamespacedName)
+	for _, ks := range items {
+		key := types.NamespacedName{Namespace: ks.Namespace, Name: ks.Name}
+		byName[key] = ks
+		for _, dep := range ks.Spec.DependsOn {
+			depKey := types.NamespacedName{Namespace: dep.Namespace, Name: dep.Name}
+			if depKey.Namespace == "" {
+				depKey.Namespace = ks.Namespace
+			}
+			dependents[depKey] = append(dependents[depKey], key)
+		}
+	}
+
+	depth := make(map[types.NamespacedName]int)
+	queue := make([]types.NamespacedName, 0, len(roots))
+	for _, root := range roots {
+		if _, ok := byName[root]; ok {
+			depth[root] = 0
+			queue = append(queue, root)
+		}
+	}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, dependent := range dependents[current] {
+			// Keep the longest path to order the dependents correctly,
+			// the depth bound guards against dependency cycles.
+			d := depth[current] + 1
+			if prev, seen := depth[dependent]; (seen && prev >= d) || d > len(items) {
+				continue
+			}
+			depth[dependent] = d
+			queue = append(queue, dependent)
+		}
+	}
+
+	keys := make([]types.NamespacedName, 0, len(depth))
+	for key := range depth {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if depth[keys[i]] != depth[keys[j]] {
+			return depth[keys[i]] > depth[keys[j]]
+		}
+		return keys[i].String() < keys[j].String()
+	})
+
+	result := make([]kustomizev1.Kustomization, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, byName[key])
+	}
+	return result
+}
+
 func (obj kustomizationAdapter) isSuspended() bool {
 	return obj.Kustomization.Spec.Suspend
 }
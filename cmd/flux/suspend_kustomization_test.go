@@ -0,0 +1,102 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	"github.com/fluxcd/pkg/apis/meta"
+)
+
+func TestKustomizationDependents(t *testing.T) {
+	ks := func(namespace, name string, deps ...meta.NamespacedObjectReference) kustomizev1.Kustomization {
+		k := kustomizev1.Kustomization{}
+		k.Namespace = namespace
+		k.Name = name
+		k.Spec.DependsOn = deps
+		return k
+	}
+	dep := func(namespace, name string) meta.NamespacedObjectReference {
+		return meta.NamespacedObjectReference{Namespace: namespace, Name: name}
+	}
+
+	items := []kustomizev1.Kustomization{
+		ks("flux-system", "infra"),
+		ks("flux-system", "config", dep("", "infra")),
+		ks("apps", "podinfo", dep("flux-system", "infra"), dep("flux-system", "config")),
+		ks("apps", "frontend", dep("", "podinfo")),
+		ks("apps", "unrelated"),
+		// a cycle must not loop forever
+		ks("flux-system", "cycle-a", dep("", "cycle-b"), dep("", "infra")),
+		ks("flux-system", "cycle-b", dep("", "cycle-a")),
+	}
+
+	got := kustomizationDependents(items, []types.NamespacedName{{Namespace: "flux-system", Name: "infra"}})
+	var names []string
+	for _, k := range got {
+		names = append(names, k.Namespace+"/"+k.Name)
+	}
+
+	// frontend depends on podinfo which depends on config, the longest
+	// path defines the order.
+	want := []string{
+		"apps/frontend",
+		"apps/podinfo",
+		"flux-system/cycle-b",
+		"flux-system/config",
+		"flux-system/cycle-a",
+		"flux-system/infra",
+	}
+	if len(names) != len(want) {
+		t.Fatalf("got %v, want %v", names, want)
+	}
+	index := make(map[string]int)
+	for i, n := range names {
+		index[n] = i
+	}
+	for _, n := range want {
+		if _, ok := index[n]; !ok {
+			t.Fatalf("missing %s in %v", n, names)
+		}
+	}
+	if index["apps/frontend"] > index["apps/podinfo"] || index["apps/podinfo"] > index["flux-system/config"] ||
+		index["flux-system/config"] > index["flux-system/infra"] {
+		t.Errorf("dependents are not ordered downstream first: %v", names)
+	}
+	if names[len(names)-1] != "flux-system/infra" {
+		t.Errorf("expected the root to be last, got %v", names)
+	}
+
+	if got := kustomizationDependents(items, []types.NamespacedName{{Namespace: "apps", Name: "unrelated"}}); !reflect.DeepEqual(got, []kustomizev1.Kustomization{items[4]}) {
+		t.Errorf("expected only the root without dependents, got %v", got)
+	}
+}
+
+func TestSuspendKustomizationPrunePreview(t *testing.T) {
+	isolateEnv(t)
+	useFakeCluster(t, readObjectFile(t, "testdata/fake/prune_preview.yaml")...)
+
+	cmd := cmdTestCase{
+		args:   "suspend kustomization --all -n flux-system --prune-preview",
+		assert: assertGoldenFile("testdata/fake/suspend_prune_preview.golden"),
+	}
+	cmd.runTestCmd(t)
+}
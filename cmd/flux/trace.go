@@ -62,13 +62,23 @@ You can also trace multiple objects with different resource kinds using <resourc
   
   # API Version and Kind can also be specified explicitly
   # Note that either both, kind and api-version, or neither have to be specified.
-  flux trace redis --kind=helmrelease --api-version=helm.toolkit.fluxcd.io/v2beta1 -n redis`,
+  flux trace redis --kind=helmrelease --api-version=helm.toolkit.fluxcd.io/v2beta1 -n redis
+
+  # Report which Kustomizations and HelmReleases have applied a commit of a Git repository
+  flux trace gitrepository flux-system --revision=5a2d8a5
+
+  # Report which HelmReleases have applied a chart version in a semver range
+  flux trace helmrepository podinfo --revision=">=6.0.0 <7.0.0"
+
+  # Report which image policies, automations and workloads relate to an image tag
+  flux trace image ghcr.io/stefanprodan/podinfo:6.3.5`,
 	RunE: traceCmdRun,
 }
 
 type traceFlags struct {
 	apiVersion string
 	kind       string
+	revision   string
 }
 
 var traceArgs = traceFlags{}
@@ -78,6 +88,8 @@ func init() {
 		"the Kubernetes object kind, e.g. Deployment'")
 	traceCmd.Flags().StringVar(&traceArgs.apiVersion, "api-version", "",
 		"the Kubernetes object API version, e.g. 'apps/v1'")
+	traceCmd.Flags().StringVar(&traceArgs.revision, "revision", "",
+		"trace a source revision instead of an object, e.g. a commit SHA, 'main@sha1:<commit>' or a semver range, and report which Kustomizations and HelmReleases have applied it")
 	rootCmd.AddCommand(traceCmd)
 }
 
@@ -85,11 +97,15 @@ func traceCmdRun(cmd *cobra.Command, args []string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(kubeconfigArgs, kubeclientOptions)
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
 	if err != nil {
 		return err
 	}
 
+	if traceArgs.revision != "" {
+		return traceRevision(ctx, kubeClient, args)
+	}
+
 	var objects []*unstructured.Unstructured
 	if traceArgs.kind != "" || traceArgs.apiVersion != "" {
 		var obj *unstructured.Unstructured
@@ -0,0 +1,282 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/spf13/cobra"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	autov1 "github.com/fluxcd/image-automation-controller/api/v1beta1"
+	imagev1 "github.com/fluxcd/image-reflector-controller/api/v1beta2"
+
+	"github.com/fluxcd/flux2/pkg/printers"
+)
+
+var traceImageCmd = &cobra.Command{
+	Use:   "image <image-ref>",
+	Short: "Trace an image through the image policies and automations",
+	Long: `The trace image command reports which ImageRepositories scan the repository of an image,
+which ImagePolicies select the image or why they don't, which ImageUpdateAutomations
+would write it to Git, and which workloads currently run it.`,
+	Example: `  # Trace an image tag in all namespaces
+  flux trace image ghcr.io/stefanprodan/podinfo:6.3.5`,
+	Args: cobra.ExactArgs(1),
+	RunE: traceImageCmdRun,
+}
+
+func init() {
+	traceCmd.AddCommand(traceImageCmd)
+}
+
+const traceImageSelected = "selected"
+
+func traceImageCmdRun(cmd *cobra.Command, args []string) error {
+	ref, err := name.ParseReference(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid image reference '%s': %w", args[0], err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
+	if err != nil {
+		return err
+	}
+
+	report, err := traceImage(ctx, kubeClient, ref)
+	if err != nil {
+		return err
+	}
+
+	w := cmd.OutOrStdout()
+	sections := []struct {
+		title  string
+		header []string
+		rows   [][]string
+	}{
+		{"ImageRepositories", []string{"Namespace", "Name", "Tags", "Last scan", "Latest tags"}, report.repositories},
+		{"ImagePolicies", []string{"Namespace", "Name", "Latest image", "Status"}, report.policies},
+		{"ImageUpdateAutomations", []string{"Namespace", "Name", "Source", "Push branch", "Last push"}, report.automations},
+		{"Workloads", []string{"Namespace", "Kind", "Name", "Container"}, report.workloads},
+	}
+	for i, s := range sections {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		if len(s.rows) == 0 {
+			fmt.Fprintf(w, "%s: none found\n", s.title)
+			continue
+		}
+		fmt.Fprintf(w, "%s:\n", s.title)
+		if err := printers.TablePrinter(s.header).Print(w, s.rows); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type imageTraceReport struct {
+	repositories [][]string
+	policies     [][]string
+	automations  [][]string
+	workloads    [][]string
+}
+
+// traceImage collects the Flux image objects and the workloads related to
+// the image reference across all namespaces.
+func traceImage(ctx context.Context, kubeClient client.Client, ref name.Reference) (*imageTraceReport, error) {
+	report := &imageTraceReport{}
+	repository := ref.Context().Name()
+	tag, isTag := ref.(name.Tag)
+
+	var repoList imagev1.ImageRepositoryList
+	if err := kubeClient.List(ctx, &repoList); err != nil {
+		return nil, fmt.Errorf("failed to list ImageRepositories: %w", err)
+	}
+	repos := map[string]bool{}
+	for _, repo := range repoList.Items {
+		r, err := name.NewRepository(repo.Spec.Image)
+		if err != nil || r.Name() != repository {
+			continue
+		}
+		repos[repo.Namespace+"/"+repo.Name] = true
+
+		tags, lastScan, latest := "-", "-", "-"
+		if scan := repo.Status.LastScanResult; scan != nil {
+			tags = fmt.Sprint(scan.TagCount)
+			lastScan = scan.ScanTime.Time.Format(time.RFC3339)
+			latest = "tag not listed"
+			for _, t := range scan.LatestTags {
+				if isTag && t == tag.TagStr() {
+					latest = "tag listed"
+				}
+			}
+		}
+		report.repositories = append(report.repositories, []string{repo.Namespace, repo.Name, tags, lastScan, latest})
+	}
+
+	var policyList imagev1.ImagePolicyList
+	if err := kubeClient.List(ctx, &policyList); err != nil {
+		return nil, fmt.Errorf("failed to list ImagePolicies: %w", err)
+	}
+	selectedIn := map[string]bool{}
+	for _, policy := range policyList.Items {
+		repoRef := policy.Spec.ImageRepositoryRef
+		if repoRef.Namespace == "" {
+			repoRef.Namespace = policy.Namespace
+		}
+		if !repos[repoRef.Namespace+"/"+repoRef.Name] {
+			continue
+		}
+		status := imagePolicyTraceStatus(policy, ref)
+		if status == traceImageSelected {
+			selectedIn[policy.Namespace] = true
+		}
+		latest := policy.Status.LatestImage
+		if latest == "" {
+			latest = "-"
+		}
+		report.policies = append(report.policies, []string{policy.Namespace, policy.Name, latest, status})
+	}
+
+	// The automations update the fields marked with the policies of their
+	// own namespace.
+	var autoList autov1.ImageUpdateAutomationList
+	if err := kubeClient.List(ctx, &autoList); err != nil {
+		return nil, fmt.Errorf("failed to list ImageUpdateAutomations: %w", err)
+	}
+	for _, auto := range autoList.Items {
+		if !selectedIn[auto.Namespace] {
+			continue
+		}
+		source := auto.Spec.SourceRef
+		if source.Namespace == "" {
+			source.Namespace = auto.Namespace
+		}
+		branch := "-"
+		if git := auto.Spec.GitSpec; git != nil {
+			if git.Push != nil {
+				branch = git.Push.Branch
+			} else if git.Checkout != nil {
+				branch = git.Checkout.Reference.Branch
+			}
+		}
+		lastPush := "-"
+		if auto.Status.LastPushTime != nil {
+			lastPush = fmt.Sprintf("%s (%s)", auto.Status.LastPushCommit, auto.Status.LastPushTime.Time.Format(time.RFC3339))
+		}
+		report.automations = append(report.automations, []string{auto.Namespace, auto.Name,
+			fmt.Sprintf("%s/%s.%s", source.Kind, source.Name, source.Namespace), branch, lastPush})
+	}
+
+	workloads, err := imageWorkloads(ctx, kubeClient, ref)
+	if err != nil {
+		return nil, err
+	}
+	report.workloads = workloads
+	return report, nil
+}
+
+// imagePolicyTraceStatus returns 'selected' if the policy selected the image,
+// otherwise the reason why the tag of the image is not selected.
+func imagePolicyTraceStatus(policy imagev1.ImagePolicy, ref name.Reference) string {
+	latest := policy.Status.LatestImage
+	if latest != "" {
+		if latestRef, err := name.ParseReference(latest); err == nil && latestRef.Name() == ref.Name() {
+			return traceImageSelected
+		}
+	}
+	tag, ok := ref.(name.Tag)
+	if !ok {
+		return "not selected, the policy selects tags"
+	}
+
+	result, err := evaluateImagePolicy(policy.Spec, []string{tag.TagStr()})
+	if err != nil {
+		return fmt.Sprintf("not selected, %s", err)
+	}
+	t := result.Tags[0]
+	switch t.Status {
+	case tagStatusFiltered:
+		return fmt.Sprintf("not selected, tag filtered out by pattern '%s'", policy.Spec.FilterTags.Pattern)
+	case tagStatusInvalid:
+		return fmt.Sprintf("not selected, '%s' is invalid for the policy", t.Value)
+	case tagStatusOutOfRange:
+		return fmt.Sprintf("not selected, '%s' is out of range '%s'", t.Value, policy.Spec.Policy.SemVer.Range)
+	}
+	if latest == "" {
+		return "not selected, the policy has no latest image yet"
+	}
+	return "not selected, candidate superseded by the latest image"
+}
+
+// imageWorkloads returns the Deployments, StatefulSets and DaemonSets with
+// a container running the image.
+func imageWorkloads(ctx context.Context, kubeClient client.Client, ref name.Reference) ([][]string, error) {
+	var rows [][]string
+	add := func(kind, namespace, name string, spec corev1.PodSpec) {
+		containers := append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...)
+		for _, c := range containers {
+			if imageRefEquals(c.Image, ref) {
+				rows = append(rows, []string{namespace, kind, name, c.Name})
+			}
+		}
+	}
+
+	var deployments appsv1.DeploymentList
+	if err := kubeClient.List(ctx, &deployments); err != nil {
+		return nil, fmt.Errorf("failed to list Deployments: %w", err)
+	}
+	for _, d := range deployments.Items {
+		add("Deployment", d.Namespace, d.Name, d.Spec.Template.Spec)
+	}
+
+	var statefulSets appsv1.StatefulSetList
+	if err := kubeClient.List(ctx, &statefulSets); err != nil {
+		return nil, fmt.Errorf("failed to list StatefulSets: %w", err)
+	}
+	for _, s := range statefulSets.Items {
+		add("StatefulSet", s.Namespace, s.Name, s.Spec.Template.Spec)
+	}
+
+	var daemonSets appsv1.DaemonSetList
+	if err := kubeClient.List(ctx, &daemonSets); err != nil {
+		return nil, fmt.Errorf("failed to list DaemonSets: %w", err)
+	}
+	for _, d := range daemonSets.Items {
+		add("DaemonSet", d.Namespace, d.Name, d.Spec.Template.Spec)
+	}
+	return rows, nil
+}
+
+// imageRefEquals reports if the image of a container is the image reference,
+// after normalizing the registry and the default tag.
+func imageRefEquals(image string, ref name.Reference) bool {
+	r, err := name.ParseReference(image)
+	if err != nil {
+		return false
+	}
+	return r.Name() == ref.Name()
+}
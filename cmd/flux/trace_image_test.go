@@ -0,0 +1,41 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import "testing"
+
+func TestTraceImage(t *testing.T) {
+	runFakeCmdTests(t, []fakeCmdTestCase{
+		{
+			name:       "selected tag",
+			args:       "trace image ghcr.io/stefanprodan/podinfo:6.3.5",
+			objectFile: "testdata/fake/trace_image.yaml",
+			goldenFile: "testdata/fake/trace_image.golden",
+		},
+		{
+			name:       "unknown image",
+			args:       "trace image nginx",
+			objectFile: "testdata/fake/trace_image.yaml",
+			goldenFile: "testdata/fake/trace_image_none.golden",
+		},
+		{
+			name:    "invalid reference",
+			args:    "trace image ghcr.io/stefanprodan/podinfo:6.3.5:latest",
+			wantErr: "invalid image reference 'ghcr.io/stefanprodan/podinfo:6.3.5:latest': could not parse reference: ghcr.io/stefanprodan/podinfo:6.3.5:latest",
+		},
+	})
+}
@@ -0,0 +1,211 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	fluxmeta "github.com/fluxcd/pkg/apis/meta"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+
+	"github.com/fluxcd/flux2/pkg/printers"
+)
+
+const (
+	revisionApplied   = "applied"
+	revisionAttempted = "attempted"
+	revisionBehind    = "behind"
+)
+
+// traceRevision reports for every Kustomization and HelmRelease which
+// references the source given in args, if it has applied the revision
+// passed with --revision.
+func traceRevision(ctx context.Context, kubeClient client.Client, args []string) error {
+	objects, err := getObjectDynamic(args)
+	if err != nil {
+		return err
+	}
+	if len(objects) != 1 {
+		return fmt.Errorf("a single source is required when tracing a revision")
+	}
+	source := objects[0]
+	if source.GroupVersionKind().Group != sourcev1.GroupVersion.Group {
+		return fmt.Errorf("%s/%s is not a Flux source", source.GetKind(), source.GetName())
+	}
+	if revision, found, _ := unstructured.NestedString(source.Object, "status", "artifact", "revision"); found {
+		logger.Actionf("%s/%s.%s is at revision %s", source.GetKind(), source.GetName(), source.GetNamespace(), revision)
+	}
+
+	var rows [][]string
+
+	var ksList kustomizev1.KustomizationList
+	if err := kubeClient.List(ctx, &ksList); err != nil {
+		return fmt.Errorf("failed to list Kustomizations: %w", err)
+	}
+	for _, ks := range ksList.Items {
+		ref := ks.Spec.SourceRef
+		if !sourceRefMatches(source.GetKind(), source.GetName(), source.GetNamespace(), ref.Kind, ref.Name, ref.Namespace, ks.Namespace) {
+			continue
+		}
+		status := revisionStatus(ks.Status.LastAppliedRevision, ks.Status.LastAttemptedRevision)
+		rows = append(rows, revisionRow(kustomizev1.KustomizationKind, ks.Namespace, ks.Name,
+			status, ks.Status.LastAppliedRevision, ks.Status.Conditions))
+	}
+
+	var hrList helmv2.HelmReleaseList
+	if err := kubeClient.List(ctx, &hrList); err != nil {
+		return fmt.Errorf("failed to list HelmReleases: %w", err)
+	}
+	for _, hr := range hrList.Items {
+		ref := hr.Spec.Chart.Spec.SourceRef
+		if !sourceRefMatches(source.GetKind(), source.GetName(), source.GetNamespace(), ref.Kind, ref.Name, ref.Namespace, hr.Namespace) {
+			continue
+		}
+		status, err := helmReleaseRevisionStatus(ctx, kubeClient, hr)
+		if err != nil {
+			return err
+		}
+		rows = append(rows, revisionRow(helmv2.HelmReleaseKind, hr.Namespace, hr.Name,
+			status, hr.Status.LastAppliedRevision, hr.Status.Conditions))
+	}
+
+	if len(rows) == 0 {
+		logger.Failuref("no Kustomizations or HelmReleases found for %s/%s.%s", source.GetKind(), source.GetName(), source.GetNamespace())
+		return nil
+	}
+
+	header := []string{"Kind", "Namespace", "Name", "Status", "Applied revision", "Last ready transition"}
+	return printers.TablePrinter(header).Print(rootCmd.OutOrStdout(), rows)
+}
+
+// revisionStatus reports if the last applied or attempted revision of an
+// object matches the revision passed with --revision.
+func revisionStatus(applied, attempted string) string {
+	switch {
+	case revisionMatches(applied, traceArgs.revision):
+		return revisionApplied
+	case revisionMatches(attempted, traceArgs.revision):
+		return revisionAttempted
+	}
+	return revisionBehind
+}
+
+// helmReleaseRevisionStatus reports if a HelmRelease has applied the
+// revision passed with --revision. The revisions of a HelmRelease are chart
+// versions, hence the revision is looked up in the source artifact revision
+// the HelmChart of the release was built from, falling back to the chart
+// version for semver ranges.
+func helmReleaseRevisionStatus(ctx context.Context, kubeClient client.Client, hr helmv2.HelmRelease) (string, error) {
+	chartNamespace, chartName := hr.Status.GetHelmChart()
+	if chartName == "" {
+		return revisionStatus(hr.Status.LastAppliedRevision, hr.Status.LastAttemptedRevision), nil
+	}
+
+	var chart sourcev1.HelmChart
+	if err := kubeClient.Get(ctx, client.ObjectKey{Namespace: chartNamespace, Name: chartName}, &chart); err != nil {
+		if apierrors.IsNotFound(err) {
+			return revisionBehind, nil
+		}
+		return "", fmt.Errorf("failed to get HelmChart %s/%s: %w", chartNamespace, chartName, err)
+	}
+	if chart.Status.Artifact == nil {
+		return revisionBehind, nil
+	}
+
+	chartVersion := chart.Status.Artifact.Revision
+	if !revisionMatches(chart.Status.ObservedSourceArtifactRevision, traceArgs.revision) &&
+		!revisionMatches(chartVersion, traceArgs.revision) {
+		return revisionBehind, nil
+	}
+	switch chartVersion {
+	case hr.Status.LastAppliedRevision:
+		return revisionApplied, nil
+	case hr.Status.LastAttemptedRevision:
+		return revisionAttempted, nil
+	}
+	return revisionBehind, nil
+}
+
+// revisionRow returns the table row of an object, with the time of the last
+// transition of its Ready condition.
+func revisionRow(kind, namespace, name, status, applied string, conditions []metav1.Condition) []string {
+	var lastTransition string
+	if c := meta.FindStatusCondition(conditions, fluxmeta.ReadyCondition); c != nil {
+		lastTransition = c.LastTransitionTime.Time.Format(time.RFC3339)
+	}
+	return []string{kind, namespace, name, status, applied, lastTransition}
+}
+
+// sourceRefMatches reports if the source reference of an object in the
+// given namespace points to the source with the given kind, name and
+// namespace.
+func sourceRefMatches(kind, name, namespace, refKind, refName, refNamespace, objNamespace string) bool {
+	if refNamespace == "" {
+		refNamespace = objNamespace
+	}
+	return kind == refKind && name == refName && namespace == refNamespace
+}
+
+// revisionMatches reports if the given revision as reported in the status of
+// an object matches the expected revision. The expected revision can be a
+// full revision, e.g. 'main@sha1:<commit>', a commit SHA of at least seven
+// characters, or a semver range matched against the tag or chart version.
+func revisionMatches(revision, expected string) bool {
+	if revision == "" || expected == "" {
+		return false
+	}
+
+	revision = sourcev1.TransformLegacyRevision(revision)
+	if revision == sourcev1.TransformLegacyRevision(expected) {
+		return true
+	}
+
+	name, digest := revision, ""
+	if i := strings.LastIndex(revision, "@"); i >= 0 {
+		name, digest = revision[:i], revision[i+1:]
+	} else if strings.Contains(revision, ":") {
+		name, digest = "", revision
+	}
+	if _, hash, ok := strings.Cut(digest, ":"); ok && len(expected) >= 7 && strings.HasPrefix(hash, expected) {
+		return true
+	}
+
+	if name != "" {
+		c, err := semver.NewConstraint(expected)
+		if err != nil {
+			return false
+		}
+		v, err := semver.NewVersion(strings.TrimPrefix(name, "refs/tags/"))
+		if err != nil {
+			return false
+		}
+		return c.Check(v)
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"testing"
+
+	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/fluxcd/flux2/internal/utils"
+)
+
+func TestRevisionMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		revision string
+		expected string
+		want     bool
+	}{
+		{"full revision", "main@sha1:5a2d8a5e1f0a9c8b7d6e5f4a3b2c1d0e9f8a7b6c", "main@sha1:5a2d8a5e1f0a9c8b7d6e5f4a3b2c1d0e9f8a7b6c", true},
+		{"legacy revision", "main/5a2d8a5e1f0a9c8b7d6e5f4a3b2c1d0e9f8a7b6c", "main@sha1:5a2d8a5e1f0a9c8b7d6e5f4a3b2c1d0e9f8a7b6c", true},
+		{"commit prefix", "main@sha1:5a2d8a5e1f0a9c8b7d6e5f4a3b2c1d0e9f8a7b6c", "5a2d8a5", true},
+		{"short commit prefix", "main@sha1:5a2d8a5e1f0a9c8b7d6e5f4a3b2c1d0e9f8a7b6c", "5a2d", false},
+		{"other commit", "main@sha1:5a2d8a5e1f0a9c8b7d6e5f4a3b2c1d0e9f8a7b6c", "1b2c3d4", false},
+		{"digest prefix", "sha256:0c7ab7f2e5a0e1b9d6c8a4f3e2d1c0b9a8f7e6d5c4b3a2f1e0d9c8b7a6f5e4d3", "0c7ab7f2", true},
+		{"tag in range", "v1.2.3@sha1:5a2d8a5e1f0a9c8b7d6e5f4a3b2c1d0e9f8a7b6c", "1.2.x", true},
+		{"chart version in range", "6.3.5", ">=6.0.0 <7.0.0", true},
+		{"chart version out of range", "5.1.0", ">=6.0.0 <7.0.0", false},
+		{"empty revision", "", "5a2d8a5", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := revisionMatches(tt.revision, tt.expected); got != tt.want {
+				t.Errorf("revisionMatches(%q, %q) = %v, want %v", tt.revision, tt.expected, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHelmReleaseRevisionStatus(t *testing.T) {
+	chart := &sourcev1.HelmChart{
+		ObjectMeta: metav1.ObjectMeta{Name: "flux-system-podinfo", Namespace: "flux-system"},
+		Status: sourcev1.HelmChartStatus{
+			ObservedSourceArtifactRevision: "main@sha1:5a2d8a5e1f0a9c8b7d6e5f4a3b2c1d0e9f8a7b6c",
+			Artifact:                       &sourcev1.Artifact{Revision: "6.3.5"},
+		},
+	}
+	kubeClient := fake.NewClientBuilder().WithScheme(utils.NewScheme()).WithObjects(chart).Build()
+
+	tests := []struct {
+		name      string
+		revision  string
+		helmChart string
+		applied   string
+		attempted string
+		want      string
+	}{
+		{"source revision applied", "5a2d8a5", "flux-system/flux-system-podinfo", "6.3.5", "6.3.5", revisionApplied},
+		{"source revision attempted", "5a2d8a5", "flux-system/flux-system-podinfo", "6.3.4", "6.3.5", revisionAttempted},
+		{"chart not upgraded yet", "5a2d8a5", "flux-system/flux-system-podinfo", "6.3.4", "6.3.4", revisionBehind},
+		{"other source revision", "1b2c3d4", "flux-system/flux-system-podinfo", "6.3.5", "6.3.5", revisionBehind},
+		{"chart version in range", ">=6.0.0 <7.0.0", "flux-system/flux-system-podinfo", "6.3.5", "6.3.5", revisionApplied},
+		{"chart not found", "5a2d8a5", "flux-system/other", "6.3.5", "6.3.5", revisionBehind},
+		{"no chart in status", "6.3.x", "", "6.3.5", "6.3.5", revisionApplied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			traceArgs.revision = tt.revision
+			t.Cleanup(func() { traceArgs.revision = "" })
+
+			hr := helmv2.HelmRelease{
+				Status: helmv2.HelmReleaseStatus{
+					HelmChart:             tt.helmChart,
+					LastAppliedRevision:   tt.applied,
+					LastAttemptedRevision: tt.attempted,
+				},
+			}
+			got, err := helmReleaseRevisionStatus(context.TODO(), kubeClient, hr)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("helmReleaseRevisionStatus() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
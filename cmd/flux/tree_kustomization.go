@@ -80,7 +80,7 @@ func treeKsCmdRun(cmd *cobra.Command, args []string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(kubeconfigArgs, kubeclientOptions)
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
 	if err != nil {
 		return err
 	}
@@ -192,6 +192,30 @@ func treeKustomization(ctx context.Context, tree tree.ObjMetadataTree, item *kus
 	return nil
 }
 
+// printPruneTree prints in tree form the inventory of the given Kustomizations
+// which have prune enabled, i.e. the objects garbage collected by
+// kustomize-controller when the Kustomizations are deleted.
+func printPruneTree(ctx context.Context, w io.Writer, kubeClient client.Client, items []kustomizev1.Kustomization) error {
+	for i := range items {
+		k := &items[i]
+		if !k.Spec.Prune {
+			logger.Successf("prune is disabled for %s/%s, its objects are kept on deletion", k.Namespace, k.Name)
+			continue
+		}
+		kTree := tree.New(object.ObjMetadata{
+			Namespace: k.Namespace,
+			Name:      k.Name,
+			GroupKind: schema.GroupKind{Group: kustomizev1.GroupVersion.Group, Kind: kustomizev1.KustomizationKind},
+		})
+		if err := treeKustomization(ctx, kTree, k, kubeClient, false); err != nil {
+			return err
+		}
+		logger.Warningf("prune is enabled for %s/%s, these objects are deleted with it", k.Namespace, k.Name)
+		fmt.Fprintln(w, kTree.Print())
+	}
+	return nil
+}
+
 type hrStorage struct {
 	Name     string `json:"name,omitempty"`
 	Manifest string `json:"manifest,omitempty"`
@@ -23,7 +23,6 @@ import (
 	"github.com/manifoldco/promptui"
 	"github.com/spf13/cobra"
 
-	"github.com/fluxcd/flux2/internal/utils"
 	"github.com/fluxcd/flux2/pkg/uninstall"
 )
 
@@ -72,11 +71,15 @@ func uninstallCmdRun(cmd *cobra.Command, args []string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(kubeconfigArgs, kubeclientOptions)
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
 	if err != nil {
 		return err
 	}
 
+	if !uninstallArgs.dryRun {
+		recordNamespaceAuditEvent(ctx, kubeClient, *kubeconfigArgs.Namespace, "Uninstalled", "Flux uninstalled")
+	}
+
 	logger.Actionf("deleting components in %s namespace", *kubeconfigArgs.Namespace)
 	uninstall.Components(ctx, logger, kubeClient, *kubeconfigArgs.Namespace, uninstallArgs.dryRun)
 
@@ -0,0 +1,177 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	extgogit "github.com/fluxcd/go-git/v5"
+	"github.com/fluxcd/go-git/v5/plumbing"
+	"github.com/fluxcd/go-git/v5/storage/memory"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/fluxcd/flux2/internal/provenance"
+	"github.com/fluxcd/flux2/internal/utils"
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+)
+
+var verifyKsCmd = &cobra.Command{
+	Use:     "kustomization [name]",
+	Aliases: []string{"ks"},
+	Short:   "Verify the Git provenance of the revision applied by a Kustomization",
+	Long: `The verify kustomization command checks that the revision last applied by a Kustomization
+exists in the remote Git repository and is in the history of the tracked branch or tag,
+detecting rewritten history or tampered artifacts.
+When key rings are given, the commit must also be signed by one of the trusted OpenPGP keys.`,
+	Example: `  # Verify that the applied commit is in the history of the tracked branch
+  flux verify kustomization podinfo
+
+  # Verify that the applied commit is signed by a trusted key
+  flux verify kustomization podinfo --keyring=./keys/maintainers.asc`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: resourceNamesCompletionFunc(kustomizev1.GroupVersion.WithKind(kustomizev1.KustomizationKind)),
+	RunE:              verifyKsCmdRun,
+}
+
+type verifyKsFlags struct {
+	keyRings []string
+}
+
+var verifyKsArgs verifyKsFlags
+
+func init() {
+	verifyKsCmd.Flags().StringSliceVar(&verifyKsArgs.keyRings, "keyring", nil,
+		"path to an armored OpenPGP public key ring, the applied commit must be signed by a key of one of the key rings")
+
+	verifyCmd.AddCommand(verifyKsCmd)
+}
+
+func verifyKsCmdRun(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	var keyRings []string
+	for _, path := range verifyKsArgs.keyRings {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read key ring: %w", err)
+		}
+		keyRings = append(keyRings, string(data))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := newKubeClient(kubeconfigArgs, kubeclientOptions)
+	if err != nil {
+		return err
+	}
+
+	var ks kustomizev1.Kustomization
+	if err := kubeClient.Get(ctx, types.NamespacedName{Namespace: *kubeconfigArgs.Namespace, Name: name}, &ks); err != nil {
+		return err
+	}
+
+	sourceRef := ks.Spec.SourceRef
+	if sourceRef.Kind != sourcev1.GitRepositoryKind {
+		return fmt.Errorf("provenance can only be verified for %s sources, %s/%s has a %s source",
+			sourcev1.GitRepositoryKind, ks.Namespace, ks.Name, sourceRef.Kind)
+	}
+	sourceNamespace := sourceRef.Namespace
+	if sourceNamespace == "" {
+		sourceNamespace = ks.Namespace
+	}
+
+	commit, err := appliedCommit(ks.Status.LastAppliedRevision)
+	if err != nil {
+		return err
+	}
+
+	var repository sourcev1.GitRepository
+	if err := kubeClient.Get(ctx, types.NamespacedName{Namespace: sourceNamespace, Name: sourceRef.Name}, &repository); err != nil {
+		return err
+	}
+
+	auth, caBundle, err := gitRepositoryAuth(ctx, kubeClient, repository)
+	if err != nil {
+		return err
+	}
+
+	ref := verifiedReference(repository)
+	logger.Actionf("cloning %s", repository.Spec.URL)
+	repo, err := extgogit.CloneContext(ctx, memory.NewStorage(), nil, &extgogit.CloneOptions{
+		URL:           repository.Spec.URL,
+		Auth:          auth,
+		CABundle:      caBundle,
+		ReferenceName: ref,
+		SingleBranch:  ref != "",
+		NoCheckout:    true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to clone %s: %w", repository.Spec.URL, err)
+	}
+
+	result, err := provenance.VerifyCommit(repo, ref, commit, keyRings...)
+	if err != nil {
+		return fmt.Errorf("verification of %s/%s failed: %w", ks.Namespace, ks.Name, err)
+	}
+
+	if result.Reference != "" {
+		logger.Successf("commit %s is in the history of %s", utils.TruncateHex(result.Commit), result.Reference)
+	} else {
+		logger.Successf("commit %s exists in the repository", utils.TruncateHex(result.Commit))
+	}
+	if result.Signer != "" {
+		logger.Successf("commit %s is signed by %s", utils.TruncateHex(result.Commit), result.Signer)
+	}
+	return nil
+}
+
+// appliedCommit returns the commit hash of the revision last applied by a
+// Kustomization, in either the legacy '<branch>/<hash>' or the
+// '<branch>@sha1:<hash>' format.
+func appliedCommit(revision string) (string, error) {
+	if revision == "" {
+		return "", fmt.Errorf("no revision has been applied yet")
+	}
+	revision = sourcev1.TransformLegacyRevision(revision)
+	if i := strings.LastIndex(revision, "@"); i >= 0 {
+		revision = revision[i+1:]
+	}
+	algo, hash, ok := strings.Cut(revision, ":")
+	if !ok || algo != "sha1" || hash == "" {
+		return "", fmt.Errorf("unsupported revision format '%s'", revision)
+	}
+	return hash, nil
+}
+
+// verifiedReference returns the reference of which history must contain
+// the applied commit, or an empty reference if the GitRepository is pinned
+// to a commit or semver range.
+func verifiedReference(repository sourcev1.GitRepository) plumbing.ReferenceName {
+	if branch := trackedBranch(repository); branch != "" {
+		return plumbing.NewBranchReferenceName(branch)
+	}
+	if ref := repository.Spec.Reference; ref != nil && ref.Tag != "" && ref.SemVer == "" && ref.Commit == "" {
+		return plumbing.NewTagReferenceName(ref.Tag)
+	}
+	return ""
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/fluxcd/go-git/v5/plumbing"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+)
+
+func TestAppliedCommit(t *testing.T) {
+	tests := []struct {
+		revision string
+		want     string
+		wantErr  bool
+	}{
+		{revision: "main@sha1:5394cb7f48332b2de7c17dd8b8384bbc84b7e738", want: "5394cb7f48332b2de7c17dd8b8384bbc84b7e738"},
+		{revision: "main/5394cb7f48332b2de7c17dd8b8384bbc84b7e738", want: "5394cb7f48332b2de7c17dd8b8384bbc84b7e738"},
+		{revision: "sha1:5394cb7f48332b2de7c17dd8b8384bbc84b7e738", want: "5394cb7f48332b2de7c17dd8b8384bbc84b7e738"},
+		{revision: "6.1.0@sha256:3b6cdcc7adcc9a84d3214ee1c029543789d90b5ae69debe9efa3f66e982875de", wantErr: true},
+		{revision: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.revision, func(t *testing.T) {
+			got, err := appliedCommit(tt.revision)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("appliedCommit() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("appliedCommit() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifiedReference(t *testing.T) {
+	tests := []struct {
+		name string
+		ref  *sourcev1.GitRepositoryRef
+		want plumbing.ReferenceName
+	}{
+		{name: "default branch", want: "refs/heads/master"},
+		{name: "branch", ref: &sourcev1.GitRepositoryRef{Branch: "main"}, want: "refs/heads/main"},
+		{name: "tag", ref: &sourcev1.GitRepositoryRef{Tag: "v1.0.0"}, want: "refs/tags/v1.0.0"},
+		{name: "semver", ref: &sourcev1.GitRepositoryRef{SemVer: ">=1.0.0"}, want: ""},
+		{name: "commit", ref: &sourcev1.GitRepositoryRef{Branch: "main", Commit: "5394cb7"}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repository := sourcev1.GitRepository{Spec: sourcev1.GitRepositorySpec{Reference: tt.ref}}
+			if got := verifiedReference(repository); got != tt.want {
+				t.Errorf("verifiedReference() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flags
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fluxcd/flux2/pkg/manifestgen/sourcesecret"
+)
+
+var supportedSecretBackends = []string{
+	string(sourcesecret.KubernetesBackend),
+	string(sourcesecret.ExternalSecretsBackend),
+	string(sourcesecret.VaultBackend),
+}
+
+type SecretBackend string
+
+func (b *SecretBackend) String() string {
+	return string(*b)
+}
+
+func (b *SecretBackend) Set(str string) error {
+	if strings.TrimSpace(str) == "" {
+		return fmt.Errorf("no secret backend given, must be one of: %s",
+			strings.Join(supportedSecretBackends, ", "))
+	}
+	for _, v := range supportedSecretBackends {
+		if str == v {
+			*b = SecretBackend(str)
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported secret backend '%s', must be one of: %s",
+		str, strings.Join(supportedSecretBackends, ", "))
+}
+
+func (b *SecretBackend) Type() string {
+	return "secretBackend"
+}
+
+func (b *SecretBackend) Description() string {
+	return fmt.Sprintf("backend the secret is generated for, the non-kubernetes backends generate a manifest that references the credentials in the given --store (%s)",
+		strings.Join(supportedSecretBackends, ", "))
+}
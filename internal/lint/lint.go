@@ -0,0 +1,252 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package lint checks the reconciliation settings of Flux objects, such as
+// intervals and timeouts, for combinations which are valid according to the
+// API but unlikely to behave as intended.
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// MinInterval is the shortest interval considered sensible, as most
+// reconciliations take about this long to complete. The create commands
+// reject shorter intervals.
+const MinInterval = time.Minute
+
+// MinRegistryInterval is the shortest interval considered sensible for
+// objects polling a container registry which enforces rate limits.
+const MinRegistryInterval = 5 * time.Minute
+
+// defaultHelmTimeout is the timeout applied by helm-controller when none is
+// specified on the HelmRelease.
+const defaultHelmTimeout = 5 * time.Minute
+
+// Options configures the checks performed by Lint.
+type Options struct {
+	// MinInterval is the shortest interval which does not trigger a finding,
+	// defaults to MinInterval.
+	MinInterval time.Duration
+	// WaitTimeout is the time a client waits for the object to become ready.
+	// When set, health check timeouts exceeding it trigger a finding.
+	WaitTimeout time.Duration
+}
+
+// Finding describes a problem detected by a rule.
+type Finding struct {
+	// Rule is the name of the rule which reported the finding.
+	Rule string
+	// Message describes the problem.
+	Message string
+}
+
+// Rule checks an object and returns a message for each problem it detects.
+type Rule struct {
+	Name  string
+	Check func(obj *unstructured.Unstructured, opts Options) ([]string, error)
+}
+
+// Rules are the rules run by Lint.
+var Rules = []Rule{
+	{Name: "min-interval", Check: checkMinInterval},
+	{Name: "retry-interval", Check: checkRetryInterval},
+	{Name: "health-check-timeout", Check: checkHealthCheckTimeout},
+	{Name: "registry-rate-limit", Check: checkRegistryRateLimit},
+}
+
+// Lint runs all rules against the object and returns the findings.
+func Lint(obj *unstructured.Unstructured, opts Options) ([]Finding, error) {
+	if opts.MinInterval == 0 {
+		opts.MinInterval = MinInterval
+	}
+	var findings []Finding
+	for _, rule := range Rules {
+		messages, err := rule.Check(obj, opts)
+		if err != nil {
+			return nil, fmt.Errorf("%s/%s/%s: %w", obj.GetKind(), obj.GetNamespace(), obj.GetName(), err)
+		}
+		for _, msg := range messages {
+			findings = append(findings, Finding{Rule: rule.Name, Message: msg})
+		}
+	}
+	return findings, nil
+}
+
+func checkMinInterval(obj *unstructured.Unstructured, opts Options) ([]string, error) {
+	interval, ok, err := duration(obj, "spec", "interval")
+	if !ok || err != nil {
+		return nil, err
+	}
+	if interval < opts.MinInterval {
+		return []string{fmt.Sprintf("interval %s is shorter than the typical reconciliation duration of %s",
+			interval, opts.MinInterval)}, nil
+	}
+	return nil, nil
+}
+
+func checkRetryInterval(obj *unstructured.Unstructured, _ Options) ([]string, error) {
+	retryInterval, ok, err := duration(obj, "spec", "retryInterval")
+	if !ok || err != nil {
+		return nil, err
+	}
+	interval, ok, err := duration(obj, "spec", "interval")
+	if !ok || err != nil {
+		return nil, err
+	}
+	if retryInterval > interval {
+		return []string{fmt.Sprintf("retry interval %s is longer than the interval %s, failures are retried less often than successful reconciliations",
+			retryInterval, interval)}, nil
+	}
+	return nil, nil
+}
+
+func checkHealthCheckTimeout(obj *unstructured.Unstructured, opts Options) ([]string, error) {
+	timeouts, err := healthCheckTimeouts(obj)
+	if err != nil || len(timeouts) == 0 {
+		return nil, err
+	}
+	// kustomize-controller defaults the timeout to the interval, a longer
+	// timeout delays the next reconciliation, whereas the Helm timeouts apply
+	// to individual operations.
+	interval, hasInterval, err := duration(obj, "spec", "interval")
+	if err != nil {
+		return nil, err
+	}
+	hasInterval = hasInterval && obj.GetKind() == "Kustomization"
+
+	fields := make([]string, 0, len(timeouts))
+	for field := range timeouts {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	var messages []string
+	for _, field := range fields {
+		timeout := timeouts[field]
+		if hasInterval && timeout > interval {
+			messages = append(messages, fmt.Sprintf("%s %s exceeds the interval %s", field, timeout, interval))
+		}
+		if opts.WaitTimeout > 0 && timeout > opts.WaitTimeout {
+			messages = append(messages, fmt.Sprintf("%s %s exceeds the wait timeout %s", field, timeout, opts.WaitTimeout))
+		}
+	}
+	return messages, nil
+}
+
+func checkRegistryRateLimit(obj *unstructured.Unstructured, _ Options) ([]string, error) {
+	var ref string
+	switch obj.GetKind() {
+	case "ImageRepository":
+		ref, _, _ = unstructured.NestedString(obj.Object, "spec", "image")
+	case "OCIRepository", "HelmRepository":
+		ref, _, _ = unstructured.NestedString(obj.Object, "spec", "url")
+		if !strings.HasPrefix(ref, "oci://") {
+			return nil, nil
+		}
+	default:
+		return nil, nil
+	}
+	registry := rateLimitedRegistry(strings.TrimPrefix(ref, "oci://"))
+	if registry == "" {
+		return nil, nil
+	}
+	interval, ok, err := duration(obj, "spec", "interval")
+	if !ok || err != nil {
+		return nil, err
+	}
+	if interval < MinRegistryInterval {
+		return []string{fmt.Sprintf("interval %s may exceed the rate limits of %s, consider an interval of %s or longer",
+			interval, registry, MinRegistryInterval)}, nil
+	}
+	return nil, nil
+}
+
+// rateLimitedRegistry returns the name of the registry hosting the image
+// if it is known to enforce rate limits on the API calls made by Flux.
+func rateLimitedRegistry(image string) string {
+	host, _, found := strings.Cut(image, "/")
+	switch {
+	case !found || !strings.ContainsAny(host, ".:") && host != "localhost":
+		// images without a registry host are pulled from Docker Hub
+		return "Docker Hub"
+	case host == "docker.io" || host == "index.docker.io" || host == "registry-1.docker.io":
+		return "Docker Hub"
+	case host == "public.ecr.aws" || strings.Contains(host, ".dkr.ecr.") && strings.HasSuffix(host, ".amazonaws.com"):
+		return "Amazon ECR"
+	default:
+		return ""
+	}
+}
+
+// healthCheckTimeouts returns the timeouts within which the object is
+// expected to become healthy, indexed by a description of their origin.
+func healthCheckTimeouts(obj *unstructured.Unstructured) (map[string]time.Duration, error) {
+	switch obj.GetKind() {
+	case "Kustomization":
+		wait, _, _ := unstructured.NestedBool(obj.Object, "spec", "wait")
+		checks, _, _ := unstructured.NestedSlice(obj.Object, "spec", "healthChecks")
+		if !wait && len(checks) == 0 {
+			return nil, nil
+		}
+		timeout, ok, err := duration(obj, "spec", "timeout")
+		if !ok || err != nil {
+			return nil, err
+		}
+		return map[string]time.Duration{"health check timeout": timeout}, nil
+	case "HelmRelease":
+		timeout, ok, err := duration(obj, "spec", "timeout")
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			timeout = defaultHelmTimeout
+		}
+		timeouts := map[string]time.Duration{}
+		for _, action := range []string{"install", "upgrade"} {
+			actionTimeout, ok, err := duration(obj, "spec", action, "timeout")
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				actionTimeout = timeout
+			}
+			timeouts[action+" timeout"] = actionTimeout
+		}
+		return timeouts, nil
+	default:
+		return nil, nil
+	}
+}
+
+// duration parses the duration found at the path of the object, the
+// returned bool is false if the field is not set.
+func duration(obj *unstructured.Unstructured, fields ...string) (time.Duration, bool, error) {
+	value, ok, err := unstructured.NestedString(obj.Object, fields...)
+	if !ok || err != nil {
+		return 0, false, err
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid duration '%s' in field %v: %w", value, fields, err)
+	}
+	return d, true, nil
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lint
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name  string
+		kind  string
+		spec  map[string]interface{}
+		opts  Options
+		rules []string
+	}{
+		{
+			name: "sensible settings",
+			kind: "Kustomization",
+			spec: map[string]interface{}{"interval": "10m", "retryInterval": "2m", "timeout": "5m", "wait": true},
+		},
+		{
+			name:  "interval too short",
+			kind:  "GitRepository",
+			spec:  map[string]interface{}{"interval": "10s"},
+			rules: []string{"min-interval"},
+		},
+		{
+			name:  "retry interval longer than interval",
+			kind:  "Kustomization",
+			spec:  map[string]interface{}{"interval": "1m", "retryInterval": "5m"},
+			rules: []string{"retry-interval"},
+		},
+		{
+			name:  "health check timeout exceeds interval",
+			kind:  "Kustomization",
+			spec:  map[string]interface{}{"interval": "1m", "timeout": "3m", "healthChecks": []interface{}{map[string]interface{}{"kind": "Deployment"}}},
+			rules: []string{"health-check-timeout"},
+		},
+		{
+			name: "timeout without health checks",
+			kind: "Kustomization",
+			spec: map[string]interface{}{"interval": "1m", "timeout": "3m"},
+		},
+		{
+			name:  "helm default timeout exceeds wait timeout",
+			kind:  "HelmRelease",
+			spec:  map[string]interface{}{"interval": "10m", "upgrade": map[string]interface{}{"timeout": "1m"}},
+			opts:  Options{WaitTimeout: 2 * time.Minute},
+			rules: []string{"health-check-timeout"},
+		},
+		{
+			name:  "image repository polling ECR too often",
+			kind:  "ImageRepository",
+			spec:  map[string]interface{}{"interval": "1m", "image": "012345678901.dkr.ecr.us-east-1.amazonaws.com/podinfo"},
+			rules: []string{"registry-rate-limit"},
+		},
+		{
+			name:  "image repository polling Docker Hub too often",
+			kind:  "ImageRepository",
+			spec:  map[string]interface{}{"interval": "1m", "image": "stefanprodan/podinfo"},
+			rules: []string{"registry-rate-limit"},
+		},
+		{
+			name: "image repository polling GHCR",
+			kind: "ImageRepository",
+			spec: map[string]interface{}{"interval": "1m", "image": "ghcr.io/stefanprodan/podinfo"},
+		},
+		{
+			name:  "OCI Helm repository polling ECR too often",
+			kind:  "HelmRepository",
+			spec:  map[string]interface{}{"interval": "1m", "url": "oci://public.ecr.aws/charts"},
+			rules: []string{"registry-rate-limit"},
+		},
+		{
+			name: "HTTPS Helm repository",
+			kind: "HelmRepository",
+			spec: map[string]interface{}{"interval": "1m", "url": "https://stefanprodan.github.io/podinfo"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			obj := &unstructured.Unstructured{Object: map[string]interface{}{"spec": tt.spec}}
+			obj.SetKind(tt.kind)
+			obj.SetName("test")
+
+			findings, err := Lint(obj, tt.opts)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			var rules []string
+			for _, f := range findings {
+				rules = append(rules, f.Rule)
+			}
+			g.Expect(rules).To(Equal(tt.rules))
+		})
+	}
+}
+
+func TestLintInvalidDuration(t *testing.T) {
+	g := NewWithT(t)
+
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{"interval": "often"},
+	}}
+	obj.SetKind("GitRepository")
+
+	_, err := Lint(obj, Options{})
+	g.Expect(err).To(HaveOccurred())
+}
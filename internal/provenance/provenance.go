@@ -0,0 +1,133 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package provenance verifies that the revisions applied on the cluster
+// originate from the history of the Git repository they were fetched from.
+package provenance
+
+import (
+	"errors"
+	"fmt"
+
+	extgogit "github.com/fluxcd/go-git/v5"
+	"github.com/fluxcd/go-git/v5/plumbing"
+	"github.com/fluxcd/go-git/v5/plumbing/object"
+)
+
+// ErrCommitNotFound is returned when the commit does not exist in the
+// repository, e.g. after the history was rewritten.
+var ErrCommitNotFound = errors.New("commit not found")
+
+// ErrNotReachable is returned when the commit exists but is not in the
+// history of the reference.
+var ErrNotReachable = errors.New("commit not reachable")
+
+// ErrUntrustedSignature is returned when the commit is not signed by any of
+// the trusted keys.
+var ErrUntrustedSignature = errors.New("untrusted signature")
+
+// Result holds the outcome of a successful verification.
+type Result struct {
+	// Commit is the hash of the verified commit.
+	Commit string
+	// Reference is the reference of which history contains the commit,
+	// empty if the reachability was not verified.
+	Reference string
+	// Signer is the identity of the key which signed the commit, empty if
+	// the signature was not verified.
+	Signer string
+}
+
+// VerifyCommit verifies that the commit exists in the repository and, when
+// a reference is given, that it is in the history of the reference. When key
+// rings are given, the commit must be signed by a key of one of the armored
+// key rings.
+func VerifyCommit(repo *extgogit.Repository, ref plumbing.ReferenceName, hash string, keyRings ...string) (*Result, error) {
+	commit, err := repo.CommitObject(plumbing.NewHash(hash))
+	if err != nil {
+		if errors.Is(err, plumbing.ErrObjectNotFound) {
+			return nil, fmt.Errorf("%w: %s does not exist in the repository", ErrCommitNotFound, hash)
+		}
+		return nil, err
+	}
+
+	result := &Result{Commit: commit.Hash.String()}
+
+	if ref != "" {
+		reachable, err := isReachable(repo, ref, commit)
+		if err != nil {
+			return nil, err
+		}
+		if !reachable {
+			return nil, fmt.Errorf("%w: %s is not in the history of %s", ErrNotReachable, hash, ref.Short())
+		}
+		result.Reference = ref.Short()
+	}
+
+	if len(keyRings) > 0 {
+		signer, err := verifySignature(commit, keyRings)
+		if err != nil {
+			return nil, err
+		}
+		result.Signer = signer
+	}
+
+	return result, nil
+}
+
+// isReachable returns true if the commit is the head of the reference or
+// one of its ancestors. Annotated tags are peeled to the commit they point to.
+func isReachable(repo *extgogit.Repository, ref plumbing.ReferenceName, commit *object.Commit) (bool, error) {
+	resolved, err := repo.Reference(ref, true)
+	if err != nil {
+		return false, fmt.Errorf("failed to resolve %s: %w", ref.Short(), err)
+	}
+	hash := resolved.Hash()
+	if tag, err := repo.TagObject(hash); err == nil {
+		target, err := tag.Commit()
+		if err != nil {
+			return false, fmt.Errorf("failed to peel tag %s: %w", ref.Short(), err)
+		}
+		hash = target.Hash
+	} else if !errors.Is(err, plumbing.ErrObjectNotFound) {
+		return false, err
+	}
+	if hash == commit.Hash {
+		return true, nil
+	}
+	head, err := repo.CommitObject(hash)
+	if err != nil {
+		return false, err
+	}
+	return commit.IsAncestor(head)
+}
+
+func verifySignature(commit *object.Commit, keyRings []string) (string, error) {
+	if commit.PGPSignature == "" {
+		return "", fmt.Errorf("%w: %s is not signed", ErrUntrustedSignature, commit.Hash)
+	}
+	for _, keyRing := range keyRings {
+		entity, err := commit.Verify(keyRing)
+		if err != nil {
+			continue
+		}
+		for name := range entity.Identities {
+			return name, nil
+		}
+		return entity.PrimaryKey.KeyIdString(), nil
+	}
+	return "", fmt.Errorf("%w: %s is not signed by any of the trusted keys", ErrUntrustedSignature, commit.Hash)
+}
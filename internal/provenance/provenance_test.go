@@ -0,0 +1,131 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provenance
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	extgogit "github.com/fluxcd/go-git/v5"
+	"github.com/fluxcd/go-git/v5/plumbing"
+	"github.com/fluxcd/go-git/v5/plumbing/object"
+	"github.com/fluxcd/go-git/v5/storage/memory"
+	"github.com/go-git/go-billy/v5/memfs"
+	. "github.com/onsi/gomega"
+)
+
+func TestVerifyCommit(t *testing.T) {
+	g := NewWithT(t)
+
+	signer, err := openpgp.NewEntity("Flux", "", "flux@example.com", nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	trusted := armoredPublicKey(t, signer)
+	other, err := openpgp.NewEntity("Other", "", "other@example.com", nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	untrusted := armoredPublicKey(t, other)
+
+	repo, err := extgogit.Init(memory.NewStorage(), memfs.New())
+	g.Expect(err).ToNot(HaveOccurred())
+	wt, err := repo.Worktree()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	fs := wt.Filesystem
+	commit := func(msg string, key *openpgp.Entity) string {
+		f, err := fs.Create(msg)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(f.Close()).To(Succeed())
+		_, err = wt.Add(msg)
+		g.Expect(err).ToNot(HaveOccurred())
+		hash, err := wt.Commit(msg, &extgogit.CommitOptions{
+			Author:  &object.Signature{Name: "Flux", Email: "flux@example.com", When: time.Now()},
+			SignKey: key,
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+		return hash.String()
+	}
+
+	first := commit("first", nil)
+	signed := commit("signed", signer)
+	g.Expect(wt.Checkout(&extgogit.CheckoutOptions{
+		Hash:   plumbing.NewHash(first),
+		Branch: plumbing.NewBranchReferenceName("feature"),
+		Create: true,
+	})).To(Succeed())
+	unmerged := commit("unmerged", nil)
+
+	master := plumbing.NewBranchReferenceName("master")
+
+	tagger := &object.Signature{Name: "Flux", Email: "flux@example.com", When: time.Now()}
+	_, err = repo.CreateTag("v1.0.0", plumbing.NewHash(signed), &extgogit.CreateTagOptions{Tagger: tagger, Message: "v1.0.0"})
+	g.Expect(err).ToNot(HaveOccurred())
+	annotatedTag := plumbing.NewTagReferenceName("v1.0.0")
+
+	tests := []struct {
+		name     string
+		ref      plumbing.ReferenceName
+		hash     string
+		keyRings []string
+		wantErr  error
+		signer   string
+	}{
+		{name: "head of branch", ref: master, hash: signed},
+		{name: "ancestor of branch", ref: master, hash: first},
+		{name: "annotated tag", ref: annotatedTag, hash: signed},
+		{name: "ancestor of annotated tag", ref: annotatedTag, hash: first},
+		{name: "not in history of annotated tag", ref: annotatedTag, hash: unmerged, wantErr: ErrNotReachable},
+		{name: "without reference", hash: unmerged},
+		{name: "not in history", ref: master, hash: unmerged, wantErr: ErrNotReachable},
+		{name: "rewritten history", ref: master, hash: "16cfcc0b9066b3234dda29927ac1c19860d9663f", wantErr: ErrCommitNotFound},
+		{name: "trusted signature", ref: master, hash: signed, keyRings: []string{untrusted, trusted}, signer: "Flux <flux@example.com>"},
+		{name: "untrusted signature", ref: master, hash: signed, keyRings: []string{untrusted}, wantErr: ErrUntrustedSignature},
+		{name: "unsigned commit", ref: master, hash: first, keyRings: []string{trusted}, wantErr: ErrUntrustedSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			result, err := VerifyCommit(repo, tt.ref, tt.hash, tt.keyRings...)
+			if tt.wantErr != nil {
+				g.Expect(errors.Is(err, tt.wantErr)).To(BeTrue(), "unexpected error: %v", err)
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(result.Commit).To(Equal(tt.hash))
+			g.Expect(result.Signer).To(Equal(tt.signer))
+		})
+	}
+}
+
+func armoredPublicKey(t *testing.T, entity *openpgp.Entity) string {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := armor.Encode(&buf, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := entity.Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
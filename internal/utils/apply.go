@@ -39,17 +39,27 @@ import (
 // Apply is the equivalent of 'kubectl apply --server-side -f'.
 // If the given manifest is a kustomization.yaml, then apply performs the equivalent of 'kubectl apply --server-side -k'.
 func Apply(ctx context.Context, rcg genericclioptions.RESTClientGetter, opts *runclient.Options, root, manifestPath string) (string, error) {
-	objs, err := readObjects(root, manifestPath)
+	changeSet, err := ApplyChangeSet(ctx, rcg, opts, root, manifestPath)
 	if err != nil {
 		return "", err
 	}
+	return changeSet.String(), nil
+}
+
+// ApplyChangeSet is the same as Apply, but it returns the change set with
+// the action taken for each object.
+func ApplyChangeSet(ctx context.Context, rcg genericclioptions.RESTClientGetter, opts *runclient.Options, root, manifestPath string) (*ssa.ChangeSet, error) {
+	objs, err := readObjects(root, manifestPath)
+	if err != nil {
+		return nil, err
+	}
 
 	if len(objs) == 0 {
-		return "", fmt.Errorf("no Kubernetes objects found at: %s", manifestPath)
+		return nil, fmt.Errorf("no Kubernetes objects found at: %s", manifestPath)
 	}
 
 	if err := ssa.SetNativeKindsDefaults(objs); err != nil {
-		return "", err
+		return nil, err
 	}
 
 	changeSet := ssa.NewChangeSet()
@@ -71,24 +81,52 @@ func Apply(ctx context.Context, rcg genericclioptions.RESTClientGetter, opts *ru
 	if len(stageOne) > 0 {
 		cs, err := applySet(ctx, rcg, opts, stageOne)
 		if err != nil {
-			return "", err
+			return nil, err
 		}
 		changeSet.Append(cs.Entries)
 	}
 
 	if err := waitForSet(rcg, opts, changeSet); err != nil {
-		return "", err
+		return nil, err
 	}
 
 	if len(stageTwo) > 0 {
 		cs, err := applySet(ctx, rcg, opts, stageTwo)
 		if err != nil {
-			return "", err
+			return nil, err
 		}
 		changeSet.Append(cs.Entries)
 	}
 
-	return changeSet.String(), nil
+	return changeSet, nil
+}
+
+// ApplyStats counts the objects by the action taken when applying them.
+type ApplyStats struct {
+	Created    int
+	Configured int
+	Unchanged  int
+}
+
+// Add counts the objects of the change set.
+func (s *ApplyStats) Add(changeSet *ssa.ChangeSet) {
+	if changeSet == nil {
+		return
+	}
+	for _, entry := range changeSet.Entries {
+		switch ssa.Action(entry.Action) {
+		case ssa.CreatedAction:
+			s.Created++
+		case ssa.ConfiguredAction:
+			s.Configured++
+		case ssa.UnchangedAction:
+			s.Unchanged++
+		}
+	}
+}
+
+func (s ApplyStats) String() string {
+	return fmt.Sprintf("%d created, %d configured, %d unchanged", s.Created, s.Configured, s.Unchanged)
 }
 
 func readObjects(root, manifestPath string) ([]*unstructured.Unstructured, error) {
@@ -117,7 +155,8 @@ func readObjects(root, manifestPath string) ([]*unstructured.Unstructured, error
 	return ssa.ReadObjects(bufio.NewReader(ms))
 }
 
-func newManager(rcg genericclioptions.RESTClientGetter, opts *runclient.Options) (*ssa.ResourceManager, error) {
+// NewResourceManager returns a server-side apply manager with the flux field owner.
+func NewResourceManager(rcg genericclioptions.RESTClientGetter, opts *runclient.Options) (*ssa.ResourceManager, error) {
 	cfg, err := KubeConfig(rcg, opts)
 	if err != nil {
 		return nil, err
@@ -140,7 +179,7 @@ func newManager(rcg genericclioptions.RESTClientGetter, opts *runclient.Options)
 }
 
 func applySet(ctx context.Context, rcg genericclioptions.RESTClientGetter, opts *runclient.Options, objects []*unstructured.Unstructured) (*ssa.ChangeSet, error) {
-	man, err := newManager(rcg, opts)
+	man, err := NewResourceManager(rcg, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -149,7 +188,7 @@ func applySet(ctx context.Context, rcg genericclioptions.RESTClientGetter, opts
 }
 
 func waitForSet(rcg genericclioptions.RESTClientGetter, opts *runclient.Options, changeSet *ssa.ChangeSet) error {
-	man, err := newManager(rcg, opts)
+	man, err := NewResourceManager(rcg, opts)
 	if err != nil {
 		return err
 	}
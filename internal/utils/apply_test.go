@@ -0,0 +1,40 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/fluxcd/pkg/ssa"
+	. "github.com/onsi/gomega"
+)
+
+func TestApplyStats(t *testing.T) {
+	g := NewWithT(t)
+
+	changeSet := ssa.NewChangeSet()
+	for _, action := range []ssa.Action{ssa.CreatedAction, ssa.ConfiguredAction, ssa.UnchangedAction, ssa.UnchangedAction} {
+		changeSet.Add(ssa.ChangeSetEntry{Action: string(action)})
+	}
+
+	var stats ApplyStats
+	stats.Add(changeSet)
+	stats.Add(changeSet)
+	stats.Add(nil)
+	g.Expect(stats).To(Equal(ApplyStats{Created: 2, Configured: 2, Unchanged: 4}))
+	g.Expect(stats.String()).To(Equal("2 created, 2 configured, 4 unchanged"))
+}
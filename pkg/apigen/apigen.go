@@ -0,0 +1,92 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package apigen constructs Flux custom resources from plain option
+// structs, applying the same validation as the flux create commands. It
+// allows tools such as Terraform providers and operators to generate
+// objects which are identical to the ones created by the CLI for the same
+// values. The defaults of the command flags, like the reconciliation
+// interval per kind, are not applied: the options are used as given.
+package apigen
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/fluxcd/pkg/apis/meta"
+)
+
+// ObjectOptions holds the metadata and reconciliation settings common to
+// all generated objects.
+type ObjectOptions struct {
+	Name      string
+	Namespace string
+	Labels    map[string]string
+	// Interval is set as given, it is not defaulted when zero.
+	Interval time.Duration
+	// Suspend generates the object with reconciliation suspended.
+	Suspend bool
+}
+
+func (o ObjectOptions) validate() error {
+	if o.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	return nil
+}
+
+func (o ObjectOptions) objectMeta() metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      o.Name,
+		Namespace: o.Namespace,
+		Labels:    o.Labels,
+	}
+}
+
+func (o ObjectOptions) interval() metav1.Duration {
+	return metav1.Duration{Duration: o.Interval}
+}
+
+// optionalDuration returns nil for a zero duration, for the optional
+// timeout fields of the APIs.
+func optionalDuration(d time.Duration) *metav1.Duration {
+	if d <= 0 {
+		return nil
+	}
+	return &metav1.Duration{Duration: d}
+}
+
+// localObjectReference returns nil for an empty name, for the optional
+// secret reference fields of the APIs.
+func localObjectReference(name string) *meta.LocalObjectReference {
+	if name == "" {
+		return nil
+	}
+	return &meta.LocalObjectReference{Name: name}
+}
+
+// ignorePaths joins the given paths into a .sourceignore formatted string,
+// or returns nil when no paths are given.
+func ignorePaths(paths []string) *string {
+	if len(paths) == 0 {
+		return nil
+	}
+	s := strings.Join(paths, "\n")
+	return &s
+}
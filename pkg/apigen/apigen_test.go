@@ -0,0 +1,307 @@
+//go:build !e2e
+// +build !e2e
+
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apigen
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+)
+
+func TestKustomization(t *testing.T) {
+	opts := KustomizationOptions{
+		ObjectOptions: ObjectOptions{Name: "podinfo", Namespace: "flux-system", Interval: time.Minute},
+		SourceName:    "podinfo",
+		Path:          "./deploy",
+		Prune:         true,
+		DependsOn:     []string{"infra/redis"},
+		HealthChecks:  []string{"Deployment/podinfo.default", "HelmRelease/redis.infra"},
+		HealthTimeout: 2 * time.Minute,
+	}
+	ks, err := Kustomization(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ks.Spec.SourceRef.Kind != sourcev1.GitRepositoryKind {
+		t.Errorf("expected default source kind GitRepository, got %s", ks.Spec.SourceRef.Kind)
+	}
+	if len(ks.Spec.HealthChecks) != 2 || ks.Spec.HealthChecks[1].APIVersion == "" {
+		t.Errorf("unexpected health checks %v", ks.Spec.HealthChecks)
+	}
+	discovered := opts
+	discovered.HealthChecks = []string{"Pod/app.default"}
+	discovered.HealthCheckAPIVersions = map[string]string{"Pod": "v1"}
+	if ks, err := Kustomization(discovered); err != nil {
+		t.Errorf("unexpected error %v", err)
+	} else if ks.Spec.HealthChecks[0].APIVersion != "v1" {
+		t.Errorf("expected discovered API version, got %v", ks.Spec.HealthChecks)
+	}
+	if len(ks.Spec.DependsOn) != 1 || ks.Spec.DependsOn[0].Namespace != "infra" {
+		t.Errorf("unexpected dependencies %v", ks.Spec.DependsOn)
+	}
+	if ks.Spec.Timeout == nil || ks.Spec.Timeout.Duration != 2*time.Minute {
+		t.Errorf("unexpected timeout %v", ks.Spec.Timeout)
+	}
+	withComponents := opts
+	withComponents.Components = []string{"../components/ingress", "./components/tls"}
+	if ks, err := Kustomization(withComponents); err != nil {
+		t.Errorf("unexpected error %v", err)
+	} else if len(ks.Spec.Components) != 2 {
+		t.Errorf("unexpected components %v", ks.Spec.Components)
+	}
+
+	for _, tt := range []struct {
+		mutate  func(o *KustomizationOptions)
+		wantErr string
+	}{
+		{func(o *KustomizationOptions) { o.Name = "" }, "name is required"},
+		{func(o *KustomizationOptions) { o.Path = "deploy" }, "path must begin with ./"},
+		{func(o *KustomizationOptions) { o.HealthChecks = []string{"Pod/app.default"} }, "invalid health check kind"},
+		{func(o *KustomizationOptions) { o.Components = []string{"/components/ingress"} }, "must be relative"},
+		{func(o *KustomizationOptions) { o.Components = []string{"../../components/ingress"} }, "points outside of the source"},
+		{func(o *KustomizationOptions) {
+			o.HealthChecks = []string{"Deployment/app.default"}
+			o.HealthCheckAPIVersions = map[string]string{"Pod": "v1"}
+		}, "invalid health check kind 'Deployment' can be one of: Pod"},
+	} {
+		o := opts
+		tt.mutate(&o)
+		if _, err := Kustomization(o); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("got error %v, want %q", err, tt.wantErr)
+		}
+	}
+}
+
+func TestHelmRelease(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.yaml")
+	override := filepath.Join(dir, "override.yaml")
+	if err := os.WriteFile(base, []byte("replicas: 1\nimage:\n  tag: v1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(override, []byte("image:\n  tag: v1.5\n---\nimage:\n  tag: v2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	hr, err := HelmRelease(HelmReleaseOptions{
+		ObjectOptions: ObjectOptions{Name: "podinfo", Namespace: "flux-system"},
+		SourceKind:    sourcev1.HelmRepositoryKind,
+		SourceName:    "podinfo",
+		Chart:         "podinfo",
+		ValuesFiles:   []string{base, override},
+		ValuesFrom:    []string{"secret/podinfo-values"},
+		CRDs:          "CreateReplace",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hr.Spec.Chart.Spec.ReconcileStrategy != sourcev1.ReconcileStrategyChartVersion {
+		t.Errorf("expected default reconcile strategy, got %s", hr.Spec.Chart.Spec.ReconcileStrategy)
+	}
+	if got := string(hr.Spec.Values.Raw); got != `{"image":{"tag":"v2"},"replicas":1}` {
+		t.Errorf("unexpected merged values %s", got)
+	}
+	if len(hr.Spec.ValuesFrom) != 1 || hr.Spec.ValuesFrom[0].Kind != "Secret" {
+		t.Errorf("unexpected values from %v", hr.Spec.ValuesFrom)
+	}
+	if hr.Spec.Install == nil || hr.Spec.Upgrade == nil || hr.Spec.Upgrade.CRDs != "CreateReplace" {
+		t.Errorf("unexpected CRDs policy %v %v", hr.Spec.Install, hr.Spec.Upgrade)
+	}
+
+	if _, err := HelmRelease(HelmReleaseOptions{
+		ObjectOptions:     ObjectOptions{Name: "podinfo"},
+		Chart:             "podinfo",
+		ReconcileStrategy: "Always",
+	}); err == nil {
+		t.Error("expected error for invalid reconcile strategy")
+	}
+}
+
+func TestGitRepository(t *testing.T) {
+	opts := GitRepositoryOptions{
+		ObjectOptions: ObjectOptions{Name: "podinfo"},
+		URL:           "https://github.com/stefanprodan/podinfo",
+		Branch:        "master",
+		Tag:           "6.0.0",
+		IgnorePaths:   []string{"/*", "!/deploy"},
+	}
+	repo, err := GitRepository(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.Spec.Reference.Tag != "6.0.0" || repo.Spec.Reference.Branch != "" {
+		t.Errorf("expected tag to take precedence over branch, got %v", repo.Spec.Reference)
+	}
+	if repo.Spec.Ignore == nil || *repo.Spec.Ignore != "/*\n!/deploy" {
+		t.Errorf("unexpected ignore %v", repo.Spec.Ignore)
+	}
+	if repo.Spec.SecretRef != nil || repo.Spec.Timeout != nil || repo.Spec.Verification != nil {
+		t.Errorf("expected optional fields to be unset")
+	}
+
+	opts.VerifySecretRef = "pgp-public-keys"
+	repo, err = GitRepository(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := repo.Spec.Verification; v == nil || v.Mode != "head" || v.SecretRef.Name != "pgp-public-keys" {
+		t.Errorf("expected head verification with the pgp-public-keys secret, got %v", v)
+	}
+
+	opts.VerifyMode = "tag"
+	if _, err := GitRepository(opts); err == nil {
+		t.Error("expected error for unsupported verification mode")
+	}
+	opts.VerifyMode = ""
+
+	opts.URL = "ftp://example.com/repo"
+	if _, err := GitRepository(opts); err == nil {
+		t.Error("expected error for unsupported scheme")
+	}
+}
+
+func TestHelmRepository(t *testing.T) {
+	repo, err := HelmRepository(HelmRepositoryOptions{
+		ObjectOptions:   ObjectOptions{Name: "podinfo"},
+		URL:             "oci://ghcr.io/stefanprodan/charts",
+		PassCredentials: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.Spec.Type != sourcev1.HelmRepositoryTypeOCI {
+		t.Errorf("expected OCI type, got %q", repo.Spec.Type)
+	}
+	if repo.Spec.PassCredentials {
+		t.Error("expected pass credentials to be ignored without a secret")
+	}
+
+	repo, err = HelmRepository(HelmRepositoryOptions{
+		ObjectOptions: ObjectOptions{Name: "podinfo"},
+		URL:           "oci://ghcr.io/stefanprodan/charts",
+		Type:          sourcev1.HelmRepositoryTypeOCI,
+		Provider:      sourcev1.AzureOCIProvider,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.Spec.Provider != sourcev1.AzureOCIProvider {
+		t.Errorf("expected azure provider, got %q", repo.Spec.Provider)
+	}
+
+	for _, tt := range []struct {
+		opts    HelmRepositoryOptions
+		wantErr string
+	}{
+		{HelmRepositoryOptions{URL: "https://stefanprodan.github.io/podinfo", Type: "oci"}, "must use the oci scheme"},
+		{HelmRepositoryOptions{URL: "oci://ghcr.io/stefanprodan/charts", Type: "default"}, "requires the repository type oci"},
+		{HelmRepositoryOptions{URL: "oci://ghcr.io/stefanprodan/charts", Type: "git"}, "type must be one of"},
+		{HelmRepositoryOptions{URL: "https://stefanprodan.github.io/podinfo", Provider: "aws"}, "only supported for repositories of type oci"},
+		{HelmRepositoryOptions{URL: "oci://ghcr.io/stefanprodan/charts", Provider: "aws", SecretRef: "creds"}, "can't be combined"},
+	} {
+		tt.opts.ObjectOptions = ObjectOptions{Name: "podinfo"}
+		if _, err := HelmRepository(tt.opts); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+		}
+	}
+}
+
+func TestImagePolicy(t *testing.T) {
+	opts := ImagePolicyOptions{
+		ObjectOptions:      ObjectOptions{Name: "podinfo"},
+		ImageRepositoryRef: "podinfo",
+		Numerical:          "asc",
+		FilterRegex:        `^main-[a-f0-9]+-(?P<ts>[0-9]+)`,
+		FilterExtract:      "$ts",
+	}
+	if _, err := ImagePolicy(opts); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		mutate  func(o *ImagePolicyOptions)
+		wantErr string
+	}{
+		{func(o *ImagePolicyOptions) { o.SemVer = ">=1.0.0" }, "only one of"},
+		{func(o *ImagePolicyOptions) { o.Numerical = "" }, "policy is required"},
+		{func(o *ImagePolicyOptions) { o.Numerical = "up" }, "numerical order must be one of"},
+		{func(o *ImagePolicyOptions) { o.FilterExtract = "$unknown" }, "capture group $unknown"},
+		{func(o *ImagePolicyOptions) { o.FilterRegex = "" }, "without a filter regex"},
+		{func(o *ImagePolicyOptions) { o.Suspend = true }, "can't be suspended"},
+	} {
+		o := opts
+		tt.mutate(&o)
+		if _, err := ImagePolicy(o); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("got error %v, want %q", err, tt.wantErr)
+		}
+	}
+}
+
+func TestImageUpdateAutomation(t *testing.T) {
+	update, err := ImageUpdateAutomation(ImageUpdateAutomationOptions{
+		ObjectOptions:     ObjectOptions{Name: "flux-system"},
+		GitRepositoryName: "flux-system",
+		CheckoutBranch:    "main",
+		AuthorName:        "fluxbot",
+		AuthorEmail:       "fluxbot@example.com",
+		Path:              "./clusters/my-cluster",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if update.Spec.GitSpec.Push != nil {
+		t.Error("expected no push spec without a push branch")
+	}
+	if update.Spec.Update == nil || update.Spec.Update.Path != "./clusters/my-cluster" {
+		t.Errorf("unexpected update strategy %v", update.Spec.Update)
+	}
+
+	signed, err := ImageUpdateAutomation(ImageUpdateAutomationOptions{
+		ObjectOptions:       ObjectOptions{Name: "flux-system"},
+		GitRepositoryName:   "flux-system",
+		CheckoutBranch:      "main",
+		AuthorName:          "fluxbot",
+		AuthorEmail:         "fluxbot@example.com",
+		Signoff:             true,
+		SigningKeySecretRef: "signing-key",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantTemplate := DefaultImageUpdateCommitTemplate + "\n\nSigned-off-by: fluxbot <fluxbot@example.com>\n"
+	if got := signed.Spec.GitSpec.Commit.MessageTemplate; got != wantTemplate {
+		t.Errorf("got message template %q, want %q", got, wantTemplate)
+	}
+	if key := signed.Spec.GitSpec.Commit.SigningKey; key == nil || key.SecretRef.Name != "signing-key" {
+		t.Errorf("unexpected signing key %v", key)
+	}
+
+	if _, err := ImageUpdateAutomation(ImageUpdateAutomationOptions{
+		ObjectOptions:     ObjectOptions{Name: "flux-system"},
+		GitRepositoryName: "flux-system",
+		CheckoutBranch:    "main",
+	}); err == nil {
+		t.Error("expected error for missing author")
+	}
+}
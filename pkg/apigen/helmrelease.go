@@ -0,0 +1,223 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apigen
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+
+	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/fluxcd/pkg/runtime/transform"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+
+	"github.com/fluxcd/flux2/internal/utils"
+)
+
+// SupportedHelmReleaseValuesFromKinds are the kinds which can be
+// referenced in HelmReleaseOptions.ValuesFrom.
+var SupportedHelmReleaseValuesFromKinds = []string{"Secret", "ConfigMap"}
+
+// SupportedHelmReleaseReconcileStrategies are the accepted values of
+// HelmReleaseOptions.ReconcileStrategy.
+var SupportedHelmReleaseReconcileStrategies = []string{sourcev1.ReconcileStrategyRevision, sourcev1.ReconcileStrategyChartVersion}
+
+// HelmReleaseOptions holds the values used to construct a HelmRelease.
+type HelmReleaseOptions struct {
+	ObjectOptions
+
+	ReleaseName     string
+	DependsOn       []string
+	TargetNamespace string
+	CreateNamespace bool
+
+	SourceKind      string
+	SourceName      string
+	SourceNamespace string
+
+	Chart         string
+	ChartVersion  string
+	ChartInterval time.Duration
+	// ReconcileStrategy defaults to ChartVersion.
+	ReconcileStrategy string
+
+	// ValuesFiles are paths to YAML files which are merged in order
+	// into the inline values.
+	ValuesFiles []string
+	// ValuesFrom are references in the format '<kind>/<name>'.
+	ValuesFrom []string
+
+	ServiceAccountName  string
+	KubeConfigSecretRef string
+	// CRDs is the upgrade CRDs policy, one of Skip, Create or
+	// CreateReplace.
+	CRDs string
+}
+
+func (o HelmReleaseOptions) reconcileStrategy() string {
+	if o.ReconcileStrategy == "" {
+		return sourcev1.ReconcileStrategyChartVersion
+	}
+	return o.ReconcileStrategy
+}
+
+// HelmRelease returns a HelmRelease constructed from the given options.
+func HelmRelease(opts HelmReleaseOptions) (*helmv2.HelmRelease, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	if opts.Chart == "" {
+		return nil, fmt.Errorf("chart name or path is required")
+	}
+	if !utils.ContainsItemString(SupportedHelmReleaseReconcileStrategies, opts.reconcileStrategy()) {
+		return nil, fmt.Errorf("'%s' is an invalid reconcile strategy(valid: %s)",
+			opts.ReconcileStrategy, strings.Join(SupportedHelmReleaseReconcileStrategies, ", "))
+	}
+
+	helmRelease := &helmv2.HelmRelease{
+		ObjectMeta: opts.objectMeta(),
+		Spec: helmv2.HelmReleaseSpec{
+			ReleaseName:     opts.ReleaseName,
+			DependsOn:       utils.MakeDependsOn(opts.DependsOn),
+			Interval:        opts.interval(),
+			TargetNamespace: opts.TargetNamespace,
+			Chart: helmv2.HelmChartTemplate{
+				Spec: helmv2.HelmChartTemplateSpec{
+					Chart:   opts.Chart,
+					Version: opts.ChartVersion,
+					SourceRef: helmv2.CrossNamespaceObjectReference{
+						Kind:      opts.SourceKind,
+						Name:      opts.SourceName,
+						Namespace: opts.SourceNamespace,
+					},
+					ReconcileStrategy: opts.reconcileStrategy(),
+					Interval:          optionalDuration(opts.ChartInterval),
+				},
+			},
+			Suspend:            opts.Suspend,
+			ServiceAccountName: opts.ServiceAccountName,
+		},
+	}
+
+	if opts.KubeConfigSecretRef != "" {
+		helmRelease.Spec.KubeConfig = &helmv2.KubeConfig{
+			SecretRef: meta.SecretKeyReference{
+				Name: opts.KubeConfigSecretRef,
+			},
+		}
+	}
+
+	if opts.CreateNamespace {
+		if helmRelease.Spec.Install == nil {
+			helmRelease.Spec.Install = &helmv2.Install{}
+		}
+		helmRelease.Spec.Install.CreateNamespace = true
+	}
+
+	if opts.CRDs != "" {
+		if helmRelease.Spec.Install == nil {
+			helmRelease.Spec.Install = &helmv2.Install{}
+		}
+		helmRelease.Spec.Install.CRDs = helmv2.Create
+		helmRelease.Spec.Upgrade = &helmv2.Upgrade{CRDs: helmv2.CRDsPolicy(opts.CRDs)}
+	}
+
+	if len(opts.ValuesFiles) > 0 {
+		values, err := MergeValuesFiles(opts.ValuesFiles)
+		if err != nil {
+			return nil, err
+		}
+		helmRelease.Spec.Values = values
+	}
+
+	if len(opts.ValuesFrom) > 0 {
+		values := []helmv2.ValuesReference{}
+		for _, value := range opts.ValuesFrom {
+			sourceKind, sourceName := utils.ParseObjectKindName(value)
+			if sourceKind == "" {
+				return nil, fmt.Errorf("invalid Kubernetes object reference '%s', must be in format <kind>/<name>", value)
+			}
+			cleanSourceKind, ok := utils.ContainsEqualFoldItemString(SupportedHelmReleaseValuesFromKinds, sourceKind)
+			if !ok {
+				return nil, fmt.Errorf("reference kind '%s' is not supported, must be one of: %s",
+					sourceKind, strings.Join(SupportedHelmReleaseValuesFromKinds, ", "))
+			}
+			values = append(values, helmv2.ValuesReference{
+				Name: sourceName,
+				Kind: cleanSourceKind,
+			})
+		}
+		helmRelease.Spec.ValuesFrom = values
+	}
+
+	return helmRelease, nil
+}
+
+// MergeValuesFiles merges the YAML values files in order, the values of a file
+// override the ones of the previous files. The documents of a multi-doc file
+// are merged in the order they appear.
+func MergeValuesFiles(files []string) (*apiextensionsv1.JSON, error) {
+	valuesMap := make(map[string]interface{})
+	for _, v := range files {
+		data, err := os.ReadFile(v)
+		if err != nil {
+			return nil, fmt.Errorf("reading values from %s failed: %w", v, err)
+		}
+
+		reader := k8syaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(data)))
+		for {
+			doc, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("reading values from %s failed: %w", v, err)
+			}
+
+			jsonBytes, err := yaml.YAMLToJSON(doc)
+			if err != nil {
+				return nil, fmt.Errorf("converting values to JSON from %s failed: %w", v, err)
+			}
+			if len(bytes.TrimSpace(jsonBytes)) == 0 || string(jsonBytes) == "null" {
+				continue
+			}
+
+			jsonMap := make(map[string]interface{})
+			if err := json.Unmarshal(jsonBytes, &jsonMap); err != nil {
+				return nil, fmt.Errorf("unmarshaling values from %s failed: %w", v, err)
+			}
+
+			valuesMap = transform.MergeMaps(valuesMap, jsonMap)
+		}
+	}
+
+	jsonRaw, err := json.Marshal(valuesMap)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling values failed: %w", err)
+	}
+	return &apiextensionsv1.JSON{Raw: jsonRaw}, nil
+}
@@ -0,0 +1,357 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apigen
+
+import (
+	"fmt"
+	"regexp/syntax"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/go-containerregistry/pkg/name"
+
+	autov1 "github.com/fluxcd/image-automation-controller/api/v1beta1"
+	imagev1 "github.com/fluxcd/image-reflector-controller/api/v1beta2"
+	"github.com/fluxcd/pkg/apis/meta"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+)
+
+// ImageRepositoryOptions holds the values used to construct an
+// ImageRepository.
+type ImageRepositoryOptions struct {
+	ObjectOptions
+
+	Image         string
+	SecretRef     string
+	CertSecretRef string
+	Timeout       time.Duration
+}
+
+// ImageRepository returns an ImageRepository constructed from the given
+// options.
+func ImageRepository(opts ImageRepositoryOptions) (*imagev1.ImageRepository, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	if opts.Image == "" {
+		return nil, fmt.Errorf("an image repository is required")
+	}
+	if _, err := name.NewRepository(opts.Image); err != nil {
+		return nil, fmt.Errorf("unable to parse image value: %w", err)
+	}
+
+	return &imagev1.ImageRepository{
+		ObjectMeta: opts.objectMeta(),
+		Spec: imagev1.ImageRepositorySpec{
+			Image:         opts.Image,
+			Interval:      opts.interval(),
+			Suspend:       opts.Suspend,
+			Timeout:       optionalDuration(opts.Timeout),
+			SecretRef:     localObjectReference(opts.SecretRef),
+			CertSecretRef: localObjectReference(opts.CertSecretRef),
+		},
+	}, nil
+}
+
+// ImagePolicyOptions holds the values used to construct an ImagePolicy.
+// Exactly one of SemVer, Alphabetical or Numerical must be set.
+type ImagePolicyOptions struct {
+	ObjectOptions
+
+	ImageRepositoryRef string
+
+	SemVer string
+	// Alphabetical and Numerical are the sort order, asc or desc.
+	Alphabetical string
+	Numerical    string
+
+	FilterRegex string
+	// FilterExtract may only be set with FilterRegex, and can only
+	// reference capture groups defined in it.
+	FilterExtract string
+}
+
+// ImagePolicy returns an ImagePolicy constructed from the given options.
+// The interval is not used, as policies are not reconciled on a schedule,
+// and policies can't be suspended.
+func ImagePolicy(opts ImagePolicyOptions) (*imagev1.ImagePolicy, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	if opts.ImageRepositoryRef == "" {
+		return nil, fmt.Errorf("the name of an ImageRepository in the namespace is required")
+	}
+	if opts.Suspend {
+		return nil, fmt.Errorf("ImagePolicy objects can't be suspended")
+	}
+
+	policy := &imagev1.ImagePolicy{
+		ObjectMeta: opts.objectMeta(),
+		Spec: imagev1.ImagePolicySpec{
+			ImageRepositoryRef: meta.NamespacedObjectReference{
+				Name: opts.ImageRepositoryRef,
+			},
+		},
+	}
+
+	set := 0
+	for _, p := range []string{opts.SemVer, opts.Alphabetical, opts.Numerical} {
+		if p != "" {
+			set++
+		}
+	}
+	switch {
+	case set > 1:
+		return nil, fmt.Errorf("only one of semver, alphabetical or numerical policy can be specified")
+	case opts.SemVer != "":
+		policy.Spec.Policy.SemVer = &imagev1.SemVerPolicy{
+			Range: opts.SemVer,
+		}
+	case opts.Alphabetical != "":
+		if opts.Alphabetical != "desc" && opts.Alphabetical != "asc" {
+			return nil, fmt.Errorf("alphabetical order must be one of [\"asc\", \"desc\"]")
+		}
+		policy.Spec.Policy.Alphabetical = &imagev1.AlphabeticalPolicy{
+			Order: opts.Alphabetical,
+		}
+	case opts.Numerical != "":
+		if opts.Numerical != "desc" && opts.Numerical != "asc" {
+			return nil, fmt.Errorf("numerical order must be one of [\"asc\", \"desc\"]")
+		}
+		policy.Spec.Policy.Numerical = &imagev1.NumericalPolicy{
+			Order: opts.Numerical,
+		}
+	default:
+		return nil, fmt.Errorf("a semver, alphabetical or numerical policy is required")
+	}
+
+	if opts.FilterRegex != "" {
+		exp, err := syntax.Parse(opts.FilterRegex, syntax.Perl)
+		if err != nil {
+			return nil, fmt.Errorf("filter regex is an invalid regex pattern")
+		}
+		policy.Spec.FilterTags = &imagev1.TagFilter{
+			Pattern: opts.FilterRegex,
+		}
+
+		if opts.FilterExtract != "" {
+			if err := validateExtractStr(opts.FilterExtract, exp.CapNames()); err != nil {
+				return nil, err
+			}
+			policy.Spec.FilterTags.Extract = opts.FilterExtract
+		}
+	} else if opts.FilterExtract != "" {
+		return nil, fmt.Errorf("cannot specify a filter extract without a filter regex")
+	}
+
+	return policy, nil
+}
+
+// DefaultImageUpdateCommitTemplate is the commit message used by the
+// image-automation-controller when no template is given.
+const DefaultImageUpdateCommitTemplate = "Update from image update automation"
+
+// ImageUpdateAutomationOptions holds the values used to construct an
+// ImageUpdateAutomation.
+type ImageUpdateAutomationOptions struct {
+	ObjectOptions
+
+	GitRepositoryName      string
+	GitRepositoryNamespace string
+	CheckoutBranch         string
+	// PushBranch defaults to the checkout branch when empty.
+	PushBranch string
+
+	AuthorName     string
+	AuthorEmail    string
+	CommitTemplate string
+	// Signoff appends a Signed-off-by trailer for the author to the
+	// commit message template.
+	Signoff bool
+	// SigningKeySecretRef is the name of a secret holding the GPG key
+	// used to sign the commits.
+	SigningKeySecretRef string
+
+	// Path enables the Setters update strategy for the given path
+	// relative to the repository root.
+	Path string
+}
+
+// ImageUpdateAutomation returns an ImageUpdateAutomation constructed from
+// the given options.
+func ImageUpdateAutomation(opts ImageUpdateAutomationOptions) (*autov1.ImageUpdateAutomation, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	switch {
+	case opts.GitRepositoryName == "":
+		return nil, fmt.Errorf("a reference to a GitRepository is required")
+	case opts.CheckoutBranch == "":
+		return nil, fmt.Errorf("the Git repository branch is required")
+	case opts.AuthorName == "":
+		return nil, fmt.Errorf("the author name is required")
+	case opts.AuthorEmail == "":
+		return nil, fmt.Errorf("the author email is required")
+	}
+
+	messageTemplate := opts.CommitTemplate
+	if opts.Signoff {
+		if messageTemplate == "" {
+			messageTemplate = DefaultImageUpdateCommitTemplate
+		}
+		messageTemplate = strings.TrimRight(messageTemplate, "\n") +
+			fmt.Sprintf("\n\nSigned-off-by: %s <%s>\n", opts.AuthorName, opts.AuthorEmail)
+	}
+
+	update := &autov1.ImageUpdateAutomation{
+		ObjectMeta: opts.objectMeta(),
+		Spec: autov1.ImageUpdateAutomationSpec{
+			SourceRef: autov1.CrossNamespaceSourceReference{
+				Kind:      sourcev1.GitRepositoryKind,
+				Name:      opts.GitRepositoryName,
+				Namespace: opts.GitRepositoryNamespace,
+			},
+			GitSpec: &autov1.GitSpec{
+				Checkout: &autov1.GitCheckoutSpec{
+					Reference: sourcev1.GitRepositoryRef{
+						Branch: opts.CheckoutBranch,
+					},
+				},
+				Commit: autov1.CommitSpec{
+					Author: autov1.CommitUser{
+						Name:  opts.AuthorName,
+						Email: opts.AuthorEmail,
+					},
+					MessageTemplate: messageTemplate,
+				},
+			},
+			Interval: opts.interval(),
+			Suspend:  opts.Suspend,
+		},
+	}
+
+	if opts.SigningKeySecretRef != "" {
+		update.Spec.GitSpec.Commit.SigningKey = &autov1.SigningKey{
+			SecretRef: meta.LocalObjectReference{Name: opts.SigningKeySecretRef},
+		}
+	}
+
+	if opts.PushBranch != "" {
+		update.Spec.GitSpec.Push = &autov1.PushSpec{
+			Branch: opts.PushBranch,
+		}
+	}
+
+	if opts.Path != "" {
+		update.Spec.Update = &autov1.UpdateStrategy{
+			Path:     opts.Path,
+			Strategy: autov1.UpdateStrategySetters,
+		}
+	}
+
+	return update, nil
+}
+
+// validateExtractStr performs a dry-run of the extract function in Regexp
+// to validate the template.
+func validateExtractStr(template string, capNames []string) error {
+	for len(template) > 0 {
+		i := strings.Index(template, "$")
+		if i < 0 {
+			return nil
+		}
+		template = template[i:]
+		if len(template) > 1 && template[1] == '$' {
+			template = template[2:]
+			continue
+		}
+		name, num, rest, ok := extract(template)
+		if !ok {
+			return fmt.Errorf("filter extract is malformed")
+		}
+		template = rest
+		if num >= 0 {
+			// we won't worry about numbers as we can't validate these
+			continue
+		}
+		found := false
+		for _, capName := range capNames {
+			if name == capName {
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("capture group $%s used in filter extract not found in filter regex", name)
+		}
+	}
+	return nil
+}
+
+// extract method from the regexp package
+// returns the name or number of the value prepended by $
+func extract(str string) (name string, num int, rest string, ok bool) {
+	if len(str) < 2 || str[0] != '$' {
+		return
+	}
+	brace := false
+	if str[1] == '{' {
+		brace = true
+		str = str[2:]
+	} else {
+		str = str[1:]
+	}
+	i := 0
+	for i < len(str) {
+		rune, size := utf8.DecodeRuneInString(str[i:])
+		if !unicode.IsLetter(rune) && !unicode.IsDigit(rune) && rune != '_' {
+			break
+		}
+		i += size
+	}
+	if i == 0 {
+		// empty name is not okay
+		return
+	}
+	name = str[:i]
+	if brace {
+		if i >= len(str) || str[i] != '}' {
+			// missing closing brace
+			return
+		}
+		i++
+	}
+
+	// Parse number.
+	num = 0
+	for i := 0; i < len(name); i++ {
+		if name[i] < '0' || '9' < name[i] || num >= 1e8 {
+			num = -1
+			break
+		}
+		num = num*10 + int(name[i]) - '0'
+	}
+	// Disallow leading zeros.
+	if name[0] == '0' && len(name) > 1 {
+		num = -1
+	}
+
+	rest = str[i:]
+	ok = true
+	return
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apigen
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	"github.com/fluxcd/pkg/apis/meta"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+
+	"github.com/fluxcd/flux2/internal/utils"
+)
+
+// KustomizationOptions holds the values used to construct a Kustomization.
+type KustomizationOptions struct {
+	ObjectOptions
+
+	// SourceKind defaults to GitRepository.
+	SourceKind      string
+	SourceName      string
+	SourceNamespace string
+
+	// Path must be relative to the source root and begin with './'.
+	Path            string
+	Prune           bool
+	DependsOn       []string
+	TargetNamespace string
+
+	// Components are paths to kustomize components relative to Path, they
+	// must not point outside of the source.
+	Components []string
+
+	// HealthChecks are in the format '<kind>/<name>.<namespace>', they are
+	// ignored when Wait is set.
+	HealthChecks  []string
+	Wait          bool
+	HealthTimeout time.Duration
+
+	// HealthCheckAPIVersions maps the kinds allowed in HealthChecks to
+	// their API version, e.g. as discovered from the cluster. When empty,
+	// the kinds are limited to Deployment, DaemonSet, StatefulSet and
+	// HelmRelease.
+	HealthCheckAPIVersions map[string]string
+
+	ServiceAccountName  string
+	KubeConfigSecretRef string
+	DecryptionProvider  string
+	DecryptionSecret    string
+}
+
+// Kustomization returns a Kustomization constructed from the given options.
+func Kustomization(opts KustomizationOptions) (*kustomizev1.Kustomization, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	if opts.Path == "" {
+		return nil, fmt.Errorf("path is required")
+	}
+	if !strings.HasPrefix(opts.Path, "./") {
+		return nil, fmt.Errorf("path must begin with ./")
+	}
+	for _, component := range opts.Components {
+		if err := validateComponent(opts.Path, component); err != nil {
+			return nil, err
+		}
+	}
+
+	sourceKind := opts.SourceKind
+	if sourceKind == "" {
+		sourceKind = sourcev1.GitRepositoryKind
+	}
+
+	kustomization := &kustomizev1.Kustomization{
+		ObjectMeta: opts.objectMeta(),
+		Spec: kustomizev1.KustomizationSpec{
+			DependsOn:  utils.MakeDependsOn(opts.DependsOn),
+			Interval:   opts.interval(),
+			Path:       opts.Path,
+			Components: opts.Components,
+			Prune:      opts.Prune,
+			SourceRef: kustomizev1.CrossNamespaceSourceReference{
+				Kind:      sourceKind,
+				Name:      opts.SourceName,
+				Namespace: opts.SourceNamespace,
+			},
+			Suspend:            opts.Suspend,
+			TargetNamespace:    opts.TargetNamespace,
+			ServiceAccountName: opts.ServiceAccountName,
+		},
+	}
+
+	if opts.KubeConfigSecretRef != "" {
+		kustomization.Spec.KubeConfig = &meta.KubeConfigReference{
+			SecretRef: meta.SecretKeyReference{
+				Name: opts.KubeConfigSecretRef,
+			},
+		}
+	}
+
+	if len(opts.HealthChecks) > 0 && !opts.Wait {
+		healthChecks, err := parseHealthChecks(opts.HealthChecks, opts.HealthCheckAPIVersions)
+		if err != nil {
+			return nil, err
+		}
+		kustomization.Spec.HealthChecks = healthChecks
+		kustomization.Spec.Timeout = &metav1.Duration{Duration: opts.HealthTimeout}
+	}
+
+	if opts.Wait {
+		kustomization.Spec.Wait = true
+		kustomization.Spec.Timeout = &metav1.Duration{Duration: opts.HealthTimeout}
+	}
+
+	if opts.DecryptionProvider != "" {
+		kustomization.Spec.Decryption = &kustomizev1.Decryption{
+			Provider:  opts.DecryptionProvider,
+			SecretRef: localObjectReference(opts.DecryptionSecret),
+		}
+	}
+
+	return kustomization, nil
+}
+
+// validateComponent returns an error if the component path is not relative
+// to the Kustomization path or points outside of the source.
+func validateComponent(ksPath, component string) error {
+	if component == "" || path.IsAbs(component) {
+		return fmt.Errorf("invalid component path '%s': must be relative to the path of the Kustomization", component)
+	}
+	if p := path.Join(ksPath, component); p == ".." || strings.HasPrefix(p, "../") {
+		return fmt.Errorf("invalid component path '%s': points outside of the source", component)
+	}
+	return nil
+}
+
+func parseHealthChecks(checks []string, apiVersions map[string]string) ([]meta.NamespacedObjectKindReference, error) {
+	if len(apiVersions) == 0 {
+		apiVersions = map[string]string{
+			"Deployment":           "",
+			"DaemonSet":            "",
+			"StatefulSet":          "",
+			helmv2.HelmReleaseKind: helmv2.GroupVersion.String(),
+		}
+	}
+
+	healthChecks := make([]meta.NamespacedObjectKindReference, 0, len(checks))
+	for _, w := range checks {
+		kindObj := strings.Split(w, "/")
+		if len(kindObj) != 2 {
+			return nil, fmt.Errorf("invalid health check '%s' must be in the format 'kind/name.namespace' %v", w, kindObj)
+		}
+		kind := kindObj[0]
+		apiVersion, ok := apiVersions[kind]
+		if !ok {
+			kinds := make([]string, 0, len(apiVersions))
+			for k := range apiVersions {
+				kinds = append(kinds, k)
+			}
+			sort.Strings(kinds)
+			return nil, fmt.Errorf("invalid health check kind '%s' can be one of: %s", kind, strings.Join(kinds, ", "))
+		}
+		nameNs := strings.Split(kindObj[1], ".")
+		if len(nameNs) != 2 {
+			return nil, fmt.Errorf("invalid health check '%s' must be in the format 'kind/name.namespace'", w)
+		}
+
+		healthChecks = append(healthChecks, meta.NamespacedObjectKindReference{
+			APIVersion: apiVersion,
+			Kind:       kind,
+			Name:       nameNs[0],
+			Namespace:  nameNs[1],
+		})
+	}
+	return healthChecks, nil
+}
@@ -0,0 +1,288 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apigen
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+)
+
+// GitRepositoryOptions holds the values used to construct a GitRepository.
+type GitRepositoryOptions struct {
+	ObjectOptions
+
+	// URL must use the ssh, http or https scheme.
+	URL string
+	// Only one of SemVer, Tag or Branch is set on the reference,
+	// in that order of precedence.
+	Branch string
+	Tag    string
+	SemVer string
+
+	SecretRef         string
+	RecurseSubmodules bool
+	IgnorePaths       []string
+	Timeout           time.Duration
+
+	// VerifySecretRef enables the verification of the commit signatures
+	// with the GPG public keys of the referenced secret, VerifyMode
+	// defaults to 'head'.
+	VerifySecretRef string
+	VerifyMode      string
+}
+
+// GitRepository returns a GitRepository constructed from the given options.
+func GitRepository(opts GitRepositoryOptions) (*sourcev1.GitRepository, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	if opts.URL == "" {
+		return nil, fmt.Errorf("url is required")
+	}
+	u, err := url.Parse(opts.URL)
+	if err != nil {
+		return nil, fmt.Errorf("git URL parse failed: %w", err)
+	}
+	if u.Scheme != "ssh" && u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("git URL scheme '%s' not supported, can be: ssh, http and https", u.Scheme)
+	}
+	if opts.Branch == "" && opts.Tag == "" && opts.SemVer == "" {
+		return nil, fmt.Errorf("a Git ref is required, one of branch, tag or semver must be set")
+	}
+	if opts.VerifyMode != "" && opts.VerifyMode != "head" {
+		return nil, fmt.Errorf("verification mode '%s' not supported, can be: head", opts.VerifyMode)
+	}
+
+	gitRepository := &sourcev1.GitRepository{
+		ObjectMeta: opts.objectMeta(),
+		Spec: sourcev1.GitRepositorySpec{
+			URL:               opts.URL,
+			Interval:          opts.interval(),
+			Suspend:           opts.Suspend,
+			RecurseSubmodules: opts.RecurseSubmodules,
+			Reference:         &sourcev1.GitRepositoryRef{},
+			Ignore:            ignorePaths(opts.IgnorePaths),
+			Timeout:           optionalDuration(opts.Timeout),
+			SecretRef:         localObjectReference(opts.SecretRef),
+		},
+	}
+
+	switch {
+	case opts.SemVer != "":
+		gitRepository.Spec.Reference.SemVer = opts.SemVer
+	case opts.Tag != "":
+		gitRepository.Spec.Reference.Tag = opts.Tag
+	default:
+		gitRepository.Spec.Reference.Branch = opts.Branch
+	}
+
+	if opts.VerifySecretRef != "" {
+		mode := opts.VerifyMode
+		if mode == "" {
+			mode = "head"
+		}
+		gitRepository.Spec.Verification = &sourcev1.GitRepositoryVerification{
+			Mode:      mode,
+			SecretRef: meta.LocalObjectReference{Name: opts.VerifySecretRef},
+		}
+	}
+
+	return gitRepository, nil
+}
+
+// HelmRepositoryOptions holds the values used to construct a HelmRepository.
+type HelmRepositoryOptions struct {
+	ObjectOptions
+
+	// URL with the oci scheme results in a repository of type OCI.
+	URL string
+	// Type is inferred from the URL scheme when empty.
+	Type string
+	// Provider is only supported for the OCI type, and is left to the API
+	// default (generic) when empty.
+	Provider string
+
+	SecretRef string
+	// PassCredentials is only set when SecretRef is given.
+	PassCredentials bool
+	Timeout         time.Duration
+}
+
+// HelmRepository returns a HelmRepository constructed from the given options.
+func HelmRepository(opts HelmRepositoryOptions) (*sourcev1.HelmRepository, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	if opts.URL == "" {
+		return nil, fmt.Errorf("url is required")
+	}
+	u, err := url.Parse(opts.URL)
+	if err != nil {
+		return nil, fmt.Errorf("url parse failed: %w", err)
+	}
+
+	helmRepository := &sourcev1.HelmRepository{
+		ObjectMeta: opts.objectMeta(),
+		Spec: sourcev1.HelmRepositorySpec{
+			URL:      opts.URL,
+			Interval: opts.interval(),
+			Suspend:  opts.Suspend,
+			Timeout:  optionalDuration(opts.Timeout),
+		},
+	}
+
+	switch opts.Type {
+	case "":
+		if u.Scheme == sourcev1.HelmRepositoryTypeOCI {
+			helmRepository.Spec.Type = sourcev1.HelmRepositoryTypeOCI
+		}
+	case sourcev1.HelmRepositoryTypeOCI:
+		if u.Scheme != "oci" {
+			return nil, fmt.Errorf("url must use the oci scheme for repositories of type %s", opts.Type)
+		}
+		helmRepository.Spec.Type = opts.Type
+	case sourcev1.HelmRepositoryTypeDefault:
+		if u.Scheme == "oci" {
+			return nil, fmt.Errorf("url with the oci scheme requires the repository type %s", sourcev1.HelmRepositoryTypeOCI)
+		}
+		helmRepository.Spec.Type = opts.Type
+	default:
+		return nil, fmt.Errorf("repository type must be one of: %s, %s",
+			sourcev1.HelmRepositoryTypeDefault, sourcev1.HelmRepositoryTypeOCI)
+	}
+
+	if opts.Provider != "" {
+		if helmRepository.Spec.Type != sourcev1.HelmRepositoryTypeOCI {
+			return nil, fmt.Errorf("provider is only supported for repositories of type %s", sourcev1.HelmRepositoryTypeOCI)
+		}
+		if opts.Provider != sourcev1.GenericOCIProvider && opts.SecretRef != "" {
+			return nil, fmt.Errorf("secret ref can't be combined with the %s provider", opts.Provider)
+		}
+		helmRepository.Spec.Provider = opts.Provider
+	}
+
+	if opts.SecretRef != "" {
+		helmRepository.Spec.SecretRef = localObjectReference(opts.SecretRef)
+		helmRepository.Spec.PassCredentials = opts.PassCredentials
+	}
+
+	return helmRepository, nil
+}
+
+// BucketOptions holds the values used to construct a Bucket.
+type BucketOptions struct {
+	ObjectOptions
+
+	BucketName string
+	// Provider is left to the API default (generic) when empty.
+	Provider string
+	Endpoint string
+	Region   string
+	Insecure bool
+
+	SecretRef   string
+	IgnorePaths []string
+	Timeout     time.Duration
+}
+
+// Bucket returns a Bucket constructed from the given options.
+func Bucket(opts BucketOptions) (*sourcev1.Bucket, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	if opts.BucketName == "" {
+		return nil, fmt.Errorf("bucket name is required")
+	}
+	if opts.Endpoint == "" {
+		return nil, fmt.Errorf("endpoint is required")
+	}
+
+	return &sourcev1.Bucket{
+		ObjectMeta: opts.objectMeta(),
+		Spec: sourcev1.BucketSpec{
+			BucketName: opts.BucketName,
+			Provider:   opts.Provider,
+			Insecure:   opts.Insecure,
+			Endpoint:   opts.Endpoint,
+			Region:     opts.Region,
+			Interval:   opts.interval(),
+			Suspend:    opts.Suspend,
+			Ignore:     ignorePaths(opts.IgnorePaths),
+			Timeout:    optionalDuration(opts.Timeout),
+			SecretRef:  localObjectReference(opts.SecretRef),
+		},
+	}, nil
+}
+
+// OCIRepositoryOptions holds the values used to construct an OCIRepository.
+type OCIRepositoryOptions struct {
+	ObjectOptions
+
+	URL string
+	// At least one of Tag, SemVer or Digest is required.
+	Tag    string
+	SemVer string
+	Digest string
+
+	// Provider is left to the API default (generic) when empty.
+	Provider string
+	Insecure bool
+
+	SecretRef          string
+	CertSecretRef      string
+	ServiceAccountName string
+	IgnorePaths        []string
+	Timeout            time.Duration
+}
+
+// OCIRepository returns an OCIRepository constructed from the given options.
+func OCIRepository(opts OCIRepositoryOptions) (*sourcev1.OCIRepository, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	if opts.URL == "" {
+		return nil, fmt.Errorf("url is required")
+	}
+	if opts.SemVer == "" && opts.Tag == "" && opts.Digest == "" {
+		return nil, fmt.Errorf("one of tag, semver or digest is required")
+	}
+
+	return &sourcev1.OCIRepository{
+		ObjectMeta: opts.objectMeta(),
+		Spec: sourcev1.OCIRepositorySpec{
+			Provider: opts.Provider,
+			URL:      opts.URL,
+			Insecure: opts.Insecure,
+			Interval: opts.interval(),
+			Suspend:  opts.Suspend,
+			Reference: &sourcev1.OCIRepositoryRef{
+				Digest: opts.Digest,
+				SemVer: opts.SemVer,
+				Tag:    opts.Tag,
+			},
+			Ignore:             ignorePaths(opts.IgnorePaths),
+			Timeout:            optionalDuration(opts.Timeout),
+			ServiceAccountName: opts.ServiceAccountName,
+			SecretRef:          localObjectReference(opts.SecretRef),
+			CertSecretRef:      localObjectReference(opts.CertSecretRef),
+		},
+	}, nil
+}
@@ -27,20 +27,28 @@ import (
 	apierr "k8s.io/apimachinery/pkg/api/errors"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"sigs.k8s.io/cli-utils/pkg/object"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
 	"github.com/fluxcd/pkg/apis/meta"
+	runclient "github.com/fluxcd/pkg/runtime/client"
 	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
 
+	"github.com/fluxcd/flux2/internal/utils"
+	"github.com/fluxcd/flux2/pkg/log"
 	"github.com/fluxcd/flux2/pkg/manifestgen/install"
 	"github.com/fluxcd/flux2/pkg/manifestgen/sourcesecret"
 	"github.com/fluxcd/flux2/pkg/manifestgen/sync"
+	"github.com/fluxcd/flux2/pkg/status"
 )
 
 var (
 	ErrReconciledWithWarning = errors.New("reconciled with warning")
+	ErrUnmigratedManifests   = errors.New("manifests outside of the Flux directory are not migrated")
 )
 
 type Reconciler interface {
@@ -75,46 +83,133 @@ type RepositoryReconciler interface {
 	ReconcileRepository(ctx context.Context) error
 }
 
+type SourceSecretVerifier interface {
+	// VerifySourceSecret verifies that a source secret which is managed
+	// outside of the bootstrap process exists on the cluster, and that
+	// the credentials it contains can be used to clone the repository.
+	VerifySourceSecret(ctx context.Context, secretOpts sourcesecret.Options, syncOpts sync.Options) error
+}
+
+type ProgressReporter interface {
+	// ReportProgress reports the current phase and the completion
+	// percentage of the bootstrap.
+	ReportProgress(phase string, percent int)
+}
+
+type SummaryReporter interface {
+	// ReportSummary reports the objects applied on the cluster and the
+	// time spent in each phase of a successful bootstrap.
+	ReportSummary(phases Phases)
+}
+
+// Phase records the time spent in a phase of the bootstrap.
+type Phase struct {
+	Name     string
+	Duration time.Duration
+}
+
+// Phases lists the phases of the bootstrap in the order they ran.
+type Phases []Phase
+
+// Total returns the time spent in all the phases.
+func (p Phases) Total() time.Duration {
+	var total time.Duration
+	for _, phase := range p {
+		total += phase.Duration
+	}
+	return total
+}
+
+func (p Phases) String() string {
+	s := make([]string, 0, len(p))
+	for _, phase := range p {
+		s = append(s, fmt.Sprintf("%s %s", phase.Name, phase.Duration.Round(time.Millisecond)))
+	}
+	return strings.Join(s, ", ")
+}
+
 type PostGenerateSecretFunc func(ctx context.Context, secret corev1.Secret, options sourcesecret.Options) error
 
 func Run(ctx context.Context, reconciler Reconciler, manifestsBase string,
 	installOpts install.Options, secretOpts sourcesecret.Options, syncOpts sync.Options,
 	pollInterval, timeout time.Duration) error {
 
+	reportProgress := func(phase string, percent int) {
+		if p, ok := reconciler.(ProgressReporter); ok {
+			p.ReportProgress(phase, percent)
+		}
+	}
+
+	var phases Phases
+	phaseStart := time.Now()
+	endPhase := func(name string) {
+		now := time.Now()
+		phases = append(phases, Phase{Name: name, Duration: now.Sub(phaseStart)})
+		phaseStart = now
+	}
+
 	var err error
+	reportProgress("repository", 0)
 	if r, ok := reconciler.(RepositoryReconciler); ok {
 		if err = r.ReconcileRepository(ctx); err != nil && !errors.Is(err, ErrReconciledWithWarning) {
 			return err
 		}
 	}
 
+	if v, ok := reconciler.(SourceSecretVerifier); ok {
+		if err := v.VerifySourceSecret(ctx, secretOpts, syncOpts); err != nil {
+			return err
+		}
+	}
+	endPhase("repository")
+
+	reportProgress("install", 10)
 	if err := reconciler.ReconcileComponents(ctx, manifestsBase, installOpts, secretOpts); err != nil {
 		return err
 	}
+	endPhase("components")
+	reportProgress("secret", 50)
 	if err := reconciler.ReconcileSourceSecret(ctx, secretOpts); err != nil {
 		return err
 	}
+	endPhase("source secret")
+	reportProgress("sync", 60)
 	if err := reconciler.ReconcileSyncConfig(ctx, syncOpts); err != nil {
 		return err
 	}
+	endPhase("sync")
 
+	reportProgress("wait", 70)
 	var healthErrCount int
 	if err := reconciler.ReportKustomizationHealth(ctx, syncOpts, pollInterval, timeout); err != nil {
 		healthErrCount++
 	}
+	reportProgress("wait", 90)
 	if err := reconciler.ReportComponentsHealth(ctx, installOpts, timeout); err != nil {
 		healthErrCount++
 	}
+	endPhase("health checks")
+	reportProgress("wait", 100)
 	if healthErrCount > 0 {
 		// Composing a "smart" error message here from the returned
 		// errors does not result in any useful information for the
 		// user, as both methods log the failures they run into.
-		err = fmt.Errorf("bootstrap failed with %d health check failure(s)", healthErrCount)
+		return fmt.Errorf("bootstrap failed with %d health check failure(s)", healthErrCount)
 	}
 
+	if r, ok := reconciler.(SummaryReporter); ok {
+		r.ReportSummary(phases)
+	}
 	return err
 }
 
+// reportSummary logs the counts of the applied objects and the time spent in
+// each phase.
+func reportSummary(logger log.Logger, applied utils.ApplyStats, phases Phases) {
+	logger.Successf("applied objects: %s", applied)
+	logger.Successf("completed in %s (%s)", phases.Total().Round(time.Millisecond), phases)
+}
+
 func mustInstallManifests(ctx context.Context, kube client.Client, namespace string) bool {
 	namespacedName := types.NamespacedName{
 		Namespace: namespace,
@@ -208,6 +303,40 @@ func kustomizationReconciled(ctx context.Context, kube client.Client, objKey cli
 	}
 }
 
+// reportComponentsHealth waits for the Deployments of the components and
+// extra components in install.Options to become ready.
+func reportComponentsHealth(rcg genericclioptions.RESTClientGetter, opts *runclient.Options, logger log.Logger,
+	install install.Options, timeout time.Duration) error {
+	cfg, err := utils.KubeConfig(rcg, opts)
+	if err != nil {
+		return err
+	}
+
+	checker, err := status.NewStatusChecker(cfg, 5*time.Second, timeout, logger)
+	if err != nil {
+		return err
+	}
+
+	var components = install.Components
+	components = append(components, install.ComponentsExtra...)
+
+	var identifiers []object.ObjMetadata
+	for _, component := range components {
+		identifiers = append(identifiers, object.ObjMetadata{
+			Namespace: install.Namespace,
+			Name:      component,
+			GroupKind: schema.GroupKind{Group: "apps", Kind: "Deployment"},
+		})
+	}
+
+	logger.Actionf("confirming components are healthy")
+	if err := checker.Assess(identifiers...); err != nil {
+		return err
+	}
+	logger.Successf("all components are healthy")
+	return nil
+}
+
 func retry(retries int, wait time.Duration, fn func() error) (err error) {
 	for i := 0; ; i++ {
 		err = fn()
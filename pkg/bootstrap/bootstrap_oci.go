@@ -0,0 +1,330 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/kustomize/api/konfig"
+	"sigs.k8s.io/yaml"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	"github.com/fluxcd/pkg/kustomize/filesys"
+	oci "github.com/fluxcd/pkg/oci/client"
+	runclient "github.com/fluxcd/pkg/runtime/client"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+
+	"github.com/fluxcd/flux2/internal/utils"
+	"github.com/fluxcd/flux2/pkg/log"
+	"github.com/fluxcd/flux2/pkg/manifestgen/install"
+	"github.com/fluxcd/flux2/pkg/manifestgen/kustomization"
+	"github.com/fluxcd/flux2/pkg/manifestgen/sourcesecret"
+	"github.com/fluxcd/flux2/pkg/manifestgen/sync"
+)
+
+// OCIBootstrapper bootstraps Flux from an OCI artifact instead of a Git
+// repository. The component and sync manifests are added to the content of
+// the artifact, which is pushed to the registry and synced by the cluster
+// with an OCIRepository.
+type OCIBootstrapper struct {
+	url string
+	tag string
+
+	// workDir holds the content of the artifact.
+	workDir string
+	pulled  bool
+	digest  string
+
+	restClientGetter  genericclioptions.RESTClientGetter
+	restClientOptions *runclient.Options
+
+	// applied counts the objects applied on the cluster.
+	applied utils.ApplyStats
+
+	ociClient *oci.Client
+	kube      client.Client
+	logger    log.Logger
+}
+
+type OCIOption interface {
+	applyOCI(b *OCIBootstrapper)
+}
+
+// WithArtifact sets the oci:// URL of the repository and the tag of the
+// artifact the manifests are pushed to.
+func WithArtifact(url, tag string) OCIOption {
+	return artifactOption{url: url, tag: tag}
+}
+
+type artifactOption struct {
+	url string
+	tag string
+}
+
+func (o artifactOption) applyOCI(b *OCIBootstrapper) {
+	b.url = o.url
+	b.tag = o.tag
+}
+
+// NewOCIBootstrapper returns a bootstrapper which assembles the content of
+// the artifact in workDir, and pushes it with the given OCI client.
+func NewOCIBootstrapper(ociClient *oci.Client, kube client.Client, workDir string, opts ...OCIOption) (*OCIBootstrapper, error) {
+	b := &OCIBootstrapper{
+		ociClient: ociClient,
+		kube:      kube,
+		workDir:   workDir,
+	}
+	for _, opt := range opts {
+		opt.applyOCI(b)
+	}
+	if _, err := oci.ParseArtifactURL(b.URL()); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// URL returns the oci:// URL of the artifact the bootstrapper pushes to.
+func (b *OCIBootstrapper) URL() string {
+	return fmt.Sprintf("%s:%s", b.url, b.tag)
+}
+
+// artifactAddress returns the address of the artifact without the oci://
+// scheme, as expected by the OCI client.
+func (b *OCIBootstrapper) artifactAddress() string {
+	return strings.TrimPrefix(b.URL(), sourcev1.OCIRepositoryPrefix)
+}
+
+// pullArtifact extracts the content of the existing artifact to the working
+// directory, to preserve the manifests added to it outside of bootstrap.
+func (b *OCIBootstrapper) pullArtifact(ctx context.Context) error {
+	if b.pulled {
+		return nil
+	}
+
+	b.logger.Actionf("pulling artifact %q", b.URL())
+	if _, err := b.ociClient.Pull(ctx, b.artifactAddress(), b.workDir); err != nil {
+		var terr *transport.Error
+		if !errors.As(err, &terr) || terr.StatusCode != http.StatusNotFound {
+			return fmt.Errorf("failed to pull artifact: %w", err)
+		}
+		b.logger.Successf("artifact not found, a new one will be pushed")
+	} else {
+		b.logger.Successf("pulled artifact")
+	}
+	b.pulled = true
+	return nil
+}
+
+func (b *OCIBootstrapper) ReconcileComponents(ctx context.Context, manifestsBase string, options install.Options, _ sourcesecret.Options) error {
+	if err := b.pullArtifact(ctx); err != nil {
+		return err
+	}
+
+	// Generate component manifests
+	b.logger.Actionf("generating component manifests")
+	manifests, err := install.Generate(options, manifestsBase)
+	if err != nil {
+		return fmt.Errorf("component manifest generation failed: %w", err)
+	}
+	componentsYAML := filepath.Join(b.workDir, manifests.Path)
+	if err := writeFile(componentsYAML, manifests.Content); err != nil {
+		return err
+	}
+	b.logger.Successf("generated component manifests")
+
+	// Conditionally install manifests
+	if mustInstallManifests(ctx, b.kube, options.Namespace) {
+		b.logger.Actionf("installing components in %q namespace", options.Namespace)
+
+		kfile := filepath.Join(filepath.Dir(componentsYAML), konfig.DefaultKustomizationFileName())
+		if _, err := os.Stat(kfile); err == nil {
+			// Apply the components and their patches
+			if err := b.apply(ctx, b.workDir, kfile); err != nil {
+				return err
+			}
+		} else {
+			// Apply the CRDs and controllers
+			if err := b.apply(ctx, b.workDir, componentsYAML); err != nil {
+				return err
+			}
+		}
+		b.logger.Successf("installed components")
+	}
+
+	b.logger.Successf("reconciled components")
+	return nil
+}
+
+// ReconcileSourceSecret reconciles the image pull secret of the
+// OCIRepository. It is a no-op when no registry credentials are given, in
+// which case the registry is accessed anonymously or with the provider
+// configured in the sync options.
+func (b *OCIBootstrapper) ReconcileSourceSecret(ctx context.Context, options sourcesecret.Options) error {
+	secretKey := client.ObjectKey{Name: options.Name, Namespace: options.Namespace}
+	if options.Registry == "" || options.Username == "" {
+		b.logger.Successf("skipping source secret, no registry credentials given")
+		return nil
+	}
+
+	b.logger.Actionf("generating source secret")
+	manifest, err := sourcesecret.Generate(options)
+	if err != nil {
+		return err
+	}
+	var secret corev1.Secret
+	if err := yaml.Unmarshal([]byte(manifest.Content), &secret); err != nil {
+		return fmt.Errorf("failed to unmarshal generated source secret manifest: %w", err)
+	}
+
+	b.logger.Actionf("applying source secret %q", secretKey)
+	if err = reconcileSecret(ctx, b.kube, secret); err != nil {
+		return err
+	}
+	b.logger.Successf("reconciled source secret")
+	return nil
+}
+
+func (b *OCIBootstrapper) ReconcileSyncConfig(ctx context.Context, options sync.Options) error {
+	// Confirm that sync configuration does not overwrite existing config
+	if curPath, err := kustomizationPathDiffers(ctx, b.kube, client.ObjectKey{Name: options.Name, Namespace: options.Namespace}, options.TargetPath); err != nil {
+		return fmt.Errorf("failed to determine if sync configuration would overwrite existing Kustomization: %w", err)
+	} else if curPath != "" {
+		return fmt.Errorf("sync path configuration (%q) would overwrite path (%q) of existing Kustomization", options.TargetPath, curPath)
+	}
+
+	if err := b.pullArtifact(ctx); err != nil {
+		return err
+	}
+
+	// Generate sync manifests and write to the artifact content
+	b.logger.Actionf("generating sync manifests")
+	options.SourceKind = sourcev1.OCIRepositoryKind
+	manifests, err := sync.Generate(options)
+	if err != nil {
+		return fmt.Errorf("sync manifests generation failed: %w", err)
+	}
+
+	fs, err := filesys.MakeFsOnDiskSecureBuild(b.workDir)
+	if err != nil {
+		return fmt.Errorf("failed to initialize Kustomize file system: %w", err)
+	}
+	if err = fs.WriteFile(filepath.Join(b.workDir, manifests.Path), []byte(manifests.Content)); err != nil {
+		return err
+	}
+
+	kusManifests, err := kustomization.Generate(kustomization.Options{
+		FileSystem: fs,
+		BaseDir:    b.workDir,
+		TargetPath: filepath.Dir(manifests.Path),
+	})
+	if err != nil {
+		return fmt.Errorf("%s generation failed: %w", konfig.DefaultKustomizationFileName(), err)
+	}
+	if err := writeFile(filepath.Join(b.workDir, kusManifests.Path), kusManifests.Content); err != nil {
+		return err
+	}
+	b.logger.Successf("generated sync manifests")
+
+	// Push the artifact
+	b.logger.Actionf("pushing manifests to %q", b.URL())
+	digestURL, err := b.ociClient.Push(ctx, b.artifactAddress(), b.workDir, oci.Metadata{
+		Source:   b.url,
+		Revision: b.tag,
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to push manifests: %w", err)
+	}
+	if i := strings.LastIndex(digestURL, "@"); i >= 0 {
+		b.digest = digestURL[i+1:]
+	}
+	b.logger.Successf("pushed manifests to %q (%q)", b.URL(), b.digest)
+
+	// Apply to cluster
+	b.logger.Actionf("applying sync manifests")
+	if err := b.apply(ctx, b.workDir, filepath.Join(b.workDir, kusManifests.Path)); err != nil {
+		return err
+	}
+
+	b.logger.Successf("reconciled sync configuration")
+	return nil
+}
+
+func (b *OCIBootstrapper) ReportKustomizationHealth(ctx context.Context, options sync.Options, pollInterval, timeout time.Duration) error {
+	objKey := client.ObjectKey{Name: options.Name, Namespace: options.Namespace}
+
+	b.logger.Waitingf("waiting for Kustomization %q to be reconciled", objKey.String())
+
+	expectRevision := fmt.Sprintf("%s@%s", b.tag, b.digest)
+	var k kustomizev1.Kustomization
+	if err := wait.PollImmediate(pollInterval, timeout, kustomizationReconciled(
+		ctx, b.kube, objKey, &k, expectRevision),
+	); err != nil {
+		b.logger.Failuref(err.Error())
+		return err
+	}
+
+	b.logger.Successf("Kustomization reconciled successfully")
+	return nil
+}
+
+func (b *OCIBootstrapper) ReportComponentsHealth(ctx context.Context, install install.Options, timeout time.Duration) error {
+	return reportComponentsHealth(b.restClientGetter, b.restClientOptions, b.logger, install, timeout)
+}
+
+// apply applies the manifest to the cluster and counts the applied objects.
+func (b *OCIBootstrapper) apply(ctx context.Context, root, manifestPath string) error {
+	changeSet, err := utils.ApplyChangeSet(ctx, b.restClientGetter, b.restClientOptions, root, manifestPath)
+	if err != nil {
+		return err
+	}
+	b.applied.Add(changeSet)
+	return nil
+}
+
+// ReportSummary logs the objects applied on the cluster and the time spent
+// in each phase.
+func (b *OCIBootstrapper) ReportSummary(phases Phases) {
+	reportSummary(b.logger, b.applied, phases)
+}
+
+// ReportProgress forwards the phase and the completion percentage to the
+// logger, if it supports progress reporting.
+func (b *OCIBootstrapper) ReportProgress(phase string, percent int) {
+	if l, ok := b.logger.(log.ProgressLogger); ok {
+		l.Progress(phase, percent)
+	}
+}
+
+// writeFile writes the content to the path, creating the parent directories.
+func writeFile(path, content string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(content), 0o644)
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootstrap
+
+import (
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/registry"
+	. "github.com/onsi/gomega"
+
+	oci "github.com/fluxcd/pkg/oci/client"
+
+	"github.com/fluxcd/flux2/pkg/log"
+)
+
+func TestOCIBootstrapper_pullArtifact(t *testing.T) {
+	g := NewWithT(t)
+
+	srv := httptest.NewServer(registry.New())
+	defer srv.Close()
+	u, err := url.Parse(srv.URL)
+	g.Expect(err).ToNot(HaveOccurred())
+	repoURL := "oci://" + u.Host + "/fleet/cluster"
+
+	ociClient := oci.NewLocalClient()
+	newBootstrapper := func() *OCIBootstrapper {
+		b, err := NewOCIBootstrapper(ociClient, nil, t.TempDir(), WithArtifact(repoURL, "latest"), WithLogger(log.NopLogger{}))
+		g.Expect(err).ToNot(HaveOccurred())
+		return b
+	}
+
+	// A missing artifact starts from empty content
+	b := newBootstrapper()
+	g.Expect(b.pullArtifact(context.TODO())).To(Succeed())
+	entries, err := os.ReadDir(b.workDir)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(entries).To(BeEmpty())
+
+	// The content of an existing artifact is preserved
+	appPath := filepath.Join(b.workDir, "apps", "podinfo.yaml")
+	g.Expect(writeFile(appPath, "kind: Namespace\n")).To(Succeed())
+	_, err = ociClient.Push(context.TODO(), b.artifactAddress(), b.workDir, oci.Metadata{Source: repoURL, Revision: "latest"}, nil)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	b = newBootstrapper()
+	g.Expect(b.pullArtifact(context.TODO())).To(Succeed())
+	data, err := os.ReadFile(filepath.Join(b.workDir, "apps", "podinfo.yaml"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(data)).To(Equal("kind: Namespace\n"))
+}
+
+func TestNewOCIBootstrapper_invalidURL(t *testing.T) {
+	g := NewWithT(t)
+
+	_, err := NewOCIBootstrapper(oci.NewLocalClient(), nil, t.TempDir(), WithArtifact("ghcr.io/org/fleet", "latest"))
+	g.Expect(err).To(HaveOccurred())
+}
@@ -18,20 +18,24 @@ package bootstrap
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/ProtonMail/go-crypto/openpgp"
-	gogit "github.com/fluxcd/go-git/v5"
+	extgogit "github.com/fluxcd/go-git/v5"
+	"golang.org/x/crypto/ssh"
 	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/runtime/schema"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
-	"sigs.k8s.io/cli-utils/pkg/object"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/kustomize/api/konfig"
 	"sigs.k8s.io/yaml"
@@ -39,37 +43,55 @@ import (
 	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
 	"github.com/fluxcd/pkg/kustomize/filesys"
 	runclient "github.com/fluxcd/pkg/runtime/client"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
 
 	"github.com/fluxcd/flux2/internal/utils"
 	"github.com/fluxcd/flux2/pkg/log"
+	"github.com/fluxcd/flux2/pkg/manifestgen"
 	"github.com/fluxcd/flux2/pkg/manifestgen/install"
 	"github.com/fluxcd/flux2/pkg/manifestgen/kustomization"
 	"github.com/fluxcd/flux2/pkg/manifestgen/sourcesecret"
 	"github.com/fluxcd/flux2/pkg/manifestgen/sync"
-	"github.com/fluxcd/flux2/pkg/status"
 	"github.com/fluxcd/pkg/git"
+	"github.com/fluxcd/pkg/git/gogit"
 	"github.com/fluxcd/pkg/git/repository"
 )
 
 type PlainGitBootstrapper struct {
-	url    string
-	branch string
+	url           string
+	branch        string
+	defaultBranch string
 
 	signature             git.Signature
 	commitMessageAppendix string
+	signoff               bool
+	shallowClone          bool
 
 	gpgKeyRing    openpgp.EntityList
 	gpgPassphrase string
 	gpgKeyID      string
 
+	sshSigner ssh.Signer
+
 	restClientGetter  genericclioptions.RESTClientGetter
 	restClientOptions *runclient.Options
 
 	postGenerateSecret []PostGenerateSecretFunc
+	existingSecret     bool
+	secretless         bool
+	sopsAge            bool
+
+	// gitHubAppTokenFallback is set when the GitRepository API does not
+	// support the GitHub provider and the source secret holds the token.
+	gitHubAppTokenFallback bool
+
+	commits []string
+	applied utils.ApplyStats
 
-	gitClient repository.Client
-	kube      client.Client
-	logger    log.Logger
+	gitClient    repository.Client
+	newGitClient GitClientFactory
+	kube         client.Client
+	logger       log.Logger
 }
 
 type GitOption interface {
@@ -107,6 +129,184 @@ func NewPlainGitProvider(git repository.Client, kube client.Client, opts ...GitO
 	return b, nil
 }
 
+// RepositoryURL returns the URL of the Git repository the bootstrapper
+// commits to.
+func (b *PlainGitBootstrapper) RepositoryURL() string {
+	return b.url
+}
+
+// Commits returns the revisions of the commits pushed by the
+// bootstrapper, in the order they were pushed.
+func (b *PlainGitBootstrapper) Commits() []string {
+	return b.commits
+}
+
+// cloneBranch clones the configured branch of the Git repository. When the
+// branch does not exist and the default branch of the repository is known,
+// the default branch is cloned instead and the branch is created from its
+// HEAD, to be pushed along with the first commit.
+func (b *PlainGitBootstrapper) cloneBranch(ctx context.Context) error {
+	err := b.clone(ctx, b.branch)
+	var notFound git.ErrRepositoryNotFound
+	if err == nil || !errors.As(err, &notFound) || b.defaultBranch == "" || b.defaultBranch == b.branch {
+		return err
+	}
+
+	b.logger.Actionf("branch %q not found, creating it from %q", b.branch, b.defaultBranch)
+	if err := b.resetWorkDir(); err != nil {
+		return err
+	}
+	if err := b.clone(ctx, b.defaultBranch); err != nil {
+		return err
+	}
+	if err := b.gitClient.SwitchBranch(ctx, b.branch); err != nil {
+		return fmt.Errorf("failed to create branch %q: %w", b.branch, err)
+	}
+	return nil
+}
+
+// clone clones the branch, with a depth of one if shallow clones are
+// enabled. When the shallow clone fails, e.g. because the server does not
+// support it, the branch is cloned again with its full history.
+func (b *PlainGitBootstrapper) clone(ctx context.Context, branch string) error {
+	opts := repository.CloneOptions{
+		CheckoutStrategy: repository.CheckoutStrategy{
+			Branch: branch,
+		},
+		ShallowClone: b.shallowClone,
+	}
+	_, err := b.gitClient.Clone(ctx, b.url, opts)
+	var notFound git.ErrRepositoryNotFound
+	if err == nil || !b.shallowClone || errors.As(err, &notFound) {
+		return err
+	}
+
+	b.logger.Warningf("shallow clone failed, falling back to a full clone: %s", err)
+	if err := b.resetWorkDir(); err != nil {
+		return err
+	}
+	opts.ShallowClone = false
+	_, err = b.gitClient.Clone(ctx, b.url, opts)
+	return err
+}
+
+// resetWorkDir empties the directory of the clone, and replaces the Git
+// client with a new one if a GitClientFactory was configured as the storage
+// of the current client may still reference the removed clone.
+func (b *PlainGitBootstrapper) resetWorkDir() error {
+	path := b.gitClient.Path()
+	if err := os.RemoveAll(path); err != nil {
+		return fmt.Errorf("failed to remove tmp dir: %w", err)
+	}
+	if err := os.Mkdir(path, 0o700); err != nil {
+		return fmt.Errorf("failed to recreate tmp dir: %w", err)
+	}
+	if b.newGitClient != nil {
+		c, err := b.newGitClient(path)
+		if err != nil {
+			return fmt.Errorf("failed to create a Git client: %w", err)
+		}
+		b.gitClient = c
+	}
+	return nil
+}
+
+// commitMessage returns the commit message for the given subject, with the
+// configured appendix and the Signed-off-by trailer of the author.
+func (b *PlainGitBootstrapper) commitMessage(subject string) string {
+	msg := subject
+	if b.commitMessageAppendix != "" {
+		msg = msg + "\n\n" + b.commitMessageAppendix
+	}
+	if b.signoff {
+		msg = fmt.Sprintf("%s\n\nSigned-off-by: %s <%s>", msg, b.signature.Name, b.signature.Email)
+	}
+	return msg
+}
+
+// pushRetries is the number of times a push rejected as non-fast-forward is
+// retried, after rebasing the generated commit on the updated branch.
+const pushRetries = 4
+
+// pushRetryWait is the wait before the first push retry, it doubles with
+// every retry.
+const pushRetryWait = time.Second
+
+// commitAndPush commits the files returned by write, along with the changes
+// it made to the worktree, and pushes the commit. When the push is rejected
+// because another bootstrap run or a controller pushed to the branch in the
+// meantime, the branch is cloned again and write is called to regenerate the
+// changes on top of it, which rebases the generated commit, before the push
+// is retried with an exponential backoff.
+func (b *PlainGitBootstrapper) commitAndPush(ctx context.Context, what, msg string, signer *openpgp.Entity, write func() (map[string]io.Reader, error)) error {
+	wait := pushRetryWait
+	for i := 0; ; i++ {
+		files, err := write()
+		if err != nil {
+			return err
+		}
+
+		commit, err := b.gitClient.Commit(git.Commit{
+			Author:  b.signature,
+			Message: msg,
+		}, repository.WithFiles(files), repository.WithSigner(signer))
+		if err == git.ErrNoStagedFiles {
+			b.logger.Successf("%s are up to date", what)
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to commit %s: %w", what, err)
+		}
+		if b.sshSigner != nil {
+			if commit, err = sshSignHead(b.gitClient.Path(), b.sshSigner); err != nil {
+				return fmt.Errorf("failed to sign %s commit: %w", what, err)
+			}
+		}
+
+		b.logger.Successf("committed %s to %q (%q)", what, b.branch, commit)
+		b.logger.Actionf("pushing %s to %q", what, b.url)
+		err = b.gitClient.Push(ctx)
+		if err == nil {
+			b.commits = append(b.commits, commit)
+			return nil
+		}
+		if !isNonFastForward(err) || i >= pushRetries {
+			return fmt.Errorf("failed to push %s: %w", what, err)
+		}
+
+		b.logger.Waitingf("git conflict detected, rebasing on %q and retrying in %s", b.branch, wait)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("failed to push %s: %w", what, ctx.Err())
+		case <-time.After(wait):
+		}
+		wait *= 2
+		if err := b.recloneBranch(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+// recloneBranch replaces the clone of the branch with a fresh one.
+func (b *PlainGitBootstrapper) recloneBranch(ctx context.Context) error {
+	if err := retry(1, 2*time.Second, func() error {
+		if err := b.resetWorkDir(); err != nil {
+			return err
+		}
+		return b.cloneBranch(ctx)
+	}); err != nil {
+		return fmt.Errorf("failed to clone repository: %w", err)
+	}
+	return nil
+}
+
+// isNonFastForward returns true if the push was rejected because the
+// remote branch has commits missing from the local one.
+func isNonFastForward(err error) bool {
+	return errors.Is(err, extgogit.ErrNonFastForwardUpdate) ||
+		strings.Contains(err.Error(), extgogit.ErrNonFastForwardUpdate.Error())
+}
+
 func (b *PlainGitBootstrapper) ReconcileComponents(ctx context.Context, manifestsBase string, options install.Options, _ sourcesecret.Options) error {
 	// Clone if not already
 	if _, err := b.gitClient.Head(); err != nil {
@@ -117,11 +317,7 @@ func (b *PlainGitBootstrapper) ReconcileComponents(ctx context.Context, manifest
 		b.logger.Actionf("cloning branch %q from Git repository %q", b.branch, b.url)
 		var cloned bool
 		if err = retry(1, 2*time.Second, func() (err error) {
-			_, err = b.gitClient.Clone(ctx, b.url, repository.CloneOptions{
-				CheckoutStrategy: repository.CheckoutStrategy{
-					Branch: b.branch,
-				},
-			})
+			err = b.cloneBranch(ctx)
 			if err != nil {
 				b.logger.Warningf(" clone failure: %s", err)
 			}
@@ -153,29 +349,14 @@ func (b *PlainGitBootstrapper) ReconcileComponents(ctx context.Context, manifest
 			return fmt.Errorf("failed to generate OpenPGP entity: %w", err)
 		}
 	}
-	commitMsg := fmt.Sprintf("Add Flux %s component manifests", options.Version)
-	if b.commitMessageAppendix != "" {
-		commitMsg = commitMsg + "\n\n" + b.commitMessageAppendix
-	}
-
-	commit, err := b.gitClient.Commit(git.Commit{
-		Author:  b.signature,
-		Message: commitMsg,
-	}, repository.WithFiles(map[string]io.Reader{
-		manifests.Path: strings.NewReader(manifests.Content),
-	}), repository.WithSigner(signer))
-	if err != nil && err != git.ErrNoStagedFiles {
-		return fmt.Errorf("failed to commit sync manifests: %w", err)
-	}
+	commitMsg := b.commitMessage(fmt.Sprintf("Add Flux %s component manifests", options.Version))
 
-	if err == nil {
-		b.logger.Successf("committed sync manifests to %q (%q)", b.branch, commit)
-		b.logger.Actionf("pushing component manifests to %q", b.url)
-		if err = b.gitClient.Push(ctx); err != nil {
-			return fmt.Errorf("failed to push manifests: %w", err)
-		}
-	} else {
-		b.logger.Successf("component manifests are up to date")
+	if err := b.commitAndPush(ctx, "component manifests", commitMsg, signer, func() (map[string]io.Reader, error) {
+		return map[string]io.Reader{
+			manifests.Path: strings.NewReader(manifests.Content),
+		}, nil
+	}); err != nil {
+		return err
 	}
 
 	// Conditionally install manifests
@@ -186,12 +367,12 @@ func (b *PlainGitBootstrapper) ReconcileComponents(ctx context.Context, manifest
 		kfile := filepath.Join(filepath.Dir(componentsYAML), konfig.DefaultKustomizationFileName())
 		if _, err := os.Stat(kfile); err == nil {
 			// Apply the components and their patches
-			if _, err := utils.Apply(ctx, b.restClientGetter, b.restClientOptions, b.gitClient.Path(), kfile); err != nil {
+			if err := b.apply(ctx, b.gitClient.Path(), kfile); err != nil {
 				return err
 			}
 		} else {
 			// Apply the CRDs and controllers
-			if _, err := utils.Apply(ctx, b.restClientGetter, b.restClientOptions, b.gitClient.Path(), componentsYAML); err != nil {
+			if err := b.apply(ctx, b.gitClient.Path(), componentsYAML); err != nil {
 				return err
 			}
 		}
@@ -203,8 +384,50 @@ func (b *PlainGitBootstrapper) ReconcileComponents(ctx context.Context, manifest
 }
 
 func (b *PlainGitBootstrapper) ReconcileSourceSecret(ctx context.Context, options sourcesecret.Options) error {
-	// Determine if there is an existing secret
 	secretKey := client.ObjectKey{Name: options.Name, Namespace: options.Namespace}
+
+	if b.secretless {
+		b.logger.Actionf("verifying source-controller supports workload identity for Git")
+		ok, err := gitRepositorySupportsProvider(ctx, b.kube, "")
+		if err != nil {
+			return fmt.Errorf("failed to verify the GitRepository API: %w", err)
+		}
+		if !ok {
+			return fmt.Errorf("the installed GitRepository API %s/%s does not support the provider field, "+
+				"upgrade Flux or bootstrap with a source secret", sourcev1.GroupVersion.Group, sync.GitRepositoryProviderVersion)
+		}
+		b.logger.Successf("skipping source secret, Git authentication uses workload identity")
+		return nil
+	}
+
+	if options.GitHubAppID != "" {
+		b.logger.Actionf("verifying source-controller supports GitHub App authentication")
+		ok, err := gitRepositorySupportsProvider(ctx, b.kube, sync.GitHubProvider)
+		if err != nil {
+			return fmt.Errorf("failed to verify the GitRepository API: %w", err)
+		}
+		if ok {
+			options.Username, options.Password = "", ""
+		} else {
+			if options.Password == "" {
+				return fmt.Errorf("the installed GitRepository API %s/%s does not support the %s provider, upgrade Flux",
+					sourcev1.GroupVersion.Group, sync.GitRepositoryProviderVersion, sync.GitHubProvider)
+			}
+			b.logger.Warningf("the installed GitRepository API does not support the %s provider, "+
+				"the source secret holds the installation token of the GitHub App which has to be renewed before it expires", sync.GitHubProvider)
+			options.GitHubAppID, options.GitHubAppInstallationID, options.GitHubAppBaseURL = "", "", ""
+			options.GitHubAppPrivateKey = nil
+			b.gitHubAppTokenFallback = true
+		}
+	}
+
+	// Leave externally managed secrets untouched
+	if b.existingSecret {
+		b.logger.Successf("using existing source secret %q", secretKey)
+		return nil
+	}
+
+	// Determine if there is an existing secret
 	b.logger.Actionf("determining if source secret %q exists", secretKey)
 	ok, err := secretExists(ctx, b.kube, secretKey)
 	if err != nil {
@@ -212,7 +435,7 @@ func (b *PlainGitBootstrapper) ReconcileSourceSecret(ctx context.Context, option
 	}
 
 	// Return early if exists and no custom config is passed
-	if ok && options.Keypair == nil && len(options.CAFile) == 0 && len(options.Username+options.Password) == 0 {
+	if ok && options.Keypair == nil && len(options.CAFile) == 0 && len(options.Username+options.Password) == 0 && options.GitHubAppID == "" && options.Proxy == "" {
 		b.logger.Successf("source secret up to date")
 		return nil
 	}
@@ -244,6 +467,128 @@ func (b *PlainGitBootstrapper) ReconcileSourceSecret(ctx context.Context, option
 	return nil
 }
 
+// gitRepositorySupportsProvider returns true if the GitRepository CRD
+// installed on the cluster serves the API version the sync manifests use
+// when a provider is set, and defines spec.provider in it. When a provider
+// is given, it must also be allowed by the schema of the field.
+func gitRepositorySupportsProvider(ctx context.Context, kube client.Client, provider string) (bool, error) {
+	var crd apiextensionsv1.CustomResourceDefinition
+	crdName := "gitrepositories." + sourcev1.GroupVersion.Group
+	if err := kube.Get(ctx, client.ObjectKey{Name: crdName}, &crd); err != nil {
+		return false, err
+	}
+	for _, version := range crd.Spec.Versions {
+		if version.Name != sync.GitRepositoryProviderVersion || version.Schema == nil || version.Schema.OpenAPIV3Schema == nil {
+			continue
+		}
+		if !version.Served {
+			return false, nil
+		}
+		spec := version.Schema.OpenAPIV3Schema.Properties["spec"]
+		field, ok := spec.Properties["provider"]
+		if !ok {
+			return false, nil
+		}
+		if provider == "" || len(field.Enum) == 0 {
+			return true, nil
+		}
+		for _, value := range field.Enum {
+			var allowed string
+			if err := json.Unmarshal(value.Raw, &allowed); err == nil && allowed == provider {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	return false, nil
+}
+
+// VerifySourceSecret verifies the existing source secret configured with
+// WithExistingSourceSecret, by cloning the sync URL with the credentials
+// found in the secret. It is a no-op when no existing secret is configured.
+func (b *PlainGitBootstrapper) VerifySourceSecret(ctx context.Context, secretOpts sourcesecret.Options, syncOpts sync.Options) error {
+	if !b.existingSecret {
+		return nil
+	}
+
+	secretKey := client.ObjectKey{Name: secretOpts.Name, Namespace: secretOpts.Namespace}
+	b.logger.Actionf("verifying existing source secret %q", secretKey)
+	var secret corev1.Secret
+	if err := b.kube.Get(ctx, secretKey, &secret); err != nil {
+		if apierr.IsNotFound(err) {
+			return fmt.Errorf("source secret %q not found, it must exist when bootstrapping with an existing secret", secretKey)
+		}
+		return fmt.Errorf("failed to get source secret %q: %w", secretKey, err)
+	}
+
+	u, err := url.Parse(syncOpts.URL)
+	if err != nil {
+		return fmt.Errorf("failed to parse sync URL %q: %w", syncOpts.URL, err)
+	}
+	if missing := missingSourceSecretKeys(u.Scheme, secret.Data); len(missing) > 0 {
+		return fmt.Errorf("source secret %q lacks the %s keys required for %s authentication",
+			secretKey, strings.Join(missing, ", "), u.Scheme)
+	}
+	if _, ok := secret.Data[sourcesecret.GitHubAppIDSecretKey]; ok {
+		// The app credentials are exchanged for a token by source-controller,
+		// they can't be used as is to clone the repository.
+		b.logger.Successf("source secret %q contains GitHub App credentials", secretKey)
+		return nil
+	}
+	authOpts, err := git.NewAuthOptions(*u, secret.Data)
+	if err != nil {
+		return fmt.Errorf("invalid credentials in source secret %q: %w", secretKey, err)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "flux-bootstrap-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary working directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	gitClient, err := gogit.NewClient(tmpDir, authOpts, gogit.WithDiskStorage())
+	if err != nil {
+		return fmt.Errorf("failed to create Git client: %w", err)
+	}
+	cloneCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+	if _, err = gitClient.Clone(cloneCtx, syncOpts.URL, repository.CloneOptions{
+		CheckoutStrategy: repository.CheckoutStrategy{
+			Branch: b.branch,
+		},
+		ShallowClone: true,
+	}); err != nil {
+		return fmt.Errorf("failed to clone %s with source secret %q: %w", syncOpts.URL, secretKey, err)
+	}
+	b.logger.Successf("source secret %q can be used to clone %s", secretKey, syncOpts.URL)
+	return nil
+}
+
+// missingSourceSecretKeys returns the keys the source secret data lacks to
+// authenticate with the Git transport of the given URL scheme. A secret
+// without credentials is accepted for HTTP/S, e.g. one holding only a CA
+// for a public repository.
+func missingSourceSecretKeys(scheme string, data map[string][]byte) []string {
+	var required []string
+	switch {
+	case scheme == "ssh":
+		required = []string{sourcesecret.PrivateKeySecretKey, sourcesecret.KnownHostsSecretKey}
+	case len(data[sourcesecret.BearerTokenSecretKey]) > 0:
+	case len(data[sourcesecret.GitHubAppIDSecretKey]) > 0:
+		required = []string{sourcesecret.GitHubAppInstallationIDSecretKey, sourcesecret.GitHubAppPrivateKeySecretKey}
+	case len(data[sourcesecret.UsernameSecretKey]) > 0 || len(data[sourcesecret.PasswordSecretKey]) > 0:
+		required = []string{sourcesecret.UsernameSecretKey, sourcesecret.PasswordSecretKey}
+	}
+
+	var missing []string
+	for _, key := range required {
+		if len(data[key]) == 0 {
+			missing = append(missing, key)
+		}
+	}
+	return missing
+}
+
 func (b *PlainGitBootstrapper) ReconcileSyncConfig(ctx context.Context, options sync.Options) error {
 	// Confirm that sync configuration does not overwrite existing config
 	if curPath, err := kustomizationPathDiffers(ctx, b.kube, client.ObjectKey{Name: options.Name, Namespace: options.Namespace}, options.TargetPath); err != nil {
@@ -258,11 +603,7 @@ func (b *PlainGitBootstrapper) ReconcileSyncConfig(ctx context.Context, options
 			b.logger.Actionf("cloning branch %q from Git repository %q", b.branch, b.url)
 			var cloned bool
 			if err = retry(1, 2*time.Second, func() (err error) {
-				_, err = b.gitClient.Clone(ctx, b.url, repository.CloneOptions{
-					CheckoutStrategy: repository.CheckoutStrategy{
-						Branch: b.branch,
-					},
-				})
+				err = b.cloneBranch(ctx)
 				if err == nil {
 					cloned = true
 				}
@@ -277,6 +618,12 @@ func (b *PlainGitBootstrapper) ReconcileSyncConfig(ctx context.Context, options
 		return err
 	}
 
+	// Fall back to the token based source secret when the GitHub provider
+	// is not supported, see ReconcileSourceSecret
+	if b.gitHubAppTokenFallback && options.Provider == sync.GitHubProvider {
+		options.Provider = ""
+	}
+
 	// Generate sync manifests and write to Git repository
 	b.logger.Actionf("generating sync manifests")
 	manifests, err := sync.Generate(options)
@@ -284,28 +631,6 @@ func (b *PlainGitBootstrapper) ReconcileSyncConfig(ctx context.Context, options
 		return fmt.Errorf("sync manifests generation failed: %w", err)
 	}
 
-	// Create secure Kustomize FS
-	fs, err := filesys.MakeFsOnDiskSecureBuild(b.gitClient.Path())
-	if err != nil {
-		return fmt.Errorf("failed to initialize Kustomize file system: %w", err)
-	}
-
-	if err = fs.WriteFile(filepath.Join(b.gitClient.Path(), manifests.Path), []byte(manifests.Content)); err != nil {
-		return err
-	}
-
-	// Generate Kustomization
-	kusManifests, err := kustomization.Generate(kustomization.Options{
-		FileSystem: fs,
-		BaseDir:    b.gitClient.Path(),
-		TargetPath: filepath.Dir(manifests.Path),
-	})
-	if err != nil {
-		return fmt.Errorf("%s generation failed: %w", konfig.DefaultKustomizationFileName(), err)
-	}
-	b.logger.Successf("generated sync manifests")
-
-	// Write generated files and make a commit
 	var signer *openpgp.Entity
 	if b.gpgKeyRing != nil {
 		signer, err = getOpenPgpEntity(b.gpgKeyRing, b.gpgPassphrase, b.gpgKeyID)
@@ -313,55 +638,49 @@ func (b *PlainGitBootstrapper) ReconcileSyncConfig(ctx context.Context, options
 			return fmt.Errorf("failed to generate OpenPGP entity: %w", err)
 		}
 	}
-	commitMsg := fmt.Sprintf("Add Flux sync manifests")
-	if b.commitMessageAppendix != "" {
-		commitMsg = commitMsg + "\n\n" + b.commitMessageAppendix
-	}
+	commitMsg := b.commitMessage("Add Flux sync manifests")
 
-	commit, err := b.gitClient.Commit(git.Commit{
-		Author:  b.signature,
-		Message: commitMsg,
-	}, repository.WithFiles(map[string]io.Reader{
-		kusManifests.Path: strings.NewReader(kusManifests.Content),
-	}), repository.WithSigner(signer))
-	if err != nil && err != git.ErrNoStagedFiles {
-		return fmt.Errorf("failed to commit sync manifests: %w", err)
-	}
-
-	if err == nil {
-		b.logger.Successf("committed sync manifests to %q (%q)", b.branch, commit)
-		b.logger.Actionf("pushing sync manifests to %q", b.url)
-		err = b.gitClient.Push(ctx)
+	// Write generated files and make a commit, the files are written again
+	// to the fresh clone when the push conflicts with another commit
+	var kusManifests *manifestgen.Manifest
+	if err := b.commitAndPush(ctx, "sync manifests", commitMsg, signer, func() (map[string]io.Reader, error) {
+		// Create secure Kustomize FS
+		fs, err := filesys.MakeFsOnDiskSecureBuild(b.gitClient.Path())
 		if err != nil {
-			if strings.HasPrefix(err.Error(), gogit.ErrNonFastForwardUpdate.Error()) {
-				b.logger.Waitingf("git conflict detected, retrying with a fresh clone")
-				if err := os.RemoveAll(b.gitClient.Path()); err != nil {
-					return fmt.Errorf("failed to remove tmp dir: %w", err)
-				}
-				if err := os.Mkdir(b.gitClient.Path(), 0o700); err != nil {
-					return fmt.Errorf("failed to recreate tmp dir: %w", err)
-				}
-				if err = retry(1, 2*time.Second, func() (err error) {
-					_, err = b.gitClient.Clone(ctx, b.url, repository.CloneOptions{
-						CheckoutStrategy: repository.CheckoutStrategy{
-							Branch: b.branch,
-						},
-					})
-					return
-				}); err != nil {
-					return fmt.Errorf("failed to clone repository: %w", err)
-				}
-				return b.ReconcileSyncConfig(ctx, options)
+			return nil, fmt.Errorf("failed to initialize Kustomize file system: %w", err)
+		}
+
+		if err = fs.WriteFile(filepath.Join(b.gitClient.Path(), manifests.Path), []byte(manifests.Content)); err != nil {
+			return nil, err
+		}
+
+		if b.sopsAge {
+			if err := b.reconcileSOPSConfig(ctx, options); err != nil {
+				return nil, err
 			}
-			return fmt.Errorf("failed to push sync manifests: %w", err)
 		}
-	} else {
-		b.logger.Successf("sync manifests are up to date")
+
+		// Generate Kustomization
+		kusManifests, err = kustomization.Generate(kustomization.Options{
+			FileSystem: fs,
+			BaseDir:    b.gitClient.Path(),
+			TargetPath: filepath.Dir(manifests.Path),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s generation failed: %w", konfig.DefaultKustomizationFileName(), err)
+		}
+		b.logger.Successf("generated sync manifests")
+
+		return map[string]io.Reader{
+			kusManifests.Path: strings.NewReader(kusManifests.Content),
+		}, nil
+	}); err != nil {
+		return err
 	}
 
 	// Apply to cluster
 	b.logger.Actionf("applying sync manifests")
-	if _, err := utils.Apply(ctx, b.restClientGetter, b.restClientOptions, b.gitClient.Path(), filepath.Join(b.gitClient.Path(), kusManifests.Path)); err != nil {
+	if err := b.apply(ctx, b.gitClient.Path(), filepath.Join(b.gitClient.Path(), kusManifests.Path)); err != nil {
 		return err
 	}
 
@@ -394,34 +713,31 @@ func (b *PlainGitBootstrapper) ReportKustomizationHealth(ctx context.Context, op
 }
 
 func (b *PlainGitBootstrapper) ReportComponentsHealth(ctx context.Context, install install.Options, timeout time.Duration) error {
-	cfg, err := utils.KubeConfig(b.restClientGetter, b.restClientOptions)
-	if err != nil {
-		return err
-	}
+	return reportComponentsHealth(b.restClientGetter, b.restClientOptions, b.logger, install, timeout)
+}
 
-	checker, err := status.NewStatusChecker(cfg, 5*time.Second, timeout, b.logger)
+// apply applies the manifest to the cluster and counts the applied objects.
+func (b *PlainGitBootstrapper) apply(ctx context.Context, root, manifestPath string) error {
+	changeSet, err := utils.ApplyChangeSet(ctx, b.restClientGetter, b.restClientOptions, root, manifestPath)
 	if err != nil {
 		return err
 	}
+	b.applied.Add(changeSet)
+	return nil
+}
 
-	var components = install.Components
-	components = append(components, install.ComponentsExtra...)
-
-	var identifiers []object.ObjMetadata
-	for _, component := range components {
-		identifiers = append(identifiers, object.ObjMetadata{
-			Namespace: install.Namespace,
-			Name:      component,
-			GroupKind: schema.GroupKind{Group: "apps", Kind: "Deployment"},
-		})
-	}
+// ReportSummary logs the objects applied on the cluster and the time spent
+// in each phase.
+func (b *PlainGitBootstrapper) ReportSummary(phases Phases) {
+	reportSummary(b.logger, b.applied, phases)
+}
 
-	b.logger.Actionf("confirming components are healthy")
-	if err := checker.Assess(identifiers...); err != nil {
-		return err
+// ReportProgress forwards the phase and the completion percentage to the
+// logger, if it supports progress reporting.
+func (b *PlainGitBootstrapper) ReportProgress(phase string, percent int) {
+	if l, ok := b.logger.(log.ProgressLogger); ok {
+		l.Progress(phase, percent)
 	}
-	b.logger.Successf("all components are healthy")
-	return nil
 }
 
 func getOpenPgpEntity(keyRing openpgp.EntityList, passphrase, keyID string) (*openpgp.Entity, error) {
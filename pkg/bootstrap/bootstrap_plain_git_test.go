@@ -0,0 +1,589 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootstrap
+
+import (
+	"context"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/cgi"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	extgogit "github.com/fluxcd/go-git/v5"
+	"github.com/fluxcd/go-git/v5/plumbing"
+	"github.com/fluxcd/go-git/v5/plumbing/object"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/fluxcd/flux2/internal/utils"
+	"github.com/fluxcd/flux2/pkg/log"
+	"github.com/fluxcd/flux2/pkg/manifestgen/sourcesecret"
+	"github.com/fluxcd/flux2/pkg/manifestgen/sync"
+	"github.com/fluxcd/pkg/git"
+	"github.com/fluxcd/pkg/git/gogit"
+	"github.com/fluxcd/pkg/git/repository"
+)
+
+func TestPlainGitBootstrapper_commitMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []GitOption
+		want string
+	}{
+		{
+			name: "subject only",
+			want: "Add Flux sync manifests",
+		},
+		{
+			name: "with appendix",
+			opts: []GitOption{WithCommitMessageAppendix("[ci skip]")},
+			want: "Add Flux sync manifests\n\n[ci skip]",
+		},
+		{
+			name: "with appendix and signoff",
+			opts: []GitOption{
+				WithSignature("Flux", "flux@example.com"),
+				WithCommitMessageAppendix("[ci skip]"),
+				WithSignoff(true),
+			},
+			want: "Add Flux sync manifests\n\n[ci skip]\n\nSigned-off-by: Flux <flux@example.com>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			b, err := NewPlainGitProvider(nil, nil, tt.opts...)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(b.commitMessage("Add Flux sync manifests")).To(Equal(tt.want))
+		})
+	}
+}
+
+func Test_gitRepositorySupportsProvider(t *testing.T) {
+	newCRD := func(version string, specProperties map[string]apiextensionsv1.JSONSchemaProps) *apiextensionsv1.CustomResourceDefinition {
+		return &apiextensionsv1.CustomResourceDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: "gitrepositories.source.toolkit.fluxcd.io"},
+			Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+				Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+					{
+						Name:   version,
+						Served: true,
+						Schema: &apiextensionsv1.CustomResourceValidation{
+							OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{
+								Properties: map[string]apiextensionsv1.JSONSchemaProps{
+									"spec": {Properties: specProperties},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	enum := func(values ...string) []apiextensionsv1.JSON {
+		var result []apiextensionsv1.JSON
+		for _, v := range values {
+			result = append(result, apiextensionsv1.JSON{Raw: []byte(`"` + v + `"`)})
+		}
+		return result
+	}
+	notServed := newCRD("v1", map[string]apiextensionsv1.JSONSchemaProps{
+		"provider": {Type: "string"},
+	})
+	notServed.Spec.Versions[0].Served = false
+
+	tests := []struct {
+		name     string
+		crd      *apiextensionsv1.CustomResourceDefinition
+		provider string
+		want     bool
+		wantErr  bool
+	}{
+		{
+			name: "provider field",
+			crd: newCRD("v1", map[string]apiextensionsv1.JSONSchemaProps{
+				"url":      {Type: "string"},
+				"provider": {Type: "string"},
+			}),
+			want: true,
+		},
+		{
+			name: "no provider field",
+			crd: newCRD("v1", map[string]apiextensionsv1.JSONSchemaProps{
+				"url": {Type: "string"},
+			}),
+			want: false,
+		},
+		{
+			name: "provider field in other version",
+			crd: newCRD("v1beta2", map[string]apiextensionsv1.JSONSchemaProps{
+				"provider": {Type: "string"},
+			}),
+			want: false,
+		},
+		{
+			name:     "provider allowed",
+			crd:      newCRD("v1", map[string]apiextensionsv1.JSONSchemaProps{"provider": {Type: "string", Enum: enum("generic", "azure", "github")}}),
+			provider: "github",
+			want:     true,
+		},
+		{
+			name:     "provider not allowed",
+			crd:      newCRD("v1", map[string]apiextensionsv1.JSONSchemaProps{"provider": {Type: "string", Enum: enum("generic", "azure")}}),
+			provider: "github",
+			want:     false,
+		},
+		{
+			name: "version not served",
+			crd:  notServed,
+			want: false,
+		},
+		{
+			name:    "no CRD",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			builder := fake.NewClientBuilder().WithScheme(utils.NewScheme())
+			if tt.crd != nil {
+				builder = builder.WithObjects(tt.crd)
+			}
+
+			got, err := gitRepositorySupportsProvider(context.TODO(), builder.Build(), tt.provider)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestPlainGitBootstrapper_ReconcileSourceSecretGitHubApp(t *testing.T) {
+	newCRD := func(providers ...string) *apiextensionsv1.CustomResourceDefinition {
+		var enum []apiextensionsv1.JSON
+		for _, p := range providers {
+			enum = append(enum, apiextensionsv1.JSON{Raw: []byte(`"` + p + `"`)})
+		}
+		return &apiextensionsv1.CustomResourceDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: "gitrepositories.source.toolkit.fluxcd.io"},
+			Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+				Versions: []apiextensionsv1.CustomResourceDefinitionVersion{{
+					Name:   "v1",
+					Served: true,
+					Schema: &apiextensionsv1.CustomResourceValidation{
+						OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{
+							Properties: map[string]apiextensionsv1.JSONSchemaProps{
+								"spec": {Properties: map[string]apiextensionsv1.JSONSchemaProps{
+									"provider": {Type: "string", Enum: enum},
+								}},
+							},
+						},
+					},
+				}},
+			},
+		}
+	}
+
+	tests := []struct {
+		name         string
+		crd          *apiextensionsv1.CustomResourceDefinition
+		token        string
+		wantKeys     []string
+		wantFallback bool
+		wantErr      bool
+	}{
+		{
+			name:     "github provider supported",
+			crd:      newCRD("generic", "azure", "github"),
+			token:    "ghs_token",
+			wantKeys: []string{"githubAppID", "githubAppInstallationID", "githubAppPrivateKey"},
+		},
+		{
+			name:         "github provider not supported",
+			crd:          newCRD("generic", "azure"),
+			token:        "ghs_token",
+			wantKeys:     []string{"password", "username"},
+			wantFallback: true,
+		},
+		{
+			name:    "github provider not supported without token",
+			crd:     newCRD("generic", "azure"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			kube := fake.NewClientBuilder().WithScheme(utils.NewScheme()).WithObjects(tt.crd).Build()
+			b, err := NewPlainGitProvider(nil, kube, WithLogger(log.NopLogger{}))
+			g.Expect(err).ToNot(HaveOccurred())
+
+			options := sourcesecret.Options{
+				Name:                    "flux-system",
+				Namespace:               "flux-system",
+				GitHubAppID:             "1",
+				GitHubAppInstallationID: "2",
+				GitHubAppPrivateKey:     []byte("key"),
+			}
+			if tt.token != "" {
+				options.Username, options.Password = "git", tt.token
+			}
+			err = b.ReconcileSourceSecret(context.TODO(), options)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(b.gitHubAppTokenFallback).To(Equal(tt.wantFallback))
+
+			var secret corev1.Secret
+			g.Expect(kube.Get(context.TODO(), client.ObjectKey{Name: "flux-system", Namespace: "flux-system"}, &secret)).To(Succeed())
+			var keys []string
+			for k := range secret.StringData {
+				keys = append(keys, k)
+			}
+			for k := range secret.Data {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			g.Expect(keys).To(Equal(tt.wantKeys))
+		})
+	}
+}
+
+func Test_missingSourceSecretKeys(t *testing.T) {
+	tests := []struct {
+		name   string
+		scheme string
+		data   map[string][]byte
+		want   []string
+	}{
+		{
+			name:   "ssh",
+			scheme: "ssh",
+			data:   map[string][]byte{"identity": []byte("key"), "known_hosts": []byte("hosts")},
+		},
+		{
+			name:   "ssh without known_hosts",
+			scheme: "ssh",
+			data:   map[string][]byte{"identity": []byte("key")},
+			want:   []string{"known_hosts"},
+		},
+		{
+			name:   "ssh with basic auth",
+			scheme: "ssh",
+			data:   map[string][]byte{"username": []byte("git"), "password": []byte("token")},
+			want:   []string{"identity", "known_hosts"},
+		},
+		{
+			name:   "https basic auth",
+			scheme: "https",
+			data:   map[string][]byte{"username": []byte("git"), "password": []byte("token")},
+		},
+		{
+			name:   "https without password",
+			scheme: "https",
+			data:   map[string][]byte{"username": []byte("git")},
+			want:   []string{"password"},
+		},
+		{
+			name:   "https bearer token",
+			scheme: "https",
+			data:   map[string][]byte{"bearerToken": []byte("token")},
+		},
+		{
+			name:   "https GitHub App",
+			scheme: "https",
+			data:   map[string][]byte{"githubAppID": []byte("1"), "githubAppPrivateKey": []byte("key")},
+			want:   []string{"githubAppInstallationID"},
+		},
+		{
+			name:   "https CA only",
+			scheme: "https",
+			data:   map[string][]byte{"caFile": []byte("ca")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(missingSourceSecretKeys(tt.scheme, tt.data)).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestPlainGitBootstrapper_VerifySourceSecret(t *testing.T) {
+	repoURL, caFile := newBasicAuthGitServer(t, "git", "s3cr3t")
+	secretOpts := sourcesecret.Options{Name: "flux-system", Namespace: "flux-system"}
+	newSecret := func(data map[string]string) *corev1.Secret {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: secretOpts.Name, Namespace: secretOpts.Namespace},
+			Data:       map[string][]byte{},
+		}
+		for k, v := range data {
+			secret.Data[k] = []byte(v)
+		}
+		return secret
+	}
+
+	tests := []struct {
+		name     string
+		existing bool
+		secret   *corev1.Secret
+		wantErr  string
+	}{
+		{
+			name: "no existing secret",
+		},
+		{
+			name:     "valid credentials",
+			existing: true,
+			secret:   newSecret(map[string]string{"username": "git", "password": "s3cr3t", "caFile": caFile}),
+		},
+		{
+			name:     "secret not found",
+			existing: true,
+			wantErr:  "not found",
+		},
+		{
+			name:     "missing password",
+			existing: true,
+			secret:   newSecret(map[string]string{"username": "git"}),
+			wantErr:  "lacks the password keys",
+		},
+		{
+			name:     "wrong password",
+			existing: true,
+			secret:   newSecret(map[string]string{"username": "git", "password": "wrong", "caFile": caFile}),
+			wantErr:  "authentication required",
+		},
+		{
+			name:     "no credentials",
+			existing: true,
+			secret:   newSecret(map[string]string{"caFile": caFile}),
+			wantErr:  "authentication required",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			builder := fake.NewClientBuilder().WithScheme(utils.NewScheme())
+			if tt.secret != nil {
+				builder = builder.WithObjects(tt.secret)
+			}
+			opts := []GitOption{WithBranch("master"), WithLogger(log.NopLogger{})}
+			if tt.existing {
+				opts = append(opts, WithExistingSourceSecret())
+			}
+			b, err := NewPlainGitProvider(nil, builder.Build(), opts...)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			err = b.VerifySourceSecret(context.TODO(), secretOpts, sync.Options{URL: repoURL})
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+		})
+	}
+}
+
+// newBasicAuthGitServer serves a repository with a single commit on the
+// master branch over HTTPS, with git http-backend behind basic auth, and
+// returns its URL and the PEM encoded certificate of the server.
+func newBasicAuthGitServer(t *testing.T, username, password string) (string, string) {
+	t.Helper()
+	execPath, err := exec.Command("git", "--exec-path").Output()
+	if err != nil {
+		t.Skip("git is required to serve the test repository")
+	}
+	backend := filepath.Join(strings.TrimSpace(string(execPath)), "git-http-backend")
+	if _, err := os.Stat(backend); err != nil {
+		t.Skip("git http-backend is required to serve the test repository")
+	}
+
+	root := t.TempDir()
+	remote := filepath.Join(root, "repo.git")
+	_, err = extgogit.PlainInit(remote, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seed, err := gogit.NewClient(t.TempDir(), &git.AuthOptions{Transport: git.HTTP}, gogit.WithDiskStorage())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := seed.Init(context.TODO(), remote, "master"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := seed.Commit(git.Commit{
+		Author:  git.Signature{Name: "Flux", Email: "flux@example.com"},
+		Message: "Initial commit",
+	}, repository.WithFiles(map[string]io.Reader{"README.md": strings.NewReader("flux")})); err != nil {
+		t.Fatal(err)
+	}
+	if err := seed.Push(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+
+	handler := &cgi.Handler{
+		Path: backend,
+		Env:  []string{"GIT_PROJECT_ROOT=" + root, "GIT_HTTP_EXPORT_ALL=1"},
+	}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if u, p, ok := r.BasicAuth(); !ok || u != username || p != password {
+			w.Header().Set("WWW-Authenticate", `Basic realm="git"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	caFile := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	return server.URL + "/repo.git", string(caFile)
+}
+
+func TestPlainGitBootstrapper_commitAndPushConflict(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	remote := t.TempDir()
+	_, err := extgogit.PlainInit(remote, true)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	newClient := func() *gogit.Client {
+		c, err := gogit.NewClient(t.TempDir(), &git.AuthOptions{Transport: git.HTTP}, gogit.WithDiskStorage())
+		g.Expect(err).ToNot(HaveOccurred())
+		return c
+	}
+	commit := func(c *gogit.Client, file string) {
+		_, err := c.Commit(git.Commit{
+			Author:  git.Signature{Name: "Flux", Email: "flux@example.com"},
+			Message: "Add " + file,
+		}, repository.WithFiles(map[string]io.Reader{file: strings.NewReader(file)}))
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(c.Push(ctx)).To(Succeed())
+	}
+	clone := func(c *gogit.Client) {
+		_, err := c.Clone(ctx, remote, repository.CloneOptions{
+			CheckoutStrategy: repository.CheckoutStrategy{Branch: "main"},
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+	}
+
+	seed := newClient()
+	g.Expect(seed.Init(ctx, remote, "main")).To(Succeed())
+	commit(seed, "README.md")
+
+	// Clone before another commit lands on the branch
+	bootstrapClient := newClient()
+	clone(bootstrapClient)
+	other := newClient()
+	clone(other)
+	commit(other, "other.txt")
+
+	b, err := NewPlainGitProvider(bootstrapClient, nil,
+		WithRepositoryURL(remote), WithBranch("main"), WithLogger(log.NopLogger{}), WithShallowClone(true),
+		WithGitClientFactory(func(path string) (repository.Client, error) {
+			return gogit.NewClient(path, &git.AuthOptions{Transport: git.HTTP}, gogit.WithDiskStorage())
+		}))
+	g.Expect(err).ToNot(HaveOccurred())
+	err = b.commitAndPush(ctx, "sync manifests", "Add Flux sync manifests", nil, func() (map[string]io.Reader, error) {
+		return map[string]io.Reader{"gotk-sync.yaml": strings.NewReader("sync")}, nil
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(b.Commits()).To(HaveLen(1))
+
+	// Both commits are on the branch
+	verify := newClient()
+	clone(verify)
+	for _, file := range []string{"other.txt", "gotk-sync.yaml"} {
+		_, err := os.Stat(filepath.Join(verify.Path(), file))
+		g.Expect(err).ToNot(HaveOccurred(), file)
+	}
+}
+
+func TestPlainGitBootstrapper_shallowClone(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	remote := t.TempDir()
+	_, err := extgogit.PlainInit(remote, true)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	newClient := func(path string) (repository.Client, error) {
+		return gogit.NewClient(path, &git.AuthOptions{Transport: git.HTTP}, gogit.WithDiskStorage())
+	}
+	seed, err := newClient(t.TempDir())
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(seed.Init(ctx, remote, "main")).To(Succeed())
+	for _, file := range []string{"a.txt", "b.txt", "c.txt"} {
+		_, err := seed.Commit(git.Commit{
+			Author:  git.Signature{Name: "Flux", Email: "flux@example.com"},
+			Message: "Add " + file,
+		}, repository.WithFiles(map[string]io.Reader{file: strings.NewReader(file)}))
+		g.Expect(err).ToNot(HaveOccurred())
+	}
+	g.Expect(seed.Push(ctx)).To(Succeed())
+
+	bootstrapClient, err := newClient(t.TempDir())
+	g.Expect(err).ToNot(HaveOccurred())
+	b, err := NewPlainGitProvider(bootstrapClient, nil,
+		WithRepositoryURL(remote), WithBranch("main"), WithLogger(log.NopLogger{}), WithShallowClone(true))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(b.cloneBranch(ctx)).To(Succeed())
+
+	// Only the last commit is cloned
+	_, err = os.Stat(filepath.Join(bootstrapClient.Path(), ".git", "shallow"))
+	g.Expect(err).ToNot(HaveOccurred())
+
+	err = b.commitAndPush(ctx, "sync manifests", "Add Flux sync manifests", nil, func() (map[string]io.Reader, error) {
+		return map[string]io.Reader{"gotk-sync.yaml": strings.NewReader("sync")}, nil
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	repo, err := extgogit.PlainOpen(remote)
+	g.Expect(err).ToNot(HaveOccurred())
+	ref, err := repo.Reference(plumbing.NewBranchReferenceName("main"), true)
+	g.Expect(err).ToNot(HaveOccurred())
+	commits, err := repo.Log(&extgogit.LogOptions{From: ref.Hash()})
+	g.Expect(err).ToNot(HaveOccurred())
+	var count int
+	g.Expect(commits.ForEach(func(*object.Commit) error {
+		count++
+		return nil
+	})).To(Succeed())
+	g.Expect(count).To(Equal(4))
+}
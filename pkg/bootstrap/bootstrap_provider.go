@@ -18,6 +18,8 @@ package bootstrap
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"net/url"
@@ -25,16 +27,23 @@ import (
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/fluxcd/go-git-providers/gitprovider"
+	notificationv1 "github.com/fluxcd/notification-controller/api/v1beta2"
 
 	"github.com/fluxcd/flux2/pkg/bootstrap/provider"
+	"github.com/fluxcd/flux2/pkg/log"
 	"github.com/fluxcd/flux2/pkg/manifestgen/sourcesecret"
 	"github.com/fluxcd/flux2/pkg/manifestgen/sync"
 	"github.com/fluxcd/pkg/git/repository"
 )
 
+// webhookTokenKey is the key of the token in the secret of the Receiver.
+const webhookTokenKey = "token"
+
 type GitProviderBootstrapper struct {
 	*PlainGitBootstrapper
 
@@ -59,6 +68,10 @@ type GitProviderBootstrapper struct {
 
 	sshHostname string
 
+	webhookReceiverURL string
+
+	detectDefaultBranch bool
+
 	provider gitprovider.Client
 }
 
@@ -184,11 +197,58 @@ func (o reconcileOption) applyGitProvider(b *GitProviderBootstrapper) {
 	b.reconcile = true
 }
 
+// WithWebhookReceiver generates a Receiver reconciling the GitRepository on
+// the push events, and registers a webhook sending the events to the
+// Receiver on the repository. The URL is the external address of the
+// webhook receiver of notification-controller.
+func WithWebhookReceiver(url string) GitProviderOption {
+	return webhookReceiverOption(url)
+}
+
+type webhookReceiverOption string
+
+func (o webhookReceiverOption) applyGitProvider(b *GitProviderBootstrapper) {
+	b.webhookReceiverURL = string(o)
+}
+
+// WithDefaultBranchDetection makes the bootstrapper commit to the default
+// branch of the provider repository instead of the configured branch, for
+// when no branch was given explicitly.
+func WithDefaultBranchDetection() GitProviderOption {
+	return defaultBranchDetectionOption(true)
+}
+
+type defaultBranchDetectionOption bool
+
+func (o defaultBranchDetectionOption) applyGitProvider(b *GitProviderBootstrapper) {
+	b.detectDefaultBranch = bool(o)
+}
+
 func (b *GitProviderBootstrapper) ReconcileSyncConfig(ctx context.Context, options sync.Options) error {
 	if b.repository == nil {
 		return errors.New("repository is required")
 	}
 
+	var webhookToken string
+	if b.webhookReceiverURL != "" {
+		switch provider.GitProvider(b.provider.ProviderID()) {
+		case provider.GitProviderGitHub:
+			options.ReceiverType = notificationv1.GitHubReceiver
+		case provider.GitProviderGitLab:
+			options.ReceiverType = notificationv1.GitLabReceiver
+		default:
+			return fmt.Errorf("webhook receivers are not supported for the Git provider '%s'", b.provider.ProviderID())
+		}
+		if options.ReceiverSecret == "" {
+			options.ReceiverSecret = options.Name + "-webhook-token"
+		}
+		var err error
+		webhookToken, err = reconcileWebhookToken(ctx, b.kube, client.ObjectKey{Name: options.ReceiverSecret, Namespace: options.Namespace}, b.logger)
+		if err != nil {
+			return err
+		}
+	}
+
 	if b.url == "" {
 		bootstrapURL, err := b.getCloneURL(b.repository, gitprovider.TransportType(b.bootstrapTransportType))
 		if err != nil {
@@ -205,7 +265,89 @@ func (b *GitProviderBootstrapper) ReconcileSyncConfig(ctx context.Context, optio
 		options.URL = syncURL
 	}
 
-	return b.PlainGitBootstrapper.ReconcileSyncConfig(ctx, options)
+	if b.detectDefaultBranch {
+		options.Branch = b.branch
+	}
+
+	if err := b.PlainGitBootstrapper.ReconcileSyncConfig(ctx, options); err != nil {
+		return err
+	}
+
+	if b.webhookReceiverURL != "" {
+		receiver := notificationv1.Receiver{}
+		receiver.SetName(options.Name)
+		receiver.SetNamespace(options.Namespace)
+		hookURL := strings.TrimSuffix(b.webhookReceiverURL, "/") + receiver.GetWebhookPath(webhookToken)
+		changed, err := provider.ReconcileWebhook(ctx, b.provider, b.repository.Repository(), hookURL, webhookToken)
+		if err != nil {
+			return err
+		}
+		if changed {
+			b.logger.Successf("configured webhook %q for %q", hookURL, b.repository.Repository().String())
+		}
+	}
+	return nil
+}
+
+// reconcileWebhookToken returns the token of the webhook Receiver stored in
+// the secret, the secret is created with a random token when it doesn't
+// exist. The token of an existing secret is kept, as the webhook path of the
+// Receiver is derived from it.
+func reconcileWebhookToken(ctx context.Context, kube client.Client, key client.ObjectKey, logger log.Logger) (string, error) {
+	var secret corev1.Secret
+	err := kube.Get(ctx, key, &secret)
+	switch {
+	case err == nil:
+		token := string(secret.Data[webhookTokenKey])
+		if token == "" {
+			return "", fmt.Errorf("no '%s' found in webhook secret %q", webhookTokenKey, key)
+		}
+		logger.Successf("using the webhook token of secret %q", key)
+		return token, nil
+	case !apierr.IsNotFound(err):
+		return "", fmt.Errorf("failed to get webhook secret %q: %w", key, err)
+	}
+
+	data := make([]byte, 32)
+	if _, err := rand.Read(data); err != nil {
+		return "", fmt.Errorf("failed to generate webhook token: %w", err)
+	}
+	token := hex.EncodeToString(data)
+	secret = corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      key.Name,
+			Namespace: key.Namespace,
+		},
+		StringData: map[string]string{
+			webhookTokenKey: token,
+		},
+	}
+	if err := kube.Create(ctx, &secret); err != nil {
+		return "", fmt.Errorf("failed to create webhook secret %q: %w", key, err)
+	}
+	logger.Successf("generated webhook token in secret %q", key)
+	return token, nil
+}
+
+// VerifySourceSecret verifies the existing source secret against the sync
+// URL of the reconciled repository, see PlainGitBootstrapper.VerifySourceSecret.
+func (b *GitProviderBootstrapper) VerifySourceSecret(ctx context.Context, secretOpts sourcesecret.Options, syncOpts sync.Options) error {
+	if !b.existingSecret {
+		return nil
+	}
+	if b.repository == nil {
+		return errors.New("repository is required")
+	}
+
+	if syncOpts.URL == "" {
+		syncURL, err := b.getCloneURL(b.repository, gitprovider.TransportType(b.syncTransportType))
+		if err != nil {
+			return err
+		}
+		syncOpts.URL = syncURL
+	}
+
+	return b.PlainGitBootstrapper.VerifySourceSecret(ctx, secretOpts, syncOpts)
 }
 
 // ReconcileRepository reconciles an organization or user repository with the
@@ -233,10 +375,27 @@ func (b *GitProviderBootstrapper) ReconcileRepository(ctx context.Context) error
 
 	b.repository = repo
 	WithRepositoryURL(cloneURL).applyGit(b.PlainGitBootstrapper)
+	b.useRepositoryDefaultBranch(repo)
 
 	return err
 }
 
+// useRepositoryDefaultBranch configures the default branch of the provider
+// repository on the embedded PlainGitBootstrapper, which creates the
+// configured branch from it when missing. With WithDefaultBranchDetection,
+// the default branch is also used as the branch to commit to.
+func (b *GitProviderBootstrapper) useRepositoryDefaultBranch(repo gitprovider.UserRepository) {
+	defaultBranch := repo.Get().DefaultBranch
+	if defaultBranch == nil || *defaultBranch == "" {
+		return
+	}
+	b.PlainGitBootstrapper.defaultBranch = *defaultBranch
+	if b.detectDefaultBranch && b.branch != *defaultBranch {
+		b.logger.Successf("using the default branch %q of the repository", *defaultBranch)
+		b.branch = *defaultBranch
+	}
+}
+
 func (b *GitProviderBootstrapper) reconcileDeployKey(ctx context.Context, secret corev1.Secret, options sourcesecret.Options) error {
 	if b.repository == nil {
 		return errors.New("repository is required")
@@ -0,0 +1,110 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootstrap
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/fluxcd/flux2/internal/utils"
+	"github.com/fluxcd/flux2/pkg/log"
+)
+
+func Test_reconcileWebhookToken(t *testing.T) {
+	g := NewWithT(t)
+	key := client.ObjectKey{Name: "flux-system-webhook-token", Namespace: "flux-system"}
+
+	kube := fake.NewClientBuilder().WithScheme(utils.NewScheme()).Build()
+	token, err := reconcileWebhookToken(context.TODO(), kube, key, log.NopLogger{})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(token).To(HaveLen(64))
+
+	var secret corev1.Secret
+	g.Expect(kube.Get(context.TODO(), key, &secret)).To(Succeed())
+	g.Expect(secret.StringData).To(HaveKeyWithValue(webhookTokenKey, token))
+
+	existing := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+		Data:       map[string][]byte{webhookTokenKey: []byte("existing")},
+	}
+	kube = fake.NewClientBuilder().WithScheme(utils.NewScheme()).WithObjects(existing).Build()
+	token, err = reconcileWebhookToken(context.TODO(), kube, key, log.NopLogger{})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(token).To(Equal("existing"))
+
+	existing.Data = map[string][]byte{"other": []byte("value")}
+	kube = fake.NewClientBuilder().WithScheme(utils.NewScheme()).WithObjects(existing).Build()
+	_, err = reconcileWebhookToken(context.TODO(), kube, key, log.NopLogger{})
+	g.Expect(err).To(HaveOccurred())
+}
+
+type defaultBranchRepository struct {
+	gitprovider.UserRepository
+	defaultBranch *string
+}
+
+func (r defaultBranchRepository) Get() gitprovider.RepositoryInfo {
+	return gitprovider.RepositoryInfo{DefaultBranch: r.defaultBranch}
+}
+
+func TestGitProviderBootstrapper_useRepositoryDefaultBranch(t *testing.T) {
+	tests := []struct {
+		name              string
+		opts              []GitProviderOption
+		defaultBranch     *string
+		wantBranch        string
+		wantDefaultBranch string
+	}{
+		{
+			name:              "branch given",
+			opts:              []GitProviderOption{WithBranch("main")},
+			defaultBranch:     gitprovider.StringVar("develop"),
+			wantBranch:        "main",
+			wantDefaultBranch: "develop",
+		},
+		{
+			name:              "branch detected",
+			opts:              []GitProviderOption{WithBranch("main"), WithDefaultBranchDetection()},
+			defaultBranch:     gitprovider.StringVar("develop"),
+			wantBranch:        "develop",
+			wantDefaultBranch: "develop",
+		},
+		{
+			name:       "no default branch",
+			opts:       []GitProviderOption{WithBranch("main"), WithDefaultBranchDetection()},
+			wantBranch: "main",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			b, err := NewGitProviderBootstrapper(nil, nil, nil, append(tt.opts, WithLogger(log.NopLogger{}))...)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			b.useRepositoryDefaultBranch(defaultBranchRepository{defaultBranch: tt.defaultBranch})
+			g.Expect(b.branch).To(Equal(tt.wantBranch))
+			g.Expect(b.PlainGitBootstrapper.defaultBranch).To(Equal(tt.wantDefaultBranch))
+		})
+	}
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	extgogit "github.com/fluxcd/go-git/v5"
+	"github.com/fluxcd/go-git/v5/config"
+	"github.com/fluxcd/go-git/v5/plumbing"
+	"github.com/fluxcd/go-git/v5/plumbing/transport"
+	"github.com/fluxcd/go-git/v5/plumbing/transport/http"
+	"github.com/fluxcd/go-git/v5/plumbing/transport/ssh"
+	"github.com/fluxcd/go-git/v5/storage/memory"
+	gossh "golang.org/x/crypto/ssh"
+
+	"github.com/fluxcd/pkg/git"
+	"github.com/fluxcd/pkg/ssh/knownhosts"
+)
+
+// DefaultBranch returns the name of the branch the HEAD of the remote Git
+// repository points to. An empty string is returned for an empty
+// repository, or when the server does not advertise its HEAD.
+func DefaultBranch(ctx context.Context, url string, authOpts *git.AuthOptions) (string, error) {
+	authMethod, err := transportAuth(authOpts)
+	if err != nil {
+		return "", fmt.Errorf("unable to construct auth method: %w", err)
+	}
+
+	remote := extgogit.NewRemote(memory.NewStorage(), &config.RemoteConfig{
+		Name: extgogit.DefaultRemoteName,
+		URLs: []string{url},
+	})
+	listOpts := &extgogit.ListOptions{
+		Auth: authMethod,
+	}
+	if authOpts != nil {
+		listOpts.CABundle = authOpts.CAFile
+	}
+	refs, err := remote.ListContext(ctx, listOpts)
+	if err != nil {
+		if errors.Is(err, transport.ErrEmptyRemoteRepository) {
+			return "", nil
+		}
+		return "", fmt.Errorf("unable to list references of '%s': %w", url, err)
+	}
+
+	for _, ref := range refs {
+		if ref.Name() == plumbing.HEAD && ref.Type() == plumbing.SymbolicReference && ref.Target().IsBranch() {
+			return ref.Target().Short(), nil
+		}
+	}
+	return "", nil
+}
+
+// transportAuth returns the go-git authentication method for the given
+// options, mirroring the behaviour of the gogit client.
+func transportAuth(opts *git.AuthOptions) (transport.AuthMethod, error) {
+	if opts == nil {
+		return nil, nil
+	}
+	switch opts.Transport {
+	case git.HTTPS, git.HTTP:
+		if opts.Username != "" || opts.Password != "" {
+			return &http.BasicAuth{
+				Username: opts.Username,
+				Password: opts.Password,
+			}, nil
+		} else if opts.BearerToken != "" {
+			return &http.TokenAuth{
+				Token: opts.BearerToken,
+			}, nil
+		}
+		return nil, nil
+	case git.SSH:
+		if len(opts.Identity) == 0 {
+			return ssh.DefaultAuthBuilder(opts.Username)
+		}
+		pk, err := ssh.NewPublicKeys(opts.Username, opts.Identity, opts.Password)
+		if err != nil {
+			return nil, err
+		}
+		var callback gossh.HostKeyCallback
+		if len(opts.KnownHosts) > 0 {
+			callback, err = knownhosts.New(opts.KnownHosts)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return &publicKeysWithHostKey{PublicKeys: pk, callback: callback}, nil
+	default:
+		return nil, fmt.Errorf("unknown transport '%s'", opts.Transport)
+	}
+}
+
+// publicKeysWithHostKey verifies the host key of the server against the
+// configured known hosts.
+type publicKeysWithHostKey struct {
+	*ssh.PublicKeys
+	callback gossh.HostKeyCallback
+}
+
+func (a *publicKeysWithHostKey) ClientConfig() (*gossh.ClientConfig, error) {
+	config, err := a.PublicKeys.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	if a.callback != nil {
+		config.HostKeyCallback = a.callback
+	}
+	return config, nil
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootstrap
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	extgogit "github.com/fluxcd/go-git/v5"
+	"github.com/fluxcd/go-git/v5/plumbing"
+	"github.com/fluxcd/go-git/v5/plumbing/object"
+	. "github.com/onsi/gomega"
+)
+
+func TestDefaultBranch(t *testing.T) {
+	g := NewWithT(t)
+
+	dir := t.TempDir()
+	repo, err := extgogit.PlainInit(dir, false)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	empty, err := DefaultBranch(context.TODO(), dir, nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(empty).To(BeEmpty())
+
+	g.Expect(os.WriteFile(filepath.Join(dir, "README.md"), []byte("flux"), 0o600)).To(Succeed())
+	wt, err := repo.Worktree()
+	g.Expect(err).ToNot(HaveOccurred())
+	_, err = wt.Add("README.md")
+	g.Expect(err).ToNot(HaveOccurred())
+	commit, err := wt.Commit("initial", &extgogit.CommitOptions{
+		Author: &object.Signature{Name: "flux", Email: "flux@example.com", When: time.Now()},
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName("trunk"), commit))).To(Succeed())
+	g.Expect(repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, plumbing.NewBranchReferenceName("trunk")))).To(Succeed())
+
+	branch, err := DefaultBranch(context.TODO(), dir, nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(branch).To(Equal("trunk"))
+}
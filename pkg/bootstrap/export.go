@@ -0,0 +1,92 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootstrap
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	securejoin "github.com/cyphar/filepath-securejoin"
+	"sigs.k8s.io/kustomize/api/konfig"
+
+	"github.com/fluxcd/pkg/kustomize/filesys"
+
+	"github.com/fluxcd/flux2/pkg/manifestgen/install"
+	"github.com/fluxcd/flux2/pkg/manifestgen/kustomization"
+	"github.com/fluxcd/flux2/pkg/manifestgen/sync"
+)
+
+// Export writes the component and sync manifests a Git bootstrap commits to
+// the repository to the given directory, without pushing or applying them.
+// It returns the paths of the written files, relative to the directory.
+func Export(dir, manifestsBase string, installOpts install.Options, syncOpts sync.Options) ([]string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create export directory: %w", err)
+	}
+
+	components, err := install.Generate(installOpts, manifestsBase)
+	if err != nil {
+		return nil, fmt.Errorf("component manifest generation failed: %w", err)
+	}
+	manifests, err := sync.Generate(syncOpts)
+	if err != nil {
+		return nil, fmt.Errorf("sync manifests generation failed: %w", err)
+	}
+
+	fs, err := filesys.MakeFsOnDiskSecureBuild(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Kustomize file system: %w", err)
+	}
+	for _, m := range []struct{ path, content string }{
+		{components.Path, components.Content},
+		{manifests.Path, manifests.Content},
+	} {
+		path, err := securejoin.SecureJoin(dir, m.path)
+		if err != nil {
+			return nil, err
+		}
+		if err := fs.MkdirAll(filepath.Dir(path)); err != nil {
+			return nil, err
+		}
+		if err := fs.WriteFile(path, []byte(m.content)); err != nil {
+			return nil, err
+		}
+	}
+
+	kusManifests, err := kustomization.Generate(kustomization.Options{
+		FileSystem: fs,
+		BaseDir:    dir,
+		TargetPath: filepath.Dir(manifests.Path),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s generation failed: %w", konfig.DefaultKustomizationFileName(), err)
+	}
+	path, err := securejoin.SecureJoin(dir, kusManifests.Path)
+	if err != nil {
+		return nil, err
+	}
+	if err := fs.WriteFile(path, []byte(kusManifests.Content)); err != nil {
+		return nil, err
+	}
+
+	return []string{components.Path, manifests.Path, kusManifests.Path}, nil
+}
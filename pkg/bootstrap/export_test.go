@@ -0,0 +1,70 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootstrap
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/fluxcd/flux2/pkg/manifestgen/install"
+	"github.com/fluxcd/flux2/pkg/manifestgen/sync"
+)
+
+func TestExport(t *testing.T) {
+	g := NewWithT(t)
+
+	base := t.TempDir()
+	g.Expect(os.WriteFile(filepath.Join(base, "kustomization.yaml"), []byte(`apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+resources:
+- namespace.yaml
+`), 0o600)).To(Succeed())
+	g.Expect(os.WriteFile(filepath.Join(base, "namespace.yaml"), []byte(`apiVersion: v1
+kind: Namespace
+metadata:
+  name: flux-system
+`), 0o600)).To(Succeed())
+
+	installOpts := install.MakeDefaultOptions()
+	installOpts.BaseURL = base
+	installOpts.TargetPath = "clusters/dev"
+	syncOpts := sync.MakeDefaultOptions()
+	syncOpts.URL = "ssh://git@example.com/fleet.git"
+	syncOpts.TargetPath = "clusters/dev"
+
+	dir := t.TempDir()
+	files, err := Export(dir, t.TempDir(), installOpts, syncOpts)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(files).To(Equal([]string{
+		"clusters/dev/flux-system/gotk-components.yaml",
+		"clusters/dev/flux-system/gotk-sync.yaml",
+		"clusters/dev/flux-system/kustomization.yaml",
+	}))
+
+	components, err := os.ReadFile(filepath.Join(dir, files[0]))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(components)).To(ContainSubstring("kind: Namespace"))
+	syncManifest, err := os.ReadFile(filepath.Join(dir, files[1]))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(syncManifest)).To(ContainSubstring("url: ssh://git@example.com/fleet.git"))
+	kustomization, err := os.ReadFile(filepath.Join(dir, files[2]))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(kustomization)).To(ContainSubstring("- gotk-components.yaml\n- gotk-sync.yaml"))
+}
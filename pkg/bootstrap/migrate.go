@@ -0,0 +1,322 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	"github.com/fluxcd/pkg/apis/meta"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+)
+
+// MigrateOptions are the options of the migration of a bootstrapped
+// repository to a new target path or branch.
+type MigrateOptions struct {
+	// Name and Namespace of the GitRepository and the Kustomization syncing
+	// the cluster.
+	Name      string
+	Namespace string
+
+	// Path is the current target path of the cluster in the repository, and
+	// TargetPath the one it is migrated to.
+	Path       string
+	TargetPath string
+
+	// TargetBranch is the branch the cluster is migrated to, the branch of
+	// the bootstrapper is kept when empty.
+	TargetBranch string
+
+	// SyncFile is the name of the file of the sync manifests.
+	SyncFile string
+
+	// Force migrates from or to the root of the repository even though
+	// manifests outside of the Flux directory stop or start being synced.
+	Force bool
+}
+
+// Migrate moves the manifests of the cluster to the target path and branch
+// of the options, rewrites the sync manifests for them, and points the
+// GitRepository and the Kustomization on the cluster to the new location.
+//
+// When the current or the target path is the root of the repository, only
+// the directory of the Flux manifests is moved, the other files are left as
+// is. Otherwise, the whole directory of the current path is moved.
+// As the sync Kustomization prunes, migrating from the root would delete the
+// objects of the other manifests of the repository from the cluster, and
+// migrating to the root would apply all of them: ErrUnmigratedManifests is
+// returned with the list of these manifests unless options.Force is set.
+func (b *PlainGitBootstrapper) Migrate(ctx context.Context, options MigrateOptions) error {
+	from, to := cleanTargetPath(options.Path), cleanTargetPath(options.TargetPath)
+	if options.TargetBranch == "" {
+		options.TargetBranch = b.branch
+	}
+	if from != to && from != "." && to != "." &&
+		(strings.HasPrefix(to+"/", from+"/") || strings.HasPrefix(from+"/", to+"/")) {
+		return fmt.Errorf("target path %q and current path %q must not be nested", options.TargetPath, options.Path)
+	}
+
+	b.logger.Actionf("cloning branch %q from Git repository %q", b.branch, b.url)
+	if err := retry(1, 2*time.Second, func() error {
+		return b.cloneBranch(ctx)
+	}); err != nil {
+		return fmt.Errorf("failed to clone repository: %w", err)
+	}
+	b.logger.Successf("cloned repository")
+
+	if options.TargetBranch != b.branch {
+		b.logger.Actionf("switching to branch %q", options.TargetBranch)
+		if err := b.gitClient.SwitchBranch(ctx, options.TargetBranch); err != nil {
+			return fmt.Errorf("failed to switch to branch %q: %w", options.TargetBranch, err)
+		}
+		b.branch = options.TargetBranch
+	}
+
+	var signer *openpgp.Entity
+	if b.gpgKeyRing != nil {
+		var err error
+		signer, err = getOpenPgpEntity(b.gpgKeyRing, b.gpgPassphrase, b.gpgKeyID)
+		if err != nil {
+			return fmt.Errorf("failed to generate OpenPGP entity: %w", err)
+		}
+	}
+	commitMsg := b.commitMessage(fmt.Sprintf("Migrate Flux sync manifests to %s", to))
+
+	// The manifests are moved again in the fresh clone when the push
+	// conflicts with another commit
+	if err := b.commitAndPush(ctx, "migrated manifests", commitMsg, signer, func() (map[string]io.Reader, error) {
+		return nil, b.migrateManifests(from, to, options)
+	}); err != nil {
+		return err
+	}
+
+	b.logger.Actionf("updating the sync configuration on the cluster")
+	objKey := client.ObjectKey{Name: options.Name, Namespace: options.Namespace}
+	requestedAt := time.Now().Format(time.RFC3339Nano)
+
+	var repo sourcev1.GitRepository
+	if err := b.kube.Get(ctx, objKey, &repo); err != nil {
+		return fmt.Errorf("failed to get GitRepository %q: %w", objKey, err)
+	}
+	repoPatch := client.MergeFrom(repo.DeepCopy())
+	if repo.Spec.Reference == nil {
+		repo.Spec.Reference = &sourcev1.GitRepositoryRef{}
+	}
+	repo.Spec.Reference.Branch = options.TargetBranch
+	setReconcileRequest(&repo, requestedAt)
+	if err := b.kube.Patch(ctx, &repo, repoPatch); err != nil {
+		return fmt.Errorf("failed to update GitRepository %q: %w", objKey, err)
+	}
+
+	var ks kustomizev1.Kustomization
+	if err := b.kube.Get(ctx, objKey, &ks); err != nil {
+		return fmt.Errorf("failed to get Kustomization %q: %w", objKey, err)
+	}
+	ksPatch := client.MergeFrom(ks.DeepCopy())
+	ks.Spec.Path = kustomizationPath(to)
+	setReconcileRequest(&ks, requestedAt)
+	if err := b.kube.Patch(ctx, &ks, ksPatch); err != nil {
+		return fmt.Errorf("failed to update Kustomization %q: %w", objKey, err)
+	}
+	b.logger.Successf("sync configuration migrated to %q on branch %q", ks.Spec.Path, options.TargetBranch)
+
+	return nil
+}
+
+// migrateManifests moves the manifests from the current path to the target
+// path in the worktree, and rewrites the sync manifests. When the manifests
+// are already in the target path, only the sync manifests are rewritten.
+func (b *PlainGitBootstrapper) migrateManifests(from, to string, options MigrateOptions) error {
+	root := b.gitClient.Path()
+	fromDir, toDir := filepath.Join(root, from), filepath.Join(root, to)
+	if from == "." || to == "." {
+		fromDir = filepath.Join(fromDir, options.Namespace)
+		toDir = filepath.Join(toDir, options.Namespace)
+	}
+
+	if from != to && (from == "." || to == ".") && !options.Force {
+		// The manifests outside of the Flux directory are synced either
+		// before or after the migration, but not both
+		others, err := manifestsOutside(root, filepath.Join(root, options.Namespace), fromDir)
+		if err != nil {
+			return err
+		}
+		if len(others) > 0 {
+			what := "would be applied from the root of the repository"
+			if from == "." {
+				what = "would no longer be synced and their objects would be pruned"
+			}
+			return fmt.Errorf("%w: %s %s", ErrUnmigratedManifests, listManifests(others), what)
+		}
+	}
+
+	if from != to {
+		_, fromErr := os.Stat(fromDir)
+		_, toErr := os.Stat(toDir)
+		switch {
+		case fromErr == nil && toErr == nil:
+			return fmt.Errorf("target path %q already exists in the repository", to)
+		case fromErr == nil:
+			b.logger.Actionf("moving %q to %q", strings.TrimPrefix(fromDir, root+string(filepath.Separator)), strings.TrimPrefix(toDir, root+string(filepath.Separator)))
+			if err := os.MkdirAll(filepath.Dir(toDir), 0o755); err != nil {
+				return err
+			}
+			if err := os.Rename(fromDir, toDir); err != nil {
+				return fmt.Errorf("failed to move the manifests: %w", err)
+			}
+		case os.IsNotExist(fromErr) && os.IsNotExist(toErr):
+			return fmt.Errorf("no manifests found in the current path %q on branch %q", from, b.branch)
+		}
+	}
+
+	syncPath := filepath.Join(root, to, options.Namespace, options.SyncFile)
+	data, err := os.ReadFile(syncPath)
+	if err != nil {
+		return fmt.Errorf("failed to read the sync manifests: %w", err)
+	}
+	content, err := migrateSyncManifests(data, options.Name, options.TargetBranch, to)
+	if err != nil {
+		return fmt.Errorf("failed to rewrite %s: %w", options.SyncFile, err)
+	}
+	return os.WriteFile(syncPath, []byte(content), 0o644)
+}
+
+// migrateSyncManifests sets the branch of the GitRepository and the path of
+// the Kustomization named name in the sync manifests, the other fields and
+// the comments are kept as is.
+func migrateSyncManifests(data []byte, name, branch, targetPath string) (string, error) {
+	// The generation warning precedes the first document separator and
+	// would be dropped by the parser
+	var header string
+	if content := string(data); !strings.HasPrefix(content, "---") {
+		if i := strings.Index(content, "\n---"); i >= 0 {
+			header = content[:i+1]
+		}
+	}
+
+	nodes, err := kio.FromBytes(data)
+	if err != nil {
+		return "", err
+	}
+	var foundRepo, foundKs bool
+	for _, node := range nodes {
+		if node.GetName() != name {
+			continue
+		}
+		switch node.GetKind() {
+		case sourcev1.GitRepositoryKind:
+			foundRepo = true
+			if err := node.PipeE(kyaml.LookupCreate(kyaml.MappingNode, "spec", "ref"), kyaml.SetField("branch", kyaml.NewStringRNode(branch))); err != nil {
+				return "", err
+			}
+		case kustomizev1.KustomizationKind:
+			foundKs = true
+			if err := node.PipeE(kyaml.LookupCreate(kyaml.MappingNode, "spec"), kyaml.SetField("path", kyaml.NewStringRNode(kustomizationPath(targetPath)))); err != nil {
+				return "", err
+			}
+		}
+	}
+	if !foundRepo || !foundKs {
+		return "", fmt.Errorf("GitRepository and Kustomization %q not found", name)
+	}
+	content, err := kio.StringAll(nodes)
+	if err != nil {
+		return "", err
+	}
+	if header != "" {
+		content = header + "---\n" + content
+	}
+	return content, nil
+}
+
+// manifestsOutside returns the YAML files of the repository at root, relative
+// to it, which are not in the excluded directories. The hidden directories
+// like .git are skipped.
+func manifestsOutside(root string, excluded ...string) ([]string, error) {
+	var manifests []string
+	err := filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != root && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			for _, dir := range excluded {
+				if p == dir {
+					return filepath.SkipDir
+				}
+			}
+			return nil
+		}
+		if ext := filepath.Ext(p); ext == ".yaml" || ext == ".yml" {
+			rel, err := filepath.Rel(root, p)
+			if err != nil {
+				return err
+			}
+			manifests = append(manifests, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	return manifests, err
+}
+
+// listManifests returns the first manifests of the list joined by commas.
+func listManifests(manifests []string) string {
+	const max = 10
+	if len(manifests) <= max {
+		return strings.Join(manifests, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(manifests[:max], ", "), len(manifests)-max)
+}
+
+// cleanTargetPath returns the target path relative to the root of the
+// repository, without the leading './'.
+func cleanTargetPath(p string) string {
+	return path.Clean(strings.TrimPrefix(filepath.ToSlash(p), "./"))
+}
+
+// kustomizationPath returns the Kustomization path of the cleaned target
+// path, in the format of the generated sync manifests.
+func kustomizationPath(targetPath string) string {
+	if targetPath == "." {
+		return "./"
+	}
+	return "./" + targetPath
+}
+
+// setReconcileRequest annotates the object to be reconciled at once.
+func setReconcileRequest(obj client.Object, requestedAt string) {
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[meta.ReconcileRequestAnnotation] = requestedAt
+	obj.SetAnnotations(annotations)
+}
@@ -0,0 +1,261 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootstrap
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	extgogit "github.com/fluxcd/go-git/v5"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/fluxcd/flux2/internal/utils"
+	"github.com/fluxcd/flux2/pkg/log"
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/fluxcd/pkg/git"
+	"github.com/fluxcd/pkg/git/gogit"
+	"github.com/fluxcd/pkg/git/repository"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+)
+
+const testSyncManifests = `# This manifest was generated by flux. DO NOT EDIT.
+---
+apiVersion: source.toolkit.fluxcd.io/v1beta2
+kind: GitRepository
+metadata:
+  name: flux-system
+  namespace: flux-system
+spec:
+  interval: 1m0s
+  ref:
+    branch: main
+  secretRef:
+    name: flux-system
+  url: ssh://git@example.com/org/fleet
+---
+apiVersion: kustomize.toolkit.fluxcd.io/v1beta2
+kind: Kustomization
+metadata:
+  name: flux-system
+  namespace: flux-system
+spec:
+  interval: 10m0s
+  path: ./clusters/staging
+  prune: true
+  sourceRef:
+    kind: GitRepository
+    name: flux-system
+`
+
+func Test_migrateSyncManifests(t *testing.T) {
+	g := NewWithT(t)
+
+	content, err := migrateSyncManifests([]byte(testSyncManifests), "flux-system", "production", "clusters/production")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(content).To(ContainSubstring("# This manifest was generated by flux. DO NOT EDIT."))
+	g.Expect(content).To(ContainSubstring("branch: production"))
+	g.Expect(content).To(ContainSubstring("path: ./clusters/production"))
+	g.Expect(content).To(ContainSubstring("url: ssh://git@example.com/org/fleet"))
+
+	content, err = migrateSyncManifests([]byte(testSyncManifests), "flux-system", "main", ".")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(content).To(ContainSubstring("path: ./\n"))
+
+	_, err = migrateSyncManifests([]byte(testSyncManifests), "other", "main", ".")
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestPlainGitBootstrapper_Migrate(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	remote := t.TempDir()
+	_, err := extgogit.PlainInit(remote, true)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	newClient := func(path string) (repository.Client, error) {
+		return gogit.NewClient(path, &git.AuthOptions{Transport: git.HTTP}, gogit.WithDiskStorage())
+	}
+	seed, err := newClient(t.TempDir())
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(seed.Init(ctx, remote, "main")).To(Succeed())
+	_, err = seed.Commit(git.Commit{
+		Author:  git.Signature{Name: "Flux", Email: "flux@example.com"},
+		Message: "Add Flux sync manifests",
+	}, repository.WithFiles(map[string]io.Reader{
+		"README.md": strings.NewReader("fleet"),
+		"clusters/staging/flux-system/gotk-sync.yaml": strings.NewReader(testSyncManifests),
+		"clusters/staging/apps.yaml":                  strings.NewReader("apps"),
+	}))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(seed.Push(ctx)).To(Succeed())
+
+	objMeta := metav1.ObjectMeta{Name: "flux-system", Namespace: "flux-system"}
+	kube := fake.NewClientBuilder().WithScheme(utils.NewScheme()).WithObjects(
+		&sourcev1.GitRepository{
+			ObjectMeta: objMeta,
+			Spec:       sourcev1.GitRepositorySpec{Reference: &sourcev1.GitRepositoryRef{Branch: "main"}},
+		},
+		&kustomizev1.Kustomization{
+			ObjectMeta: objMeta,
+			Spec:       kustomizev1.KustomizationSpec{Path: "./clusters/staging"},
+		},
+	).Build()
+
+	bootstrapClient, err := newClient(t.TempDir())
+	g.Expect(err).ToNot(HaveOccurred())
+	b, err := NewPlainGitProvider(bootstrapClient, kube,
+		WithRepositoryURL(remote), WithBranch("main"), WithLogger(log.NopLogger{}),
+		WithSignature("Flux", "flux@example.com"), WithGitClientFactory(newClient))
+	g.Expect(err).ToNot(HaveOccurred())
+
+	options := MigrateOptions{
+		Name:         "flux-system",
+		Namespace:    "flux-system",
+		Path:         "./clusters/staging",
+		TargetPath:   "clusters/production",
+		TargetBranch: "production",
+		SyncFile:     "gotk-sync.yaml",
+	}
+	g.Expect(b.Migrate(ctx, options)).To(Succeed())
+	g.Expect(b.Commits()).To(HaveLen(1))
+
+	// The cluster directory is moved on the new branch
+	verify, err := newClient(t.TempDir())
+	g.Expect(err).ToNot(HaveOccurred())
+	_, err = verify.Clone(ctx, remote, repository.CloneOptions{
+		CheckoutStrategy: repository.CheckoutStrategy{Branch: "production"},
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	for _, file := range []string{"README.md", "clusters/production/apps.yaml"} {
+		_, err := os.Stat(filepath.Join(verify.Path(), file))
+		g.Expect(err).ToNot(HaveOccurred(), file)
+	}
+	_, err = os.Stat(filepath.Join(verify.Path(), "clusters/staging"))
+	g.Expect(os.IsNotExist(err)).To(BeTrue())
+	data, err := os.ReadFile(filepath.Join(verify.Path(), "clusters/production/flux-system/gotk-sync.yaml"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(data)).To(ContainSubstring("branch: production"))
+	g.Expect(string(data)).To(ContainSubstring("path: ./clusters/production"))
+
+	// The cluster objects point to the new location
+	objKey := client.ObjectKey{Name: "flux-system", Namespace: "flux-system"}
+	var repo sourcev1.GitRepository
+	g.Expect(kube.Get(ctx, objKey, &repo)).To(Succeed())
+	g.Expect(repo.Spec.Reference.Branch).To(Equal("production"))
+	g.Expect(repo.GetAnnotations()).To(HaveKey(meta.ReconcileRequestAnnotation))
+	var ks kustomizev1.Kustomization
+	g.Expect(kube.Get(ctx, objKey, &ks)).To(Succeed())
+	g.Expect(ks.Spec.Path).To(Equal("./clusters/production"))
+	g.Expect(ks.GetAnnotations()).To(HaveKey(meta.ReconcileRequestAnnotation))
+
+	// Nested paths are rejected
+	options.Path, options.TargetPath = "clusters", "clusters/production"
+	g.Expect(b.Migrate(ctx, options)).ToNot(Succeed())
+}
+
+func TestPlainGitBootstrapper_MigrateFromRoot(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	remote := t.TempDir()
+	_, err := extgogit.PlainInit(remote, true)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	newClient := func(path string) (repository.Client, error) {
+		return gogit.NewClient(path, &git.AuthOptions{Transport: git.HTTP}, gogit.WithDiskStorage())
+	}
+	seed, err := newClient(t.TempDir())
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(seed.Init(ctx, remote, "main")).To(Succeed())
+	_, err = seed.Commit(git.Commit{
+		Author:  git.Signature{Name: "Flux", Email: "flux@example.com"},
+		Message: "Add Flux sync manifests",
+	}, repository.WithFiles(map[string]io.Reader{
+		"README.md":                        strings.NewReader("fleet"),
+		"flux-system/gotk-sync.yaml":       strings.NewReader(strings.ReplaceAll(testSyncManifests, "./clusters/staging", "./")),
+		"apps/podinfo.yaml":                strings.NewReader("podinfo"),
+		"infrastructure/ingress-nginx.yml": strings.NewReader("ingress-nginx"),
+	}))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(seed.Push(ctx)).To(Succeed())
+
+	objMeta := metav1.ObjectMeta{Name: "flux-system", Namespace: "flux-system"}
+	kube := fake.NewClientBuilder().WithScheme(utils.NewScheme()).WithObjects(
+		&sourcev1.GitRepository{
+			ObjectMeta: objMeta,
+			Spec:       sourcev1.GitRepositorySpec{Reference: &sourcev1.GitRepositoryRef{Branch: "main"}},
+		},
+		&kustomizev1.Kustomization{
+			ObjectMeta: objMeta,
+			Spec:       kustomizev1.KustomizationSpec{Path: "./"},
+		},
+	).Build()
+	newBootstrapper := func() *PlainGitBootstrapper {
+		c, err := newClient(t.TempDir())
+		g.Expect(err).ToNot(HaveOccurred())
+		b, err := NewPlainGitProvider(c, kube,
+			WithRepositoryURL(remote), WithBranch("main"), WithLogger(log.NopLogger{}),
+			WithSignature("Flux", "flux@example.com"), WithGitClientFactory(newClient))
+		g.Expect(err).ToNot(HaveOccurred())
+		return b
+	}
+	options := MigrateOptions{
+		Name:       "flux-system",
+		Namespace:  "flux-system",
+		Path:       "./",
+		TargetPath: "clusters/production",
+		SyncFile:   "gotk-sync.yaml",
+	}
+
+	// The other manifests of the root would be pruned
+	b := newBootstrapper()
+	err = b.Migrate(ctx, options)
+	g.Expect(err).To(MatchError(ErrUnmigratedManifests))
+	g.Expect(err.Error()).To(ContainSubstring("apps/podinfo.yaml, infrastructure/ingress-nginx.yml would no longer be synced"))
+	g.Expect(b.Commits()).To(BeEmpty())
+	var ks kustomizev1.Kustomization
+	g.Expect(kube.Get(ctx, client.ObjectKeyFromObject(&kustomizev1.Kustomization{ObjectMeta: objMeta}), &ks)).To(Succeed())
+	g.Expect(ks.Spec.Path).To(Equal("./"))
+
+	// Only the Flux directory is moved when forced
+	options.Force = true
+	b = newBootstrapper()
+	g.Expect(b.Migrate(ctx, options)).To(Succeed())
+	g.Expect(b.Commits()).To(HaveLen(1))
+
+	verify, err := newClient(t.TempDir())
+	g.Expect(err).ToNot(HaveOccurred())
+	_, err = verify.Clone(ctx, remote, repository.CloneOptions{
+		CheckoutStrategy: repository.CheckoutStrategy{Branch: "main"},
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	for _, file := range []string{"apps/podinfo.yaml", "infrastructure/ingress-nginx.yml", "clusters/production/flux-system/gotk-sync.yaml"} {
+		_, err := os.Stat(filepath.Join(verify.Path(), file))
+		g.Expect(err).ToNot(HaveOccurred(), file)
+	}
+	g.Expect(kube.Get(ctx, client.ObjectKeyFromObject(&kustomizev1.Kustomization{ObjectMeta: objMeta}), &ks)).To(Succeed())
+	g.Expect(ks.Spec.Path).To(Equal("./clusters/production"))
+}
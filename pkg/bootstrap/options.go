@@ -20,10 +20,12 @@ import (
 	"fmt"
 	"os"
 
+	"golang.org/x/crypto/ssh"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 
 	"github.com/ProtonMail/go-crypto/openpgp"
 	"github.com/fluxcd/pkg/git"
+	"github.com/fluxcd/pkg/git/repository"
 	runclient "github.com/fluxcd/pkg/runtime/client"
 
 	"github.com/fluxcd/flux2/pkg/log"
@@ -34,6 +36,12 @@ type Option interface {
 	GitProviderOption
 }
 
+// CommonOption is an Option which also applies to the OCIBootstrapper.
+type CommonOption interface {
+	Option
+	OCIOption
+}
+
 func WithBranch(branch string) Option {
 	return branchOption(branch)
 }
@@ -48,6 +56,22 @@ func (o branchOption) applyGitProvider(b *GitProviderBootstrapper) {
 	o.applyGit(b.PlainGitBootstrapper)
 }
 
+// WithDefaultBranch sets the default branch of the Git repository, used to
+// create the configured branch when it does not exist yet.
+func WithDefaultBranch(branch string) Option {
+	return defaultBranchOption(branch)
+}
+
+type defaultBranchOption string
+
+func (o defaultBranchOption) applyGit(b *PlainGitBootstrapper) {
+	b.defaultBranch = string(o)
+}
+
+func (o defaultBranchOption) applyGitProvider(b *GitProviderBootstrapper) {
+	o.applyGit(b.PlainGitBootstrapper)
+}
+
 func WithSignature(name, email string) Option {
 	return signatureOption{
 		Name:  name,
@@ -70,6 +94,38 @@ func (o signatureOption) applyGitProvider(b *GitProviderBootstrapper) {
 	o.applyGit(b.PlainGitBootstrapper)
 }
 
+// WithShallowClone clones the branch with a depth of one, instead of
+// cloning its full history.
+func WithShallowClone(shallow bool) Option {
+	return shallowCloneOption(shallow)
+}
+
+type shallowCloneOption bool
+
+func (o shallowCloneOption) applyGit(b *PlainGitBootstrapper) {
+	b.shallowClone = bool(o)
+}
+
+func (o shallowCloneOption) applyGitProvider(b *GitProviderBootstrapper) {
+	o.applyGit(b.PlainGitBootstrapper)
+}
+
+// WithSignoff appends a Signed-off-by trailer for the commit author to
+// the commit messages.
+func WithSignoff(signoff bool) Option {
+	return signoffOption(signoff)
+}
+
+type signoffOption bool
+
+func (o signoffOption) applyGit(b *PlainGitBootstrapper) {
+	b.signoff = bool(o)
+}
+
+func (o signoffOption) applyGitProvider(b *GitProviderBootstrapper) {
+	o.applyGit(b.PlainGitBootstrapper)
+}
+
 func WithCommitMessageAppendix(appendix string) Option {
 	return commitMessageAppendixOption(appendix)
 }
@@ -84,7 +140,7 @@ func (o commitMessageAppendixOption) applyGitProvider(b *GitProviderBootstrapper
 	o.applyGit(b.PlainGitBootstrapper)
 }
 
-func WithKubeconfig(rcg genericclioptions.RESTClientGetter, opts *runclient.Options) Option {
+func WithKubeconfig(rcg genericclioptions.RESTClientGetter, opts *runclient.Options) CommonOption {
 	return kubeconfigOption{
 		rcg:  rcg,
 		opts: opts,
@@ -105,7 +161,12 @@ func (o kubeconfigOption) applyGitProvider(b *GitProviderBootstrapper) {
 	o.applyGit(b.PlainGitBootstrapper)
 }
 
-func WithLogger(logger log.Logger) Option {
+func (o kubeconfigOption) applyOCI(b *OCIBootstrapper) {
+	b.restClientGetter = o.rcg
+	b.restClientOptions = o.opts
+}
+
+func WithLogger(logger log.Logger) CommonOption {
 	return loggerOption{logger}
 }
 
@@ -121,6 +182,10 @@ func (o loggerOption) applyGitProvider(b *GitProviderBootstrapper) {
 	b.logger = o.logger
 }
 
+func (o loggerOption) applyOCI(b *OCIBootstrapper) {
+	b.logger = o.logger
+}
+
 func WithGitCommitSigning(gpgKeyRing openpgp.EntityList, passphrase, keyID string) Option {
 	return gitCommitSigningOption{
 		gpgKeyRing:    gpgKeyRing,
@@ -145,6 +210,75 @@ func (o gitCommitSigningOption) applyGitProvider(b *GitProviderBootstrapper) {
 	o.applyGit(b.PlainGitBootstrapper)
 }
 
+// WithSSHCommitSigning configures the bootstrapper to sign the commits with
+// the SSH key, as Git does with gpg.format set to ssh.
+func WithSSHCommitSigning(signer ssh.Signer) Option {
+	return sshCommitSigningOption{signer: signer}
+}
+
+type sshCommitSigningOption struct {
+	signer ssh.Signer
+}
+
+func (o sshCommitSigningOption) applyGit(b *PlainGitBootstrapper) {
+	b.sshSigner = o.signer
+}
+
+func (o sshCommitSigningOption) applyGitProvider(b *GitProviderBootstrapper) {
+	o.applyGit(b.PlainGitBootstrapper)
+}
+
+// WithExistingSourceSecret configures the bootstrapper to use the source
+// secret found on the cluster instead of generating one, the secret is
+// verified against the sync URL before the components are installed.
+func WithExistingSourceSecret() Option {
+	return existingSourceSecretOption(true)
+}
+
+type existingSourceSecretOption bool
+
+func (o existingSourceSecretOption) applyGit(b *PlainGitBootstrapper) {
+	b.existingSecret = bool(o)
+}
+
+func (o existingSourceSecretOption) applyGitProvider(b *GitProviderBootstrapper) {
+	o.applyGit(b.PlainGitBootstrapper)
+}
+
+// WithSecretless configures the bootstrapper to not generate a source
+// secret, as source-controller authenticates to the Git server with the
+// workload identity of the provider set in the sync options.
+func WithSecretless() Option {
+	return secretlessOption(true)
+}
+
+type secretlessOption bool
+
+func (o secretlessOption) applyGit(b *PlainGitBootstrapper) {
+	b.secretless = bool(o)
+}
+
+func (o secretlessOption) applyGitProvider(b *GitProviderBootstrapper) {
+	o.applyGit(b.PlainGitBootstrapper)
+}
+
+// WithSOPSAge configures the bootstrapper to generate an age key in the
+// decryption secret of the sync options and to commit a SOPS creation rule
+// for it to the repository.
+func WithSOPSAge() Option {
+	return sopsAgeOption(true)
+}
+
+type sopsAgeOption bool
+
+func (o sopsAgeOption) applyGit(b *PlainGitBootstrapper) {
+	b.sopsAge = bool(o)
+}
+
+func (o sopsAgeOption) applyGitProvider(b *GitProviderBootstrapper) {
+	o.applyGit(b.PlainGitBootstrapper)
+}
+
 func LoadEntityListFromPath(path string) (openpgp.EntityList, error) {
 	if path == "" {
 		return nil, nil
@@ -159,3 +293,22 @@ func LoadEntityListFromPath(path string) (openpgp.EntityList, error) {
 	}
 	return entityList, nil
 }
+
+// GitClientFactory returns a new Git client for the repository at path.
+type GitClientFactory func(path string) (repository.Client, error)
+
+// WithGitClientFactory configures the factory of the Git client used to
+// clone the repository again when a push is rejected as non-fast-forward.
+func WithGitClientFactory(f GitClientFactory) Option {
+	return gitClientFactoryOption(f)
+}
+
+type gitClientFactoryOption GitClientFactory
+
+func (o gitClientFactoryOption) applyGit(b *PlainGitBootstrapper) {
+	b.newGitClient = GitClientFactory(o)
+}
+
+func (o gitClientFactoryOption) applyGitProvider(b *GitProviderBootstrapper) {
+	o.applyGit(b.PlainGitBootstrapper)
+}
@@ -19,6 +19,7 @@ package provider
 import (
 	"fmt"
 
+	"github.com/fluxcd/go-git-providers/gitea"
 	"github.com/fluxcd/go-git-providers/github"
 	"github.com/fluxcd/go-git-providers/gitlab"
 	"github.com/fluxcd/go-git-providers/gitprovider"
@@ -31,10 +32,12 @@ import (
 func BuildGitProvider(config Config) (gitprovider.Client, error) {
 	var client gitprovider.Client
 	var err error
+	rateLimit := gitprovider.WithPreChainTransportHook(rateLimitTransportHook(config.Logger))
 	switch config.Provider {
 	case GitProviderGitHub:
 		opts := []gitprovider.ClientOption{
 			gitprovider.WithOAuth2Token(config.Token),
+			rateLimit,
 		}
 		if config.Hostname != "" {
 			opts = append(opts, gitprovider.WithDomain(config.Hostname))
@@ -48,6 +51,7 @@ func BuildGitProvider(config Config) (gitprovider.Client, error) {
 	case GitProviderGitLab:
 		opts := []gitprovider.ClientOption{
 			gitprovider.WithConditionalRequests(true),
+			rateLimit,
 		}
 		if config.Hostname != "" {
 			opts = append(opts, gitprovider.WithDomain(config.Hostname))
@@ -59,7 +63,7 @@ func BuildGitProvider(config Config) (gitprovider.Client, error) {
 			return nil, err
 		}
 	case GitProviderStash:
-		opts := []gitprovider.ClientOption{}
+		opts := []gitprovider.ClientOption{rateLimit}
 		if config.Hostname != "" {
 			opts = append(opts, gitprovider.WithDomain(config.Hostname))
 		}
@@ -69,6 +73,17 @@ func BuildGitProvider(config Config) (gitprovider.Client, error) {
 		if client, err = stash.NewStashClient(config.Username, config.Token, opts...); err != nil {
 			return nil, err
 		}
+	case GitProviderGitea:
+		opts := []gitprovider.ClientOption{rateLimit}
+		if config.Hostname != "" {
+			opts = append(opts, gitprovider.WithDomain(config.Hostname))
+		}
+		if config.CaBundle != nil {
+			opts = append(opts, gitprovider.WithCustomCAPostChainTransportHook(config.CaBundle))
+		}
+		if client, err = gitea.NewClient(config.Token, opts...); err != nil {
+			return nil, err
+		}
 	default:
 		return nil, fmt.Errorf("unsupported Git provider '%s'", config.Provider)
 	}
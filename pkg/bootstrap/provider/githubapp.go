@@ -0,0 +1,123 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// GitHubAppConfig defines the GitHub App credentials used to mint
+// installation access tokens.
+type GitHubAppConfig struct {
+	// AppID is the ID of the GitHub App.
+	AppID string
+
+	// InstallationID is the ID of the installation of the App on the
+	// organization or user owning the repository.
+	InstallationID string
+
+	// PrivateKey is the PEM encoded private key of the App.
+	PrivateKey []byte
+
+	// BaseURL is the URL of the GitHub API,
+	// e.g. https://api.github.com or https://github.example.com/api/v3.
+	BaseURL string
+
+	// CaBundle contains the CA bundle to use for the client.
+	CaBundle []byte
+}
+
+// GitHubAppBaseURL returns the URL of the GitHub API for the hostname,
+// the hostname of GitHub Enterprise Server exposes it under /api/v3.
+func GitHubAppBaseURL(hostname string) string {
+	if hostname == "" || hostname == "github.com" {
+		return "https://api.github.com"
+	}
+	return fmt.Sprintf("https://%s/api/v3", hostname)
+}
+
+// GitHubAppToken mints an installation access token for the GitHub App,
+// the token expires after one hour.
+func GitHubAppToken(ctx context.Context, config GitHubAppConfig) (string, error) {
+	if _, err := strconv.ParseInt(config.AppID, 10, 64); err != nil {
+		return "", fmt.Errorf("invalid GitHub App ID %q", config.AppID)
+	}
+	if _, err := strconv.ParseInt(config.InstallationID, 10, 64); err != nil {
+		return "", fmt.Errorf("invalid GitHub App installation ID %q", config.InstallationID)
+	}
+	key, err := jwt.ParseRSAPrivateKeyFromPEM(config.PrivateKey)
+	if err != nil {
+		return "", fmt.Errorf("invalid GitHub App private key: %w", err)
+	}
+
+	// The issued at time is set in the past to allow for clock drift
+	now := time.Now()
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.RegisteredClaims{
+		Issuer:    config.AppID,
+		IssuedAt:  jwt.NewNumericDate(now.Add(-time.Minute)),
+		ExpiresAt: jwt.NewNumericDate(now.Add(9 * time.Minute)),
+	}).SignedString(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign GitHub App JWT: %w", err)
+	}
+
+	u := fmt.Sprintf("%s/app/installations/%s/access_tokens", strings.TrimSuffix(config.BaseURL, "/"), config.InstallationID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+signed)
+
+	client := http.DefaultClient
+	if len(config.CaBundle) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(config.CaBundle) {
+			return "", fmt.Errorf("invalid CA bundle")
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+		client = &http.Client{Transport: transport}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to request GitHub App installation token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Token   string `json:"token"`
+		Message string `json:"message"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil && resp.StatusCode == http.StatusCreated {
+		return "", fmt.Errorf("failed to decode GitHub App installation token: %w", err)
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("failed to request GitHub App installation token: %s %s", resp.Status, result.Message)
+	}
+	return result.Token, nil
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v4"
+	. "github.com/onsi/gomega"
+)
+
+func TestGitHubAppToken(t *testing.T) {
+	g := NewWithT(t)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	g.Expect(err).ToNot(HaveOccurred())
+	privateKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/app/installations/456/access_tokens" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var claims jwt.RegisteredClaims
+		_, err := jwt.ParseWithClaims(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), &claims,
+			func(*jwt.Token) (interface{}, error) { return &key.PublicKey, nil })
+		if err != nil || claims.Issuer != "123" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"message":"A JSON web token could not be decoded"}`))
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"token":"ghs_token","expires_at":"2023-01-01T00:00:00Z"}`))
+	}))
+	defer srv.Close()
+
+	config := GitHubAppConfig{
+		AppID:          "123",
+		InstallationID: "456",
+		PrivateKey:     privateKey,
+		BaseURL:        srv.URL,
+	}
+	token, err := GitHubAppToken(context.TODO(), config)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(token).To(Equal("ghs_token"))
+
+	config.AppID = "789"
+	_, err = GitHubAppToken(context.TODO(), config)
+	g.Expect(err).To(MatchError(ContainSubstring("401 Unauthorized A JSON web token could not be decoded")))
+
+	config.AppID = "app"
+	_, err = GitHubAppToken(context.TODO(), config)
+	g.Expect(err).To(MatchError(`invalid GitHub App ID "app"`))
+
+	g.Expect(GitHubAppBaseURL("")).To(Equal("https://api.github.com"))
+	g.Expect(GitHubAppBaseURL("github.example.com")).To(Equal("https://github.example.com/api/v3"))
+}
@@ -16,6 +16,8 @@ limitations under the License.
 
 package provider
 
+import "github.com/fluxcd/flux2/pkg/log"
+
 // GitProvider holds a Git provider definition.
 type GitProvider string
 
@@ -23,6 +25,7 @@ const (
 	GitProviderGitHub GitProvider = "github"
 	GitProviderGitLab GitProvider = "gitlab"
 	GitProviderStash  GitProvider = "stash"
+	GitProviderGitea  GitProvider = "gitea"
 )
 
 // Config defines the configuration for connecting to a GitProvider.
@@ -44,4 +47,8 @@ type Config struct {
 
 	// CABunle contains the CA bundle to use for the client.
 	CaBundle []byte
+
+	// Logger is used to report the wait for the Provider API
+	// rate limit to reset.
+	Logger log.Logger
 }
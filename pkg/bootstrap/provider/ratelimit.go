@@ -0,0 +1,160 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+
+	"github.com/fluxcd/flux2/pkg/log"
+)
+
+// rateLimitMaxRetries is the number of times a throttled request is retried.
+const rateLimitMaxRetries = 5
+
+// rateLimitTransport waits for the rate limit of the Git provider API to
+// reset when a request is throttled, and retries it. The wait duration is
+// taken from the Retry-After header, or from the reset time of the
+// X-RateLimit (GitHub, Gitea) and RateLimit (GitLab) headers.
+type rateLimitTransport struct {
+	next   http.RoundTripper
+	logger log.Logger
+	now    func() time.Time
+}
+
+// rateLimitTransportHook returns a ChainableRoundTripperFunc wrapping the
+// transport of a provider client with a rateLimitTransport.
+func rateLimitTransportHook(logger log.Logger) gitprovider.ChainableRoundTripperFunc {
+	if logger == nil {
+		logger = log.NopLogger{}
+	}
+	return func(in http.RoundTripper) http.RoundTripper {
+		if in == nil {
+			in = http.DefaultTransport
+		}
+		return &rateLimitTransport{next: in, logger: logger, now: time.Now}
+	}
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for retry := 0; ; retry++ {
+		resp, err := t.next.RoundTrip(req)
+		if err != nil {
+			return resp, err
+		}
+
+		wait, throttled := t.rateLimitWait(resp)
+		if !throttled {
+			if wait <= 0 || !t.canWait(req, wait) {
+				return resp, nil
+			}
+			// The quota was exhausted by this request, wait for the reset
+			// before returning so that the next request is not throttled.
+			t.logger.Waitingf("API rate limit of %s exhausted, waiting %s for it to reset", req.URL.Host, wait.Round(time.Second))
+			if err := t.sleep(req, wait); err != nil {
+				resp.Body.Close()
+				return nil, err
+			}
+			return resp, nil
+		}
+
+		if wait < 0 {
+			wait = 0
+		}
+		if retry == rateLimitMaxRetries || !t.canWait(req, wait) || (req.Body != nil && req.GetBody == nil) {
+			t.logger.Warningf("API rate limit of %s exceeded, resets in %s", req.URL.Host, wait.Round(time.Second))
+			return resp, nil
+		}
+		resp.Body.Close()
+
+		t.logger.Waitingf("API rate limit of %s exceeded, retrying in %s", req.URL.Host, wait.Round(time.Second))
+		if err := t.sleep(req, wait); err != nil {
+			return nil, err
+		}
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// rateLimitWait returns how long to wait before sending another request,
+// and whether the response was throttled.
+func (t *rateLimitTransport) rateLimitWait(resp *http.Response) (time.Duration, bool) {
+	throttled := resp.StatusCode == http.StatusTooManyRequests
+	remaining := firstHeader(resp.Header, "X-RateLimit-Remaining", "RateLimit-Remaining")
+	if resp.StatusCode == http.StatusForbidden && (remaining == "0" || resp.Header.Get("Retry-After") != "") {
+		throttled = true
+	}
+
+	if throttled {
+		if v := resp.Header.Get("Retry-After"); v != "" {
+			if seconds, err := strconv.Atoi(v); err == nil {
+				return time.Duration(seconds) * time.Second, true
+			}
+			if date, err := http.ParseTime(v); err == nil {
+				return date.Sub(t.now()), true
+			}
+		}
+	} else if remaining != "0" || resp.StatusCode >= 300 {
+		return 0, false
+	}
+
+	reset, err := strconv.ParseInt(firstHeader(resp.Header, "X-RateLimit-Reset", "RateLimit-Reset"), 10, 64)
+	if err != nil {
+		if throttled {
+			// Throttled without a hint, back off for a minute
+			return time.Minute, true
+		}
+		return 0, false
+	}
+	return time.Unix(reset, 0).Sub(t.now()), throttled
+}
+
+// canWait returns false when waiting would exceed the deadline of the
+// request context.
+func (t *rateLimitTransport) canWait(req *http.Request, wait time.Duration) bool {
+	deadline, ok := req.Context().Deadline()
+	return !ok || t.now().Add(wait).Before(deadline)
+}
+
+func (t *rateLimitTransport) sleep(req *http.Request, wait time.Duration) error {
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-req.Context().Done():
+		return req.Context().Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func firstHeader(h http.Header, keys ...string) string {
+	for _, k := range keys {
+		if v := h.Get(k); v != "" {
+			return v
+		}
+	}
+	return ""
+}
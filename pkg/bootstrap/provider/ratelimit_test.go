@@ -0,0 +1,113 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestRateLimitTransport(t *testing.T) {
+	tests := []struct {
+		name       string
+		throttle   func(w http.ResponseWriter)
+		timeout    time.Duration
+		wantStatus int
+		wantCalls  int
+	}{
+		{
+			name: "retries after Retry-After",
+			throttle: func(w http.ResponseWriter) {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+			},
+			wantStatus: http.StatusOK,
+			wantCalls:  2,
+		},
+		{
+			name: "retries after X-RateLimit-Reset",
+			throttle: func(w http.ResponseWriter) {
+				w.Header().Set("X-RateLimit-Remaining", "0")
+				w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Unix(), 10))
+				w.WriteHeader(http.StatusForbidden)
+			},
+			wantStatus: http.StatusOK,
+			wantCalls:  2,
+		},
+		{
+			name: "does not retry other errors",
+			throttle: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusForbidden)
+			},
+			wantStatus: http.StatusForbidden,
+			wantCalls:  1,
+		},
+		{
+			name: "does not wait past the deadline",
+			throttle: func(w http.ResponseWriter) {
+				w.Header().Set("Retry-After", "3600")
+				w.WriteHeader(http.StatusTooManyRequests)
+			},
+			timeout:    time.Minute,
+			wantStatus: http.StatusTooManyRequests,
+			wantCalls:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			calls := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				body, _ := io.ReadAll(r.Body)
+				g.Expect(string(body)).To(Equal("payload"))
+				if calls == 1 {
+					tt.throttle(w)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer srv.Close()
+
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL, strings.NewReader("payload"))
+			g.Expect(err).ToNot(HaveOccurred())
+
+			client := &http.Client{Transport: rateLimitTransportHook(nil)(nil)}
+			resp, err := client.Do(req)
+			g.Expect(err).ToNot(HaveOccurred())
+			defer resp.Body.Close()
+			g.Expect(resp.StatusCode).To(Equal(tt.wantStatus))
+			g.Expect(calls).To(Equal(tt.wantCalls))
+		})
+	}
+}
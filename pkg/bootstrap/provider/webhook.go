@@ -0,0 +1,108 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/google/go-github/v55/github"
+	"github.com/xanzy/go-gitlab"
+)
+
+// ReconcileWebhook makes sure the repository has a webhook sending the push
+// events to the URL, signed or authenticated with the secret. The webhook is
+// matched by its URL, an existing one is left as is. It returns true if the
+// webhook was created.
+//
+// Only the GitHub and GitLab providers are supported, go-git-providers has no
+// webhook API, the webhook is configured with their API clients.
+func ReconcileWebhook(ctx context.Context, client gitprovider.Client, repo gitprovider.RepositoryRef, url, secret string) (bool, error) {
+	switch c := client.Raw().(type) {
+	case *github.Client:
+		return reconcileGitHubWebhook(ctx, c, repo.GetIdentity(), repo.GetRepository(), url, secret)
+	case *gitlab.Client:
+		return reconcileGitLabWebhook(ctx, c, repo.GetIdentity()+"/"+repo.GetRepository(), url, secret)
+	default:
+		return false, fmt.Errorf("webhooks are not supported for the Git provider '%s'", client.ProviderID())
+	}
+}
+
+func reconcileGitHubWebhook(ctx context.Context, c *github.Client, owner, repo, url, secret string) (bool, error) {
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		hooks, resp, err := c.Repositories.ListHooks(ctx, owner, repo, opts)
+		if err != nil {
+			return false, fmt.Errorf("failed to list the webhooks of %s/%s: %w", owner, repo, err)
+		}
+		for _, hook := range hooks {
+			if hookURL, _ := hook.Config["url"].(string); hookURL == url {
+				return false, nil
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	_, _, err := c.Repositories.CreateHook(ctx, owner, repo, &github.Hook{
+		Config: map[string]interface{}{
+			"url":          url,
+			"content_type": "json",
+			"secret":       secret,
+		},
+		Events: []string{"push"},
+		Active: github.Bool(true),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to create the webhook of %s/%s: %w", owner, repo, err)
+	}
+	return true, nil
+}
+
+func reconcileGitLabWebhook(ctx context.Context, c *gitlab.Client, project, url, secret string) (bool, error) {
+	opts := &gitlab.ListProjectHooksOptions{PerPage: 100}
+	for {
+		hooks, resp, err := c.Projects.ListProjectHooks(project, opts, gitlab.WithContext(ctx))
+		if err != nil {
+			return false, fmt.Errorf("failed to list the webhooks of %s: %w", project, err)
+		}
+		for _, hook := range hooks {
+			if hook.URL == url {
+				return false, nil
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	_, _, err := c.Projects.AddProjectHook(project, &gitlab.AddProjectHookOptions{
+		URL:                   gitlab.String(url),
+		Token:                 gitlab.String(secret),
+		PushEvents:            gitlab.Bool(true),
+		TagPushEvents:         gitlab.Bool(true),
+		EnableSSLVerification: gitlab.Bool(true),
+	}, gitlab.WithContext(ctx))
+	if err != nil {
+		return false, fmt.Errorf("failed to create the webhook of %s: %w", project, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-github/v55/github"
+	. "github.com/onsi/gomega"
+	"github.com/xanzy/go-gitlab"
+)
+
+func TestReconcileGitHubWebhook(t *testing.T) {
+	g := NewWithT(t)
+
+	var hooks []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.Expect(r.URL.Path).To(Equal("/repos/org/fleet/hooks"))
+		switch r.Method {
+		case http.MethodGet:
+			g.Expect(json.NewEncoder(w).Encode(hooks)).To(Succeed())
+		case http.MethodPost:
+			var hook map[string]interface{}
+			g.Expect(json.NewDecoder(r.Body).Decode(&hook)).To(Succeed())
+			hooks = append(hooks, hook)
+			w.WriteHeader(http.StatusCreated)
+			g.Expect(json.NewEncoder(w).Encode(hook)).To(Succeed())
+		}
+	}))
+	defer server.Close()
+
+	c := github.NewClient(nil)
+	c.BaseURL, _ = url.Parse(server.URL + "/")
+
+	hookURL := "https://flux.example.com/hook/abc"
+	changed, err := reconcileGitHubWebhook(context.TODO(), c, "org", "fleet", hookURL, "token")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(changed).To(BeTrue())
+	g.Expect(hooks).To(HaveLen(1))
+	g.Expect(hooks[0]["config"]).To(HaveKeyWithValue("url", hookURL))
+	g.Expect(hooks[0]["config"]).To(HaveKeyWithValue("secret", "token"))
+	g.Expect(hooks[0]["events"]).To(ConsistOf("push"))
+
+	changed, err = reconcileGitHubWebhook(context.TODO(), c, "org", "fleet", hookURL, "token")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(changed).To(BeFalse())
+	g.Expect(hooks).To(HaveLen(1))
+}
+
+func TestReconcileGitLabWebhook(t *testing.T) {
+	g := NewWithT(t)
+
+	var hooks []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.Expect(r.URL.EscapedPath()).To(Equal("/api/v4/projects/group%2Fsub%2Ffleet/hooks"))
+		switch r.Method {
+		case http.MethodGet:
+			g.Expect(json.NewEncoder(w).Encode(hooks)).To(Succeed())
+		case http.MethodPost:
+			var hook map[string]interface{}
+			g.Expect(json.NewDecoder(r.Body).Decode(&hook)).To(Succeed())
+			hooks = append(hooks, hook)
+			w.WriteHeader(http.StatusCreated)
+			g.Expect(json.NewEncoder(w).Encode(hook)).To(Succeed())
+		}
+	}))
+	defer server.Close()
+
+	c, err := gitlab.NewClient("", gitlab.WithBaseURL(server.URL))
+	g.Expect(err).ToNot(HaveOccurred())
+
+	hookURL := "https://flux.example.com/hook/abc"
+	changed, err := reconcileGitLabWebhook(context.TODO(), c, "group/sub/fleet", hookURL, "token")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(changed).To(BeTrue())
+	g.Expect(hooks).To(HaveLen(1))
+	g.Expect(hooks[0]).To(HaveKeyWithValue("url", hookURL))
+	g.Expect(hooks[0]).To(HaveKeyWithValue("token", "token"))
+	g.Expect(hooks[0]).To(HaveKeyWithValue("push_events", true))
+
+	changed, err = reconcileGitLabWebhook(context.TODO(), c, "group/sub/fleet", hookURL, "token")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(changed).To(BeFalse())
+	g.Expect(hooks).To(HaveLen(1))
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootstrap
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"filippo.io/age"
+	corev1 "k8s.io/api/core/v1"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/fluxcd/flux2/pkg/log"
+	"github.com/fluxcd/flux2/pkg/manifestgen/sync"
+)
+
+const (
+	// sopsAgeKeyName is the key of the age identity in the decryption
+	// secret, kustomize-controller imports the keys with the .agekey suffix.
+	sopsAgeKeyName = "age.agekey"
+
+	// sopsConfigFile is the SOPS configuration at the root of the repository.
+	sopsConfigFile = ".sops.yaml"
+
+	// sopsEncryptedRegex restricts the encryption to the data of the
+	// Kubernetes secrets, so that the manifests stay readable.
+	sopsEncryptedRegex = "^(data|stringData)$"
+)
+
+// reconcileSOPSConfig makes sure the decryption secret of the sync options
+// holds an age key and writes the creation rule encrypting the manifests of
+// the target path for it to the SOPS configuration of the repository.
+func (b *PlainGitBootstrapper) reconcileSOPSConfig(ctx context.Context, options sync.Options) error {
+	if options.DecryptionSecret == "" {
+		return fmt.Errorf("a decryption secret is required to set up SOPS with age")
+	}
+
+	secretKey := client.ObjectKey{Name: options.DecryptionSecret, Namespace: options.Namespace}
+	recipient, err := reconcileSOPSAgeKey(ctx, b.kube, secretKey, b.logger)
+	if err != nil {
+		return err
+	}
+
+	configPath := filepath.Join(b.gitClient.Path(), sopsConfigFile)
+	data, err := os.ReadFile(configPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", sopsConfigFile, err)
+	}
+	config, err := sopsConfig(data, options.TargetPath, recipient)
+	if err != nil {
+		return fmt.Errorf("failed to update %s: %w", sopsConfigFile, err)
+	}
+	if err := os.WriteFile(configPath, config, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", sopsConfigFile, err)
+	}
+	b.logger.Successf("generated %s creation rule for age recipient %s", sopsConfigFile, recipient)
+	return nil
+}
+
+// reconcileSOPSAgeKey generates an age identity in the secret when it doesn't
+// exist and returns the recipient of the identity. The identity of an existing
+// secret is kept, the files encrypted for it could not be decrypted otherwise.
+func reconcileSOPSAgeKey(ctx context.Context, kube client.Client, key client.ObjectKey, logger log.Logger) (string, error) {
+	var secret corev1.Secret
+	err := kube.Get(ctx, key, &secret)
+	switch {
+	case err == nil:
+		identities, err := age.ParseIdentities(bytes.NewReader(secret.Data[sopsAgeKeyName]))
+		if err != nil {
+			return "", fmt.Errorf("failed to parse the age key '%s' of secret %q: %w", sopsAgeKeyName, key, err)
+		}
+		for _, identity := range identities {
+			if x25519, ok := identity.(*age.X25519Identity); ok {
+				logger.Successf("using the age key of secret %q", key)
+				return x25519.Recipient().String(), nil
+			}
+		}
+		return "", fmt.Errorf("no X25519 age key found in '%s' of secret %q", sopsAgeKeyName, key)
+	case !apierr.IsNotFound(err):
+		return "", fmt.Errorf("failed to get decryption secret %q: %w", key, err)
+	}
+
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate age key: %w", err)
+	}
+	recipient := identity.Recipient().String()
+	secret = corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      key.Name,
+			Namespace: key.Namespace,
+		},
+		StringData: map[string]string{
+			sopsAgeKeyName: fmt.Sprintf("# public key: %s\n%s\n", recipient, identity.String()),
+		},
+	}
+	if err := kube.Create(ctx, &secret); err != nil {
+		return "", fmt.Errorf("failed to create decryption secret %q: %w", key, err)
+	}
+	logger.Successf("generated age key in secret %q", key)
+	logger.Warningf("back up the age key of secret %q, the encrypted files can't be decrypted without it", key)
+	return recipient, nil
+}
+
+// sopsConfig returns the SOPS configuration with a creation rule encrypting
+// the YAML files of the target path for the age recipient. The rules of the
+// existing configuration are kept, a previous rule for the same path is
+// replaced and the new rule is put first as SOPS uses the first match.
+func sopsConfig(data []byte, targetPath, recipient string) ([]byte, error) {
+	config := map[string]interface{}{}
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			return nil, err
+		}
+	}
+
+	pathRegex := `\.ya?ml$`
+	if p := strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(targetPath)), "/"); p != "" {
+		pathRegex = regexp.QuoteMeta(p) + `/.*` + pathRegex
+	}
+	rules := []interface{}{
+		map[string]interface{}{
+			"path_regex":      pathRegex,
+			"encrypted_regex": sopsEncryptedRegex,
+			"age":             recipient,
+		},
+	}
+	existing, _ := config["creation_rules"].([]interface{})
+	for _, rule := range existing {
+		if r, ok := rule.(map[string]interface{}); ok && r["path_regex"] == pathRegex {
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	config["creation_rules"] = rules
+
+	return yaml.Marshal(config)
+}
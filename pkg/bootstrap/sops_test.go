@@ -0,0 +1,119 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootstrap
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/yaml"
+
+	"github.com/fluxcd/flux2/internal/utils"
+	"github.com/fluxcd/flux2/pkg/log"
+)
+
+func Test_reconcileSOPSAgeKey(t *testing.T) {
+	g := NewWithT(t)
+	key := client.ObjectKey{Name: "sops-age", Namespace: "flux-system"}
+
+	kube := fake.NewClientBuilder().WithScheme(utils.NewScheme()).Build()
+	recipient, err := reconcileSOPSAgeKey(context.TODO(), kube, key, log.NopLogger{})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(recipient).To(HavePrefix("age1"))
+
+	var secret corev1.Secret
+	g.Expect(kube.Get(context.TODO(), key, &secret)).To(Succeed())
+	identities, err := age.ParseIdentities(strings.NewReader(secret.StringData[sopsAgeKeyName]))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(identities).To(HaveLen(1))
+	g.Expect(identities[0].(*age.X25519Identity).Recipient().String()).To(Equal(recipient))
+
+	identity, err := age.GenerateX25519Identity()
+	g.Expect(err).ToNot(HaveOccurred())
+	existing := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+		Data:       map[string][]byte{sopsAgeKeyName: []byte(identity.String())},
+	}
+	kube = fake.NewClientBuilder().WithScheme(utils.NewScheme()).WithObjects(existing).Build()
+	recipient, err = reconcileSOPSAgeKey(context.TODO(), kube, key, log.NopLogger{})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(recipient).To(Equal(identity.Recipient().String()))
+
+	existing.Data = map[string][]byte{"identity.asc": []byte("gpg")}
+	kube = fake.NewClientBuilder().WithScheme(utils.NewScheme()).WithObjects(existing).Build()
+	_, err = reconcileSOPSAgeKey(context.TODO(), kube, key, log.NopLogger{})
+	g.Expect(err).To(HaveOccurred())
+}
+
+func Test_sopsConfig(t *testing.T) {
+	tests := []struct {
+		name       string
+		existing   string
+		targetPath string
+		want       []map[string]string
+	}{
+		{
+			name:       "root path",
+			targetPath: "./",
+			want: []map[string]string{
+				{"path_regex": `\.ya?ml$`, "encrypted_regex": sopsEncryptedRegex, "age": "age1new"},
+			},
+		},
+		{
+			name:       "cluster path",
+			targetPath: "./clusters/my-cluster",
+			want: []map[string]string{
+				{"path_regex": `clusters/my-cluster/.*\.ya?ml$`, "encrypted_regex": sopsEncryptedRegex, "age": "age1new"},
+			},
+		},
+		{
+			name: "existing rules",
+			existing: `creation_rules:
+- path_regex: clusters/my-cluster/.*\.ya?ml$
+  age: age1old
+- path_regex: apps/.*\.ya?ml$
+  pgp: ABCDEF
+`,
+			targetPath: "clusters/my-cluster",
+			want: []map[string]string{
+				{"path_regex": `clusters/my-cluster/.*\.ya?ml$`, "encrypted_regex": sopsEncryptedRegex, "age": "age1new"},
+				{"path_regex": `apps/.*\.ya?ml$`, "pgp": "ABCDEF"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			data, err := sopsConfig([]byte(tt.existing), tt.targetPath, "age1new")
+			g.Expect(err).ToNot(HaveOccurred())
+
+			var config struct {
+				CreationRules []map[string]string `json:"creation_rules"`
+			}
+			g.Expect(yaml.Unmarshal(data, &config)).To(Succeed())
+			g.Expect(config.CreationRules).To(Equal(tt.want))
+		})
+	}
+}
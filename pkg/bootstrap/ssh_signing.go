@@ -0,0 +1,157 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootstrap
+
+import (
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	extgogit "github.com/fluxcd/go-git/v5"
+	"github.com/fluxcd/go-git/v5/plumbing"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	// sshSigMagic is the preamble of the SSH signatures, see
+	// https://github.com/openssh/openssh-portable/blob/master/PROTOCOL.sshsig
+	sshSigMagic = "SSHSIG"
+	// sshSigNamespace is the namespace Git signs and verifies commits with.
+	sshSigNamespace = "git"
+	sshSigHash      = "sha512"
+	sshSigVersion   = 1
+)
+
+// LoadSSHSignerFromPath reads the SSH private key at path, decrypted with the
+// password if it is encrypted. It returns nil if path is empty.
+func LoadSSHSignerFromPath(path, password string) (ssh.Signer, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read SSH signing key: %w", err)
+	}
+	var signer ssh.Signer
+	if password != "" {
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(data, []byte(password))
+	} else {
+		signer, err = ssh.ParsePrivateKey(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse SSH signing key: %w", err)
+	}
+	return signer, nil
+}
+
+// sshSignHead replaces the HEAD commit of the repository at path with the
+// same commit signed with the SSH key, and returns the hash of the signed
+// commit. The branch HEAD points to is updated to the signed commit.
+func sshSignHead(path string, signer ssh.Signer) (string, error) {
+	repo, err := extgogit.PlainOpen(path)
+	if err != nil {
+		return "", err
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return "", err
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := &plumbing.MemoryObject{}
+	if err := commit.EncodeWithoutSignature(unsigned); err != nil {
+		return "", err
+	}
+	r, err := unsigned.Reader()
+	if err != nil {
+		return "", err
+	}
+	payload, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	signature, err := sshSign(signer, payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign commit with SSH key: %w", err)
+	}
+
+	signed := *commit
+	signed.PGPSignature = signature
+	obj := repo.Storer.NewEncodedObject()
+	if err := signed.Encode(obj); err != nil {
+		return "", err
+	}
+	hash, err := repo.Storer.SetEncodedObject(obj)
+	if err != nil {
+		return "", err
+	}
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(head.Name(), hash)); err != nil {
+		return "", err
+	}
+	return hash.String(), nil
+}
+
+// sshSign returns the armored SSH signature of the message in the Git
+// namespace, in the format of 'ssh-keygen -Y sign'.
+func sshSign(signer ssh.Signer, message []byte) (string, error) {
+	digest := sha512.Sum512(message)
+	signedData := append([]byte(sshSigMagic), ssh.Marshal(struct {
+		Namespace     string
+		Reserved      string
+		HashAlgorithm string
+		Hash          []byte
+	}{sshSigNamespace, "", sshSigHash, digest[:]})...)
+
+	var sig *ssh.Signature
+	var err error
+	if as, ok := signer.(ssh.AlgorithmSigner); ok && signer.PublicKey().Type() == ssh.KeyAlgoRSA {
+		// SHA-1 RSA signatures are rejected by the Git hosts
+		sig, err = as.SignWithAlgorithm(rand.Reader, signedData, ssh.KeyAlgoRSASHA512)
+	} else {
+		sig, err = signer.Sign(rand.Reader, signedData)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	blob := append([]byte(sshSigMagic), ssh.Marshal(struct {
+		Version       uint32
+		PublicKey     []byte
+		Namespace     string
+		Reserved      string
+		HashAlgorithm string
+		Signature     []byte
+	}{sshSigVersion, signer.PublicKey().Marshal(), sshSigNamespace, "", sshSigHash, ssh.Marshal(sig)})...)
+
+	encoded := base64.StdEncoding.EncodeToString(blob)
+	var b strings.Builder
+	b.WriteString("-----BEGIN SSH SIGNATURE-----\n")
+	for len(encoded) > 70 {
+		b.WriteString(encoded[:70] + "\n")
+		encoded = encoded[70:]
+	}
+	b.WriteString(encoded + "\n")
+	b.WriteString("-----END SSH SIGNATURE-----\n")
+	return b.String(), nil
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootstrap
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"io"
+	"strings"
+	"testing"
+
+	extgogit "github.com/fluxcd/go-git/v5"
+	"github.com/fluxcd/go-git/v5/plumbing"
+	. "github.com/onsi/gomega"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/fluxcd/flux2/pkg/log"
+	"github.com/fluxcd/pkg/git"
+	"github.com/fluxcd/pkg/git/gogit"
+)
+
+func TestPlainGitBootstrapper_sshCommitSigning(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	g.Expect(err).ToNot(HaveOccurred())
+	signer, err := ssh.NewSignerFromKey(key)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	remote := t.TempDir()
+	_, err = extgogit.PlainInit(remote, true)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	c, err := gogit.NewClient(t.TempDir(), &git.AuthOptions{Transport: git.HTTP}, gogit.WithDiskStorage())
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Init(ctx, remote, "main")).To(Succeed())
+
+	b, err := NewPlainGitProvider(c, nil,
+		WithRepositoryURL(remote), WithBranch("main"), WithLogger(log.NopLogger{}),
+		WithSignature("Flux", "flux@example.com"), WithSSHCommitSigning(signer))
+	g.Expect(err).ToNot(HaveOccurred())
+	err = b.commitAndPush(ctx, "sync manifests", "Add Flux sync manifests", nil, func() (map[string]io.Reader, error) {
+		return map[string]io.Reader{"gotk-sync.yaml": strings.NewReader("sync")}, nil
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(b.Commits()).To(HaveLen(1))
+
+	// The pushed commit is the signed one
+	repo, err := extgogit.PlainOpen(remote)
+	g.Expect(err).ToNot(HaveOccurred())
+	ref, err := repo.Reference(plumbing.NewBranchReferenceName("main"), true)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(ref.Hash().String()).To(Equal(b.Commits()[0]))
+	commit, err := repo.CommitObject(ref.Hash())
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(commit.Message).To(Equal("Add Flux sync manifests"))
+
+	// The signature verifies against the public key
+	armored := strings.TrimSpace(commit.PGPSignature)
+	g.Expect(armored).To(HavePrefix("-----BEGIN SSH SIGNATURE-----\n"))
+	g.Expect(armored).To(HaveSuffix("\n-----END SSH SIGNATURE-----"))
+	encoded := strings.TrimSuffix(strings.TrimPrefix(armored, "-----BEGIN SSH SIGNATURE-----\n"), "\n-----END SSH SIGNATURE-----")
+	blob, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(encoded, "\n", ""))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(blob[:len(sshSigMagic)])).To(Equal(sshSigMagic))
+
+	var sigBlob struct {
+		Version       uint32
+		PublicKey     []byte
+		Namespace     string
+		Reserved      string
+		HashAlgorithm string
+		Signature     []byte
+	}
+	g.Expect(ssh.Unmarshal(blob[len(sshSigMagic):], &sigBlob)).To(Succeed())
+	g.Expect(sigBlob.Namespace).To(Equal("git"))
+	g.Expect(sigBlob.PublicKey).To(Equal(signer.PublicKey().Marshal()))
+	var sig ssh.Signature
+	g.Expect(ssh.Unmarshal(sigBlob.Signature, &sig)).To(Succeed())
+
+	unsigned := &plumbing.MemoryObject{}
+	g.Expect(commit.EncodeWithoutSignature(unsigned)).To(Succeed())
+	r, err := unsigned.Reader()
+	g.Expect(err).ToNot(HaveOccurred())
+	payload, err := io.ReadAll(r)
+	g.Expect(err).ToNot(HaveOccurred())
+	digest := sha512.Sum512(payload)
+	signedData := append([]byte(sshSigMagic), ssh.Marshal(struct {
+		Namespace     string
+		Reserved      string
+		HashAlgorithm string
+		Hash          []byte
+	}{"git", "", "sha512", digest[:]})...)
+	g.Expect(signer.PublicKey().Verify(signedData, &sig)).To(Succeed())
+}
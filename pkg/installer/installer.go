@@ -0,0 +1,247 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package installer provides a programmatic API for installing and
+// bootstrapping Flux, for use by tools such as cluster provisioners and
+// test frameworks which would otherwise shell out to the flux binary.
+package installer
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"sigs.k8s.io/cli-utils/pkg/object"
+
+	"github.com/fluxcd/pkg/git"
+	"github.com/fluxcd/pkg/git/gogit"
+	"github.com/fluxcd/pkg/ssa"
+
+	"github.com/fluxcd/flux2/internal/utils"
+	"github.com/fluxcd/flux2/pkg/bootstrap"
+	"github.com/fluxcd/flux2/pkg/manifestgen"
+	"github.com/fluxcd/flux2/pkg/manifestgen/install"
+	"github.com/fluxcd/flux2/pkg/manifestgen/sourcesecret"
+	"github.com/fluxcd/flux2/pkg/manifestgen/sync"
+	"github.com/fluxcd/flux2/pkg/status"
+)
+
+// Generate returns the install manifests for the given options,
+// the equivalent of 'flux install --export'.
+func Generate(opts ...Option) (*manifestgen.Manifest, error) {
+	o := makeOptions(opts...)
+	return generate(o)
+}
+
+func generate(o options) (*manifestgen.Manifest, error) {
+	if err := utils.ValidateComponents(o.install.Components); err != nil {
+		return nil, err
+	}
+	manifest, err := install.Generate(o.install, o.manifestsBase)
+	if err != nil {
+		return nil, fmt.Errorf("generating install manifests failed: %w", err)
+	}
+	return manifest, nil
+}
+
+// Install installs or upgrades Flux on the cluster the RESTClientGetter
+// points to, and waits for the components to become healthy,
+// the equivalent of 'flux install'.
+func Install(ctx context.Context, rcg genericclioptions.RESTClientGetter, opts ...Option) error {
+	o := makeOptions(opts...)
+	o.install.ManifestFile = fmt.Sprintf("%s.yaml", o.install.Namespace)
+	opts = append(opts, WithInstallOptions(o.install))
+
+	o.logger.Generatef("generating manifests")
+	manifest, err := generate(o)
+	if err != nil {
+		return err
+	}
+
+	o.logger.Actionf("installing components in %s namespace", o.install.Namespace)
+	if _, err := Apply(ctx, rcg, manifest, opts...); err != nil {
+		return fmt.Errorf("install failed: %w", err)
+	}
+
+	o.logger.Waitingf("verifying installation")
+	if err := WaitForComponents(rcg, opts...); err != nil {
+		return fmt.Errorf("install failed: %w", err)
+	}
+
+	o.logger.Successf("install finished")
+	return nil
+}
+
+// Apply applies the install manifests returned by Generate on the cluster
+// the RESTClientGetter points to, and returns the change set with the
+// action taken for each object.
+func Apply(ctx context.Context, rcg genericclioptions.RESTClientGetter, manifest *manifestgen.Manifest, opts ...Option) (*ssa.ChangeSet, error) {
+	o := makeOptions(opts...)
+
+	tmpDir, err := manifestgen.MkdirTempAbs("", o.install.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if _, err := manifest.WriteFile(tmpDir); err != nil {
+		return nil, err
+	}
+	return utils.ApplyChangeSet(ctx, rcg, o.clientOptions, tmpDir, filepath.Join(tmpDir, manifest.Path))
+}
+
+// WaitForComponents waits for the Deployments of the components to become
+// healthy, polling at the poll interval until the timeout.
+func WaitForComponents(rcg genericclioptions.RESTClientGetter, opts ...Option) error {
+	o := makeOptions(opts...)
+
+	kubeConfig, err := utils.KubeConfig(rcg, o.clientOptions)
+	if err != nil {
+		return err
+	}
+	statusChecker, err := status.NewStatusChecker(kubeConfig, o.pollInterval, o.install.Timeout, o.logger)
+	if err != nil {
+		return err
+	}
+
+	var identifiers []object.ObjMetadata
+	for _, component := range o.install.Components {
+		identifiers = append(identifiers, object.ObjMetadata{
+			Namespace: o.install.Namespace,
+			Name:      component,
+			GroupKind: schema.GroupKind{Group: "apps", Kind: "Deployment"},
+		})
+	}
+	return statusChecker.Assess(identifiers...)
+}
+
+// Bootstrap commits the Flux manifests to the given Git repository and
+// configures the cluster the RESTClientGetter points to to synchronize
+// with it, the equivalent of 'flux bootstrap git'. HTTP/S repositories
+// require WithBasicAuth, SSH repositories require WithPrivateKey.
+func Bootstrap(ctx context.Context, rcg genericclioptions.RESTClientGetter, repositoryURL string, opts ...Option) error {
+	o := makeOptions(opts...)
+	if err := utils.ValidateComponents(o.install.Components); err != nil {
+		return err
+	}
+
+	repoURL, err := url.Parse(repositoryURL)
+	if err != nil {
+		return fmt.Errorf("invalid repository URL: %w", err)
+	}
+
+	secretOpts := sourcesecret.Options{
+		Name:         o.secretName,
+		Namespace:    o.install.Namespace,
+		TargetPath:   o.path,
+		ManifestFile: sourcesecret.MakeDefaultOptions().ManifestFile,
+		CAFile:       o.caBundle,
+	}
+	authData := map[string][]byte{
+		"caFile": o.caBundle,
+	}
+	syncURL := *repoURL
+
+	switch git.TransportType(repoURL.Scheme) {
+	case git.HTTP, git.HTTPS:
+		if o.password == "" {
+			return fmt.Errorf("basic auth credentials are required for %s repositories", repoURL.Scheme)
+		}
+		authData["username"] = []byte(o.username)
+		authData["password"] = []byte(o.password)
+		secretOpts.Username = o.username
+		secretOpts.Password = o.password
+		syncURL.User = nil
+	case git.SSH:
+		if len(o.privateKey) == 0 {
+			return fmt.Errorf("a private key is required for SSH repositories")
+		}
+		knownHosts, err := sourcesecret.ScanHostKey(repoURL.Host)
+		if err != nil {
+			return err
+		}
+		authData["identity"] = o.privateKey
+		authData["known_hosts"] = knownHosts
+		authData["password"] = []byte(o.password)
+
+		keypair, err := sourcesecret.LoadKeyPair(o.privateKey, o.password)
+		if err != nil {
+			return err
+		}
+		secretOpts.Keypair = keypair
+		secretOpts.Password = o.password
+		secretOpts.SSHHostname = repoURL.Host
+		if syncURL.User == nil {
+			syncURL.User = url.User(git.DefaultPublicKeyAuthUser)
+		}
+	default:
+		return fmt.Errorf("scheme %q is not supported", repoURL.Scheme)
+	}
+
+	authOpts, err := git.NewAuthOptions(*repoURL, authData)
+	if err != nil {
+		return fmt.Errorf("failed to create authentication options for %s: %w", repositoryURL, err)
+	}
+
+	tmpDir, err := manifestgen.MkdirTempAbs("", "flux-bootstrap-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary working dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	clientOpts := []gogit.ClientOption{gogit.WithDiskStorage()}
+	if repoURL.Scheme == string(git.HTTP) {
+		clientOpts = append(clientOpts, gogit.WithInsecureCredentialsOverHTTP())
+	}
+	gitClient, err := gogit.NewClient(tmpDir, authOpts, clientOpts...)
+	if err != nil {
+		return fmt.Errorf("failed to create a Git client: %w", err)
+	}
+
+	kubeClient, err := utils.KubeClient(rcg, o.clientOptions)
+	if err != nil {
+		return err
+	}
+
+	o.install.TargetPath = o.path
+	syncOpts := sync.Options{
+		Interval:     o.interval,
+		Name:         o.install.Namespace,
+		Namespace:    o.install.Namespace,
+		URL:          syncURL.String(),
+		Branch:       o.branch,
+		Secret:       o.secretName,
+		TargetPath:   o.path,
+		ManifestFile: sync.MakeDefaultOptions().ManifestFile,
+	}
+
+	b, err := bootstrap.NewPlainGitProvider(gitClient, kubeClient,
+		bootstrap.WithRepositoryURL(repositoryURL),
+		bootstrap.WithBranch(o.branch),
+		bootstrap.WithSignature(o.authorName, o.authorEmail),
+		bootstrap.WithKubeconfig(rcg, o.clientOptions),
+		bootstrap.WithLogger(o.logger),
+	)
+	if err != nil {
+		return err
+	}
+
+	return bootstrap.Run(ctx, b, o.manifestsBase, o.install, secretOpts, syncOpts, o.pollInterval, o.install.Timeout)
+}
@@ -0,0 +1,96 @@
+//go:build e2e
+// +build e2e
+
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package installer
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/fluxcd/pkg/ssa"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/fluxcd/flux2/internal/utils"
+	"github.com/fluxcd/flux2/pkg/log"
+	"github.com/fluxcd/flux2/pkg/uninstall"
+)
+
+func TestInstall(t *testing.T) {
+	kubeconfig := os.Getenv("TEST_KUBECONFIG")
+	if kubeconfig == "" {
+		t.Fatal("environment variable TEST_KUBECONFIG is required to run tests against an existing cluster")
+	}
+	rcg := genericclioptions.NewConfigFlags(false)
+	rcg.KubeConfig = &kubeconfig
+
+	// The controllers watch their namespace only, so that they do not
+	// reconcile the objects of the instance installed by the CLI tests.
+	namespace := "flux-installer-e2e"
+	opts := []Option{
+		WithNamespace(namespace),
+		WithComponents("source-controller", "kustomize-controller"),
+		WithWatchAllNamespaces(false),
+		WithTimeout(5 * time.Minute),
+	}
+
+	kubeClient, err := utils.KubeClient(rcg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		ctx := context.Background()
+		_ = uninstall.Components(ctx, log.NopLogger{}, kubeClient, namespace, false)
+		_ = uninstall.Namespace(ctx, log.NopLogger{}, kubeClient, namespace, false)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+	if err := Install(ctx, rcg, opts...); err != nil {
+		t.Fatalf("install failed: %s", err)
+	}
+
+	for _, name := range []string{"source-controller", "kustomize-controller"} {
+		var d appsv1.Deployment
+		if err := kubeClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &d); err != nil {
+			t.Fatalf("expected deployment %s to be installed: %s", name, err)
+		}
+		if d.Status.ReadyReplicas == 0 {
+			t.Errorf("expected deployment %s to be ready", name)
+		}
+	}
+
+	// Installing the same version again changes nothing
+	manifest, err := Generate(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changeSet, err := Apply(ctx, rcg, manifest, opts...)
+	if err != nil {
+		t.Fatalf("upgrade failed: %s", err)
+	}
+	for _, entry := range changeSet.Entries {
+		if entry.Action != string(ssa.UnchangedAction) {
+			t.Errorf("expected %s to be unchanged, got %s", entry.Subject, entry.Action)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+//go:build !e2e
+// +build !e2e
+
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package installer
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	"github.com/fluxcd/flux2/pkg/manifestgen"
+	"github.com/fluxcd/flux2/pkg/manifestgen/install"
+)
+
+func TestMakeOptions(t *testing.T) {
+	o := makeOptions()
+	if o.install.Namespace != "flux-system" || o.secretName != "flux-system" {
+		t.Errorf("unexpected default namespace %q and secret %q", o.install.Namespace, o.secretName)
+	}
+	if len(o.install.ComponentsExtra) != 0 {
+		t.Errorf("expected no extra components by default, got %v", o.install.ComponentsExtra)
+	}
+
+	o = makeOptions(
+		WithNamespace("flux"),
+		WithComponents("source-controller", "kustomize-controller"),
+		WithComponentsExtra("image-reflector-controller"),
+		WithVersion("v0.40.0"),
+		WithTimeout(time.Minute),
+		WithBranch("dev"),
+		WithPath("clusters/e2e"),
+		WithBasicAuth("user", "token"),
+	)
+	if o.install.Namespace != "flux" || o.secretName != "flux" {
+		t.Errorf("unexpected namespace %q and secret %q", o.install.Namespace, o.secretName)
+	}
+	wantComponents := []string{"source-controller", "kustomize-controller", "image-reflector-controller"}
+	if !reflect.DeepEqual(o.install.Components, wantComponents) {
+		t.Errorf("got components %v, want %v", o.install.Components, wantComponents)
+	}
+	if o.install.Version != "v0.40.0" || o.install.Timeout != time.Minute {
+		t.Errorf("unexpected version %q and timeout %s", o.install.Version, o.install.Timeout)
+	}
+	if o.branch != "dev" || o.path != "clusters/e2e" || o.username != "user" || o.password != "token" {
+		t.Errorf("unexpected bootstrap options %+v", o)
+	}
+
+	installOpts := install.MakeDefaultOptions()
+	installOpts.Namespace = "flux-e2e"
+	installOpts.PriorityClassName = "system-cluster-critical"
+	o = makeOptions(WithInstallOptions(installOpts))
+	if !reflect.DeepEqual(o.install, installOpts) || o.secretName != "flux-e2e" {
+		t.Errorf("unexpected install options %+v and secret %q", o.install, o.secretName)
+	}
+
+	o = makeOptions(WithSecretName("git-auth"), WithNamespace("flux"))
+	if o.secretName != "git-auth" {
+		t.Errorf("explicit secret name was overridden: %q", o.secretName)
+	}
+}
+
+func TestGenerate_InvalidComponent(t *testing.T) {
+	_, err := Generate(WithComponents("unknown-controller"))
+	if err == nil {
+		t.Fatal("expected error for unknown component")
+	}
+}
+
+func TestApply_NoObjects(t *testing.T) {
+	manifest := &manifestgen.Manifest{Path: "flux-system.yaml", Content: "# no objects\n"}
+	_, err := Apply(context.TODO(), genericclioptions.NewConfigFlags(false), manifest)
+	if err == nil || !strings.Contains(err.Error(), "no Kubernetes objects found") {
+		t.Errorf("got error %v, want no Kubernetes objects found", err)
+	}
+}
+
+func TestBootstrap_Credentials(t *testing.T) {
+	rcg := genericclioptions.NewConfigFlags(false)
+	tests := []struct {
+		name    string
+		url     string
+		opts    []Option
+		wantErr string
+	}{
+		{
+			name:    "https without credentials",
+			url:     "https://example.com/org/fleet",
+			wantErr: "basic auth credentials are required",
+		},
+		{
+			name:    "ssh without private key",
+			url:     "ssh://git@example.com/org/fleet",
+			wantErr: "a private key is required",
+		},
+		{
+			name:    "unsupported scheme",
+			url:     "ftp://example.com/org/fleet",
+			wantErr: "scheme \"ftp\" is not supported",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Bootstrap(context.TODO(), rcg, tt.url, tt.opts...)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,288 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package installer
+
+import (
+	"time"
+
+	runclient "github.com/fluxcd/pkg/runtime/client"
+
+	"github.com/fluxcd/flux2/pkg/log"
+	"github.com/fluxcd/flux2/pkg/manifestgen/install"
+	"github.com/fluxcd/flux2/pkg/manifestgen/sync"
+)
+
+// Option configures an install or bootstrap performed by this package.
+// The options mirror the flags of the flux install and bootstrap commands.
+type Option func(*options)
+
+type options struct {
+	install       install.Options
+	manifestsBase string
+	clientOptions *runclient.Options
+	logger        log.Logger
+	pollInterval  time.Duration
+
+	branch      string
+	path        string
+	interval    time.Duration
+	secretName  string
+	username    string
+	password    string
+	privateKey  []byte
+	caBundle    []byte
+	authorName  string
+	authorEmail string
+}
+
+func makeOptions(opts ...Option) options {
+	o := options{
+		install:       install.MakeDefaultOptions(),
+		clientOptions: &runclient.Options{},
+		logger:        log.NopLogger{},
+		pollInterval:  2 * time.Second,
+		branch:        "main",
+		interval:      sync.MakeDefaultOptions().Interval,
+		username:      "git",
+		authorName:    "Flux",
+	}
+	// The extra components are opt-in, as with the CLI.
+	o.install.ComponentsExtra = nil
+	o.install.Timeout = 5 * time.Minute
+	o.secretName = o.install.Namespace
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithInstallOptions replaces the install options set so far, for the
+// settings without a dedicated Option such as the affinity of the pods.
+func WithInstallOptions(opts install.Options) Option {
+	return func(o *options) {
+		if o.secretName == o.install.Namespace {
+			o.secretName = opts.Namespace
+		}
+		o.install = opts
+	}
+}
+
+// WithVersion sets the Flux version to install, e.g. "v0.40.0" or "latest".
+func WithVersion(version string) Option {
+	return func(o *options) {
+		o.install.Version = version
+	}
+}
+
+// WithNamespace sets the namespace Flux is installed in.
+func WithNamespace(namespace string) Option {
+	return func(o *options) {
+		if o.secretName == o.install.Namespace {
+			o.secretName = namespace
+		}
+		o.install.Namespace = namespace
+	}
+}
+
+// WithComponents sets the list of components to install.
+func WithComponents(components ...string) Option {
+	return func(o *options) {
+		o.install.Components = components
+	}
+}
+
+// WithComponentsExtra adds components to the ones supplied or defaulted,
+// e.g. "image-reflector-controller" and "image-automation-controller".
+func WithComponentsExtra(components ...string) Option {
+	return func(o *options) {
+		o.install.Components = append(o.install.Components, components...)
+	}
+}
+
+// WithRegistry sets the container registry the component images are
+// pulled from.
+func WithRegistry(registry string) Option {
+	return func(o *options) {
+		o.install.Registry = registry
+	}
+}
+
+// WithImagePullSecret sets the Kubernetes secret used for pulling the
+// component images from a private registry.
+func WithImagePullSecret(name string) Option {
+	return func(o *options) {
+		o.install.ImagePullSecret = name
+	}
+}
+
+// WithWatchAllNamespaces configures whether the controllers watch for
+// custom resources in all namespaces.
+func WithWatchAllNamespaces(watch bool) Option {
+	return func(o *options) {
+		o.install.WatchAllNamespaces = watch
+	}
+}
+
+// WithWatchNamespaces scopes the instance to the given namespaces and its
+// own, the controllers watch the custom resources of their namespace only.
+func WithWatchNamespaces(namespaces ...string) Option {
+	return func(o *options) {
+		o.install.WatchNamespaces = namespaces
+	}
+}
+
+// WithNetworkPolicy configures whether ingress access to the controllers
+// from other namespaces is denied.
+func WithNetworkPolicy(enabled bool) Option {
+	return func(o *options) {
+		o.install.NetworkPolicy = enabled
+	}
+}
+
+// WithLogLevel sets the log level of the controllers.
+func WithLogLevel(level string) Option {
+	return func(o *options) {
+		o.install.LogLevel = level
+	}
+}
+
+// WithClusterDomain sets the internal cluster domain.
+func WithClusterDomain(domain string) Option {
+	return func(o *options) {
+		o.install.ClusterDomain = domain
+	}
+}
+
+// WithTolerationKeys sets the toleration keys used to schedule the
+// component pods onto nodes with matching taints.
+func WithTolerationKeys(keys ...string) Option {
+	return func(o *options) {
+		o.install.TolerationKeys = keys
+	}
+}
+
+// WithBaseURL sets the URL the manifests are downloaded from, or the path
+// to a local Kustomize overlay.
+func WithBaseURL(url string) Option {
+	return func(o *options) {
+		o.install.BaseURL = url
+	}
+}
+
+// WithManifestsBase sets a local directory containing the manifests base,
+// for consumers that embed the manifests instead of downloading them.
+func WithManifestsBase(dir string) Option {
+	return func(o *options) {
+		o.manifestsBase = dir
+	}
+}
+
+// WithTimeout sets the timeout for the generation, apply and health
+// assessment operations.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.install.Timeout = timeout
+	}
+}
+
+// WithPollInterval sets the interval at which the health of the
+// components and Kustomization is polled.
+func WithPollInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.pollInterval = interval
+	}
+}
+
+// WithLogger sets the logger used to report progress.
+func WithLogger(logger log.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithClientOptions sets the Kubernetes client options, e.g. QPS and burst.
+func WithClientOptions(opts *runclient.Options) Option {
+	return func(o *options) {
+		o.clientOptions = opts
+	}
+}
+
+// WithBranch sets the Git branch to bootstrap.
+func WithBranch(branch string) Option {
+	return func(o *options) {
+		o.branch = branch
+	}
+}
+
+// WithPath sets the path relative to the repository root the cluster
+// manifests are written to.
+func WithPath(path string) Option {
+	return func(o *options) {
+		o.path = path
+	}
+}
+
+// WithInterval sets the sync interval of the GitRepository and
+// Kustomization.
+func WithInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.interval = interval
+	}
+}
+
+// WithSecretName sets the name of the Git credentials secret.
+func WithSecretName(name string) Option {
+	return func(o *options) {
+		o.secretName = name
+	}
+}
+
+// WithBasicAuth authenticates with the Git server over HTTP/S using the
+// given username and password or token. The credentials are also used by
+// the cluster to sync the repository.
+func WithBasicAuth(username, password string) Option {
+	return func(o *options) {
+		o.username = username
+		o.password = password
+	}
+}
+
+// WithPrivateKey authenticates with the Git server over SSH using the
+// given PEM encoded private key and optional password. The key is also used
+// by the cluster to sync the repository.
+func WithPrivateKey(privateKey []byte, password string) Option {
+	return func(o *options) {
+		o.privateKey = privateKey
+		o.password = password
+	}
+}
+
+// WithCABundle sets the TLS CA bundle used to connect to the Git server.
+func WithCABundle(caBundle []byte) Option {
+	return func(o *options) {
+		o.caBundle = caBundle
+	}
+}
+
+// WithAuthor sets the name and email of the author of the bootstrap
+// commits.
+func WithAuthor(name, email string) Option {
+	return func(o *options) {
+		o.authorName = name
+		o.authorEmail = email
+	}
+}
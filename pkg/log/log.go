@@ -30,3 +30,12 @@ type Logger interface {
 	// Failuref logs a formatted failure message.
 	Failuref(format string, a ...interface{})
 }
+
+// ProgressLogger is a Logger which can report the completion percentage of
+// long-running operations.
+type ProgressLogger interface {
+	Logger
+	// Progress sets the phase and the completion percentage of the current
+	// operation.
+	Progress(phase string, percent int)
+}
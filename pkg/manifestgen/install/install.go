@@ -17,9 +17,13 @@ limitations under the License.
 package install
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"path"
@@ -27,6 +31,13 @@ import (
 	"time"
 
 	securejoin "github.com/cyphar/filepath-securejoin"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/kustomize/api/konfig"
+	kustypes "sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/yaml"
 
 	"github.com/fluxcd/flux2/pkg/manifestgen"
 )
@@ -88,6 +99,111 @@ func Generate(options Options, manifestsBase string) (*manifestgen.Manifest, err
 	}, nil
 }
 
+// GenerateFiles returns the install manifests split into one file per
+// component, plus files for the namespace, the RBAC and the network
+// policies, and a kustomization.yaml listing them as resources.
+// The paths of the returned manifests are relative to the target directory.
+func GenerateFiles(options Options, manifestsBase string) ([]manifestgen.Manifest, error) {
+	manifest, err := Generate(options, manifestsBase)
+	if err != nil {
+		return nil, err
+	}
+	return splitManifest(manifest.Content, options)
+}
+
+const componentLabel = "app.kubernetes.io/component"
+
+// splitManifest groups the documents of the multi-doc YAML per file, the
+// documents are kept as is to preserve the formatting of the manifests.
+func splitManifest(content string, options Options) ([]manifestgen.Manifest, error) {
+	files := make(map[string]*strings.Builder)
+	reader := k8syaml.NewYAMLReader(bufio.NewReader(strings.NewReader(content)))
+	for {
+		doc, err := reader.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("failed to read manifests: %w", err)
+		}
+
+		var obj metav1.PartialObjectMetadata
+		if err := yaml.Unmarshal(doc, &obj); err != nil {
+			return nil, fmt.Errorf("failed to parse manifest: %w", err)
+		}
+		if obj.Kind == "" {
+			continue
+		}
+
+		file := manifestFileFor(obj)
+		if files[file] == nil {
+			files[file] = &strings.Builder{}
+			files[file].WriteString(GetGenWarning(options) + "\n")
+		}
+		files[file].WriteString("---\n")
+		files[file].Write(bytes.TrimPrefix(doc, []byte("---\n")))
+	}
+
+	order := []string{"namespace.yaml"}
+	for _, component := range options.Components {
+		order = append(order, component+".yaml")
+	}
+	for _, component := range options.ComponentsExtra {
+		order = append(order, component+".yaml")
+	}
+	order = append(order, "rbac.yaml", "policies.yaml", "common.yaml")
+
+	var manifests []manifestgen.Manifest
+	kus := kustypes.Kustomization{
+		TypeMeta: kustypes.TypeMeta{
+			APIVersion: kustypes.KustomizationVersion,
+			Kind:       kustypes.KustomizationKind,
+		},
+	}
+	for _, file := range order {
+		if files[file] == nil {
+			continue
+		}
+		manifests = append(manifests, manifestgen.Manifest{
+			Path:    file,
+			Content: files[file].String(),
+		})
+		kus.Resources = append(kus.Resources, file)
+		delete(files, file)
+	}
+	for file := range files {
+		return nil, fmt.Errorf("unexpected component in manifests: %s", strings.TrimSuffix(file, ".yaml"))
+	}
+
+	kd, err := yaml.Marshal(kus)
+	if err != nil {
+		return nil, err
+	}
+	manifests = append(manifests, manifestgen.Manifest{
+		Path:    konfig.DefaultKustomizationFileName(),
+		Content: string(kd),
+	})
+	return manifests, nil
+}
+
+// manifestFileFor returns the name of the file the object belongs to.
+func manifestFileFor(obj metav1.PartialObjectMetadata) string {
+	if component := obj.GetLabels()[componentLabel]; component != "" {
+		return component + ".yaml"
+	}
+	gv, _ := schema.ParseGroupVersion(obj.APIVersion)
+	switch {
+	case obj.Kind == "Namespace":
+		return "namespace.yaml"
+	case gv.Group == rbacv1.GroupName:
+		return "rbac.yaml"
+	case obj.Kind == "NetworkPolicy":
+		return "policies.yaml"
+	default:
+		return "common.yaml"
+	}
+}
+
 // GetLatestVersion calls the GitHub API and returns the latest released version.
 func GetLatestVersion() (string, error) {
 	ghURL := "https://api.github.com/repos/fluxcd/flux2/releases/latest"
@@ -17,9 +17,19 @@ limitations under the License.
 package install
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
 )
 
 func TestGenerate(t *testing.T) {
@@ -48,3 +58,366 @@ func TestGenerate(t *testing.T) {
 
 	fmt.Println(output)
 }
+
+func TestSplitManifest(t *testing.T) {
+	content := `---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: flux-system
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    app.kubernetes.io/component: source-controller
+  name: source-controller
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: cluster-reconciler
+---
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: allow-egress
+---
+apiVersion: v1
+kind: Service
+metadata:
+  labels:
+    app.kubernetes.io/component: kustomize-controller
+  name: kustomize-controller
+`
+	opts := MakeDefaultOptions()
+	opts.Components = []string{"source-controller", "kustomize-controller"}
+	opts.ComponentsExtra = nil
+
+	manifests, err := splitManifest(GetGenWarning(opts)+"\n"+content, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"namespace.yaml",
+		"source-controller.yaml",
+		"kustomize-controller.yaml",
+		"rbac.yaml",
+		"policies.yaml",
+		"kustomization.yaml",
+	}
+	if len(manifests) != len(expected) {
+		t.Fatalf("expected %d files, got %d", len(expected), len(manifests))
+	}
+	for i, m := range manifests {
+		if m.Path != expected[i] {
+			t.Errorf("expected file '%s' at position %d, got '%s'", expected[i], i, m.Path)
+		}
+	}
+
+	if !strings.Contains(manifests[1].Content, "name: source-controller") {
+		t.Errorf("source-controller deployment not found in %s", manifests[1].Path)
+	}
+	for _, file := range expected[:5] {
+		if !strings.Contains(manifests[5].Content, "- "+file) {
+			t.Errorf("resource '%s' not found in kustomization.yaml", file)
+		}
+	}
+
+	opts.Components = []string{"source-controller"}
+	if _, err := splitManifest(content, opts); err == nil {
+		t.Error("expected error for unknown component")
+	}
+}
+
+func TestSources(t *testing.T) {
+	base := t.TempDir()
+	for _, name := range []string{"kustomization.yaml", "source-controller.yaml", "helm-controller.yaml", "policies.yaml", "README.md"} {
+		if err := os.WriteFile(filepath.Join(base, name), []byte("# "+name+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := MakeDefaultOptions()
+	opts.BaseURL = base
+	opts.Components = []string{"source-controller"}
+	opts.NetworkPolicy = false
+	manifests, err := Sources(opts, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var paths []string
+	for _, m := range manifests {
+		paths = append(paths, m.Path)
+	}
+	if want := "kustomization.yaml,source-controller.yaml"; strings.Join(paths, ",") != want {
+		t.Errorf("expected manifests %s, got %v", want, paths)
+	}
+}
+
+func TestNodeSelectorTemplate(t *testing.T) {
+	localhostProfile := "profiles/flux.json"
+	opts := MakeDefaultOptions()
+	opts.NodeSelector = map[string]string{"node-role.kubernetes.io/infra": "true"}
+	opts.PriorityClassName = "system-cluster-critical"
+	opts.SeccompProfile = &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeLocalhost, LocalhostProfile: &localhostProfile}
+	opts.Affinity = &corev1.Affinity{
+		NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+					MatchExpressions: []corev1.NodeSelectorRequirement{{
+						Key:      "topology.kubernetes.io/zone",
+						Operator: corev1.NodeSelectorOpIn,
+						Values:   []string{"eu-west-1a"},
+					}},
+				}},
+			},
+		},
+	}
+
+	file := filepath.Join(t.TempDir(), "node-selector.yaml")
+	if err := execTemplate(opts, nodeSelectorTmpl, file); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var deployment appsv1.Deployment
+	if err := yaml.UnmarshalStrict(data, &deployment); err != nil {
+		t.Fatalf("invalid patch: %v\n%s", err, data)
+	}
+	spec := deployment.Spec.Template.Spec
+	wantSelector := map[string]string{"kubernetes.io/os": "linux", "node-role.kubernetes.io/infra": "true"}
+	if !reflect.DeepEqual(spec.NodeSelector, wantSelector) {
+		t.Errorf("expected node selector %v, got %v", wantSelector, spec.NodeSelector)
+	}
+	if spec.PriorityClassName != opts.PriorityClassName {
+		t.Errorf("expected priority class %s, got %s", opts.PriorityClassName, spec.PriorityClassName)
+	}
+	if !reflect.DeepEqual(spec.Affinity, opts.Affinity) {
+		t.Errorf("expected affinity %v, got %v", opts.Affinity, spec.Affinity)
+	}
+	if spec.SecurityContext == nil || !reflect.DeepEqual(spec.SecurityContext.SeccompProfile, opts.SeccompProfile) {
+		t.Errorf("expected seccomp profile %v, got %v", opts.SeccompProfile, spec.SecurityContext)
+	}
+}
+
+func TestNamespaceTemplate(t *testing.T) {
+	tests := []struct {
+		name        string
+		podSecurity map[string]string
+		want        map[string]string
+	}{
+		{
+			name: "default",
+			want: map[string]string{
+				"pod-security.kubernetes.io/warn":         "restricted",
+				"pod-security.kubernetes.io/warn-version": "latest",
+			},
+		},
+		{
+			name:        "enforce",
+			podSecurity: map[string]string{"enforce": "restricted", "audit": "baseline"},
+			want: map[string]string{
+				"pod-security.kubernetes.io/enforce":         "restricted",
+				"pod-security.kubernetes.io/enforce-version": "latest",
+				"pod-security.kubernetes.io/audit":           "baseline",
+				"pod-security.kubernetes.io/audit-version":   "latest",
+				"pod-security.kubernetes.io/warn":            "restricted",
+				"pod-security.kubernetes.io/warn-version":    "latest",
+			},
+		},
+		{
+			name:        "warn",
+			podSecurity: map[string]string{"warn": "baseline"},
+			want: map[string]string{
+				"pod-security.kubernetes.io/warn":         "baseline",
+				"pod-security.kubernetes.io/warn-version": "latest",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := MakeDefaultOptions()
+			opts.PodSecurity = tt.podSecurity
+
+			file := filepath.Join(t.TempDir(), "namespace.yaml")
+			if err := execTemplate(opts, namespaceTmpl, file); err != nil {
+				t.Fatal(err)
+			}
+			data, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var namespace corev1.Namespace
+			if err := yaml.UnmarshalStrict(data, &namespace); err != nil {
+				t.Fatalf("invalid namespace: %v\n%s", err, data)
+			}
+			if !reflect.DeepEqual(namespace.Labels, tt.want) {
+				t.Errorf("expected labels %v, got %v", tt.want, namespace.Labels)
+			}
+		})
+	}
+}
+
+func TestKustomizationTemplateSeccompProfile(t *testing.T) {
+	opts := MakeDefaultOptions()
+	opts.SeccompProfile = &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault}
+
+	file := filepath.Join(t.TempDir(), "kustomization.yaml")
+	if err := execTemplate(opts, kustomizationTmpl, file); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var kustomization struct {
+		PatchesJson6902 []struct {
+			Patch string `json:"patch"`
+		} `json:"patchesJson6902"`
+	}
+	if err := yaml.Unmarshal(data, &kustomization); err != nil {
+		t.Fatalf("invalid kustomization: %v\n%s", err, data)
+	}
+	if len(kustomization.PatchesJson6902) != len(opts.Components) {
+		t.Fatalf("expected %d patches, got %d", len(opts.Components), len(kustomization.PatchesJson6902))
+	}
+	for _, p := range kustomization.PatchesJson6902 {
+		var ops []struct {
+			Op    string          `json:"op"`
+			Path  string          `json:"path"`
+			Value json.RawMessage `json:"value"`
+		}
+		if err := yaml.Unmarshal([]byte(p.Patch), &ops); err != nil {
+			t.Fatalf("invalid patch: %v\n%s", err, p.Patch)
+		}
+		last := ops[len(ops)-1]
+		var profile corev1.SeccompProfile
+		if err := json.Unmarshal(last.Value, &profile); err != nil ||
+			last.Path != "/spec/template/spec/containers/0/securityContext/seccompProfile" || profile.Type != corev1.SeccompProfileTypeRuntimeDefault {
+			t.Errorf("expected seccomp profile patch, got %v", last)
+		}
+	}
+}
+
+func TestCIDRsPolicyTemplate(t *testing.T) {
+	opts := MakeDefaultOptions()
+	opts.NetworkPolicyCIDRs = []string{"10.0.0.0/8", "fd00::/8"}
+
+	file := filepath.Join(t.TempDir(), "policies-cidrs.yaml")
+	if err := execTemplate(opts, cidrsPolicyTmpl, file); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var policy networkingv1.NetworkPolicy
+	if err := yaml.UnmarshalStrict(data, &policy); err != nil {
+		t.Fatalf("invalid network policy: %v\n%s", err, data)
+	}
+	var cidrs []string
+	for _, peer := range policy.Spec.Ingress[0].From {
+		cidrs = append(cidrs, peer.IPBlock.CIDR)
+	}
+	if !reflect.DeepEqual(cidrs, opts.NetworkPolicyCIDRs) {
+		t.Errorf("expected CIDRs %v, got %v", opts.NetworkPolicyCIDRs, cidrs)
+	}
+}
+
+func TestGenerateWatchNamespaces(t *testing.T) {
+	base := t.TempDir()
+	files := map[string]string{
+		"source-controller.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: source-controller
+spec:
+  template:
+    spec:
+      containers:
+        - name: manager
+          args: [a, b, c, d, e, f, g]
+`,
+		"rbac.yaml": `apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: crd-controller
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: crd-controller
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: crd-controller
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: cluster-reconciler
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: cluster-admin
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(base, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := MakeDefaultOptions()
+	opts.Namespace = "tenant-a"
+	opts.Components = []string{"source-controller"}
+	opts.NetworkPolicy = false
+	opts.WatchNamespaces = []string{"apps", "web"}
+	if err := generate(base, opts); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(base, "output.yaml")
+	if err := build(base, output); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var bindings []string
+	for _, doc := range strings.Split(string(data), "\n---\n") {
+		var obj metav1.PartialObjectMetadata
+		if err := yaml.Unmarshal([]byte(doc), &obj); err != nil {
+			t.Fatal(err)
+		}
+		switch obj.Kind {
+		case "ClusterRoleBinding":
+			t.Errorf("unexpected ClusterRoleBinding %s", obj.Name)
+		case "RoleBinding":
+			bindings = append(bindings, obj.Namespace+"/"+obj.Name)
+		case "Deployment":
+			if !strings.Contains(doc, "--watch-all-namespaces=false") {
+				t.Errorf("expected %s to watch its namespace only", obj.Name)
+			}
+		}
+	}
+	want := []string{
+		"apps/cluster-reconciler-tenant-a",
+		"tenant-a/cluster-reconciler-tenant-a",
+		"tenant-a/crd-controller-tenant-a",
+		"web/cluster-reconciler-tenant-a",
+	}
+	if !reflect.DeepEqual(bindings, want) {
+		t.Errorf("expected role bindings %v, got %v", want, bindings)
+	}
+}
@@ -29,9 +29,78 @@ import (
 	"github.com/fluxcd/pkg/kustomize/filesys"
 	"github.com/fluxcd/pkg/untar"
 
+	"github.com/fluxcd/flux2/pkg/manifestgen"
 	"github.com/fluxcd/flux2/pkg/manifestgen/kustomization"
 )
 
+// Sources returns the manifests the install manifests are built from,
+// i.e. the raw manifests of the selected components, as downloaded from a
+// GitHub release or read from a local Options.BaseURL, and the generated
+// kustomization files. The manifestsBase should be set to an empty string
+// when Sources is called by consumers that don't embed the manifests.
+// The paths of the returned manifests are relative to the manifests base.
+func Sources(options Options, manifestsBase string) ([]manifestgen.Manifest, error) {
+	base := manifestsBase
+	if !strings.HasPrefix(options.BaseURL, "http") {
+		base = options.BaseURL
+	} else {
+		if manifestsBase == "" {
+			tmpDir, err := manifestgen.MkdirTempAbs("", options.Namespace)
+			if err != nil {
+				return nil, fmt.Errorf("temp dir error: %w", err)
+			}
+			defer os.RemoveAll(tmpDir)
+			base = tmpDir
+
+			ctx, cancel := context.WithTimeout(context.Background(), options.Timeout)
+			defer cancel()
+			if err := fetch(ctx, options.BaseURL, options.Version, base); err != nil {
+				return nil, err
+			}
+		}
+		if err := generate(base, options); err != nil {
+			return nil, err
+		}
+	}
+
+	allComponents := append(MakeDefaultOptions().Components, MakeDefaultOptions().ComponentsExtra...)
+	var manifests []manifestgen.Manifest
+	err := filepath.WalkDir(base, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(base, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if ext := path.Ext(rel); ext != ".yaml" && ext != ".yml" {
+			return nil
+		}
+		component := strings.TrimSuffix(rel, path.Ext(rel))
+		switch {
+		case containsItemString(allComponents, component) && !containsItemString(options.Components, component):
+			return nil
+		case (rel == "policies.yaml" || rel == "policies-cidrs.yaml") && !options.NetworkPolicy:
+			return nil
+		case rel == "rbac.yaml" && base != options.BaseURL:
+			// copied to the roles directory by generate
+			return nil
+		}
+
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		manifests = append(manifests, manifestgen.Manifest{Path: rel, Content: string(content)})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading manifests failed: %w", err)
+	}
+	return manifests, nil
+}
+
 func fetch(ctx context.Context, url, version, dir string) error {
 	ghURL := fmt.Sprintf("%s/latest/download/manifests.tar.gz", url)
 	if strings.HasPrefix(version, "v") {
@@ -76,6 +145,17 @@ func generate(base string, options Options) error {
 		options.EventsAddr = fmt.Sprintf("http://%s.%s.svc.%s./", options.NotificationController, options.Namespace, options.ClusterDomain)
 	}
 
+	if len(options.WatchNamespaces) > 0 {
+		// The reconcilers can always apply to the instance namespace
+		options.WatchAllNamespaces = false
+		if !containsItemString(options.WatchNamespaces, options.Namespace) {
+			options.WatchNamespaces = append([]string{options.Namespace}, options.WatchNamespaces...)
+		}
+		if err := execTemplate(options, namespacesRBACTmpl, path.Join(base, "rbac-namespaces.yaml")); err != nil {
+			return fmt.Errorf("generate namespaces rbac failed: %w", err)
+		}
+	}
+
 	if err := execTemplate(options, namespaceTmpl, path.Join(base, "namespace.yaml")); err != nil {
 		return fmt.Errorf("generate namespace failed: %w", err)
 	}
@@ -84,6 +164,12 @@ func generate(base string, options Options) error {
 		return fmt.Errorf("generate labels failed: %w", err)
 	}
 
+	if options.NetworkPolicy && len(options.NetworkPolicyCIDRs) > 0 {
+		if err := execTemplate(options, cidrsPolicyTmpl, path.Join(base, "policies-cidrs.yaml")); err != nil {
+			return fmt.Errorf("generate network policy failed: %w", err)
+		}
+	}
+
 	if err := execTemplate(options, nodeSelectorTmpl, path.Join(base, "node-selector.yaml")); err != nil {
 		return fmt.Errorf("generate node selector failed: %w", err)
 	}
@@ -16,7 +16,11 @@ limitations under the License.
 
 package install
 
-import "time"
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
 
 type Options struct {
 	BaseURL                string
@@ -29,6 +33,7 @@ type Options struct {
 	ImagePullSecret        string
 	WatchAllNamespaces     bool
 	NetworkPolicy          bool
+	NetworkPolicyCIDRs     []string
 	LogLevel               string
 	NotificationController string
 	ManifestFile           string
@@ -36,6 +41,24 @@ type Options struct {
 	TargetPath             string
 	ClusterDomain          string
 	TolerationKeys         []string
+	NodeSelector           map[string]string
+	Affinity               *corev1.Affinity
+	PriorityClassName      string
+
+	// PodSecurity maps the Pod Security admission modes (enforce, audit,
+	// warn) to the level labelled on the namespace, warn defaults to
+	// restricted.
+	PodSecurity map[string]string
+
+	// WatchNamespaces scopes the instance to the listed namespaces and its
+	// own: the controllers watch the custom resources of the instance
+	// namespace only, and the reconcilers are bound to the namespaces with
+	// role bindings instead of cluster role bindings.
+	WatchNamespaces []string
+
+	// SeccompProfile is set on the controller pods and containers when
+	// not nil, overriding the RuntimeDefault profile of the manifests.
+	SeccompProfile *corev1.SeccompProfile
 }
 
 func MakeDefaultOptions() Options {
@@ -19,6 +19,7 @@ package install
 import (
 	"bufio"
 	"bytes"
+	"encoding/json"
 	"io"
 	"os"
 	"text/template"
@@ -30,6 +31,8 @@ var kustomizationTmpl = `---
 {{- $registry := .Registry }}
 {{- $logLevel := .LogLevel }}
 {{- $clusterDomain := .ClusterDomain }}
+{{- $seccompProfile := .SeccompProfile }}
+{{- $namespace := .Namespace }}
 apiVersion: kustomize.config.k8s.io/v1beta1
 kind: Kustomization
 namespace: {{.Namespace}}
@@ -41,8 +44,14 @@ resources:
   - namespace.yaml
 {{- if .NetworkPolicy }}
   - policies.yaml
+{{- if .NetworkPolicyCIDRs }}
+  - policies-cidrs.yaml
+{{- end }}
 {{- end }}
   - roles
+{{- if .WatchNamespaces }}
+  - rbac-namespaces.yaml
+{{- end }}
 {{- range .Components }}
   - {{.}}.yaml
 {{- end }}
@@ -67,6 +76,11 @@ patchesJson6902:
     - op: replace
       path: /spec/template/spec/containers/0/args/1
       value: --log-level={{$logLevel}}
+{{- if $seccompProfile }}
+    - op: add
+      path: /spec/template/spec/containers/0/securityContext/seccompProfile
+      value: {{toJSON $seccompProfile}}
+{{- end }}
 {{- else if eq $component "source-controller" }}
 - target:
     group: apps
@@ -86,6 +100,11 @@ patchesJson6902:
     - op: replace
       path: /spec/template/spec/containers/0/args/6
       value: --storage-adv-addr=source-controller.$(RUNTIME_NAMESPACE).svc.{{$clusterDomain}}.
+{{- if $seccompProfile }}
+    - op: add
+      path: /spec/template/spec/containers/0/securityContext/seccompProfile
+      value: {{toJSON $seccompProfile}}
+{{- end }}
 {{- else }}
 - target:
     group: apps
@@ -102,6 +121,28 @@ patchesJson6902:
     - op: replace
       path: /spec/template/spec/containers/0/args/2
       value: --log-level={{$logLevel}}
+{{- if $seccompProfile }}
+    - op: add
+      path: /spec/template/spec/containers/0/securityContext/seccompProfile
+      value: {{toJSON $seccompProfile}}
+{{- end }}
+{{- end }}
+{{- end }}
+
+{{- range .WatchNamespaces }}
+{{- if ne . $namespace }}
+- target:
+    group: rbac.authorization.k8s.io
+    version: v1
+    kind: RoleBinding
+    name: cluster-reconciler-{{.}}
+  patch: |-
+    - op: replace
+      path: /metadata/namespace
+      value: {{.}}
+    - op: replace
+      path: /metadata/name
+      value: cluster-reconciler-{{$namespace}}
 {{- end }}
 {{- end }}
 
@@ -121,6 +162,56 @@ namespace: {{.Namespace}}
 resources:
   - rbac.yaml
 nameSuffix: -{{.Namespace}}
+{{- if .WatchNamespaces }}
+patches:
+- target:
+    kind: ClusterRoleBinding
+  patch: |-
+    $patch: delete
+    apiVersion: rbac.authorization.k8s.io/v1
+    kind: ClusterRoleBinding
+    metadata:
+      name: all
+{{- end }}
+`
+
+// namespacesRBACTmpl binds the reconcilers to the watched namespaces, the
+// role bindings are moved to their namespace by the kustomization patches
+// as the namespace transformer sets the instance namespace on all objects.
+var namespacesRBACTmpl = `---
+{{- $namespace := .Namespace }}
+{{- range .WatchNamespaces }}
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: cluster-reconciler-{{.}}
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: cluster-admin
+subjects:
+  - kind: ServiceAccount
+    name: kustomize-controller
+    namespace: {{$namespace}}
+  - kind: ServiceAccount
+    name: helm-controller
+    namespace: {{$namespace}}
+---
+{{- end }}
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: crd-controller-{{.Namespace}}
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: crd-controller-{{.Namespace}}
+subjects:
+{{- range .Components }}
+  - kind: ServiceAccount
+    name: {{.}}
+    namespace: {{$namespace}}
+{{- end }}
 `
 
 var nodeSelectorTmpl = `---
@@ -132,7 +223,22 @@ spec:
   template:
     spec:
       nodeSelector:
+{{- if not (index .NodeSelector "kubernetes.io/os") }}
         kubernetes.io/os: linux
+{{- end }}
+{{- range $key, $value := .NodeSelector }}
+        "{{$key}}": "{{$value}}"
+{{- end }}
+{{- if .PriorityClassName }}
+      priorityClassName: {{.PriorityClassName}}
+{{- end }}
+{{- if .Affinity }}
+      affinity: {{toJSON .Affinity}}
+{{- end }}
+{{- if .SeccompProfile }}
+      securityContext:
+        seccompProfile: {{toJSON .SeccompProfile}}
+{{- end }}
 {{- if .ImagePullSecret }}
       imagePullSecrets:
        - name: {{.ImagePullSecret}}
@@ -146,6 +252,23 @@ spec:
 {{- end }}
 `
 
+var cidrsPolicyTmpl = `---
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: allow-cidrs
+spec:
+  podSelector: {}
+  policyTypes:
+  - Ingress
+  ingress:
+  - from:
+{{- range .NetworkPolicyCIDRs }}
+    - ipBlock:
+        cidr: "{{.}}"
+{{- end }}
+`
+
 var labelsTmpl = `---
 apiVersion: builtin
 kind: LabelTransformer
@@ -166,12 +289,18 @@ kind: Namespace
 metadata:
   name: {{.Namespace}}
   labels:
+{{- if not (index .PodSecurity "warn") }}
     pod-security.kubernetes.io/warn: restricted
     pod-security.kubernetes.io/warn-version: latest
+{{- end }}
+{{- range $mode, $level := .PodSecurity }}
+    pod-security.kubernetes.io/{{$mode}}: {{$level}}
+    pod-security.kubernetes.io/{{$mode}}-version: latest
+{{- end }}
 `
 
 func execTemplate(obj interface{}, tmpl, filename string) error {
-	t, err := template.New("tmpl").Parse(tmpl)
+	t, err := template.New("tmpl").Funcs(template.FuncMap{"toJSON": toJSON}).Parse(tmpl)
 	if err != nil {
 		return err
 	}
@@ -200,6 +329,12 @@ func execTemplate(obj interface{}, tmpl, filename string) error {
 	return file.Sync()
 }
 
+// toJSON returns the JSON encoding of v, which is valid inline YAML.
+func toJSON(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	return string(data), err
+}
+
 func copyFile(src, dst string) error {
 	in, err := os.Open(src)
 	if err != nil {
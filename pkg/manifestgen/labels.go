@@ -17,7 +17,7 @@ limitations under the License.
 package manifestgen
 
 // These labels can be used to track down the namespace, custom resource definitions, deployments,
-// services, network policies, service accounts, cluster roles and cluster role bindings belonging to Flux.
+// services, network policies, service accounts, cluster roles, cluster role bindings and role bindings belonging to Flux.
 const (
 	PartOfLabelKey   = "app.kubernetes.io/part-of"
 	PartOfLabelValue = "flux"
@@ -0,0 +1,150 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sourcesecret
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	"github.com/fluxcd/flux2/pkg/manifestgen"
+)
+
+// Backend is the secret management system a source secret manifest is
+// generated for.
+type Backend string
+
+const (
+	// KubernetesBackend generates a Kubernetes Secret with the credentials
+	// inlined, this is the default.
+	KubernetesBackend Backend = "kubernetes"
+	// ExternalSecretsBackend generates an external-secrets.io ExternalSecret
+	// which references the credentials in a SecretStore.
+	ExternalSecretsBackend Backend = "external-secrets"
+	// VaultBackend generates a Vault Secrets Operator VaultStaticSecret
+	// which references the credentials in a Vault KV secrets engine.
+	VaultBackend Backend = "vault"
+)
+
+const (
+	externalSecretAPIVersion    = "external-secrets.io/v1beta1"
+	vaultStaticSecretAPIVersion = "secrets.hashicorp.com/v1beta1"
+	defaultRefreshInterval      = "1h"
+)
+
+// GeneratorFunc generates the manifest of a source secret for a specific
+// Backend.
+type GeneratorFunc func(options Options) (*manifestgen.Manifest, error)
+
+var generators = map[Backend]GeneratorFunc{
+	ExternalSecretsBackend: generateExternalSecret,
+	VaultBackend:           generateVaultStaticSecret,
+}
+
+// RegisterGenerator registers the GeneratorFunc used by Generate for the
+// given Backend, replacing any existing registration.
+func RegisterGenerator(backend Backend, fn GeneratorFunc) {
+	generators[backend] = fn
+}
+
+// storePath returns the path of the credentials in the backend store,
+// which defaults to <namespace>/<name> when not configured.
+func storePath(options Options) string {
+	if options.StorePath != "" {
+		return strings.Trim(options.StorePath, "/")
+	}
+	return path.Join(options.Namespace, options.Name)
+}
+
+func generateExternalSecret(options Options) (*manifestgen.Manifest, error) {
+	if options.Store == "" {
+		return nil, fmt.Errorf("a secret store name is required for the %s backend", ExternalSecretsBackend)
+	}
+
+	obj := newBackendObject(externalSecretAPIVersion, "ExternalSecret", options)
+	obj.Object["spec"] = map[string]interface{}{
+		"refreshInterval": defaultRefreshInterval,
+		"secretStoreRef": map[string]interface{}{
+			"kind": "SecretStore",
+			"name": options.Store,
+		},
+		"target": map[string]interface{}{
+			"name":           options.Name,
+			"creationPolicy": "Owner",
+		},
+		"dataFrom": []interface{}{
+			map[string]interface{}{
+				"extract": map[string]interface{}{
+					"key": storePath(options),
+				},
+			},
+		},
+	}
+	return backendManifest(obj, options)
+}
+
+func generateVaultStaticSecret(options Options) (*manifestgen.Manifest, error) {
+	if options.Store == "" {
+		return nil, fmt.Errorf("a Vault auth name is required for the %s backend", VaultBackend)
+	}
+
+	// The first element of the path is the mount of the KV secrets engine
+	mount, secretPath, ok := strings.Cut(storePath(options), "/")
+	if !ok || secretPath == "" {
+		return nil, fmt.Errorf("invalid Vault secret path %q, must be in the format <mount>/<path>", storePath(options))
+	}
+
+	obj := newBackendObject(vaultStaticSecretAPIVersion, "VaultStaticSecret", options)
+	obj.Object["spec"] = map[string]interface{}{
+		"vaultAuthRef": options.Store,
+		"type":         "kv-v2",
+		"mount":        mount,
+		"path":         secretPath,
+		"refreshAfter": defaultRefreshInterval,
+		"destination": map[string]interface{}{
+			"name":   options.Name,
+			"create": true,
+		},
+	}
+	return backendManifest(obj, options)
+}
+
+func newBackendObject(apiVersion, kind string, options Options) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetName(options.Name)
+	obj.SetNamespace(options.Namespace)
+	if len(options.Labels) > 0 {
+		obj.SetLabels(options.Labels)
+	}
+	return obj
+}
+
+func backendManifest(obj *unstructured.Unstructured, options Options) (*manifestgen.Manifest, error) {
+	b, err := yaml.Marshal(obj.Object)
+	if err != nil {
+		return nil, err
+	}
+	return &manifestgen.Manifest{
+		Path:    path.Join(options.TargetPath, options.Namespace, options.ManifestFile),
+		Content: fmt.Sprintf("---\n%s", resourceToString(b)),
+	}, nil
+}
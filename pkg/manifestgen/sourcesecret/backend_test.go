@@ -0,0 +1,114 @@
+//go:build !e2e
+// +build !e2e
+
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sourcesecret
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+func TestGenerate_Backends(t *testing.T) {
+	tests := []struct {
+		name      string
+		options   Options
+		wantKind  string
+		wantField []string
+		wantValue string
+		wantErr   string
+	}{
+		{
+			name: "external secret with default path",
+			options: Options{
+				Name:      "podinfo-auth",
+				Namespace: "flux-system",
+				Backend:   ExternalSecretsBackend,
+				Store:     "vault-backend",
+			},
+			wantKind:  "ExternalSecret",
+			wantField: []string{"spec", "secretStoreRef", "name"},
+			wantValue: "vault-backend",
+		},
+		{
+			name: "external secret without store",
+			options: Options{
+				Name:      "podinfo-auth",
+				Namespace: "flux-system",
+				Backend:   ExternalSecretsBackend,
+			},
+			wantErr: "secret store name is required",
+		},
+		{
+			name: "vault static secret",
+			options: Options{
+				Name:      "podinfo-auth",
+				Namespace: "flux-system",
+				Backend:   VaultBackend,
+				Store:     "flux",
+				StorePath: "/kv/git/podinfo",
+			},
+			wantKind:  "VaultStaticSecret",
+			wantField: []string{"spec", "path"},
+			wantValue: "git/podinfo",
+		},
+		{
+			name: "vault static secret without mount",
+			options: Options{
+				Name:      "podinfo-auth",
+				Namespace: "flux-system",
+				Backend:   VaultBackend,
+				Store:     "flux",
+				StorePath: "podinfo",
+			},
+			wantErr: "invalid Vault secret path",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifest, err := Generate(tt.options)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Generate() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+
+			var obj unstructured.Unstructured
+			if err := yaml.Unmarshal([]byte(manifest.Content), &obj.Object); err != nil {
+				t.Fatalf("failed to unmarshal manifest: %v", err)
+			}
+			if kind := obj.GetKind(); kind != tt.wantKind {
+				t.Errorf("kind = %s, want %s", kind, tt.wantKind)
+			}
+			if value, _, _ := unstructured.NestedString(obj.Object, tt.wantField...); value != tt.wantValue {
+				t.Errorf("%s = %s, want %s", strings.Join(tt.wantField, "."), value, tt.wantValue)
+			}
+			if strings.Contains(manifest.Content, "stringData") {
+				t.Errorf("manifest must not contain credentials:\n%s", manifest.Content)
+			}
+		})
+	}
+}
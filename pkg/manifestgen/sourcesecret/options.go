@@ -31,14 +31,21 @@ const (
 )
 
 const (
-	UsernameSecretKey   = "username"
-	PasswordSecretKey   = "password"
-	CAFileSecretKey     = "caFile"
-	CertFileSecretKey   = "certFile"
-	KeyFileSecretKey    = "keyFile"
-	PrivateKeySecretKey = "identity"
-	PublicKeySecretKey  = "identity.pub"
-	KnownHostsSecretKey = "known_hosts"
+	UsernameSecretKey    = "username"
+	PasswordSecretKey    = "password"
+	BearerTokenSecretKey = "bearerToken"
+	CAFileSecretKey      = "caFile"
+	CertFileSecretKey    = "certFile"
+	KeyFileSecretKey     = "keyFile"
+	PrivateKeySecretKey  = "identity"
+	PublicKeySecretKey   = "identity.pub"
+	KnownHostsSecretKey  = "known_hosts"
+	ProxySecretKey       = "proxy"
+
+	GitHubAppIDSecretKey             = "githubAppID"
+	GitHubAppInstallationIDSecretKey = "githubAppInstallationID"
+	GitHubAppPrivateKeySecretKey     = "githubAppPrivateKey"
+	GitHubAppBaseURLSecretKey        = "githubAppBaseURL"
 )
 
 type Options struct {
@@ -53,11 +60,40 @@ type Options struct {
 	Keypair             *ssh.KeyPair
 	Username            string
 	Password            string
+	BearerToken         string
 	CAFile              []byte
 	CertFile            []byte
 	KeyFile             []byte
 	TargetPath          string
 	ManifestFile        string
+
+	// Backend selects the generator of the manifest, when set to anything
+	// else than KubernetesBackend no credentials are included in the
+	// manifest, instead these are referenced from the Store at StorePath.
+	Backend   Backend
+	Store     string
+	StorePath string
+
+	// GitHubAppID, GitHubAppInstallationID and GitHubAppPrivateKey are
+	// the GitHub App credentials source-controller mints installation
+	// tokens with, GitHubAppBaseURL is set for GitHub Enterprise Server.
+	GitHubAppID             string
+	GitHubAppInstallationID string
+	GitHubAppPrivateKey     []byte
+	GitHubAppBaseURL        string
+
+	// ExtraSSHHostnames are scanned for host keys in addition to
+	// SSHHostname, e.g. the hosts of the Git submodules.
+	ExtraSSHHostnames []string
+
+	// Proxy is the URL of the HTTP/S or SOCKS5 proxy the Git server is
+	// reached through.
+	Proxy string
+
+	// GPGPublicKeys are the armored public key rings of the trusted Git
+	// authors, keyed by file name, as expected by the commit signature
+	// verification of GitRepository.
+	GPGPublicKeys map[string][]byte
 }
 
 func MakeDefaultOptions() Options {
@@ -72,5 +108,6 @@ func MakeDefaultOptions() Options {
 		CertFile:            []byte{},
 		KeyFile:             []byte{},
 		ManifestFile:        "secret.yaml",
+		Backend:             KubernetesBackend,
 	}
 }
@@ -24,6 +24,7 @@ import (
 	"net"
 	"os"
 	"path"
+	"strings"
 	"time"
 
 	cryptssh "golang.org/x/crypto/ssh"
@@ -60,15 +61,29 @@ type DockerConfigEntry struct {
 }
 
 func Generate(options Options) (*manifestgen.Manifest, error) {
+	if generate, ok := generators[options.Backend]; ok {
+		return generate(options)
+	}
+
 	var err error
 
 	var keypair *ssh.KeyPair
 	switch {
-	case options.Username != "" && options.Password != "":
+	case options.Username != "" && options.Password != "", options.BearerToken != "", options.GitHubAppID != "":
 		// noop
 	case options.Keypair != nil:
+		algorithm, err := keyPairAlgorithm(options.Keypair)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkKeyAlgorithm(options.SSHHostname, algorithm); err != nil {
+			return nil, err
+		}
 		keypair = options.Keypair
 	case len(options.PrivateKeyAlgorithm) > 0:
+		if err := checkKeyAlgorithm(options.SSHHostname, options.PrivateKeyAlgorithm); err != nil {
+			return nil, err
+		}
 		if keypair, err = generateKeyPair(options); err != nil {
 			return nil, err
 		}
@@ -79,6 +94,16 @@ func Generate(options Options) (*manifestgen.Manifest, error) {
 		if hostKey, err = ScanHostKey(options.SSHHostname); err != nil {
 			return nil, err
 		}
+		for _, host := range options.ExtraSSHHostnames {
+			if host == options.SSHHostname {
+				continue
+			}
+			extraKey, err := ScanHostKey(host)
+			if err != nil {
+				return nil, err
+			}
+			hostKey = append(append(hostKey, '\n'), extraKey...)
+		}
 	}
 
 	var dockerCfgJson []byte
@@ -153,6 +178,27 @@ func buildSecret(keypair *ssh.KeyPair, hostKey, caFile, certFile, keyFile, docke
 		secret.StringData[PasswordSecretKey] = options.Password
 	}
 
+	if options.BearerToken != "" {
+		secret.StringData[BearerTokenSecretKey] = options.BearerToken
+	}
+
+	if options.GitHubAppID != "" {
+		secret.StringData[GitHubAppIDSecretKey] = options.GitHubAppID
+		secret.StringData[GitHubAppInstallationIDSecretKey] = options.GitHubAppInstallationID
+		secret.StringData[GitHubAppPrivateKeySecretKey] = string(options.GitHubAppPrivateKey)
+		if options.GitHubAppBaseURL != "" {
+			secret.StringData[GitHubAppBaseURLSecretKey] = options.GitHubAppBaseURL
+		}
+	}
+
+	if options.Proxy != "" {
+		secret.StringData[ProxySecretKey] = options.Proxy
+	}
+
+	for name, keyRing := range options.GPGPublicKeys {
+		secret.StringData[name] = string(keyRing)
+	}
+
 	if len(caFile) != 0 {
 		secret.StringData[CAFileSecretKey] = string(caFile)
 	}
@@ -194,6 +240,46 @@ func generateKeyPair(options Options) (*ssh.KeyPair, error) {
 	return pair, nil
 }
 
+// rsaOnlySSHHosts are the SSH hosts of the Git servers which only accept
+// RSA keys for user authentication, in addition to *.visualstudio.com.
+var rsaOnlySSHHosts = []string{"ssh.dev.azure.com"}
+
+// checkKeyAlgorithm returns an error when the SSH server of the host is
+// known to reject keys of the given algorithm.
+func checkKeyAlgorithm(host string, algorithm PrivateKeyAlgorithm) error {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if algorithm == RSAPrivateKeyAlgorithm {
+		return nil
+	}
+	rsaOnly := strings.HasSuffix(host, ".visualstudio.com")
+	for _, h := range rsaOnlySSHHosts {
+		rsaOnly = rsaOnly || host == h
+	}
+	if rsaOnly {
+		return fmt.Errorf("the SSH server %s only accepts RSA keys, %s keys are not supported", host, algorithm)
+	}
+	return nil
+}
+
+// keyPairAlgorithm returns the algorithm of the public key of the pair.
+func keyPairAlgorithm(keypair *ssh.KeyPair) (PrivateKeyAlgorithm, error) {
+	pub, _, _, _, err := cryptssh.ParseAuthorizedKey(keypair.PublicKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse public key: %w", err)
+	}
+	switch pub.Type() {
+	case cryptssh.KeyAlgoRSA:
+		return RSAPrivateKeyAlgorithm, nil
+	case cryptssh.KeyAlgoED25519:
+		return Ed25519PrivateKeyAlgorithm, nil
+	case cryptssh.KeyAlgoECDSA256, cryptssh.KeyAlgoECDSA384, cryptssh.KeyAlgoECDSA521:
+		return ECDSAPrivateKeyAlgorithm, nil
+	}
+	return PrivateKeyAlgorithm(pub.Type()), nil
+}
+
 func ScanHostKey(host string) ([]byte, error) {
 	if _, _, err := net.SplitHostPort(host); err != nil {
 		// Assume we are dealing with a hostname without a port,
@@ -22,6 +22,7 @@ package sourcesecret
 import (
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 
 	"golang.org/x/crypto/ssh"
@@ -88,3 +89,47 @@ func Test_PasswordlessLoadKeyPair(t *testing.T) {
 		})
 	}
 }
+
+func Test_generateKeyPairEd25519(t *testing.T) {
+	pair, err := generateKeyPair(Options{PrivateKeyAlgorithm: Ed25519PrivateKeyAlgorithm})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !strings.HasPrefix(string(pair.PublicKey), ssh.KeyAlgoED25519+" ") {
+		t.Errorf("expected an ed25519 public key, got %s", pair.PublicKey)
+	}
+	algorithm, err := keyPairAlgorithm(pair)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if algorithm != Ed25519PrivateKeyAlgorithm {
+		t.Errorf("keyPairAlgorithm() = %s, want %s", algorithm, Ed25519PrivateKeyAlgorithm)
+	}
+}
+
+func Test_checkKeyAlgorithm(t *testing.T) {
+	tests := []struct {
+		host      string
+		algorithm PrivateKeyAlgorithm
+		wantErr   bool
+	}{
+		{host: "github.com", algorithm: Ed25519PrivateKeyAlgorithm},
+		{host: "gitlab.com:22", algorithm: ECDSAPrivateKeyAlgorithm},
+		{host: "ssh.dev.azure.com", algorithm: RSAPrivateKeyAlgorithm},
+		{host: "ssh.dev.azure.com", algorithm: Ed25519PrivateKeyAlgorithm, wantErr: true},
+		{host: "org.vs-ssh.visualstudio.com:22", algorithm: ECDSAPrivateKeyAlgorithm, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.host+"/"+string(tt.algorithm), func(t *testing.T) {
+			if err := checkKeyAlgorithm(tt.host, tt.algorithm); (err != nil) != tt.wantErr {
+				t.Errorf("checkKeyAlgorithm() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// The check happens before the host key is scanned
+	_, err := Generate(Options{SSHHostname: "ssh.dev.azure.com", PrivateKeyAlgorithm: Ed25519PrivateKeyAlgorithm})
+	if err == nil || !strings.Contains(err.Error(), "only accepts RSA keys") {
+		t.Errorf("expected RSA only error, got %v", err)
+	}
+}
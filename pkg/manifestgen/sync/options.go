@@ -33,8 +33,41 @@ type Options struct {
 	TargetPath        string
 	ManifestFile      string
 	RecurseSubmodules bool
+
+	// Provider is the provider used by source-controller to authenticate
+	// to the Git server, the secret is not referenced when it is set to a
+	// workload identity provider. For GitHubProvider the secret holds the
+	// GitHub App credentials.
+	Provider string
+
+	// SourceKind is the kind of the source the Kustomization syncs from,
+	// either GitRepository or OCIRepository. Defaults to GitRepository.
+	SourceKind string
+
+	// DecryptionSecret enables the SOPS decryption of the Kustomization
+	// with the keys found in the secret.
+	DecryptionSecret string
+
+	// ReceiverType generates a notification-controller Receiver of this
+	// type, e.g. github or gitlab, which triggers the reconciliation of the
+	// GitRepository on the push events of the repository. The webhook
+	// token is read from ReceiverSecret.
+	ReceiverType   string
+	ReceiverSecret string
 }
 
+// GitHubProvider authenticates to GitHub with the installation tokens of
+// the GitHub App found in the secret.
+const GitHubProvider = "github"
+
+// GitRepositoryProviderVersion is the GitRepository API version defining
+// spec.provider, the GitRepository is generated with it when a provider is set.
+const GitRepositoryProviderVersion = "v1"
+
+// DecryptionProviderSOPS is the decryption provider of the Kustomization
+// set when Options.DecryptionSecret is given.
+const DecryptionProviderSOPS = "sops"
+
 func MakeDefaultOptions() Options {
 	return Options{
 		Interval:     1 * time.Minute,
@@ -27,6 +27,7 @@ import (
 	"sigs.k8s.io/yaml"
 
 	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	notificationv1 "github.com/fluxcd/notification-controller/api/v1beta2"
 	"github.com/fluxcd/pkg/apis/meta"
 	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
 
@@ -34,6 +35,122 @@ import (
 )
 
 func Generate(options Options) (*manifestgen.Manifest, error) {
+	sourceKind := sourcev1.GitRepositoryKind
+	if options.SourceKind != "" {
+		sourceKind = options.SourceKind
+	}
+
+	var sourceData []byte
+	var err error
+	switch sourceKind {
+	case sourcev1.GitRepositoryKind:
+		sourceData, err = generateGitRepository(options)
+	case sourcev1.OCIRepositoryKind:
+		sourceData, err = generateOCIRepository(options)
+	default:
+		return nil, fmt.Errorf("unsupported source kind '%s'", sourceKind)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	gvk := kustomizev1.GroupVersion.WithKind(kustomizev1.KustomizationKind)
+	kustomization := kustomizev1.Kustomization{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       gvk.Kind,
+			APIVersion: gvk.GroupVersion().String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      options.Name,
+			Namespace: options.Namespace,
+		},
+		Spec: kustomizev1.KustomizationSpec{
+			Interval: metav1.Duration{
+				Duration: 10 * time.Minute,
+			},
+			Path:  fmt.Sprintf("./%s", strings.TrimPrefix(options.TargetPath, "./")),
+			Prune: true,
+			SourceRef: kustomizev1.CrossNamespaceSourceReference{
+				Kind: sourceKind,
+				Name: options.Name,
+			},
+		},
+	}
+
+	if options.DecryptionSecret != "" {
+		kustomization.Spec.Decryption = &kustomizev1.Decryption{
+			Provider:  DecryptionProviderSOPS,
+			SecretRef: &meta.LocalObjectReference{Name: options.DecryptionSecret},
+		}
+	}
+
+	ksData, err := yaml.Marshal(kustomization)
+	if err != nil {
+		return nil, err
+	}
+
+	content := fmt.Sprintf("%s\n---\n%s---\n%s", manifestgen.GenWarning, resourceToString(sourceData), resourceToString(ksData))
+	if options.ReceiverType != "" {
+		if sourceKind != sourcev1.GitRepositoryKind {
+			return nil, fmt.Errorf("a receiver can't be generated for the source kind '%s'", sourceKind)
+		}
+		receiverData, err := generateReceiver(options)
+		if err != nil {
+			return nil, err
+		}
+		content = fmt.Sprintf("%s---\n%s", content, resourceToString(receiverData))
+	}
+
+	return &manifestgen.Manifest{
+		Path:    path.Join(options.TargetPath, options.Namespace, options.ManifestFile),
+		Content: content,
+	}, nil
+}
+
+// generateReceiver returns the Receiver reconciling the GitRepository on the
+// push events sent by the Git server.
+func generateReceiver(options Options) ([]byte, error) {
+	var events []string
+	switch options.ReceiverType {
+	case notificationv1.GitHubReceiver:
+		events = []string{"ping", "push"}
+	case notificationv1.GitLabReceiver:
+		events = []string{"Push Hook", "Tag Push Hook"}
+	default:
+		return nil, fmt.Errorf("unsupported receiver type '%s'", options.ReceiverType)
+	}
+
+	gvk := notificationv1.GroupVersion.WithKind(notificationv1.ReceiverKind)
+	receiver := notificationv1.Receiver{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       gvk.Kind,
+			APIVersion: gvk.GroupVersion().String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      options.Name,
+			Namespace: options.Namespace,
+		},
+		Spec: notificationv1.ReceiverSpec{
+			Type:   options.ReceiverType,
+			Events: events,
+			Resources: []notificationv1.CrossNamespaceObjectReference{
+				{
+					Kind: sourcev1.GitRepositoryKind,
+					Name: options.Name,
+				},
+			},
+			SecretRef: meta.LocalObjectReference{
+				Name: options.ReceiverSecret,
+			},
+		},
+	}
+	return yaml.Marshal(receiver)
+}
+
+// generateGitRepository returns the GitRepository cloning the reference
+// from the Git server, the secret is not referenced when a provider is set.
+// When a provider is set, the GitRepository uses GitRepositoryProviderVersion.
+func generateGitRepository(options Options) ([]byte, error) {
 	gvk := sourcev1.GroupVersion.WithKind(sourcev1.GitRepositoryKind)
 	gitRef := &sourcev1.GitRepositoryRef{}
 	if options.Branch != "" {
@@ -63,21 +180,32 @@ func Generate(options Options) (*manifestgen.Manifest, error) {
 			Interval: metav1.Duration{
 				Duration: options.Interval,
 			},
-			Reference: gitRef,
-			SecretRef: &meta.LocalObjectReference{
-				Name: options.Secret,
-			},
+			Reference:         gitRef,
 			RecurseSubmodules: options.RecurseSubmodules,
 		},
 	}
+	if options.Provider == "" || options.Provider == GitHubProvider {
+		gitRepository.Spec.SecretRef = &meta.LocalObjectReference{
+			Name: options.Secret,
+		}
+	}
 
 	gitData, err := yaml.Marshal(gitRepository)
 	if err != nil {
 		return nil, err
 	}
+	if options.Provider != "" {
+		return setProvider(gitData, options.Provider)
+	}
+	return gitData, nil
+}
 
-	gvk = kustomizev1.GroupVersion.WithKind(kustomizev1.KustomizationKind)
-	kustomization := kustomizev1.Kustomization{
+// generateOCIRepository returns the OCIRepository pulling the artifact tag
+// from the registry, the secret is not referenced when a provider other than
+// generic is set.
+func generateOCIRepository(options Options) ([]byte, error) {
+	gvk := sourcev1.GroupVersion.WithKind(sourcev1.OCIRepositoryKind)
+	ociRepository := sourcev1.OCIRepository{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       gvk.Kind,
 			APIVersion: gvk.GroupVersion().String(),
@@ -86,28 +214,42 @@ func Generate(options Options) (*manifestgen.Manifest, error) {
 			Name:      options.Name,
 			Namespace: options.Namespace,
 		},
-		Spec: kustomizev1.KustomizationSpec{
+		Spec: sourcev1.OCIRepositorySpec{
+			URL: options.URL,
 			Interval: metav1.Duration{
-				Duration: 10 * time.Minute,
+				Duration: options.Interval,
 			},
-			Path:  fmt.Sprintf("./%s", strings.TrimPrefix(options.TargetPath, "./")),
-			Prune: true,
-			SourceRef: kustomizev1.CrossNamespaceSourceReference{
-				Kind: sourcev1.GitRepositoryKind,
-				Name: options.Name,
+			Reference: &sourcev1.OCIRepositoryRef{
+				Tag:    options.Tag,
+				SemVer: options.SemVer,
 			},
 		},
 	}
+	if options.Provider != "" && options.Provider != sourcev1.GenericOCIProvider {
+		ociRepository.Spec.Provider = options.Provider
+	} else if options.Secret != "" {
+		ociRepository.Spec.SecretRef = &meta.LocalObjectReference{
+			Name: options.Secret,
+		}
+	}
+	return yaml.Marshal(ociRepository)
+}
 
-	ksData, err := yaml.Marshal(kustomization)
-	if err != nil {
+// setProvider sets spec.provider on the marshalled GitRepository and moves
+// it to GitRepositoryProviderVersion, as the field is not part of the API
+// version the manifests are generated with.
+func setProvider(data []byte, provider string) ([]byte, error) {
+	var obj map[string]interface{}
+	if err := yaml.Unmarshal(data, &obj); err != nil {
 		return nil, err
 	}
-
-	return &manifestgen.Manifest{
-		Path:    path.Join(options.TargetPath, options.Namespace, options.ManifestFile),
-		Content: fmt.Sprintf("%s\n---\n%s---\n%s", manifestgen.GenWarning, resourceToString(gitData), resourceToString(ksData)),
-	}, nil
+	obj["apiVersion"] = sourcev1.GroupVersion.Group + "/" + GitRepositoryProviderVersion
+	spec, ok := obj["spec"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("failed to set provider: spec not found")
+	}
+	spec["provider"] = provider
+	return yaml.Marshal(obj)
 }
 
 func resourceToString(data []byte) string {
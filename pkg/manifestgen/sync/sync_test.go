@@ -43,3 +43,118 @@ func TestGenerate(t *testing.T) {
 
 	fmt.Println(output.Content)
 }
+
+func TestGenerateWithProvider(t *testing.T) {
+	opts := MakeDefaultOptions()
+	opts.URL = "https://dev.azure.com/org/project/_git/fleet"
+	opts.Provider = "azure"
+	output, err := Generate(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"apiVersion: source.toolkit.fluxcd.io/v1\nkind: GitRepository\n", "  provider: azure\n"} {
+		if !strings.Contains(output.Content, want) {
+			t.Errorf("%q not found in:\n%s", want, output.Content)
+		}
+	}
+	if strings.Contains(output.Content, "secretRef") {
+		t.Errorf("secretRef should be omitted when a provider is set:\n%s", output.Content)
+	}
+	if strings.Contains(output.Content, "creationTimestamp") {
+		t.Errorf("unexpected creationTimestamp in:\n%s", output.Content)
+	}
+}
+
+func TestGenerateWithGitHubProvider(t *testing.T) {
+	opts := MakeDefaultOptions()
+	opts.URL = "https://github.com/org/fleet"
+	opts.Provider = GitHubProvider
+	output, err := Generate(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"  provider: github\n", "  secretRef:\n    name: flux-system\n"} {
+		if !strings.Contains(output.Content, want) {
+			t.Errorf("%q not found in:\n%s", want, output.Content)
+		}
+	}
+}
+
+func TestGenerateOCIRepository(t *testing.T) {
+	opts := MakeDefaultOptions()
+	opts.SourceKind = sourcev1.OCIRepositoryKind
+	opts.URL = "oci://ghcr.io/org/fleet"
+	opts.Tag = "latest"
+	output, err := Generate(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"kind: OCIRepository\n",
+		"  url: oci://ghcr.io/org/fleet\n",
+		"    tag: latest\n",
+		"  secretRef:\n    name: flux-system\n",
+		"    kind: OCIRepository\n",
+	} {
+		if !strings.Contains(output.Content, want) {
+			t.Errorf("%q not found in:\n%s", want, output.Content)
+		}
+	}
+	if strings.Contains(output.Content, "branch") {
+		t.Errorf("unexpected branch in:\n%s", output.Content)
+	}
+
+	opts.Provider = "aws"
+	output, err = Generate(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(output.Content, "  provider: aws\n") || strings.Contains(output.Content, "secretRef") {
+		t.Errorf("expected provider without secretRef in:\n%s", output.Content)
+	}
+}
+
+func TestGenerateWithDecryption(t *testing.T) {
+	opts := MakeDefaultOptions()
+	opts.URL = "ssh://git@github.com/org/fleet"
+	opts.DecryptionSecret = "sops-age"
+	output, err := Generate(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "  decryption:\n    provider: sops\n    secretRef:\n      name: sops-age\n"
+	if !strings.Contains(output.Content, want) {
+		t.Errorf("%q not found in:\n%s", want, output.Content)
+	}
+}
+
+func TestGenerateWithReceiver(t *testing.T) {
+	opts := MakeDefaultOptions()
+	opts.URL = "ssh://git@github.com/org/fleet"
+	opts.ReceiverType = "github"
+	opts.ReceiverSecret = "flux-system-webhook-token"
+	output, err := Generate(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"kind: Receiver\n",
+		"  events:\n  - ping\n  - push\n",
+		"  resources:\n  - kind: GitRepository\n    name: flux-system\n",
+		"  secretRef:\n    name: flux-system-webhook-token\n",
+	} {
+		if !strings.Contains(output.Content, want) {
+			t.Errorf("%q not found in:\n%s", want, output.Content)
+		}
+	}
+
+	opts.SourceKind = sourcev1.OCIRepositoryKind
+	if _, err := Generate(opts); err == nil {
+		t.Error("expected an error for a receiver of an OCIRepository")
+	}
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/polling/engine"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/polling/statusreaders"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/status"
+)
+
+// gatewayAPIGroup is the API group of the Gateway API kinds, which report
+// their status with the Accepted and Programmed conditions instead of Ready.
+const gatewayAPIGroup = "gateway.networking.k8s.io"
+
+var gatewayRouteKinds = map[string]bool{
+	"HTTPRoute": true,
+	"GRPCRoute": true,
+	"TLSRoute":  true,
+	"TCPRoute":  true,
+	"UDPRoute":  true,
+}
+
+// gatewayStatusReader computes the status of the Gateway API kinds, the
+// other kinds are left to the kstatus readers.
+type gatewayStatusReader struct {
+	engine.StatusReader
+}
+
+func newGatewayStatusReader(mapper meta.RESTMapper) engine.StatusReader {
+	return &gatewayStatusReader{
+		StatusReader: statusreaders.NewGenericStatusReader(mapper, gatewayStatus),
+	}
+}
+
+func (r *gatewayStatusReader) Supports(gk schema.GroupKind) bool {
+	if gk.Group != gatewayAPIGroup {
+		return false
+	}
+	return gk.Kind == "Gateway" || gk.Kind == "GatewayClass" || gatewayRouteKinds[gk.Kind]
+}
+
+// gatewayStatus returns the status of a Gateway API object:
+//   - a GatewayClass is current when Accepted
+//   - a Gateway is current when Accepted and Programmed
+//   - a route is current when Accepted by all its parents
+//
+// The conditions must have been observed for the current generation.
+func gatewayStatus(u *unstructured.Unstructured) (*status.Result, error) {
+	generation := u.GetGeneration()
+	switch kind := u.GetKind(); {
+	case kind == "GatewayClass":
+		conditions, _, err := unstructured.NestedSlice(u.Object, "status", "conditions")
+		if err != nil {
+			return nil, err
+		}
+		return gatewayConditionsStatus(conditions, generation, "Accepted"), nil
+	case kind == "Gateway":
+		conditions, _, err := unstructured.NestedSlice(u.Object, "status", "conditions")
+		if err != nil {
+			return nil, err
+		}
+		return gatewayConditionsStatus(conditions, generation, "Accepted", "Programmed"), nil
+	case gatewayRouteKinds[kind]:
+		parents, _, err := unstructured.NestedSlice(u.Object, "status", "parents")
+		if err != nil {
+			return nil, err
+		}
+		if len(parents) == 0 {
+			return &status.Result{Status: status.InProgressStatus, Message: "Route not accepted by any parent"}, nil
+		}
+		for _, p := range parents {
+			parent, ok := p.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			conditions, _, err := unstructured.NestedSlice(parent, "conditions")
+			if err != nil {
+				return nil, err
+			}
+			if res := gatewayConditionsStatus(conditions, generation, "Accepted", "ResolvedRefs"); res.Status != status.CurrentStatus {
+				return res, nil
+			}
+		}
+		return &status.Result{Status: status.CurrentStatus, Message: "Route accepted"}, nil
+	default:
+		return status.Compute(u)
+	}
+}
+
+// gatewayConditionsStatus returns the current status when the condition
+// types are all true for the generation, the object is in progress
+// otherwise. A false condition doesn't fail the object, Gateways report
+// Programmed false while their infrastructure is provisioned.
+func gatewayConditionsStatus(conditions []interface{}, generation int64, types ...string) *status.Result {
+	for _, t := range types {
+		var found bool
+		for _, c := range conditions {
+			cond, ok := c.(map[string]interface{})
+			if !ok || cond["type"] != t {
+				continue
+			}
+			found = true
+			observed, _, _ := unstructured.NestedInt64(cond, "observedGeneration")
+			message, _, _ := unstructured.NestedString(cond, "message")
+			switch {
+			case observed != 0 && observed < generation:
+				return &status.Result{Status: status.InProgressStatus, Message: fmt.Sprintf("%s condition not observed for generation %d", t, generation)}
+			case cond["status"] != "True":
+				return &status.Result{Status: status.InProgressStatus, Message: fmt.Sprintf("%s: %s", t, message)}
+			}
+		}
+		if !found {
+			return &status.Result{Status: status.InProgressStatus, Message: fmt.Sprintf("%s condition not reported", t)}
+		}
+	}
+	return &status.Result{Status: status.CurrentStatus, Message: "Resource is current"}
+}
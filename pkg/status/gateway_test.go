@@ -0,0 +1,172 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/status"
+	"sigs.k8s.io/cli-utils/pkg/object"
+	"sigs.k8s.io/yaml"
+)
+
+func TestGatewayStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		want     status.Status
+	}{
+		{
+			name: "programmed gateway",
+			manifest: `
+apiVersion: gateway.networking.k8s.io/v1beta1
+kind: Gateway
+metadata:
+  generation: 2
+status:
+  conditions:
+  - type: Accepted
+    status: "True"
+    observedGeneration: 2
+  - type: Programmed
+    status: "True"
+    observedGeneration: 2
+`,
+			want: status.CurrentStatus,
+		},
+		{
+			name: "gateway pending",
+			manifest: `
+apiVersion: gateway.networking.k8s.io/v1beta1
+kind: Gateway
+metadata:
+  generation: 1
+status:
+  conditions:
+  - type: Accepted
+    status: "True"
+    observedGeneration: 1
+  - type: Programmed
+    status: "False"
+    reason: Pending
+    observedGeneration: 1
+`,
+			want: status.InProgressStatus,
+		},
+		{
+			name: "stale gateway conditions",
+			manifest: `
+apiVersion: gateway.networking.k8s.io/v1beta1
+kind: Gateway
+metadata:
+  generation: 3
+status:
+  conditions:
+  - type: Accepted
+    status: "True"
+    observedGeneration: 2
+  - type: Programmed
+    status: "True"
+    observedGeneration: 2
+`,
+			want: status.InProgressStatus,
+		},
+		{
+			name: "accepted route",
+			manifest: `
+apiVersion: gateway.networking.k8s.io/v1beta1
+kind: HTTPRoute
+metadata:
+  generation: 1
+status:
+  parents:
+  - conditions:
+    - type: Accepted
+      status: "True"
+    - type: ResolvedRefs
+      status: "True"
+`,
+			want: status.CurrentStatus,
+		},
+		{
+			name: "route without parents",
+			manifest: `
+apiVersion: gateway.networking.k8s.io/v1beta1
+kind: HTTPRoute
+metadata:
+  generation: 1
+`,
+			want: status.InProgressStatus,
+		},
+		{
+			name: "route with unresolved refs",
+			manifest: `
+apiVersion: gateway.networking.k8s.io/v1beta1
+kind: HTTPRoute
+metadata:
+  generation: 1
+status:
+  parents:
+  - conditions:
+    - type: Accepted
+      status: "True"
+    - type: ResolvedRefs
+      status: "False"
+      message: backend not found
+`,
+			want: status.InProgressStatus,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := yaml.YAMLToJSON([]byte(tt.manifest))
+			if err != nil {
+				t.Fatal(err)
+			}
+			u := &unstructured.Unstructured{}
+			if err := u.UnmarshalJSON(data); err != nil {
+				t.Fatal(err)
+			}
+			res, err := gatewayStatus(u)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Status != tt.want {
+				t.Errorf("gatewayStatus() = %s (%s), want %s", res.Status, res.Message, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnknownKindsError(t *testing.T) {
+	if err := unknownKindsError(nil); err != nil {
+		t.Errorf("unknownKindsError() = %v, want nil", err)
+	}
+
+	route := schema.GroupKind{Group: "gateway.networking.k8s.io", Kind: "HTTPRoute"}
+	err := unknownKindsError([]object.ObjMetadata{
+		{GroupKind: route, Name: "a"},
+		{GroupKind: route, Name: "b"},
+		{GroupKind: schema.GroupKind{Group: "example.com", Kind: "Widget"}, Name: "c"},
+	})
+	want := "no matches for kinds HTTPRoute.gateway.networking.k8s.io, Widget.example.com, the CRDs are not installed on the cluster"
+	if err == nil || err.Error() != want {
+		t.Errorf("unknownKindsError() = %v, want %s", err, want)
+	}
+}
@@ -18,15 +18,19 @@ package status
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
 	"time"
 
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/rest"
 	"sigs.k8s.io/cli-utils/pkg/kstatus/polling"
 	"sigs.k8s.io/cli-utils/pkg/kstatus/polling/aggregator"
 	"sigs.k8s.io/cli-utils/pkg/kstatus/polling/collector"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/polling/engine"
 	"sigs.k8s.io/cli-utils/pkg/kstatus/polling/event"
 	"sigs.k8s.io/cli-utils/pkg/kstatus/status"
 	"sigs.k8s.io/cli-utils/pkg/object"
@@ -40,10 +44,15 @@ type StatusChecker struct {
 	pollInterval time.Duration
 	timeout      time.Duration
 	client       client.Client
+	restMapper   meta.RESTMapper
 	statusPoller *polling.StatusPoller
 	logger       log.Logger
 }
 
+// NewStatusChecker returns a StatusChecker assessing the status of the
+// objects with kstatus. The custom kinds are assessed with the kstatus
+// conventions, the Gateway API kinds with their Accepted and Programmed
+// conditions.
 func NewStatusChecker(kubeConfig *rest.Config, pollInterval time.Duration, timeout time.Duration, log log.Logger) (*StatusChecker, error) {
 	restMapper, err := apiutil.NewDynamicRESTMapper(kubeConfig)
 	if err != nil {
@@ -54,16 +63,33 @@ func NewStatusChecker(kubeConfig *rest.Config, pollInterval time.Duration, timeo
 		return nil, err
 	}
 
+	poller := polling.NewStatusPoller(c, restMapper, polling.Options{
+		CustomStatusReaders: []engine.StatusReader{newGatewayStatusReader(restMapper)},
+	})
+
 	return &StatusChecker{
 		pollInterval: pollInterval,
 		timeout:      timeout,
 		client:       c,
-		statusPoller: polling.NewStatusPoller(c, restMapper, polling.Options{}),
+		restMapper:   restMapper,
+		statusPoller: poller,
 		logger:       log,
 	}, nil
 }
 
+// Assess waits for the objects to be current. The objects of a kind missing
+// from the cluster, e.g. when its CRD is not installed, are reported without
+// waiting for them.
 func (sc *StatusChecker) Assess(identifiers ...object.ObjMetadata) error {
+	identifiers, unknownKinds := sc.splitUnknownKinds(identifiers)
+	for _, id := range unknownKinds {
+		sc.logger.Failuref("%s: %s kind not found in group %s, the CRD is not installed", id.Name, strings.ToLower(id.GroupKind.Kind), id.GroupKind.Group)
+	}
+	unknownErr := unknownKindsError(unknownKinds)
+	if len(identifiers) == 0 {
+		return unknownErr
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), sc.timeout)
 	defer cancel()
 
@@ -93,9 +119,41 @@ func (sc *StatusChecker) Assess(identifiers ...object.ObjMetadata) error {
 	}
 
 	if coll.Error != nil || ctx.Err() == context.DeadlineExceeded {
-		return fmt.Errorf("timed out waiting for condition")
+		return errors.Join(unknownErr, fmt.Errorf("timed out waiting for condition"))
+	}
+	return unknownErr
+}
+
+// splitUnknownKinds splits the identifiers in those of a kind known to the
+// RESTMapper, and those of a kind missing from the cluster.
+func (sc *StatusChecker) splitUnknownKinds(identifiers []object.ObjMetadata) ([]object.ObjMetadata, []object.ObjMetadata) {
+	var known, unknown []object.ObjMetadata
+	for _, id := range identifiers {
+		if _, err := sc.restMapper.RESTMapping(id.GroupKind); meta.IsNoMatchError(err) {
+			unknown = append(unknown, id)
+			continue
+		}
+		known = append(known, id)
+	}
+	return known, unknown
+}
+
+// unknownKindsError returns an error naming the kinds missing from the
+// cluster, or nil if there are none.
+func unknownKindsError(identifiers []object.ObjMetadata) error {
+	var kinds []string
+	seen := map[schema.GroupKind]bool{}
+	for _, id := range identifiers {
+		if !seen[id.GroupKind] {
+			seen[id.GroupKind] = true
+			kinds = append(kinds, id.GroupKind.String())
+		}
+	}
+	if len(kinds) == 0 {
+		return nil
 	}
-	return nil
+	sort.Strings(kinds)
+	return fmt.Errorf("no matches for kinds %s, the CRDs are not installed on the cluster", strings.Join(kinds, ", "))
 }
 
 // desiredStatusNotifierFunc returns an Observer function for the
@@ -108,6 +108,24 @@ func Components(ctx context.Context, logger log.Logger, kubeClient client.Client
 			}
 		}
 	}
+	{
+		// The role bindings of an instance scoped with watch namespaces
+		var list rbacv1.RoleBindingList
+		instanceSelector := client.MatchingLabels{
+			manifestgen.PartOfLabelKey:   manifestgen.PartOfLabelValue,
+			manifestgen.InstanceLabelKey: namespace,
+		}
+		if err := kubeClient.List(ctx, &list, instanceSelector); err == nil {
+			for _, r := range list.Items {
+				if err := kubeClient.Delete(ctx, &r, opts); err != nil {
+					logger.Failuref("RoleBinding/%s/%s deletion failed: %s", r.Namespace, r.Name, err.Error())
+					aggregateErr = append(aggregateErr, err)
+				} else {
+					logger.Successf("RoleBinding/%s/%s deleted %s", r.Namespace, r.Name, dryRunStr)
+				}
+			}
+		}
+	}
 	{
 		var list rbacv1.ClusterRoleBindingList
 		if err := kubeClient.List(ctx, &list, selector); err == nil {